@@ -40,6 +40,30 @@ func (h *AnalysisHandler) AnalyzeCode(c *gin.Context) {
 	syntaxResult := syntaxAnalyzer.Analyze()
 	
 	semanticAnalyzer := service.NewSemanticAnalyzer(lexicalResult.Tokens, syntaxResult.AST)
+	config := service.RuleConfig{
+		Enable:     request.EnableRules,
+		Disable:    request.DisableRules,
+		Severities: request.SeverityOverrides,
+	}
+	if request.Exercise != "" {
+		exercise, found := service.LookupExercise(request.Exercise)
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Ejercicio '" + request.Exercise + "' no encontrado",
+			})
+			return
+		}
+		config = exercise.WithRules(config)
+		semanticAnalyzer.SetExercise(exercise)
+	}
+	if err := semanticAnalyzer.Configure(config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Configuración de reglas inválida: " + err.Error(),
+		})
+		return
+	}
 	semanticResult := semanticAnalyzer.Analyze()
 	
 	response := models.AnalysisResponse{
@@ -66,6 +90,21 @@ func (h *AnalysisHandler) GetHealth(c *gin.Context) {
 	})
 }
 
+// GetRules lista las reglas semánticas que se pueden habilitar, deshabilitar
+// o cambiar de severidad en POST /analyze
+func (h *AnalysisHandler) GetRules(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"rules": service.RuleInfos(),
+	})
+}
+
+// GetExercises lista los ejercicios disponibles para el campo 'exercise' de POST /analyze
+func (h *AnalysisHandler) GetExercises(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"exercises": service.ExerciseInfos(),
+	})
+}
+
 func (h *AnalysisHandler) GetAnalysisInfo(c *gin.Context) {
 	info := gin.H{
 		"name":        "Analizador Léxico, Sintáctico y Semántico",
@@ -74,20 +113,53 @@ func (h *AnalysisHandler) GetAnalysisInfo(c *gin.Context) {
 			"Análisis léxico con reconocimiento de tokens",
 			"Análisis sintáctico con construcción de AST",
 			"Análisis semántico con tabla de símbolos",
-			"Verificaciones específicas para funciones factorial",
+			"Árbol de scopes (módulo, función, clase, comprensión) con resolución LEGB",
+			"Asignación definida sobre el grafo de flujo de control de cada función",
+			"Detección de código inalcanzable y de caminos sin 'return'",
+			"Exportación del grafo de flujo de control en JSON y Graphviz DOT (/api/v1/cfg)",
+			"Grafo de llamadas con detección de recursión directa y mutua",
+			"Casos base y avance de los argumentos en cada llamada recursiva",
+			"Heurísticas de terminación: recursión sin caso base, casos base inalcanzables y argumentos que no decrecen",
+			"Inferencia de tipos (int, float, str, bool, None, list[T]) y firmas de funciones, con anotaciones como punto de partida",
+			"Motor de reglas semánticas configurable por petición (enableRules, disableRules, severityOverrides; /api/v1/rules)",
+			"Ejercicios declarativos en YAML o JSON con requisitos evaluados sobre el AST (/api/v1/exercises)",
+			"Verificación de argumentos en llamadas (cantidad, nombres repetidos o desconocidos, obligatorios faltantes) para funciones del programa, integradas y de módulos",
+			"Resolución de importaciones contra un catálogo de la biblioteca estándar",
+			"Variables, parámetros, importaciones y funciones sin usar, silenciables con '# noqa'",
+			"Advertencias de nombres que ocultan globales, variables de funciones externas, su propia función o builtins",
+			"Plegado de constantes y evaluación de llamadas a funciones puras con recursión acotada (factorial(5) → 120)",
+			"Detección de divisiones por cero garantizadas y condiciones siempre verdaderas o siempre falsas",
+			"Advertencias numéricas: factoriales calculados en float, '/' donde se esperaba '//' y resultados que no caben en 64 bits para las entradas literales",
+			"Métricas por función: complejidad ciclomática, anidamiento, sentencias, clase de recursión, Halstead y líneas de código y comentarios",
+			"Sugerencias de optimización de la recursión con el código antes y después: acumulador para llamadas que no son de cola, functools.lru_cache para subproblemas solapados y bucle while para la recursión de cola",
+			"Ejecución en un intérprete aislado con enteros de precisión arbitraria y límites de pasos, profundidad, memoria, tiempo y salida (/api/v1/run)",
+			"Corrección automática con casos de prueba: aserciones, valores esperados, salida esperada con diff, pasos y tiempo por caso (/api/v1/grade)",
+			"Registro de llamadas de funciones recursivas con argumentos y valores de retorno: árbol JSON, Graphviz, Mermaid y línea de tiempo de la pila (/api/v1/trace)",
+			"Depurador paso a paso por WebSocket: pausa al inicio, step into/over/out, puntos de interrupción, variables por frame y expresiones vigiladas (/api/v1/debug)",
+			"Compilación a bytecode de pila (LOAD_FAST, BINARY_OP, CALL...) con desensamblador al estilo dis y ejecución en una máquina virtual con los mismos límites (/api/v1/disassemble; engine=vm en /run y /grade)",
+			"Explorador del compilador: tokens, CST, AST, tabla de símbolos, CFG y bytecode lado a lado, con la posición de cada elemento en el código (/api/v1/compile)",
+			"Traducción a Go con math/big y a JavaScript con BigInt y source map, con informe de las construcciones sin equivalente (/api/v1/transpile?target=go|js)",
 		},
 		"supported_constructs": []string{
 			"Definición de funciones",
-			"Estructuras condicionales (if-else)",
+			"Estructuras condicionales (if-elif-else)",
+			"Bucles while y for",
+			"Manejo de excepciones (try, except, else, finally, raise)",
+			"Clases, lambdas y comprensiones de listas",
+			"Declaraciones global y nonlocal",
 			"Llamadas recursivas",
 			"Expresiones aritméticas",
 			"Asignación de variables",
 			"Llamadas a funciones",
+			"Importaciones (import, from ... import, as)",
+			"Acceso a atributos de módulos",
+			"Anotaciones de tipo en parámetros, retornos y variables",
 		},
 		"token_types": []string{
 			"KEYWORD", "IDENTIFIER", "NUMBER", "STRING",
 			"OPERATOR", "SYMBOL", "COMMENT", "NEWLINE", "ERROR",
 		},
+		"supported_modules": service.StdlibModuleNames(),
 	}
 	
 	c.JSON(http.StatusOK, info)
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"examen-back/models"
+	"examen-back/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DisassembleCode compila el programa a bytecode y devuelve el código del
+// módulo y de cada función con sus constantes, nombres e instrucciones, y
+// las construcciones que la máquina de pila delega al intérprete de árbol.
+// Con function=nombre se filtra una función y con format=text la respuesta
+// es el listado al estilo de dis.dis.
+func (h *AnalysisHandler) DisassembleCode(c *gin.Context) {
+	var request models.DisassembleRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "JSON inválido: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "El código no puede estar vacío",
+		})
+		return
+	}
+
+	lexicalAnalyzer := service.NewLexicalAnalyzer(request.Code)
+	lexicalResult := lexicalAnalyzer.Tokenize()
+
+	syntaxAnalyzer := service.NewSyntaxAnalyzer(lexicalResult.Tokens)
+	syntaxResult := syntaxAnalyzer.Analyze()
+
+	if !syntaxResult.Valid {
+		c.JSON(http.StatusOK, models.DisassembleResponse{
+			Code:         []models.CodeObject{},
+			SyntaxErrors: syntaxResult.Errors,
+			Success:      false,
+			Message:      "El código tiene errores de sintaxis y no se compiló",
+		})
+		return
+	}
+
+	codes := service.CompileProgram(syntaxResult.AST).Disassemble()
+
+	if name := c.Query("function"); name != "" {
+		selected := make([]models.CodeObject, 0, 1)
+		for _, code := range codes {
+			if code.Name == name {
+				selected = append(selected, code)
+			}
+		}
+		if len(selected) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "No se encontró la función '" + name + "'",
+			})
+			return
+		}
+		codes = selected
+	}
+
+	if c.Query("format") == "text" {
+		listings := make([]string, 0, len(codes))
+		for _, code := range codes {
+			listings = append(listings, code.Text)
+		}
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(strings.Join(listings, "\n")))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DisassembleResponse{
+		Code:         codes,
+		SyntaxErrors: syntaxResult.Errors,
+		Success:      true,
+		Message:      "Código compilado exitosamente",
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"examen-back/models"
+	"examen-back/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TraceCalls ejecuta el programa registrando cada llamada a las funciones
+// recursivas (o a las indicadas en "functions") con sus argumentos y su valor
+// de retorno. Devuelve el árbol de llamadas, la línea de tiempo de la
+// profundidad de la pila y el árbol como texto, Graphviz y Mermaid. Con
+// format=dot, format=mermaid o format=text la respuesta es solo ese diagrama.
+func (h *AnalysisHandler) TraceCalls(c *gin.Context) {
+	var request models.CallTraceRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "JSON inválido: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "El código no puede estar vacío",
+		})
+		return
+	}
+
+	lexicalAnalyzer := service.NewLexicalAnalyzer(request.Code)
+	lexicalResult := lexicalAnalyzer.Tokenize()
+
+	syntaxAnalyzer := service.NewSyntaxAnalyzer(lexicalResult.Tokens)
+	syntaxResult := syntaxAnalyzer.Analyze()
+
+	if !syntaxResult.Valid {
+		c.JSON(http.StatusOK, models.CallTraceResponse{
+			SyntaxErrors: syntaxResult.Errors,
+			Success:      false,
+			Message:      "El código tiene errores de sintaxis y no se ejecutó",
+		})
+		return
+	}
+
+	var call *models.ASTNode
+	if request.Call != "" {
+		parsed, err := service.ParseRunCall(request.Call)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Llamada inválida: " + err.Error(),
+			})
+			return
+		}
+		call = parsed
+	}
+
+	interpreter := service.NewInterpreter(syntaxResult.AST, service.NormalizeRunLimits(request.Limits))
+	result, trace, err := interpreter.Trace(request.Stdin, call, request.Functions, request.MaxCalls)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "No se pudo registrar las llamadas: " + err.Error(),
+		})
+		return
+	}
+
+	switch c.Query("format") {
+	case "dot":
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(trace.Dot))
+		return
+	case "mermaid":
+		c.Data(http.StatusOK, "text/vnd.mermaid; charset=utf-8", []byte(trace.Mermaid))
+		return
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(trace.Text))
+		return
+	}
+
+	response := models.CallTraceResponse{
+		RunResult:    result,
+		Trace:        trace,
+		SyntaxErrors: syntaxResult.Errors,
+		Success:      true,
+		Message:      "Llamadas registradas exitosamente",
+	}
+
+	if result.Error != nil {
+		response.Success = false
+		response.Message = "La ejecución terminó con " + result.Error.Type
+		if result.Error.Limit {
+			response.Message = "La ejecución se detuvo por un límite del sandbox: " + result.Error.Type
+		}
+	}
+	if trace.Truncated {
+		response.Message += fmt.Sprintf(" (se registraron %d de %d llamadas)", trace.Recorded, trace.TotalCalls)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"examen-back/models"
+	"examen-back/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCFG devuelve el grafo de flujo de control de cada función. El código se
+// recibe en el cuerpo JSON (POST) o en el parámetro "code" (GET). Con
+// format=dot la respuesta es el texto Graphviz de todas las funciones y con
+// function=nombre se limita a una sola.
+func (h *AnalysisHandler) GetCFG(c *gin.Context) {
+	var request models.AnalysisRequest
+
+	if c.Request.Method == http.MethodGet {
+		request.Code = c.Query("code")
+	} else if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "JSON inválido: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "El código no puede estar vacío",
+		})
+		return
+	}
+
+	lexicalAnalyzer := service.NewLexicalAnalyzer(request.Code)
+	lexicalResult := lexicalAnalyzer.Tokenize()
+
+	syntaxAnalyzer := service.NewSyntaxAnalyzer(lexicalResult.Tokens)
+	syntaxResult := syntaxAnalyzer.Analyze()
+
+	cfgExporter := service.NewCFGExporter(syntaxResult.AST)
+	functions := cfgExporter.Export()
+
+	if name := c.Query("function"); name != "" {
+		selected := make([]models.FunctionCFG, 0, 1)
+		for _, function := range functions {
+			if function.Name == name {
+				selected = append(selected, function)
+			}
+		}
+		if len(selected) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "No se encontró la función '" + name + "'",
+			})
+			return
+		}
+		functions = selected
+	}
+
+	if c.Query("format") == "dot" {
+		graphs := make([]string, 0, len(functions))
+		for _, function := range functions {
+			graphs = append(graphs, function.Dot)
+		}
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(strings.Join(graphs, "\n")))
+		return
+	}
+
+	response := models.CFGResponse{
+		Functions:    functions,
+		SyntaxErrors: syntaxResult.Errors,
+		Success:      true,
+		Message:      "Grafo de flujo de control generado exitosamente",
+	}
+
+	if !syntaxResult.Valid {
+		response.Success = false
+		response.Message = "Grafo generado con errores de sintaxis"
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"examen-back/models"
+	"examen-back/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompileCode devuelve todas las etapas del compilador para el mismo código,
+// una junto a otra: tokens, árbol de sintaxis concreta (CST), AST, tabla de
+// símbolos, grafos de flujo de control y bytecode. Cada elemento lleva su
+// posición en el código (líneas, columnas y desplazamientos) para enlazarlo
+// con el editor. Con errores de sintaxis solo se devuelven los tokens.
+func (h *AnalysisHandler) CompileCode(c *gin.Context) {
+	var request models.CompileRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "JSON inválido: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "El código no puede estar vacío",
+		})
+		return
+	}
+
+	lexicalAnalyzer := service.NewLexicalAnalyzer(request.Code)
+	lexicalResult := lexicalAnalyzer.Tokenize()
+
+	syntaxAnalyzer := service.NewSyntaxAnalyzer(lexicalResult.Tokens)
+	syntaxResult := syntaxAnalyzer.Analyze()
+
+	if !syntaxResult.Valid {
+		response := service.CompilePipeline(lexicalResult.Tokens, lexicalAnalyzer.Ranges(), nil)
+		response.SyntaxErrors = syntaxResult.Errors
+		response.Success = false
+		response.Message = "El código tiene errores de sintaxis; solo se muestra la etapa de tokens"
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	response := service.CompilePipeline(lexicalResult.Tokens, lexicalAnalyzer.Ranges(), syntaxResult.AST)
+	response.SyntaxErrors = syntaxResult.Errors
+	response.Success = true
+	response.Message = "Compilación completada exitosamente"
+	c.JSON(http.StatusOK, response)
+}
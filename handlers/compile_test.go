@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"examen-back/models"
+)
+
+// sourceText devuelve el texto del código que cubre un rango
+func sourceText(code string, r models.SourceRange) string {
+	return code[r.StartOffset:r.EndOffset]
+}
+
+func TestCompileCodeReturnsEveryStage(t *testing.T) {
+	const code = "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\nprint(factorial(5))\n"
+	body, _ := json.Marshal(models.CompileRequest{Code: code})
+	var response models.CompileResponse
+	if status := post(t, NewAnalysisHandler().CompileCode, string(body), &response); status != http.StatusOK || !response.Success {
+		t.Fatalf("estado %d: %+v", status, response)
+	}
+	if got := strings.Join(response.Stages, ","); got != "tokens,cst,ast,symbols,cfg,bytecode" {
+		t.Errorf("etapas inesperadas: %s", got)
+	}
+	if response.CST == nil || response.AST == nil || len(response.CFG) != 2 || len(response.Bytecode) != 2 {
+		t.Fatalf("faltan etapas: CST %v, AST %v, %d CFG, %d objetos de código", response.CST != nil, response.AST != nil, len(response.CFG), len(response.Bytecode))
+	}
+
+	for _, token := range response.Tokens {
+		if token.Type == "IDENTIFIER" && sourceText(code, token.Range) != token.Value {
+			t.Errorf("el rango del token %d no cubre '%s': %+v", token.Index, token.Value, token.Range)
+		}
+	}
+	linked := 0
+	for _, symbol := range response.Symbols {
+		if symbol.Name != "factorial" {
+			continue
+		}
+		linked++
+		if symbol.Range == nil || sourceText(code, *symbol.Range) != "factorial" || len(symbol.References) != 2 {
+			t.Errorf("factorial debe enlazar su definición y sus dos usos: %+v", symbol)
+		}
+	}
+	for _, object := range response.Bytecode {
+		if object.Name != "factorial" {
+			continue
+		}
+		linked++
+		if object.Range == nil || !strings.HasPrefix(sourceText(code, *object.Range), "def factorial") {
+			t.Errorf("el bytecode de factorial debe enlazar su definición: %+v", object.Range)
+		}
+	}
+	if linked != 2 {
+		t.Errorf("factorial debe aparecer una vez en los símbolos y otra en el bytecode, aparece %d veces", linked)
+	}
+}
+
+func TestCompileCodeWithSyntaxErrors(t *testing.T) {
+	var response models.CompileResponse
+	if status := post(t, NewAnalysisHandler().CompileCode, `{"code": "def f(:\n    return 1\n"}`, &response); status != http.StatusOK {
+		t.Fatalf("estado %d", status)
+	}
+	if response.Success || len(response.SyntaxErrors) == 0 || len(response.Tokens) == 0 {
+		t.Fatalf("se esperaban los tokens y los errores de sintaxis: %+v", response)
+	}
+	if response.AST != nil || response.CST != nil || len(response.Bytecode) > 0 {
+		t.Errorf("con errores de sintaxis solo se devuelven los tokens: %+v", response)
+	}
+
+	var rejected map[string]any
+	if status := post(t, NewAnalysisHandler().CompileCode, `{"code": ""}`, &rejected); status != http.StatusBadRequest {
+		t.Errorf("el código vacío debe rechazarse, estado %d", status)
+	}
+}
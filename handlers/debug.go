@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"sync"
+
+	"examen-back/models"
+	"examen-back/service"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// maxDebugMessage limita el tamaño de cada mensaje del cliente
+const maxDebugMessage = 1 << 20
+
+// DebugCode abre una sesión de depuración por WebSocket. El primer mensaje
+// es {"command":"start","code":...} con los puntos de interrupción y las
+// expresiones vigiladas iniciales; la ejecución empieza en pausa antes de la
+// primera sentencia. Después el cliente envía comandos (stepInto, stepOver,
+// stepOut, continue, pause, setBreakpoints, setWatches, evaluate, stop) y el
+// servidor responde con eventos (paused, output, breakpoints, evaluation,
+// terminated, error).
+func (h *AnalysisHandler) DebugCode(c *gin.Context) {
+	server := websocket.Server{Handler: debugConnection}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func debugConnection(conn *websocket.Conn) {
+	defer conn.Close()
+	conn.MaxPayloadBytes = maxDebugMessage
+
+	var sending sync.Mutex
+	send := func(event models.DebugEvent) {
+		sending.Lock()
+		defer sending.Unlock()
+		websocket.JSON.Send(conn, event)
+	}
+	fail := func(message string) {
+		send(models.DebugEvent{Event: "error", Message: message})
+	}
+
+	var start models.DebugCommand
+	if err := websocket.JSON.Receive(conn, &start); err != nil {
+		fail("JSON inválido: " + err.Error())
+		return
+	}
+	if start.Command != "start" {
+		fail("El primer comando debe ser 'start' con el código a depurar")
+		return
+	}
+	if start.Code == "" {
+		fail("El código no puede estar vacío")
+		return
+	}
+
+	lexicalAnalyzer := service.NewLexicalAnalyzer(start.Code)
+	lexicalResult := lexicalAnalyzer.Tokenize()
+
+	syntaxAnalyzer := service.NewSyntaxAnalyzer(lexicalResult.Tokens)
+	syntaxResult := syntaxAnalyzer.Analyze()
+
+	if !syntaxResult.Valid {
+		send(models.DebugEvent{
+			Event:        "error",
+			SyntaxErrors: syntaxResult.Errors,
+			Message:      "El código tiene errores de sintaxis y no se ejecutó",
+		})
+		return
+	}
+
+	var call *models.ASTNode
+	if start.Call != "" {
+		parsed, err := service.ParseRunCall(start.Call)
+		if err != nil {
+			fail("Llamada inválida: " + err.Error())
+			return
+		}
+		call = parsed
+	}
+
+	session := service.NewDebugSession(syntaxResult.AST, call, start.Stdin, service.NormalizeRunLimits(start.Limits), send)
+	session.SetBreakpoints(start.Breakpoints)
+	if err := session.SetWatches(start.Watches); err != nil {
+		fail(err.Error())
+		return
+	}
+
+	go session.Run()
+	go func() {
+		// Al terminar la ejecución se cierra la conexión, lo que también
+		// termina el bucle de lectura
+		<-session.Done()
+		conn.Close()
+	}()
+
+	for {
+		var command models.DebugCommand
+		if err := websocket.JSON.Receive(conn, &command); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				fail("JSON inválido: " + err.Error())
+				continue
+			}
+			if _, ok := err.(*json.UnmarshalTypeError); ok {
+				fail("JSON inválido: " + err.Error())
+				continue
+			}
+			break
+		}
+		if err := session.Command(command); err != nil {
+			fail(err.Error())
+		}
+	}
+
+	session.Close()
+	<-session.Done()
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"examen-back/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// debugClient es una conexión de prueba a /api/v1/debug
+type debugClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dialDebug(t *testing.T) *debugClient {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/debug", NewAnalysisHandler().DebugCode)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/debug"
+	conn, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatalf("no se pudo abrir el WebSocket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	// Un evento que no llega no debe colgar la prueba
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	return &debugClient{t: t, conn: conn}
+}
+
+func (c *debugClient) send(command models.DebugCommand) {
+	c.t.Helper()
+	if err := websocket.JSON.Send(c.conn, command); err != nil {
+		c.t.Fatalf("no se pudo enviar '%s': %v", command.Command, err)
+	}
+}
+
+// expect recibe el siguiente evento y comprueba su tipo
+func (c *debugClient) expect(event string) models.DebugEvent {
+	c.t.Helper()
+	var received models.DebugEvent
+	if err := websocket.JSON.Receive(c.conn, &received); err != nil {
+		c.t.Fatalf("se esperaba el evento '%s': %v", event, err)
+	}
+	if received.Event != event {
+		c.t.Fatalf("se recibió %+v y se esperaba el evento '%s'", received, event)
+	}
+	return received
+}
+
+// expectPaused recibe una pausa y comprueba el motivo y la línea
+func (c *debugClient) expectPaused(reason string, line int) models.DebugEvent {
+	c.t.Helper()
+	paused := c.expect("paused")
+	if paused.Reason != reason || paused.Line != line {
+		c.t.Fatalf("pausa por %s en línea %d, se esperaba %s en línea %d", paused.Reason, paused.Line, reason, line)
+	}
+	return paused
+}
+
+// local busca una variable del frame indicado
+func local(event models.DebugEvent, frame int, name string) string {
+	if frame >= len(event.Frames) {
+		return ""
+	}
+	for _, variable := range event.Frames[frame].Locals {
+		if variable.Name == name {
+			return variable.Value
+		}
+	}
+	return ""
+}
+
+func TestDebugSession(t *testing.T) {
+	const code = "def f(n):\n    x = n * 2\n    return x\n\ny = f(3)\nprint(y)\nz = y + 1\nprint(z)\n"
+	c := dialDebug(t)
+
+	c.send(models.DebugCommand{Command: "start", Code: code, Breakpoints: []int{2}, Watches: []string{"y"}})
+	entry := c.expectPaused("entry", 1)
+	if len(entry.Watches) != 1 || !strings.Contains(entry.Watches[0].Error, "NameError") {
+		t.Errorf("la expresión vigilada aún no tiene valor: %+v", entry.Watches)
+	}
+
+	c.send(models.DebugCommand{Command: "continue"})
+	breakpoint := c.expectPaused("breakpoint", 2)
+	if len(breakpoint.Frames) != 2 || breakpoint.Frames[0].Function != "f" || local(breakpoint, 0, "n") != "3" {
+		t.Errorf("pila inesperada en el punto de interrupción: %+v", breakpoint.Frames)
+	}
+
+	c.send(models.DebugCommand{Command: "evaluate", Expression: "n + 10"})
+	evaluation := c.expect("evaluation").Evaluation
+	if evaluation == nil || evaluation.Value != "13" || evaluation.Type != "int" {
+		t.Errorf("evaluación inesperada: %+v", evaluation)
+	}
+
+	c.send(models.DebugCommand{Command: "stepOver"})
+	if step := c.expectPaused("step", 3); local(step, 0, "x") != "6" {
+		t.Errorf("x debería valer 6 después de la línea 2: %+v", step.Frames)
+	}
+
+	c.send(models.DebugCommand{Command: "setBreakpoints", Breakpoints: []int{7}})
+	if breakpoints := c.expect("breakpoints").Breakpoints; len(breakpoints) != 1 || breakpoints[0] != 7 {
+		t.Errorf("puntos de interrupción inesperados: %v", breakpoints)
+	}
+
+	c.send(models.DebugCommand{Command: "continue"})
+	if output := c.expect("output"); output.Stream != "stdout" || output.Text != "6\n" {
+		t.Errorf("salida inesperada: %+v", output)
+	}
+	if paused := c.expectPaused("breakpoint", 7); paused.Watches[0].Value != "6" {
+		t.Errorf("la expresión vigilada debería valer 6: %+v", paused.Watches)
+	}
+
+	c.send(models.DebugCommand{Command: "stop"})
+	terminated := c.expect("terminated")
+	if terminated.Result == nil || terminated.Result.Error == nil || terminated.Result.Error.Type != "DebugSessionStopped" {
+		t.Errorf("la sesión debería terminar detenida: %+v", terminated.Result)
+	}
+}
+
+func TestDebugSessionRunsToCompletion(t *testing.T) {
+	c := dialDebug(t)
+	c.send(models.DebugCommand{Command: "start", Code: "print(1 + 1)\n"})
+	c.expectPaused("entry", 1)
+	c.send(models.DebugCommand{Command: "continue"})
+	c.expect("output")
+	terminated := c.expect("terminated")
+	if terminated.Result == nil || terminated.Result.Error != nil || terminated.Result.Stdout != "2\n" {
+		t.Errorf("resultado inesperado: %+v", terminated.Result)
+	}
+}
+
+func TestDebugSessionRejectsInvalidStart(t *testing.T) {
+	cases := []struct {
+		name    string
+		command models.DebugCommand
+		message string
+	}{
+		{"sin start", models.DebugCommand{Command: "continue"}, "El primer comando debe ser 'start'"},
+		{"código vacío", models.DebugCommand{Command: "start"}, "El código no puede estar vacío"},
+		{"error de sintaxis", models.DebugCommand{Command: "start", Code: "print(1"}, "errores de sintaxis"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := dialDebug(t)
+			c.send(tc.command)
+			if failure := c.expect("error"); !strings.Contains(failure.Message, tc.message) {
+				t.Errorf("mensaje %q, se esperaba %q", failure.Message, tc.message)
+			}
+		})
+	}
+}
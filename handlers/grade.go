@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"examen-back/models"
+	"examen-back/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GradeSubmission ejecuta la entrega contra sus casos de prueba: los de
+// "tests" y, si se indica "exercise", los del ejercicio. Cada caso se ejecuta
+// en un intérprete nuevo y devuelve si pasó, los valores obtenido y esperado,
+// el diff de la salida, los pasos y el tiempo. La entrega completa tiene un
+// tiempo total de service.MaxGradeTimeMs; al agotarse se informa en
+// "budgetExhausted" y los casos restantes no se ejecutan.
+func (h *AnalysisHandler) GradeSubmission(c *gin.Context) {
+	var request models.GradeRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "JSON inválido: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "El código no puede estar vacío",
+		})
+		return
+	}
+	if err := service.ValidateEngine(request.Engine); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	tests := request.Tests
+	if request.Exercise != "" {
+		spec, ok := service.LookupExercise(request.Exercise)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Ejercicio '" + request.Exercise + "' no encontrado",
+			})
+			return
+		}
+		tests = append(append([]models.TestCase{}, spec.Tests...), tests...)
+	}
+
+	if len(tests) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Se necesita al menos un caso de prueba",
+		})
+		return
+	}
+	if len(tests) > service.MaxTestCases {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("Se admiten como máximo %d casos de prueba", service.MaxTestCases),
+		})
+		return
+	}
+	for i, test := range request.Tests {
+		if err := service.ValidateTestCase(test); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("Caso de prueba %d inválido: %s", i+1, err.Error()),
+			})
+			return
+		}
+	}
+
+	lexicalAnalyzer := service.NewLexicalAnalyzer(request.Code)
+	lexicalResult := lexicalAnalyzer.Tokenize()
+
+	syntaxAnalyzer := service.NewSyntaxAnalyzer(lexicalResult.Tokens)
+	syntaxResult := syntaxAnalyzer.Analyze()
+
+	if !syntaxResult.Valid {
+		c.JSON(http.StatusOK, models.GradeResponse{
+			GradeResult: models.GradeResult{
+				Tests:  []models.TestResult{},
+				Total:  len(tests),
+				Limits: service.NormalizeRunLimits(request.Limits),
+			},
+			SyntaxErrors: syntaxResult.Errors,
+			Success:      false,
+			Message:      "El código tiene errores de sintaxis y no se ejecutó",
+		})
+		return
+	}
+
+	result := service.GradeSubmission(syntaxResult.AST, tests, request.Limits, request.Engine)
+	message := fmt.Sprintf("%d de %d casos aprobados", result.Passed, result.Total)
+	if result.BudgetExhausted {
+		message += fmt.Sprintf("; se agotó el tiempo total de la entrega (%d ms)", service.MaxGradeTimeMs)
+	}
+	c.JSON(http.StatusOK, models.GradeResponse{
+		GradeResult:  result,
+		SyntaxErrors: syntaxResult.Errors,
+		Success:      result.Passed == result.Total,
+		Message:      message,
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+
+	"examen-back/models"
+	"examen-back/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunCode ejecuta el programa en el intérprete del sandbox y devuelve la
+// salida estándar, el valor de la expresión "call" (o de la última expresión
+// del módulo) y el error de ejecución con su línea. Los límites de pasos,
+// profundidad, memoria, tiempo y salida se pueden ajustar en "limits" hasta
+// los máximos del servidor. Con "engine": "vm" el programa se compila a
+// bytecode y se ejecuta en la máquina de pila con los mismos límites.
+func (h *AnalysisHandler) RunCode(c *gin.Context) {
+	var request models.RunRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "JSON inválido: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "El código no puede estar vacío",
+		})
+		return
+	}
+	if err := service.ValidateEngine(request.Engine); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	lexicalAnalyzer := service.NewLexicalAnalyzer(request.Code)
+	lexicalResult := lexicalAnalyzer.Tokenize()
+
+	syntaxAnalyzer := service.NewSyntaxAnalyzer(lexicalResult.Tokens)
+	syntaxResult := syntaxAnalyzer.Analyze()
+
+	if !syntaxResult.Valid {
+		c.JSON(http.StatusOK, models.RunResponse{
+			SyntaxErrors: syntaxResult.Errors,
+			Success:      false,
+			Message:      "El código tiene errores de sintaxis y no se ejecutó",
+		})
+		return
+	}
+
+	var call *models.ASTNode
+	if request.Call != "" {
+		parsed, err := service.ParseRunCall(request.Call)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Llamada inválida: " + err.Error(),
+			})
+			return
+		}
+		call = parsed
+	}
+
+	interpreter := service.NewInterpreter(syntaxResult.AST, service.NormalizeRunLimits(request.Limits))
+	if request.Engine == service.EngineVM {
+		interpreter.UseBytecode(service.CompileProgram(syntaxResult.AST))
+	}
+	result := interpreter.Run(request.Stdin, call)
+
+	response := models.RunResponse{
+		RunResult:    result,
+		SyntaxErrors: syntaxResult.Errors,
+		Success:      true,
+		Message:      "Ejecución completada exitosamente",
+	}
+
+	if result.Error != nil {
+		response.Success = false
+		response.Message = "La ejecución terminó con " + result.Error.Type
+		if result.Error.Limit {
+			response.Message = "La ejecución se detuvo por un límite del sandbox: " + result.Error.Type
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
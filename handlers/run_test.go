@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"examen-back/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// post envía body a un handler y decodifica la respuesta JSON en response
+func post(t *testing.T, handler gin.HandlerFunc, body string, response any) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/", handler)
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("respuesta que no es JSON: %s", recorder.Body.String())
+	}
+	return recorder.Code
+}
+
+func TestRunCodeReportsErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		request models.RunRequest
+		error   string
+		line    int
+		message string
+	}{
+		{"excepción", models.RunRequest{Code: "x = 1\nprint(x // 0)\n"}, "ZeroDivisionError", 2, "La ejecución terminó con ZeroDivisionError"},
+		{"excepción en la máquina de pila", models.RunRequest{Code: "x = 1\nprint(x // 0)\n", Engine: "vm"}, "ZeroDivisionError", 2, "La ejecución terminó con ZeroDivisionError"},
+		{"límite", models.RunRequest{Code: "while True:\n    pass\n", Limits: &models.RunLimits{MaxSteps: 100}}, "StepLimitExceeded", 2, "La ejecución se detuvo por un límite del sandbox: StepLimitExceeded"},
+	}
+	h := NewAnalysisHandler()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.request)
+			var response models.RunResponse
+			if status := post(t, h.RunCode, string(body), &response); status != http.StatusOK {
+				t.Fatalf("estado %d", status)
+			}
+			if response.Success || response.Error == nil {
+				t.Fatalf("se esperaba un error: %+v", response)
+			}
+			if response.Error.Type != tc.error || response.Error.Line != tc.line || response.Message != tc.message {
+				t.Errorf("error %s en línea %d (%q), se esperaba %s en línea %d (%q)",
+					response.Error.Type, response.Error.Line, response.Message, tc.error, tc.line, tc.message)
+			}
+		})
+	}
+}
+
+func TestRunCodeRejectsInvalidRequests(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"JSON inválido", "{", http.StatusBadRequest},
+		{"código vacío", `{"code": ""}`, http.StatusBadRequest},
+		{"motor desconocido", `{"code": "print(1)", "engine": "jit"}`, http.StatusBadRequest},
+		{"llamada inválida", `{"code": "print(1)", "call": "f("}`, http.StatusBadRequest},
+		{"error de sintaxis", `{"code": "print(1"}`, http.StatusOK},
+	}
+	h := NewAnalysisHandler()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var response struct {
+				Success      bool     `json:"success"`
+				Message      string   `json:"message"`
+				SyntaxErrors []string `json:"syntaxErrors"`
+			}
+			if status := post(t, h.RunCode, tc.body, &response); status != tc.status {
+				t.Fatalf("estado %d, se esperaba %d", status, tc.status)
+			}
+			if response.Success || response.Message == "" {
+				t.Errorf("respuesta inesperada: %+v", response)
+			}
+			if tc.status == http.StatusOK && len(response.SyntaxErrors) == 0 {
+				t.Errorf("faltan los errores de sintaxis")
+			}
+		})
+	}
+}
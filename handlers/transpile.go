@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"examen-back/models"
+	"examen-back/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TranspileCode traduce el programa al lenguaje indicado en target (go o
+// js). Los enteros que pueden crecer sin cota se traducen con math/big en Go
+// y con BigInt en JavaScript; la traducción a JavaScript trae además un
+// source map v3 que lleva cada línea generada a la línea de Python. Las
+// construcciones sin equivalente quedan como comentario en el código
+// generado y se listan en untranslated. Con format=text la respuesta es
+// solo el código generado.
+func (h *AnalysisHandler) TranspileCode(c *gin.Context) {
+	var request models.TranspileRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "JSON inválido: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "El código no puede estar vacío",
+		})
+		return
+	}
+	target := c.Query("target")
+	if err := service.ValidateTarget(target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	lexicalAnalyzer := service.NewLexicalAnalyzer(request.Code)
+	lexicalResult := lexicalAnalyzer.Tokenize()
+
+	syntaxAnalyzer := service.NewSyntaxAnalyzer(lexicalResult.Tokens)
+	syntaxResult := syntaxAnalyzer.Analyze()
+
+	if !syntaxResult.Valid {
+		c.JSON(http.StatusOK, models.TranspileResponse{
+			Success:      false,
+			Message:      "El código tiene errores de sintaxis",
+			Target:       target,
+			Untranslated: []models.UntranslatedConstruct{},
+			Notes:        []models.TranspileNote{},
+			SyntaxErrors: syntaxResult.Errors,
+		})
+		return
+	}
+
+	response := service.Transpile(syntaxResult.AST, request.Code, target)
+	response.Success = true
+	if len(response.Untranslated) > 0 {
+		response.Message = "Traducción parcial: hay construcciones sin equivalente"
+	} else {
+		response.Message = "Traducción completada exitosamente"
+	}
+
+	if c.Query("format") == "text" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(response.Output))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
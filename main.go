@@ -5,6 +5,7 @@ import (
 	"os"
 	"examen-back/config"
 	"examen-back/routes"
+	"examen-back/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -20,6 +21,13 @@ func main() {
 	}
 	defer config.CloseDB()
 
+	exercisesDir := getEnv("EXERCISES_DIR", "exercises")
+	if loaded, err := service.LoadExercises(exercisesDir); err != nil {
+		log.Printf("No se pudieron cargar los ejercicios de %s: %v", exercisesDir, err)
+	} else {
+		log.Printf("%d ejercicios cargados desde %s", loaded, exercisesDir)
+	}
+
 	gin.SetMode(getEnv("GIN_MODE", "debug"))
 	r := gin.Default()
 
@@ -31,6 +39,7 @@ func main() {
 	log.Printf("  POST /analyze - Análisis de código")
 	log.Printf("  GET  /api/v1/health - Estado del servicio")
 	log.Printf("  GET  /api/v1/info - Información del analizador")
+	log.Printf("  GET  /api/v1/exercises - Ejercicios disponibles")
 
 	if err := r.Run(":" + port); err != nil {
 		log.Fatal("Server failed to start:", err)
@@ -0,0 +1,47 @@
+package models
+
+type DisassembleRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// BytecodeInstruction es una instrucción del desensamblado. Statement marca
+// el comienzo de una sentencia (cuenta un paso y es donde se detiene el
+// depurador) y JumpTarget las instrucciones a las que salta otra.
+type BytecodeInstruction struct {
+	Offset     int          `json:"offset"`
+	Line       int          `json:"line"`
+	Opcode     string       `json:"opcode"`
+	Arg        *int         `json:"arg,omitempty"`
+	ArgRepr    string       `json:"argRepr,omitempty"`
+	JumpTarget bool         `json:"jumpTarget,omitempty"`
+	Statement  bool         `json:"statement,omitempty"`
+	Range      *SourceRange `json:"range,omitempty"`
+}
+
+// BytecodeFallback es una construcción que el compilador no traduce y que la
+// máquina de pila delega al intérprete de árbol (EXEC_NODE o EVAL_NODE)
+type BytecodeFallback struct {
+	Line   int          `json:"line"`
+	Type   string       `json:"type"`
+	Source string       `json:"source"`
+	Range  *SourceRange `json:"range,omitempty"`
+}
+
+type CodeObject struct {
+	Name         string                `json:"name"`
+	Line         int                   `json:"line"`
+	ArgCount     int                   `json:"argCount"`
+	Names        []string              `json:"names"`
+	Constants    []string              `json:"constants"`
+	Instructions []BytecodeInstruction `json:"instructions"`
+	Fallbacks    []BytecodeFallback    `json:"fallbacks"`
+	Text         string                `json:"text"`
+	Range        *SourceRange          `json:"range,omitempty"`
+}
+
+type DisassembleResponse struct {
+	Code         []CodeObject `json:"code"`
+	SyntaxErrors []string     `json:"syntaxErrors"`
+	Success      bool         `json:"success"`
+	Message      string       `json:"message,omitempty"`
+}
@@ -0,0 +1,63 @@
+package models
+
+type CallTraceRequest struct {
+	Code      string     `json:"code" binding:"required"`
+	Call      string     `json:"call"`
+	Stdin     string     `json:"stdin"`
+	Functions []string   `json:"functions"`
+	MaxCalls  int        `json:"maxCalls,omitempty"`
+	Limits    *RunLimits `json:"limits"`
+}
+
+type CallArgument struct {
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value"`
+}
+
+// CallNode es una llamada registrada: sus argumentos, el valor que devolvió
+// (o la excepción que la terminó) y las llamadas que hizo a su vez
+type CallNode struct {
+	ID        int            `json:"id"`
+	Function  string         `json:"function"`
+	Label     string         `json:"label"`
+	Arguments []CallArgument `json:"arguments"`
+	Return    string         `json:"return,omitempty"`
+	Exception string         `json:"exception,omitempty"`
+	Cached    bool           `json:"cached,omitempty"`
+	Line      int            `json:"line"`
+	Depth     int            `json:"depth"`
+	StartStep int            `json:"startStep"`
+	EndStep   int            `json:"endStep"`
+	Children  []*CallNode    `json:"children"`
+}
+
+// DepthSample es un punto de la línea de tiempo de la pila: la profundidad
+// (el módulo es 1) después de cada llamada ("call"), retorno ("return") o
+// excepción ("raise")
+type DepthSample struct {
+	Step  int    `json:"step"`
+	Depth int    `json:"depth"`
+	Event string `json:"event"`
+	Call  int    `json:"call"`
+}
+
+type CallTrace struct {
+	Calls      []*CallNode   `json:"calls"`
+	TotalCalls int           `json:"totalCalls"`
+	Recorded   int           `json:"recorded"`
+	MaxDepth   int           `json:"maxDepth"`
+	Truncated  bool          `json:"truncated"`
+	Functions  []string      `json:"functions"`
+	Timeline   []DepthSample `json:"timeline"`
+	Text       string        `json:"text"`
+	Dot        string        `json:"dot"`
+	Mermaid    string        `json:"mermaid"`
+}
+
+type CallTraceResponse struct {
+	RunResult
+	Trace        CallTrace `json:"trace"`
+	SyntaxErrors []string  `json:"syntaxErrors"`
+	Success      bool      `json:"success"`
+	Message      string    `json:"message,omitempty"`
+}
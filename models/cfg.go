@@ -0,0 +1,39 @@
+package models
+
+type CFGStatement struct {
+	Line  int          `json:"line"`
+	Type  string       `json:"type"`
+	Text  string       `json:"text"`
+	Range *SourceRange `json:"range,omitempty"`
+}
+
+type CFGBlock struct {
+	ID         int            `json:"id"`
+	Label      string         `json:"label"`
+	Statements []CFGStatement `json:"statements"`
+	Reachable  bool           `json:"reachable"`
+}
+
+type CFGEdge struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Kind string `json:"kind"`
+}
+
+type FunctionCFG struct {
+	Name   string       `json:"name"`
+	Line   int          `json:"line"`
+	Entry  int          `json:"entry"`
+	Exit   int          `json:"exit"`
+	Blocks []CFGBlock   `json:"blocks"`
+	Edges  []CFGEdge    `json:"edges"`
+	Dot    string       `json:"dot"`
+	Range  *SourceRange `json:"range,omitempty"`
+}
+
+type CFGResponse struct {
+	Functions    []FunctionCFG `json:"functions"`
+	SyntaxErrors []string      `json:"syntaxErrors"`
+	Success      bool          `json:"success"`
+	Message      string        `json:"message,omitempty"`
+}
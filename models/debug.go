@@ -0,0 +1,58 @@
+package models
+
+// DebugCommand es un mensaje del cliente en una sesión de depuración. El
+// primero debe ser "start" con el código; después se admiten "continue",
+// "stepInto", "stepOver", "stepOut", "pause", "setBreakpoints",
+// "setWatches", "evaluate" y "stop".
+type DebugCommand struct {
+	Command     string     `json:"command"`
+	Code        string     `json:"code,omitempty"`
+	Call        string     `json:"call,omitempty"`
+	Stdin       string     `json:"stdin,omitempty"`
+	Limits      *RunLimits `json:"limits,omitempty"`
+	Breakpoints []int      `json:"breakpoints,omitempty"`
+	Watches     []string   `json:"watches,omitempty"`
+	Expression  string     `json:"expression,omitempty"`
+	Frame       int        `json:"frame,omitempty"`
+}
+
+type DebugVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// DebugFrame es un frame de la pila con sus variables locales. El frame 0 es
+// el más interno.
+type DebugFrame struct {
+	Function string          `json:"function"`
+	Line     int             `json:"line"`
+	Locals   []DebugVariable `json:"locals"`
+}
+
+type DebugWatch struct {
+	Expression string `json:"expression"`
+	Value      string `json:"value,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DebugEvent es un mensaje del servidor: "paused" (con Reason entry, step,
+// return, breakpoint o pause; en return con el valor devuelto), "output",
+// "breakpoints", "evaluation", "terminated" o "error"
+type DebugEvent struct {
+	Event        string       `json:"event"`
+	Reason       string       `json:"reason,omitempty"`
+	Line         int          `json:"line,omitempty"`
+	Depth        int          `json:"depth,omitempty"`
+	ReturnValue  string       `json:"returnValue,omitempty"`
+	Frames       []DebugFrame `json:"frames,omitempty"`
+	Watches      []DebugWatch `json:"watches,omitempty"`
+	Breakpoints  []int        `json:"breakpoints,omitempty"`
+	Stream       string       `json:"stream,omitempty"`
+	Text         string       `json:"text,omitempty"`
+	Evaluation   *DebugWatch  `json:"evaluation,omitempty"`
+	Result       *RunResult   `json:"result,omitempty"`
+	SyntaxErrors []string     `json:"syntaxErrors,omitempty"`
+	Message      string       `json:"message,omitempty"`
+}
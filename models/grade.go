@@ -0,0 +1,63 @@
+package models
+
+// TestCase es un caso de prueba de una entrega. Se indica una aserción
+// (Expression, por ejemplo "factorial(0) == 1") o una llamada con su valor
+// esperado (Call y Expected), y opcionalmente la salida esperada y la
+// excepción que debe lanzarse. Sin Expression ni Call el caso ejecuta el
+// programa completo con Stdin y compara su salida.
+type TestCase struct {
+	Name           string  `yaml:"name" json:"name,omitempty"`
+	Expression     string  `yaml:"expression" json:"expression,omitempty"`
+	Call           string  `yaml:"call" json:"call,omitempty"`
+	Expected       string  `yaml:"expected" json:"expected,omitempty"`
+	ExpectedStdout *string `yaml:"expectedStdout" json:"expectedStdout,omitempty"`
+	Raises         string  `yaml:"raises" json:"raises,omitempty"`
+	Stdin          string  `yaml:"stdin" json:"stdin,omitempty"`
+	Weight         float64 `yaml:"weight" json:"weight,omitempty"`
+}
+
+type TestResult struct {
+	Name           string        `json:"name"`
+	Passed         bool          `json:"passed"`
+	Message        string        `json:"message"`
+	Actual         string        `json:"actual,omitempty"`
+	Expected       string        `json:"expected,omitempty"`
+	Stdout         string        `json:"stdout"`
+	ExpectedStdout *string       `json:"expectedStdout,omitempty"`
+	Diff           string        `json:"diff,omitempty"`
+	Error          *RuntimeError `json:"error"`
+	Weight         float64       `json:"weight"`
+	Steps          int           `json:"steps"`
+	MaxDepth       int           `json:"maxDepth"`
+	MemoryBytes    int64         `json:"memoryBytes"`
+	DurationMs     float64       `json:"durationMs"`
+}
+
+type GradeRequest struct {
+	Code     string     `json:"code" binding:"required"`
+	Tests    []TestCase `json:"tests"`
+	Exercise string     `json:"exercise,omitempty"`
+	Limits   *RunLimits `json:"limits"`
+	Engine   string     `json:"engine,omitempty"`
+}
+
+type GradeResult struct {
+	Tests      []TestResult `json:"tests"`
+	Passed     int          `json:"passed"`
+	Total      int          `json:"total"`
+	Score      float64      `json:"score"`
+	Steps      int          `json:"steps"`
+	DurationMs float64      `json:"durationMs"`
+	Limits     RunLimits    `json:"limits"`
+	Engine     string       `json:"engine"`
+	// BudgetExhausted indica que se agotó el tiempo total de la entrega y
+	// algún caso se cortó o no llegó a ejecutarse
+	BudgetExhausted bool `json:"budgetExhausted,omitempty"`
+}
+
+type GradeResponse struct {
+	GradeResult
+	SyntaxErrors []string `json:"syntaxErrors"`
+	Success      bool     `json:"success"`
+	Message      string   `json:"message,omitempty"`
+}
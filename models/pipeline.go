@@ -0,0 +1,55 @@
+package models
+
+// SourceRange ubica un elemento en el código: líneas y columnas desde 1 con
+// la columna final exclusiva, y los desplazamientos en bytes equivalentes
+type SourceRange struct {
+	StartLine   int `json:"startLine"`
+	StartCol    int `json:"startCol"`
+	EndLine     int `json:"endLine"`
+	EndCol      int `json:"endCol"`
+	StartOffset int `json:"startOffset"`
+	EndOffset   int `json:"endOffset"`
+}
+
+type CompileRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type StageToken struct {
+	Index int         `json:"index"`
+	Type  string      `json:"type"`
+	Value string      `json:"value"`
+	Range SourceRange `json:"range"`
+}
+
+// StageNode es un nodo del CST o del AST. Las hojas del CST son los tokens
+// que ve el analizador sintáctico (Token es su índice en la etapa de tokens);
+// los nodos que el analizador agrega sin token propio no tienen Range.
+type StageNode struct {
+	Type     string       `json:"type"`
+	Value    string       `json:"value,omitempty"`
+	Token    *int         `json:"token,omitempty"`
+	Range    *SourceRange `json:"range,omitempty"`
+	Children []*StageNode `json:"children,omitempty"`
+}
+
+// StageSymbol es una entrada de la tabla de símbolos con la posición del
+// nombre donde se define y la de cada uso que se resuelve a ella
+type StageSymbol struct {
+	Symbol
+	Range      *SourceRange  `json:"range,omitempty"`
+	References []SourceRange `json:"references"`
+}
+
+type CompileResponse struct {
+	Stages       []string      `json:"stages"`
+	Tokens       []StageToken  `json:"tokens"`
+	CST          *StageNode    `json:"cst"`
+	AST          *StageNode    `json:"ast"`
+	Symbols      []StageSymbol `json:"symbols"`
+	CFG          []FunctionCFG `json:"cfg"`
+	Bytecode     []CodeObject  `json:"bytecode"`
+	SyntaxErrors []string      `json:"syntaxErrors"`
+	Success      bool          `json:"success"`
+	Message      string        `json:"message,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+type RunLimits struct {
+	MaxSteps       int   `json:"maxSteps,omitempty"`
+	MaxDepth       int   `json:"maxDepth,omitempty"`
+	MaxMemoryBytes int64 `json:"maxMemoryBytes,omitempty"`
+	TimeoutMs      int   `json:"timeoutMs,omitempty"`
+	MaxOutputBytes int   `json:"maxOutputBytes,omitempty"`
+}
+
+type RunRequest struct {
+	Code   string     `json:"code" binding:"required"`
+	Call   string     `json:"call"`
+	Stdin  string     `json:"stdin"`
+	Limits *RunLimits `json:"limits"`
+	// Engine elige el motor: "tree" (intérprete de árbol, por defecto) o "vm" (bytecode)
+	Engine string `json:"engine,omitempty"`
+}
+
+type TraceFrame struct {
+	Function string `json:"function"`
+	Line     int    `json:"line"`
+	Repeated int    `json:"repeated,omitempty"`
+}
+
+type RuntimeError struct {
+	Type      string       `json:"type"`
+	Message   string       `json:"message"`
+	Line      int          `json:"line"`
+	Limit     bool         `json:"limit"`
+	Traceback []TraceFrame `json:"traceback"`
+}
+
+type RunResult struct {
+	Stdout      string        `json:"stdout"`
+	Stderr      string        `json:"stderr"`
+	ReturnValue string        `json:"returnValue,omitempty"`
+	ReturnType  string        `json:"returnType,omitempty"`
+	Error       *RuntimeError `json:"error"`
+	Steps       int           `json:"steps"`
+	MaxDepth    int           `json:"maxDepth"`
+	MemoryBytes int64         `json:"memoryBytes"`
+	DurationMs  float64       `json:"durationMs"`
+	Limits      RunLimits     `json:"limits"`
+	Engine      string        `json:"engine"`
+}
+
+type RunResponse struct {
+	RunResult
+	SyntaxErrors []string `json:"syntaxErrors"`
+	Success      bool     `json:"success"`
+	Message      string   `json:"message,omitempty"`
+}
@@ -19,23 +19,176 @@ type SyntaxAnalysis struct {
 }
 
 type SemanticAnalysis struct {
-	Checks      []SemanticCheck `json:"checks"`
-	SymbolTable []Symbol        `json:"symbolTable"`
-	Warnings    []string        `json:"warnings"`
-	Valid       bool            `json:"valid"`
+	Exercise      string                   `json:"exercise,omitempty"`
+	Checks        []SemanticCheck          `json:"checks"`
+	SymbolTable   []Symbol                 `json:"symbolTable"`
+	Warnings      []string                 `json:"warnings"`
+	Scopes        []ScopeInfo              `json:"scopes"`
+	Diagnostics   []Diagnostic             `json:"diagnostics"`
+	CallGraph     []CallEdge               `json:"callGraph"`
+	Recursion     []RecursionInfo          `json:"recursion"`
+	Constants     []ConstantValue          `json:"constants"`
+	Metrics       []FunctionMetrics        `json:"metrics"`
+	Optimizations []OptimizationSuggestion `json:"optimizations"`
+	Valid         bool                     `json:"valid"`
 }
 
 type SemanticCheck struct {
+	Rule        string `json:"rule,omitempty"`
 	Description string `json:"description"`
 	Passed      bool   `json:"passed"`
 	Line        int    `json:"line,omitempty"`
 }
 
+type Diagnostic struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Line     int    `json:"line,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+}
+
+// ConstantValue es una expresión cuyo valor se calcula en tiempo de compilación
+type ConstantValue struct {
+	Expression string `json:"expression"`
+	Value      string `json:"value"`
+	Type       string `json:"type"`
+	Line       int    `json:"line"`
+	Col        int    `json:"col,omitempty"`
+}
+
+// FunctionMetrics reúne las métricas de tamaño y complejidad de una función.
+// Recursion es "none", "direct" o "mutual"; RecursionDepth estima el
+// crecimiento de la pila: "none", "logarithmic", "linear", "structural", "unknown" o "unbounded".
+type FunctionMetrics struct {
+	Function             string          `json:"function"`
+	Line                 int             `json:"line"`
+	EndLine              int             `json:"endLine"`
+	Parameters           int             `json:"parameters"`
+	Statements           int             `json:"statements"`
+	CyclomaticComplexity int             `json:"cyclomaticComplexity"`
+	MaxNesting           int             `json:"maxNesting"`
+	Recursion            string          `json:"recursion"`
+	RecursionDepth       string          `json:"recursionDepth"`
+	RecursiveCalls       int             `json:"recursiveCalls"`
+	Halstead             HalsteadMetrics `json:"halstead"`
+	Lines                LineMetrics     `json:"lines"`
+}
+
+// HalsteadMetrics son las métricas de Halstead calculadas sobre los tokens
+type HalsteadMetrics struct {
+	DistinctOperators int     `json:"distinctOperators"`
+	DistinctOperands  int     `json:"distinctOperands"`
+	Operators         int     `json:"operators"`
+	Operands          int     `json:"operands"`
+	Vocabulary        int     `json:"vocabulary"`
+	Length            int     `json:"length"`
+	Volume            float64 `json:"volume"`
+	Difficulty        float64 `json:"difficulty"`
+	Effort            float64 `json:"effort"`
+	Time              float64 `json:"time"`
+	Bugs              float64 `json:"bugs"`
+}
+
+// LineMetrics cuenta las líneas de código, comentarios y en blanco
+type LineMetrics struct {
+	Total        int     `json:"total"`
+	Code         int     `json:"code"`
+	Comment      int     `json:"comment"`
+	Blank        int     `json:"blank"`
+	CommentRatio float64 `json:"commentRatio"`
+}
+
+// RuleInfo describe una regla semántica registrada
+type RuleInfo struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	Category    string `json:"category"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// ExerciseInfo describe un ejercicio cargado desde su especificación
+type ExerciseInfo struct {
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description,omitempty"`
+	Requirements []string `json:"requirements"`
+	Tests        int      `json:"tests"`
+}
+
 type Symbol struct {
-	Name  string `json:"name"`
-	Type  string `json:"type"`
-	Scope string `json:"scope"`
-	Line  int    `json:"line"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Scope   string `json:"scope"`
+	Line    int    `json:"line"`
+	Module  string `json:"module,omitempty"`
+	Binding string `json:"binding,omitempty"`
+
+	InferredType string `json:"inferredType,omitempty"`
+}
+
+type ScopeInfo struct {
+	Name    string   `json:"name"`
+	Kind    string   `json:"kind"`
+	Parent  string   `json:"parent,omitempty"`
+	Line    int      `json:"line"`
+	Symbols []Symbol `json:"symbols"`
+}
+
+type CallEdge struct {
+	Caller string `json:"caller"`
+	Callee string `json:"callee"`
+	Line   int    `json:"line"`
+}
+
+type RecursionInfo struct {
+	Function       string          `json:"function"`
+	Line           int             `json:"line"`
+	Kind           string          `json:"kind"`
+	Cycle          []string        `json:"cycle,omitempty"`
+	BaseCases      []BaseCase      `json:"baseCases"`
+	RecursiveCalls []RecursiveCall `json:"recursiveCalls"`
+}
+
+type BaseCase struct {
+	Line      int    `json:"line"`
+	Condition string `json:"condition"`
+	Returns   string `json:"returns,omitempty"`
+}
+
+type RecursiveCall struct {
+	Callee         string             `json:"callee"`
+	Line           int                `json:"line"`
+	Arguments      []ArgumentProgress `json:"arguments"`
+	TowardBaseCase bool               `json:"towardBaseCase"`
+}
+
+type ArgumentProgress struct {
+	Parameter  string `json:"parameter"`
+	Expression string `json:"expression"`
+	Progress   string `json:"progress"`
+}
+
+// OptimizationSuggestion propone reescribir una función recursiva. Kind es
+// "memoization", "accumulator" o "loop"; las ediciones de cada sugerencia se
+// aplican sobre el código original, independientemente de las demás.
+type OptimizationSuggestion struct {
+	Kind     string     `json:"kind"`
+	Function string     `json:"function"`
+	Line     int        `json:"line"`
+	Message  string     `json:"message"`
+	Edits    []CodeEdit `json:"edits"`
+}
+
+// CodeEdit reemplaza las líneas Line a EndLine (Before) por After; sin Before,
+// After se inserta antes de Line. Los fragmentos se reconstruyen desde el AST,
+// así que no conservan comentarios ni el formato original.
+type CodeEdit struct {
+	Line    int    `json:"line"`
+	EndLine int    `json:"endLine,omitempty"`
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after"`
 }
 
 type ASTNode struct {
@@ -43,10 +196,22 @@ type ASTNode struct {
 	Value    string     `json:"value,omitempty"`
 	Children []*ASTNode `json:"children,omitempty"`
 	Line     int        `json:"line"`
+	Col      int        `json:"col,omitempty"`
+
+	// Constant es el valor de la expresión cuando se conoce sin ejecutar el programa
+	Constant string `json:"constant,omitempty"`
 }
 
 type AnalysisRequest struct {
 	Code string `json:"code" binding:"required"`
+
+	// Selección de reglas semánticas por identificador (ver GET /api/v1/rules)
+	EnableRules       []string          `json:"enableRules,omitempty"`
+	DisableRules      []string          `json:"disableRules,omitempty"`
+	SeverityOverrides map[string]string `json:"severityOverrides,omitempty"`
+
+	// Ejercicio cuyos requisitos se evalúan (ver GET /api/v1/exercises)
+	Exercise string `json:"exercise,omitempty"`
 }
 
 type AnalysisResponse struct {
@@ -55,4 +220,4 @@ type AnalysisResponse struct {
 	Semantic SemanticAnalysis `json:"semantic"`
 	Success  bool             `json:"success"`
 	Message  string           `json:"message,omitempty"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,44 @@
+package models
+
+type TranspileRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// UntranslatedConstruct es una construcción de Python sin equivalente en el
+// lenguaje de destino; el código generado la deja como comentario
+type UntranslatedConstruct struct {
+	Construct string `json:"construct"`
+	Line      int    `json:"line"`
+	Source    string `json:"source"`
+	Reason    string `json:"reason"`
+}
+
+// TranspileNote advierte una diferencia de comportamiento entre el código
+// generado y el original (por ejemplo, el redondeo de la división entera)
+type TranspileNote struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+type TranspileResponse struct {
+	Success      bool                    `json:"success"`
+	Message      string                  `json:"message"`
+	Target       string                  `json:"target"`
+	Output       string                  `json:"output"`
+	Untranslated []UntranslatedConstruct `json:"untranslated"`
+	Notes        []TranspileNote         `json:"notes"`
+	SourceMap    *SourceMap              `json:"sourceMap,omitempty"`
+	SyntaxErrors []string                `json:"syntaxErrors,omitempty"`
+}
+
+// SourceMap es un Source Map v3 (https://sourcemaps.info/spec.html) que
+// enlaza cada línea del código generado con la línea y la columna del
+// programa en Python
+type SourceMap struct {
+	Version        int      `json:"version"`
+	File           string   `json:"file"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent"`
+	Names          []string `json:"names"`
+	Mappings       string   `json:"mappings"`
+}
@@ -15,6 +15,17 @@ func SetupAnalysisRoutes(router *gin.Engine) {
 	api := router.Group("/api/v1")
 	{
 		api.POST("/analyze", analysisHandler.AnalyzeCode)
+		api.GET("/cfg", analysisHandler.GetCFG)
+		api.POST("/cfg", analysisHandler.GetCFG)
+		api.GET("/rules", analysisHandler.GetRules)
+		api.GET("/exercises", analysisHandler.GetExercises)
+		api.POST("/run", analysisHandler.RunCode)
+		api.POST("/grade", analysisHandler.GradeSubmission)
+		api.POST("/trace", analysisHandler.TraceCalls)
+		api.GET("/debug", analysisHandler.DebugCode)
+		api.POST("/disassemble", analysisHandler.DisassembleCode)
+		api.POST("/compile", analysisHandler.CompileCode)
+		api.POST("/transpile", analysisHandler.TranspileCode)
 		
 		api.GET("/health", analysisHandler.GetHealth)
 		api.GET("/info", analysisHandler.GetAnalysisInfo)
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// signatureParam es un parámetro de una firma. kind es "positional" (solo
+// posicional, antes de '/'), "standard", "keyword" (solo por nombre, después
+// de '*'), "varargs" (*args) o "varkw" (**kwargs).
+type signatureParam struct {
+	name     string
+	kind     string
+	optional bool
+}
+
+// callSignature es la firma contra la que se verifican los argumentos de una llamada
+type callSignature struct {
+	name    string
+	display string
+	params  []signatureParam
+}
+
+// parseSignature interpreta una firma del catálogo ("(x, /, base=10) -> int")
+func parseSignature(name, text string) callSignature {
+	sig := callSignature{name: name}
+	open := strings.Index(text, "(")
+	if open < 0 {
+		return sig
+	}
+
+	depth := 0
+	var quote rune
+	start := open + 1
+	entries := make([]string, 0)
+	end := len(text)
+scan:
+	for i, r := range text[open+1:] {
+		pos := open + 1 + i
+		switch {
+		case quote != 0:
+			if r == quote && text[pos-1] != '\\' {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' && depth == 0:
+			entries = append(entries, text[start:pos])
+			end = pos
+			break scan
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			entries = append(entries, text[start:pos])
+			start = pos + 1
+		}
+	}
+	sig.display = name + text[open:end+1]
+
+	keywordOnly := false
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			continue
+		case entry == "/":
+			for i := range sig.params {
+				sig.params[i].kind = "positional"
+			}
+		case entry == "*":
+			keywordOnly = true
+		case strings.HasPrefix(entry, "**"):
+			sig.params = append(sig.params, signatureParam{name: entry[2:], kind: "varkw"})
+		case strings.HasPrefix(entry, "*"):
+			sig.params = append(sig.params, signatureParam{name: entry[1:], kind: "varargs"})
+			keywordOnly = true
+		default:
+			param := signatureParam{name: entry, kind: "standard"}
+			if eq := strings.Index(entry, "="); eq >= 0 {
+				param.name = strings.TrimSpace(entry[:eq])
+				param.optional = true
+			}
+			if keywordOnly {
+				param.kind = "keyword"
+			}
+			sig.params = append(sig.params, param)
+		}
+	}
+	return sig
+}
+
+// functionSignature construye la firma de una FunctionDef a partir de sus Parameter
+func functionSignature(node *models.ASTNode) callSignature {
+	params, _ := functionParts(node)
+	sig := callSignature{
+		name:    node.Value,
+		display: fmt.Sprintf("%s(%s)", node.Value, formatParameters(params)),
+	}
+
+	keywordOnly := false
+	for _, param := range params {
+		switch {
+		case param.Value == "*":
+			keywordOnly = true
+		case strings.HasPrefix(param.Value, "**"):
+			sig.params = append(sig.params, signatureParam{name: param.Value[2:], kind: "varkw"})
+		case strings.HasPrefix(param.Value, "*"):
+			sig.params = append(sig.params, signatureParam{name: param.Value[1:], kind: "varargs"})
+			keywordOnly = true
+		default:
+			kind := "standard"
+			if keywordOnly {
+				kind = "keyword"
+			}
+			sig.params = append(sig.params, signatureParam{name: param.Value, kind: kind, optional: parameterDefault(param) != nil})
+		}
+	}
+	return sig
+}
+
+// checkCallArity verifica cada llamada a una función del programa, a una
+// función integrada o a una función del catálogo de módulos contra su firma
+func checkCallArity(ast *models.ASTNode, tree *scopeTree) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+	if ast == nil {
+		return diagnostics
+	}
+
+	var visit func(node *models.ASTNode)
+	visit = func(node *models.ASTNode) {
+		if node.Type == "FunctionCall" {
+			if sig, found := calleeSignature(node, tree); found {
+				diagnostics = append(diagnostics, sig.check(node)...)
+			}
+		}
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	visit(ast)
+
+	return diagnostics
+}
+
+// calleeSignature busca la firma de la función llamada; las llamadas a métodos
+// y a valores sin firma conocida no se verifican
+func calleeSignature(call *models.ASTNode, tree *scopeTree) (callSignature, bool) {
+	symbol := tree.resolved[call]
+	dotted := strings.Contains(call.Value, ".")
+
+	var member ModuleMember
+	known := false
+	switch {
+	case symbol == nil && !dotted:
+		member, known = builtinSignature(call.Value)
+	case symbol == nil:
+		return callSignature{}, false
+	case symbol.kind == "module" && dotted:
+		member, known = modulePathMember(symbol, call.Value)
+	case dotted:
+		return callSignature{}, false
+	case symbol.kind == "function" && symbol.node != nil && symbol.node.Type == "FunctionDef":
+		return functionSignature(symbol.node), true
+	case symbol.module != "":
+		member, known = importedMember(symbol)
+	}
+
+	if !known || (member.Kind != "function" && member.Kind != "class") {
+		return callSignature{}, false
+	}
+	parts := strings.Split(call.Value, ".")
+	return parseSignature(parts[len(parts)-1], member.Signature), true
+}
+
+// check enlaza los argumentos de la llamada con los parámetros como lo hace
+// Python y reporta los que producirían un TypeError
+func (sig callSignature) check(call *models.ASTNode) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+	report := func(code, message string) {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Code:     code,
+			Severity: "error",
+			Message:  message,
+			Line:     call.Line,
+		})
+	}
+	source := formatSource(call)
+
+	positional := make([]signatureParam, 0)
+	var varargs, varkw bool
+	names := make([]string, 0)
+	for _, param := range sig.params {
+		switch param.kind {
+		case "positional", "standard":
+			positional = append(positional, param)
+		case "varargs":
+			varargs = true
+		case "varkw":
+			varkw = true
+		}
+		if param.kind == "standard" || param.kind == "keyword" {
+			names = append(names, param.name)
+		}
+	}
+
+	filled := make(map[string]bool)
+	given := 0
+	keywords := make([]*models.ASTNode, 0)
+	seen := make(map[string]bool)
+	for _, arg := range call.Children {
+		if arg.Type != "Keyword" {
+			if given < len(positional) {
+				filled[positional[given].name] = true
+			}
+			given++
+			continue
+		}
+		if seen[arg.Value] {
+			report("duplicate-keyword", fmt.Sprintf("El argumento '%s' se pasa dos veces por nombre en la llamada '%s' (línea %d)", arg.Value, source, call.Line))
+			continue
+		}
+		seen[arg.Value] = true
+		keywords = append(keywords, arg)
+	}
+
+	if given > len(positional) && !varargs {
+		limit := fmt.Sprintf("como máximo %d", len(positional))
+		if len(positional) == 0 {
+			limit = "ninguno"
+		}
+		report("too-many-arguments", fmt.Sprintf("La llamada '%s' en línea %d pasa %d argumentos posicionales, pero '%s' acepta %s; la firma es %s", source, call.Line, given, sig.name, limit, sig.display))
+	}
+
+	// Python informa el nombre inesperado antes que los argumentos que faltan
+	unexpected := false
+	for _, keyword := range keywords {
+		var param *signatureParam
+		for i := range sig.params {
+			if sig.params[i].name == keyword.Value && sig.params[i].kind != "varargs" && sig.params[i].kind != "varkw" {
+				param = &sig.params[i]
+			}
+		}
+
+		switch {
+		case param != nil && param.kind == "positional":
+			if !varkw {
+				unexpected = true
+				report("unknown-keyword", fmt.Sprintf("'%s' no admite '%s' por nombre en la llamada '%s' (línea %d): es un parámetro solo posicional", sig.name, keyword.Value, source, call.Line))
+			}
+		case param == nil:
+			if !varkw {
+				unexpected = true
+				report("unknown-keyword", fmt.Sprintf("'%s' no tiene un parámetro llamado '%s' (llamada '%s', línea %d)%s", sig.name, keyword.Value, source, call.Line, suggestName(keyword.Value, names)))
+			}
+		case filled[param.name]:
+			report("multiple-values", fmt.Sprintf("La llamada '%s' en línea %d da dos valores a '%s': uno por posición y otro por nombre", source, call.Line, param.name))
+		default:
+			filled[param.name] = true
+		}
+	}
+
+	missing := make([]string, 0)
+	for _, param := range sig.params {
+		if (param.kind == "positional" || param.kind == "standard" || param.kind == "keyword") && !param.optional && !filled[param.name] {
+			missing = append(missing, "'"+param.name+"'")
+		}
+	}
+	switch {
+	case unexpected:
+	case len(missing) == 1:
+		report("missing-argument", fmt.Sprintf("Falta el argumento obligatorio %s en la llamada '%s' (línea %d); la firma es %s", missing[0], source, call.Line, sig.display))
+	case len(missing) > 1:
+		report("missing-argument", fmt.Sprintf("Faltan los argumentos obligatorios %s en la llamada '%s' (línea %d); la firma es %s", strings.Join(missing, ", "), source, call.Line, sig.display))
+	}
+
+	return diagnostics
+}
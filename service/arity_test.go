@@ -0,0 +1,46 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCallArity(t *testing.T) {
+	const factorial = "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\n"
+	const power = "def power(base, exp=2, *, mod=None):\n    return base ** exp\n\n"
+	cases := []struct {
+		name string
+		code string
+		want map[string][]int
+	}{
+		{"demasiados argumentos", factorial + "print(factorial(5, 6))\n", map[string][]int{"too-many-arguments": {6}}},
+		{"falta un argumento", factorial + "print(factorial())\n", map[string][]int{"missing-argument": {6}}},
+		{"nombre desconocido", factorial + "print(factorial(m=5))\n", map[string][]int{"unknown-keyword": {6}, "missing-argument": nil}},
+		{"dos valores para un parámetro", power + "print(power(2, base=3))\n", map[string][]int{"multiple-values": {4}}},
+		{"nombre repetido", power + "print(power(2, mod=3, mod=4))\n", map[string][]int{"duplicate-keyword": {4}}},
+		{"solo por nombre", power + "print(power(2, 3, 5))\n", map[string][]int{"too-many-arguments": {4}}},
+		{"builtin sin argumentos", "print(len())\n", map[string][]int{"missing-argument": {1}}},
+		{"builtin con demasiados", "print(len('a', 'b'))\n", map[string][]int{"too-many-arguments": {1}}},
+		{"builtin solo posicional", "print(len(obj='a'))\n", map[string][]int{"unknown-keyword": {1}}},
+		{"nombre desconocido en print", "print(1, color='rojo')\n", map[string][]int{"unknown-keyword": {1}}},
+		{"función de un módulo", "import math\nprint(math.sqrt(1, 2))\n", map[string][]int{"too-many-arguments": {2}}},
+		{"llamadas correctas", factorial + power + "print(factorial(5), power(2), power(2, 3, mod=5), power(base=2, exp=3))\nprint(1, 2, sep=', ', end='')\nprint(int('7', 10), range(1, 10, 2), len([1]))\n",
+			map[string][]int{"too-many-arguments": nil, "missing-argument": nil, "unknown-keyword": nil, "multiple-values": nil, "duplicate-keyword": nil}},
+		{"argumentos variables", "def f(*args, **kwargs):\n    return len(args) + len(kwargs)\n\nprint(f(1, 2, 3, x=4))\n", map[string][]int{"too-many-arguments": nil, "unknown-keyword": nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectDiagnostics(t, tc.code, tc.want)
+		})
+	}
+}
+
+func TestCallArityMessages(t *testing.T) {
+	result := analyze(t, "def factorial(n):\n    return 1\n\nprint(factorial(num=5))\nprint(factorial(5, 6))\n")
+	if found := diagnosticsWithCode(result, "unknown-keyword"); len(found) != 1 || !strings.Contains(found[0].Message, "'factorial' no tiene un parámetro llamado 'num'") {
+		t.Errorf("mensaje inesperado para el nombre desconocido: %v", found)
+	}
+	if found := diagnosticsWithCode(result, "too-many-arguments"); len(found) != 1 || !strings.Contains(found[0].Message, "la firma es factorial(n)") {
+		t.Errorf("el mensaje debe mostrar la firma: %v", found)
+	}
+}
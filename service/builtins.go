@@ -0,0 +1,84 @@
+package service
+
+// Catálogo de funciones y tipos integrados de Python, con el mismo formato de
+// firma que los módulos de la biblioteca estándar. Para las clases, llamar al
+// nombre devuelve una instancia del propio tipo.
+var builtinFunctions = map[string]ModuleMember{
+	"print":      stubFunc("(*values, sep=' ', end='\\n', file=None, flush=False) -> None"),
+	"input":      stubFunc("(prompt='', /) -> str"),
+	"len":        stubFunc("(obj, /) -> int"),
+	"abs":        stubFunc("(x, /) -> object"),
+	"round":      stubFunc("(number, ndigits=None) -> object"),
+	"pow":        stubFunc("(base, exp, mod=None) -> object"),
+	"divmod":     stubFunc("(x, y, /) -> tuple"),
+	"max":        stubFunc("(iterable, /, *args, key=None, default=None) -> object"),
+	"min":        stubFunc("(iterable, /, *args, key=None, default=None) -> object"),
+	"sum":        stubFunc("(iterable, /, start=0) -> object"),
+	"sorted":     stubFunc("(iterable, /, *, key=None, reverse=False) -> list"),
+	"reversed":   stubClass("(sequence, /)"),
+	"enumerate":  stubClass("(iterable, start=0)"),
+	"zip":        stubClass("(*iterables, strict=False)"),
+	"map":        stubClass("(function, iterable, /, *iterables)"),
+	"filter":     stubClass("(function, iterable, /)"),
+	"range":      stubClass("(start_or_stop, stop=None, step=1, /)"),
+	"iter":       stubFunc("(object, sentinel=None, /) -> object"),
+	"next":       stubFunc("(iterator, default=None, /) -> object"),
+	"all":        stubFunc("(iterable, /) -> bool"),
+	"any":        stubFunc("(iterable, /) -> bool"),
+	"isinstance": stubFunc("(obj, class_or_tuple, /) -> bool"),
+	"issubclass": stubFunc("(cls, class_or_tuple, /) -> bool"),
+	"hasattr":    stubFunc("(obj, name, /) -> bool"),
+	"getattr":    stubFunc("(obj, name, default=None, /) -> object"),
+	"setattr":    stubFunc("(obj, name, value, /) -> None"),
+	"callable":   stubFunc("(obj, /) -> bool"),
+	"id":         stubFunc("(obj, /) -> int"),
+	"hash":       stubFunc("(obj, /) -> int"),
+	"chr":        stubFunc("(i, /) -> str"),
+	"ord":        stubFunc("(c, /) -> int"),
+	"bin":        stubFunc("(number, /) -> str"),
+	"hex":        stubFunc("(number, /) -> str"),
+	"oct":        stubFunc("(number, /) -> str"),
+	"repr":       stubFunc("(obj, /) -> str"),
+	"format":     stubFunc("(value, format_spec='', /) -> str"),
+	"super":      stubClass("(type=None, object_or_type=None, /)"),
+	"exit":       stubFunc("(code=None) -> None"),
+	"quit":       stubFunc("(code=None) -> None"),
+	"open":       stubFunc("(file, mode='r', buffering=-1, encoding=None, errors=None, newline=None, closefd=True, opener=None) -> object"),
+	"int":        stubClass("(x=0, /, base=10)"),
+	"float":      stubClass("(x=0.0, /)"),
+	"str":        stubClass("(object='', encoding='utf-8', errors='strict')"),
+	"bool":       stubClass("(x=False, /)"),
+	"list":       stubClass("(iterable=(), /)"),
+	"tuple":      stubClass("(iterable=(), /)"),
+	"dict":       stubClass("(mapping=None, /, **kwargs)"),
+	"set":        stubClass("(iterable=(), /)"),
+	"frozenset":  stubClass("(iterable=(), /)"),
+	"object":     stubClass("()"),
+	"type":       stubClass("(object_or_name, bases=None, dict=None, /)"),
+
+	// Las demás excepciones de exceptionHierarchy se agregan en init
+	"AttributeError": stubClass("(*args, name=None, obj=None)"),
+	"NameError":      stubClass("(*args, name=None)"),
+
+	"__name__": stubConst("str"),
+}
+
+func init() {
+	for _, entry := range exceptionHierarchy {
+		if _, ok := builtinFunctions[entry[0]]; !ok {
+			builtinFunctions[entry[0]] = stubClass("(*args)")
+		}
+	}
+}
+
+// isBuiltinName indica si el nombre se resuelve en el scope de builtins
+func isBuiltinName(name string) bool {
+	_, ok := builtinFunctions[name]
+	return ok
+}
+
+// builtinSignature busca una función o tipo integrado por nombre
+func builtinSignature(name string) (ModuleMember, bool) {
+	member, ok := builtinFunctions[name]
+	return member, ok
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"examen-back/models"
+)
+
+func TestBuiltinRegistryMatchesRuntime(t *testing.T) {
+	in := NewInterpreter(parse("").AST, models.RunLimits{})
+	for name := range builtinFunctions {
+		if _, ok := in.builtins[name]; !ok && name != "__name__" {
+			t.Errorf("'%s' está en el catálogo pero no en el intérprete", name)
+		}
+	}
+	for name := range in.builtins {
+		if !isBuiltinName(name) {
+			t.Errorf("'%s' está en el intérprete pero no en el catálogo", name)
+		}
+	}
+	for name := range builtinFunctionTable {
+		if !isBuiltinName(name) {
+			t.Errorf("'%s' tiene implementación pero no está en el catálogo", name)
+		}
+	}
+}
+
+func TestBuiltinNamesAreDefined(t *testing.T) {
+	const code = `class A:
+    def __init__(self):
+        super().__init__()
+        setattr(self, "x", 1)
+
+try:
+    print(A().x)
+except (LookupError, KeyboardInterrupt):
+    exit(1)
+`
+	if found := diagnosticsWithCode(analyze(t, code), "undefined-name"); len(found) > 0 {
+		t.Errorf("nombres integrados informados como no definidos: %v", found)
+	}
+	result := NewInterpreter(parse(code).AST, models.RunLimits{}).Run("", nil)
+	if result.Error != nil || result.Stdout != "1\n" {
+		t.Errorf("resultado inesperado: %q %+v", result.Stdout, result.Error)
+	}
+}
@@ -0,0 +1,955 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// opcode es una instrucción de la máquina de pila. Los nombres siguen a los
+// de CPython (LOAD_FAST, BINARY_OP, CALL...) para que el desensamblado se
+// pueda comparar con el módulo dis.
+type opcode uint8
+
+const (
+	opNop opcode = iota
+	opPopTop
+	opCopy
+	opSwap
+	opLoadConst
+	opLoadFast
+	opStoreFast
+	opLoadGlobal
+	opStoreGlobal
+	opLoadName
+	opStoreName
+	opLoadDeref
+	opStoreDeref
+	opLoadAttr
+	opStoreAttr
+	opBinarySubscr
+	opStoreSubscr
+	opBuildSlice
+	opBinaryOp
+	opCompareOp
+	opUnaryNegative
+	opUnaryPositive
+	opUnaryInvert
+	opUnaryNot
+	opBuildList
+	opBuildTuple
+	opBuildSet
+	opBuildMap
+	opUnpackSequence
+	opKwNames
+	opCall
+	opMakeFunction
+	opJumpForward
+	opJumpBackward
+	opPopJumpIfFalse
+	opPopJumpIfTrue
+	opJumpIfFalseOrPop
+	opJumpIfTrueOrPop
+	opGetIter
+	opForIter
+	opReturnValue
+	opExecNode
+	opEvalNode
+)
+
+var opcodeNames = [...]string{
+	opNop:              "NOP",
+	opPopTop:           "POP_TOP",
+	opCopy:             "COPY",
+	opSwap:             "SWAP",
+	opLoadConst:        "LOAD_CONST",
+	opLoadFast:         "LOAD_FAST",
+	opStoreFast:        "STORE_FAST",
+	opLoadGlobal:       "LOAD_GLOBAL",
+	opStoreGlobal:      "STORE_GLOBAL",
+	opLoadName:         "LOAD_NAME",
+	opStoreName:        "STORE_NAME",
+	opLoadDeref:        "LOAD_DEREF",
+	opStoreDeref:       "STORE_DEREF",
+	opLoadAttr:         "LOAD_ATTR",
+	opStoreAttr:        "STORE_ATTR",
+	opBinarySubscr:     "BINARY_SUBSCR",
+	opStoreSubscr:      "STORE_SUBSCR",
+	opBuildSlice:       "BUILD_SLICE",
+	opBinaryOp:         "BINARY_OP",
+	opCompareOp:        "COMPARE_OP",
+	opUnaryNegative:    "UNARY_NEGATIVE",
+	opUnaryPositive:    "UNARY_POSITIVE",
+	opUnaryInvert:      "UNARY_INVERT",
+	opUnaryNot:         "UNARY_NOT",
+	opBuildList:        "BUILD_LIST",
+	opBuildTuple:       "BUILD_TUPLE",
+	opBuildSet:         "BUILD_SET",
+	opBuildMap:         "BUILD_MAP",
+	opUnpackSequence:   "UNPACK_SEQUENCE",
+	opKwNames:          "KW_NAMES",
+	opCall:             "CALL",
+	opMakeFunction:     "MAKE_FUNCTION",
+	opJumpForward:      "JUMP_FORWARD",
+	opJumpBackward:     "JUMP_BACKWARD",
+	opPopJumpIfFalse:   "POP_JUMP_IF_FALSE",
+	opPopJumpIfTrue:    "POP_JUMP_IF_TRUE",
+	opJumpIfFalseOrPop: "JUMP_IF_FALSE_OR_POP",
+	opJumpIfTrueOrPop:  "JUMP_IF_TRUE_OR_POP",
+	opGetIter:          "GET_ITER",
+	opForIter:          "FOR_ITER",
+	opReturnValue:      "RETURN_VALUE",
+	opExecNode:         "EXEC_NODE",
+	opEvalNode:         "EVAL_NODE",
+}
+
+func (op opcode) String() string {
+	return opcodeNames[op]
+}
+
+// hasArgument indica si la instrucción usa su argumento
+func (op opcode) hasArgument() bool {
+	switch op {
+	case opNop, opPopTop, opBinarySubscr, opStoreSubscr, opUnaryNegative, opUnaryPositive,
+		opUnaryInvert, opUnaryNot, opGetIter, opReturnValue:
+		return false
+	}
+	return true
+}
+
+func (op opcode) isJump() bool {
+	switch op {
+	case opJumpForward, opJumpBackward, opPopJumpIfFalse, opPopJumpIfTrue,
+		opJumpIfFalseOrPop, opJumpIfTrueOrPop, opForIter:
+		return true
+	}
+	return false
+}
+
+// binaryOperators son los argumentos de BINARY_OP y COMPARE_OP; las
+// versiones con "=" son las de la asignación aumentada
+var binaryOperators = []string{
+	"+", "-", "*", "/", "//", "%", "**", "<<", ">>", "&", "|", "^", "@",
+	"+=", "-=", "*=", "/=", "//=", "%=", "**=", "<<=", ">>=", "&=", "|=", "^=", "@=",
+	"<", "<=", "==", "!=", ">", ">=", "in", "not in", "is", "is not",
+}
+
+var unaryOpcodes = map[string]opcode{
+	"-": opUnaryNegative, "+": opUnaryPositive, "~": opUnaryInvert, "not": opUnaryNot,
+}
+
+var unarySymbols = map[opcode]string{
+	opUnaryNegative: "-", opUnaryPositive: "+", opUnaryInvert: "~", opUnaryNot: "not",
+}
+
+func binaryOperator(op string) int {
+	for i, candidate := range binaryOperators {
+		if candidate == op {
+			return i
+		}
+	}
+	return -1
+}
+
+// Marcas de las instrucciones: flagTick cuenta un paso de ejecución y
+// flagStep marca el comienzo de la sentencia node (línea actual y depurador),
+// igual que hace el intérprete de árbol antes de cada sentencia
+const (
+	flagTick = 1 << iota
+	flagStep
+)
+
+type instruction struct {
+	op    opcode
+	arg   int
+	flags uint8
+	line  int
+	node  *models.ASTNode
+	// start marca la primera instrucción de una sentencia
+	start bool
+	// source es la sentencia o expresión que generó la instrucción
+	source *models.ASTNode
+}
+
+// loopTargets indica a EXEC_NODE dónde seguir si la sentencia interpretada
+// termina con break o continue, y la altura de la pila tras el break
+type loopTargets struct {
+	breakTo    int
+	continueTo int
+	height     int
+}
+
+// codeObject es el código compilado de una función, una lambda o el módulo
+type codeObject struct {
+	name      string
+	node      *models.ASTNode
+	argCount  int
+	code      []instruction
+	consts    []pyValue
+	names     []string
+	nodes     []*models.ASTNode
+	kwnames   [][]string
+	loops     map[int]loopTargets
+	fallbacks []*models.ASTNode
+}
+
+// BytecodeProgram es el programa compilado: el código del módulo y el de
+// cada función y lambda, indexado por su nodo del AST
+type BytecodeProgram struct {
+	main  *codeObject
+	codes map[*models.ASTNode]*codeObject
+	order []*codeObject
+}
+
+// CompileProgram compila el programa a bytecode. Las construcciones que el
+// compilador no traduce (try, class, import, comprensiones...) se ejecutan
+// con el intérprete de árbol mediante EXEC_NODE y EVAL_NODE, de modo que
+// todo programa se puede compilar.
+func CompileProgram(ast *models.ASTNode) *BytecodeProgram {
+	program := &BytecodeProgram{codes: make(map[*models.ASTNode]*codeObject)}
+	tree := buildScopeTree(ast)
+
+	var body []*models.ASTNode
+	if ast != nil {
+		body = ast.Children
+	}
+	program.main = program.compile("<module>", ast, tree.module, tree, body, false)
+
+	walkNodes(ast, func(node *models.ASTNode) {
+		if node.Type != "FunctionDef" && node.Type != "Lambda" {
+			return
+		}
+		params, body := functionParts(node)
+		name := node.Value
+		if node.Type == "Lambda" {
+			name = "<lambda>"
+		}
+		code := program.compile(name, node, tree.byNode[node], tree, body, node.Type == "Lambda")
+		code.argCount = len(params)
+		program.codes[node] = code
+	})
+	return program
+}
+
+func (p *BytecodeProgram) compile(name string, node *models.ASTNode, sc *scope, tree *scopeTree, body []*models.ASTNode, lambda bool) *codeObject {
+	c := &compiler{
+		tree:       tree,
+		scope:      sc,
+		constIndex: make(map[string]int),
+		nameIndex:  make(map[string]int),
+		nodeIndex:  make(map[*models.ASTNode]int),
+		code:       &codeObject{name: name, node: node, loops: make(map[int]loopTargets)},
+	}
+	if node != nil {
+		c.line = node.Line
+	}
+
+	switch {
+	case lambda:
+		c.expression(body[0])
+		c.emit(opReturnValue, 0)
+	case sc.kind == "module":
+		for i, stmt := range body {
+			if i == len(body)-1 && isExpression(stmt) {
+				// El valor de la última expresión del módulo es el resultado de Run
+				start := len(c.code.code)
+				c.line = stmt.Line
+				c.expression(stmt)
+				c.mark(start, stmt)
+				c.emit(opReturnValue, 0)
+				return c.finish(p)
+			}
+			c.statement(stmt)
+		}
+		c.implicitReturn()
+	default:
+		for _, stmt := range body {
+			c.statement(stmt)
+		}
+		c.implicitReturn()
+	}
+	return c.finish(p)
+}
+
+// compileLoop son los saltos pendientes de un bucle en compilación
+type compileLoop struct {
+	isFor     bool
+	height    int
+	breaks    []int
+	continues []int
+	execs     []int
+}
+
+type compiler struct {
+	tree       *scopeTree
+	scope      *scope
+	code       *codeObject
+	constIndex map[string]int
+	nameIndex  map[string]int
+	nodeIndex  map[*models.ASTNode]int
+	loops      []*compileLoop
+	line       int
+	source     *models.ASTNode
+	// height es la altura de la pila entre sentencias: un iterador por cada for abierto
+	height int
+}
+
+// implicitReturn es el "return None" que agrega el compilador al final del código
+func (c *compiler) implicitReturn() {
+	c.source = nil
+	c.emit(opLoadConst, c.constant(noneValue()))
+	c.emit(opReturnValue, 0)
+}
+
+func (c *compiler) finish(p *BytecodeProgram) *codeObject {
+	p.order = append(p.order, c.code)
+	return c.code
+}
+
+func (c *compiler) emit(op opcode, arg int) int {
+	c.code.code = append(c.code.code, instruction{op: op, arg: arg, line: c.line, source: c.source})
+	return len(c.code.code) - 1
+}
+
+// patch apunta el salto at a la siguiente instrucción que se emita
+func (c *compiler) patch(at int) {
+	c.code.code[at].arg = len(c.code.code)
+}
+
+// mark marca start como comienzo de la sentencia
+func (c *compiler) mark(start int, stmt *models.ASTNode) {
+	c.code.code[start].flags |= flagTick | flagStep
+	c.code.code[start].node = stmt
+	c.code.code[start].start = true
+}
+
+func (c *compiler) constant(value constValue) int {
+	key := value.kind + ":" + value.Repr()
+	if index, ok := c.constIndex[key]; ok {
+		return index
+	}
+	c.code.consts = append(c.code.consts, value)
+	c.constIndex[key] = len(c.code.consts) - 1
+	return len(c.code.consts) - 1
+}
+
+func (c *compiler) name(name string) int {
+	if index, ok := c.nameIndex[name]; ok {
+		return index
+	}
+	c.code.names = append(c.code.names, name)
+	c.nameIndex[name] = len(c.code.names) - 1
+	return len(c.code.names) - 1
+}
+
+func (c *compiler) node(node *models.ASTNode) int {
+	if index, ok := c.nodeIndex[node]; ok {
+		return index
+	}
+	c.code.nodes = append(c.code.nodes, node)
+	c.nodeIndex[node] = len(c.code.nodes) - 1
+	return len(c.code.nodes) - 1
+}
+
+// nameOps elige las instrucciones de carga y almacenamiento de un nombre con
+// las mismas reglas que lookup y store del intérprete
+func (c *compiler) nameOps(name string) (opcode, opcode) {
+	if c.scope.kind == "module" {
+		return opLoadName, opStoreName
+	}
+	symbol := c.scope.symbols[name]
+	switch {
+	case symbol == nil, symbol.binding == "global", symbol.binding == "builtin":
+		return opLoadGlobal, opStoreGlobal
+	case (symbol.binding == "local" || symbol.binding == "cell") && (c.scope.kind == "function" || c.scope.kind == "lambda"):
+		return opLoadFast, opStoreFast
+	}
+	return opLoadDeref, opStoreDeref
+}
+
+func (c *compiler) load(name string) {
+	op, _ := c.nameOps(name)
+	c.emit(op, c.name(name))
+}
+
+func (c *compiler) store(name string) {
+	_, op := c.nameOps(name)
+	c.emit(op, c.name(name))
+}
+
+// fallback interpreta la sentencia con el intérprete de árbol
+func (c *compiler) fallback(stmt *models.ASTNode) {
+	at := c.emit(opExecNode, c.node(stmt))
+	c.code.fallbacks = append(c.code.fallbacks, stmt)
+	if len(c.loops) > 0 {
+		loop := c.loops[len(c.loops)-1]
+		loop.execs = append(loop.execs, at)
+	}
+}
+
+func (c *compiler) statement(node *models.ASTNode) {
+	if node.Type == "Block" {
+		for _, child := range node.Children {
+			c.statement(child)
+		}
+		return
+	}
+
+	c.line = node.Line
+	outer := c.source
+	c.source = node
+	defer func() { c.source = outer }()
+	start := len(c.code.code)
+	switch node.Type {
+	case "Assignment":
+		if node.Value != "" {
+			c.expression(node.Children[0])
+			c.store(node.Value)
+			break
+		}
+		if !assignable(node.Children[0]) {
+			c.fallback(node)
+			return
+		}
+		c.expression(node.Children[1])
+		c.assign(node.Children[0])
+
+	case "AugAssignment":
+		if !c.augAssign(node) {
+			c.fallback(node)
+			return
+		}
+
+	case "AnnotatedName", "PassStatement", "GlobalStatement", "NonlocalStatement":
+		c.emit(opNop, 0)
+
+	case "IfStatement":
+		c.expression(node.Children[0])
+		skip := c.emit(opPopJumpIfFalse, 0)
+		c.statement(node.Children[1])
+		if len(node.Children) > 2 {
+			end := c.emit(opJumpForward, 0)
+			c.patch(skip)
+			c.statement(node.Children[2])
+			c.patch(end)
+		} else {
+			c.patch(skip)
+		}
+
+	case "WhileStatement":
+		c.emit(opNop, 0)
+		condition := len(c.code.code)
+		c.expression(node.Children[0])
+		exit := c.emit(opPopJumpIfFalse, 0)
+		loop := c.enterLoop(false)
+		c.statement(node.Children[1])
+		back := c.backJump(condition, node)
+		c.patch(exit)
+		c.exitLoop(loop, back, node, 2)
+
+	case "ForStatement":
+		if !assignable(node.Children[0]) {
+			c.fallback(node)
+			return
+		}
+		c.expression(node.Children[1])
+		iter := c.emit(opGetIter, 0)
+		c.code.code[iter].flags = flagTick
+		next := c.emit(opForIter, 0)
+		c.height++
+		loop := c.enterLoop(true)
+		c.assign(node.Children[0])
+		c.statement(node.Children[2])
+		back := c.backJump(next, node)
+		c.height--
+		c.patch(next)
+		c.exitLoop(loop, back, node, 3)
+
+	case "FunctionDef":
+		c.emit(opMakeFunction, c.node(node))
+		c.store(node.Value)
+
+	case "ReturnStatement":
+		if len(node.Children) == 0 {
+			c.emit(opLoadConst, c.constant(noneValue()))
+		} else {
+			c.expression(node.Children[0])
+		}
+		c.emit(opReturnValue, 0)
+
+	case "BreakStatement", "ContinueStatement":
+		if len(c.loops) == 0 {
+			c.fallback(node)
+			return
+		}
+		loop := c.loops[len(c.loops)-1]
+		if node.Type == "ContinueStatement" {
+			loop.continues = append(loop.continues, c.emit(opJumpBackward, 0))
+			break
+		}
+		if loop.isFor {
+			c.emit(opPopTop, 0)
+		}
+		loop.breaks = append(loop.breaks, c.emit(opJumpForward, 0))
+
+	default:
+		if !isExpression(node) {
+			c.fallback(node)
+			return
+		}
+		c.expression(node)
+		c.emit(opPopTop, 0)
+	}
+	c.mark(start, node)
+}
+
+// backJump cierra una iteración del bucle. Como en el intérprete de árbol,
+// cada vuelta cuenta un paso y vuelve a detenerse en la cabecera del bucle.
+func (c *compiler) backJump(target int, loop *models.ASTNode) int {
+	c.line = loop.Line
+	c.source = loop
+	back := c.emit(opJumpBackward, target)
+	c.code.code[back].flags = flagTick | flagStep
+	c.code.code[back].node = loop
+	return back
+}
+
+func (c *compiler) enterLoop(isFor bool) *compileLoop {
+	loop := &compileLoop{isFor: isFor, height: c.height}
+	c.loops = append(c.loops, loop)
+	return loop
+}
+
+// exitLoop compila el else del bucle (si tiene) y resuelve los saltos de
+// break y continue
+func (c *compiler) exitLoop(loop *compileLoop, back int, node *models.ASTNode, elseIndex int) {
+	c.loops = c.loops[:len(c.loops)-1]
+	if len(node.Children) > elseIndex {
+		c.statement(node.Children[elseIndex])
+	}
+	end := len(c.code.code)
+	for _, at := range loop.breaks {
+		c.code.code[at].arg = end
+	}
+	for _, at := range loop.continues {
+		c.code.code[at].arg = back
+	}
+	height := loop.height
+	if loop.isFor {
+		height--
+	}
+	for _, at := range loop.execs {
+		c.code.loops[at] = loopTargets{breakTo: end, continueTo: back, height: height}
+	}
+}
+
+// assignable indica si el destino se puede compilar
+func assignable(target *models.ASTNode) bool {
+	switch target.Type {
+	case "Identifier", "Subscript", "Attribute":
+		return true
+	case "Tuple", "List":
+		for _, child := range target.Children {
+			if !assignable(child) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// assign guarda el valor de la cima de la pila en el destino
+func (c *compiler) assign(target *models.ASTNode) {
+	switch target.Type {
+	case "Identifier":
+		c.store(target.Value)
+	case "Tuple", "List":
+		c.emit(opUnpackSequence, len(target.Children))
+		for _, child := range target.Children {
+			c.assign(child)
+		}
+	case "Subscript":
+		c.expression(target.Children[0])
+		c.index(target.Children[1])
+		c.emit(opStoreSubscr, 0)
+	case "Attribute":
+		c.expression(target.Children[0])
+		c.emit(opStoreAttr, c.name(target.Value))
+	}
+}
+
+// augAssign evalúa el destino una sola vez, como el intérprete
+func (c *compiler) augAssign(node *models.ASTNode) bool {
+	target := node.Children[0]
+	op := binaryOperator(node.Value)
+	if op < 0 {
+		return false
+	}
+	switch target.Type {
+	case "Identifier":
+		c.load(target.Value)
+		c.expression(node.Children[1])
+		c.emit(opBinaryOp, op)
+		c.store(target.Value)
+	case "Subscript":
+		c.expression(target.Children[0])
+		c.index(target.Children[1])
+		c.emit(opCopy, 2)
+		c.emit(opCopy, 2)
+		c.emit(opBinarySubscr, 0)
+		c.expression(node.Children[1])
+		c.emit(opBinaryOp, op)
+		c.emit(opSwap, 3)
+		c.emit(opSwap, 2)
+		c.emit(opStoreSubscr, 0)
+	case "Attribute":
+		c.expression(target.Children[0])
+		c.emit(opCopy, 1)
+		c.emit(opLoadAttr, c.name(target.Value))
+		c.expression(node.Children[1])
+		c.emit(opBinaryOp, op)
+		c.emit(opSwap, 2)
+		c.emit(opStoreAttr, c.name(target.Value))
+	default:
+		return false
+	}
+	return true
+}
+
+// folded calcula las expresiones formadas solo por literales, como el
+// optimizador de CPython. Las que lanzan una excepción se dejan para la
+// ejecución.
+func folded(node *models.ASTNode) (constValue, bool) {
+	switch node.Type {
+	case "Number", "String", "Boolean", "None":
+		return literalValue(node)
+	case "UnaryOp":
+		if node.Value == "not" {
+			return constValue{}, false
+		}
+		operand, ok := folded(node.Children[0])
+		if !ok {
+			return constValue{}, false
+		}
+		value, err := constUnary(node.Value, operand)
+		return value, err == nil && smallConstant(value)
+	case "BinaryOp":
+		switch node.Value {
+		case "and", "or", "is", "is not", "in", "not in":
+			return constValue{}, false
+		}
+		left, ok := folded(node.Children[0])
+		if !ok {
+			return constValue{}, false
+		}
+		right, ok := folded(node.Children[1])
+		if !ok {
+			return constValue{}, false
+		}
+		value, err := constBinary(node.Value, left, right)
+		return value, err == nil && smallConstant(value)
+	}
+	return constValue{}, false
+}
+
+// smallConstant descarta los resultados que el intérprete contabiliza como
+// memoria asignada (ver allocateValue), para que ambos motores midan lo mismo
+func smallConstant(value constValue) bool {
+	switch value.kind {
+	case "str":
+		return len(value.s) <= 16
+	case "int":
+		return value.i.BitLen() <= 64
+	}
+	return true
+}
+
+func (c *compiler) expression(node *models.ASTNode) {
+	outer := c.source
+	c.source = node
+	defer func() { c.source = outer }()
+
+	if value, ok := folded(node); ok {
+		c.emit(opLoadConst, c.constant(value))
+		return
+	}
+
+	switch node.Type {
+	case "Identifier":
+		c.load(node.Value)
+
+	case "BinaryOp":
+		if node.Value == "and" || node.Value == "or" {
+			c.expression(node.Children[0])
+			op := opJumpIfFalseOrPop
+			if node.Value == "or" {
+				op = opJumpIfTrueOrPop
+			}
+			skip := c.emit(op, 0)
+			c.expression(node.Children[1])
+			c.patch(skip)
+			return
+		}
+		op := binaryOperator(node.Value)
+		if op < 0 {
+			c.evalNode(node)
+			return
+		}
+		c.expression(node.Children[0])
+		c.expression(node.Children[1])
+		if isComparison(node.Value) {
+			c.emit(opCompareOp, op)
+		} else {
+			c.emit(opBinaryOp, op)
+		}
+
+	case "UnaryOp":
+		op, ok := unaryOpcodes[node.Value]
+		if !ok {
+			c.evalNode(node)
+			return
+		}
+		c.expression(node.Children[0])
+		c.emit(op, 0)
+
+	case "IfExpression":
+		c.expression(node.Children[0])
+		skip := c.emit(opPopJumpIfFalse, 0)
+		c.expression(node.Children[1])
+		end := c.emit(opJumpForward, 0)
+		c.patch(skip)
+		c.expression(node.Children[2])
+		c.patch(end)
+
+	case "List", "Tuple", "Set":
+		for _, child := range node.Children {
+			c.expression(child)
+		}
+		op := map[string]opcode{"List": opBuildList, "Tuple": opBuildTuple, "Set": opBuildSet}[node.Type]
+		c.emit(op, len(node.Children))
+
+	case "Dict":
+		for _, child := range node.Children {
+			c.expression(child)
+		}
+		c.emit(opBuildMap, len(node.Children)/2)
+
+	case "FunctionCall":
+		parts := strings.Split(node.Value, ".")
+		c.load(parts[0])
+		for _, attr := range parts[1:] {
+			c.emit(opLoadAttr, c.name(attr))
+		}
+		c.arguments(node.Children)
+
+	case "MethodCall":
+		c.expression(node.Children[0])
+		c.arguments(node.Children[1:])
+
+	case "Attribute":
+		c.expression(node.Children[0])
+		c.emit(opLoadAttr, c.name(node.Value))
+
+	case "Subscript":
+		c.expression(node.Children[0])
+		c.index(node.Children[1])
+		c.emit(opBinarySubscr, 0)
+
+	case "Lambda":
+		c.emit(opMakeFunction, c.node(node))
+
+	default:
+		c.evalNode(node)
+	}
+}
+
+// evalNode evalúa la expresión con el intérprete de árbol
+func (c *compiler) evalNode(node *models.ASTNode) {
+	c.emit(opEvalNode, c.node(node))
+	c.code.fallbacks = append(c.code.fallbacks, node)
+}
+
+func (c *compiler) index(node *models.ASTNode) {
+	if node.Type != "Slice" {
+		c.expression(node)
+		return
+	}
+	for _, child := range node.Children {
+		c.expression(child)
+	}
+	for i := len(node.Children); i < 3; i++ {
+		c.emit(opLoadConst, c.constant(noneValue()))
+	}
+	c.emit(opBuildSlice, 3)
+}
+
+// arguments compila los argumentos y la llamada. Los argumentos por nombre van
+// al final y KW_NAMES indica sus nombres.
+func (c *compiler) arguments(args []*models.ASTNode) {
+	names := make([]string, 0)
+	for _, arg := range args {
+		if arg.Type == "Keyword" {
+			continue
+		}
+		c.expression(arg)
+	}
+	for _, arg := range args {
+		if arg.Type == "Keyword" {
+			c.expression(arg.Children[0])
+			names = append(names, arg.Value)
+		}
+	}
+	if len(names) > 0 {
+		c.code.kwnames = append(c.code.kwnames, names)
+		c.emit(opKwNames, len(c.code.kwnames)-1)
+	}
+	c.emit(opCall, len(args))
+}
+
+// argumentText describe el argumento de la instrucción para el desensamblado
+func (code *codeObject) argumentText(instr instruction) string {
+	switch instr.op {
+	case opLoadConst:
+		return code.constText(instr.arg)
+	case opLoadFast, opStoreFast, opLoadGlobal, opStoreGlobal, opLoadName, opStoreName,
+		opLoadDeref, opStoreDeref, opLoadAttr, opStoreAttr:
+		return code.names[instr.arg]
+	case opBinaryOp, opCompareOp:
+		return binaryOperators[instr.arg]
+	case opKwNames:
+		return "(" + strings.Join(code.kwnames[instr.arg], ", ") + ")"
+	case opMakeFunction, opExecNode, opEvalNode:
+		node := code.nodes[instr.arg]
+		if node.Type == "FunctionDef" {
+			return "<code " + node.Value + ">"
+		}
+		if node.Type == "Lambda" {
+			return "<code <lambda>>"
+		}
+		return fmt.Sprintf("%s: %s", node.Type, shorten(formatSource(node), 40))
+	}
+	if instr.op.isJump() {
+		return fmt.Sprintf("to %d", instr.arg)
+	}
+	return ""
+}
+
+func (code *codeObject) constText(index int) string {
+	if value, ok := code.consts[index].(constValue); ok {
+		return value.Repr()
+	}
+	return fmt.Sprintf("<%s>", typeName(code.consts[index]))
+}
+
+func shorten(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit-1]) + "…"
+	}
+	return text
+}
+
+// Disassemble exporta el código del módulo y de cada función y lambda, en el
+// orden del programa, con un listado al estilo del módulo dis de CPython
+func (p *BytecodeProgram) Disassemble() []models.CodeObject {
+	return p.disassemble(nil)
+}
+
+// disassemble exporta el código; con locate cada instrucción lleva la
+// posición de la sentencia o expresión que la generó
+func (p *BytecodeProgram) disassemble(locate nodeLocator) []models.CodeObject {
+	codes := make([]models.CodeObject, 0, len(p.order))
+	for _, code := range p.order {
+		codes = append(codes, code.export(locate))
+	}
+	return codes
+}
+
+func (code *codeObject) export(locate nodeLocator) models.CodeObject {
+	exported := models.CodeObject{
+		Name:         code.name,
+		ArgCount:     code.argCount,
+		Names:        append([]string{}, code.names...),
+		Constants:    make([]string, 0, len(code.consts)),
+		Instructions: make([]models.BytecodeInstruction, 0, len(code.code)),
+		Fallbacks:    make([]models.BytecodeFallback, 0, len(code.fallbacks)),
+		Range:        locate.find(code.node),
+	}
+	if code.node != nil && code.node.Type != "Program" {
+		exported.Line = code.node.Line
+	}
+	for i := range code.consts {
+		exported.Constants = append(exported.Constants, code.constText(i))
+	}
+	for _, node := range code.fallbacks {
+		exported.Fallbacks = append(exported.Fallbacks, models.BytecodeFallback{
+			Line:   node.Line,
+			Type:   node.Type,
+			Source: shorten(formatSource(node), 60),
+			Range:  locate.find(node),
+		})
+	}
+
+	targets := make(map[int]bool)
+	for _, instr := range code.code {
+		if instr.op.isJump() {
+			targets[instr.arg] = true
+		}
+	}
+	for offset, instr := range code.code {
+		item := models.BytecodeInstruction{
+			Offset:     offset,
+			Line:       instr.line,
+			Opcode:     instr.op.String(),
+			ArgRepr:    code.argumentText(instr),
+			JumpTarget: targets[offset],
+			Statement:  instr.start,
+			Range:      locate.find(instr.source),
+		}
+		if instr.op.hasArgument() {
+			arg := instr.arg
+			item.Arg = &arg
+		}
+		exported.Instructions = append(exported.Instructions, item)
+	}
+	exported.Text = disassemblyText(exported)
+	return exported
+}
+
+// disassemblyText imita la salida de dis.dis: la línea fuente al comenzar
+// cada línea, ">>" en los destinos de salto, el desplazamiento, la
+// instrucción, su argumento y entre paréntesis su significado
+func disassemblyText(code models.CodeObject) string {
+	var out strings.Builder
+	if code.Name == "<module>" {
+		out.WriteString("Disassembly of <module>:\n")
+	} else {
+		fmt.Fprintf(&out, "Disassembly of <code object %s, line %d>:\n", code.Name, code.Line)
+	}
+	line := -1
+	for _, instr := range code.Instructions {
+		lineText := ""
+		if instr.Line != line {
+			if line != -1 {
+				out.WriteString("\n")
+			}
+			line = instr.Line
+			lineText = fmt.Sprint(line)
+		}
+		marker := ""
+		if instr.JumpTarget {
+			marker = ">>"
+		}
+		text := fmt.Sprintf("%4s %6s %4d %-20s", lineText, marker, instr.Offset, instr.Opcode)
+		if instr.Arg != nil {
+			text += fmt.Sprintf(" %4d", *instr.Arg)
+			if instr.ArgRepr != "" {
+				text += " (" + instr.ArgRepr + ")"
+			}
+		}
+		out.WriteString(strings.TrimRight(text, " ") + "\n")
+	}
+	return out.String()
+}
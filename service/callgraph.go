@@ -0,0 +1,154 @@
+package service
+
+import (
+	"strings"
+
+	"examen-back/models"
+)
+
+// callSite es una llamada a una función definida en el programa. caller es nil
+// para las llamadas hechas a nivel de módulo.
+type callSite struct {
+	caller *models.ASTNode
+	callee *models.ASTNode
+	call   *models.ASTNode
+}
+
+// callGraph relaciona cada FunctionDef con las funciones del programa que llama.
+// Las llamadas dentro de lambdas y comprensiones cuentan para la función que las contiene.
+type callGraph struct {
+	functions []*models.ASTNode
+	names     map[*models.ASTNode]string
+	calls     map[*models.ASTNode][]callSite
+	module    []callSite
+}
+
+func buildCallGraph(ast *models.ASTNode, tree *scopeTree) *callGraph {
+	graph := &callGraph{
+		names: make(map[*models.ASTNode]string),
+		calls: make(map[*models.ASTNode][]callSite),
+	}
+	if ast == nil {
+		return graph
+	}
+
+	var visit func(node, caller *models.ASTNode)
+	visit = func(node, caller *models.ASTNode) {
+		if node == nil {
+			return
+		}
+
+		switch node.Type {
+		case "FunctionDef":
+			// Los valores por defecto se evalúan al definir la función
+			params, body := functionParts(node)
+			for _, param := range params {
+				visit(param, caller)
+			}
+			graph.functions = append(graph.functions, node)
+			graph.names[node] = functionName(tree, node)
+			for _, stmt := range body {
+				visit(stmt, node)
+			}
+			return
+
+		case "FunctionCall":
+			if symbol := tree.resolved[node]; symbol != nil && symbol.kind == "function" && symbol.node != nil && !strings.Contains(node.Value, ".") {
+				site := callSite{caller: caller, callee: symbol.node, call: node}
+				if caller == nil {
+					graph.module = append(graph.module, site)
+				} else {
+					graph.calls[caller] = append(graph.calls[caller], site)
+				}
+			}
+		}
+
+		for _, child := range node.Children {
+			visit(child, caller)
+		}
+	}
+	visit(ast, nil)
+
+	return graph
+}
+
+// functionName devuelve el nombre calificado de una función ("outer.inner", "Clase.metodo")
+func functionName(tree *scopeTree, node *models.ASTNode) string {
+	if sc := tree.byNode[node]; sc != nil {
+		return strings.TrimPrefix(sc.name, tree.module.name+".")
+	}
+	return node.Value
+}
+
+// stronglyConnected agrupa las funciones en componentes fuertemente conexas
+// (algoritmo de Tarjan). Una componente con más de una función es recursión mutua.
+func (g *callGraph) stronglyConnected() [][]*models.ASTNode {
+	index := make(map[*models.ASTNode]int)
+	low := make(map[*models.ASTNode]int)
+	onStack := make(map[*models.ASTNode]bool)
+	stack := make([]*models.ASTNode, 0)
+	components := make([][]*models.ASTNode, 0)
+	counter := 0
+
+	var connect func(fn *models.ASTNode)
+	connect = func(fn *models.ASTNode) {
+		index[fn] = counter
+		low[fn] = counter
+		counter++
+		stack = append(stack, fn)
+		onStack[fn] = true
+
+		for _, site := range g.calls[fn] {
+			if _, seen := index[site.callee]; !seen {
+				connect(site.callee)
+				low[fn] = min(low[fn], low[site.callee])
+			} else if onStack[site.callee] {
+				low[fn] = min(low[fn], index[site.callee])
+			}
+		}
+
+		if low[fn] == index[fn] {
+			component := make([]*models.ASTNode, 0)
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == fn {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, fn := range g.functions {
+		if _, seen := index[fn]; !seen {
+			connect(fn)
+		}
+	}
+
+	return components
+}
+
+func (g *callGraph) callsItself(fn *models.ASTNode) bool {
+	for _, site := range g.calls[fn] {
+		if site.callee == fn {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *callGraph) edges() []models.CallEdge {
+	edges := make([]models.CallEdge, 0)
+	for _, site := range g.module {
+		edges = append(edges, models.CallEdge{Caller: "<module>", Callee: g.names[site.callee], Line: site.call.Line})
+	}
+	for _, fn := range g.functions {
+		for _, site := range g.calls[fn] {
+			edges = append(edges, models.CallEdge{Caller: g.names[fn], Callee: g.names[site.callee], Line: site.call.Line})
+		}
+	}
+	return edges
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// Límites del registro de llamadas. Las llamadas que superan maxCalls se
+// ejecutan igual pero no se registran; los diagramas muestran como mucho
+// MaxDiagramCalls llamadas.
+const (
+	DefaultTraceCalls = 1000
+	MaxTraceCalls     = 5000
+	MaxDiagramCalls   = 200
+	maxTraceValue     = 60
+)
+
+// callTracer registra las llamadas a las funciones seleccionadas mientras el
+// intérprete ejecuta el programa
+type callTracer struct {
+	nodes     map[*models.ASTNode]bool
+	names     map[string]bool
+	functions []string
+	maxCalls  int
+	active    bool
+
+	roots     []*models.CallNode
+	stack     []*models.CallNode
+	timeline  []models.DepthSample
+	total     int
+	recorded  int
+	maxDepth  int
+	truncated bool
+}
+
+// newCallTracer prepara el registro de las funciones nombradas o, si no se
+// indica ninguna, de las funciones recursivas del programa (de todas si no
+// hay recursión)
+func newCallTracer(ast *models.ASTNode, functions []string, maxCalls int) (*callTracer, error) {
+	if maxCalls <= 0 {
+		maxCalls = DefaultTraceCalls
+	}
+	t := &callTracer{
+		nodes:     make(map[*models.ASTNode]bool),
+		names:     make(map[string]bool),
+		functions: make([]string, 0),
+		maxCalls:  min(maxCalls, MaxTraceCalls),
+		active:    true,
+	}
+
+	for _, name := range functions {
+		short := name[strings.LastIndex(name, ".")+1:]
+		if findNode(ast, func(node *models.ASTNode) bool {
+			return node.Type == "FunctionDef" && node.Value == short
+		}) == nil {
+			return nil, fmt.Errorf("no se encontró la función '%s'", name)
+		}
+		if !t.names[name] {
+			t.names[name] = true
+			t.functions = append(t.functions, name)
+		}
+	}
+	if len(functions) > 0 {
+		return t, nil
+	}
+
+	tree := buildScopeTree(ast)
+	for _, function := range analyzeRecursion(buildCallGraph(ast, tree)).functions {
+		t.nodes[function.node] = true
+		t.functions = append(t.functions, function.name)
+	}
+	if len(t.nodes) == 0 {
+		walkNodes(ast, func(node *models.ASTNode) {
+			if node.Type == "FunctionDef" {
+				t.nodes[node] = true
+				t.functions = append(t.functions, node.Value)
+			}
+		})
+	}
+	return t, nil
+}
+
+// walkNodes recorre el árbol en preorden
+func walkNodes(node *models.ASTNode, visit func(*models.ASTNode)) {
+	if node == nil {
+		return
+	}
+	visit(node)
+	for _, child := range node.Children {
+		walkNodes(child, visit)
+	}
+}
+
+func (t *callTracer) traces(fn *pyFunction) bool {
+	if len(t.names) > 0 {
+		return t.names[fn.name] || t.names[fn.qualname]
+	}
+	return t.nodes[fn.node]
+}
+
+// enter registra el comienzo de una llamada, antes de crear su frame.
+// Devuelve nil si la llamada no se registra.
+func (t *callTracer) enter(in *Interpreter, fn *pyFunction, args []pyValue, kwargs []keywordArg) *models.CallNode {
+	if !t.active || !t.traces(fn) {
+		return nil
+	}
+	t.total++
+	if t.recorded >= t.maxCalls {
+		t.truncated = true
+		return nil
+	}
+
+	node := t.newNode(in, fn, args, kwargs)
+	node.StartStep = in.steps
+	t.timeline = append(t.timeline, models.DepthSample{Step: in.steps, Depth: node.Depth, Event: "call", Call: node.ID})
+	t.stack = append(t.stack, node)
+	return node
+}
+
+// exit completa la llamada con su valor o su error
+func (t *callTracer) exit(in *Interpreter, node *models.CallNode, value pyValue, err error) {
+	if node == nil {
+		return
+	}
+	t.stack = t.stack[:len(t.stack)-1]
+	node.EndStep = in.steps
+
+	event := "return"
+	if err != nil {
+		event = "raise"
+		node.Exception = t.describeError(in, err)
+	} else {
+		node.Return = t.valueText(in, value)
+	}
+	t.timeline = append(t.timeline, models.DepthSample{Step: in.steps, Depth: node.Depth - 1, Event: event, Call: node.ID})
+}
+
+// cached registra una llamada que functools.lru_cache resolvió sin ejecutar la función
+func (t *callTracer) cached(in *Interpreter, fn *pyFunction, args []pyValue, kwargs []keywordArg, value pyValue) {
+	if !t.active || !t.traces(fn) {
+		return
+	}
+	t.total++
+	if t.recorded >= t.maxCalls {
+		t.truncated = true
+		return
+	}
+
+	node := t.newNode(in, fn, args, kwargs)
+	node.Cached = true
+	node.Return = t.valueText(in, value)
+	node.StartStep, node.EndStep = in.steps, in.steps
+}
+
+// newNode crea el nodo y lo cuelga de la llamada registrada en curso
+func (t *callTracer) newNode(in *Interpreter, fn *pyFunction, args []pyValue, kwargs []keywordArg) *models.CallNode {
+	t.recorded++
+	node := &models.CallNode{
+		ID:        t.recorded,
+		Function:  fn.qualname,
+		Arguments: t.arguments(in, fn, args, kwargs),
+		Line:      in.frame.line,
+		Depth:     in.depth + 1,
+		Children:  make([]*models.CallNode, 0),
+	}
+	if fn.node.Type == "Lambda" {
+		node.Function = "<lambda>"
+	}
+
+	parts := make([]string, len(node.Arguments))
+	for i, argument := range node.Arguments {
+		parts[i] = argument.Value
+		if argument.Name != "" && i >= len(args) {
+			parts[i] = argument.Name + "=" + argument.Value
+		}
+	}
+	node.Label = fmt.Sprintf("%s(%s)", fn.name, strings.Join(parts, ", "))
+
+	if len(t.stack) > 0 {
+		parent := t.stack[len(t.stack)-1]
+		parent.Children = append(parent.Children, node)
+	} else {
+		t.roots = append(t.roots, node)
+	}
+	t.maxDepth = max(t.maxDepth, node.Depth)
+	return node
+}
+
+// arguments nombra los argumentos posicionales con los parámetros de la
+// función. En los métodos se omite self.
+func (t *callTracer) arguments(in *Interpreter, fn *pyFunction, args []pyValue, kwargs []keywordArg) []models.CallArgument {
+	params, _ := functionParts(fn.node)
+	if fn.class != nil && len(args) > 0 && len(params) > 0 {
+		args, params = args[1:], params[1:]
+	}
+
+	arguments := make([]models.CallArgument, 0, len(args)+len(kwargs))
+	for i, arg := range args {
+		name := ""
+		if i < len(params) && !strings.HasPrefix(params[i].Value, "*") {
+			name = params[i].Value
+		}
+		arguments = append(arguments, models.CallArgument{Name: name, Value: t.valueText(in, arg)})
+	}
+	for _, kwarg := range kwargs {
+		arguments = append(arguments, models.CallArgument{Name: kwarg.name, Value: t.valueText(in, kwarg.value)})
+	}
+	return arguments
+}
+
+// valueText es el repr del valor, acortado. Durante el repr se suspende el
+// registro para no anotar las llamadas a __repr__.
+func (t *callTracer) valueText(in *Interpreter, value pyValue) string {
+	in.tracer = nil
+	text, err := in.repr(value)
+	in.tracer = t
+	if err != nil {
+		return fmt.Sprintf("<%s>", typeName(value))
+	}
+	if runes := []rune(text); len(runes) > maxTraceValue {
+		text = string(runes[:maxTraceValue-1]) + "…"
+	}
+	return text
+}
+
+func (t *callTracer) describeError(in *Interpreter, err error) string {
+	switch e := err.(type) {
+	case *pyException:
+		in.tracer = nil
+		message, strErr := in.exceptionString(e.value)
+		in.tracer = t
+		if strErr != nil || message == "" {
+			return e.value.class.name
+		}
+		return e.value.class.name + ": " + message
+	case *limitError:
+		return e.kind
+	}
+	return err.Error()
+}
+
+// export devuelve el árbol de llamadas con su línea de tiempo y los diagramas
+func (t *callTracer) export() models.CallTrace {
+	trace := models.CallTrace{
+		Calls:      t.roots,
+		TotalCalls: t.total,
+		Recorded:   t.recorded,
+		MaxDepth:   t.maxDepth,
+		Truncated:  t.truncated,
+		Functions:  t.functions,
+		Timeline:   t.timeline,
+	}
+	if trace.Calls == nil {
+		trace.Calls = make([]*models.CallNode, 0)
+	}
+	if trace.Timeline == nil {
+		trace.Timeline = make([]models.DepthSample, 0)
+	}
+	trace.Text = renderCallText(trace.Calls)
+	trace.Dot = renderCallDot(trace)
+	trace.Mermaid = renderCallMermaid(trace)
+	return trace
+}
+
+// callOutcome es el texto del valor que vuelve de la llamada
+func callOutcome(call *models.CallNode) string {
+	switch {
+	case call.Exception != "":
+		return "raise " + call.Exception
+	case call.Cached:
+		return call.Return + " (caché)"
+	}
+	return call.Return
+}
+
+// renderCallText muestra el árbol indentado: "factorial(4) → 24"
+func renderCallText(calls []*models.CallNode) string {
+	var text strings.Builder
+	var visit func(call *models.CallNode, indent int)
+	visit = func(call *models.CallNode, indent int) {
+		fmt.Fprintf(&text, "%s%s → %s\n", strings.Repeat("  ", indent), call.Label, callOutcome(call))
+		for _, child := range call.Children {
+			visit(child, indent+1)
+		}
+	}
+	for _, call := range calls {
+		visit(call, 0)
+	}
+	return text.String()
+}
+
+// diagramEdges recorre las llamadas que entran en el diagrama: cada una con su
+// llamador (0 para el programa)
+func diagramEdges(calls []*models.CallNode, visit func(call *models.CallNode, caller int)) {
+	var walk func(call *models.CallNode, caller int)
+	walk = func(call *models.CallNode, caller int) {
+		if call.ID > MaxDiagramCalls {
+			return
+		}
+		visit(call, caller)
+		for _, child := range call.Children {
+			walk(child, call.ID)
+		}
+	}
+	for _, call := range calls {
+		walk(call, 0)
+	}
+}
+
+// renderCallDot genera el árbol en Graphviz DOT: las flechas continuas son
+// las llamadas y las discontinuas los valores que vuelven al llamador
+func renderCallDot(trace models.CallTrace) string {
+	var dot strings.Builder
+	dot.WriteString("digraph calls {\n")
+	dot.WriteString("\tnode [shape=box, fontname=\"Courier\"];\n")
+	dot.WriteString("\tC0 [label=\"programa\", shape=ellipse];\n")
+	if trace.Recorded > MaxDiagramCalls {
+		fmt.Fprintf(&dot, "\t// se muestran %d de %d llamadas\n", MaxDiagramCalls, trace.Recorded)
+	}
+
+	diagramEdges(trace.Calls, func(call *models.CallNode, caller int) {
+		attributes := fmt.Sprintf("label=%s", dotQuote(call.Label))
+		switch {
+		case call.Exception != "":
+			attributes += ", color=\"firebrick\""
+		case call.Cached:
+			attributes += ", style=dashed, color=\"gray40\""
+		}
+		fmt.Fprintf(&dot, "\tC%d [%s];\n", call.ID, attributes)
+		fmt.Fprintf(&dot, "\tC%d -> C%d;\n", caller, call.ID)
+
+		style := `style=dashed, color="royalblue"`
+		if call.Exception != "" {
+			style = `style=dashed, color="firebrick"`
+		}
+		fmt.Fprintf(&dot, "\tC%d -> C%d [label=%s, %s];\n", call.ID, caller, dotQuote(callOutcome(call)), style)
+	})
+
+	dot.WriteString("}\n")
+	return dot.String()
+}
+
+// renderCallMermaid genera el mismo árbol como diagrama de flujo de Mermaid
+func renderCallMermaid(trace models.CallTrace) string {
+	var mermaid strings.Builder
+	mermaid.WriteString("flowchart TD\n")
+	mermaid.WriteString("\tC0([\"programa\"])\n")
+	if trace.Recorded > MaxDiagramCalls {
+		fmt.Fprintf(&mermaid, "\t%%%% se muestran %d de %d llamadas\n", MaxDiagramCalls, trace.Recorded)
+	}
+
+	diagramEdges(trace.Calls, func(call *models.CallNode, caller int) {
+		fmt.Fprintf(&mermaid, "\tC%d[%s]\n", call.ID, mermaidQuote(call.Label))
+		fmt.Fprintf(&mermaid, "\tC%d --> C%d\n", caller, call.ID)
+		fmt.Fprintf(&mermaid, "\tC%d -.->|%s| C%d\n", call.ID, mermaidQuote(callOutcome(call)), caller)
+		switch {
+		case call.Exception != "":
+			fmt.Fprintf(&mermaid, "\tstyle C%d stroke:#b22222\n", call.ID)
+		case call.Cached:
+			fmt.Fprintf(&mermaid, "\tstyle C%d stroke-dasharray:4\n", call.ID)
+		}
+	})
+	return mermaid.String()
+}
+
+func mermaidQuote(text string) string {
+	return "\"" + strings.NewReplacer("\"", "#quot;", "\n", " ").Replace(text) + "\""
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"examen-back/models"
+)
+
+// traceCalls ejecuta el programa registrando las llamadas
+func traceCalls(t *testing.T, code, call string, functions []string, maxCalls int) (models.RunResult, models.CallTrace) {
+	t.Helper()
+	syntax := parse(code)
+	if !syntax.Valid {
+		t.Fatalf("errores de sintaxis: %v", syntax.Errors)
+	}
+	var node *models.ASTNode
+	if call != "" {
+		parsed, err := ParseRunCall(call)
+		if err != nil {
+			t.Fatalf("llamada inválida: %v", err)
+		}
+		node = parsed
+	}
+	result, trace, err := NewInterpreter(syntax.AST, NormalizeRunLimits(nil)).Trace("", node, functions, maxCalls)
+	if err != nil {
+		t.Fatalf("no se pudo registrar las llamadas: %v", err)
+	}
+	return result, trace
+}
+
+const tracedFactorial = "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\n"
+
+func TestCallTraceTree(t *testing.T) {
+	result, trace := traceCalls(t, tracedFactorial+"print(factorial(3))\n", "", nil, 0)
+	if result.Stdout != "6\n" || result.Error != nil {
+		t.Fatalf("la ejecución registrada debe terminar igual: %q %v", result.Stdout, result.Error)
+	}
+	if trace.TotalCalls != 3 || trace.Recorded != 3 || trace.MaxDepth != 4 || trace.Truncated || len(trace.Functions) != 1 {
+		t.Fatalf("resumen inesperado: %+v", trace)
+	}
+
+	labels := make([]string, 0)
+	for call := trace.Calls[0]; call != nil; {
+		labels = append(labels, call.Label+" → "+call.Return)
+		if call.Arguments[0].Name != "n" {
+			t.Errorf("el argumento debe llevar el nombre del parámetro: %+v", call.Arguments)
+		}
+		if len(call.Children) == 0 {
+			break
+		}
+		call = call.Children[0]
+	}
+	if got := strings.Join(labels, ", "); got != "factorial(3) → 6, factorial(2) → 2, factorial(1) → 1" {
+		t.Errorf("árbol de llamadas inesperado: %s", got)
+	}
+	if trace.Text != "factorial(3) → 6\n  factorial(2) → 2\n    factorial(1) → 1\n" {
+		t.Errorf("texto inesperado:\n%s", trace.Text)
+	}
+	for _, fragment := range []string{"C1 -> C2;", "C2 -> C1 [label=\"2\""} {
+		if !strings.Contains(trace.Dot, fragment) {
+			t.Errorf("el DOT no contiene %q:\n%s", fragment, trace.Dot)
+		}
+	}
+	for _, fragment := range []string{"flowchart TD", "C1 --> C2", "C2 -.->|\"2\"| C1"} {
+		if !strings.Contains(trace.Mermaid, fragment) {
+			t.Errorf("el Mermaid no contiene %q:\n%s", fragment, trace.Mermaid)
+		}
+	}
+}
+
+func TestCallTraceTimeline(t *testing.T) {
+	_, trace := traceCalls(t, tracedFactorial+"print(factorial(3))\n", "", nil, 0)
+	events := make([]string, 0)
+	depths := make([]int, 0)
+	for i, sample := range trace.Timeline {
+		events = append(events, sample.Event)
+		depths = append(depths, sample.Depth)
+		if i > 0 && sample.Step < trace.Timeline[i-1].Step {
+			t.Errorf("la línea de tiempo debe avanzar: %+v", trace.Timeline)
+		}
+	}
+	if strings.Join(events, " ") != "call call call return return return" || depths[2] != 4 || depths[5] != 1 {
+		t.Errorf("línea de tiempo inesperada: %+v", trace.Timeline)
+	}
+}
+
+func TestCallTraceExceptionsAndTruncation(t *testing.T) {
+	const code = "def f(n):\n    if n == 0:\n        raise ValueError('x')\n    return f(n - 1)\n\ntry:\n    f(2)\nexcept ValueError:\n    print('ok')\n"
+	result, trace := traceCalls(t, code, "", nil, 2)
+	if result.Stdout != "ok\n" {
+		t.Fatalf("salida inesperada: %q", result.Stdout)
+	}
+	if !trace.Truncated || trace.Recorded != 2 || trace.TotalCalls != 3 {
+		t.Errorf("se esperaban 2 de 3 llamadas registradas: %+v", trace)
+	}
+	if call := trace.Calls[0]; call.Exception != "ValueError: x" || call.Return != "" {
+		t.Errorf("la llamada terminó con una excepción: %+v", call)
+	}
+	if last := trace.Timeline[len(trace.Timeline)-1]; last.Event != "raise" || last.Depth != 1 {
+		t.Errorf("la pila debe vaciarse por la excepción: %+v", last)
+	}
+}
+
+func TestCallTraceSelection(t *testing.T) {
+	const code = "def double(x):\n    return 2 * x\n\n" + tracedFactorial + "print(double(factorial(2)))\n"
+
+	_, trace := traceCalls(t, code, "", nil, 0)
+	if len(trace.Functions) != 1 || trace.Functions[0] != "factorial" {
+		t.Errorf("sin 'functions' solo se registran las funciones recursivas: %v", trace.Functions)
+	}
+
+	_, trace = traceCalls(t, code, "double(5)", []string{"double"}, 0)
+	if trace.TotalCalls != 1 || trace.Calls[0].Label != "double(5)" || trace.Calls[0].Return != "10" {
+		t.Errorf("con una llamada se registra solo esa ejecución: %+v", trace.Calls)
+	}
+
+	syntax := parse(code)
+	if _, _, err := NewInterpreter(syntax.AST, NormalizeRunLimits(nil)).Trace("", nil, []string{"triple"}, 0); err == nil || !strings.Contains(err.Error(), "'triple'") {
+		t.Errorf("una función inexistente debe ser un error: %v", err)
+	}
+}
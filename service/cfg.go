@@ -0,0 +1,505 @@
+package service
+
+import "examen-back/models"
+
+// basicBlock agrupa pasos que se ejecutan en secuencia. Un paso es una sentencia
+// simple, la condición de un if/while, el iterable de un for o, al inicio del
+// cuerpo de un for, el propio ForStatement (que asigna el objetivo del bucle).
+// Los bloques de un except empiezan con el ExceptHandler, que liga el nombre de 'as'.
+type basicBlock struct {
+	id    int
+	label string
+	steps []*models.ASTNode
+	succs []cfgEdge
+	preds []*basicBlock
+	// cause es la sentencia que dejó inalcanzable a un bloque "unreachable"
+	cause *models.ASTNode
+}
+
+// cfgEdge etiqueta la transición: normal, true, false, loop-back, break, return,
+// exception. Una arista exception puede tomarse antes de ejecutar cualquier paso
+// del bloque de origen.
+type cfgEdge struct {
+	to   *basicBlock
+	kind string
+}
+
+type controlFlowGraph struct {
+	name   string
+	node   *models.ASTNode
+	entry  *basicBlock
+	exit   *basicBlock
+	blocks []*basicBlock
+	// roles indica la sentencia a la que pertenece un paso que no es una
+	// sentencia completa: "if", "while" (condiciones) o "for" (iterable)
+	roles map[*models.ASTNode]string
+}
+
+type loopContext struct {
+	header *basicBlock
+	after  *basicBlock
+	// finallies es la cantidad de finally abiertos al entrar al bucle; los que
+	// se abren después están entre un break o continue y su destino
+	finallies int
+}
+
+type cfgBuilder struct {
+	graph   *controlFlowGraph
+	current *basicBlock
+	loops   []loopContext
+	// raiseTargets y finallies son pilas con el destino de una excepción
+	// (bloque except o finally) y de un return dentro de un try con finally
+	raiseTargets []*basicBlock
+	finallies    []*basicBlock
+	// exitedBy es la última sentencia tras la cual no se puede continuar
+	exitedBy *models.ASTNode
+}
+
+// buildCFG construye el grafo de flujo de control de un cuerpo de sentencias
+func buildCFG(name string, node *models.ASTNode, body []*models.ASTNode) *controlFlowGraph {
+	b := &cfgBuilder{graph: &controlFlowGraph{name: name, node: node, roles: make(map[*models.ASTNode]string)}}
+	b.graph.entry = b.newBlock("entry")
+	b.current = b.graph.entry
+	// La salida se agrega a la lista al final para que quede como último bloque
+	b.graph.exit = &basicBlock{label: "exit"}
+
+	b.visitBody(body)
+
+	if b.current != nil {
+		b.connect(b.current, b.graph.exit, "normal")
+	}
+	b.graph.exit.id = len(b.graph.blocks)
+	b.graph.blocks = append(b.graph.blocks, b.graph.exit)
+
+	return b.graph
+}
+
+// buildFunctionCFGs construye un CFG para el nivel de módulo y uno por cada función
+func buildFunctionCFGs(ast *models.ASTNode) []*controlFlowGraph {
+	graphs := make([]*controlFlowGraph, 0)
+	if ast == nil {
+		return graphs
+	}
+
+	graphs = append(graphs, buildCFG("<module>", ast, ast.Children))
+
+	var visit func(node *models.ASTNode, prefix string)
+	visit = func(node *models.ASTNode, prefix string) {
+		for _, child := range node.Children {
+			if child == nil {
+				continue
+			}
+			switch child.Type {
+			case "FunctionDef":
+				_, body := functionParts(child)
+				name := prefix + child.Value
+				graphs = append(graphs, buildCFG(name, child, body))
+				visit(child, name+".")
+			case "ClassDef":
+				visit(child, prefix+child.Value+".")
+			default:
+				visit(child, prefix)
+			}
+		}
+	}
+	visit(ast, "")
+
+	return graphs
+}
+
+func (b *cfgBuilder) newBlock(label string) *basicBlock {
+	block := &basicBlock{id: len(b.graph.blocks), label: label}
+	b.graph.blocks = append(b.graph.blocks, block)
+	return block
+}
+
+func (b *cfgBuilder) connect(from, to *basicBlock, kind string) {
+	from.succs = append(from.succs, cfgEdge{to: to, kind: kind})
+	to.preds = append(to.preds, from)
+}
+
+// block devuelve el bloque actual; tras un return, break o continue el código
+// siguiente empieza un bloque sin predecesores (inalcanzable)
+func (b *cfgBuilder) block() *basicBlock {
+	if b.current == nil {
+		b.current = b.newBlock("unreachable")
+		b.current.cause = b.exitedBy
+	}
+	return b.current
+}
+
+func (b *cfgBuilder) add(step *models.ASTNode) {
+	block := b.block()
+	block.steps = append(block.steps, step)
+}
+
+// returnTarget es el finally más interno o, si no hay, la salida
+func (b *cfgBuilder) returnTarget() *basicBlock {
+	if len(b.finallies) > 0 {
+		return b.finallies[len(b.finallies)-1]
+	}
+	return b.graph.exit
+}
+
+// loopTarget es el destino de un break o continue: el finally más interno
+// abierto dentro del bucle o, si no hay, la salida o la cabecera del bucle
+func (b *cfgBuilder) loopTarget(isContinue bool) *basicBlock {
+	if len(b.loops) == 0 {
+		return nil
+	}
+	loop := b.loops[len(b.loops)-1]
+	switch {
+	case len(b.finallies) > loop.finallies:
+		return b.finallies[len(b.finallies)-1]
+	case isContinue:
+		return loop.header
+	}
+	return loop.after
+}
+
+// raiseTarget es el except o finally más interno o, si no hay, la salida
+func (b *cfgBuilder) raiseTarget() *basicBlock {
+	if len(b.raiseTargets) > 0 {
+		return b.raiseTargets[len(b.raiseTargets)-1]
+	}
+	return b.graph.exit
+}
+
+func (b *cfgBuilder) visitBody(body []*models.ASTNode) {
+	for _, stmt := range body {
+		b.visitStatement(stmt)
+	}
+}
+
+func (b *cfgBuilder) visitStatement(stmt *models.ASTNode) {
+	if stmt == nil {
+		return
+	}
+
+	switch stmt.Type {
+	case "IfStatement":
+		b.visitIf(stmt)
+
+	case "WhileStatement":
+		b.visitWhile(stmt)
+
+	case "ForStatement":
+		b.visitFor(stmt)
+
+	case "TryStatement":
+		b.visitTry(stmt)
+
+	case "ReturnStatement":
+		b.add(stmt)
+		b.connect(b.current, b.returnTarget(), "return")
+		b.current = nil
+
+	case "RaiseStatement":
+		b.add(stmt)
+		b.connect(b.current, b.raiseTarget(), "exception")
+		b.current = nil
+
+	case "BreakStatement":
+		b.add(stmt)
+		if target := b.loopTarget(false); target != nil {
+			b.connect(b.current, target, "break")
+		}
+		b.current = nil
+
+	case "ContinueStatement":
+		b.add(stmt)
+		if target := b.loopTarget(true); target != nil {
+			b.connect(b.current, target, "loop-back")
+		}
+		b.current = nil
+
+	default:
+		b.add(stmt)
+	}
+
+	if b.current == nil {
+		b.exitedBy = stmt
+	}
+}
+
+func (b *cfgBuilder) visitIf(stmt *models.ASTNode) {
+	b.add(stmt.Children[0])
+	b.graph.roles[stmt.Children[0]] = "if"
+	condition := b.current
+
+	after := b.newBlock("if.end")
+
+	thenBlock := b.newBlock("if.then")
+	b.connect(condition, thenBlock, "true")
+	b.current = thenBlock
+	b.visitBody(stmt.Children[1].Children)
+	if b.current != nil {
+		b.connect(b.current, after, "normal")
+	}
+
+	if len(stmt.Children) > 2 {
+		elseBlock := b.newBlock("if.else")
+		b.connect(condition, elseBlock, "false")
+		b.current = elseBlock
+		b.visitBody(stmt.Children[2].Children)
+		if b.current != nil {
+			b.connect(b.current, after, "normal")
+		}
+	} else {
+		b.connect(condition, after, "false")
+	}
+
+	b.current = b.continueAfter(after)
+}
+
+// continueAfter descarta el bloque de unión si ninguna rama llega a él
+func (b *cfgBuilder) continueAfter(after *basicBlock) *basicBlock {
+	if len(after.preds) > 0 {
+		return after
+	}
+
+	for i, block := range b.graph.blocks {
+		if block == after {
+			b.graph.blocks = append(b.graph.blocks[:i], b.graph.blocks[i+1:]...)
+			break
+		}
+	}
+	for i, block := range b.graph.blocks {
+		block.id = i
+	}
+	return nil
+}
+
+func (b *cfgBuilder) visitWhile(stmt *models.ASTNode) {
+	header := b.newBlock("while.cond")
+	b.connect(b.block(), header, "normal")
+	header.steps = append(header.steps, stmt.Children[0])
+	b.graph.roles[stmt.Children[0]] = "while"
+
+	after := b.newBlock("while.end")
+	body := b.newBlock("while.body")
+	b.connect(header, body, "true")
+
+	// while True: solo se sale con break o return
+	if !isAlwaysTrue(stmt.Children[0]) {
+		b.visitLoopElse(stmt, 2, header, after)
+	}
+
+	b.loops = append(b.loops, loopContext{header: header, after: after, finallies: len(b.finallies)})
+	b.current = body
+	b.visitBody(stmt.Children[1].Children)
+	if b.current != nil {
+		b.connect(b.current, header, "loop-back")
+	}
+	b.loops = b.loops[:len(b.loops)-1]
+
+	b.current = b.continueAfter(after)
+}
+
+func (b *cfgBuilder) visitFor(stmt *models.ASTNode) {
+	// El iterable se evalúa una vez antes del bucle
+	b.add(stmt.Children[1])
+	b.graph.roles[stmt.Children[1]] = "for"
+
+	header := b.newBlock("for.header")
+	b.connect(b.current, header, "normal")
+
+	after := b.newBlock("for.end")
+	body := b.newBlock("for.body")
+	b.connect(header, body, "true")
+	body.steps = append(body.steps, stmt)
+
+	b.visitLoopElse(stmt, 3, header, after)
+
+	b.loops = append(b.loops, loopContext{header: header, after: after, finallies: len(b.finallies)})
+	b.current = body
+	b.visitBody(stmt.Children[2].Children)
+	if b.current != nil {
+		b.connect(b.current, header, "loop-back")
+	}
+	b.loops = b.loops[:len(b.loops)-1]
+
+	b.current = b.continueAfter(after)
+}
+
+// visitLoopElse conecta la salida normal del bucle, pasando por el bloque else si existe
+func (b *cfgBuilder) visitLoopElse(stmt *models.ASTNode, elseIndex int, header, after *basicBlock) {
+	if len(stmt.Children) <= elseIndex {
+		b.connect(header, after, "false")
+		return
+	}
+
+	elseBlock := b.newBlock("loop.else")
+	b.connect(header, elseBlock, "false")
+	b.current = elseBlock
+	b.visitBody(stmt.Children[elseIndex].Children)
+	if b.current != nil {
+		b.connect(b.current, after, "normal")
+	}
+}
+
+// visitTry modela un try: cualquier bloque del cuerpo puede saltar al despacho
+// de excepciones, que prueba cada except en orden. Si ningún except captura
+// todo, la excepción sigue hacia el finally o el try externo. Como hace CPython,
+// el finally se genera dos veces: una para la salida normal y otra para las
+// salidas por return, break, continue o excepción, que después continúan su
+// camino.
+func (b *cfgBuilder) visitTry(stmt *models.ASTNode) {
+	handlers := make([]*models.ASTNode, 0)
+	var elseBody, finallyBody *models.ASTNode
+	for _, child := range stmt.Children[1:] {
+		switch {
+		case child.Type == "ExceptHandler":
+			handlers = append(handlers, child)
+		case child.Value == "else":
+			elseBody = child
+		case child.Value == "finally":
+			finallyBody = child
+		}
+	}
+
+	after := b.newBlock("try.end")
+	join := after
+	var abrupt *basicBlock
+	if finallyBody != nil {
+		join = b.newBlock("try.finally")
+		abrupt = b.newBlock("finally.abrupt")
+		b.finallies = append(b.finallies, abrupt)
+		b.raiseTargets = append(b.raiseTargets, abrupt)
+	}
+
+	var dispatch *basicBlock
+	if len(handlers) > 0 {
+		dispatch = b.newBlock("try.except")
+		b.raiseTargets = append(b.raiseTargets, dispatch)
+	}
+
+	body := b.newBlock("try.body")
+	b.connect(b.block(), body, "normal")
+	first := len(b.graph.blocks) - 1
+	b.current = body
+	b.visitBody(stmt.Children[0].Children)
+
+	if dispatch != nil {
+		for _, block := range b.graph.blocks[first:] {
+			if !block.hasEdgeTo(dispatch) {
+				b.connect(block, dispatch, "exception")
+			}
+		}
+		b.raiseTargets = b.raiseTargets[:len(b.raiseTargets)-1]
+	}
+
+	if b.current != nil && elseBody != nil {
+		elseBlock := b.newBlock("try.else")
+		b.connect(b.current, elseBlock, "normal")
+		b.current = elseBlock
+		b.visitBody(elseBody.Children)
+	}
+	if b.current != nil {
+		b.connect(b.current, join, "normal")
+	}
+
+	catchAll := false
+	for _, handler := range handlers {
+		handlerBlock := b.newBlock("except")
+		b.connect(dispatch, handlerBlock, "exception")
+		handlerBlock.steps = append(handlerBlock.steps, handler)
+		b.current = handlerBlock
+		b.visitBody(handler.Children[len(handler.Children)-1].Children)
+		if b.current != nil {
+			b.connect(b.current, join, "normal")
+		}
+		if len(handler.Children) == 1 {
+			catchAll = true
+		}
+	}
+	if dispatch != nil && !catchAll {
+		b.connect(dispatch, b.raiseTarget(), "exception")
+	}
+
+	if abrupt != nil {
+		b.finallies = b.finallies[:len(b.finallies)-1]
+		b.raiseTargets = b.raiseTargets[:len(b.raiseTargets)-1]
+
+		b.current = b.continueAfter(join)
+		if b.current != nil {
+			b.visitBody(finallyBody.Children)
+			if b.current != nil {
+				b.connect(b.current, after, "normal")
+			}
+		}
+
+		returns, raises := abrupt.hasEdgeFrom("return"), abrupt.hasEdgeFrom("exception")
+		breaks, continues := abrupt.hasEdgeFrom("break"), abrupt.hasEdgeFrom("loop-back")
+		b.current = b.continueAfter(abrupt)
+		if b.current != nil {
+			b.visitBody(finallyBody.Children)
+			if b.current != nil && returns {
+				b.connect(b.current, b.returnTarget(), "return")
+			}
+			if b.current != nil && raises {
+				b.connect(b.current, b.raiseTarget(), "exception")
+			}
+			if b.current != nil && breaks {
+				b.connect(b.current, b.loopTarget(false), "break")
+			}
+			if b.current != nil && continues {
+				b.connect(b.current, b.loopTarget(true), "loop-back")
+			}
+		}
+	}
+
+	b.current = b.continueAfter(after)
+}
+
+func (block *basicBlock) hasEdgeTo(target *basicBlock) bool {
+	for _, edge := range block.succs {
+		if edge.to == target {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEdgeFrom indica si algún predecesor llega al bloque con una arista del tipo dado
+func (block *basicBlock) hasEdgeFrom(kind string) bool {
+	for _, pred := range block.preds {
+		for _, edge := range pred.succs {
+			if edge.to == block && edge.kind == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isAlwaysTrue(condition *models.ASTNode) bool {
+	switch condition.Type {
+	case "Boolean":
+		return condition.Value == "True"
+	case "Number":
+		for _, digit := range condition.Value {
+			if digit != '0' && digit != '.' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reachable devuelve los bloques alcanzables desde la entrada
+func (g *controlFlowGraph) reachable() map[*basicBlock]bool {
+	seen := map[*basicBlock]bool{g.entry: true}
+	queue := []*basicBlock{g.entry}
+	for len(queue) > 0 {
+		block := queue[0]
+		queue = queue[1:]
+		for _, edge := range block.succs {
+			if !seen[edge.to] {
+				seen[edge.to] = true
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+	return seen
+}
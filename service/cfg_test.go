@@ -0,0 +1,222 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"examen-back/models"
+)
+
+// exportFunction devuelve el CFG exportado de la primera función del programa
+func exportFunction(t *testing.T, code string) models.FunctionCFG {
+	t.Helper()
+	syntax := parse(code)
+	if !syntax.Valid {
+		t.Fatalf("errores de sintaxis: %v", syntax.Errors)
+	}
+	functions := NewCFGExporter(syntax.AST).Export()
+	if len(functions) == 0 {
+		t.Fatalf("el programa no tiene funciones")
+	}
+	return functions[0]
+}
+
+// blockWith devuelve el bloque que contiene la sentencia con ese texto
+func blockWith(t *testing.T, function models.FunctionCFG, text string) models.CFGBlock {
+	t.Helper()
+	for _, block := range function.Blocks {
+		for _, statement := range block.Statements {
+			if statement.Text == text {
+				return block
+			}
+		}
+	}
+	t.Fatalf("ningún bloque contiene '%s'", text)
+	return models.CFGBlock{}
+}
+
+// successors devuelve las aristas que salen de un bloque con el rótulo del destino
+func successors(function models.FunctionCFG, id int) map[string]string {
+	labels := make(map[int]string)
+	for _, block := range function.Blocks {
+		labels[block.ID] = block.Label
+	}
+	edges := make(map[string]string)
+	for _, edge := range function.Edges {
+		if edge.From == id {
+			edges[labels[edge.To]] = edge.Kind
+		}
+	}
+	return edges
+}
+
+func TestCFGExportBaseCase(t *testing.T) {
+	function := exportFunction(t, "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n")
+	if function.Name != "factorial" || function.Line != 1 || !strings.HasPrefix(function.Dot, "digraph") {
+		t.Fatalf("CFG inesperado: %+v", function)
+	}
+	condition := blockWith(t, function, "if n <= 1")
+	if edges := successors(function, condition.ID); edges["if.then"] != "true" || edges["if.end"] != "false" {
+		t.Errorf("la condición debe dividir el flujo en true y false: %v", edges)
+	}
+	if edges := successors(function, blockWith(t, function, "return 1").ID); edges["exit"] != "return" {
+		t.Errorf("el caso base debe ir a la salida: %v", edges)
+	}
+}
+
+func TestCFGBreakAndContinueRunFinally(t *testing.T) {
+	cases := []struct {
+		statement string
+		kind      string
+		target    string
+	}{
+		{"continue", "loop-back", "for.header"},
+		{"break", "break", "for.end"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.statement, func(t *testing.T) {
+			code := "def f():\n    for i in range(3):\n        try:\n            x = 1\n            " + tc.statement + "\n        finally:\n            y = i\n    return y\n"
+			function := exportFunction(t, code)
+			jump := blockWith(t, function, tc.statement)
+			if edges := successors(function, jump.ID); edges["finally.abrupt"] != tc.kind || len(edges) != 1 {
+				t.Fatalf("'%s' debe pasar por el finally: %v", tc.statement, edges)
+			}
+			var abrupt map[string]string
+			for _, block := range function.Blocks {
+				if block.Label == "finally.abrupt" {
+					abrupt = successors(function, block.ID)
+				}
+			}
+			if abrupt[tc.target] != tc.kind {
+				t.Errorf("después del finally '%s' debe seguir hacia %s: %v", tc.statement, tc.target, abrupt)
+			}
+
+			if found := diagnosticsWithCode(analyze(t, code+"\nprint(f())\n"), "used-before-assignment"); len(found) > 0 {
+				t.Errorf("el finally asigna y antes de salir del bucle: %v", found)
+			}
+		})
+	}
+}
+
+func TestCFGNestedFinallies(t *testing.T) {
+	const code = "def f():\n    for i in range(3):\n        try:\n            try:\n                break\n            finally:\n                a = 1\n        finally:\n            b = a\n    return b\n"
+	function := exportFunction(t, code)
+	abrupts := 0
+	for _, block := range function.Blocks {
+		if block.Label != "finally.abrupt" {
+			continue
+		}
+		abrupts++
+		if edges := successors(function, block.ID); edges["finally.abrupt"] != "break" && edges["for.end"] != "break" {
+			t.Errorf("el break debe atravesar los dos finally antes de salir: %v", edges)
+		}
+	}
+	if abrupts != 2 {
+		t.Fatalf("se esperaban dos finally para salidas abruptas, hay %d", abrupts)
+	}
+	if found := diagnosticsWithCode(analyze(t, code+"\nprint(f())\n"), "used-before-assignment"); len(found) > 0 {
+		t.Errorf("los dos finally se ejecutan antes de return b: %v", found)
+	}
+}
+
+// blockLabeled devuelve el primer bloque con ese rótulo
+func blockLabeled(t *testing.T, function models.FunctionCFG, label string) models.CFGBlock {
+	t.Helper()
+	for _, block := range function.Blocks {
+		if block.Label == label {
+			return block
+		}
+	}
+	t.Fatalf("no hay un bloque '%s'", label)
+	return models.CFGBlock{}
+}
+
+func TestCFGLoops(t *testing.T) {
+	function := exportFunction(t, "def f(n):\n    i = 0\n    while i < n:\n        i += 1\n    for x in range(n):\n        i -= x\n    else:\n        i = -1\n    return i\n")
+	if edges := successors(function, blockLabeled(t, function, "while.cond").ID); edges["while.body"] != "true" || edges["while.end"] != "false" {
+		t.Errorf("la condición del while debe entrar al cuerpo o salir: %v", edges)
+	}
+	if edges := successors(function, blockWith(t, function, "i += 1").ID); edges["while.cond"] != "loop-back" {
+		t.Errorf("el cuerpo del while debe volver a la condición: %v", edges)
+	}
+	if edges := successors(function, blockLabeled(t, function, "for.header").ID); edges["for.body"] != "true" || edges["loop.else"] != "false" || len(edges) != 2 {
+		t.Errorf("al agotarse el for debe pasar por el else: %v", edges)
+	}
+	if edges := successors(function, blockWith(t, function, "i = -1").ID); edges["for.end"] != "normal" {
+		t.Errorf("el else del for debe seguir después del bucle: %v", edges)
+	}
+
+	infinite := exportFunction(t, "def g():\n    while True:\n        pass\n")
+	if edges := successors(infinite, blockLabeled(t, infinite, "while.cond").ID); len(edges) != 1 || edges["while.body"] != "true" {
+		t.Errorf("while True solo puede entrar al cuerpo: %v", edges)
+	}
+	for _, block := range infinite.Blocks {
+		if block.Label == "while.end" {
+			t.Errorf("un while True sin break no tiene salida: %+v", block)
+		}
+	}
+}
+
+func TestCFGTryExceptFinally(t *testing.T) {
+	function := exportFunction(t, "def f(x):\n    try:\n        y = 1 // x\n    except ZeroDivisionError:\n        y = 0\n    finally:\n        print(x)\n    return y\n")
+	body := blockWith(t, function, "y = 1 // x")
+	if edges := successors(function, body.ID); edges["try.except"] != "exception" || edges["try.finally"] != "normal" {
+		t.Errorf("el cuerpo del try puede lanzar o seguir al finally: %v", edges)
+	}
+	dispatch := successors(function, blockLabeled(t, function, "try.except").ID)
+	if dispatch["except"] != "exception" || dispatch["finally.abrupt"] != "exception" {
+		t.Errorf("una excepción que no captura el except debe pasar por el finally: %v", dispatch)
+	}
+	if edges := successors(function, blockWith(t, function, "y = 0").ID); edges["try.finally"] != "normal" {
+		t.Errorf("el except debe seguir al finally normal: %v", edges)
+	}
+
+	var finallies int
+	for _, block := range function.Blocks {
+		if block.Label != "try.finally" && block.Label != "finally.abrupt" {
+			continue
+		}
+		finallies++
+		if block.Statements[0].Text != "print(x)" {
+			t.Errorf("el bloque %s debe ejecutar el finally: %+v", block.Label, block.Statements)
+		}
+	}
+	if finallies != 2 {
+		t.Errorf("el finally debe generarse para la salida normal y la abrupta, hay %d", finallies)
+	}
+	if edges := successors(function, blockLabeled(t, function, "finally.abrupt").ID); edges["exit"] != "exception" {
+		t.Errorf("la excepción no capturada debe salir de la función después del finally: %v", edges)
+	}
+}
+
+func TestCFGUnreachableBlocks(t *testing.T) {
+	const code = "def f(n):\n    if n:\n        return 1\n    else:\n        return 2\n    print(n)\n\ndef g():\n    while True:\n        break\n        x = 1\n    return 0\n"
+	syntax := parse(code)
+	functions := NewCFGExporter(syntax.AST).Export()
+	if len(functions) != 2 {
+		t.Fatalf("se esperaban dos funciones, hay %d", len(functions))
+	}
+	for _, tc := range []struct {
+		function models.FunctionCFG
+		dead     string
+		live     string
+	}{
+		{functions[0], "print(n)", "return 2"},
+		{functions[1], "x = 1", "return 0"},
+	} {
+		if block := blockWith(t, tc.function, tc.dead); block.Reachable || block.Label != "unreachable" {
+			t.Errorf("'%s' no debe ser alcanzable: %+v", tc.dead, block)
+		}
+		if block := blockWith(t, tc.function, tc.live); !block.Reachable {
+			t.Errorf("'%s' es alcanzable: %+v", tc.live, block)
+		}
+	}
+
+	found := diagnosticsWithCode(analyze(t, code+"\nprint(f(1), g())\n"), "unreachable-code")
+	if len(found) != 2 || found[0].Line != 6 || found[1].Line != 11 {
+		t.Errorf("se esperaba código inalcanzable en las líneas 6 y 11: %v", found)
+	}
+	if found := diagnosticsWithCode(analyze(t, "def h(n):\n    if n:\n        return 1\n    print(n)\n    return 0\n\nprint(h(0))\n"), "unreachable-code"); len(found) > 0 {
+		t.Errorf("después de un if sin else el código es alcanzable: %v", found)
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// CFGExporter convierte los grafos de flujo de control internos al formato de la API
+type CFGExporter struct {
+	ast *models.ASTNode
+}
+
+func NewCFGExporter(ast *models.ASTNode) *CFGExporter {
+	return &CFGExporter{ast: ast}
+}
+
+// Export devuelve el CFG de cada FunctionDef (incluidas funciones anidadas y métodos)
+func (e *CFGExporter) Export() []models.FunctionCFG {
+	functions := make([]models.FunctionCFG, 0)
+
+	for _, graph := range buildFunctionCFGs(e.ast) {
+		if graph.node.Type != "FunctionDef" {
+			continue
+		}
+		functions = append(functions, exportCFG(graph, nil))
+	}
+
+	return functions
+}
+
+// exportCFG convierte un grafo; con locate cada sentencia lleva su posición en el código
+func exportCFG(graph *controlFlowGraph, locate nodeLocator) models.FunctionCFG {
+	reachable := graph.reachable()
+	function := models.FunctionCFG{
+		Name:   graph.name,
+		Line:   graph.node.Line,
+		Entry:  graph.entry.id,
+		Exit:   graph.exit.id,
+		Blocks: make([]models.CFGBlock, 0, len(graph.blocks)),
+		Edges:  make([]models.CFGEdge, 0),
+		Range:  locate.find(graph.node),
+	}
+
+	for _, block := range graph.blocks {
+		exported := models.CFGBlock{
+			ID:         block.id,
+			Label:      block.label,
+			Statements: make([]models.CFGStatement, 0, len(block.steps)),
+			Reachable:  reachable[block],
+		}
+		for _, step := range block.steps {
+			exported.Statements = append(exported.Statements, models.CFGStatement{
+				Line:  step.Line,
+				Type:  step.Type,
+				Text:  graph.stepText(step),
+				Range: locate.find(step),
+			})
+		}
+		function.Blocks = append(function.Blocks, exported)
+
+		for _, edge := range block.succs {
+			function.Edges = append(function.Edges, models.CFGEdge{From: block.id, To: edge.to.id, Kind: edge.kind})
+		}
+	}
+
+	function.Dot = renderDot(function)
+	return function
+}
+
+// stepText muestra las condiciones y los iterables junto con su sentencia
+func (g *controlFlowGraph) stepText(step *models.ASTNode) string {
+	switch g.roles[step] {
+	case "if", "while":
+		return g.roles[step] + " " + formatSource(step)
+	case "for":
+		return "iter(" + formatSource(step) + ")"
+	}
+	return formatSource(step)
+}
+
+var dotEdgeStyles = map[string]string{
+	"true":      `color="darkgreen"`,
+	"false":     `color="firebrick"`,
+	"loop-back": `color="royalblue", style=bold`,
+	"break":     `color="gray40"`,
+	"return":    `color="gray40"`,
+	"exception": `color="darkorange", style=dashed`,
+}
+
+// renderDot genera el grafo en formato Graphviz DOT
+func renderDot(function models.FunctionCFG) string {
+	var dot strings.Builder
+
+	fmt.Fprintf(&dot, "digraph %s {\n", dotQuote(function.Name))
+	dot.WriteString("\tnode [shape=box, fontname=\"Courier\"];\n")
+
+	for _, block := range function.Blocks {
+		label := fmt.Sprintf("B%d: %s\\l", block.ID, dotEscape(block.Label))
+		for _, statement := range block.Statements {
+			label += fmt.Sprintf("%d: %s\\l", statement.Line, dotEscape(statement.Text))
+		}
+
+		attributes := fmt.Sprintf("label=\"%s\"", label)
+		if block.ID == function.Entry || block.ID == function.Exit {
+			attributes += ", shape=ellipse"
+		}
+		if !block.Reachable {
+			attributes += ", style=dashed, color=\"gray60\""
+		}
+		fmt.Fprintf(&dot, "\tB%d [%s];\n", block.ID, attributes)
+	}
+
+	for _, edge := range function.Edges {
+		if edge.Kind == "normal" {
+			fmt.Fprintf(&dot, "\tB%d -> B%d;\n", edge.From, edge.To)
+			continue
+		}
+		fmt.Fprintf(&dot, "\tB%d -> B%d [label=%s, %s];\n", edge.From, edge.To, dotQuote(edge.Kind), dotEdgeStyles[edge.Kind])
+	}
+
+	dot.WriteString("}\n")
+	return dot.String()
+}
+
+func dotQuote(text string) string {
+	return "\"" + dotEscape(text) + "\""
+}
+
+func dotEscape(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(text)
+}
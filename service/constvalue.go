@@ -0,0 +1,473 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"examen-back/models"
+)
+
+// constValue es un valor de Python conocido en tiempo de compilación. Los
+// enteros son de precisión arbitraria, como en Python.
+type constValue struct {
+	kind string // "int", "float", "str", "bool" o "None"
+	i    *big.Int
+	f    float64
+	s    string
+	b    bool
+}
+
+// errNotConstant indica que la expresión no puede evaluarse sin ejecutar el programa
+var errNotConstant = errors.New("la expresión no es constante")
+
+// pythonError es una excepción que Python lanzaría siempre al evaluar la
+// expresión; origin es el nodo donde se produce
+type pythonError struct {
+	name    string
+	message string
+	origin  *models.ASTNode
+}
+
+func (e *pythonError) Error() string {
+	return e.name + ": " + e.message
+}
+
+// errTooLarge indica un resultado que supera el tamaño permitido
+var errTooLarge = errors.New("el resultado es demasiado grande")
+
+// maxFoldBytes limita el tamaño de los enteros y cadenas calculados al plegar constantes
+const maxFoldBytes = 1 << 13
+
+func intValue(i *big.Int) constValue  { return constValue{kind: "int", i: i} }
+func floatValue(f float64) constValue { return constValue{kind: "float", f: f} }
+func strValue(s string) constValue    { return constValue{kind: "str", s: s} }
+func boolValue(b bool) constValue     { return constValue{kind: "bool", b: b} }
+func noneValue() constValue           { return constValue{kind: "None"} }
+func smallInt(n int64) constValue     { return intValue(big.NewInt(n)) }
+func (v constValue) isNumber() bool   { return v.kind == "int" || v.kind == "float" || v.kind == "bool" }
+func (v constValue) isIntegral() bool { return v.kind == "int" || v.kind == "bool" }
+
+// literalValue convierte un literal del AST en su valor
+func literalValue(node *models.ASTNode) (constValue, bool) {
+	switch node.Type {
+	case "Number":
+		text := strings.ReplaceAll(node.Value, "_", "")
+		if strings.ContainsAny(text, ".eE") {
+			f, err := strconv.ParseFloat(text, 64)
+			return floatValue(f), err == nil
+		}
+		i, ok := new(big.Int).SetString(text, 10)
+		return intValue(i), ok
+	case "String":
+		return strValue(unescapeString(node.Value)), true
+	case "Boolean":
+		return boolValue(node.Value == "True"), true
+	case "None":
+		return noneValue(), true
+	}
+	return constValue{}, false
+}
+
+// unescapeString interpreta las secuencias de escape que el lexer conserva
+func unescapeString(raw string) string {
+	if !strings.Contains(raw, "\\") {
+		return raw
+	}
+	var out strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' || i+1 == len(raw) {
+			out.WriteByte(raw[i])
+			continue
+		}
+		i++
+		switch raw[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case '0':
+			out.WriteByte(0)
+		case '\\', '\'', '"':
+			out.WriteByte(raw[i])
+		default:
+			out.WriteByte('\\')
+			out.WriteByte(raw[i])
+		}
+	}
+	return out.String()
+}
+
+// toInt devuelve el entero de un int o bool
+func (v constValue) toInt() *big.Int {
+	if v.kind == "bool" {
+		if v.b {
+			return big.NewInt(1)
+		}
+		return big.NewInt(0)
+	}
+	return v.i
+}
+
+func (v constValue) toFloat() (float64, bool) {
+	if v.kind == "float" {
+		return v.f, true
+	}
+	f, _ := new(big.Float).SetInt(v.toInt()).Float64()
+	return f, !math.IsInf(f, 0)
+}
+
+// truthy aplica las reglas de verdad de Python
+func (v constValue) truthy() bool {
+	switch v.kind {
+	case "int":
+		return v.i.Sign() != 0
+	case "float":
+		return v.f != 0
+	case "str":
+		return v.s != ""
+	case "bool":
+		return v.b
+	}
+	return false
+}
+
+// isZero indica un divisor numérico nulo (0, 0.0 o False)
+func (v constValue) isZero() bool {
+	return v.isNumber() && !v.truthy()
+}
+
+// Repr devuelve el valor como lo mostraría repr() en Python
+func (v constValue) Repr() string {
+	if v.kind == "str" {
+		return quoteString(v.s)
+	}
+	return v.Str()
+}
+
+// Str devuelve el valor como lo mostraría str() o print() en Python
+func (v constValue) Str() string {
+	switch v.kind {
+	case "int":
+		return v.i.String()
+	case "float":
+		return formatFloat(v.f)
+	case "str":
+		return v.s
+	case "bool":
+		if v.b {
+			return "True"
+		}
+		return "False"
+	}
+	return "None"
+}
+
+// formatFloat reproduce el formato de repr(float): notación fija entre 1e-4 y
+// 1e16, exponencial fuera de ese rango, y siempre con parte decimal
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	exponent := 0
+	if f != 0 {
+		exponent = int(math.Floor(math.Log10(math.Abs(f))))
+	}
+	if exponent < -4 || exponent >= 16 {
+		text := strconv.FormatFloat(f, 'e', -1, 64)
+		return strings.Replace(text, ".0e", "e", 1)
+	}
+	text := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(text, ".") {
+		text += ".0"
+	}
+	return text
+}
+
+// quoteString usa comillas simples salvo que el texto las contenga, como repr()
+func quoteString(s string) string {
+	quote := "'"
+	if strings.Contains(s, "'") && !strings.Contains(s, "\"") {
+		quote = "\""
+	}
+	var out strings.Builder
+	out.WriteString(quote)
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			out.WriteString("\\\\")
+		case r == '\n':
+			out.WriteString("\\n")
+		case r == '\t':
+			out.WriteString("\\t")
+		case r == '\r':
+			out.WriteString("\\r")
+		case string(r) == quote:
+			out.WriteString("\\" + quote)
+		case r < 0x20:
+			out.WriteString(fmt.Sprintf("\\x%02x", r))
+		default:
+			out.WriteRune(r)
+		}
+	}
+	out.WriteString(quote)
+	return out.String()
+}
+
+// constBinary evalúa "left op right" con la semántica de Python. Devuelve un
+// *pythonError si la operación siempre lanza una excepción, errNotConstant si
+// los tipos no admiten la operación y errTooLarge si el resultado es demasiado grande.
+func constBinary(op string, left, right constValue) (constValue, error) {
+	return binaryValue(op, left, right, maxFoldBytes)
+}
+
+// binaryValue es constBinary con un límite de tamaño en bytes para el resultado
+func binaryValue(op string, left, right constValue, maxBytes int) (constValue, error) {
+	if isComparison(op) {
+		return constCompare(op, left, right)
+	}
+
+	switch {
+	case left.kind == "str" && right.kind == "str" && op == "+":
+		return strValue(left.s + right.s), nil
+	case left.kind == "str" && right.isIntegral() && op == "*":
+		return repeatString(left.s, right.toInt(), maxBytes)
+	case right.kind == "str" && left.isIntegral() && op == "*":
+		return repeatString(right.s, left.toInt(), maxBytes)
+	case !left.isNumber() || !right.isNumber():
+		return constValue{}, errNotConstant
+	case left.kind == "float" || right.kind == "float":
+		return floatBinary(op, left, right)
+	}
+	return intBinary(op, left.toInt(), right.toInt(), maxBytes*8)
+}
+
+func repeatString(s string, times *big.Int, maxBytes int) (constValue, error) {
+	if times.Sign() <= 0 {
+		return strValue(""), nil
+	}
+	if !times.IsInt64() || int64(len(s))*times.Int64() > int64(maxBytes) {
+		return constValue{}, errTooLarge
+	}
+	return strValue(strings.Repeat(s, int(times.Int64()))), nil
+}
+
+func intBinary(op string, a, b *big.Int, maxBits int) (constValue, error) {
+	result := new(big.Int)
+	switch op {
+	case "+":
+		result.Add(a, b)
+	case "-":
+		result.Sub(a, b)
+	case "*":
+		if a.BitLen()+b.BitLen() > maxBits {
+			return constValue{}, errTooLarge
+		}
+		result.Mul(a, b)
+	case "/":
+		if b.Sign() == 0 {
+			return constValue{}, &pythonError{name: "ZeroDivisionError", message: "division by zero"}
+		}
+		f, _ := new(big.Rat).SetFrac(a, b).Float64()
+		if math.IsInf(f, 0) {
+			return constValue{}, &pythonError{name: "OverflowError", message: "integer division result too large for a float"}
+		}
+		return floatValue(f), nil
+	case "//", "%":
+		if b.Sign() == 0 {
+			return constValue{}, &pythonError{name: "ZeroDivisionError", message: "integer division or modulo by zero"}
+		}
+		quotient, remainder := floorDivMod(a, b)
+		if op == "//" {
+			return intValue(quotient), nil
+		}
+		return intValue(remainder), nil
+	case "**":
+		if b.Sign() < 0 {
+			fa, _ := new(big.Float).SetInt(a).Float64()
+			fb, _ := new(big.Float).SetInt(b).Float64()
+			return floatBinary(op, floatValue(fa), floatValue(fb))
+		}
+		if a.BitLen() > 1 && (!b.IsInt64() || int64(a.BitLen()-1)*b.Int64() > int64(maxBits)) {
+			return constValue{}, errTooLarge
+		}
+		result.Exp(a, b, nil)
+	case "<<", ">>":
+		if b.Sign() < 0 {
+			return constValue{}, &pythonError{name: "ValueError", message: "negative shift count"}
+		}
+		if op == ">>" {
+			if !b.IsInt64() {
+				b = big.NewInt(int64(a.BitLen()))
+			}
+			result.Rsh(a, uint(b.Int64()))
+			break
+		}
+		if !b.IsInt64() || int64(a.BitLen())+b.Int64() > int64(maxBits) {
+			return constValue{}, errTooLarge
+		}
+		result.Lsh(a, uint(b.Int64()))
+	case "&":
+		result.And(a, b)
+	case "|":
+		result.Or(a, b)
+	case "^":
+		result.Xor(a, b)
+	default:
+		return constValue{}, errNotConstant
+	}
+	if result.BitLen() > maxBits {
+		return constValue{}, errTooLarge
+	}
+	return intValue(result), nil
+}
+
+// floorDivMod redondea hacia menos infinito, como // y % de Python
+func floorDivMod(a, b *big.Int) (*big.Int, *big.Int) {
+	quotient, remainder := new(big.Int).QuoRem(a, b, new(big.Int))
+	if remainder.Sign() != 0 && remainder.Sign() != b.Sign() {
+		quotient.Sub(quotient, big.NewInt(1))
+		remainder.Add(remainder, b)
+	}
+	return quotient, remainder
+}
+
+func floatBinary(op string, left, right constValue) (constValue, error) {
+	a, okA := left.toFloat()
+	b, okB := right.toFloat()
+	if !okA || !okB {
+		return constValue{}, &pythonError{name: "OverflowError", message: "int too large to convert to float"}
+	}
+
+	switch op {
+	case "+":
+		return floatValue(a + b), nil
+	case "-":
+		return floatValue(a - b), nil
+	case "*":
+		return floatValue(a * b), nil
+	case "/", "//", "%":
+		if b == 0 {
+			message := "float division by zero"
+			if op == "%" {
+				message = "float modulo"
+			}
+			return constValue{}, &pythonError{name: "ZeroDivisionError", message: message}
+		}
+		switch op {
+		case "/":
+			return floatValue(a / b), nil
+		case "//":
+			return floatValue(math.Floor(a / b)), nil
+		}
+		m := math.Mod(a, b)
+		if m != 0 && (m < 0) != (b < 0) {
+			m += b
+		}
+		return floatValue(m), nil
+	case "**":
+		if a == 0 && b < 0 {
+			return constValue{}, &pythonError{name: "ZeroDivisionError", message: "0.0 cannot be raised to a negative power"}
+		}
+		if a < 0 && b != math.Trunc(b) {
+			// El resultado sería un número complejo
+			return constValue{}, errNotConstant
+		}
+		result := math.Pow(a, b)
+		if math.IsInf(result, 0) && !math.IsInf(a, 0) && !math.IsInf(b, 0) {
+			return constValue{}, &pythonError{name: "OverflowError", message: "(34, 'Numerical result out of range')"}
+		}
+		return floatValue(result), nil
+	}
+	return constValue{}, errNotConstant
+}
+
+// constCompare evalúa comparaciones. Ordenar tipos incompatibles lanza
+// TypeError, que ya reporta la verificación de tipos, así que no se pliega.
+func constCompare(op string, left, right constValue) (constValue, error) {
+	if op == "in" || op == "not in" {
+		if left.kind != "str" || right.kind != "str" {
+			return constValue{}, errNotConstant
+		}
+		return boolValue(strings.Contains(right.s, left.s) == (op == "in")), nil
+	}
+	if op == "is" || op == "is not" {
+		if left.kind != "None" && right.kind != "None" {
+			return constValue{}, errNotConstant
+		}
+		return boolValue((left.kind == right.kind) == (op == "is")), nil
+	}
+
+	var order int
+	switch {
+	case left.isNumber() && right.isNumber():
+		if left.kind == "float" || right.kind == "float" {
+			a, _ := left.toFloat()
+			b, _ := right.toFloat()
+			if math.IsNaN(a) || math.IsNaN(b) {
+				return boolValue(op == "!="), nil
+			}
+			order = big.NewFloat(a).Cmp(big.NewFloat(b))
+		} else {
+			order = left.toInt().Cmp(right.toInt())
+		}
+	case left.kind == "str" && right.kind == "str":
+		order = strings.Compare(left.s, right.s)
+	case op == "==" || op == "!=":
+		equal := left.kind == "None" && right.kind == "None"
+		return boolValue(equal == (op == "==")), nil
+	default:
+		return constValue{}, errNotConstant
+	}
+
+	switch op {
+	case "==":
+		return boolValue(order == 0), nil
+	case "!=":
+		return boolValue(order != 0), nil
+	case "<":
+		return boolValue(order < 0), nil
+	case "<=":
+		return boolValue(order <= 0), nil
+	case ">":
+		return boolValue(order > 0), nil
+	case ">=":
+		return boolValue(order >= 0), nil
+	}
+	return constValue{}, errNotConstant
+}
+
+func constUnary(op string, operand constValue) (constValue, error) {
+	if op == "not" {
+		return boolValue(!operand.truthy()), nil
+	}
+	switch {
+	case operand.kind == "float" && op == "-":
+		return floatValue(-operand.f), nil
+	case operand.kind == "float" && op == "+":
+		return operand, nil
+	case !operand.isIntegral():
+		return constValue{}, errNotConstant
+	}
+
+	value := operand.toInt()
+	switch op {
+	case "-":
+		return intValue(new(big.Int).Neg(value)), nil
+	case "+":
+		return intValue(value), nil
+	case "~":
+		return intValue(new(big.Int).Not(value)), nil
+	}
+	return constValue{}, errNotConstant
+}
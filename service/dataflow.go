@@ -0,0 +1,399 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"examen-back/models"
+)
+
+type nameSet map[string]bool
+
+func (set nameSet) clone() nameSet {
+	copied := make(nameSet, len(set))
+	for name := range set {
+		copied[name] = true
+	}
+	return copied
+}
+
+func (set nameSet) equals(other nameSet) bool {
+	if len(set) != len(other) {
+		return false
+	}
+	for name := range set {
+		if !other[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// assignmentState guarda las variables asignadas en todos los caminos (must)
+// y en al menos un camino (may)
+type assignmentState struct {
+	must nameSet
+	may  nameSet
+}
+
+// definiteAssignment ejecuta el análisis de asignación definida sobre un CFG.
+// El dominio son las variables locales del scope al que pertenece el grafo.
+type definiteAssignment struct {
+	graph    *controlFlowGraph
+	scope    *scope
+	tree     *scopeTree
+	domain   nameSet
+	initial  nameSet
+	in       map[*basicBlock]assignmentState
+	reported map[string]bool
+}
+
+// unboundUse describe un uso de una variable que puede no estar asignada
+type unboundUse struct {
+	name     string
+	line     int
+	possibly bool
+}
+
+func newDefiniteAssignment(graph *controlFlowGraph, tree *scopeTree) *definiteAssignment {
+	analysis := &definiteAssignment{
+		graph:    graph,
+		tree:     tree,
+		scope:    tree.module,
+		domain:   make(nameSet),
+		initial:  make(nameSet),
+		in:       make(map[*basicBlock]assignmentState),
+		reported: make(map[string]bool),
+	}
+	if sc := tree.byNode[graph.node]; sc != nil {
+		analysis.scope = sc
+	}
+
+	for _, name := range analysis.scope.order {
+		symbol := analysis.scope.symbols[name]
+		// Las variables asignadas desde otras funciones (global, nonlocal) no se
+		// pueden seguir de forma local
+		if !symbol.definesHere() || symbol.assignedElsewhere {
+			continue
+		}
+		analysis.domain[name] = true
+		if symbol.kind == "parameter" {
+			analysis.initial[name] = true
+		}
+	}
+
+	return analysis
+}
+
+// solve itera hasta el punto fijo y devuelve los usos posiblemente no asignados
+func (a *definiteAssignment) solve() []unboundUse {
+	reachable := a.graph.reachable()
+
+	a.in[a.graph.entry] = assignmentState{must: a.initial.clone(), may: a.initial.clone()}
+	changed := true
+	for changed {
+		changed = false
+		for _, block := range a.graph.blocks {
+			if block == a.graph.entry || !reachable[block] {
+				continue
+			}
+
+			state, ok := a.merge(block, reachable)
+			if !ok {
+				continue
+			}
+			previous, seen := a.in[block]
+			if !seen || !previous.must.equals(state.must) || !previous.may.equals(state.may) {
+				a.in[block] = state
+				changed = true
+			}
+		}
+	}
+
+	findings := make([]unboundUse, 0)
+	for _, block := range a.graph.blocks {
+		state, ok := a.in[block]
+		if !ok || !reachable[block] {
+			continue
+		}
+		state = assignmentState{must: state.must.clone(), may: state.may.clone()}
+		for _, step := range block.steps {
+			findings = append(findings, a.transfer(step, state, true)...)
+		}
+	}
+
+	return findings
+}
+
+// merge combina los estados de salida de los predecesores ya calculados
+func (a *definiteAssignment) merge(block *basicBlock, reachable map[*basicBlock]bool) (assignmentState, bool) {
+	var merged assignmentState
+	found := false
+
+	for _, pred := range block.preds {
+		if !reachable[pred] {
+			continue
+		}
+		in, ok := a.in[pred]
+		if !ok {
+			continue
+		}
+		out := assignmentState{must: in.must.clone(), may: in.may.clone()}
+		for _, step := range pred.steps {
+			a.transfer(step, out, false)
+		}
+		// Una excepción puede ocurrir antes de cualquier paso del predecesor,
+		// así que solo se garantiza lo asignado a su entrada
+		if hasOnlyExceptionEdges(pred, block) {
+			out.must = in.must.clone()
+		}
+
+		if !found {
+			merged = out
+			found = true
+			continue
+		}
+		for name := range merged.must {
+			if !out.must[name] {
+				delete(merged.must, name)
+			}
+		}
+		for name := range out.may {
+			merged.may[name] = true
+		}
+	}
+
+	return merged, found
+}
+
+func hasOnlyExceptionEdges(from, to *basicBlock) bool {
+	for _, edge := range from.succs {
+		if edge.to == to && edge.kind != "exception" {
+			return false
+		}
+	}
+	return true
+}
+
+// transfer aplica un paso al estado: primero se evalúan los usos y luego las asignaciones
+func (a *definiteAssignment) transfer(step *models.ASTNode, state assignmentState, report bool) []unboundUse {
+	findings := make([]unboundUse, 0)
+
+	if report {
+		for _, use := range stepLoads(step) {
+			symbol := a.tree.resolved[use]
+			if symbol == nil || symbol.owner != a.scope || !a.domain[symbol.name] || state.must[symbol.name] {
+				continue
+			}
+
+			key := fmt.Sprintf("%s:%d", symbol.name, use.Line)
+			if a.reported[key] {
+				continue
+			}
+			a.reported[key] = true
+			findings = append(findings, unboundUse{name: symbol.name, line: use.Line, possibly: state.may[symbol.name]})
+		}
+	}
+
+	for _, name := range stepDefinitions(step) {
+		if a.domain[name] {
+			state.must[name] = true
+			state.may[name] = true
+		}
+	}
+
+	return findings
+}
+
+// stepDefinitions devuelve los nombres que enlaza un paso del CFG
+func stepDefinitions(step *models.ASTNode) []string {
+	names := make([]string, 0)
+
+	switch step.Type {
+	case "Assignment":
+		if step.Value != "" {
+			names = append(names, step.Value)
+		} else {
+			names = append(names, targetNames(step.Children[0])...)
+		}
+	case "AugAssignment":
+		names = append(names, targetNames(step.Children[0])...)
+	case "ForStatement":
+		names = append(names, targetNames(step.Children[0])...)
+	case "FunctionDef", "ClassDef":
+		names = append(names, step.Value)
+	case "ExceptHandler":
+		if step.Value != "" {
+			names = append(names, step.Value)
+		}
+	case "Import":
+		for _, alias := range step.Children {
+			if len(alias.Children) > 0 {
+				names = append(names, alias.Children[0].Value)
+			} else {
+				names = append(names, dottedRoot(alias.Value))
+			}
+		}
+	case "ImportFrom":
+		for _, alias := range step.Children {
+			switch {
+			case len(alias.Children) > 0:
+				names = append(names, alias.Children[0].Value)
+			case alias.Value != "*":
+				names = append(names, alias.Value)
+			default:
+				// "from m import *" enlaza los nombres que el catálogo conoce de m
+				if stub, known := lookupModule(step.Value); known {
+					names = append(names, sortedMemberNames(stub)...)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+func targetNames(target *models.ASTNode) []string {
+	switch target.Type {
+	case "Identifier":
+		return []string{target.Value}
+	case "Tuple", "List":
+		names := make([]string, 0)
+		for _, child := range target.Children {
+			names = append(names, targetNames(child)...)
+		}
+		return names
+	}
+	return nil
+}
+
+func dottedRoot(name string) string {
+	for i, ch := range name {
+		if ch == '.' {
+			return name[:i]
+		}
+	}
+	return name
+}
+
+// stepLoads devuelve los nodos que leen un nombre al ejecutar el paso. No se
+// entra en los cuerpos de funciones y lambdas anidadas, que se ejecutan después.
+func stepLoads(step *models.ASTNode) []*models.ASTNode {
+	loads := make([]*models.ASTNode, 0)
+
+	switch step.Type {
+	case "Assignment":
+		if step.Value != "" {
+			collectLoads(step.Children[0], &loads)
+		} else {
+			collectLoads(step.Children[1], &loads)
+			collectTargetLoads(step.Children[0], &loads)
+		}
+	case "AugAssignment":
+		collectLoads(step.Children[1], &loads)
+		collectLoads(step.Children[0], &loads)
+	case "ForStatement":
+		collectTargetLoads(step.Children[0], &loads)
+	case "FunctionDef":
+		params, _ := functionParts(step)
+		for _, param := range params {
+			for _, child := range param.Children {
+				collectLoads(child, &loads)
+			}
+		}
+	case "ClassDef":
+		for _, child := range step.Children {
+			if child.Type == "Base" {
+				collectLoads(child, &loads)
+			}
+		}
+	case "ExceptHandler":
+		for _, child := range step.Children[:len(step.Children)-1] {
+			collectLoads(child, &loads)
+		}
+	case "Import", "ImportFrom", "GlobalStatement", "NonlocalStatement":
+	default:
+		collectLoads(step, &loads)
+	}
+
+	return loads
+}
+
+func collectLoads(node *models.ASTNode, loads *[]*models.ASTNode) {
+	if node == nil {
+		return
+	}
+
+	switch node.Type {
+	case "Identifier":
+		*loads = append(*loads, node)
+	case "Attribute":
+		if dottedName(node) != "" {
+			*loads = append(*loads, node)
+			return
+		}
+	case "FunctionCall":
+		*loads = append(*loads, node)
+	case "Lambda":
+		params, _ := functionParts(node)
+		for _, param := range params {
+			for _, child := range param.Children {
+				collectLoads(child, loads)
+			}
+		}
+		return
+	}
+
+	for _, child := range node.Children {
+		collectLoads(child, loads)
+	}
+}
+
+// collectTargetLoads recoge las lecturas dentro de un destino (a[i] = ... lee a e i)
+func collectTargetLoads(target *models.ASTNode, loads *[]*models.ASTNode) {
+	switch target.Type {
+	case "Identifier":
+	case "Tuple", "List":
+		for _, child := range target.Children {
+			collectTargetLoads(child, loads)
+		}
+	default:
+		collectLoads(target, loads)
+	}
+}
+
+// checkDefiniteAssignment analiza cada CFG y clasifica los usos sin asignación
+func checkDefiniteAssignment(graphs []*controlFlowGraph, tree *scopeTree) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+
+	for _, graph := range graphs {
+		analysis := newDefiniteAssignment(graph, tree)
+		findings := analysis.solve()
+		sort.SliceStable(findings, func(i, j int) bool { return findings[i].line < findings[j].line })
+
+		for _, finding := range findings {
+			if finding.possibly {
+				diagnostics = append(diagnostics, models.Diagnostic{
+					Code:     "possibly-unbound",
+					Severity: "warning",
+					Message:  fmt.Sprintf("Variable '%s' posiblemente sin asignar en línea %d: solo se asigna en algunos caminos", finding.name, finding.line),
+					Line:     finding.line,
+				})
+				continue
+			}
+
+			message := fmt.Sprintf("Variable '%s' usada antes de asignarse en línea %d: ningún camino la asigna antes", finding.name, finding.line)
+			if analysis.scope != tree.module && tree.module.symbols[finding.name] != nil {
+				message += fmt.Sprintf("; para usar la variable global declare 'global %s'", finding.name)
+			}
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Code:     "used-before-assignment",
+				Severity: "error",
+				Message:  message,
+				Line:     finding.line,
+			})
+		}
+	}
+
+	return diagnostics
+}
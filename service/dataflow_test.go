@@ -0,0 +1,42 @@
+package service
+
+import "testing"
+
+func TestDefiniteAssignment(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     string
+		never    []int
+		possibly []int
+	}{
+		{"nunca asignada", "def f():\n    print(x)\n    x = 1\n\nf()\n", []int{2}, nil},
+		{"solo en una rama", "def f(n):\n    if n:\n        x = 1\n    return x\n\nf(1)\n", nil, []int{4}},
+		{"en las dos ramas", "def f(n):\n    if n:\n        x = 1\n    else:\n        x = 2\n    return x\n\nf(1)\n", nil, nil},
+		{"dentro de un for", "def f(n):\n    for i in range(n):\n        x = i\n    return x\n\nf(1)\n", nil, []int{4}},
+		{"objetivo del for", "def f(n):\n    for i in range(n):\n        print(i)\n    return i\n\nf(1)\n", nil, []int{4}},
+		{"while True con break", "def f():\n    while True:\n        x = 1\n        break\n    return x\n\nf()\n", nil, nil},
+		{"usada antes en el bucle", "def f(n):\n    for i in range(n):\n        if i > 0:\n            print(x)\n        x = i\n\nf(2)\n", nil, []int{4}},
+		{"asignada en try y except", "def f(n):\n    try:\n        x = 10 // n\n    except ZeroDivisionError:\n        x = 0\n    return x\n\nf(0)\n", nil, nil},
+		{"solo en el try", "def f(n):\n    try:\n        x = 10 // n\n    except ZeroDivisionError:\n        print('cero')\n    return x\n\nf(0)\n", nil, []int{6}},
+		{"asignada en el finally", "def f(n):\n    try:\n        print(n)\n    finally:\n        x = n\n    return x\n\nf(0)\n", nil, nil},
+		{"función definida después", "def f():\n    return g()\n\ndef g():\n    return 1\n\nf()\n", nil, nil},
+		{"importación con asterisco", "from math import *\nprint(sqrt(2), pi)\n", nil, nil},
+		{"global sin declarar", "total = 0\n\ndef f():\n    total = total + 1\n\nf()\n", []int{4}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := analyze(t, tc.code)
+			for code, lines := range map[string][]int{"used-before-assignment": tc.never, "possibly-unbound": tc.possibly} {
+				found := diagnosticsWithCode(result, code)
+				if len(found) != len(lines) {
+					t.Fatalf("%s: se esperaban las líneas %v: %v", code, lines, found)
+				}
+				for i, line := range lines {
+					if found[i].Line != line {
+						t.Errorf("%s: se esperaba la línea %d: %v", code, line, found[i])
+					}
+				}
+			}
+		})
+	}
+}
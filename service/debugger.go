@@ -0,0 +1,393 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"examen-back/models"
+)
+
+// Límites de una sesión de depuración. El tiempo en pausa no cuenta para el
+// límite de tiempo de la ejecución, pero la sesión termina si el cliente no
+// envía comandos durante DebugIdleTimeout.
+const (
+	DebugIdleTimeout = 10 * time.Minute
+	MaxDebugFrames   = 64
+	MaxDebugWatches  = 20
+	maxDebugValue    = 200
+)
+
+var debugCommands = map[string]bool{
+	"continue": true, "stepInto": true, "stepOver": true, "stepOut": true, "pause": true,
+	"setBreakpoints": true, "setWatches": true, "evaluate": true, "stop": true,
+}
+
+// DebugSession ejecuta un programa en el intérprete deteniéndose antes de
+// cada sentencia según el modo de avance y los puntos de interrupción. El
+// intérprete corre en la goroutine de Run y recibe los comandos del cliente
+// por un canal, de modo que el estado de la sesión solo lo toca esa goroutine.
+type DebugSession struct {
+	in    *Interpreter
+	call  *models.ASTNode
+	stdin string
+	send  func(models.DebugEvent)
+
+	commands chan models.DebugCommand
+	quit     chan struct{}
+	done     chan struct{}
+	closing  sync.Once
+
+	breakpoints map[int]bool
+	watches     []debugWatch
+	mode        string
+	depth       int
+	pauseNext   bool
+
+	// Al reanudar desde un punto de interrupción no se vuelve a detener en la
+	// misma línea del mismo frame hasta salir de ella
+	skipFrame *frame
+	skipLine  int
+
+	flushedStdout int
+	flushedStderr int
+}
+
+type debugWatch struct {
+	source  string
+	program *models.ASTNode
+	err     error
+}
+
+// NewDebugSession prepara la sesión. send recibe los eventos para el cliente
+// y debe poder llamarse desde varias goroutines.
+func NewDebugSession(ast, call *models.ASTNode, stdin string, limits models.RunLimits, send func(models.DebugEvent)) *DebugSession {
+	return &DebugSession{
+		in:          NewInterpreter(ast, limits),
+		call:        call,
+		stdin:       stdin,
+		send:        send,
+		commands:    make(chan models.DebugCommand, 16),
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+		breakpoints: make(map[int]bool),
+		mode:        "entry",
+	}
+}
+
+// SetBreakpoints reemplaza los puntos de interrupción. Antes de Run se puede
+// llamar directamente; después se usa el comando "setBreakpoints".
+func (s *DebugSession) SetBreakpoints(lines []int) {
+	s.breakpoints = make(map[int]bool, len(lines))
+	for _, line := range lines {
+		if line > 0 {
+			s.breakpoints[line] = true
+		}
+	}
+}
+
+// SetWatches reemplaza las expresiones vigiladas, que se evalúan en el frame
+// más interno en cada pausa
+func (s *DebugSession) SetWatches(sources []string) error {
+	if len(sources) > MaxDebugWatches {
+		return fmt.Errorf("se admiten como máximo %d expresiones vigiladas", MaxDebugWatches)
+	}
+	s.watches = make([]debugWatch, 0, len(sources))
+	for _, source := range sources {
+		program, err := ParseRunCall(source)
+		s.watches = append(s.watches, debugWatch{source: source, program: program, err: err})
+	}
+	return nil
+}
+
+// Run ejecuta el programa hasta que termina o se detiene la sesión y envía
+// el evento "terminated" con el resultado
+func (s *DebugSession) Run() {
+	defer close(s.done)
+
+	s.in.debugger = s
+	result := s.in.Run(s.stdin, s.call)
+	s.in.debugger = nil
+	s.flush()
+
+	event := models.DebugEvent{Event: "terminated", Result: &result, Message: "Ejecución completada exitosamente"}
+	switch {
+	case result.Error != nil && result.Error.Type == "DebugSessionStopped":
+		event.Message = "La sesión de depuración se detuvo"
+	case result.Error != nil && result.Error.Limit:
+		event.Message = "La ejecución se detuvo por un límite del sandbox: " + result.Error.Type
+	case result.Error != nil:
+		event.Message = "La ejecución terminó con " + result.Error.Type
+	}
+	s.send(event)
+}
+
+// Command entrega un comando del cliente a la sesión
+func (s *DebugSession) Command(command models.DebugCommand) error {
+	if !debugCommands[command.Command] {
+		return fmt.Errorf("comando desconocido '%s'", command.Command)
+	}
+	select {
+	case s.commands <- command:
+		return nil
+	case <-s.done:
+		return fmt.Errorf("la ejecución ya terminó")
+	}
+}
+
+// Close detiene la sesión, por ejemplo al desconectarse el cliente
+func (s *DebugSession) Close() {
+	s.closing.Do(func() { close(s.quit) })
+}
+
+// Done se cierra cuando Run termina
+func (s *DebugSession) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *DebugSession) stopped() error {
+	return s.in.limit("DebugSessionStopped", "La sesión de depuración se detuvo")
+}
+
+// reached se llama antes de ejecutar cada sentencia: atiende los comandos
+// que llegaron mientras el programa corría y se detiene si corresponde
+func (s *DebugSession) reached(node *models.ASTNode, fr *frame) error {
+	for pending := true; pending; {
+		select {
+		case command := <-s.commands:
+			if err := s.running(command); err != nil {
+				return err
+			}
+		case <-s.quit:
+			return s.stopped()
+		default:
+			pending = false
+		}
+	}
+
+	if fr != s.skipFrame || node.Line != s.skipLine {
+		s.skipFrame, s.skipLine = nil, 0
+	}
+
+	reason := ""
+	switch {
+	case s.pauseNext:
+		reason = "pause"
+	case s.mode == "entry":
+		reason = "entry"
+	case s.mode == "stepInto",
+		s.mode == "stepOver" && s.in.depth <= s.depth,
+		s.mode == "stepOut" && s.in.depth < s.depth:
+		reason = "step"
+	case s.breakpoints[node.Line] && s.skipFrame == nil:
+		reason = "breakpoint"
+	default:
+		return nil
+	}
+	return s.pause(models.DebugEvent{Reason: reason, Line: node.Line}, fr)
+}
+
+// returned se llama cuando una función termina con un valor, antes de
+// desapilar su frame. Al avanzar paso a paso se detiene para mostrar el valor
+// que vuelve al llamador.
+func (s *DebugSession) returned(fr *frame, value pyValue) error {
+	switch {
+	case s.pauseNext,
+		s.mode == "stepInto",
+		(s.mode == "stepOver" || s.mode == "stepOut") && s.in.depth <= s.depth:
+		return s.pause(models.DebugEvent{Reason: "return", Line: fr.line, ReturnValue: s.valueText(value)}, fr)
+	}
+	return nil
+}
+
+// running atiende un comando recibido mientras el programa corre
+func (s *DebugSession) running(command models.DebugCommand) error {
+	switch command.Command {
+	case "pause":
+		s.pauseNext = true
+	case "stop":
+		return s.stopped()
+	case "setBreakpoints":
+		s.SetBreakpoints(command.Breakpoints)
+		s.send(models.DebugEvent{Event: "breakpoints", Breakpoints: s.breakpointLines()})
+	case "setWatches":
+		if err := s.SetWatches(command.Watches); err != nil {
+			s.send(models.DebugEvent{Event: "error", Message: err.Error()})
+		}
+	default:
+		s.send(models.DebugEvent{Event: "error", Message: fmt.Sprintf("'%s' solo se admite con el programa en pausa", command.Command)})
+	}
+	return nil
+}
+
+// pause envía el estado de la pila y espera comandos hasta que uno reanude
+// la ejecución
+func (s *DebugSession) pause(state models.DebugEvent, fr *frame) error {
+	s.pauseNext = false
+	s.flush()
+	pausedAt := time.Now()
+	defer func() { s.in.deadline = s.in.deadline.Add(time.Since(pausedAt)) }()
+	s.send(s.pausedEvent(state))
+
+	idle := time.NewTimer(DebugIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case <-s.quit:
+			return s.stopped()
+		case <-idle.C:
+			return s.in.limit("DebugIdleTimeout", "La sesión estuvo en pausa más de %d minutos sin comandos", int(DebugIdleTimeout/time.Minute))
+		case command := <-s.commands:
+			idle.Reset(DebugIdleTimeout)
+			switch command.Command {
+			case "continue", "stepInto", "stepOver", "stepOut":
+				s.mode, s.depth = command.Command, s.in.depth
+				s.skipFrame, s.skipLine = fr, state.Line
+				return nil
+			case "stop":
+				return s.stopped()
+			case "pause":
+				s.send(s.pausedEvent(state))
+			case "setBreakpoints":
+				s.SetBreakpoints(command.Breakpoints)
+				s.send(models.DebugEvent{Event: "breakpoints", Breakpoints: s.breakpointLines()})
+			case "setWatches":
+				if err := s.SetWatches(command.Watches); err != nil {
+					s.send(models.DebugEvent{Event: "error", Message: err.Error()})
+					continue
+				}
+				s.send(s.pausedEvent(state))
+			case "evaluate":
+				s.send(s.evaluation(command))
+			}
+		}
+	}
+}
+
+func (s *DebugSession) breakpointLines() []int {
+	lines := make([]int, 0, len(s.breakpoints))
+	for line := range s.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// flush envía la salida producida desde la última pausa
+func (s *DebugSession) flush() {
+	if stdout := s.in.stdout.String(); len(stdout) > s.flushedStdout {
+		s.send(models.DebugEvent{Event: "output", Stream: "stdout", Text: stdout[s.flushedStdout:]})
+		s.flushedStdout = len(stdout)
+	}
+	if stderr := s.in.stderr.String(); len(stderr) > s.flushedStderr {
+		s.send(models.DebugEvent{Event: "output", Stream: "stderr", Text: stderr[s.flushedStderr:]})
+		s.flushedStderr = len(stderr)
+	}
+}
+
+// pausedEvent completa el evento "paused" con la pila, las expresiones
+// vigiladas y los puntos de interrupción
+func (s *DebugSession) pausedEvent(state models.DebugEvent) models.DebugEvent {
+	state.Event = "paused"
+	state.Depth = s.in.depth
+	state.Frames = s.frames()
+	state.Watches = make([]models.DebugWatch, 0, len(s.watches))
+	for _, watch := range s.watches {
+		state.Watches = append(state.Watches, s.watch(watch, s.in.frame))
+	}
+	state.Breakpoints = s.breakpointLines()
+	return state
+}
+
+// frames devuelve la pila desde el frame más interno, con sus variables
+// ordenadas por nombre
+func (s *DebugSession) frames() []models.DebugFrame {
+	frames := make([]models.DebugFrame, 0)
+	for fr := s.in.frame; fr != nil && len(frames) < MaxDebugFrames; fr = fr.caller {
+		names := make([]string, 0, len(fr.locals))
+		for name := range fr.locals {
+			if !strings.HasPrefix(name, "__") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		locals := make([]models.DebugVariable, 0, len(names))
+		for _, name := range names {
+			value := fr.locals[name]
+			locals = append(locals, models.DebugVariable{Name: name, Value: s.valueText(value), Type: typeName(value)})
+		}
+		frames = append(frames, models.DebugFrame{Function: fr.function, Line: fr.line, Locals: locals})
+	}
+	return frames
+}
+
+// valueText es el repr del valor, acortado. Mientras se calcula no se
+// detiene el depurador en los __repr__ del programa.
+func (s *DebugSession) valueText(value pyValue) string {
+	s.in.debugger = nil
+	text, err := s.in.repr(value)
+	s.in.debugger = s
+	if err != nil {
+		return fmt.Sprintf("<%s>", typeName(value))
+	}
+	if runes := []rune(text); len(runes) > maxDebugValue {
+		text = string(runes[:maxDebugValue-1]) + "…"
+	}
+	return text
+}
+
+// watch evalúa una expresión vigilada en el frame indicado
+func (s *DebugSession) watch(watch debugWatch, fr *frame) models.DebugWatch {
+	result := models.DebugWatch{Expression: watch.source}
+	if watch.err != nil {
+		result.Error = watch.err.Error()
+		return result
+	}
+
+	value, err := s.in.evalInFrame(watch.program, fr)
+	switch e := err.(type) {
+	case nil:
+		result.Value, result.Type = s.valueText(value), typeName(value)
+	case *pyException:
+		result.Error = e.value.class.name
+		s.in.debugger = nil
+		if message, strErr := s.in.exceptionString(e.value); strErr == nil && message != "" {
+			result.Error += ": " + message
+		}
+		s.in.debugger = s
+	default:
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// evaluation atiende el comando "evaluate" en el frame pedido (0 es el más interno)
+func (s *DebugSession) evaluation(command models.DebugCommand) models.DebugEvent {
+	fr := s.in.frame
+	for i := 0; i < command.Frame && fr != nil; i++ {
+		fr = fr.caller
+	}
+	if fr == nil || command.Frame < 0 {
+		return models.DebugEvent{Event: "error", Message: fmt.Sprintf("no existe el frame %d", command.Frame)}
+	}
+	program, err := ParseRunCall(command.Expression)
+	result := s.watch(debugWatch{source: command.Expression, program: program, err: err}, fr)
+	return models.DebugEvent{Event: "evaluation", Evaluation: &result}
+}
+
+// evalInFrame evalúa una expresión analizada aparte con las variables de un
+// frame de la pila, sin detenerse en el depurador ni cambiar la línea actual
+func (in *Interpreter) evalInFrame(program *models.ASTNode, fr *frame) (pyValue, error) {
+	for scopeNode, sc := range buildScopeTree(program).byNode {
+		if scopeNode != program {
+			in.scopes[scopeNode] = sc
+		}
+	}
+	current, line, debugger := in.frame, fr.line, in.debugger
+	in.frame, in.debugger = fr, nil
+	defer func() { in.frame, in.debugger, fr.line = current, debugger, line }()
+	return in.eval(program.Children[0], fr)
+}
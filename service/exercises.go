@@ -0,0 +1,402 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"examen-back/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExerciseSpec describe un ejercicio de forma declarativa: los requisitos que
+// debe cumplir el programa y ajustes sobre las reglas semánticas generales
+type ExerciseSpec struct {
+	ID           string                `yaml:"id" json:"id"`
+	Title        string                `yaml:"title" json:"title"`
+	Description  string                `yaml:"description" json:"description"`
+	Requirements []ExerciseRequirement `yaml:"requirements" json:"requirements"`
+	Rules        ExerciseRules         `yaml:"rules" json:"rules"`
+	Tests        []models.TestCase     `yaml:"tests" json:"tests,omitempty"`
+}
+
+// ExerciseRequirement es un requisito del ejercicio. Kind puede ser:
+//   - define-function: define Function, con Params parámetros si se indica
+//   - recursive: Function (o alguna función si se omite) es recursiva
+//   - no-loops: no hay for ni while en Function (o en todo el programa)
+//   - no-import: no se importa Module (o ningún módulo si se omite)
+//   - calls: se llama a Function
+//   - no-call: no se llama a Function ("math.factorial")
+//   - prints-call: se muestra con print el resultado de Call, una llamada
+//     concreta ("fib(10)") o solo el nombre de la función ("factorial") para
+//     aceptar cualquier argumento
+type ExerciseRequirement struct {
+	Kind        string `yaml:"kind" json:"kind"`
+	Function    string `yaml:"function" json:"function,omitempty"`
+	Params      *int   `yaml:"params" json:"params,omitempty"`
+	Module      string `yaml:"module" json:"module,omitempty"`
+	Call        string `yaml:"call" json:"call,omitempty"`
+	Description string `yaml:"description" json:"description,omitempty"`
+
+	// call es Call normalizado con formatSource para compararlo con el AST;
+	// callee es el nombre de la función cuando Call no tiene argumentos
+	call   string
+	callee string
+}
+
+// ExerciseRules ajusta las reglas semánticas para el ejercicio, por ejemplo
+// para desactivar las verificaciones específicas de factorial
+type ExerciseRules struct {
+	Enable     []string          `yaml:"enable" json:"enable,omitempty"`
+	Disable    []string          `yaml:"disable" json:"disable,omitempty"`
+	Severities map[string]string `yaml:"severities" json:"severities,omitempty"`
+}
+
+var exerciseRegistry = make(map[string]*ExerciseSpec)
+
+// LoadExercises lee los ejercicios (*.yaml, *.yml, *.json) de un directorio y
+// los registra. Devuelve cuántos se cargaron.
+func LoadExercises(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	loaded := 0
+	for _, entry := range entries {
+		extension := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (extension != ".yaml" && extension != ".yml" && extension != ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return loaded, err
+		}
+
+		var spec ExerciseSpec
+		if extension == ".json" {
+			err = json.Unmarshal(data, &spec)
+		} else {
+			err = yaml.Unmarshal(data, &spec)
+		}
+		if err != nil {
+			return loaded, fmt.Errorf("%s: %w", path, err)
+		}
+		if spec.ID == "" {
+			spec.ID = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+
+		if err := RegisterExercise(&spec); err != nil {
+			return loaded, fmt.Errorf("%s: %w", path, err)
+		}
+		loaded++
+	}
+	return loaded, nil
+}
+
+// RegisterExercise valida un ejercicio y lo agrega al registro
+func RegisterExercise(spec *ExerciseSpec) error {
+	if _, exists := exerciseRegistry[spec.ID]; exists {
+		return fmt.Errorf("el ejercicio '%s' está definido dos veces", spec.ID)
+	}
+	if _, _, err := selectRules(spec.Rules.config()); err != nil {
+		return err
+	}
+
+	for i := range spec.Requirements {
+		requirement := &spec.Requirements[i]
+		switch requirement.Kind {
+		case "define-function", "calls", "no-call":
+			if requirement.Function == "" {
+				return fmt.Errorf("el requisito %d (%s) necesita 'function'", i+1, requirement.Kind)
+			}
+		case "recursive", "no-loops", "no-import":
+		case "prints-call":
+			call, callee, err := normalizeCall(requirement.Call)
+			if err != nil {
+				return fmt.Errorf("el requisito %d (prints-call): %w", i+1, err)
+			}
+			requirement.call, requirement.callee = call, callee
+		default:
+			return fmt.Errorf("el requisito %d tiene un tipo desconocido '%s'", i+1, requirement.Kind)
+		}
+	}
+
+	for i, test := range spec.Tests {
+		if err := ValidateTestCase(test); err != nil {
+			return fmt.Errorf("el caso de prueba %d: %w", i+1, err)
+		}
+	}
+
+	exerciseRegistry[spec.ID] = spec
+	return nil
+}
+
+// normalizeCall analiza la llamada de un requisito para compararla sin
+// depender de espacios ni comillas; si es solo un nombre ("factorial" o
+// "math.factorial") lo devuelve como callee
+func normalizeCall(source string) (call, callee string, err error) {
+	tokens := NewLexicalAnalyzer(source).Tokenize().Tokens
+	result := NewSyntaxAnalyzer(tokens).Analyze()
+	if !result.Valid || result.AST == nil || len(result.AST.Children) != 1 {
+		return "", "", fmt.Errorf("'%s' no es una llamada válida", source)
+	}
+	switch node := result.AST.Children[0]; node.Type {
+	case "FunctionCall":
+		return formatSource(node), "", nil
+	case "Identifier", "Attribute":
+		return "", formatSource(node), nil
+	}
+	return "", "", fmt.Errorf("'%s' no es una llamada válida", source)
+}
+
+// LookupExercise busca un ejercicio registrado
+func LookupExercise(id string) (*ExerciseSpec, bool) {
+	spec, ok := exerciseRegistry[id]
+	return spec, ok
+}
+
+// ExerciseInfos lista los ejercicios registrados ordenados por identificador
+func ExerciseInfos() []models.ExerciseInfo {
+	ids := make([]string, 0, len(exerciseRegistry))
+	for id := range exerciseRegistry {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	infos := make([]models.ExerciseInfo, 0, len(ids))
+	for _, id := range ids {
+		spec := exerciseRegistry[id]
+		requirements := make([]string, len(spec.Requirements))
+		for i, requirement := range spec.Requirements {
+			requirements[i] = requirement.describe()
+		}
+		infos = append(infos, models.ExerciseInfo{
+			ID:           spec.ID,
+			Title:        spec.Title,
+			Description:  spec.Description,
+			Requirements: requirements,
+			Tests:        len(spec.Tests),
+		})
+	}
+	return infos
+}
+
+func (r ExerciseRules) config() RuleConfig {
+	return RuleConfig{Enable: r.Enable, Disable: r.Disable, Severities: r.Severities}
+}
+
+// WithRules habilita las reglas de la categoría "exercise" y las combina con
+// los ajustes del ejercicio y de la petición; las reglas que cualquiera de
+// ellos deshabilita no se ejecutan y la petición tiene prioridad en las
+// severidades
+func (spec *ExerciseSpec) WithRules(config RuleConfig) RuleConfig {
+	merged := RuleConfig{
+		Enable:     append(append(exerciseRules(), spec.Rules.Enable...), config.Enable...),
+		Disable:    append(append([]string{}, spec.Rules.Disable...), config.Disable...),
+		Severities: make(map[string]string),
+	}
+	for id, severity := range spec.Rules.Severities {
+		merged.Severities[id] = severity
+	}
+	for id, severity := range config.Severities {
+		merged.Severities[id] = severity
+	}
+	return merged
+}
+
+// describe devuelve la descripción del requisito, generada si no se indicó
+func (r ExerciseRequirement) describe() string {
+	if r.Description != "" {
+		return r.Description
+	}
+
+	switch r.Kind {
+	case "define-function":
+		if r.Params != nil {
+			return fmt.Sprintf("Define la función '%s' con %d parámetro(s)", r.Function, *r.Params)
+		}
+		return fmt.Sprintf("Define la función '%s'", r.Function)
+	case "recursive":
+		if r.Function != "" {
+			return fmt.Sprintf("La función '%s' es recursiva", r.Function)
+		}
+		return "El programa usa recursión"
+	case "no-loops":
+		if r.Function != "" {
+			return fmt.Sprintf("La función '%s' no usa bucles (for, while)", r.Function)
+		}
+		return "El programa no usa bucles (for, while)"
+	case "no-import":
+		if r.Module != "" {
+			return fmt.Sprintf("No se importa el módulo '%s'", r.Module)
+		}
+		return "No se importan módulos"
+	case "calls":
+		return fmt.Sprintf("Se llama a '%s'", r.Function)
+	case "no-call":
+		return fmt.Sprintf("No se llama a '%s'", r.Function)
+	case "prints-call":
+		if r.callee != "" {
+			return fmt.Sprintf("Se muestra con print el resultado de una llamada a '%s'", r.callee)
+		}
+		return fmt.Sprintf("Se muestra el resultado de '%s' con print", r.call)
+	}
+	return r.Kind
+}
+
+// evaluateExercise comprueba cada requisito contra el AST y la tabla de símbolos
+func evaluateExercise(spec *ExerciseSpec, ctx *RuleContext) []models.SemanticCheck {
+	checks := make([]models.SemanticCheck, 0, len(spec.Requirements))
+	for _, requirement := range spec.Requirements {
+		passed, line := requirement.evaluate(ctx)
+		checks = append(checks, models.SemanticCheck{
+			Rule:        "exercise:" + spec.ID,
+			Description: requirement.describe(),
+			Passed:      passed,
+			Line:        line,
+		})
+	}
+	return checks
+}
+
+// evaluate devuelve si el requisito se cumple y la línea más relevante
+func (r ExerciseRequirement) evaluate(ctx *RuleContext) (bool, int) {
+	if ctx.AST == nil {
+		return false, 0
+	}
+
+	var function *models.ASTNode
+	if r.Function != "" && (r.Kind == "define-function" || r.Kind == "recursive" || r.Kind == "no-loops") {
+		if symbol := ctx.scopes.module.symbols[r.Function]; symbol != nil && symbol.node != nil && symbol.node.Type == "FunctionDef" {
+			function = symbol.node
+		} else {
+			return false, 0
+		}
+	}
+
+	switch r.Kind {
+	case "define-function":
+		if r.Params != nil && len(functionSignature(function).params) != *r.Params {
+			return false, function.Line
+		}
+		return true, function.Line
+
+	case "recursive":
+		if function != nil {
+			return ctx.recursion.byNode[function] != nil, function.Line
+		}
+		if len(ctx.recursion.functions) > 0 {
+			return true, ctx.recursion.functions[0].node.Line
+		}
+		return false, 0
+
+	case "no-loops":
+		scope := ctx.AST
+		if function != nil {
+			scope = function
+		}
+		if loop := findNode(scope, func(node *models.ASTNode) bool {
+			return node.Type == "ForStatement" || node.Type == "WhileStatement"
+		}); loop != nil {
+			return false, loop.Line
+		}
+		return true, 0
+
+	case "no-import":
+		if imported := findNode(ctx.AST, func(node *models.ASTNode) bool {
+			switch node.Type {
+			case "Alias":
+				return r.Module == "" || node.Value == r.Module || strings.HasPrefix(node.Value, r.Module+".")
+			case "ImportFrom":
+				return r.Module == "" || node.Value == r.Module || strings.HasPrefix(node.Value, r.Module+".")
+			}
+			return false
+		}); imported != nil {
+			return false, imported.Line
+		}
+		return true, 0
+
+	case "calls", "no-call":
+		call := findNode(ctx.AST, func(node *models.ASTNode) bool {
+			return node.Type == "FunctionCall" && (node.Value == r.Function || qualifiedCallName(node, ctx.scopes) == r.Function)
+		})
+		if r.Kind == "calls" {
+			if call == nil {
+				return false, 0
+			}
+			return true, call.Line
+		}
+		if call != nil {
+			return false, call.Line
+		}
+		return true, 0
+
+	case "prints-call":
+		printed := findNode(ctx.AST, func(node *models.ASTNode) bool {
+			if node.Type != "FunctionCall" || node.Value != "print" {
+				return false
+			}
+			for _, arg := range node.Children {
+				if arg.Type != "Keyword" && findNode(arg, func(inner *models.ASTNode) bool {
+					if inner.Type != "FunctionCall" {
+						return false
+					}
+					if r.callee != "" {
+						return inner.Value == r.callee || qualifiedCallName(inner, ctx.scopes) == r.callee
+					}
+					return formatSource(inner) == r.call
+				}) != nil {
+					return true
+				}
+			}
+			return false
+		})
+		if printed == nil {
+			return false, 0
+		}
+		return true, printed.Line
+	}
+	return false, 0
+}
+
+// qualifiedCallName devuelve el nombre completo de la función llamada a través
+// de un módulo ("math.factorial" para "factorial" importado con from math import)
+func qualifiedCallName(call *models.ASTNode, tree *scopeTree) string {
+	symbol := tree.resolved[call]
+	switch {
+	case symbol == nil:
+		return call.Value
+	case symbol.kind == "module":
+		parts := strings.SplitN(call.Value, ".", 2)
+		if len(parts) == 2 {
+			return symbol.module + "." + parts[1]
+		}
+		return symbol.module
+	case symbol.module != "" && symbol.node != nil && !strings.Contains(call.Value, "."):
+		return symbol.module + "." + symbol.node.Value
+	}
+	return call.Value
+}
+
+// findNode devuelve el primer nodo (en preorden) que cumple la condición
+func findNode(node *models.ASTNode, match func(*models.ASTNode) bool) *models.ASTNode {
+	if node == nil {
+		return nil
+	}
+	if match(node) {
+		return node
+	}
+	for _, child := range node.Children {
+		if found := findNode(child, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
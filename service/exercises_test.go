@@ -0,0 +1,88 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	"examen-back/models"
+)
+
+var loadExercisesOnce sync.Once
+
+// exercise carga los ejercicios del repositorio una sola vez y devuelve uno
+func exercise(t *testing.T, id string) *ExerciseSpec {
+	t.Helper()
+	loadExercisesOnce.Do(func() {
+		if _, err := LoadExercises("../exercises"); err != nil {
+			t.Fatalf("no se pudieron cargar los ejercicios: %v", err)
+		}
+	})
+	spec, ok := LookupExercise(id)
+	if !ok {
+		t.Fatalf("no existe el ejercicio '%s'", id)
+	}
+	return spec
+}
+
+// analyzeExercise analiza el código con los requisitos y las reglas del ejercicio
+func analyzeExercise(t *testing.T, id, code string) models.SemanticAnalysis {
+	t.Helper()
+	spec := exercise(t, id)
+	tokens := NewLexicalAnalyzer(code).Tokenize().Tokens
+	syntax := NewSyntaxAnalyzer(tokens).Analyze()
+	if !syntax.Valid {
+		t.Fatalf("errores de sintaxis: %v", syntax.Errors)
+	}
+	analyzer := NewSemanticAnalyzer(tokens, syntax.AST)
+	if err := analyzer.Configure(spec.WithRules(RuleConfig{})); err != nil {
+		t.Fatalf("configuración inválida: %v", err)
+	}
+	analyzer.SetExercise(spec)
+	return analyzer.Analyze()
+}
+
+// check devuelve la comprobación del ejercicio con esa descripción
+func check(t *testing.T, result models.SemanticAnalysis, description string) models.SemanticCheck {
+	t.Helper()
+	for _, check := range result.Checks {
+		if check.Description == description {
+			return check
+		}
+	}
+	t.Fatalf("no hay una comprobación '%s': %+v", description, result.Checks)
+	return models.SemanticCheck{}
+}
+
+func TestExercisePrintsCallAcceptsAnyArgument(t *testing.T) {
+	const definition = "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n"
+	const description = "Se muestra con print el resultado de una llamada a 'factorial'"
+	cases := []struct {
+		name   string
+		code   string
+		passed bool
+	}{
+		{"literal", definition + "print(factorial(5))\n", true},
+		{"variable", definition + "n = 6\nprint(factorial(n))\n", true},
+		{"dentro de una expresión", definition + "print('5! =', factorial(5) + 0)\n", true},
+		{"sin print", definition + "x = factorial(5)\n", false},
+		{"print de otra función", definition + "print(abs(5))\n", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := check(t, analyzeExercise(t, "factorial", tc.code), description); got.Passed != tc.passed {
+				t.Errorf("se esperaba %v: %+v", tc.passed, got)
+			}
+		})
+	}
+}
+
+func TestExercisePrintsConcreteCall(t *testing.T) {
+	const definition = "def fib(n):\n    if n < 2:\n        return n\n    return fib(n - 1) + fib(n - 2)\n"
+	const description = "Se muestra el resultado de 'fib(10)' con print"
+	if got := check(t, analyzeExercise(t, "fibonacci", definition+"print(fib( 10 ))\n"), description); !got.Passed {
+		t.Errorf("print(fib( 10 )) cumple el requisito: %+v", got)
+	}
+	if got := check(t, analyzeExercise(t, "fibonacci", definition+"print(fib(9))\n"), description); got.Passed {
+		t.Errorf("print(fib(9)) no es la llamada pedida: %+v", got)
+	}
+}
@@ -0,0 +1,856 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"examen-back/models"
+)
+
+// Límites de la evaluación en tiempo de compilación: una llamada a una función
+// pura solo se pliega si termina dentro de estos márgenes
+const (
+	maxFoldSteps = 100000
+	maxFoldDepth = 200
+	maxFoldTotal = 2000000
+)
+
+// pureBuiltins son las funciones integradas sin efectos que se pueden evaluar
+var pureBuiltins = map[string]bool{
+	"abs": true, "min": true, "max": true, "len": true, "int": true,
+	"float": true, "str": true, "bool": true, "round": true, "pow": true,
+}
+
+// Resultado de ejecutar una sentencia dentro de una función pura
+const (
+	flowNormal = iota
+	flowReturn
+	flowBreak
+	flowContinue
+)
+
+type foldSite struct {
+	node  *models.ASTNode
+	scope *scope
+}
+
+type foldResult struct {
+	value constValue
+	err   error
+}
+
+// constantFolding guarda el valor conocido de cada expresión que se puede
+// calcular sin ejecutar el programa. Una función es pura si solo usa sus
+// parámetros y variables locales, literales y llamadas a otras funciones puras;
+// sus llamadas con argumentos constantes se evalúan con un intérprete acotado.
+type constantFolding struct {
+	tree   *scopeTree
+	pure   map[*models.ASTNode]bool
+	values map[*models.ASTNode]constValue
+	raises map[*models.ASTNode]*pythonError
+	folded map[*models.ASTNode]bool
+	memo   map[string]foldResult
+
+	// roots son las expresiones plegadas más externas, en orden de aparición
+	roots []*models.ASTNode
+	// failures son las llamadas que siempre lanzan una excepción
+	failures   []*models.ASTNode
+	divisions  []foldSite
+	conditions []foldSite
+
+	steps  int
+	budget int
+	total  int
+	depth  int
+}
+
+func foldConstants(ast *models.ASTNode, tree *scopeTree) *constantFolding {
+	f := &constantFolding{
+		tree:   tree,
+		pure:   make(map[*models.ASTNode]bool),
+		values: make(map[*models.ASTNode]constValue),
+		raises: make(map[*models.ASTNode]*pythonError),
+		folded: make(map[*models.ASTNode]bool),
+		memo:   make(map[string]foldResult),
+	}
+	if ast == nil {
+		return f
+	}
+
+	f.findPureFunctions(ast)
+	f.visit(ast, tree.module, false)
+	return f
+}
+
+// visit evalúa cada expresión del programa y registra las divisiones y
+// condiciones que verifican las reglas
+func (f *constantFolding) visit(node *models.ASTNode, sc *scope, inside bool) {
+	if inner := f.tree.byNode[node]; inner != nil {
+		sc = inner
+	}
+
+	switch node.Type {
+	case "BinaryOp", "UnaryOp", "IfExpression", "FunctionCall":
+		if !f.folded[node] {
+			f.steps = 0
+			f.budget = min(maxFoldSteps, maxFoldTotal-f.total)
+			f.evaluate(node, nil)
+			f.total += f.steps
+		}
+		if _, known := f.values[node]; known && !inside {
+			f.roots = append(f.roots, node)
+			inside = true
+		}
+		if node.Type == "FunctionCall" && f.raises[node] != nil {
+			f.failures = append(f.failures, node)
+		}
+		if node.Type == "BinaryOp" && isDivision(node.Value) {
+			f.divisions = append(f.divisions, foldSite{node, sc})
+		}
+		if node.Type == "IfExpression" {
+			f.conditions = append(f.conditions, foldSite{node, sc})
+		}
+	case "AugAssignment":
+		if isDivision(strings.TrimSuffix(node.Value, "=")) {
+			f.divisions = append(f.divisions, foldSite{node, sc})
+		}
+	case "IfStatement", "WhileStatement":
+		f.conditions = append(f.conditions, foldSite{node, sc})
+	}
+
+	for _, child := range node.Children {
+		f.visit(child, sc, inside)
+	}
+}
+
+func isDivision(op string) bool {
+	return op == "/" || op == "//" || op == "%"
+}
+
+// valueOf devuelve el valor de un literal o de una expresión plegada
+func (f *constantFolding) valueOf(node *models.ASTNode) (constValue, bool) {
+	if value, ok := literalValue(node); ok {
+		return value, true
+	}
+	value, ok := f.values[node]
+	return value, ok
+}
+
+// annotate anota en el AST el valor de las expresiones plegadas
+func (f *constantFolding) annotate() {
+	for node, value := range f.values {
+		if _, literal := literalValue(node); !literal {
+			node.Constant = value.Repr()
+		}
+	}
+}
+
+// infos describe las expresiones plegadas más externas para mostrarlas junto al código
+func (f *constantFolding) infos() []models.ConstantValue {
+	infos := make([]models.ConstantValue, 0, len(f.roots))
+	for _, node := range f.roots {
+		value := f.values[node]
+		infos = append(infos, models.ConstantValue{
+			Expression: formatSource(node),
+			Value:      value.Repr(),
+			Type:       value.kind,
+			Line:       node.Line,
+			Col:        node.Col,
+		})
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Line != infos[j].Line {
+			return infos[i].Line < infos[j].Line
+		}
+		return infos[i].Col < infos[j].Col
+	})
+	return infos
+}
+
+// findPureFunctions parte de suponer puras todas las funciones que no son
+// métodos y descarta las que no lo son hasta llegar a un punto fijo, para
+// admitir la recursión directa y mutua
+func (f *constantFolding) findPureFunctions(ast *models.ASTNode) {
+	candidates := make([]*models.ASTNode, 0)
+	var collect func(node *models.ASTNode)
+	collect = func(node *models.ASTNode) {
+		if node.Type == "FunctionDef" {
+			if sc := f.tree.byNode[node]; sc != nil && sc.parent != nil && sc.parent.kind != "class" {
+				candidates = append(candidates, node)
+				f.pure[node] = true
+			}
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(ast)
+
+	for changed := true; changed; {
+		changed = false
+		for _, fn := range candidates {
+			if f.pure[fn] && !f.pureFunction(fn) {
+				delete(f.pure, fn)
+				changed = true
+			}
+		}
+	}
+}
+
+func (f *constantFolding) pureFunction(fn *models.ASTNode) bool {
+	sc := f.tree.byNode[fn]
+	params, body := functionParts(fn)
+	for _, param := range params {
+		if strings.HasPrefix(param.Value, "*") {
+			return false
+		}
+		if def := parameterDefault(param); def != nil && !f.pureExpression(def, nil) {
+			return false
+		}
+	}
+	for _, stmt := range body {
+		if !f.pureStatement(stmt, sc) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *constantFolding) pureStatement(node *models.ASTNode, sc *scope) bool {
+	switch node.Type {
+	case "PassStatement", "BreakStatement", "ContinueStatement":
+		return true
+	case "Block", "ReturnStatement":
+		for _, child := range node.Children {
+			if !f.pureStatement(child, sc) {
+				return false
+			}
+		}
+		return true
+	case "IfStatement":
+		for _, branch := range node.Children[1:] {
+			if !f.pureStatement(branch, sc) {
+				return false
+			}
+		}
+		return f.pureExpression(node.Children[0], sc)
+	case "WhileStatement":
+		return len(node.Children) == 2 && f.pureExpression(node.Children[0], sc) && f.pureStatement(node.Children[1], sc)
+	case "ForStatement":
+		if len(node.Children) != 3 || !f.localName(node.Children[0], sc) {
+			return false
+		}
+		iter := node.Children[1]
+		if iter.Type != "FunctionCall" || iter.Value != "range" || f.tree.resolved[iter] != nil {
+			return false
+		}
+		for _, arg := range iter.Children {
+			if !f.pureExpression(arg, sc) {
+				return false
+			}
+		}
+		return f.pureStatement(node.Children[2], sc)
+	case "Assignment":
+		return node.Value != "" && f.pureExpression(node.Children[0], sc)
+	case "AugAssignment":
+		return f.localName(node.Children[0], sc) && f.pureExpression(node.Children[1], sc)
+	}
+	return f.pureExpression(node, sc)
+}
+
+func (f *constantFolding) pureExpression(node *models.ASTNode, sc *scope) bool {
+	switch node.Type {
+	case "Number", "String", "Boolean", "None":
+		return true
+	case "Identifier":
+		return f.localName(node, sc)
+	case "BinaryOp", "UnaryOp", "IfExpression":
+		for _, child := range node.Children {
+			if !f.pureExpression(child, sc) {
+				return false
+			}
+		}
+		return true
+	case "FunctionCall":
+		if _, ok := f.pureCallee(node); !ok {
+			return false
+		}
+		for _, arg := range node.Children {
+			if arg.Type == "Keyword" {
+				arg = arg.Children[0]
+			}
+			if !f.pureExpression(arg, sc) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// localName indica un parámetro o variable local de la función
+func (f *constantFolding) localName(node *models.ASTNode, sc *scope) bool {
+	if node.Type != "Identifier" || sc == nil {
+		return false
+	}
+	symbol := sc.symbols[node.Value]
+	return symbol != nil && symbol.binding == "local" && (symbol.kind == "variable" || symbol.kind == "parameter")
+}
+
+// pureCallee devuelve la función pura llamada, o nil si es un builtin puro
+func (f *constantFolding) pureCallee(call *models.ASTNode) (*models.ASTNode, bool) {
+	if strings.Contains(call.Value, ".") {
+		return nil, false
+	}
+	symbol := f.tree.resolved[call]
+	if symbol == nil {
+		return nil, pureBuiltins[call.Value]
+	}
+	if symbol.kind == "function" && len(symbol.bindings) == 1 && symbol.node != nil && f.pure[symbol.node] {
+		return symbol.node, true
+	}
+	return nil, false
+}
+
+// evaluate calcula el valor de una expresión. env es nil fuera de las
+// funciones puras; en ese caso el resultado se guarda para el nodo.
+func (f *constantFolding) evaluate(node *models.ASTNode, env map[string]constValue) (constValue, error) {
+	if env == nil && f.folded[node] {
+		if value, ok := f.values[node]; ok {
+			return value, nil
+		}
+		if raised := f.raises[node]; raised != nil {
+			return constValue{}, raised
+		}
+		return constValue{}, errNotConstant
+	}
+
+	value, err := f.compute(node, env)
+	if env == nil {
+		f.folded[node] = true
+		if err == nil {
+			f.values[node] = value
+		} else if raised, ok := err.(*pythonError); ok {
+			f.raises[node] = raised
+		}
+	}
+	return value, err
+}
+
+func (f *constantFolding) compute(node *models.ASTNode, env map[string]constValue) (constValue, error) {
+	f.steps++
+	if f.steps > f.budget {
+		return constValue{}, errNotConstant
+	}
+	if value, ok := literalValue(node); ok {
+		return value, nil
+	}
+
+	switch node.Type {
+	case "Identifier":
+		if value, ok := env[node.Value]; ok {
+			return value, nil
+		}
+
+	case "UnaryOp":
+		operand, err := f.evaluate(node.Children[0], env)
+		if err != nil {
+			return constValue{}, err
+		}
+		value, err := constUnary(node.Value, operand)
+		return value, locate(err, node)
+
+	case "BinaryOp":
+		left, err := f.evaluate(node.Children[0], env)
+		if err != nil {
+			return constValue{}, err
+		}
+		switch {
+		case node.Value == "and" && !left.truthy(), node.Value == "or" && left.truthy():
+			return left, nil
+		case node.Value == "and" || node.Value == "or":
+			return f.evaluate(node.Children[1], env)
+		}
+		right, err := f.evaluate(node.Children[1], env)
+		if err != nil {
+			return constValue{}, err
+		}
+		value, err := constBinary(node.Value, left, right)
+		return value, locate(err, node)
+
+	case "IfExpression":
+		condition, err := f.evaluate(node.Children[0], env)
+		if err != nil {
+			return constValue{}, err
+		}
+		if condition.truthy() {
+			return f.evaluate(node.Children[1], env)
+		}
+		return f.evaluate(node.Children[2], env)
+
+	case "FunctionCall":
+		return f.call(node, env)
+	}
+
+	return constValue{}, errNotConstant
+}
+
+// locate asigna a la excepción el nodo donde se produce
+func locate(err error, node *models.ASTNode) error {
+	if raised, ok := err.(*pythonError); ok && raised.origin == nil {
+		raised.origin = node
+	}
+	return err
+}
+
+func (f *constantFolding) call(node *models.ASTNode, env map[string]constValue) (constValue, error) {
+	fn, ok := f.pureCallee(node)
+	if !ok {
+		return constValue{}, errNotConstant
+	}
+
+	args := make([]constValue, 0, len(node.Children))
+	keywords := make(map[string]constValue)
+	for _, arg := range node.Children {
+		expr := arg
+		if arg.Type == "Keyword" {
+			expr = arg.Children[0]
+		}
+		value, err := f.evaluate(expr, env)
+		if err != nil {
+			return constValue{}, err
+		}
+		if arg.Type == "Keyword" {
+			keywords[arg.Value] = value
+		} else {
+			args = append(args, value)
+		}
+	}
+
+	if fn == nil {
+		value, err := callPureBuiltin(node.Value, args, keywords)
+		return value, locate(err, node)
+	}
+	return f.callFunction(fn, args, keywords)
+}
+
+// callFunction ejecuta una función pura; los resultados se memorizan por argumentos
+func (f *constantFolding) callFunction(fn *models.ASTNode, args []constValue, keywords map[string]constValue) (constValue, error) {
+	params, body := functionParts(fn)
+	if len(args) > len(params) {
+		return constValue{}, errNotConstant
+	}
+
+	key := make([]string, 0, len(args)+len(keywords)+1)
+	key = append(key, fmt.Sprintf("%p", fn))
+	for _, arg := range args {
+		key = append(key, arg.kind+":"+arg.Repr())
+	}
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key = append(key, name+"="+keywords[name].kind+":"+keywords[name].Repr())
+	}
+	memoKey := strings.Join(key, "|")
+	if result, found := f.memo[memoKey]; found {
+		return result.value, result.err
+	}
+	if f.depth >= maxFoldDepth {
+		return constValue{}, errNotConstant
+	}
+
+	env := make(map[string]constValue)
+	used := 0
+	for i, param := range params {
+		value, byName := keywords[param.Value]
+		switch {
+		case i < len(args) && byName:
+			return constValue{}, errNotConstant
+		case i < len(args):
+			env[param.Value] = args[i]
+		case byName:
+			env[param.Value] = value
+			used++
+		case parameterDefault(param) != nil:
+			def, err := f.evaluate(parameterDefault(param), nil)
+			if err != nil {
+				return constValue{}, err
+			}
+			env[param.Value] = def
+		default:
+			return constValue{}, errNotConstant
+		}
+	}
+	if used != len(keywords) {
+		return constValue{}, errNotConstant
+	}
+
+	f.depth++
+	value, flow, err := f.execute(body, env)
+	f.depth--
+	if err == nil && flow != flowReturn {
+		value = noneValue()
+	}
+	if _, raised := err.(*pythonError); err == nil || raised {
+		f.memo[memoKey] = foldResult{value, err}
+	}
+	return value, err
+}
+
+func (f *constantFolding) execute(stmts []*models.ASTNode, env map[string]constValue) (constValue, int, error) {
+	for _, stmt := range stmts {
+		value, flow, err := f.executeStatement(stmt, env)
+		if err != nil || flow != flowNormal {
+			return value, flow, err
+		}
+	}
+	return constValue{}, flowNormal, nil
+}
+
+func (f *constantFolding) executeStatement(stmt *models.ASTNode, env map[string]constValue) (constValue, int, error) {
+	f.steps++
+	if f.steps > f.budget {
+		return constValue{}, flowNormal, errNotConstant
+	}
+
+	switch stmt.Type {
+	case "PassStatement":
+		return constValue{}, flowNormal, nil
+	case "BreakStatement":
+		return constValue{}, flowBreak, nil
+	case "ContinueStatement":
+		return constValue{}, flowContinue, nil
+	case "Block":
+		return f.execute(stmt.Children, env)
+
+	case "ReturnStatement":
+		if len(stmt.Children) == 0 {
+			return noneValue(), flowReturn, nil
+		}
+		value, err := f.evaluate(stmt.Children[0], env)
+		return value, flowReturn, err
+
+	case "IfStatement":
+		condition, err := f.evaluate(stmt.Children[0], env)
+		switch {
+		case err != nil:
+			return constValue{}, flowNormal, err
+		case condition.truthy():
+			return f.executeStatement(stmt.Children[1], env)
+		case len(stmt.Children) > 2:
+			return f.executeStatement(stmt.Children[2], env)
+		}
+		return constValue{}, flowNormal, nil
+
+	case "WhileStatement":
+		for {
+			condition, err := f.evaluate(stmt.Children[0], env)
+			if err != nil || !condition.truthy() {
+				return constValue{}, flowNormal, err
+			}
+			value, flow, err := f.executeStatement(stmt.Children[1], env)
+			if err != nil || flow == flowReturn {
+				return value, flow, err
+			}
+			if flow == flowBreak {
+				return constValue{}, flowNormal, nil
+			}
+		}
+
+	case "ForStatement":
+		start, stop, step, err := f.rangeBounds(stmt.Children[1], env)
+		if err != nil {
+			return constValue{}, flowNormal, err
+		}
+		target := stmt.Children[0].Value
+		for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+			env[target] = smallInt(i)
+			value, flow, err := f.executeStatement(stmt.Children[2], env)
+			if err != nil || flow == flowReturn {
+				return value, flow, err
+			}
+			if flow == flowBreak {
+				break
+			}
+		}
+		return constValue{}, flowNormal, nil
+
+	case "Assignment":
+		value, err := f.evaluate(stmt.Children[0], env)
+		if err == nil {
+			env[stmt.Value] = value
+		}
+		return constValue{}, flowNormal, err
+
+	case "AugAssignment":
+		name := stmt.Children[0].Value
+		current, bound := env[name]
+		if !bound {
+			return constValue{}, flowNormal, errNotConstant
+		}
+		right, err := f.evaluate(stmt.Children[1], env)
+		if err != nil {
+			return constValue{}, flowNormal, err
+		}
+		value, err := constBinary(strings.TrimSuffix(stmt.Value, "="), current, right)
+		if err == nil {
+			env[name] = value
+		}
+		return constValue{}, flowNormal, locate(err, stmt)
+	}
+
+	_, err := f.evaluate(stmt, env)
+	return constValue{}, flowNormal, err
+}
+
+// rangeBounds evalúa los argumentos de range() en un for
+func (f *constantFolding) rangeBounds(call *models.ASTNode, env map[string]constValue) (int64, int64, int64, error) {
+	bounds := make([]int64, 0, 3)
+	for _, arg := range call.Children {
+		if arg.Type == "Keyword" {
+			return 0, 0, 0, errNotConstant
+		}
+		value, err := f.evaluate(arg, env)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if !value.isIntegral() || !value.toInt().IsInt64() {
+			return 0, 0, 0, errNotConstant
+		}
+		bounds = append(bounds, value.toInt().Int64())
+	}
+
+	switch len(bounds) {
+	case 1:
+		return 0, bounds[0], 1, nil
+	case 2:
+		return bounds[0], bounds[1], 1, nil
+	case 3:
+		if bounds[2] == 0 {
+			return 0, 0, 0, &pythonError{name: "ValueError", message: "range() arg 3 must not be zero", origin: call}
+		}
+		return bounds[0], bounds[1], bounds[2], nil
+	}
+	return 0, 0, 0, errNotConstant
+}
+
+// callPureBuiltin evalúa las funciones integradas de pureBuiltins
+func callPureBuiltin(name string, args []constValue, keywords map[string]constValue) (constValue, error) {
+	if len(keywords) > 0 {
+		return constValue{}, errNotConstant
+	}
+
+	switch {
+	case name == "str" && len(args) == 0:
+		return strValue(""), nil
+	case name == "int" && len(args) == 0:
+		return smallInt(0), nil
+	case name == "float" && len(args) == 0:
+		return floatValue(0), nil
+	case name == "bool" && len(args) == 0:
+		return boolValue(false), nil
+	case (name == "min" || name == "max") && len(args) >= 2:
+		op := "<"
+		if name == "max" {
+			op = ">"
+		}
+		best := args[0]
+		for _, arg := range args[1:] {
+			better, err := constCompare(op, arg, best)
+			if err != nil {
+				return constValue{}, err
+			}
+			if better.b {
+				best = arg
+			}
+		}
+		return best, nil
+	case name == "pow" && len(args) == 2:
+		return constBinary("**", args[0], args[1])
+	case len(args) != 1:
+		return constValue{}, errNotConstant
+	}
+
+	arg := args[0]
+	switch name {
+	case "str":
+		return strValue(arg.Str()), nil
+	case "bool":
+		return boolValue(arg.truthy()), nil
+	case "len":
+		if arg.kind == "str" {
+			return smallInt(int64(utf8.RuneCountInString(arg.s))), nil
+		}
+	case "abs":
+		switch {
+		case arg.kind == "float":
+			return floatValue(math.Abs(arg.f)), nil
+		case arg.isIntegral():
+			return intValue(new(big.Int).Abs(arg.toInt())), nil
+		}
+	case "int":
+		switch {
+		case arg.isIntegral():
+			return intValue(arg.toInt()), nil
+		case arg.kind == "float":
+			if math.IsInf(arg.f, 0) || math.IsNaN(arg.f) {
+				return constValue{}, &pythonError{name: "ValueError", message: "cannot convert float " + formatFloat(arg.f) + " to integer"}
+			}
+			i, _ := big.NewFloat(math.Trunc(arg.f)).Int(nil)
+			return intValue(i), nil
+		case arg.kind == "str":
+			text := strings.ReplaceAll(strings.TrimSpace(arg.s), "_", "")
+			if i, ok := new(big.Int).SetString(text, 10); ok {
+				return intValue(i), nil
+			}
+			return constValue{}, &pythonError{name: "ValueError", message: "invalid literal for int() with base 10: " + quoteString(arg.s)}
+		}
+	case "float":
+		switch {
+		case arg.isNumber():
+			value, ok := arg.toFloat()
+			if !ok {
+				return constValue{}, &pythonError{name: "OverflowError", message: "int too large to convert to float"}
+			}
+			return floatValue(value), nil
+		case arg.kind == "str":
+			if value, err := strconv.ParseFloat(strings.TrimSpace(arg.s), 64); err == nil {
+				return floatValue(value), nil
+			}
+			return constValue{}, &pythonError{name: "ValueError", message: "could not convert string to float: " + quoteString(arg.s)}
+		}
+	case "round":
+		switch {
+		case arg.isIntegral():
+			return intValue(arg.toInt()), nil
+		case arg.kind == "float" && !math.IsInf(arg.f, 0) && !math.IsNaN(arg.f):
+			i, _ := big.NewFloat(math.RoundToEven(arg.f)).Int(nil)
+			return intValue(i), nil
+		}
+	}
+	return constValue{}, errNotConstant
+}
+
+// checkDivisionByZero reporta divisiones cuyo divisor siempre vale cero y
+// llamadas a funciones puras que siempre terminan dividiendo por cero
+func checkDivisionByZero(ctx *RuleContext) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+	folding := ctx.constants
+	reported := make(map[*models.ASTNode]bool)
+
+	for _, site := range folding.divisions {
+		node := site.node
+		op := strings.TrimSuffix(node.Value, "=")
+		divisor, known := folding.valueOf(node.Children[1])
+		if !known || !divisor.isZero() {
+			continue
+		}
+		// "texto % 0" es formato de cadenas, no una división
+		if op == "%" && !numericOperand(ctx, node.Children[0], site.scope) {
+			continue
+		}
+
+		reported[node] = true
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Code:     "division-by-zero",
+			Severity: "error",
+			Message:  fmt.Sprintf("'%s' en línea %d siempre lanza ZeroDivisionError%s", formatSource(node), node.Line, divisorNote(node.Children[1], divisor)),
+			Line:     node.Line,
+		})
+	}
+
+	for _, call := range folding.failures {
+		raised := folding.raises[call]
+		if raised.name != "ZeroDivisionError" || raised.origin == nil || reported[raised.origin] {
+			continue
+		}
+		reported[raised.origin] = true
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Code:     "division-by-zero",
+			Severity: "error",
+			Message:  fmt.Sprintf("La llamada '%s' en línea %d siempre lanza ZeroDivisionError al evaluar '%s' (línea %d)", formatSource(call), call.Line, formatSource(raised.origin), raised.origin.Line),
+			Line:     call.Line,
+		})
+	}
+
+	return diagnostics
+}
+
+// divisorNote explica el valor del divisor cuando no es un literal
+func divisorNote(divisor *models.ASTNode, value constValue) string {
+	if _, literal := literalValue(divisor); literal {
+		return ""
+	}
+	return fmt.Sprintf(": el divisor '%s' vale %s", formatSource(divisor), value.Repr())
+}
+
+func numericOperand(ctx *RuleContext, node *models.ASTNode, sc *scope) bool {
+	if value, known := ctx.constants.valueOf(node); known {
+		return value.isNumber()
+	}
+	return ctx.types.expressionType(node, sc).isNumeric()
+}
+
+// checkConstantConditions reporta condiciones de if, while y expresiones
+// condicionales cuyo valor se conoce sin ejecutar el programa. "while True"
+// es un bucle infinito intencional y no se reporta.
+func checkConstantConditions(ctx *RuleContext) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+
+	for _, site := range ctx.constants.conditions {
+		node := site.node
+		condition := node.Children[0]
+		value, known := ctx.constants.valueOf(condition)
+		if !known {
+			continue
+		}
+
+		source := fmt.Sprintf("La condición '%s' en línea %d", formatSource(condition), condition.Line)
+		var message string
+		switch {
+		case node.Type == "IfStatement" && value.truthy() && len(node.Children) > 2:
+			message = fmt.Sprintf("%s siempre es verdadera: %s", source, elseBranchName(node.Children[2]))
+		case node.Type == "IfStatement" && value.truthy():
+			message = fmt.Sprintf("%s siempre es verdadera: el 'if' es innecesario", source)
+		case node.Type == "IfStatement":
+			message = fmt.Sprintf("%s siempre es falsa: el bloque del 'if' nunca se ejecuta", source)
+		case node.Type == "WhileStatement" && value.truthy():
+			if _, literal := literalValue(condition); literal {
+				continue
+			}
+			message = fmt.Sprintf("%s siempre es verdadera: el 'while' solo termina con break, return o una excepción", source)
+		case node.Type == "WhileStatement":
+			message = fmt.Sprintf("%s siempre es falsa: el cuerpo del 'while' nunca se ejecuta", source)
+		case value.truthy():
+			message = fmt.Sprintf("%s siempre es verdadera: la expresión siempre vale '%s'", source, formatSource(node.Children[1]))
+		default:
+			message = fmt.Sprintf("%s siempre es falsa: la expresión siempre vale '%s'", source, formatSource(node.Children[2]))
+		}
+
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Code:     "constant-condition",
+			Severity: "warning",
+			Message:  message,
+			Line:     condition.Line,
+		})
+	}
+
+	return diagnostics
+}
+
+// elseBranchName distingue una rama elif (un IfStatement en la misma línea
+// que su bloque) de un else
+func elseBranchName(block *models.ASTNode) string {
+	if len(block.Children) == 1 && block.Children[0].Type == "IfStatement" && block.Children[0].Line == block.Line {
+		return "las ramas elif/else siguientes nunca se ejecutan"
+	}
+	return "el bloque else nunca se ejecuta"
+}
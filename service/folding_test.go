@@ -0,0 +1,97 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+const factorialSource = "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\n"
+
+func TestConstantFoldingValues(t *testing.T) {
+	result := analyze(t, factorialSource+"x = 2 * 3 + 1\nprint(factorial(5), x, 'a' + 'b', factorial(25))\ny = input()\nprint(y + 'z', factorial(len(y)))\n")
+	want := map[string][2]string{
+		"2 * 3 + 1":     {"7", "int"},
+		"factorial(5)":  {"120", "int"},
+		"\"a\" + \"b\"": {"'ab'", "str"},
+		"factorial(25)": {"15511210043330985984000000", "int"},
+	}
+	for _, constant := range result.Constants {
+		expected, ok := want[constant.Expression]
+		if !ok {
+			t.Errorf("'%s' depende de la entrada y no debe evaluarse: %+v", constant.Expression, constant)
+			continue
+		}
+		if constant.Value != expected[0] || constant.Type != expected[1] {
+			t.Errorf("'%s' vale %s (%s), se esperaba %s (%s)", constant.Expression, constant.Value, constant.Type, expected[0], expected[1])
+		}
+		delete(want, constant.Expression)
+	}
+	if len(want) > 0 {
+		t.Errorf("faltan valores constantes: %v", want)
+	}
+}
+
+func TestConstantFoldingSkipsImpureCalls(t *testing.T) {
+	result := analyze(t, "def noisy(n):\n    print(n)\n    return n\n\ndef forever(n):\n    return forever(n + 1)\n\nprint(noisy(1), forever(0))\n")
+	for _, constant := range result.Constants {
+		if strings.HasPrefix(constant.Expression, "noisy") || strings.HasPrefix(constant.Expression, "forever") {
+			t.Errorf("una función impura o sin límite no se evalúa: %+v", constant)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+		want []int
+	}{
+		{"literal", "print(1 / 0)\n", []int{1}},
+		{"expresión constante", "print(10 // (3 - 3))\n", []int{1}},
+		{"módulo", "x = 5\nx %= 0\nprint(x)\n", []int{2}},
+		{"dentro de una función pura", "def f(n):\n    return n // (n - n)\n\nprint(f(4))\n", []int{4}},
+		{"formato de cadenas", "print('%d' % 0)\n", nil},
+		{"divisor distinto de cero", "print(10 // (3 - 1), 1 / 2)\n", nil},
+		{"variable del módulo", "d = 0\nd = int(input())\nprint(10 // d)\n", nil},
+		{"divisor desconocido", "d = int(input())\nprint(10 // d)\n", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectDiagnostics(t, tc.code, map[string][]int{"division-by-zero": tc.want})
+		})
+	}
+}
+
+func TestDivisionByZeroExplainsDivisor(t *testing.T) {
+	found := diagnosticsWithCode(analyze(t, "print(10 // (3 - 3))\n"), "division-by-zero")
+	if len(found) != 1 || !strings.Contains(found[0].Message, "el divisor '3 - 3' vale 0") {
+		t.Fatalf("el mensaje debe mostrar el valor del divisor: %v", found)
+	}
+}
+
+func TestConstantConditions(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    string
+		want    []int
+		message string
+	}{
+		{"if siempre verdadero", "if 1 == 1:\n    print('a')\n", []int{1}, "siempre es verdadera: el 'if' es innecesario"},
+		{"if siempre falso", "if 5 > 10:\n    print('a')\n", []int{1}, "el bloque del 'if' nunca se ejecuta"},
+		{"else que nunca se ejecuta", "if 2 > 1:\n    print('a')\nelse:\n    print('b')\n", []int{1}, "else"},
+		{"while falso", "while 1 > 2:\n    print('a')\n", []int{1}, "el cuerpo del 'while' nunca se ejecuta"},
+		{"expresión condicional", "print('a' if 3 > 2 else 'b')\n", []int{1}, "la expresión siempre vale"},
+		{"while True intencional", "while True:\n    break\n", nil, ""},
+		{"condición de la entrada", "n = int(input())\nif n > 10:\n    print('a')\n", nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := expectDiagnostics(t, tc.code, map[string][]int{"constant-condition": tc.want})
+			for _, diagnostic := range diagnosticsWithCode(result, "constant-condition") {
+				if !strings.Contains(diagnostic.Message, tc.message) {
+					t.Errorf("el mensaje debe contener %q: %s", tc.message, diagnostic.Message)
+				}
+			}
+		})
+	}
+}
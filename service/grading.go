@@ -0,0 +1,428 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"examen-back/models"
+)
+
+// MaxTestCases limita los casos de una entrega: cada caso ejecuta el programa completo
+const MaxTestCases = 100
+
+// MaxGradeTimeMs es el tiempo total de una entrega. Cada caso tiene su propio
+// tiempo máximo, pero sin este límite 100 casos podrían ocupar el servidor
+// más de un cuarto de hora.
+const MaxGradeTimeMs = 30000
+
+// gradeBudget es MaxGradeTimeMs como duración; las pruebas lo acortan
+var gradeBudget = MaxGradeTimeMs * time.Millisecond
+
+// maxDiffCells limita la tabla del diff (líneas esperadas × obtenidas)
+const maxDiffCells = 1 << 20
+
+// parsedTest son las expresiones de un caso de prueba ya analizadas
+type parsedTest struct {
+	expression *models.ASTNode
+	call       *models.ASTNode
+	expected   *models.ASTNode
+}
+
+// parseTestCase valida un caso de prueba y analiza sus expresiones
+func parseTestCase(test models.TestCase) (parsedTest, error) {
+	var parsed parsedTest
+	switch {
+	case test.Expression != "" && test.Call != "":
+		return parsed, fmt.Errorf("indica 'expression' o 'call', no ambos")
+	case test.Expected != "" && test.Call == "":
+		return parsed, fmt.Errorf("'expected' necesita 'call'")
+	case test.Expression == "" && test.Call == "" && test.ExpectedStdout == nil && test.Raises == "":
+		return parsed, fmt.Errorf("el caso no verifica nada: indica 'expression', 'call', 'expectedStdout' o 'raises'")
+	case test.Weight < 0:
+		return parsed, fmt.Errorf("el peso no puede ser negativo")
+	}
+
+	fields := []struct {
+		source string
+		target **models.ASTNode
+	}{
+		{test.Expression, &parsed.expression},
+		{test.Call, &parsed.call},
+		{test.Expected, &parsed.expected},
+	}
+	for _, field := range fields {
+		if field.source == "" {
+			continue
+		}
+		node, err := ParseRunCall(field.source)
+		if err != nil {
+			return parsed, fmt.Errorf("'%s': %w", field.source, err)
+		}
+		*field.target = node
+	}
+	return parsed, nil
+}
+
+// ValidateTestCase comprueba que un caso de prueba se pueda ejecutar
+func ValidateTestCase(test models.TestCase) error {
+	_, err := parseTestCase(test)
+	return err
+}
+
+// GradeSubmission ejecuta cada caso de prueba en un intérprete nuevo, de modo
+// que el estado que deja un caso no afecta a los siguientes. El puntaje es el
+// porcentaje del peso de los casos aprobados (peso 1 por defecto). Con el
+// motor "vm" el programa se compila una sola vez para todos los casos. Cuando
+// se agota MaxGradeTimeMs los casos restantes no se ejecutan y no se aprueban.
+func GradeSubmission(ast *models.ASTNode, tests []models.TestCase, requested *models.RunLimits, engine string) models.GradeResult {
+	limits := NormalizeRunLimits(requested)
+	result := models.GradeResult{
+		Tests:  make([]models.TestResult, 0, len(tests)),
+		Total:  len(tests),
+		Limits: limits,
+		Engine: EngineTree,
+	}
+	var program *BytecodeProgram
+	if engine == EngineVM {
+		program = CompileProgram(ast)
+		result.Engine = EngineVM
+	}
+
+	deadline := time.Now().Add(gradeBudget)
+	totalWeight, earned := 0.0, 0.0
+	for i, test := range tests {
+		var outcome models.TestResult
+		remaining := int(time.Until(deadline).Milliseconds())
+		switch {
+		case remaining <= 0:
+			outcome = newTestResult(test, i)
+			outcome.Message = fmt.Sprintf("No se ejecutó: se agotó el tiempo total de la entrega (%d ms)", gradeBudget.Milliseconds())
+			result.BudgetExhausted = true
+		case remaining < limits.TimeoutMs:
+			// El caso solo dispone de lo que queda del tiempo total
+			shortened := limits
+			shortened.TimeoutMs = remaining
+			outcome = gradeTest(ast, program, test, shortened, i)
+			if outcome.Error != nil && outcome.Error.Type == "TimeLimitExceeded" {
+				outcome.Message = fmt.Sprintf("La ejecución se detuvo porque se agotó el tiempo total de la entrega (%d ms)", gradeBudget.Milliseconds())
+				result.BudgetExhausted = true
+			}
+		default:
+			outcome = gradeTest(ast, program, test, limits, i)
+		}
+		result.Tests = append(result.Tests, outcome)
+		result.Steps += outcome.Steps
+		result.DurationMs += outcome.DurationMs
+		totalWeight += outcome.Weight
+		if outcome.Passed {
+			result.Passed++
+			earned += outcome.Weight
+		}
+	}
+	result.DurationMs = round2(result.DurationMs)
+	if totalWeight > 0 {
+		result.Score = round2(100 * earned / totalWeight)
+	}
+	return result
+}
+
+// testName devuelve el nombre del caso o, si no tiene, su expresión
+func testName(test models.TestCase, index int) string {
+	switch {
+	case test.Name != "":
+		return test.Name
+	case test.Expression != "":
+		return test.Expression
+	case test.Call != "" && test.Expected != "":
+		return test.Call + " == " + test.Expected
+	case test.Call != "":
+		return test.Call
+	}
+	return fmt.Sprintf("caso %d", index+1)
+}
+
+// newTestResult es el resultado de un caso todavía sin ejecutar
+func newTestResult(test models.TestCase, index int) models.TestResult {
+	outcome := models.TestResult{
+		Name:           testName(test, index),
+		ExpectedStdout: test.ExpectedStdout,
+		Weight:         test.Weight,
+	}
+	if outcome.Weight == 0 {
+		outcome.Weight = 1
+	}
+	return outcome
+}
+
+func gradeTest(ast *models.ASTNode, program *BytecodeProgram, test models.TestCase, limits models.RunLimits, index int) models.TestResult {
+	outcome := newTestResult(test, index)
+	parsed, err := parseTestCase(test)
+	if err != nil {
+		outcome.Message = "Caso de prueba inválido: " + err.Error()
+		return outcome
+	}
+
+	in := NewInterpreter(ast, limits)
+	if program != nil {
+		in.UseBytecode(program)
+	}
+	outputStart := 0
+	valueOK, raised := true, false
+	run := in.session(test.Stdin, func(module *frame, _ *models.RunResult) error {
+		var err error
+		if in.ast != nil {
+			_, err = in.runModule(in.ast.Children, module)
+		}
+		// Con una aserción o una llamada solo cuenta la salida que producen
+		if err == nil && (parsed.expression != nil || parsed.call != nil) {
+			outputStart = in.stdout.Len()
+			valueOK, err = in.checkValue(parsed, module, &outcome)
+		}
+		if exception, ok := err.(*pyException); ok && test.Raises != "" && raises(exception, test.Raises) {
+			raised, valueOK = true, true
+			outcome.Actual = exception.value.class.name
+			if message, strErr := in.exceptionString(exception.value); strErr == nil && message != "" {
+				outcome.Actual += ": " + message
+			}
+			return nil
+		}
+		return err
+	})
+
+	outcome.Error = run.Error
+	outcome.Steps = run.Steps
+	outcome.MaxDepth = run.MaxDepth
+	outcome.MemoryBytes = run.MemoryBytes
+	outcome.DurationMs = run.DurationMs
+	outcome.Stdout = run.Stdout[min(outputStart, len(run.Stdout)):]
+
+	diffs := make([]string, 0, 2)
+	if !valueOK && outcome.Expected != "" {
+		diffs = append(diffs, unifiedDiff(outcome.Expected, outcome.Actual))
+	}
+	stdoutOK := true
+	if test.ExpectedStdout != nil {
+		expected, actual := normalizeOutput(*test.ExpectedStdout), normalizeOutput(outcome.Stdout)
+		if stdoutOK = expected == actual; !stdoutOK {
+			diffs = append(diffs, unifiedDiff(expected, actual))
+		}
+	}
+	outcome.Diff = strings.Join(diffs, "\n")
+
+	switch {
+	case run.Error != nil && run.Error.Limit:
+		outcome.Message = "La ejecución se detuvo por un límite del sandbox: " + run.Error.Message
+	case run.Error != nil && test.Raises != "":
+		outcome.Message = fmt.Sprintf("Se esperaba %s pero se lanzó %s", test.Raises, describeError(run.Error))
+	case run.Error != nil:
+		outcome.Message = "Error de ejecución: " + describeError(run.Error)
+	case test.Raises != "" && !raised:
+		outcome.Message = fmt.Sprintf("Se esperaba la excepción %s y no se lanzó", test.Raises)
+	case !valueOK && outcome.Expected != "":
+		outcome.Message = fmt.Sprintf("Se obtuvo %s y se esperaba %s", outcome.Actual, outcome.Expected)
+	case !valueOK:
+		outcome.Message = fmt.Sprintf("La aserción es falsa (valor: %s)", outcome.Actual)
+	case !stdoutOK:
+		outcome.Message = "La salida no coincide con la esperada"
+	default:
+		outcome.Passed = true
+		outcome.Message = "Correcto"
+	}
+	return outcome
+}
+
+// describeError resume un error de ejecución con su línea si es del programa
+func describeError(err *models.RuntimeError) string {
+	if err.Line > 0 {
+		return fmt.Sprintf("%s: %s (línea %d)", err.Type, err.Message, err.Line)
+	}
+	return err.Type + ": " + err.Message
+}
+
+// raises indica si la excepción es de la clase indicada o de una subclase,
+// integrada o del programa
+func raises(exception *pyException, name string) bool {
+	for _, class := range exception.value.class.mro {
+		if class.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValue evalúa la aserción o la llamada del caso y registra los valores
+// obtenido y esperado. En una aserción de comparación ("f(3) == 6") se evalúa
+// cada lado por separado para mostrar ambos valores.
+func (in *Interpreter) checkValue(parsed parsedTest, module *frame, outcome *models.TestResult) (bool, error) {
+	if parsed.expression != nil {
+		expr := parsed.expression.Children[0]
+		if expr.Type != "BinaryOp" || !isComparison(expr.Value) {
+			value, err := in.evalDetached(parsed.expression, expr, module)
+			if err != nil {
+				return false, err
+			}
+			if outcome.Actual, err = in.repr(value); err != nil {
+				return false, err
+			}
+			return in.truthy(value)
+		}
+
+		left, err := in.evalDetached(parsed.expression, expr.Children[0], module)
+		if err != nil {
+			return false, err
+		}
+		right, err := in.eval(expr.Children[1], module)
+		if err != nil {
+			return false, err
+		}
+		verdict, err := in.binary(expr.Value, left, right)
+		if err != nil {
+			return false, err
+		}
+		if outcome.Actual, err = in.repr(left); err != nil {
+			return false, err
+		}
+		if outcome.Expected, err = in.repr(right); err != nil {
+			return false, err
+		}
+		return in.truthy(verdict)
+	}
+
+	actual, err := in.evalDetached(parsed.call, parsed.call.Children[0], module)
+	if err != nil {
+		return false, err
+	}
+	if outcome.Actual, err = in.repr(actual); err != nil || parsed.expected == nil {
+		return err == nil, err
+	}
+	expected, err := in.evalDetached(parsed.expected, parsed.expected.Children[0], module)
+	if err != nil {
+		return false, err
+	}
+	if outcome.Expected, err = in.repr(expected); err != nil {
+		return false, err
+	}
+	return in.equal(actual, expected)
+}
+
+// normalizeOutput ignora los espacios al final de cada línea, los saltos de
+// línea finales y la diferencia entre \r\n y \n
+func normalizeOutput(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// diffLine es una línea del diff: ' ' común, '-' solo esperada, '+' solo obtenida
+type diffLine struct {
+	kind     byte
+	text     string
+	expected int
+	actual   int
+}
+
+// unifiedDiff compara dos textos línea a línea y devuelve las diferencias en
+// formato unificado, con "-" para lo esperado y "+" para lo obtenido
+func unifiedDiff(expected, actual string) string {
+	a, b := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	lines := diffLines(a, b)
+
+	const context = 2
+	var builder strings.Builder
+	builder.WriteString("--- esperado\n+++ obtenido\n")
+	for start := 0; start < len(lines); {
+		if lines[start].kind == ' ' {
+			start++
+			continue
+		}
+		// El bloque se extiende mientras los cambios estén a menos de 2*context líneas
+		first := max(start-context, 0)
+		end, quiet := start, 0
+		for end < len(lines) && quiet <= 2*context {
+			if lines[end].kind == ' ' {
+				quiet++
+			} else {
+				quiet = 0
+			}
+			end++
+		}
+		end -= max(quiet-context, 0)
+
+		expectedCount, actualCount := 0, 0
+		for _, line := range lines[first:end] {
+			if line.kind != '+' {
+				expectedCount++
+			}
+			if line.kind != '-' {
+				actualCount++
+			}
+		}
+		fmt.Fprintf(&builder, "@@ -%d,%d +%d,%d @@\n", lines[first].expected, expectedCount, lines[first].actual, actualCount)
+		for _, line := range lines[first:end] {
+			builder.WriteByte(line.kind)
+			builder.WriteString(line.text)
+			builder.WriteByte('\n')
+		}
+		start = end
+	}
+	return builder.String()
+}
+
+// diffLines alinea las líneas con la subsecuencia común más larga. Si los
+// textos son demasiado grandes se comparan solo a partir del primer cambio
+// como un reemplazo completo.
+func diffLines(a, b []string) []diffLine {
+	lines := make([]diffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	emit := func(kind byte, text string) {
+		lines = append(lines, diffLine{kind: kind, text: text, expected: i + 1, actual: j + 1})
+		if kind != '+' {
+			i++
+		}
+		if kind != '-' {
+			j++
+		}
+	}
+
+	if len(a)*len(b) > maxDiffCells {
+		for i < len(a) && j < len(b) && a[i] == b[j] {
+			emit(' ', a[i])
+		}
+		for i < len(a) {
+			emit('-', a[i])
+		}
+		for j < len(b) {
+			emit('+', b[j])
+		}
+		return lines
+	}
+
+	// common[x][y] es la longitud de la subsecuencia común de a[x:] y b[y:]
+	common := make([][]int, len(a)+1)
+	for x := range common {
+		common[x] = make([]int, len(b)+1)
+	}
+	for x := len(a) - 1; x >= 0; x-- {
+		for y := len(b) - 1; y >= 0; y-- {
+			if a[x] == b[y] {
+				common[x][y] = common[x+1][y+1] + 1
+			} else {
+				common[x][y] = max(common[x+1][y], common[x][y+1])
+			}
+		}
+	}
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			emit(' ', a[i])
+		case j < len(b) && (i == len(a) || common[i][j+1] > common[i+1][j]):
+			emit('+', b[j])
+		default:
+			emit('-', a[i])
+		}
+	}
+	return lines
+}
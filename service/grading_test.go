@@ -0,0 +1,44 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"examen-back/models"
+)
+
+func TestGradeSubmissionTimeBudget(t *testing.T) {
+	defer func(previous time.Duration) { gradeBudget = previous }(gradeBudget)
+	gradeBudget = 300 * time.Millisecond
+
+	const code = "def f(n):\n    while True:\n        pass\n"
+	tests := make([]models.TestCase, MaxTestCases)
+	for i := range tests {
+		tests[i] = models.TestCase{Call: "f(1)", Expected: "1"}
+	}
+	limits := &models.RunLimits{MaxSteps: maxRunSteps, TimeoutMs: 200}
+
+	start := time.Now()
+	result := GradeSubmission(parse(code).AST, tests, limits, EngineTree)
+	if elapsed := time.Since(start); elapsed > gradeBudget+time.Second {
+		t.Errorf("la entrega tardó %v con un tiempo total de %v", elapsed, gradeBudget)
+	}
+	if !result.BudgetExhausted || result.Passed != 0 || len(result.Tests) != MaxTestCases {
+		t.Fatalf("resultado inesperado: agotado %v, %d aprobados, %d casos", result.BudgetExhausted, result.Passed, len(result.Tests))
+	}
+	if result.Limits.TimeoutMs != 200 {
+		t.Errorf("los límites informados deben ser los pedidos, no los acortados: %+v", result.Limits)
+	}
+	// El primer caso agota su propio tiempo, el segundo el que queda del total
+	// y los demás no llegan a ejecutarse
+	messages := []string{"límite del sandbox", "se agotó el tiempo total", "No se ejecutó"}
+	for i, message := range messages {
+		if !strings.Contains(result.Tests[i].Message, message) {
+			t.Errorf("caso %d: %q no contiene %q", i+1, result.Tests[i].Message, message)
+		}
+	}
+	if last := result.Tests[MaxTestCases-1]; last.Steps != 0 || last.Weight != 1 {
+		t.Errorf("el último caso no debía ejecutarse: %+v", last)
+	}
+}
@@ -0,0 +1,1030 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"examen-back/models"
+)
+
+// functionType es la firma inferida de una FunctionDef: los tipos de los
+// parámetros salen de la anotación o de los argumentos de cada llamada, y el
+// de retorno de la unión de sus 'return'
+type functionType struct {
+	inference      *typeInference
+	node           *models.ASTNode
+	params         []*models.ASTNode
+	names          []string
+	symbols        []*scopeSymbol
+	declared       []*pyType
+	result         *pyType
+	declaredResult *pyType
+	fallsOff       bool
+	// numericUse guarda, por parámetro, una operación del cuerpo que exige un número ("n - 1")
+	numericUse map[int]*models.ASTNode
+}
+
+var typeFunction = &pyType{name: "function"}
+
+func (f *functionType) paramType(i int) *pyType {
+	if f.declared[i] != nil {
+		return f.declared[i]
+	}
+	return f.inference.symbols[f.symbols[i]]
+}
+
+func (f *functionType) returnType() *pyType {
+	if f.declaredResult != nil {
+		return f.declaredResult
+	}
+	if f.fallsOff {
+		return joinTypes(f.result, typeNone)
+	}
+	return f.result
+}
+
+// signature muestra la firma como "(int) -> int"; los parámetros sin
+// información se muestran como object
+func (f *functionType) signature() string {
+	parts := make([]string, len(f.names))
+	for i, name := range f.names {
+		switch {
+		case strings.HasPrefix(name, "*"):
+			parts[i] = name
+		case f.paramType(i) == nil:
+			parts[i] = "object"
+		default:
+			parts[i] = typeLabel(f.paramType(i))
+		}
+	}
+	result := f.returnType()
+	if result == nil {
+		result = typeObject
+	}
+	return "(" + strings.Join(parts, ", ") + ") -> " + typeLabel(result)
+}
+
+// typeLabel evita expandir firmas anidadas (una función que devuelve otra)
+func typeLabel(t *pyType) string {
+	if t != nil && t.function != nil {
+		return "function"
+	}
+	return t.String()
+}
+
+// typeInference calcula los tipos de forma insensible al flujo: cada símbolo
+// acumula la unión de todo lo que se le asigna y se itera hasta un punto fijo
+type typeInference struct {
+	tree        *scopeTree
+	symbols     map[*scopeSymbol]*pyType
+	declared    map[*scopeSymbol]*pyType
+	functions   map[*models.ASTNode]*functionType
+	changed     bool
+	reporting   bool
+	reported    map[*models.ASTNode]bool
+	diagnostics []models.Diagnostic
+}
+
+const maxInferencePasses = 20
+
+var statementTypes = map[string]bool{
+	"Program": true, "Block": true, "IfStatement": true, "WhileStatement": true, "TryStatement": true,
+	"RaiseStatement": true, "BreakStatement": true, "ContinueStatement": true, "PassStatement": true,
+	"GlobalStatement": true, "NonlocalStatement": true,
+}
+
+func inferTypes(ast *models.ASTNode, tree *scopeTree, calls *callGraph, graphs []*controlFlowGraph) *typeInference {
+	ti := &typeInference{
+		tree:      tree,
+		symbols:   make(map[*scopeSymbol]*pyType),
+		declared:  make(map[*scopeSymbol]*pyType),
+		functions: make(map[*models.ASTNode]*functionType),
+		reported:  make(map[*models.ASTNode]bool),
+	}
+	if ast == nil {
+		return ti
+	}
+
+	fallsOff := make(map[*models.ASTNode]bool)
+	for _, graph := range graphs {
+		reachable := graph.reachable()
+		for _, pred := range graph.exit.preds {
+			if reachable[pred] && pred.hasNormalEdgeTo(graph.exit) {
+				fallsOff[graph.node] = true
+			}
+		}
+	}
+	for _, fn := range calls.functions {
+		ti.functions[fn] = ti.newFunctionType(fn, fallsOff[fn])
+	}
+
+	for pass := 0; pass < maxInferencePasses; pass++ {
+		ti.changed = false
+		ti.walk(ast, tree.module, nil)
+		if !ti.changed {
+			break
+		}
+	}
+
+	ti.reporting = true
+	ti.walk(ast, tree.module, nil)
+
+	for symbol, t := range ti.symbols {
+		symbol.inferredType = typeOrFunction(t)
+	}
+
+	return ti
+}
+
+func typeOrFunction(t *pyType) string {
+	if t != nil && t.function != nil {
+		return t.function.signature()
+	}
+	return t.String()
+}
+
+func (ti *typeInference) newFunctionType(node *models.ASTNode, fallsOff bool) *functionType {
+	ft := &functionType{
+		inference:  ti,
+		node:       node,
+		fallsOff:   fallsOff,
+		numericUse: make(map[int]*models.ASTNode),
+	}
+	sc := ti.tree.byNode[node]
+	params, body := functionParts(node)
+
+	// Las listas de ft van en paralelo a los parámetros de functionParts; el
+	// separador '*' de los parámetros nombrados ocupa su lugar sin símbolo
+	for _, param := range params {
+		name := parameterName(param)
+		if name == "" {
+			ft.params = append(ft.params, param)
+			ft.names = append(ft.names, param.Value)
+			ft.symbols = append(ft.symbols, nil)
+			ft.declared = append(ft.declared, nil)
+			continue
+		}
+		symbol := sc.symbols[name]
+		label := name
+		if strings.HasPrefix(param.Value, "*") {
+			label = param.Value
+		}
+
+		var declared *pyType
+		if annotation := annotationOf(param); annotation != nil {
+			declared = typeFromAnnotation(annotation)
+			ti.declared[symbol] = declared
+			ti.symbols[symbol] = declared
+		} else if strings.HasPrefix(param.Value, "**") {
+			ti.symbols[symbol] = simpleType("dict")
+		} else if strings.HasPrefix(param.Value, "*") {
+			ti.symbols[symbol] = simpleType("tuple")
+		}
+
+		ft.params = append(ft.params, param)
+		ft.names = append(ft.names, label)
+		ft.symbols = append(ft.symbols, symbol)
+		ft.declared = append(ft.declared, declared)
+	}
+
+	if annotation := annotationOf(node); annotation != nil {
+		ft.declaredResult = typeFromAnnotation(annotation)
+	}
+
+	for _, stmt := range body {
+		ti.findNumericUses(stmt, ft)
+	}
+	return ft
+}
+
+// findNumericUses busca operaciones con un literal numérico que fallan si el
+// parámetro no es un número: "n - 1", "n <= 1", "-n". "n * 2" no cuenta porque
+// también es válido con cadenas y listas.
+func (ti *typeInference) findNumericUses(node *models.ASTNode, ft *functionType) {
+	switch node.Type {
+	case "FunctionDef", "Lambda", "ClassDef":
+		return
+	case "BinaryOp":
+		if (isArithmetic(node.Value) && node.Value != "*" && node.Value != "%") || isOrdering(node.Value) {
+			for i, operand := range node.Children {
+				if node.Children[1-i].Type == "Number" {
+					ti.markNumericUse(operand, node, ft)
+				}
+			}
+		}
+	case "UnaryOp":
+		if node.Value == "-" || node.Value == "~" {
+			ti.markNumericUse(node.Children[0], node, ft)
+		}
+	}
+
+	for _, child := range node.Children {
+		ti.findNumericUses(child, ft)
+	}
+}
+
+func (ti *typeInference) markNumericUse(operand, use *models.ASTNode, ft *functionType) {
+	if operand.Type != "Identifier" {
+		return
+	}
+	for i, symbol := range ft.symbols {
+		if _, seen := ft.numericUse[i]; !seen && symbol != nil && ti.tree.resolved[operand] == symbol && ft.declared[i] == nil {
+			ft.numericUse[i] = use
+		}
+	}
+}
+
+func isOrdering(op string) bool {
+	return op == "<" || op == "<=" || op == ">" || op == ">="
+}
+
+// typeFromAnnotation interpreta "int", "list[int]", "int | None" o "Optional"-like simples
+func typeFromAnnotation(node *models.ASTNode) *pyType {
+	switch node.Type {
+	case "Identifier":
+		return simpleType(node.Value)
+	case "None":
+		return typeNone
+	case "String":
+		return parseTypeName(node.Value)
+	case "Subscript":
+		if node.Children[0].Type == "Identifier" && containerTypes[node.Children[0].Value] {
+			inner := node.Children[1]
+			if inner.Type == "Tuple" && len(inner.Children) > 0 {
+				inner = inner.Children[0]
+			}
+			return containerOf(node.Children[0].Value, typeFromAnnotation(inner))
+		}
+	case "BinaryOp":
+		if node.Value == "|" {
+			return joinTypes(typeFromAnnotation(node.Children[0]), typeFromAnnotation(node.Children[1]))
+		}
+	}
+	return typeObject
+}
+
+// bindingSymbol devuelve el símbolo que recibe una asignación a name en sc,
+// siguiendo las declaraciones global y nonlocal
+func (ti *typeInference) bindingSymbol(sc *scope, name string) *scopeSymbol {
+	if sc == nil {
+		return nil
+	}
+	symbol := sc.symbols[name]
+	switch {
+	case symbol == nil:
+		return nil
+	case symbol.binding == "global" && sc != ti.tree.module:
+		return ti.tree.module.symbols[name]
+	case symbol.binding == "nonlocal" || symbol.binding == "free":
+		return symbol.target
+	}
+	return symbol
+}
+
+func (ti *typeInference) assign(symbol *scopeSymbol, t *pyType) {
+	if symbol == nil || t == nil {
+		return
+	}
+	if _, declared := ti.declared[symbol]; declared {
+		return
+	}
+	joined := joinTypes(ti.symbols[symbol], t)
+	if !sameType(joined, ti.symbols[symbol]) {
+		ti.symbols[symbol] = joined
+		ti.changed = true
+	}
+}
+
+func (ti *typeInference) assignTarget(target *models.ASTNode, t *pyType, sc *scope) {
+	switch target.Type {
+	case "Identifier":
+		ti.assign(ti.bindingSymbol(sc, target.Value), t)
+	case "Tuple", "List":
+		for _, child := range target.Children {
+			ti.assignTarget(child, elementType(t), sc)
+		}
+	default:
+		ti.typeOf(target, sc)
+	}
+}
+
+func (ti *typeInference) report(node *models.ASTNode, severity, code, message string) {
+	if !ti.reporting || ti.reported[node] {
+		return
+	}
+	ti.reported[node] = true
+	ti.diagnostics = append(ti.diagnostics, models.Diagnostic{
+		Code:     code,
+		Severity: severity,
+		Message:  message,
+		Line:     node.Line,
+	})
+}
+
+// walk recorre las sentencias; fn es la firma de la función que las contiene
+func (ti *typeInference) walk(node *models.ASTNode, sc *scope, fn *functionType) {
+	switch node.Type {
+	case "FunctionDef":
+		ft := ti.functions[node]
+		_, body := functionParts(node)
+		for i, param := range ft.params {
+			if value := parameterDefault(param); value != nil {
+				ti.assign(ft.symbols[i], ti.typeOf(value, sc))
+			}
+		}
+		ti.assign(ti.bindingSymbol(sc, node.Value), &pyType{name: "function", function: ft})
+		for _, stmt := range body {
+			ti.walk(stmt, ti.tree.byNode[node], ft)
+		}
+
+	case "ClassDef":
+		class := ti.tree.byNode[node]
+		for _, child := range node.Children {
+			if child.Type == "Base" {
+				for _, base := range child.Children {
+					ti.typeOf(base, sc)
+				}
+			} else {
+				ti.walk(child, class, nil)
+			}
+		}
+
+	case "Assignment":
+		if node.Value == "" {
+			ti.assignTarget(node.Children[0], ti.typeOf(node.Children[1], sc), sc)
+			return
+		}
+		t := ti.typeOf(node.Children[0], sc)
+		symbol := ti.bindingSymbol(sc, node.Value)
+		if annotation := annotationOf(node); annotation != nil {
+			declared := typeFromAnnotation(annotation)
+			ti.declareSymbol(symbol, declared)
+			if t.isDefinite() && !compatibleType(t, declared) {
+				ti.report(node, "error", "type-mismatch", fmt.Sprintf("Se asigna un valor %s a '%s' en línea %d, pero la variable está anotada como %s", t, node.Value, node.Line, declared))
+			}
+		}
+		ti.assign(symbol, t)
+
+	case "AnnotatedName":
+		ti.declareSymbol(ti.bindingSymbol(sc, node.Value), typeFromAnnotation(annotationOf(node)))
+
+	case "AugAssignment":
+		op := strings.TrimSuffix(node.Value, "=")
+		target := node.Children[0]
+		current := ti.typeOf(target, sc)
+		value := ti.typeOf(node.Children[1], sc)
+		result, ok := binaryType(op, current, value)
+		if !ok {
+			ti.report(node, "error", "type-mismatch", fmt.Sprintf("La operación '%s' en línea %d combina %s y %s, lo que produce TypeError al ejecutarse", formatSource(node), node.Line, current, value))
+		}
+		if target.Type == "Identifier" {
+			ti.assign(ti.bindingSymbol(sc, target.Value), result)
+		}
+
+	case "ForStatement":
+		target, iter := node.Children[0], node.Children[1]
+		if items, ok := ti.enumerated(iter, sc); ok && target.Type == "Tuple" && len(target.Children) == 2 {
+			// for i, x in enumerate(xs): i es int y x un elemento de xs
+			ti.assignTarget(target.Children[0], typeInt, sc)
+			ti.assignTarget(target.Children[1], elementType(items), sc)
+		} else {
+			ti.assignTarget(target, elementType(ti.typeOf(iter, sc)), sc)
+		}
+		for _, child := range node.Children[2:] {
+			ti.walk(child, sc, fn)
+		}
+
+	case "ReturnStatement":
+		t := typeNone
+		if len(node.Children) > 0 {
+			t = ti.typeOf(node.Children[0], sc)
+		}
+		if fn == nil || t == nil {
+			return
+		}
+		if fn.declaredResult != nil && t.isDefinite() && !compatibleType(t, fn.declaredResult) {
+			ti.report(node, "error", "return-type-mismatch", fmt.Sprintf("La función '%s' declara devolver %s, pero '%s' en línea %d devuelve %s", fn.node.Value, fn.declaredResult, formatSource(node), node.Line, t))
+		}
+		if joined := joinTypes(fn.result, t); !sameType(joined, fn.result) {
+			fn.result = joined
+			ti.changed = true
+		}
+
+	case "ExceptHandler":
+		if len(node.Children) > 1 {
+			ti.typeOf(node.Children[0], sc)
+		}
+		if node.Value != "" {
+			ti.assign(ti.bindingSymbol(sc, node.Value), typeObject)
+		}
+		ti.walk(node.Children[len(node.Children)-1], sc, fn)
+
+	case "Import":
+		for _, alias := range node.Children {
+			name := strings.Split(alias.Value, ".")[0]
+			if len(alias.Children) > 0 {
+				name = alias.Children[0].Value
+			}
+			ti.assign(ti.bindingSymbol(sc, name), typeModule)
+		}
+
+	case "ImportFrom":
+		for _, alias := range node.Children {
+			name := alias.Value
+			if len(alias.Children) > 0 {
+				name = alias.Children[0].Value
+			}
+			if symbol := ti.bindingSymbol(sc, name); symbol != nil {
+				ti.assign(symbol, ti.moduleMemberType(symbol))
+			}
+		}
+
+	default:
+		if !statementTypes[node.Type] {
+			ti.typeOf(node, sc)
+			return
+		}
+		for _, child := range node.Children {
+			ti.walk(child, sc, fn)
+		}
+	}
+}
+
+// enumerated devuelve el tipo de xs si iter es la llamada a la función
+// integrada enumerate(xs)
+func (ti *typeInference) enumerated(iter *models.ASTNode, sc *scope) (*pyType, bool) {
+	if iter.Type != "FunctionCall" || iter.Value != "enumerate" || ti.tree.resolved[iter] != nil ||
+		len(iter.Children) == 0 || iter.Children[0].Type == "Keyword" || iter.Children[0].Type == "Starred" {
+		return nil, false
+	}
+	ti.typeOf(iter, sc)
+	return ti.typeOf(iter.Children[0], sc), true
+}
+
+func (ti *typeInference) declareSymbol(symbol *scopeSymbol, t *pyType) {
+	if symbol == nil {
+		return
+	}
+	if _, declared := ti.declared[symbol]; !declared {
+		ti.declared[symbol] = t
+		ti.symbols[symbol] = t
+		ti.changed = true
+	}
+}
+
+// expressionType calcula el tipo de una expresión después de la inferencia,
+// sin reportar diagnósticos
+func (ti *typeInference) expressionType(node *models.ASTNode, sc *scope) *pyType {
+	reporting := ti.reporting
+	ti.reporting = false
+	defer func() { ti.reporting = reporting }()
+	return ti.typeOf(node, sc)
+}
+
+// typeOf calcula el tipo de una expresión; nil si todavía no hay información
+func (ti *typeInference) typeOf(node *models.ASTNode, sc *scope) *pyType {
+	switch node.Type {
+	case "Number":
+		return numberType(node.Value)
+	case "String":
+		return typeStr
+	case "Boolean":
+		return typeBool
+	case "None":
+		return typeNone
+
+	case "Identifier":
+		return ti.identifierType(node)
+
+	case "List", "Set", "Tuple":
+		var elem *pyType
+		for _, child := range node.Children {
+			elem = joinTypes(elem, ti.typeOf(child, sc))
+		}
+		if elem == nil {
+			elem = typeObject
+		}
+		if len(node.Children) == 0 {
+			return simpleType(strings.ToLower(node.Type))
+		}
+		return containerOf(strings.ToLower(node.Type), elem)
+
+	case "Dict":
+		var key *pyType
+		for i, child := range node.Children {
+			t := ti.typeOf(child, sc)
+			if i%2 == 0 {
+				key = joinTypes(key, t)
+			}
+		}
+		if key == nil {
+			return simpleType("dict")
+		}
+		return containerOf("dict", key)
+
+	case "ListComp", "SetComp", "DictComp", "GeneratorExp":
+		return ti.comprehensionType(node, sc)
+
+	case "Lambda":
+		params, body := functionParts(node)
+		for _, param := range params {
+			if value := parameterDefault(param); value != nil {
+				ti.typeOf(value, sc)
+			}
+		}
+		for _, expr := range body {
+			ti.typeOf(expr, ti.tree.byNode[node])
+		}
+		return typeFunction
+
+	case "IfExpression":
+		ti.typeOf(node.Children[0], sc)
+		return joinTypes(ti.typeOf(node.Children[1], sc), ti.typeOf(node.Children[2], sc))
+
+	case "UnaryOp":
+		operand := ti.typeOf(node.Children[0], sc)
+		result, ok := unaryType(node.Value, operand)
+		if !ok {
+			ti.report(node, "error", "type-mismatch", fmt.Sprintf("El operador '%s' no admite un operando %s en '%s' (línea %d): TypeError al ejecutarse", node.Value, operand, formatSource(node), node.Line))
+		}
+		return result
+
+	case "BinaryOp":
+		return ti.binaryOpType(node, sc)
+
+	case "Subscript":
+		container := ti.typeOf(node.Children[0], sc)
+		index := node.Children[1]
+		if index.Type == "Slice" {
+			for _, part := range index.Children {
+				ti.typeOf(part, sc)
+			}
+			return container
+		}
+		ti.typeOf(index, sc)
+		if container != nil && container.name == "dict" {
+			return typeObject
+		}
+		return elementType(container)
+
+	case "Attribute":
+		if path := dottedName(node); path != "" {
+			if symbol := ti.tree.resolved[node]; symbol != nil && symbol.kind == "module" {
+				if member, known := modulePathMember(symbol, path); known {
+					return memberValueType(member)
+				}
+				return typeObject
+			}
+		}
+		for _, child := range node.Children {
+			ti.typeOf(child, sc)
+		}
+		return typeObject
+
+	case "FunctionCall":
+		return ti.callType(node, sc)
+
+	case "MethodCall":
+		receiver := ti.typeOf(node.Children[0].Children[0], sc)
+		for _, arg := range node.Children[1:] {
+			ti.typeOf(arg, sc)
+		}
+		return methodType(receiver, node.Value)
+
+	case "Keyword":
+		if len(node.Children) > 0 {
+			return ti.typeOf(node.Children[0], sc)
+		}
+		return nil
+	}
+
+	for _, child := range node.Children {
+		ti.typeOf(child, sc)
+	}
+	return typeObject
+}
+
+func numberType(literal string) *pyType {
+	lower := strings.ToLower(literal)
+	switch {
+	case strings.HasPrefix(lower, "0x"), strings.HasPrefix(lower, "0o"), strings.HasPrefix(lower, "0b"):
+		return typeInt
+	case strings.HasSuffix(lower, "j"):
+		return typeObject
+	case strings.ContainsAny(lower, ".e"):
+		return typeFloat
+	}
+	return typeInt
+}
+
+func (ti *typeInference) identifierType(node *models.ASTNode) *pyType {
+	symbol := ti.tree.resolved[node]
+	if symbol == nil {
+		if _, builtin := builtinSignature(node.Value); builtin {
+			return typeFunction
+		}
+		return typeObject
+	}
+
+	switch symbol.kind {
+	case "module":
+		return typeModule
+	case "class":
+		return typeObject
+	case "function":
+		if t := ti.symbols[symbol]; t != nil {
+			return t
+		}
+		if symbol.module != "" {
+			return typeFunction
+		}
+	}
+	return ti.symbols[symbol]
+}
+
+func (ti *typeInference) binaryOpType(node *models.ASTNode, sc *scope) *pyType {
+	left := ti.typeOf(node.Children[0], sc)
+	right := ti.typeOf(node.Children[1], sc)
+	result, ok := binaryType(node.Value, left, right)
+	if !ok {
+		ti.report(node, "error", "type-mismatch", fmt.Sprintf("La operación '%s' en línea %d combina %s y %s, lo que produce TypeError al ejecutarse", formatSource(node), node.Line, left, right))
+		return nil
+	}
+	if node.Value == "**" {
+		result = powerType(result, node.Children[1])
+	}
+
+	// "5" * 3 es válido en Python pero repite la cadena; casi siempre se
+	// esperaba una multiplicación numérica
+	if node.Value == "*" && result == typeStr {
+		for i, operand := range node.Children {
+			if other := []*pyType{right, left}[i]; other != typeInt {
+				continue
+			}
+			if looksNumeric(operand) {
+				ti.report(node, "warning", "string-repetition", fmt.Sprintf("'%s' en línea %d repite la cadena %s en lugar de multiplicar números; convierta el valor con int(...) si se esperaba un cálculo", formatSource(node), node.Line, formatSource(operand)))
+			}
+		}
+	}
+	return result
+}
+
+// looksNumeric indica una cadena que probablemente debía ser un número: un
+// literal con dígitos o el resultado de input()
+func looksNumeric(node *models.ASTNode) bool {
+	switch node.Type {
+	case "String":
+		_, err := strconv.ParseFloat(strings.TrimSpace(node.Value), 64)
+		return err == nil
+	case "FunctionCall":
+		return node.Value == "input"
+	}
+	return false
+}
+
+func (ti *typeInference) comprehensionType(node *models.ASTNode, sc *scope) *pyType {
+	comp := ti.tree.byNode[node]
+	elements := make([]*models.ASTNode, 0)
+	clauses := make([]*models.ASTNode, 0)
+	for _, child := range node.Children {
+		if child.Type == "Comprehension" {
+			clauses = append(clauses, child)
+		} else {
+			elements = append(elements, child)
+		}
+	}
+	if comp == nil {
+		comp = sc
+	}
+
+	for i, clause := range clauses {
+		iterScope := comp
+		if i == 0 {
+			iterScope = sc
+		}
+		ti.assignTarget(clause.Children[0], elementType(ti.typeOf(clause.Children[1], iterScope)), comp)
+		for _, condition := range clause.Children[2:] {
+			ti.typeOf(condition, comp)
+		}
+	}
+
+	var elem *pyType
+	for i, element := range elements {
+		t := ti.typeOf(element, comp)
+		if i == 0 {
+			elem = t
+		}
+	}
+	if elem == nil {
+		elem = typeObject
+	}
+
+	switch node.Type {
+	case "SetComp":
+		return containerOf("set", elem)
+	case "DictComp":
+		return containerOf("dict", elem)
+	case "GeneratorExp":
+		return containerOf("iterator", elem)
+	}
+	return containerOf("list", elem)
+}
+
+func (ti *typeInference) callType(node *models.ASTNode, sc *scope) *pyType {
+	args := make([]*pyType, 0, len(node.Children))
+	for _, arg := range node.Children {
+		args = append(args, ti.typeOf(arg, sc))
+	}
+
+	symbol := ti.tree.resolved[node]
+	dotted := strings.Contains(node.Value, ".")
+	switch {
+	case symbol == nil:
+		return builtinCallType(node.Value, node.Children, args)
+	case symbol.kind == "module" && dotted:
+		if member, known := modulePathMember(symbol, node.Value); known {
+			return memberReturnType(member)
+		}
+		return typeObject
+	case dotted:
+		parts := strings.Split(node.Value, ".")
+		if len(parts) == 2 {
+			ti.growList(symbol, parts[1], args)
+			return methodType(ti.symbols[symbol], parts[1])
+		}
+		return typeObject
+	case symbol.kind == "function" && symbol.node != nil && ti.functions[symbol.node] != nil:
+		ft := ti.functions[symbol.node]
+		ti.propagateArguments(node, ft, args)
+		return ft.returnType()
+	case symbol.module != "":
+		if member, known := importedMember(symbol); known {
+			return memberReturnType(member)
+		}
+	}
+	return typeObject
+}
+
+// growList suma a una lista el tipo de los elementos que le agregan
+// append, insert y extend; así "res = []" seguido de "res.append(1)" queda
+// como list[int]
+func (ti *typeInference) growList(symbol *scopeSymbol, method string, args []*pyType) {
+	receiver := ti.symbols[symbol]
+	if receiver == nil || receiver.name != "list" || (symbol.kind != "variable" && symbol.kind != "parameter") {
+		return
+	}
+	var elem *pyType
+	switch {
+	case method == "append" && len(args) == 1:
+		elem = args[0]
+	case method == "insert" && len(args) == 2:
+		elem = args[1]
+	case method == "extend" && len(args) == 1 && args[0] != nil && containerTypes[args[0].name]:
+		elem = args[0].elem
+	}
+	if elem != nil {
+		ti.assign(symbol, containerOf("list", elem))
+	}
+}
+
+// propagateArguments suma el tipo de cada argumento al del parámetro que lo
+// recibe. Un argumento incompatible con la anotación o con el uso numérico
+// del parámetro se informa y no contamina el tipo inferido.
+func (ti *typeInference) propagateArguments(call *models.ASTNode, ft *functionType, args []*pyType) {
+	positional := 0
+	for i, arg := range call.Children {
+		index := -1
+		if arg.Type == "Keyword" {
+			for j, name := range ft.names {
+				if name == arg.Value {
+					index = j
+				}
+			}
+		} else {
+			if positional < len(ft.names) && !strings.HasPrefix(ft.names[positional], "*") {
+				index = positional
+			}
+			positional++
+		}
+		if index < 0 || args[i] == nil {
+			continue
+		}
+
+		t := args[i]
+		name := ft.names[index]
+		if declared := ft.declared[index]; declared != nil {
+			if t.isDefinite() && !compatibleType(t, declared) {
+				ti.report(arg, "error", "argument-type-mismatch", fmt.Sprintf("La llamada '%s' en línea %d pasa %s como '%s', pero '%s' está anotado como %s", formatSource(call), call.Line, t, name, name, declared))
+			}
+			continue
+		}
+		if use, numeric := ft.numericUse[index]; numeric && t.isDefinite() && !t.isNumeric() {
+			ti.report(arg, "error", "argument-type-mismatch", fmt.Sprintf("La llamada '%s' en línea %d pasa %s como '%s', pero '%s' se usa como número en '%s' (línea %d): TypeError al ejecutarse", formatSource(call), call.Line, t, name, name, formatSource(use), use.Line))
+			continue
+		}
+		ti.assign(ft.symbols[index], t)
+	}
+}
+
+// modulePathMember resuelve "math.pi" o "os.path.join" contra el catálogo de módulos
+func modulePathMember(symbol *scopeSymbol, path string) (ModuleMember, bool) {
+	stub, known := lookupModule(symbol.module)
+	parts := strings.Split(path, ".")
+	for i, part := range parts[1:] {
+		if !known {
+			break
+		}
+		member, exists := stub.Members[part]
+		if !exists {
+			break
+		}
+		if i == len(parts)-2 {
+			return member, true
+		}
+		if member.Kind != "module" {
+			break
+		}
+		stub, known = lookupModule(stub.Name + "." + part)
+	}
+	return ModuleMember{}, false
+}
+
+// memberValueType es el tipo de un nombre del catálogo usado como valor
+func memberValueType(member ModuleMember) *pyType {
+	switch member.Kind {
+	case "constant":
+		return parseTypeName(member.Signature)
+	case "module":
+		return typeModule
+	}
+	return typeFunction
+}
+
+func (ti *typeInference) moduleMemberType(symbol *scopeSymbol) *pyType {
+	if member, known := importedMember(symbol); known {
+		return memberValueType(member)
+	}
+	return typeObject
+}
+
+// importedMember busca en el catálogo el miembro enlazado por "from m import x [as y]"
+func importedMember(symbol *scopeSymbol) (ModuleMember, bool) {
+	stub, known := lookupModule(symbol.module)
+	if !known || symbol.node == nil {
+		return ModuleMember{}, false
+	}
+	member, exists := stub.Members[symbol.node.Value]
+	return member, exists
+}
+
+// memberReturnType lee el tipo después de "->" en la firma de un miembro del
+// catálogo; llamar a una clase devuelve una instancia
+func memberReturnType(member ModuleMember) *pyType {
+	if member.Kind == "class" {
+		return typeObject
+	}
+	if arrow := strings.LastIndex(member.Signature, "->"); arrow >= 0 {
+		return parseTypeName(member.Signature[arrow+2:])
+	}
+	return typeObject
+}
+
+// powerType corrige el tipo de ** entre enteros: con un exponente literal
+// negativo el resultado es float (2 ** -1 es 0.5)
+func powerType(result *pyType, exponent *models.ASTNode) *pyType {
+	if value, ok := intLiteral(exponent); ok && value < 0 && result == typeInt {
+		return typeFloat
+	}
+	return result
+}
+
+// builtinCallType tipa las llamadas a funciones integradas; las que dependen
+// de sus argumentos (abs, max, sorted, list...) se calculan a partir de ellos
+func builtinCallType(name string, nodes []*models.ASTNode, args []*pyType) *pyType {
+	member, known := builtinSignature(name)
+	if !known {
+		return typeObject
+	}
+
+	positional := make([]*pyType, 0, len(args))
+	for i, arg := range args {
+		if nodes[i].Type != "Keyword" {
+			positional = append(positional, arg)
+		}
+	}
+	first := typeObject
+	if len(positional) > 0 {
+		first = positional[0]
+		if first == nil {
+			return nil
+		}
+	}
+
+	switch name {
+	case "int", "float", "str", "bool", "range":
+		return simpleType(name)
+	case "list", "set", "tuple", "frozenset":
+		container := name
+		if name == "frozenset" {
+			container = "set"
+		}
+		if len(positional) == 0 {
+			return simpleType(container)
+		}
+		return containerOf(container, elementType(first))
+	case "dict":
+		return simpleType("dict")
+	case "reversed", "iter":
+		return containerOf("iterator", elementType(first))
+	case "filter":
+		if len(positional) > 1 && positional[1] != nil {
+			return containerOf("iterator", elementType(positional[1]))
+		}
+		return simpleType("iterator")
+	case "enumerate", "zip":
+		return containerOf("iterator", simpleType("tuple"))
+	case "map":
+		return simpleType("iterator")
+	case "next":
+		return elementType(first)
+	case "sorted":
+		return containerOf("list", elementType(first))
+	case "abs":
+		if t, ok := unaryType("+", first); ok {
+			return t
+		}
+		return typeObject
+	case "round":
+		if len(positional) > 1 {
+			return typeFloat
+		}
+		if first.isNumeric() {
+			return typeInt
+		}
+		return typeObject
+	case "pow":
+		if len(positional) > 1 {
+			if t, ok := binaryType("**", first, positional[1]); ok && t != nil {
+				return powerType(t, nodes[1])
+			}
+		}
+		return typeObject
+	case "max", "min":
+		if len(positional) == 1 {
+			return elementType(first)
+		}
+		var result *pyType
+		for _, arg := range positional {
+			result = joinTypes(result, arg)
+		}
+		return result
+	case "sum":
+		elem := elementType(first)
+		if elem != nil && elem.isNumeric() {
+			return joinTypes(typeInt, elem)
+		}
+		return typeObject
+	}
+	return memberReturnType(member)
+}
+
+// methodType conoce los métodos más usados de str, list y dict
+func methodType(receiver *pyType, method string) *pyType {
+	if receiver == nil {
+		return nil
+	}
+
+	switch {
+	case receiver == typeStr:
+		switch method {
+		case "upper", "lower", "strip", "lstrip", "rstrip", "title", "capitalize", "replace", "join", "format", "center", "ljust", "rjust", "zfill", "swapcase":
+			return typeStr
+		case "split", "rsplit", "splitlines":
+			return containerOf("list", typeStr)
+		case "find", "rfind", "count", "index", "rindex":
+			return typeInt
+		case "startswith", "endswith", "isdigit", "isalpha", "isalnum", "isspace", "isupper", "islower", "isnumeric", "isdecimal":
+			return typeBool
+		}
+	case receiver.name == "list":
+		switch method {
+		case "append", "extend", "insert", "sort", "reverse", "clear", "remove":
+			return typeNone
+		case "pop":
+			return elementType(receiver)
+		case "index", "count":
+			return typeInt
+		case "copy":
+			return receiver
+		}
+	case receiver.name == "dict":
+		switch method {
+		case "keys":
+			return containerOf("iterator", elementType(receiver))
+		case "values", "items":
+			return simpleType("iterator")
+		}
+	}
+	return typeObject
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"testing"
+
+	"examen-back/models"
+)
+
+// inferProgram ejecuta la inferencia de tipos sobre un programa
+func inferProgram(t *testing.T, code string) (*models.ASTNode, *typeInference) {
+	t.Helper()
+	syntax := parse(code)
+	if !syntax.Valid {
+		t.Fatalf("errores de sintaxis: %v", syntax.Errors)
+	}
+	tree := buildScopeTree(syntax.AST)
+	return syntax.AST, inferTypes(syntax.AST, tree, buildCallGraph(syntax.AST, tree), buildFunctionCFGs(syntax.AST))
+}
+
+func TestInferenceKeywordOnlySignature(t *testing.T) {
+	ast, inference := inferProgram(t, "def f(a, b=2, *, c):\n    return a + b + c\n\nprint(f(1, c=3))\n")
+	ft := inference.functions[ast.Children[0]]
+	if ft == nil || ft.signature() != "(int, int, *, int) -> int" {
+		t.Errorf("firma inesperada de f: %+v", ft)
+	}
+}
+
+func TestInferenceNegativePowerIsFloat(t *testing.T) {
+	cases := map[string]*pyType{
+		"2 ** -1":    typeFloat,
+		"pow(2, -2)": typeFloat,
+		"2 ** 3":     typeInt,
+		"2.0 ** -1":  typeFloat,
+		"pow(2, 3)":  typeInt,
+	}
+	for expression, expected := range cases {
+		_, inference := inferProgram(t, "x = "+expression+"\n")
+		if actual := inference.symbols[inference.tree.module.symbols["x"]]; actual != expected {
+			t.Errorf("%s: tipo %v, se esperaba %v", expression, actual, expected)
+		}
+	}
+}
+
+func TestInferenceFactorialSignature(t *testing.T) {
+	result := analyze(t, "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\nprint(factorial(5))\n")
+	for _, symbol := range result.SymbolTable {
+		if symbol.Name == "factorial" {
+			if symbol.InferredType != "(int) -> int" {
+				t.Errorf("tipo inferido de factorial: %q", symbol.InferredType)
+			}
+			return
+		}
+	}
+	t.Fatalf("factorial no está en la tabla de símbolos")
+}
+
+func TestInferenceTypes(t *testing.T) {
+	cases := map[string]string{
+		"x = 1 + 2":                      "int",
+		"x = 1 / 2":                      "float",
+		"x = 'a' + 'b'":                  "str",
+		"x = 1 < 2":                      "bool",
+		"x = None":                       "None",
+		"x = [1, 2]":                     "list[int]",
+		"x = len('abc')":                 "int",
+		"x = int(input())":               "int",
+		"x: float = 1":                   "float",
+		"x = [str(i) for i in range(3)]": "list[str]",
+	}
+	for statement, expected := range cases {
+		_, inference := inferProgram(t, statement+"\n")
+		if actual := inference.symbols[inference.tree.module.symbols["x"]]; actual.String() != expected {
+			t.Errorf("%s: tipo %v, se esperaba %s", statement, actual, expected)
+		}
+	}
+}
+
+func TestTypeMismatchDiagnostics(t *testing.T) {
+	const factorial = "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\n"
+	cases := []struct {
+		name string
+		code string
+		want map[string][]int
+	}{
+		{"cadena por entero", factorial + "print('5' + factorial(3))\n", map[string][]int{"type-mismatch": {6}}},
+		{"repetición de una cadena numérica", factorial + "print('5' * factorial(3))\n", map[string][]int{"string-repetition": {6}}},
+		{"argumento cadena", factorial + "print(factorial('5'))\n", map[string][]int{"argument-type-mismatch": {6}}},
+		{"argumento anotado", "def f(n: int) -> int:\n    return n\n\nprint(f(1.5))\n", map[string][]int{"argument-type-mismatch": {4}}},
+		{"retorno anotado", "def f(n: int) -> int:\n    return str(n)\n\nprint(f(1))\n", map[string][]int{"return-type-mismatch": {2}}},
+		{"variable anotada", "x: int = 'a'\nprint(x)\n", map[string][]int{"type-mismatch": {1}}},
+		{"operador unario", "print(-'a')\n", map[string][]int{"type-mismatch": {1}}},
+		{"programa correcto", factorial + "print(factorial(int('5')) * 2, 'x' * 3)\n", map[string][]int{"type-mismatch": nil, "argument-type-mismatch": nil, "string-repetition": nil}},
+		{"entero por float", "def f(n: float) -> float:\n    return n * 2\n\nprint(f(3))\n", map[string][]int{"argument-type-mismatch": nil, "return-type-mismatch": nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectDiagnostics(t, tc.code, tc.want)
+		})
+	}
+}
@@ -0,0 +1,2359 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"strings"
+	"time"
+
+	"examen-back/models"
+)
+
+// Límites de ejecución por defecto y máximos que acepta una petición
+const (
+	defaultRunSteps   = 1000000
+	maxRunSteps       = 50000000
+	defaultRunDepth   = 3000
+	maxRunDepth       = 10000
+	defaultRunMemory  = 64 << 20
+	maxRunMemory      = 512 << 20
+	defaultRunTimeout = 2000
+	maxRunTimeout     = 10000
+	defaultRunOutput  = 64 << 10
+	maxRunOutput      = 1 << 20
+
+	// pythonRecursionLimit es el valor inicial de sys.getrecursionlimit()
+	pythonRecursionLimit = 1000
+	// pythonIntMaxStrDigits es el límite de dígitos de Python 3.11+ al convertir enteros a texto
+	pythonIntMaxStrDigits = 4300
+)
+
+// NormalizeRunLimits completa los límites omitidos con los valores por defecto
+// y recorta los que superan el máximo permitido
+func NormalizeRunLimits(limits *models.RunLimits) models.RunLimits {
+	normalized := models.RunLimits{}
+	if limits != nil {
+		normalized = *limits
+	}
+	clampInt := func(value *int, fallback, maximum int) {
+		if *value <= 0 {
+			*value = fallback
+		}
+		*value = min(*value, maximum)
+	}
+	clampInt(&normalized.MaxSteps, defaultRunSteps, maxRunSteps)
+	clampInt(&normalized.MaxDepth, defaultRunDepth, maxRunDepth)
+	clampInt(&normalized.TimeoutMs, defaultRunTimeout, maxRunTimeout)
+	clampInt(&normalized.MaxOutputBytes, defaultRunOutput, maxRunOutput)
+	if normalized.MaxMemoryBytes <= 0 {
+		normalized.MaxMemoryBytes = defaultRunMemory
+	}
+	normalized.MaxMemoryBytes = min(normalized.MaxMemoryBytes, maxRunMemory)
+	return normalized
+}
+
+// ParseRunCall analiza la expresión que se evalúa después del programa (por
+// ejemplo "factorial(5)"); debe ser una única expresión
+func ParseRunCall(call string) (*models.ASTNode, error) {
+	lexical := NewLexicalAnalyzer(call).Tokenize()
+	syntax := NewSyntaxAnalyzer(lexical.Tokens).Analyze()
+	if !syntax.Valid {
+		return nil, fmt.Errorf("la llamada tiene errores de sintaxis: %s", strings.Join(syntax.Errors, "; "))
+	}
+	if syntax.AST == nil || len(syntax.AST.Children) != 1 || !isExpression(syntax.AST.Children[0]) {
+		return nil, fmt.Errorf("la llamada debe ser una única expresión")
+	}
+	return syntax.AST, nil
+}
+
+// frame es el registro de activación de un módulo, función, cuerpo de clase o
+// comprensión. closure apunta al frame donde se definió la función (saltando
+// los cuerpos de clase) y caller al frame que la llamó.
+type frame struct {
+	function string
+	scope    *scope
+	locals   map[string]pyValue
+	closure  *frame
+	caller   *frame
+	fn       *pyFunction
+	args     []pyValue
+	line     int
+}
+
+// pyException es una excepción de Python que se propaga como error de Go
+type pyException struct {
+	value     *pyInstance
+	line      int
+	traceback []models.TraceFrame
+}
+
+func (e *pyException) Error() string {
+	return e.value.class.name
+}
+
+// is indica si la excepción es de alguna de las clases integradas nombradas o de una subclase
+func (e *pyException) is(names ...string) bool {
+	for _, class := range e.value.class.mro {
+		for _, name := range names {
+			if class.builtin && class.name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// limitError detiene la ejecución al superar un límite del sandbox. A
+// diferencia de las excepciones de Python, el programa no puede capturarlo.
+type limitError struct {
+	kind      string
+	message   string
+	line      int
+	traceback []models.TraceFrame
+}
+
+func (e *limitError) Error() string {
+	return e.kind + ": " + e.message
+}
+
+// Interpreter ejecuta un programa en un sandbox: recorre el AST sin acceso al
+// sistema de archivos, la red ni el proceso, con límites de pasos, profundidad
+// de recursión, memoria asignada, tiempo y salida.
+type Interpreter struct {
+	ast      *models.ASTNode
+	tree     *scopeTree
+	scopes   map[*models.ASTNode]*scope
+	limits   models.RunLimits
+	globals  map[string]pyValue
+	builtins map[string]pyValue
+	classes  map[string]*pyClass
+	modules  map[string]*pyModule
+	literals map[*models.ASTNode]constValue
+	visiting map[pyValue]bool
+	random   *rand.Rand
+
+	exceptionBase *pyClass
+	objectClass   *pyClass
+
+	stdout   strings.Builder
+	stderr   strings.Builder
+	stdin    []string
+	frame    *frame
+	handling []*pyException
+	tracer   *callTracer
+	debugger *DebugSession
+	program  *BytecodeProgram
+
+	steps          int
+	depth          int
+	nesting        int
+	nestingChecks  int
+	maxDepth       int
+	recursionLimit int
+	maxStrDigits   int
+	memory         int64
+	deadline       time.Time
+}
+
+func NewInterpreter(ast *models.ASTNode, limits models.RunLimits) *Interpreter {
+	in := &Interpreter{
+		ast:            ast,
+		tree:           buildScopeTree(ast),
+		scopes:         make(map[*models.ASTNode]*scope),
+		limits:         NormalizeRunLimits(&limits),
+		globals:        map[string]pyValue{"__name__": strValue("__main__")},
+		modules:        make(map[string]*pyModule),
+		literals:       make(map[*models.ASTNode]constValue),
+		visiting:       make(map[pyValue]bool),
+		random:         rand.New(rand.NewSource(0)),
+		recursionLimit: pythonRecursionLimit,
+		maxStrDigits:   pythonIntMaxStrDigits,
+	}
+	for node, sc := range in.tree.byNode {
+		in.scopes[node] = sc
+	}
+	in.recursionLimit = min(in.recursionLimit, in.limits.MaxDepth)
+	in.setupBuiltins()
+	return in
+}
+
+// Run ejecuta el programa con la entrada estándar indicada y, si call no es
+// nil, evalúa después esa expresión en el scope del módulo. El valor devuelto
+// es el de call o, sin call, el de la última sentencia del módulo cuando es
+// una expresión distinta de None.
+func (in *Interpreter) Run(stdin string, call *models.ASTNode) models.RunResult {
+	return in.session(stdin, func(module *frame, result *models.RunResult) error {
+		var value pyValue
+		var err error
+		if in.ast != nil {
+			value, err = in.runModule(in.ast.Children, module)
+		}
+		if err == nil && call != nil {
+			if in.tracer != nil {
+				in.tracer.active = true
+			}
+			value, err = in.evalDetached(call, call.Children[0], module)
+		}
+		if err != nil || value == nil || call == nil && isNone(value) {
+			return err
+		}
+		text, err := in.repr(value)
+		if err == nil {
+			result.ReturnValue = text
+			result.ReturnType = typeName(value)
+		}
+		return err
+	})
+}
+
+// Trace ejecuta el programa como Run registrando las llamadas a las funciones
+// indicadas (por defecto, las recursivas). Con call solo se registran las
+// llamadas que hace esa expresión.
+func (in *Interpreter) Trace(stdin string, call *models.ASTNode, functions []string, maxCalls int) (models.RunResult, models.CallTrace, error) {
+	tracer, err := newCallTracer(in.ast, functions, maxCalls)
+	if err != nil {
+		return models.RunResult{}, models.CallTrace{}, err
+	}
+	tracer.active = call == nil
+	in.tracer = tracer
+	result := in.Run(stdin, call)
+	in.tracer = nil
+	return result, tracer.export(), nil
+}
+
+// session prepara la entrada estándar y el frame del módulo, ejecuta body y
+// completa el resultado con la salida, las métricas de ejecución y el error
+// que devolvió body
+func (in *Interpreter) session(stdin string, body func(module *frame, result *models.RunResult) error) (result models.RunResult) {
+	start := time.Now()
+	in.deadline = start.Add(time.Duration(in.limits.TimeoutMs) * time.Millisecond)
+	if stdin != "" {
+		in.stdin = strings.SplitAfter(stdin, "\n")
+		if in.stdin[len(in.stdin)-1] == "" {
+			in.stdin = in.stdin[:len(in.stdin)-1]
+		}
+	}
+
+	module := &frame{function: "<module>", scope: in.tree.module, locals: in.globals}
+	in.frame = module
+	in.depth = 1
+	in.maxDepth = 1
+	in.nesting = 0
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result.Error = &models.RuntimeError{
+				Type:    "InternalError",
+				Message: fmt.Sprintf("Error interno del intérprete: %v", recovered),
+				Line:    in.frame.line,
+			}
+		}
+		result.Stdout = in.stdout.String()
+		result.Stderr = in.stderr.String()
+		result.Steps = in.steps
+		result.MaxDepth = in.maxDepth
+		result.MemoryBytes = in.memory
+		result.DurationMs = round2(float64(time.Since(start).Microseconds()) / 1000)
+		result.Limits = in.limits
+		result.Engine = EngineTree
+		if in.program != nil {
+			result.Engine = EngineVM
+		}
+	}()
+
+	result.Error = in.runtimeError(body(module, &result))
+	return result
+}
+
+// evalDetached evalúa una expresión que se analizó aparte del programa (la
+// llamada de /run o un caso de prueba) en el scope del módulo. Sus líneas no
+// son del programa, así que un error en la propia expresión se informa con
+// línea 0.
+func (in *Interpreter) evalDetached(program, node *models.ASTNode, module *frame) (pyValue, error) {
+	for scopeNode, sc := range buildScopeTree(program).byNode {
+		if scopeNode != program {
+			in.scopes[scopeNode] = sc
+		}
+	}
+	in.frame = module
+	module.line = 0
+	return in.eval(node, module)
+}
+
+// runModule ejecuta las sentencias del módulo y devuelve el valor de la última
+// si es una expresión
+func (in *Interpreter) runModule(stmts []*models.ASTNode, module *frame) (pyValue, error) {
+	if in.program != nil {
+		value, err := in.execute(in.program.main, module)
+		if err != nil || len(stmts) == 0 || !isExpression(stmts[len(stmts)-1]) {
+			return nil, err
+		}
+		return value, nil
+	}
+
+	var last pyValue
+	for _, stmt := range stmts {
+		last = nil
+		if isExpression(stmt) {
+			if err := in.tick(); err != nil {
+				return nil, err
+			}
+			module.line = stmt.Line
+			if err := in.step(stmt, module); err != nil {
+				return nil, err
+			}
+			value, err := in.eval(stmt, module)
+			if err != nil {
+				return nil, err
+			}
+			last = value
+			continue
+		}
+		if _, _, err := in.exec(stmt, module); err != nil {
+			return nil, err
+		}
+	}
+	return last, nil
+}
+
+// runtimeError traduce el error que detuvo la ejecución. SystemExit con
+// código 0 o None termina el programa sin error.
+func (in *Interpreter) runtimeError(err error) *models.RuntimeError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *pyException:
+		message, strErr := in.exceptionString(e.value)
+		if strErr != nil {
+			message = ""
+		}
+		if e.is("SystemExit") {
+			args := exceptionArgs(e.value)
+			if len(args) == 0 || isNone(args[0]) || identical(args[0], smallInt(0)) {
+				return nil
+			}
+		}
+		return &models.RuntimeError{
+			Type:      e.value.class.name,
+			Message:   message,
+			Line:      e.line,
+			Traceback: e.traceback,
+		}
+	case *limitError:
+		return &models.RuntimeError{
+			Type:      e.kind,
+			Message:   e.message,
+			Line:      e.line,
+			Limit:     true,
+			Traceback: e.traceback,
+		}
+	}
+	return &models.RuntimeError{Type: "InternalError", Message: err.Error(), Line: in.frame.line}
+}
+
+// traceback recorre la pila de frames desde el más externo, como Python. Los
+// frames consecutivos iguales (recursión) se agrupan en uno con Repeated.
+func (in *Interpreter) traceback() []models.TraceFrame {
+	frames := make([]models.TraceFrame, 0)
+	for fr := in.frame; fr != nil; fr = fr.caller {
+		last := len(frames) - 1
+		if last >= 0 && frames[last].Function == fr.function && frames[last].Line == fr.line {
+			frames[last].Repeated++
+			continue
+		}
+		frames = append(frames, models.TraceFrame{Function: fr.function, Line: fr.line})
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// raise crea una excepción integrada de Python en la línea actual
+func (in *Interpreter) raise(class, format string, args ...interface{}) error {
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	instance := &pyInstance{
+		class: in.builtinClass(class),
+		attrs: map[string]pyValue{"args": newTuple([]pyValue{strValue(message)})},
+	}
+	return in.throw(instance)
+}
+
+func (in *Interpreter) throw(instance *pyInstance) error {
+	return &pyException{value: instance, line: in.frame.line, traceback: in.traceback()}
+}
+
+func (in *Interpreter) limit(kind, format string, args ...interface{}) error {
+	return &limitError{
+		kind:      kind,
+		message:   fmt.Sprintf(format, args...),
+		line:      in.frame.line,
+		traceback: in.traceback(),
+	}
+}
+
+// tick cuenta un paso de ejecución; el reloj se consulta cada 256 pasos
+func (in *Interpreter) tick() error {
+	in.steps++
+	if in.steps > in.limits.MaxSteps {
+		return in.limit("StepLimitExceeded", "Se superó el límite de %d pasos de ejecución", in.limits.MaxSteps)
+	}
+	if in.steps&255 == 0 {
+		return in.expired()
+	}
+	return nil
+}
+
+// expired consulta el reloj y corta la ejecución si ya pasó el tiempo máximo
+func (in *Interpreter) expired() error {
+	if time.Now().After(in.deadline) {
+		return in.limit("TimeLimitExceeded", "Se superó el tiempo máximo de ejecución de %d ms", in.limits.TimeoutMs)
+	}
+	return nil
+}
+
+// nestingPerFrame es cuántos niveles de expresiones o contenedores anidados
+// se admiten por cada nivel de MaxDepth
+const nestingPerFrame = 8
+
+// nest cuenta un nivel de la recursión del propio intérprete: expresiones
+// anidadas y contenedores que se comparan, se muestran o se usan como clave.
+// Esa recursión usa la pila de Go, que no tiene límite propio, y un
+// anidamiento de millones de niveles detendría el proceso entero; por eso se
+// cuenta contra MaxDepth y al superarlo se lanza RecursionError, como CPython.
+// Cada nest sin error se cierra con unnest. El reloj se consulta cada 256
+// niveles para cortar también los recorridos largos de un solo paso.
+func (in *Interpreter) nest() error {
+	if in.nesting >= in.limits.MaxDepth*nestingPerFrame {
+		return in.raise("RecursionError", "maximum recursion depth exceeded")
+	}
+	in.nestingChecks++
+	if in.nestingChecks&255 == 0 {
+		if err := in.expired(); err != nil {
+			return err
+		}
+	}
+	in.nesting++
+	return nil
+}
+
+func (in *Interpreter) unnest() {
+	in.nesting--
+}
+
+// step avisa al depurador, si hay uno, de que se va a ejecutar la sentencia
+func (in *Interpreter) step(node *models.ASTNode, fr *frame) error {
+	if in.debugger == nil {
+		return nil
+	}
+	return in.debugger.reached(node, fr)
+}
+
+// allocate contabiliza memoria asignada por el programa
+func (in *Interpreter) allocate(bytes int64) error {
+	in.memory += bytes
+	if in.memory > in.limits.MaxMemoryBytes {
+		return in.limit("MemoryLimitExceeded", "Se superó el límite de %d bytes de memoria asignada", in.limits.MaxMemoryBytes)
+	}
+	return nil
+}
+
+// valueBytes es el tamaño máximo de un único entero o cadena
+func (in *Interpreter) valueBytes() int {
+	return int(min(in.limits.MaxMemoryBytes/64, 1<<20))
+}
+
+// Un paso de big.Int puede durar segundos y el reloj solo se consulta entre
+// pasos, así que antes de operar con enteros grandes se estima cuánto
+// tardaría la operación a partir del tamaño de los operandos. Las constantes
+// se midieron con math/big y son algo pesimistas.
+const (
+	// karatsubaWords es el tamaño en palabras desde el que math/big multiplica por Karatsuba
+	karatsubaWords = 40
+	// nsPerKaratsubaWord es el coste de Karatsuba por palabra^log2(3)
+	nsPerKaratsubaWord = 10
+	// nsPerParsedDigit2 es el coste de leer un decimal por dígito al cuadrado
+	nsPerParsedDigit2 = 0.003
+)
+
+// mulCost estima en nanosegundos el producto de dos enteros de los bits dados
+func mulCost(aBits, bBits int) float64 {
+	n, m := float64(max(aBits, bBits)/64+1), float64(min(aBits, bBits)/64+1)
+	if m < karatsubaWords {
+		return n * m
+	}
+	return nsPerKaratsubaWord * n / m * math.Pow(m, math.Log2(3))
+}
+
+// intCost estima en nanosegundos "a op b" con enteros; el resultado de **
+// se acota a maxBits porque uno mayor se rechaza sin calcularlo
+func intCost(op string, a, b *big.Int, maxBits int) float64 {
+	switch op {
+	case "*":
+		return mulCost(a.BitLen(), b.BitLen())
+	case "//", "%":
+		// Dividir cuesta unas dos multiplicaciones del cociente por el divisor
+		if quotient := a.BitLen() - b.BitLen(); quotient > 0 {
+			return 2.5 * mulCost(quotient, b.BitLen())
+		}
+	case "**":
+		if b.Sign() > 0 && a.BitLen() > 1 {
+			bits := maxBits
+			if b.IsInt64() && int64(a.BitLen())*b.Int64() < int64(maxBits) {
+				bits = a.BitLen() * int(b.Int64())
+			}
+			// Domina el último cuadrado, de la mitad de bits que el resultado
+			return mulCost(bits/2, bits/2)
+		}
+	}
+	return 0
+}
+
+// powModCost estima pow(base, exp, mod): un producto y una reducción módulo
+// mod por cada bit del exponente
+func powModCost(exponent, modulus *big.Int) float64 {
+	return 1.5 * float64(exponent.BitLen()) * mulCost(modulus.BitLen(), modulus.BitLen())
+}
+
+// decimalCost estima la conversión entre un entero de los bits dados y decimal
+func decimalCost(bits int) float64 {
+	return 1.2 * mulCost(bits, bits)
+}
+
+// affordable comprueba que una operación que se estima en nanos
+// nanosegundos cabe en el tiempo que le queda a la ejecución
+func (in *Interpreter) affordable(operation string, nanos float64) error {
+	if nanos > float64(time.Until(in.deadline)) {
+		return in.limit("TimeLimitExceeded", "%s no terminaría dentro del tiempo máximo de ejecución de %d ms", operation, in.limits.TimeoutMs)
+	}
+	return nil
+}
+
+// allocateValue contabiliza los escalares grandes que produce una operación
+func (in *Interpreter) allocateValue(value pyValue) error {
+	if v, ok := value.(constValue); ok {
+		switch {
+		case v.kind == "str" && len(v.s) > 16:
+			return in.allocate(int64(len(v.s)))
+		case v.kind == "int" && v.i.BitLen() > 64:
+			return in.allocate(int64(v.i.BitLen() / 8))
+		}
+	}
+	return nil
+}
+
+// write agrega texto a la salida estándar respetando el límite de salida
+func (in *Interpreter) write(text string) error {
+	return in.output(&in.stdout, text)
+}
+
+// output escribe en stdout o stderr; el límite de salida es para ambas juntas
+func (in *Interpreter) output(buffer *strings.Builder, text string) error {
+	remaining := in.limits.MaxOutputBytes - in.stdout.Len() - in.stderr.Len()
+	if len(text) > remaining {
+		buffer.WriteString(text[:max(remaining, 0)])
+		return in.limit("OutputLimitExceeded", "Se superó el límite de %d bytes de salida", in.limits.MaxOutputBytes)
+	}
+	buffer.WriteString(text)
+	return nil
+}
+
+// isExpression distingue las sentencias de expresión de las demás sentencias
+func isExpression(node *models.ASTNode) bool {
+	switch node.Type {
+	case "Assignment", "AugAssignment", "AnnotatedName", "IfStatement", "WhileStatement",
+		"ForStatement", "TryStatement", "FunctionDef", "ClassDef", "ReturnStatement",
+		"PassStatement", "BreakStatement", "ContinueStatement", "RaiseStatement",
+		"Import", "ImportFrom", "GlobalStatement", "NonlocalStatement", "Block":
+		return false
+	}
+	return true
+}
+
+func (in *Interpreter) execBlock(stmts []*models.ASTNode, fr *frame) (int, pyValue, error) {
+	for _, stmt := range stmts {
+		flow, value, err := in.exec(stmt, fr)
+		if err != nil || flow != flowNormal {
+			return flow, value, err
+		}
+	}
+	return flowNormal, nil, nil
+}
+
+// exec ejecuta una sentencia. Devuelve cómo continúa el flujo (flowNormal,
+// flowReturn, flowBreak o flowContinue) y, para return, el valor devuelto.
+func (in *Interpreter) exec(node *models.ASTNode, fr *frame) (int, pyValue, error) {
+	if node.Type == "Block" {
+		return in.execBlock(node.Children, fr)
+	}
+	fr.line = node.Line
+	if err := in.tick(); err != nil {
+		return flowNormal, nil, err
+	}
+	if err := in.step(node, fr); err != nil {
+		return flowNormal, nil, err
+	}
+
+	switch node.Type {
+	case "Assignment":
+		if node.Value != "" {
+			value, err := in.eval(node.Children[0], fr)
+			if err != nil {
+				return flowNormal, nil, err
+			}
+			in.store(fr, node.Value, value)
+			return flowNormal, nil, nil
+		}
+		value, err := in.eval(node.Children[1], fr)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		return flowNormal, nil, in.assign(node.Children[0], value, fr)
+
+	case "AugAssignment":
+		return flowNormal, nil, in.augAssign(node, fr)
+
+	case "AnnotatedName", "PassStatement", "GlobalStatement", "NonlocalStatement":
+		return flowNormal, nil, nil
+
+	case "IfStatement":
+		condition, err := in.eval(node.Children[0], fr)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		truth, err := in.truthy(condition)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		if truth {
+			return in.exec(node.Children[1], fr)
+		}
+		if len(node.Children) > 2 {
+			return in.exec(node.Children[2], fr)
+		}
+		return flowNormal, nil, nil
+
+	case "WhileStatement":
+		return in.execWhile(node, fr)
+
+	case "ForStatement":
+		return in.execFor(node, fr)
+
+	case "TryStatement":
+		return in.execTry(node, fr)
+
+	case "FunctionDef":
+		function, err := in.defineFunction(node, fr)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		in.store(fr, node.Value, function)
+		return flowNormal, nil, nil
+
+	case "ClassDef":
+		class, err := in.defineClass(node, fr)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		in.store(fr, node.Value, class)
+		return flowNormal, nil, nil
+
+	case "ReturnStatement":
+		if len(node.Children) == 0 {
+			return flowReturn, noneValue(), nil
+		}
+		value, err := in.eval(node.Children[0], fr)
+		return flowReturn, value, err
+
+	case "BreakStatement":
+		return flowBreak, nil, nil
+
+	case "ContinueStatement":
+		return flowContinue, nil, nil
+
+	case "RaiseStatement":
+		return flowNormal, nil, in.execRaise(node, fr)
+
+	case "Import":
+		for _, alias := range node.Children {
+			module, err := in.importModule(alias.Value)
+			if err != nil {
+				return flowNormal, nil, err
+			}
+			name := alias.Value
+			if len(alias.Children) > 0 {
+				name = alias.Children[0].Value
+			}
+			in.store(fr, name, module)
+		}
+		return flowNormal, nil, nil
+
+	case "ImportFrom":
+		module, err := in.importModule(node.Value)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		for _, alias := range node.Children {
+			if alias.Value == "*" {
+				for name, value := range module.attrs {
+					if !strings.HasPrefix(name, "_") {
+						in.store(fr, name, value)
+					}
+				}
+				continue
+			}
+			value, ok := module.attrs[alias.Value]
+			if !ok {
+				return flowNormal, nil, in.raise("ImportError", "cannot import name '%s' from '%s'", alias.Value, module.name)
+			}
+			name := alias.Value
+			if len(alias.Children) > 0 {
+				name = alias.Children[0].Value
+			}
+			in.store(fr, name, value)
+		}
+		return flowNormal, nil, nil
+	}
+
+	_, err := in.eval(node, fr)
+	return flowNormal, nil, err
+}
+
+func (in *Interpreter) execWhile(node *models.ASTNode, fr *frame) (int, pyValue, error) {
+	for iteration := 0; ; iteration++ {
+		fr.line = node.Line
+		if iteration > 0 {
+			if err := in.step(node, fr); err != nil {
+				return flowNormal, nil, err
+			}
+		}
+		condition, err := in.eval(node.Children[0], fr)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		truth, err := in.truthy(condition)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		if !truth {
+			break
+		}
+		flow, value, err := in.exec(node.Children[1], fr)
+		if err != nil || flow == flowReturn {
+			return flow, value, err
+		}
+		if flow == flowBreak {
+			return flowNormal, nil, nil
+		}
+		if err := in.tick(); err != nil {
+			return flowNormal, nil, err
+		}
+	}
+	if len(node.Children) > 2 {
+		return in.exec(node.Children[2], fr)
+	}
+	return flowNormal, nil, nil
+}
+
+func (in *Interpreter) execFor(node *models.ASTNode, fr *frame) (int, pyValue, error) {
+	iterable, err := in.eval(node.Children[1], fr)
+	if err != nil {
+		return flowNormal, nil, err
+	}
+	next, err := in.iterator(iterable)
+	if err != nil {
+		return flowNormal, nil, err
+	}
+	for iteration := 0; ; iteration++ {
+		fr.line = node.Line
+		if err := in.tick(); err != nil {
+			return flowNormal, nil, err
+		}
+		if iteration > 0 {
+			if err := in.step(node, fr); err != nil {
+				return flowNormal, nil, err
+			}
+		}
+		item, ok, err := next()
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		if !ok {
+			break
+		}
+		if err := in.assign(node.Children[0], item, fr); err != nil {
+			return flowNormal, nil, err
+		}
+		flow, value, err := in.exec(node.Children[2], fr)
+		if err != nil || flow == flowReturn {
+			return flow, value, err
+		}
+		if flow == flowBreak {
+			return flowNormal, nil, nil
+		}
+	}
+	if len(node.Children) > 3 {
+		return in.exec(node.Children[3], fr)
+	}
+	return flowNormal, nil, nil
+}
+
+// execTry ejecuta try/except/else/finally. Los límites del sandbox no son
+// excepciones de Python: atraviesan los except y no ejecutan el finally.
+func (in *Interpreter) execTry(node *models.ASTNode, fr *frame) (int, pyValue, error) {
+	var handlers []*models.ASTNode
+	var elseBlock, finallyBlock *models.ASTNode
+	for _, child := range node.Children[1:] {
+		switch {
+		case child.Type == "ExceptHandler":
+			handlers = append(handlers, child)
+		case child.Value == "else":
+			elseBlock = child
+		case child.Value == "finally":
+			finallyBlock = child
+		}
+	}
+
+	flow, value, err := in.exec(node.Children[0], fr)
+	if exception, ok := err.(*pyException); ok {
+		for _, handler := range handlers {
+			matches, matchErr := in.handles(handler, exception, fr)
+			if matchErr != nil {
+				flow, value, err = flowNormal, nil, matchErr
+				break
+			}
+			if !matches {
+				continue
+			}
+			if handler.Value != "" {
+				in.store(fr, handler.Value, exception.value)
+			}
+			in.handling = append(in.handling, exception)
+			flow, value, err = in.exec(handler.Children[len(handler.Children)-1], fr)
+			in.handling = in.handling[:len(in.handling)-1]
+			if handler.Value != "" {
+				in.unbind(fr, handler.Value)
+			}
+			break
+		}
+	} else if err == nil && flow == flowNormal && elseBlock != nil {
+		flow, value, err = in.exec(elseBlock, fr)
+	}
+
+	if _, limited := err.(*limitError); limited || finallyBlock == nil {
+		return flow, value, err
+	}
+	finallyFlow, finallyValue, finallyErr := in.exec(finallyBlock, fr)
+	if finallyErr != nil || finallyFlow != flowNormal {
+		return finallyFlow, finallyValue, finallyErr
+	}
+	return flow, value, err
+}
+
+// handles indica si el except captura la excepción; el tipo puede ser una
+// clase o una tupla de clases
+func (in *Interpreter) handles(handler *models.ASTNode, exception *pyException, fr *frame) (bool, error) {
+	if len(handler.Children) == 1 {
+		return true, nil
+	}
+	expected, err := in.eval(handler.Children[0], fr)
+	if err != nil {
+		return false, err
+	}
+	classes := []pyValue{expected}
+	if tuple, ok := expected.(*pyTuple); ok {
+		classes = tuple.items
+	}
+	for _, candidate := range classes {
+		class, ok := candidate.(*pyClass)
+		if !ok || !class.isSubclass(in.exceptionBase) {
+			return false, in.raise("TypeError", "catching classes that do not inherit from BaseException is not allowed")
+		}
+		if exception.value.class.isSubclass(class) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (in *Interpreter) execRaise(node *models.ASTNode, fr *frame) error {
+	if len(node.Children) == 0 {
+		if len(in.handling) == 0 {
+			return in.raise("RuntimeError", "No active exception to reraise")
+		}
+		return in.handling[len(in.handling)-1]
+	}
+
+	value, err := in.eval(node.Children[0], fr)
+	if err != nil {
+		return err
+	}
+	if class, ok := value.(*pyClass); ok && class.isSubclass(in.exceptionBase) {
+		if value, err = in.call(class, nil, nil); err != nil {
+			return err
+		}
+	}
+	instance, ok := value.(*pyInstance)
+	if !ok || !instance.class.isSubclass(in.exceptionBase) {
+		return in.raise("TypeError", "exceptions must derive from BaseException")
+	}
+	if len(node.Children) > 1 {
+		cause, err := in.eval(node.Children[1], fr)
+		if err != nil {
+			return err
+		}
+		instance.attrs["__cause__"] = cause
+	}
+	return in.throw(instance)
+}
+
+// defineFunction crea la función de un def o lambda; los valores por defecto
+// se evalúan en este momento, como en Python
+func (in *Interpreter) defineFunction(node *models.ASTNode, fr *frame) (*pyFunction, error) {
+	params, _ := functionParts(node)
+	defaults := make(map[*models.ASTNode]pyValue)
+	for _, param := range params {
+		if def := parameterDefault(param); def != nil {
+			value, err := in.eval(def, fr)
+			if err != nil {
+				return nil, err
+			}
+			defaults[param] = value
+		}
+	}
+
+	name := node.Value
+	if node.Type == "Lambda" {
+		name = "<lambda>"
+	}
+	qualname := name
+	switch {
+	case fr.scope.kind == "class":
+		qualname = fr.function + "." + name
+	case fr.scope.kind != "module":
+		qualname = fr.function + ".<locals>." + name
+	}
+
+	if err := in.allocate(objectBytes); err != nil {
+		return nil, err
+	}
+	return &pyFunction{
+		name:     name,
+		qualname: qualname,
+		node:     node,
+		scope:    in.scopes[node],
+		closure:  closureOf(fr),
+		defaults: defaults,
+	}, nil
+}
+
+// closureOf es el frame que ven las funciones definidas en fr: los cuerpos de
+// clase no forman parte de la clausura
+func closureOf(fr *frame) *frame {
+	if fr.scope.kind == "class" {
+		return fr.closure
+	}
+	return fr
+}
+
+func (in *Interpreter) defineClass(node *models.ASTNode, fr *frame) (*pyClass, error) {
+	class := &pyClass{name: node.Value, attrs: make(map[string]pyValue)}
+	body := make([]*models.ASTNode, 0)
+	for _, child := range node.Children {
+		if child.Type != "Base" {
+			body = append(body, child)
+			continue
+		}
+		value, err := in.eval(child.Children[0], fr)
+		if err != nil {
+			return nil, err
+		}
+		base, ok := value.(*pyClass)
+		if !ok {
+			return nil, in.raise("TypeError", "bases must be types")
+		}
+		if base.construct != nil && base != in.objectClass {
+			return nil, in.raise("NotImplementedError", "heredar del tipo integrado '%s' no está soportado por el intérprete", base.name)
+		}
+		class.bases = append(class.bases, base)
+	}
+	if len(class.bases) == 0 {
+		class.bases = []*pyClass{in.objectClass}
+	}
+	class.mro = computeMRO(class)
+
+	qualname := node.Value
+	if fr.scope.kind != "module" {
+		qualname = fr.function + "." + node.Value
+	}
+	classFrame := &frame{
+		function: qualname,
+		scope:    in.scopes[node],
+		locals:   class.attrs,
+		closure:  closureOf(fr),
+		caller:   in.frame,
+		line:     node.Line,
+	}
+	in.frame = classFrame
+	_, _, err := in.execBlock(body, classFrame)
+	in.frame = classFrame.caller
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range class.attrs {
+		if function, ok := value.(*pyFunction); ok && function.class == nil {
+			function.class = class
+		}
+	}
+	return class, in.allocate(objectBytes)
+}
+
+// lookup resuelve un nombre según el enlace que calculó el árbol de scopes
+func (in *Interpreter) lookup(fr *frame, name string) (pyValue, error) {
+	symbol := fr.scope.symbols[name]
+	binding := ""
+	if symbol != nil {
+		binding = symbol.binding
+	}
+
+	switch {
+	case fr.scope.kind == "class":
+		// Los cuerpos de clase buscan primero en sus propios nombres y luego siguen LEGB
+		if value, ok := fr.locals[name]; ok {
+			return value, nil
+		}
+		if binding == "free" {
+			if owner := in.enclosing(fr, symbol); owner != nil {
+				if value, ok := owner.locals[name]; ok {
+					return value, nil
+				}
+			}
+		}
+	case binding == "local" || binding == "cell":
+		if value, ok := fr.locals[name]; ok {
+			return value, nil
+		}
+		if fr.scope.kind != "module" {
+			return nil, in.raise("UnboundLocalError", "cannot access local variable '%s' where it is not associated with a value", name)
+		}
+	case binding == "free" || binding == "nonlocal":
+		owner := in.enclosing(fr, symbol)
+		if owner == nil {
+			break
+		}
+		if value, ok := owner.locals[name]; ok {
+			return value, nil
+		}
+		return nil, in.raise("NameError", "cannot access free variable '%s' where it is not associated with a value in enclosing scope", name)
+	}
+
+	if value, ok := in.globals[name]; ok {
+		return value, nil
+	}
+	if value, ok := in.builtins[name]; ok {
+		return value, nil
+	}
+	return nil, in.raise("NameError", "name '%s' is not defined", name)
+}
+
+// enclosing busca en la clausura el frame dueño de una variable libre
+func (in *Interpreter) enclosing(fr *frame, symbol *scopeSymbol) *frame {
+	if symbol == nil || symbol.target == nil {
+		return nil
+	}
+	for outer := fr.closure; outer != nil; outer = outer.closure {
+		if outer.scope == symbol.target.owner {
+			return outer
+		}
+	}
+	return nil
+}
+
+// store enlaza un nombre respetando las declaraciones global y nonlocal
+func (in *Interpreter) store(fr *frame, name string, value pyValue) {
+	if symbol := fr.scope.symbols[name]; symbol != nil && fr.scope.kind != "module" {
+		switch symbol.binding {
+		case "global":
+			in.globals[name] = value
+			return
+		case "nonlocal", "free":
+			if owner := in.enclosing(fr, symbol); owner != nil {
+				owner.locals[name] = value
+				return
+			}
+		}
+	}
+	fr.locals[name] = value
+}
+
+// unbind borra el nombre ligado con "except ... as nombre" al salir del bloque
+func (in *Interpreter) unbind(fr *frame, name string) {
+	if symbol := fr.scope.symbols[name]; symbol != nil && symbol.binding == "global" {
+		delete(in.globals, name)
+		return
+	}
+	delete(fr.locals, name)
+}
+
+// assign asigna un valor a un destino: nombre, tupla o lista (desempaquetado),
+// subíndice o atributo
+func (in *Interpreter) assign(target *models.ASTNode, value pyValue, fr *frame) error {
+	switch target.Type {
+	case "Identifier":
+		in.store(fr, target.Value, value)
+		return nil
+	case "Tuple", "List":
+		items, err := in.collect(value)
+		if err != nil {
+			return err
+		}
+		expected := len(target.Children)
+		switch {
+		case len(items) > expected:
+			return in.raise("ValueError", "too many values to unpack (expected %d)", expected)
+		case len(items) < expected:
+			return in.raise("ValueError", "not enough values to unpack (expected %d, got %d)", expected, len(items))
+		}
+		for i, child := range target.Children {
+			if err := in.assign(child, items[i], fr); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "Subscript":
+		container, err := in.eval(target.Children[0], fr)
+		if err != nil {
+			return err
+		}
+		index, err := in.evalIndex(target.Children[1], fr)
+		if err != nil {
+			return err
+		}
+		return in.setItem(container, index, value)
+	case "Attribute":
+		object, err := in.eval(target.Children[0], fr)
+		if err != nil {
+			return err
+		}
+		return in.setAttr(object, target.Value, value)
+	}
+	return in.raise("SyntaxError", "cannot assign to expression")
+}
+
+// augAssign evalúa el destino una sola vez. += sobre una lista la extiende en
+// el lugar, de modo que los alias ven el cambio.
+func (in *Interpreter) augAssign(node *models.ASTNode, fr *frame) error {
+	target := node.Children[0]
+	op := strings.TrimSuffix(node.Value, "=")
+
+	var current, container, index, object pyValue
+	var err error
+	switch target.Type {
+	case "Identifier":
+		current, err = in.lookup(fr, target.Value)
+	case "Subscript":
+		if container, err = in.eval(target.Children[0], fr); err != nil {
+			return err
+		}
+		if index, err = in.evalIndex(target.Children[1], fr); err != nil {
+			return err
+		}
+		current, err = in.getItem(container, index)
+	case "Attribute":
+		if object, err = in.eval(target.Children[0], fr); err != nil {
+			return err
+		}
+		current, err = in.getAttr(object, target.Value)
+	}
+	if err != nil {
+		return err
+	}
+
+	operand, err := in.eval(node.Children[1], fr)
+	if err != nil {
+		return err
+	}
+	var result pyValue
+	if list, ok := current.(*pyList); ok && op == "+" {
+		items, err := in.collect(operand)
+		if err != nil {
+			return err
+		}
+		list.items = append(list.items, items...)
+		result = list
+	} else if result, err = in.binary(op, current, operand); err != nil {
+		return err
+	}
+
+	switch target.Type {
+	case "Identifier":
+		in.store(fr, target.Value, result)
+	case "Subscript":
+		return in.setItem(container, index, result)
+	case "Attribute":
+		return in.setAttr(object, target.Value, result)
+	}
+	return nil
+}
+
+// eval evalúa una expresión
+func (in *Interpreter) eval(node *models.ASTNode, fr *frame) (pyValue, error) {
+	if err := in.nest(); err != nil {
+		return nil, err
+	}
+	defer in.unnest()
+
+	switch node.Type {
+	case "Number", "String", "Boolean", "None":
+		if value, ok := in.literals[node]; ok {
+			return value, nil
+		}
+		value, ok := literalValue(node)
+		if !ok {
+			return nil, in.raise("SyntaxError", "invalid literal '%s'", node.Value)
+		}
+		in.literals[node] = value
+		return value, nil
+
+	case "Identifier":
+		return in.lookup(fr, node.Value)
+
+	case "BinaryOp":
+		left, err := in.eval(node.Children[0], fr)
+		if err != nil {
+			return nil, err
+		}
+		if node.Value == "and" || node.Value == "or" {
+			truth, err := in.truthy(left)
+			if err != nil || truth == (node.Value == "or") {
+				return left, err
+			}
+			return in.eval(node.Children[1], fr)
+		}
+		right, err := in.eval(node.Children[1], fr)
+		if err != nil {
+			return nil, err
+		}
+		return in.binary(node.Value, left, right)
+
+	case "UnaryOp":
+		operand, err := in.eval(node.Children[0], fr)
+		if err != nil {
+			return nil, err
+		}
+		return in.unary(node.Value, operand)
+
+	case "IfExpression":
+		condition, err := in.eval(node.Children[0], fr)
+		if err != nil {
+			return nil, err
+		}
+		truth, err := in.truthy(condition)
+		if err != nil {
+			return nil, err
+		}
+		if truth {
+			return in.eval(node.Children[1], fr)
+		}
+		return in.eval(node.Children[2], fr)
+
+	case "List", "Tuple", "Set":
+		items := make([]pyValue, 0, len(node.Children))
+		for _, child := range node.Children {
+			item, err := in.eval(child, fr)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if err := in.allocate(objectBytes + slotBytes*int64(len(items))); err != nil {
+			return nil, err
+		}
+		switch node.Type {
+		case "List":
+			return newList(items), nil
+		case "Tuple":
+			return newTuple(items), nil
+		}
+		return in.makeSet(items)
+
+	case "Dict":
+		dict := newDict()
+		for i := 0; i+1 < len(node.Children); i += 2 {
+			key, err := in.eval(node.Children[i], fr)
+			if err != nil {
+				return nil, err
+			}
+			value, err := in.eval(node.Children[i+1], fr)
+			if err != nil {
+				return nil, err
+			}
+			if err := in.dictSet(dict, key, value); err != nil {
+				return nil, err
+			}
+		}
+		return dict, in.allocate(objectBytes)
+
+	case "ListComp", "SetComp", "DictComp", "GeneratorExp":
+		return in.comprehension(node, fr)
+
+	case "Lambda":
+		return in.defineFunction(node, fr)
+
+	case "FunctionCall":
+		parts := strings.Split(node.Value, ".")
+		callee, err := in.lookup(fr, parts[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, attr := range parts[1:] {
+			if callee, err = in.getAttr(callee, attr); err != nil {
+				return nil, err
+			}
+		}
+		return in.callNode(callee, node.Children, fr)
+
+	case "MethodCall":
+		callee, err := in.eval(node.Children[0], fr)
+		if err != nil {
+			return nil, err
+		}
+		return in.callNode(callee, node.Children[1:], fr)
+
+	case "Attribute":
+		object, err := in.eval(node.Children[0], fr)
+		if err != nil {
+			return nil, err
+		}
+		return in.getAttr(object, node.Value)
+
+	case "Subscript":
+		container, err := in.eval(node.Children[0], fr)
+		if err != nil {
+			return nil, err
+		}
+		index, err := in.evalIndex(node.Children[1], fr)
+		if err != nil {
+			return nil, err
+		}
+		return in.getItem(container, index)
+	}
+
+	return nil, in.raise("NotImplementedError", "la construcción '%s' no está soportada por el intérprete", node.Type)
+}
+
+// evalIndex evalúa el índice de un subíndice; un rebanado produce un pySlice
+func (in *Interpreter) evalIndex(node *models.ASTNode, fr *frame) (pyValue, error) {
+	if node.Type != "Slice" {
+		return in.eval(node, fr)
+	}
+	parts := make([]pyValue, 3)
+	for i, child := range node.Children {
+		value, err := in.eval(child, fr)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = value
+	}
+	return &pySlice{start: parts[0], stop: parts[1], step: parts[2]}, nil
+}
+
+// comprehension evalúa una comprensión en su propio scope. El primer iterable
+// se evalúa en el scope exterior; las expresiones generadoras se evalúan de
+// inmediato y devuelven un iterador sobre los resultados.
+func (in *Interpreter) comprehension(node *models.ASTNode, fr *frame) (pyValue, error) {
+	var elements, clauses []*models.ASTNode
+	for _, child := range node.Children {
+		if child.Type == "Comprehension" {
+			clauses = append(clauses, child)
+		} else {
+			elements = append(elements, child)
+		}
+	}
+
+	first, err := in.eval(clauses[0].Children[1], fr)
+	if err != nil {
+		return nil, err
+	}
+	inner := &frame{
+		function: fr.function,
+		scope:    in.scopes[node],
+		locals:   make(map[string]pyValue),
+		closure:  closureOf(fr),
+	}
+	if inner.scope == nil {
+		inner.scope = fr.scope
+		inner.locals = fr.locals
+	}
+
+	items := make([]pyValue, 0)
+	dict := newDict()
+	var loop func(level int, iterable pyValue) error
+	loop = func(level int, iterable pyValue) error {
+		clause := clauses[level]
+		return in.iterate(iterable, func(item pyValue) (bool, error) {
+			if err := in.assign(clause.Children[0], item, inner); err != nil {
+				return false, err
+			}
+			for _, condition := range clause.Children[2:] {
+				value, err := in.eval(condition, inner)
+				if err != nil {
+					return false, err
+				}
+				if truth, err := in.truthy(value); err != nil || !truth {
+					return err == nil, err
+				}
+			}
+			if level+1 < len(clauses) {
+				next, err := in.eval(clauses[level+1].Children[1], inner)
+				if err != nil {
+					return false, err
+				}
+				return true, loop(level+1, next)
+			}
+
+			if node.Type == "DictComp" {
+				key, err := in.eval(elements[0], inner)
+				if err != nil {
+					return false, err
+				}
+				value, err := in.eval(elements[1], inner)
+				if err != nil {
+					return false, err
+				}
+				return true, in.dictSet(dict, key, value)
+			}
+			element, err := in.eval(elements[0], inner)
+			if err != nil {
+				return false, err
+			}
+			items = append(items, element)
+			return true, in.allocate(slotBytes)
+		})
+	}
+	if err := loop(0, first); err != nil {
+		return nil, err
+	}
+
+	switch node.Type {
+	case "DictComp":
+		return dict, nil
+	case "SetComp":
+		return in.makeSet(items)
+	case "GeneratorExp":
+		return &pyIterator{name: "generator", next: sliceIterator(func() []pyValue { return items })}, nil
+	}
+	return newList(items), nil
+}
+
+func (in *Interpreter) makeSet(items []pyValue) (*pySet, error) {
+	set := newSet()
+	for _, item := range items {
+		key, err := in.hashKey(item)
+		if err != nil {
+			return nil, err
+		}
+		set.add(key, item)
+	}
+	return set, nil
+}
+
+func (in *Interpreter) dictSet(dict *pyDict, key, value pyValue) error {
+	hash, err := in.hashKey(key)
+	if err != nil {
+		return err
+	}
+	if _, exists := dict.index[hash]; !exists {
+		if err := in.allocate(2 * slotBytes); err != nil {
+			return err
+		}
+	}
+	dict.set(hash, key, value)
+	return nil
+}
+
+// callNode evalúa los argumentos de una llamada y llama al objeto
+func (in *Interpreter) callNode(callee pyValue, argNodes []*models.ASTNode, fr *frame) (pyValue, error) {
+	args := make([]pyValue, 0, len(argNodes))
+	var kwargs []keywordArg
+	for _, arg := range argNodes {
+		if arg.Type == "Keyword" {
+			value, err := in.eval(arg.Children[0], fr)
+			if err != nil {
+				return nil, err
+			}
+			kwargs = append(kwargs, keywordArg{name: arg.Value, value: value})
+			continue
+		}
+		value, err := in.eval(arg, fr)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	return in.call(callee, args, kwargs)
+}
+
+// call llama a cualquier objeto invocable
+func (in *Interpreter) call(callee pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	switch c := callee.(type) {
+	case *pyFunction:
+		return in.callFunction(c, args, kwargs)
+	case *pyMethod:
+		return in.callFunction(c.function, append([]pyValue{c.self}, args...), kwargs)
+	case *pyBuiltin:
+		return c.call(in, args, kwargs)
+	case *pyClass:
+		return in.instantiate(c, args, kwargs)
+	case *pyCached:
+		return in.callCached(c, args, kwargs)
+	case *pyInstance:
+		if method, ok := c.class.lookup("__call__"); ok {
+			return in.call(in.bind(method, c), args, kwargs)
+		}
+	}
+	return nil, in.raise("TypeError", "'%s' object is not callable", typeName(callee))
+}
+
+// callSpecial llama a un método especial (__eq__, __len__...) de una instancia
+func (in *Interpreter) callSpecial(instance *pyInstance, name string, args ...pyValue) (pyValue, error) {
+	method, ok := instance.class.lookup(name)
+	if !ok {
+		return nil, in.raise("AttributeError", "'%s' object has no attribute '%s'", instance.class.name, name)
+	}
+	return in.call(in.bind(method, instance), args, nil)
+}
+
+// bind liga una función de la clase a la instancia que la recibe
+func (in *Interpreter) bind(value, self pyValue) pyValue {
+	switch v := value.(type) {
+	case *pyFunction:
+		return &pyMethod{self: self, function: v}
+	case *pyBuiltin:
+		return &pyBuiltin{name: v.name, call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return v.call(in, append([]pyValue{self}, args...), kwargs)
+		}}
+	}
+	return value
+}
+
+func (in *Interpreter) callFunction(fn *pyFunction, args []pyValue, kwargs []keywordArg) (result pyValue, err error) {
+	if in.depth >= in.recursionLimit {
+		return nil, in.raise("RecursionError", "maximum recursion depth exceeded")
+	}
+	if err := in.tick(); err != nil {
+		return nil, err
+	}
+	locals, err := in.bindArguments(fn, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.allocate(objectBytes + slotBytes*int64(len(locals))); err != nil {
+		return nil, err
+	}
+	if in.tracer != nil {
+		tracer, traced := in.tracer, in.tracer.enter(in, fn, args, kwargs)
+		defer func() { tracer.exit(in, traced, result, err) }()
+	}
+
+	fr := &frame{
+		function: fn.qualname,
+		scope:    fn.scope,
+		locals:   locals,
+		closure:  fn.closure,
+		caller:   in.frame,
+		fn:       fn,
+		args:     args,
+		line:     fn.node.Line,
+	}
+	in.frame = fr
+	in.depth++
+	in.maxDepth = max(in.maxDepth, in.depth)
+	defer func() {
+		in.frame = fr.caller
+		in.depth--
+	}()
+
+	_, body := functionParts(fn.node)
+	code := in.compiled(fn.node)
+	if fn.node.Type == "Lambda" {
+		if code != nil {
+			return in.execute(code, fr)
+		}
+		return in.eval(body[0], fr)
+	}
+	var value pyValue
+	if code != nil {
+		value, err = in.execute(code, fr)
+	} else {
+		var flow int
+		flow, value, err = in.execBlock(body, fr)
+		if flow != flowReturn {
+			value = noneValue()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if in.debugger != nil {
+		if err := in.debugger.returned(fr, value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// bindArguments asocia los argumentos a los parámetros con las reglas de
+// Python: posicionales, por nombre, valores por defecto, *args, **kwargs y
+// parámetros solo por nombre
+func (in *Interpreter) bindArguments(fn *pyFunction, args []pyValue, kwargs []keywordArg) (map[string]pyValue, error) {
+	params, _ := functionParts(fn.node)
+	locals := make(map[string]pyValue)
+	var positional, keywordOnly []*models.ASTNode
+	varArgs, varKeywords := "", ""
+	star := false
+	for _, param := range params {
+		switch {
+		case param.Value == "*":
+			star = true
+		case strings.HasPrefix(param.Value, "**"):
+			varKeywords = param.Value[2:]
+		case strings.HasPrefix(param.Value, "*"):
+			varArgs = param.Value[1:]
+			star = true
+		case star:
+			keywordOnly = append(keywordOnly, param)
+		default:
+			positional = append(positional, param)
+		}
+	}
+
+	if len(args) > len(positional) && varArgs == "" {
+		required := 0
+		for _, param := range positional {
+			if _, ok := fn.defaults[param]; !ok {
+				required++
+			}
+		}
+		takes := fmt.Sprintf("%d", len(positional))
+		if required < len(positional) {
+			takes = fmt.Sprintf("from %d to %d", required, len(positional))
+		}
+		return nil, in.raise("TypeError", "%s() takes %s positional argument%s but %d %s given", fn.qualname, takes, plural(len(positional)), len(args), map[bool]string{true: "was", false: "were"}[len(args) == 1])
+	}
+	for i, param := range positional {
+		if i < len(args) {
+			locals[param.Value] = args[i]
+		}
+	}
+	if varArgs != "" {
+		rest := make([]pyValue, 0)
+		if len(args) > len(positional) {
+			rest = append(rest, args[len(positional):]...)
+		}
+		locals[varArgs] = newTuple(rest)
+	}
+
+	extra := newDict()
+	for _, kwarg := range kwargs {
+		found := false
+		for _, param := range append(positional, keywordOnly...) {
+			if param.Value != kwarg.name {
+				continue
+			}
+			if _, bound := locals[param.Value]; bound {
+				return nil, in.raise("TypeError", "%s() got multiple values for argument '%s'", fn.qualname, kwarg.name)
+			}
+			locals[param.Value] = kwarg.value
+			found = true
+		}
+		if found {
+			continue
+		}
+		if varKeywords == "" {
+			return nil, in.raise("TypeError", "%s() got an unexpected keyword argument '%s'", fn.qualname, kwarg.name)
+		}
+		if err := in.dictSet(extra, strValue(kwarg.name), kwarg.value); err != nil {
+			return nil, err
+		}
+	}
+	if varKeywords != "" {
+		locals[varKeywords] = extra
+	}
+
+	for _, group := range []struct {
+		params []*models.ASTNode
+		kind   string
+	}{{positional, "positional"}, {keywordOnly, "keyword-only"}} {
+		missing := make([]string, 0)
+		for _, param := range group.params {
+			if _, bound := locals[param.Value]; bound {
+				continue
+			}
+			if value, ok := fn.defaults[param]; ok {
+				locals[param.Value] = value
+				continue
+			}
+			missing = append(missing, "'"+param.Value+"'")
+		}
+		if len(missing) > 0 {
+			return nil, in.raise("TypeError", "%s() missing %d required %s argument%s: %s", fn.qualname, len(missing), group.kind, plural(len(missing)), joinNames(missing))
+		}
+	}
+	return locals, nil
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// joinNames enumera como Python: 'a', 'b' and 'c'
+func joinNames(names []string) string {
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", and " + names[len(names)-1]
+}
+
+// instantiate crea una instancia de una clase del programa y llama a __init__.
+// Las excepciones guardan sus argumentos en args, como BaseException.__new__.
+func (in *Interpreter) instantiate(class *pyClass, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if class.construct != nil {
+		return class.construct(in, args, kwargs)
+	}
+	if err := in.allocate(objectBytes); err != nil {
+		return nil, err
+	}
+
+	instance := &pyInstance{class: class, attrs: make(map[string]pyValue)}
+	if class.isSubclass(in.exceptionBase) {
+		instance.attrs["args"] = newTuple(append([]pyValue(nil), args...))
+	}
+	init, ok := class.lookup("__init__")
+	if !ok {
+		if len(args) > 0 || len(kwargs) > 0 {
+			return nil, in.raise("TypeError", "%s() takes no arguments", class.name)
+		}
+		return instance, nil
+	}
+	result, err := in.call(in.bind(init, instance), args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if !isNone(result) {
+		return nil, in.raise("TypeError", "__init__() should return None, not '%s'", typeName(result))
+	}
+	return instance, nil
+}
+
+// callCached consulta la caché de functools.lru_cache antes de llamar
+func (in *Interpreter) callCached(cached *pyCached, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	parts := make([]pyValue, 0, len(args)+2*len(kwargs))
+	parts = append(parts, args...)
+	for _, kwarg := range kwargs {
+		parts = append(parts, strValue(kwarg.name), kwarg.value)
+	}
+	key, err := in.hashKey(newTuple(parts))
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := cached.cache[key]; ok {
+		cached.hits++
+		if fn, ok := cached.function.(*pyFunction); ok && in.tracer != nil {
+			in.tracer.cached(in, fn, args, kwargs, value)
+		}
+		return value, nil
+	}
+	cached.misses++
+	value, err := in.call(cached.function, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.allocate(2 * slotBytes); err != nil {
+		return nil, err
+	}
+	cached.cache[key] = value
+	return value, nil
+}
+
+// operatorMethods son los métodos especiales de cada operador binario y su versión reflejada
+var operatorMethods = map[string][2]string{
+	"+":  {"__add__", "__radd__"},
+	"-":  {"__sub__", "__rsub__"},
+	"*":  {"__mul__", "__rmul__"},
+	"/":  {"__truediv__", "__rtruediv__"},
+	"//": {"__floordiv__", "__rfloordiv__"},
+	"%":  {"__mod__", "__rmod__"},
+	"**": {"__pow__", "__rpow__"},
+	"<<": {"__lshift__", "__rlshift__"},
+	">>": {"__rshift__", "__rrshift__"},
+	"&":  {"__and__", "__rand__"},
+	"|":  {"__or__", "__ror__"},
+	"^":  {"__xor__", "__rxor__"},
+}
+
+// binary evalúa "a op b" con la semántica de Python
+func (in *Interpreter) binary(op string, a, b pyValue) (pyValue, error) {
+	switch op {
+	case "==", "!=":
+		same, err := in.equal(a, b)
+		return boolValue(same == (op == "==")), err
+	case "<", "<=", ">", ">=":
+		result, err := in.compare(op, a, b)
+		return boolValue(result), err
+	case "in", "not in":
+		found, err := in.contains(b, a)
+		return boolValue(found == (op == "in")), err
+	case "is", "is not":
+		return boolValue(identical(a, b) == (op == "is")), nil
+	}
+
+	left, leftScalar := a.(constValue)
+	right, rightScalar := b.(constValue)
+	if leftScalar && left.kind == "str" && op == "%" {
+		text, err := in.formatPercent(left.s, b)
+		if err != nil {
+			return nil, err
+		}
+		return strValue(text), in.allocateValue(strValue(text))
+	}
+	if leftScalar && rightScalar && left.isIntegral() && right.isIntegral() {
+		cost := intCost(op, left.toInt(), right.toInt(), in.valueBytes()*8)
+		if err := in.affordable(fmt.Sprintf("La operación '%s' con enteros tan grandes", op), cost); err != nil {
+			return nil, err
+		}
+	}
+	if leftScalar && rightScalar {
+		result, err := binaryValue(op, left, right, in.valueBytes())
+		switch e := err.(type) {
+		case nil:
+			return result, in.allocateValue(result)
+		case *pythonError:
+			return nil, in.raise(e.name, "%s", e.message)
+		}
+		if err == errTooLarge {
+			return nil, in.limit("MemoryLimitExceeded", "El resultado de '%s' supera el tamaño máximo de %d bytes por valor", op, in.valueBytes())
+		}
+		if op == "**" && left.isNumber() && right.isNumber() {
+			return nil, in.raise("NotImplementedError", "los números complejos no están soportados por el intérprete")
+		}
+	}
+
+	if result, ok, err := in.sequenceBinary(op, a, b); ok || err != nil {
+		return result, err
+	}
+
+	methods := operatorMethods[op]
+	if instance, ok := a.(*pyInstance); ok {
+		if _, defined := instance.class.lookup(methods[0]); defined {
+			return in.callSpecial(instance, methods[0], b)
+		}
+	}
+	if instance, ok := b.(*pyInstance); ok {
+		if _, defined := instance.class.lookup(methods[1]); defined {
+			return in.callSpecial(instance, methods[1], a)
+		}
+	}
+
+	switch {
+	case leftScalar && left.kind == "str" && op == "+":
+		return nil, in.raise("TypeError", "can only concatenate str (not \"%s\") to str", typeName(b))
+	case op == "*" && (isRepeatable(a) || isRepeatable(b)):
+		other := b
+		if isRepeatable(b) && !isRepeatable(a) {
+			other = a
+		}
+		return nil, in.raise("TypeError", "can't multiply sequence by non-int of type '%s'", typeName(other))
+	case op == "+" && isSequence(a):
+		return nil, in.raise("TypeError", "can only concatenate %s (not \"%s\") to %s", typeName(a), typeName(b), typeName(a))
+	}
+	return nil, in.raise("TypeError", "unsupported operand type(s) for %s: '%s' and '%s'", op, typeName(a), typeName(b))
+}
+
+// isRepeatable indica los valores que admiten "secuencia * entero"
+func isRepeatable(value pyValue) bool {
+	if text, ok := value.(constValue); ok {
+		return text.kind == "str"
+	}
+	return isSequence(value)
+}
+
+func isSequence(value pyValue) bool {
+	switch value.(type) {
+	case *pyList, *pyTuple:
+		return true
+	}
+	return false
+}
+
+// sequenceBinary implementa los operadores de listas, tuplas, conjuntos y diccionarios
+func (in *Interpreter) sequenceBinary(op string, a, b pyValue) (pyValue, bool, error) {
+	repeat := func(items []pyValue, count pyValue) ([]pyValue, bool, error) {
+		times, ok := count.(constValue)
+		if !ok || !times.isIntegral() {
+			return nil, false, nil
+		}
+		n := times.toInt()
+		if n.Sign() <= 0 || len(items) == 0 {
+			return []pyValue{}, true, nil
+		}
+		if !n.IsInt64() || n.Int64() > math.MaxInt32 {
+			return nil, true, in.limit("MemoryLimitExceeded", "Se superó el límite de %d bytes de memoria asignada", in.limits.MaxMemoryBytes)
+		}
+		total := int64(len(items)) * n.Int64()
+		if err := in.allocate(slotBytes * total); err != nil {
+			return nil, true, err
+		}
+		result := make([]pyValue, 0, total)
+		for i := int64(0); i < n.Int64(); i++ {
+			result = append(result, items...)
+		}
+		return result, true, nil
+	}
+
+	switch l := a.(type) {
+	case *pyList:
+		switch r := b.(type) {
+		case *pyList:
+			if op == "+" {
+				if err := in.allocate(objectBytes + slotBytes*int64(len(l.items)+len(r.items))); err != nil {
+					return nil, true, err
+				}
+				return newList(append(append([]pyValue{}, l.items...), r.items...)), true, nil
+			}
+		case constValue:
+			if op == "*" {
+				items, ok, err := repeat(l.items, r)
+				if ok && err == nil {
+					return newList(items), true, nil
+				}
+				return nil, ok, err
+			}
+		}
+	case *pyTuple:
+		switch r := b.(type) {
+		case *pyTuple:
+			if op == "+" {
+				if err := in.allocate(objectBytes + slotBytes*int64(len(l.items)+len(r.items))); err != nil {
+					return nil, true, err
+				}
+				return newTuple(append(append([]pyValue{}, l.items...), r.items...)), true, nil
+			}
+		case constValue:
+			if op == "*" {
+				items, ok, err := repeat(l.items, r)
+				if ok && err == nil {
+					return newTuple(items), true, nil
+				}
+				return nil, ok, err
+			}
+		}
+	case constValue:
+		if op == "*" && l.isIntegral() && isSequence(b) {
+			return in.sequenceBinary(op, b, a)
+		}
+	case *pySet:
+		if r, ok := b.(*pySet); ok {
+			result := newSet()
+			switch op {
+			case "|":
+				for _, item := range append(append([]pyValue{}, l.items...), r.items...) {
+					key, _ := in.hashKey(item)
+					result.add(key, item)
+				}
+			case "&", "-":
+				for _, item := range l.items {
+					key, _ := in.hashKey(item)
+					if _, found := r.index[key]; found == (op == "&") {
+						result.add(key, item)
+					}
+				}
+			case "^":
+				for _, pair := range [][2]*pySet{{l, r}, {r, l}} {
+					for _, item := range pair[0].items {
+						key, _ := in.hashKey(item)
+						if _, found := pair[1].index[key]; !found {
+							result.add(key, item)
+						}
+					}
+				}
+			default:
+				return nil, false, nil
+			}
+			return result, true, in.allocate(objectBytes + slotBytes*int64(len(result.items)))
+		}
+	case *pyDict:
+		if r, ok := b.(*pyDict); ok && op == "|" {
+			result := newDict()
+			for _, source := range []*pyDict{l, r} {
+				for i, key := range source.keys {
+					if err := in.dictSet(result, key, source.values[i]); err != nil {
+						return nil, true, err
+					}
+				}
+			}
+			return result, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+var unaryMethods = map[string]string{"-": "__neg__", "+": "__pos__", "~": "__invert__"}
+
+func (in *Interpreter) unary(op string, operand pyValue) (pyValue, error) {
+	if op == "not" {
+		truth, err := in.truthy(operand)
+		return boolValue(!truth), err
+	}
+	if value, ok := operand.(constValue); ok {
+		result, err := constUnary(op, value)
+		if err == nil {
+			return result, nil
+		}
+	}
+	if instance, ok := operand.(*pyInstance); ok {
+		if _, defined := instance.class.lookup(unaryMethods[op]); defined {
+			return in.callSpecial(instance, unaryMethods[op])
+		}
+	}
+	return nil, in.raise("TypeError", "bad operand type for unary %s: '%s'", op, typeName(operand))
+}
+
+// getAttr lee un atributo: de la instancia, de su clase (ligando los métodos),
+// de un módulo o un método de un tipo integrado
+func (in *Interpreter) getAttr(object pyValue, name string) (pyValue, error) {
+	switch o := object.(type) {
+	case *pyInstance:
+		if value, ok := o.attrs[name]; ok {
+			return value, nil
+		}
+		if name == "__class__" {
+			return o.class, nil
+		}
+		if value, ok := o.class.lookup(name); ok {
+			return in.bind(value, o), nil
+		}
+	case *pyClass:
+		switch name {
+		case "__name__", "__qualname__":
+			return strValue(o.name), nil
+		case "__mro__":
+			items := make([]pyValue, len(o.mro))
+			for i, class := range o.mro {
+				items[i] = class
+			}
+			return newTuple(items), nil
+		}
+		if value, ok := o.lookup(name); ok {
+			return value, nil
+		}
+		if o.builtin {
+			if method, ok := in.unboundMethod(o.name, name); ok {
+				return method, nil
+			}
+		}
+		return nil, in.raise("AttributeError", "type object '%s' has no attribute '%s'", o.name, name)
+	case *pySuper:
+		mro := in.typeOf(o.self).mro
+		for i, class := range mro {
+			if class != o.class {
+				continue
+			}
+			for _, next := range mro[i+1:] {
+				if value, ok := next.attrs[name]; ok {
+					return in.bind(value, o.self), nil
+				}
+			}
+		}
+		if name == "__init__" {
+			return &pyBuiltin{name: "__init__", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+				return noneValue(), nil
+			}}, nil
+		}
+		return nil, in.raise("AttributeError", "'super' object has no attribute '%s'", name)
+	case *pyModule:
+		if value, ok := o.attrs[name]; ok {
+			return value, nil
+		}
+		return nil, in.raise("AttributeError", "module '%s' has no attribute '%s'", o.name, name)
+	case *pyFunction:
+		switch name {
+		case "__name__":
+			return strValue(o.name), nil
+		case "__qualname__":
+			return strValue(o.qualname), nil
+		}
+	case *pyCached:
+		return in.cachedAttr(o, name)
+	case *pyBuiltin:
+		if name == "__name__" {
+			return strValue(o.name), nil
+		}
+	}
+
+	if method, ok := in.boundMethod(object, name); ok {
+		return method, nil
+	}
+	return nil, in.raise("AttributeError", "'%s' object has no attribute '%s'", typeName(object), name)
+}
+
+func (in *Interpreter) setAttr(object pyValue, name string, value pyValue) error {
+	switch o := object.(type) {
+	case *pyInstance:
+		if _, ok := o.attrs[name]; !ok {
+			if err := in.allocate(slotBytes); err != nil {
+				return err
+			}
+		}
+		o.attrs[name] = value
+		return nil
+	case *pyClass:
+		if !o.builtin {
+			o.attrs[name] = value
+			return nil
+		}
+		return in.raise("TypeError", "cannot set '%s' attribute of immutable type '%s'", name, o.name)
+	}
+	return in.raise("AttributeError", "'%s' object has no attribute '%s' and no __dict__ for setting new attributes", typeName(object), name)
+}
+
+// sliceBounds calcula los índices efectivos de un rebanado, como slice.indices
+func (in *Interpreter) sliceBounds(slice *pySlice, length int64) (int64, int64, int64, error) {
+	index := func(value pyValue, fallback int64) (int64, bool, error) {
+		if isNone(value) {
+			return fallback, false, nil
+		}
+		number, ok := value.(constValue)
+		if !ok || !number.isIntegral() {
+			return 0, false, in.raise("TypeError", "slice indices must be integers or None or have an __index__ method")
+		}
+		i := number.toInt()
+		if !i.IsInt64() {
+			if i.Sign() < 0 {
+				return math.MinInt64 / 2, true, nil
+			}
+			return math.MaxInt64 / 2, true, nil
+		}
+		return i.Int64(), true, nil
+	}
+
+	step, _, err := index(slice.step, 1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if step == 0 {
+		return 0, 0, 0, in.raise("ValueError", "slice step cannot be zero")
+	}
+	clamp := func(value pyValue, forward, backward int64) (int64, error) {
+		fallback := forward
+		if step < 0 {
+			fallback = backward
+		}
+		i, given, err := index(value, fallback)
+		if err != nil || !given {
+			return i, err
+		}
+		if i < 0 {
+			i += length
+			if i < 0 {
+				i = map[bool]int64{true: -1, false: 0}[step < 0]
+			}
+		} else if i >= length {
+			i = map[bool]int64{true: length - 1, false: length}[step < 0]
+		}
+		return i, nil
+	}
+	start, err := clamp(slice.start, 0, length-1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	stop, err := clamp(slice.stop, length, -1)
+	return start, stop, step, err
+}
+
+func sliceItems(items []pyValue, start, stop, step int64) []pyValue {
+	result := make([]pyValue, 0)
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		result = append(result, items[i])
+	}
+	return result
+}
+
+// sequenceIndex normaliza un índice entero (negativo desde el final)
+func (in *Interpreter) sequenceIndex(index pyValue, length int, kind string) (int, error) {
+	number, ok := index.(constValue)
+	if !ok || !number.isIntegral() {
+		return 0, in.raise("TypeError", "%s indices must be integers or slices, not %s", kind, typeName(index))
+	}
+	i := number.toInt()
+	if i.Sign() < 0 {
+		i = new(big.Int).Add(i, big.NewInt(int64(length)))
+	}
+	if !i.IsInt64() || i.Int64() < 0 || i.Int64() >= int64(length) {
+		return 0, in.raise("IndexError", "%s index out of range", kind)
+	}
+	return int(i.Int64()), nil
+}
+
+func (in *Interpreter) getItem(container, index pyValue) (pyValue, error) {
+	slice, isSlice := index.(*pySlice)
+	switch c := container.(type) {
+	case *pyList, *pyTuple:
+		items, kind := sequenceItems(c)
+		if isSlice {
+			start, stop, step, err := in.sliceBounds(slice, int64(len(items)))
+			if err != nil {
+				return nil, err
+			}
+			result := sliceItems(items, start, stop, step)
+			if err := in.allocate(objectBytes + slotBytes*int64(len(result))); err != nil {
+				return nil, err
+			}
+			if kind == "list" {
+				return newList(result), nil
+			}
+			return newTuple(result), nil
+		}
+		i, err := in.sequenceIndex(index, len(items), kind)
+		if err != nil {
+			return nil, err
+		}
+		return items[i], nil
+	case constValue:
+		if c.kind != "str" {
+			break
+		}
+		runes := []rune(c.s)
+		if isSlice {
+			start, stop, step, err := in.sliceBounds(slice, int64(len(runes)))
+			if err != nil {
+				return nil, err
+			}
+			var out strings.Builder
+			for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+				out.WriteRune(runes[i])
+			}
+			result := strValue(out.String())
+			return result, in.allocateValue(result)
+		}
+		i, err := in.sequenceIndex(index, len(runes), "string")
+		if err != nil {
+			return nil, err
+		}
+		return strValue(string(runes[i])), nil
+	case *pyDict:
+		key, err := in.hashKey(index)
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := c.get(key); ok {
+			return value, nil
+		}
+		return nil, in.throw(&pyInstance{class: in.builtinClass("KeyError"), attrs: map[string]pyValue{"args": newTuple([]pyValue{index})}})
+	case *pyRange:
+		if isSlice {
+			start, stop, step, err := in.sliceBounds(slice, c.length())
+			if err != nil {
+				return nil, err
+			}
+			return &pyRange{start: c.start + start*c.step, stop: c.start + stop*c.step, step: c.step * step}, nil
+		}
+		if c.length() > math.MaxInt32 {
+			number, ok := index.(constValue)
+			if ok && number.isIntegral() && number.toInt().IsInt64() {
+				i := number.toInt().Int64()
+				if i < 0 {
+					i += c.length()
+				}
+				if i >= 0 && i < c.length() {
+					return smallInt(c.start + i*c.step), nil
+				}
+			}
+		}
+		i, err := in.sequenceIndex(index, int(min(c.length(), math.MaxInt32)), "range object")
+		if err != nil {
+			return nil, err
+		}
+		return smallInt(c.start + int64(i)*c.step), nil
+	case *pyInstance:
+		if _, defined := c.class.lookup("__getitem__"); defined {
+			return in.callSpecial(c, "__getitem__", index)
+		}
+	case *pyClass:
+		// list[int], dict[str, int]: anotaciones genéricas
+		if c.builtin {
+			return c, nil
+		}
+	}
+	return nil, in.raise("TypeError", "'%s' object is not subscriptable", typeName(container))
+}
+
+func sequenceItems(value pyValue) ([]pyValue, string) {
+	switch v := value.(type) {
+	case *pyList:
+		return v.items, "list"
+	case *pyTuple:
+		return v.items, "tuple"
+	}
+	return nil, ""
+}
+
+func (in *Interpreter) setItem(container, index, value pyValue) error {
+	switch c := container.(type) {
+	case *pyList:
+		slice, isSlice := index.(*pySlice)
+		if !isSlice {
+			i, err := in.sequenceIndex(index, len(c.items), "list assignment")
+			if err != nil {
+				return err
+			}
+			c.items[i] = value
+			return nil
+		}
+		items, err := in.collect(value)
+		if err != nil {
+			return err
+		}
+		start, stop, step, err := in.sliceBounds(slice, int64(len(c.items)))
+		if err != nil {
+			return err
+		}
+		if step == 1 {
+			stop = max(stop, start)
+			replaced := append(append(append([]pyValue{}, c.items[:start]...), items...), c.items[stop:]...)
+			c.items = replaced
+			return nil
+		}
+		positions := make([]int64, 0)
+		for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+			positions = append(positions, i)
+		}
+		if len(positions) != len(items) {
+			return in.raise("ValueError", "attempt to assign sequence of size %d to extended slice of size %d", len(items), len(positions))
+		}
+		for i, position := range positions {
+			c.items[position] = items[i]
+		}
+		return nil
+	case *pyDict:
+		return in.dictSet(c, index, value)
+	case *pyInstance:
+		if _, defined := c.class.lookup("__setitem__"); defined {
+			_, err := in.callSpecial(c, "__setitem__", index, value)
+			return err
+		}
+	}
+	return in.raise("TypeError", "'%s' object does not support item assignment", typeName(container))
+}
@@ -11,6 +11,8 @@ type LexicalAnalyzer struct {
 	line     int
 	col      int
 	tokens   []models.Token
+	// ranges[i] es la posición exacta de tokens[i] en el código
+	ranges   []models.SourceRange
 }
 
 func NewLexicalAnalyzer(input string) *LexicalAnalyzer {
@@ -48,6 +50,11 @@ var keywords = map[string]bool{
 	"pass":   true,
 	"break":  true,
 	"continue": true,
+	"global": true,
+	"nonlocal": true,
+	"lambda": true,
+	"is":     true,
+	"raise":  true,
 }
 
 func (l *LexicalAnalyzer) peek() rune {
@@ -152,6 +159,7 @@ func (l *LexicalAnalyzer) Tokenize() models.LexicalAnalysis {
 		}
 		
 		ch := l.peek()
+		line, col, offset := l.line, l.col, l.position
 		
 		switch {
 		case ch == '\n':
@@ -180,8 +188,14 @@ func (l *LexicalAnalyzer) Tokenize() models.LexicalAnalysis {
 			l.addToken("NUMBER", number)
 			
 		case ch == '"' || ch == '\'':
+			// La columna es la de la comilla inicial porque el valor no la incluye
 			str := l.readString(ch)
-			l.addToken("STRING", str)
+			l.tokens = append(l.tokens, models.Token{
+				Type:  "STRING",
+				Value: str,
+				Line:  line,
+				Col:   col,
+			})
 			
 		case ch == '(':
 			l.advance()
@@ -237,6 +251,9 @@ func (l *LexicalAnalyzer) Tokenize() models.LexicalAnalysis {
 			if l.peek() == '=' {
 				l.advance()
 				l.addToken("OPERATOR", "-=")
+			} else if l.peek() == '>' {
+				l.advance()
+				l.addToken("SYMBOL", "->")
 			} else {
 				l.addToken("OPERATOR", "-")
 			}
@@ -346,6 +363,15 @@ func (l *LexicalAnalyzer) Tokenize() models.LexicalAnalysis {
 			l.advance()
 			l.addToken("ERROR", string(ch))
 		}
+		
+		l.ranges = append(l.ranges, models.SourceRange{
+			StartLine:   line,
+			StartCol:    col,
+			EndLine:     l.line,
+			EndCol:      l.col,
+			StartOffset: offset,
+			EndOffset:   l.position,
+		})
 	}
 	
 	// Calcular estadísticas
@@ -358,4 +384,11 @@ func (l *LexicalAnalyzer) Tokenize() models.LexicalAnalysis {
 		Tokens: l.tokens,
 		Stats:  stats,
 	}
-}
\ No newline at end of file
+}
+
+// Ranges devuelve la posición de cada token de Tokenize (inicio inclusivo y
+// fin exclusivo). A diferencia de Line y Col de los tokens, incluye el final
+// y es exacta también para los saltos de línea.
+func (l *LexicalAnalyzer) Ranges() []models.SourceRange {
+	return l.ranges
+}
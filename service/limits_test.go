@@ -0,0 +1,78 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"examen-back/models"
+)
+
+// run ejecuta un programa en el motor indicado con los límites dados
+func run(t *testing.T, code, engine string, limits models.RunLimits) models.RunResult {
+	t.Helper()
+	syntax := parse(code)
+	if !syntax.Valid {
+		t.Fatalf("errores de sintaxis: %v", syntax.Errors)
+	}
+	in := NewInterpreter(syntax.AST, NormalizeRunLimits(&limits))
+	if engine == EngineVM {
+		in.UseBytecode(CompileProgram(syntax.AST))
+	}
+	return in.Run("", nil)
+}
+
+func TestRunLimits(t *testing.T) {
+	cases := []struct {
+		name   string
+		code   string
+		limits models.RunLimits
+		error  string
+		limit  bool
+	}{
+		{"pasos", "while True:\n    pass\n", models.RunLimits{MaxSteps: 1000}, "StepLimitExceeded", true},
+		{"profundidad", "def f(n):\n    return f(n + 1)\n\nf(0)\n", models.RunLimits{MaxDepth: 50}, "RecursionError", false},
+		{"memoria", "x = []\nwhile True:\n    x.append([0] * 1000)\n", models.RunLimits{MaxMemoryBytes: 1 << 20}, "MemoryLimitExceeded", true},
+		{"tiempo", "while True:\n    pass\n", models.RunLimits{MaxSteps: maxRunSteps, TimeoutMs: 100}, "TimeLimitExceeded", true},
+		{"salida", "while True:\n    print('x' * 100)\n", models.RunLimits{MaxOutputBytes: 1000}, "OutputLimitExceeded", true},
+		{"contenedores anidados", "x = []\nfor i in range(100000):\n    x = [x]\nprint(x == x[0])\n", models.RunLimits{MaxDepth: 100}, "RecursionError", false},
+		{"texto de contenedores anidados", "x = []\nfor i in range(100000):\n    x = [x]\nprint(str(x))\n", models.RunLimits{MaxDepth: 100}, "RecursionError", false},
+		{"pow modular de enteros grandes", "x = 7 ** 100000\nprint(pow(x, x, x + 2))\n", models.RunLimits{TimeoutMs: 200}, "TimeLimitExceeded", true},
+		{"producto de enteros grandes", "x = 3 ** 2000000\nprint((x * x) % 10)\n", models.RunLimits{TimeoutMs: 10}, "TimeLimitExceeded", true},
+		{"conversión de un entero grande", "import sys\nsys.set_int_max_str_digits(0)\nprint(len(str(7 ** 2000000)))\n", models.RunLimits{TimeoutMs: 50}, "TimeLimitExceeded", true},
+		{"división entre cero", "print(1 // 0)\n", models.RunLimits{}, "ZeroDivisionError", false},
+	}
+	for _, tc := range cases {
+		for _, engine := range []string{EngineTree, EngineVM} {
+			t.Run(tc.name+"/"+engine, func(t *testing.T) {
+				start := time.Now()
+				result := run(t, tc.code, engine, tc.limits)
+				if result.Error == nil {
+					t.Fatalf("se esperaba %s y terminó sin error (salida %q)", tc.error, result.Stdout)
+				}
+				if result.Error.Type != tc.error || result.Error.Limit != tc.limit {
+					t.Errorf("error %s (límite %v), se esperaba %s (límite %v): %s",
+						result.Error.Type, result.Error.Limit, tc.error, tc.limit, result.Error.Message)
+				}
+				if result.Error.Line == 0 {
+					t.Errorf("el error no indica la línea")
+				}
+				// Ninguna ejecución debe pasarse mucho del tiempo máximo
+				timeout := time.Duration(NormalizeRunLimits(&tc.limits).TimeoutMs) * time.Millisecond
+				if elapsed := time.Since(start); elapsed > timeout+time.Second {
+					t.Errorf("tardó %v con un tiempo máximo de %v", elapsed, timeout)
+				}
+			})
+		}
+	}
+}
+
+func TestRunOutputIsTruncatedAtLimit(t *testing.T) {
+	result := run(t, "for i in range(1000):\n    print('abcdefghi')\n", EngineTree, models.RunLimits{MaxOutputBytes: 100})
+	if result.Error == nil || result.Error.Type != "OutputLimitExceeded" {
+		t.Fatalf("se esperaba OutputLimitExceeded: %+v", result.Error)
+	}
+	if len(result.Stdout) > 100 || !strings.HasPrefix(result.Stdout, "abcdefghi\n") {
+		t.Errorf("salida inesperada (%d bytes): %q", len(result.Stdout), result.Stdout)
+	}
+}
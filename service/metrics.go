@@ -0,0 +1,337 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"examen-back/models"
+)
+
+// depthRank ordena las clases de profundidad de recursión de menor a mayor
+var depthRank = map[string]int{"": 0, "none": 0, "logarithmic": 1, "linear": 2, "structural": 3, "unknown": 4, "unbounded": 5}
+
+// computeMetrics calcula las métricas de tamaño y complejidad de cada función.
+// Las líneas y tokens de una función anidada se cuentan solo en ella.
+func computeMetrics(tokens []models.Token, ast *models.ASTNode, tree *scopeTree, recursion *recursionAnalysis) []models.FunctionMetrics {
+	metrics := make([]models.FunctionMetrics, 0)
+	if ast == nil {
+		return metrics
+	}
+
+	functions := make([]*models.ASTNode, 0)
+	var collect func(node *models.ASTNode)
+	collect = func(node *models.ASTNode) {
+		if node.Type == "FunctionDef" {
+			functions = append(functions, node)
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(ast)
+
+	// Cada línea pertenece a la función más interna que la contiene
+	owners := make(map[int]*models.ASTNode)
+	ends := make(map[*models.ASTNode]int)
+	for _, fn := range functions {
+		ends[fn] = lastLine(fn)
+		for line := fn.Line; line <= ends[fn]; line++ {
+			owners[line] = fn
+		}
+	}
+
+	for _, fn := range functions {
+		owned := make([]models.Token, 0)
+		for _, token := range tokens {
+			if owners[token.Line] == fn {
+				owned = append(owned, token)
+			}
+		}
+		params, body := functionParts(fn)
+
+		info := models.FunctionMetrics{
+			Function:             functionName(tree, fn),
+			Line:                 fn.Line,
+			EndLine:              ends[fn],
+			Parameters:           countParameters(params),
+			Statements:           countStatements(body),
+			CyclomaticComplexity: 1 + countDecisions(body),
+			MaxNesting:           maxNesting(body, 0),
+			Recursion:            "none",
+			RecursionDepth:       "none",
+			Halstead:             halstead(owned),
+			Lines:                lineMetrics(owned, fn, ends[fn], owners),
+		}
+		if rf := recursion.byNode[fn]; rf != nil {
+			info.Recursion = rf.kind
+			info.RecursionDepth = recursion.depthClass(rf)
+			info.RecursiveCalls = len(rf.calls)
+		}
+		metrics = append(metrics, info)
+	}
+
+	sort.SliceStable(metrics, func(i, j int) bool { return metrics[i].Line < metrics[j].Line })
+	return metrics
+}
+
+// lastLine es la última línea que ocupa un nodo
+func lastLine(node *models.ASTNode) int {
+	last := node.Line
+	for _, child := range node.Children {
+		last = max(last, lastLine(child))
+	}
+	return last
+}
+
+func countParameters(params []*models.ASTNode) int {
+	count := 0
+	for _, param := range params {
+		if param.Value != "*" {
+			count++
+		}
+	}
+	return count
+}
+
+// isNestedDefinition indica los nodos cuyo cuerpo no forma parte de la función
+// que los contiene: funciones anidadas y clases tienen sus propias métricas
+func isNestedDefinition(node *models.ASTNode) bool {
+	return node.Type == "FunctionDef" || node.Type == "ClassDef"
+}
+
+// countStatements cuenta las sentencias del cuerpo, incluidas las de los
+// bloques anidados; una función o clase anidada cuenta como una sentencia
+func countStatements(body []*models.ASTNode) int {
+	count := 0
+	for _, stmt := range body {
+		count++
+		if !isNestedDefinition(stmt) {
+			count += countBlockStatements(stmt.Children)
+		}
+	}
+	return count
+}
+
+func countBlockStatements(children []*models.ASTNode) int {
+	count := 0
+	for _, child := range children {
+		switch {
+		case child.Type == "Block":
+			count += countStatements(child.Children)
+		case !isNestedDefinition(child):
+			count += countBlockStatements(child.Children)
+		}
+	}
+	return count
+}
+
+// countDecisions cuenta los puntos de decisión de McCabe: if/elif, bucles,
+// except, expresiones condicionales, condiciones y bucles de comprensiones y
+// cada operador and/or
+func countDecisions(nodes []*models.ASTNode) int {
+	count := 0
+	for _, node := range nodes {
+		if isNestedDefinition(node) {
+			continue
+		}
+		switch node.Type {
+		case "IfStatement", "WhileStatement", "ForStatement", "ExceptHandler", "IfExpression":
+			count++
+		case "Comprehension":
+			// Un bucle más una decisión por cada condición
+			count += len(node.Children) - 1
+		case "BinaryOp":
+			if (node.Value == "and" || node.Value == "or") && !isChainedComparison(node) {
+				count++
+			}
+		}
+		count += countDecisions(node.Children)
+	}
+	return count
+}
+
+// isChainedComparison reconoce el "and" que el parser crea para a < b < c:
+// comparte la posición del operador con la segunda comparación
+func isChainedComparison(node *models.ASTNode) bool {
+	right := node.Children[1]
+	return node.Value == "and" && right.Type == "BinaryOp" && isComparison(right.Value) && right.Line == node.Line && right.Col == node.Col
+}
+
+// maxNesting mide la profundidad máxima de bloques anidados. Un elif no
+// agrega un nivel respecto de su if.
+func maxNesting(nodes []*models.ASTNode, depth int) int {
+	deepest := depth
+	for _, node := range nodes {
+		if isNestedDefinition(node) {
+			continue
+		}
+		if node.Type != "Block" {
+			deepest = max(deepest, maxNesting(node.Children, depth))
+			continue
+		}
+		if len(node.Children) == 1 && node.Children[0].Type == "IfStatement" && node.Children[0].Line == node.Line {
+			deepest = max(deepest, maxNesting(node.Children, depth))
+			continue
+		}
+		deepest = max(deepest, maxNesting(node.Children, depth+1))
+	}
+	return deepest
+}
+
+// halstead calcula las métricas de Halstead sobre los tokens de la función.
+// Los operandos son nombres y literales; los operadores, las palabras clave,
+// los operadores y los delimitadores (los paréntesis cuentan una vez por par).
+func halstead(tokens []models.Token) models.HalsteadMetrics {
+	operators := make(map[string]int)
+	operands := make(map[string]int)
+	for _, token := range tokens {
+		switch token.Type {
+		case "IDENTIFIER", "NUMBER", "STRING":
+			operands[token.Type+":"+token.Value]++
+		case "KEYWORD":
+			switch token.Value {
+			case "True", "False", "None", "print":
+				operands[token.Type+":"+token.Value]++
+			default:
+				operators[token.Value]++
+			}
+		case "OPERATOR":
+			operators[token.Value]++
+		case "SYMBOL":
+			switch token.Value {
+			case ")", "]", "}":
+			case "(":
+				operators["()"]++
+			case "[":
+				operators["[]"]++
+			case "{":
+				operators["{}"]++
+			default:
+				operators[token.Value]++
+			}
+		}
+	}
+
+	metrics := models.HalsteadMetrics{
+		DistinctOperators: len(operators),
+		DistinctOperands:  len(operands),
+	}
+	for _, count := range operators {
+		metrics.Operators += count
+	}
+	for _, count := range operands {
+		metrics.Operands += count
+	}
+	metrics.Vocabulary = metrics.DistinctOperators + metrics.DistinctOperands
+	metrics.Length = metrics.Operators + metrics.Operands
+	if metrics.Vocabulary == 0 || metrics.DistinctOperands == 0 {
+		return metrics
+	}
+
+	volume := float64(metrics.Length) * math.Log2(float64(metrics.Vocabulary))
+	difficulty := float64(metrics.DistinctOperators) / 2 * float64(metrics.Operands) / float64(metrics.DistinctOperands)
+	effort := difficulty * volume
+	metrics.Volume = round2(volume)
+	metrics.Difficulty = round2(difficulty)
+	metrics.Effort = round2(effort)
+	metrics.Time = round2(effort / 18)
+	metrics.Bugs = round2(volume / 3000)
+	return metrics
+}
+
+// lineMetrics clasifica las líneas propias de la función en código,
+// comentarios y líneas en blanco
+func lineMetrics(tokens []models.Token, fn *models.ASTNode, end int, owners map[int]*models.ASTNode) models.LineMetrics {
+	code := make(map[int]bool)
+	comments := make(map[int]bool)
+	for _, token := range tokens {
+		switch token.Type {
+		case "COMMENT":
+			comments[token.Line] = true
+		case "NEWLINE":
+		default:
+			code[token.Line] = true
+		}
+	}
+
+	metrics := models.LineMetrics{Code: len(code), Comment: len(comments)}
+	for line := fn.Line; line <= end; line++ {
+		if owners[line] != fn {
+			continue
+		}
+		metrics.Total++
+		if !code[line] && !comments[line] {
+			metrics.Blank++
+		}
+	}
+	if metrics.Code > 0 {
+		metrics.CommentRatio = round2(float64(metrics.Comment) / float64(metrics.Code))
+	}
+	return metrics
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// depthClass estima cómo crece la profundidad de la pila con la entrada:
+// "logarithmic" si los argumentos se dividen, "linear" si avanzan un paso
+// constante, "structural" si la recursión sigue la forma de los datos (llamadas
+// dentro de un for), "unknown" si no se reconoce el avance de alguna llamada y
+// "unbounded" si el ciclo no tiene caso base.
+func (a *recursionAnalysis) depthClass(rf *recursiveFunction) string {
+	if !a.cycleHasBaseCase(rf) {
+		return "unbounded"
+	}
+
+	worst := "none"
+	for _, call := range rf.calls {
+		if class := a.callDepthClass(call); depthRank[class] > depthRank[worst] {
+			worst = class
+		}
+	}
+	return worst
+}
+
+// callDepthClass clasifica una llamada por el argumento que mejor avanza
+// hacia un caso base
+func (a *recursionAnalysis) callDepthClass(call recursiveCall) string {
+	callee := a.byNode[call.site.callee]
+	if !call.toward || callee == nil {
+		return "unknown"
+	}
+
+	best := ""
+	choose := func(class string) {
+		if best == "" || depthRank[class] < depthRank[best] {
+			best = class
+		}
+	}
+	for _, base := range callee.baseCases {
+		if base.loop && call.inLoop {
+			choose("structural")
+		}
+	}
+	for position, step := range call.args {
+		if position >= len(callee.params) {
+			break
+		}
+		for _, base := range callee.baseCases {
+			for _, guard := range base.guards {
+				if guard.param != callee.params[position] || !progressReaches(step, guard) {
+					continue
+				}
+				if step.progress == "halving" {
+					choose("logarithmic")
+				} else {
+					choose("linear")
+				}
+			}
+		}
+	}
+
+	if best == "" {
+		return "linear"
+	}
+	return best
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"examen-back/models"
+)
+
+// functionMetrics devuelve las métricas de cada función del programa por nombre
+func functionMetrics(t *testing.T, code string) map[string]models.FunctionMetrics {
+	t.Helper()
+	metrics := make(map[string]models.FunctionMetrics)
+	for _, function := range analyze(t, code).Metrics {
+		metrics[function.Function] = function
+	}
+	return metrics
+}
+
+func TestFunctionMetrics(t *testing.T) {
+	metrics := functionMetrics(t, "def factorial(n):\n    # caso base\n    if n <= 1:\n        return 1\n\n    return n * factorial(n - 1)\n\n"+
+		"def f(a, b=1, *args, **kw):\n    for i in range(a):\n        if i % 2 == 0 and i > b:\n            while b < i:\n                b += 1\n    return b\n\n"+
+		"print(factorial(5), f(3))\n")
+
+	factorial := metrics["factorial"]
+	if factorial.Line != 1 || factorial.EndLine != 6 || factorial.Parameters != 1 || factorial.Statements != 3 ||
+		factorial.CyclomaticComplexity != 2 || factorial.MaxNesting != 1 || factorial.Recursion != "direct" || factorial.RecursiveCalls != 1 {
+		t.Errorf("métricas inesperadas de factorial: %+v", factorial)
+	}
+	if lines := factorial.Lines; lines != (models.LineMetrics{Total: 6, Code: 4, Comment: 1, Blank: 1, CommentRatio: 0.25}) {
+		t.Errorf("líneas inesperadas de factorial: %+v", lines)
+	}
+
+	// for, if, and y while suman cuatro decisiones
+	f := metrics["f"]
+	if f.Parameters != 4 || f.Statements != 5 || f.CyclomaticComplexity != 5 || f.MaxNesting != 3 || f.Recursion != "none" || f.RecursionDepth != "none" {
+		t.Errorf("métricas inesperadas de f: %+v", f)
+	}
+}
+
+func TestHalsteadMetrics(t *testing.T) {
+	halstead := functionMetrics(t, "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\nprint(factorial(5))\n")["factorial"].Halstead
+	if halstead.Vocabulary != halstead.DistinctOperators+halstead.DistinctOperands || halstead.Length != halstead.Operators+halstead.Operands {
+		t.Errorf("vocabulario y longitud deben sumar operadores y operandos: %+v", halstead)
+	}
+	if halstead.DistinctOperands != 3 || halstead.Volume <= 0 || halstead.Effort < halstead.Volume {
+		t.Errorf("métricas de Halstead inesperadas: %+v", halstead)
+	}
+
+}
+
+func TestRecursionDepthClass(t *testing.T) {
+	metrics := functionMetrics(t, "def fib(n):\n    if n < 2:\n        return n\n    return fib(n - 1) + fib(n - 2)\n\n"+
+		"def power(b, e):\n    if e == 0:\n        return 1\n    return power(b, e // 2)\n\n"+
+		"def total(items):\n    if not items:\n        return 0\n    return items[0] + total(items[1:])\n\n"+
+		"def forever(n):\n    return forever(n)\n\n"+
+		"def even(n):\n    return n == 0 or odd(n - 1)\n\ndef odd(n):\n    return n != 0 and even(n - 1)\n\n"+
+		"print(fib(5), power(2, 8), total([1]), forever(1), even(4))\n")
+	want := map[string]string{"fib": "linear", "power": "logarithmic", "forever": "unbounded", "even": "linear", "odd": "linear"}
+	for name, class := range want {
+		if metrics[name].RecursionDepth != class {
+			t.Errorf("%s: profundidad %q, se esperaba %q", name, metrics[name].RecursionDepth, class)
+		}
+	}
+	if metrics["fib"].RecursiveCalls != 2 || metrics["even"].Recursion != "mutual" {
+		t.Errorf("fib tiene dos llamadas recursivas y even es mutua: %+v %+v", metrics["fib"], metrics["even"])
+	}
+	if class := metrics["total"].RecursionDepth; class == "unbounded" || class == "none" {
+		t.Errorf("total recorre la lista y tiene caso base: %q", class)
+	}
+}
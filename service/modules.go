@@ -0,0 +1,245 @@
+package service
+
+import "sort"
+
+// ModuleMember describe un nombre exportado por un módulo de la biblioteca estándar.
+// Para funciones y clases Signature es la firma al estilo Python ("(n, /) -> int");
+// para constantes es el tipo del valor ("float").
+type ModuleMember struct {
+	Kind      string
+	Signature string
+}
+
+type ModuleStub struct {
+	Name    string
+	Members map[string]ModuleMember
+}
+
+func stubFunc(signature string) ModuleMember {
+	return ModuleMember{Kind: "function", Signature: signature}
+}
+
+func stubConst(typeName string) ModuleMember {
+	return ModuleMember{Kind: "constant", Signature: typeName}
+}
+
+func stubClass(signature string) ModuleMember {
+	return ModuleMember{Kind: "class", Signature: signature}
+}
+
+func stubModule() ModuleMember {
+	return ModuleMember{Kind: "module"}
+}
+
+// Catálogo de módulos estándar conocidos. No hay una instalación real de Python
+// disponible, así que los nombres exportados se describen aquí a mano.
+var stdlibModules = map[string]*ModuleStub{
+	"math": {
+		Name: "math",
+		Members: map[string]ModuleMember{
+			"pi":        stubConst("float"),
+			"e":         stubConst("float"),
+			"tau":       stubConst("float"),
+			"inf":       stubConst("float"),
+			"nan":       stubConst("float"),
+			"factorial": stubFunc("(n, /) -> int"),
+			"sqrt":      stubFunc("(x, /) -> float"),
+			"isqrt":     stubFunc("(n, /) -> int"),
+			"pow":       stubFunc("(x, y, /) -> float"),
+			"exp":       stubFunc("(x, /) -> float"),
+			"log":       stubFunc("(x, base=e, /) -> float"),
+			"log2":      stubFunc("(x, /) -> float"),
+			"log10":     stubFunc("(x, /) -> float"),
+			"floor":     stubFunc("(x, /) -> int"),
+			"ceil":      stubFunc("(x, /) -> int"),
+			"trunc":     stubFunc("(x, /) -> int"),
+			"fabs":      stubFunc("(x, /) -> float"),
+			"fmod":      stubFunc("(x, y, /) -> float"),
+			"gcd":       stubFunc("(*integers) -> int"),
+			"lcm":       stubFunc("(*integers) -> int"),
+			"comb":      stubFunc("(n, k, /) -> int"),
+			"perm":      stubFunc("(n, k=None, /) -> int"),
+			"prod":      stubFunc("(iterable, /, *, start=1) -> int"),
+			"fsum":      stubFunc("(seq, /) -> float"),
+			"isclose":   stubFunc("(a, b, *, rel_tol=1e-09, abs_tol=0.0) -> bool"),
+			"isfinite":  stubFunc("(x, /) -> bool"),
+			"isinf":     stubFunc("(x, /) -> bool"),
+			"isnan":     stubFunc("(x, /) -> bool"),
+			"sin":       stubFunc("(x, /) -> float"),
+			"cos":       stubFunc("(x, /) -> float"),
+			"tan":       stubFunc("(x, /) -> float"),
+			"asin":      stubFunc("(x, /) -> float"),
+			"acos":      stubFunc("(x, /) -> float"),
+			"atan":      stubFunc("(x, /) -> float"),
+			"atan2":     stubFunc("(y, x, /) -> float"),
+			"hypot":     stubFunc("(*coordinates) -> float"),
+			"degrees":   stubFunc("(x, /) -> float"),
+			"radians":   stubFunc("(x, /) -> float"),
+			"copysign":  stubFunc("(x, y, /) -> float"),
+		},
+	},
+	"functools": {
+		Name: "functools",
+		Members: map[string]ModuleMember{
+			"reduce":         stubFunc("(function, iterable, initial=None, /) -> object"),
+			"lru_cache":      stubFunc("(maxsize=128, typed=False) -> object"),
+			"cache":          stubFunc("(user_function, /) -> object"),
+			"partial":        stubClass("(func, /, *args, **keywords)"),
+			"wraps":          stubFunc("(wrapped, assigned=None, updated=None) -> object"),
+			"cmp_to_key":     stubFunc("(mycmp, /) -> object"),
+			"total_ordering": stubFunc("(cls, /) -> object"),
+		},
+	},
+	"sys": {
+		Name: "sys",
+		Members: map[string]ModuleMember{
+			"argv":              stubConst("list[str]"),
+			"path":              stubConst("list[str]"),
+			"maxsize":           stubConst("int"),
+			"version":           stubConst("str"),
+			"platform":          stubConst("str"),
+			"stdin":             stubConst("object"),
+			"stdout":            stubConst("object"),
+			"stderr":            stubConst("object"),
+			"setrecursionlimit": stubFunc("(limit, /) -> None"),
+			"getrecursionlimit": stubFunc("() -> int"),
+			"getsizeof":         stubFunc("(obj, default=0, /) -> int"),
+			"exit":              stubFunc("(status=None, /) -> None"),
+		},
+	},
+	"random": {
+		Name: "random",
+		Members: map[string]ModuleMember{
+			"random":    stubFunc("() -> float"),
+			"randint":   stubFunc("(a, b) -> int"),
+			"randrange": stubFunc("(start, stop=None, step=1) -> int"),
+			"uniform":   stubFunc("(a, b) -> float"),
+			"choice":    stubFunc("(seq) -> object"),
+			"shuffle":   stubFunc("(x) -> None"),
+			"sample":    stubFunc("(population, k, *, counts=None) -> list"),
+			"seed":      stubFunc("(a=None, version=2) -> None"),
+		},
+	},
+	"itertools": {
+		Name: "itertools",
+		Members: map[string]ModuleMember{
+			"count":        stubClass("(start=0, step=1)"),
+			"cycle":        stubClass("(iterable, /)"),
+			"repeat":       stubClass("(object, times=None)"),
+			"accumulate":   stubClass("(iterable, func=None, *, initial=None)"),
+			"chain":        stubClass("(*iterables)"),
+			"islice":       stubClass("(iterable, *args)"),
+			"product":      stubClass("(*iterables, repeat=1)"),
+			"permutations": stubClass("(iterable, r=None)"),
+			"combinations": stubClass("(iterable, r)"),
+		},
+	},
+	"operator": {
+		Name: "operator",
+		Members: map[string]ModuleMember{
+			"add":      stubFunc("(a, b, /) -> object"),
+			"sub":      stubFunc("(a, b, /) -> object"),
+			"mul":      stubFunc("(a, b, /) -> object"),
+			"truediv":  stubFunc("(a, b, /) -> float"),
+			"floordiv": stubFunc("(a, b, /) -> object"),
+			"mod":      stubFunc("(a, b, /) -> object"),
+			"pow":      stubFunc("(a, b, /) -> object"),
+			"neg":      stubFunc("(a, /) -> object"),
+		},
+	},
+	"collections": {
+		Name: "collections",
+		Members: map[string]ModuleMember{
+			"Counter":     stubClass("(iterable=None, /, **kwds)"),
+			"defaultdict": stubClass("(default_factory=None, /)"),
+			"deque":       stubClass("(iterable=None, maxlen=None)"),
+			"namedtuple":  stubFunc("(typename, field_names, *, rename=False, defaults=None, module=None) -> object"),
+			"OrderedDict": stubClass("(other=None, /, **kwds)"),
+		},
+	},
+	"time": {
+		Name: "time",
+		Members: map[string]ModuleMember{
+			"time":         stubFunc("() -> float"),
+			"perf_counter": stubFunc("() -> float"),
+			"sleep":        stubFunc("(secs, /) -> None"),
+		},
+	},
+	"string": {
+		Name: "string",
+		Members: map[string]ModuleMember{
+			"ascii_letters":   stubConst("str"),
+			"ascii_lowercase": stubConst("str"),
+			"ascii_uppercase": stubConst("str"),
+			"digits":          stubConst("str"),
+			"punctuation":     stubConst("str"),
+		},
+	},
+	"decimal": {
+		Name: "decimal",
+		Members: map[string]ModuleMember{
+			"Decimal":    stubClass("(value=0, context=None)"),
+			"getcontext": stubFunc("() -> object"),
+		},
+	},
+	"fractions": {
+		Name: "fractions",
+		Members: map[string]ModuleMember{
+			"Fraction": stubClass("(numerator=0, denominator=None)"),
+		},
+	},
+	"typing": {
+		Name: "typing",
+		Members: map[string]ModuleMember{
+			"Any":      stubClass("()"),
+			"List":     stubClass("()"),
+			"Dict":     stubClass("()"),
+			"Tuple":    stubClass("()"),
+			"Optional": stubClass("()"),
+			"Union":    stubClass("()"),
+			"Callable": stubClass("()"),
+		},
+	},
+	"os": {
+		Name: "os",
+		Members: map[string]ModuleMember{
+			"path":    stubModule(),
+			"environ": stubConst("object"),
+			"getcwd":  stubFunc("() -> str"),
+			"listdir": stubFunc("(path=None) -> list[str]"),
+		},
+	},
+	"os.path": {
+		Name: "os.path",
+		Members: map[string]ModuleMember{
+			"join":     stubFunc("(a, /, *p) -> str"),
+			"exists":   stubFunc("(path) -> bool"),
+			"basename": stubFunc("(p) -> str"),
+			"dirname":  stubFunc("(p) -> str"),
+		},
+	},
+}
+
+func lookupModule(name string) (*ModuleStub, bool) {
+	stub, ok := stdlibModules[name]
+	return stub, ok
+}
+
+func sortedMemberNames(stub *ModuleStub) []string {
+	names := make([]string, 0, len(stub.Members))
+	for name := range stub.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StdlibModuleNames devuelve los módulos del catálogo ordenados alfabéticamente
+func StdlibModuleNames() []string {
+	names := make([]string, 0, len(stdlibModules))
+	for name := range stdlibModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
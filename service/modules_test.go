@@ -0,0 +1,57 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportResolution(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+		want map[string][]int
+	}{
+		{"from import", "from math import factorial\nprint(factorial(5))\n", nil},
+		{"atributo del módulo", "import math\nprint(math.factorial(5))\n", nil},
+		{"alias", "import math as m\nprint(m.sqrt(2))\n", nil},
+		{"módulo anidado", "import os.path\nprint(os.path.join('a', 'b'))\n", nil},
+		{"asterisco", "from math import *\nprint(sqrt(2), pi)\n", nil},
+		{"atributo inexistente", "import math\nprint(math.factorail(5))\n", map[string][]int{"unknown-module-attribute": {2}}},
+		{"nombre inexistente", "from math import factorail\nprint(1)\n", map[string][]int{"import-name-not-found": {1}}},
+		{"módulo fuera del catálogo", "import numpy\nprint(numpy.zeros(3))\n", map[string][]int{"unknown-module": {1}, "unknown-module-attribute": nil}},
+		{"nombre original tras el alias", "import math as m\nprint(math.sqrt(2))\n", map[string][]int{"undefined-name": {2}}},
+	}
+	codes := []string{"unknown-module", "unknown-module-attribute", "import-name-not-found", "undefined-name", "used-before-assignment"}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			want := make(map[string][]int)
+			for _, code := range codes {
+				want[code] = tc.want[code]
+			}
+			expectDiagnostics(t, tc.code, want)
+		})
+	}
+}
+
+func TestImportSuggestsCatalogueName(t *testing.T) {
+	found := diagnosticsWithCode(analyze(t, "import math\nprint(math.factorail(5))\n"), "unknown-module-attribute")
+	if len(found) != 1 || found[0].Severity != "error" || !strings.Contains(found[0].Message, "'factorial'") {
+		t.Fatalf("se esperaba un error que sugiera 'factorial': %v", found)
+	}
+}
+
+func TestImportedNamesInSymbolTable(t *testing.T) {
+	result := analyze(t, "import math as m\nfrom functools import lru_cache as cache\nprint(m.pi, cache)\n")
+	want := map[string][2]string{"m": {"module", "math"}, "cache": {"function", "functools"}}
+	for _, symbol := range result.SymbolTable {
+		if expected, ok := want[symbol.Name]; ok {
+			if symbol.Type != expected[0] || symbol.Module != expected[1] {
+				t.Errorf("'%s' debe ser %s de %s: %+v", symbol.Name, expected[0], expected[1], symbol)
+			}
+			delete(want, symbol.Name)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("faltan en la tabla de símbolos: %v", want)
+	}
+}
@@ -0,0 +1,667 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// optimizationAdvisor propone reescrituras de las funciones con recursión
+// directa: lru_cache cuando las llamadas recursivas resuelven subproblemas que
+// se solapan, un acumulador cuando la llamada no es de cola y un bucle cuando
+// todas lo son (en el código original o después de agregar el acumulador)
+type optimizationAdvisor struct {
+	tokens      []models.Token
+	ast         *models.ASTNode
+	constants   *constantFolding
+	suggestions []models.OptimizationSuggestion
+}
+
+// accumulatorRewrite es la versión de una función con el parámetro acumulador
+type accumulatorRewrite struct {
+	fn    *models.ASTNode
+	name  string
+	calls map[*models.ASTNode]bool
+}
+
+// Nombres que se prueban para el parámetro acumulador
+var accumulatorNames = []string{"acc", "acumulado", "resultado", "acc_"}
+
+func adviseOptimizations(tokens []models.Token, ast *models.ASTNode, recursion *recursionAnalysis, constants *constantFolding) []models.OptimizationSuggestion {
+	a := &optimizationAdvisor{
+		tokens:      tokens,
+		ast:         ast,
+		constants:   constants,
+		suggestions: make([]models.OptimizationSuggestion, 0),
+	}
+	for _, rf := range recursion.functions {
+		if rf.kind != "direct" || len(rf.calls) == 0 {
+			continue
+		}
+		a.memoization(rf)
+		a.loop(rf, a.accumulator(rf))
+	}
+	return a.suggestions
+}
+
+// memoization sugiere lru_cache para una función pura con dos llamadas
+// recursivas que pueden ejecutarse en la misma invocación (como en fibonacci)
+// o con una llamada dentro de un bucle: los argumentos se repiten entre ramas
+// y el número de llamadas crece exponencialmente
+func (a *optimizationAdvisor) memoization(rf *recursiveFunction) {
+	if !a.constants.pure[rf.node] {
+		return
+	}
+
+	parents := parentNodes(rf.node)
+	var first, second *models.ASTNode
+	inLoop := false
+search:
+	for i, call := range rf.calls {
+		if call.inLoop {
+			first, inLoop = call.site.call, true
+			break
+		}
+		for _, other := range rf.calls[i+1:] {
+			if !exclusiveBranches(parents, call.site.call, other.site.call) {
+				first, second = call.site.call, other.site.call
+				break search
+			}
+		}
+	}
+	if first == nil {
+		return
+	}
+
+	message := fmt.Sprintf("'%s' hace dos llamadas recursivas en la misma ejecución ('%s' y '%s', línea %d) cuyos subproblemas se solapan: los mismos argumentos se recalculan una y otra vez y el número de llamadas puede crecer exponencialmente",
+		rf.name, formatSource(first), formatSource(second), first.Line)
+	if inLoop {
+		message = fmt.Sprintf("'%s' se llama a sí misma dentro de un bucle ('%s', línea %d): los mismos subproblemas se resuelven una y otra vez",
+			rf.name, formatSource(first), first.Line)
+	}
+	message += ". Como la función es pura, functools.lru_cache guarda el resultado de cada argumento y cada subproblema se calcula una sola vez"
+
+	edits := make([]models.CodeEdit, 0, 2)
+	cache := a.cacheDecorator()
+	if cache == "" {
+		cache = "lru_cache"
+		edits = append(edits, models.CodeEdit{Line: a.importLine(), After: "from functools import lru_cache"})
+	}
+	// El parser no admite decoradores, así que la función se envuelve después
+	// de definirla. Las llamadas recursivas buscan el nombre al ejecutarse y
+	// también pasan por la caché.
+	edits = append(edits, models.CodeEdit{
+		Line:  lastLine(rf.node) + 1,
+		After: fmt.Sprintf("%s%s = %s(maxsize=None)(%s)", a.indentation(rf.node), rf.node.Value, cache, rf.node.Value),
+	})
+
+	a.suggestions = append(a.suggestions, models.OptimizationSuggestion{
+		Kind:     "memoization",
+		Function: rf.name,
+		Line:     rf.node.Line,
+		Message:  message,
+		Edits:    edits,
+	})
+}
+
+// cacheDecorator devuelve el nombre con el que el programa ya importa
+// functools.lru_cache, o "" si hay que agregar la importación
+func (a *optimizationAdvisor) cacheDecorator() string {
+	for _, stmt := range a.ast.Children {
+		for _, alias := range stmt.Children {
+			if alias.Type != "Alias" {
+				continue
+			}
+			name := alias.Value
+			if len(alias.Children) > 0 {
+				name = alias.Children[0].Value
+			}
+			switch {
+			case stmt.Type == "ImportFrom" && stmt.Value == "functools" && alias.Value == "lru_cache":
+				return name
+			case stmt.Type == "Import" && alias.Value == "functools":
+				return name + ".lru_cache"
+			}
+		}
+	}
+	return ""
+}
+
+// importLine es la línea de la primera sentencia después del docstring del módulo
+func (a *optimizationAdvisor) importLine() int {
+	for _, stmt := range a.ast.Children {
+		if stmt.Type != "String" {
+			return stmt.Line
+		}
+	}
+	return 1
+}
+
+// indentation devuelve la sangría de la línea donde empieza el nodo
+func (a *optimizationAdvisor) indentation(node *models.ASTNode) string {
+	for _, token := range a.tokens {
+		if token.Line == node.Line && token.Type != "NEWLINE" {
+			return strings.Repeat(" ", token.Col-1)
+		}
+	}
+	return ""
+}
+
+// accumulator reescribe una función cuyos retornos recursivos son de la
+// forma "E op f(...)" o "f(...) op E" con op + o *. El acumulador guarda la
+// parte ya calculada: f(x, acc) vale acc op f(x), así que la llamada pasa a
+// ser "f(x', acc op E)" y los casos base devuelven "acc op base".
+func (a *optimizationAdvisor) accumulator(rf *recursiveFunction) *accumulatorRewrite {
+	params, body := functionParts(rf.node)
+	if !plainParameters(params) || !terminates(body) {
+		return nil
+	}
+	calls := make(map[*models.ASTNode]bool)
+	for _, call := range rf.calls {
+		calls[call.site.call] = true
+	}
+
+	op, side := "", ""
+	var bases, recursive []*models.ASTNode
+	var first *models.ASTNode
+	for _, ret := range functionReturns(body) {
+		if len(ret.Children) == 0 {
+			return nil
+		}
+		value := ret.Children[0]
+		if !reachesAny(value, calls) {
+			bases = append(bases, ret)
+			continue
+		}
+		if value.Type != "BinaryOp" || (value.Value != "+" && value.Value != "*") {
+			return nil
+		}
+
+		left, right := value.Children[0], value.Children[1]
+		current := ""
+		switch {
+		case calls[right] && !reachesAny(left, calls):
+			current = "left"
+		case calls[left] && !reachesAny(right, calls):
+			current = "right"
+		default:
+			return nil
+		}
+		// La multiplicación conmuta, así que el acumulador siempre puede ir a la izquierda
+		if value.Value == "*" {
+			current = "left"
+		}
+		if op == "" {
+			op, side, first = value.Value, current, ret
+		} else if op != value.Value || side != current {
+			return nil
+		}
+		recursive = append(recursive, ret)
+	}
+	if len(recursive) == 0 || len(recursive) != len(calls) {
+		return nil
+	}
+
+	identity := accumulatorIdentity(op, bases)
+	name := accumulatorName(rf.node)
+	if identity == nil || name == "" {
+		return nil
+	}
+
+	acc := &models.ASTNode{Type: "Identifier", Value: name, Line: rf.node.Line}
+	combine := func(value *models.ASTNode) *models.ASTNode {
+		if side == "right" {
+			return &models.ASTNode{Type: "BinaryOp", Value: op, Children: []*models.ASTNode{value, acc}, Line: value.Line}
+		}
+		return &models.ASTNode{Type: "BinaryOp", Value: op, Children: []*models.ASTNode{acc, value}, Line: value.Line}
+	}
+
+	rewrite := &accumulatorRewrite{name: name, calls: make(map[*models.ASTNode]bool)}
+	replace := make(map[*models.ASTNode][]*models.ASTNode)
+	for _, ret := range bases {
+		value := ret.Children[0]
+		if value.Type != identity.Type || value.Value != identity.Value {
+			value = combine(value)
+		} else {
+			value = acc
+		}
+		replace[ret] = []*models.ASTNode{{Type: "ReturnStatement", Children: []*models.ASTNode{value}, Line: ret.Line, Col: ret.Col}}
+	}
+	for _, ret := range recursive {
+		call, rest := ret.Children[0].Children[1], ret.Children[0].Children[0]
+		if !calls[call] {
+			call, rest = rest, call
+		}
+		argument := combine(rest)
+		for _, arg := range call.Children {
+			if arg.Type == "Keyword" {
+				argument = &models.ASTNode{Type: "Keyword", Value: name, Children: []*models.ASTNode{argument}, Line: call.Line}
+				break
+			}
+		}
+		next := &models.ASTNode{Type: "FunctionCall", Value: call.Value, Children: append(append([]*models.ASTNode{}, call.Children...), argument), Line: call.Line, Col: call.Col}
+		rewrite.calls[next] = true
+		replace[ret] = []*models.ASTNode{{Type: "ReturnStatement", Children: []*models.ASTNode{next}, Line: ret.Line, Col: ret.Col}}
+	}
+
+	rewrite.fn = rewriteTree(rf.node, replace)
+	parameter := &models.ASTNode{Type: "Parameter", Value: name, Children: []*models.ASTNode{identity}, Line: rf.node.Line}
+	rewrite.fn.Children = append(append(append([]*models.ASTNode{}, rewrite.fn.Children[:len(params)]...), parameter), rewrite.fn.Children[len(params):]...)
+
+	call := first.Children[0].Children[1]
+	if !calls[call] {
+		call = first.Children[0].Children[0]
+	}
+	a.suggestions = append(a.suggestions, models.OptimizationSuggestion{
+		Kind:     "accumulator",
+		Function: rf.name,
+		Line:     rf.node.Line,
+		Message: fmt.Sprintf("La llamada recursiva '%s' en línea %d no es de cola: al volver de ella todavía hay que calcular '%s'. Con un acumulador '%s' el resultado parcial se pasa como argumento y la llamada queda en posición de cola; Python no elimina las llamadas de cola, pero en esta forma la recursión se convierte directamente en un bucle",
+			formatSource(call), call.Line, formatSource(first.Children[0]), name),
+		Edits: []models.CodeEdit{a.functionEdit(rf.node, rewrite.fn)},
+	})
+	return rewrite
+}
+
+// accumulatorIdentity es el valor inicial del acumulador: el neutro de la
+// operación para el tipo que devuelven los casos base (1 para *, 0 o "" para +)
+func accumulatorIdentity(op string, bases []*models.ASTNode) *models.ASTNode {
+	if op == "*" {
+		return &models.ASTNode{Type: "Number", Value: "1"}
+	}
+	for _, ret := range bases {
+		switch ret.Children[0].Type {
+		case "Number":
+			return &models.ASTNode{Type: "Number", Value: "0"}
+		case "String":
+			return &models.ASTNode{Type: "String", Value: ""}
+		}
+	}
+	return nil
+}
+
+// accumulatorName elige un nombre que la función no use todavía
+func accumulatorName(fn *models.ASTNode) string {
+	params, _ := functionParts(fn)
+	for _, name := range accumulatorNames {
+		taken := mentionsName(fn, name)
+		for _, param := range params {
+			taken = taken || parameterName(param) == name
+		}
+		if !taken {
+			return name
+		}
+	}
+	return ""
+}
+
+// loop reescribe como bucle una función cuyas llamadas recursivas son todas
+// de cola: cada "return f(args)" reasigna los parámetros y vuelve al inicio
+// del bucle. Con acc se parte de la versión con acumulador, que pasa a ser
+// una variable local.
+func (a *optimizationAdvisor) loop(rf *recursiveFunction, acc *accumulatorRewrite) {
+	fn := rf.node
+	calls := make(map[*models.ASTNode]bool)
+	for _, call := range rf.calls {
+		calls[call.site.call] = true
+	}
+	if acc != nil {
+		fn, calls = acc.fn, acc.calls
+	}
+	params, body := functionParts(fn)
+	if !plainParameters(params) || definesClosure(body) {
+		return
+	}
+
+	tails := make([]*models.ASTNode, 0, len(calls))
+	if !tailCalls(body, calls, true, &tails) || len(tails) != len(calls) {
+		return
+	}
+
+	// Cada llamada de cola se reemplaza por la asignación de los parámetros que cambian
+	last := body[len(body)-1]
+	assignments := make(map[*models.ASTNode][]*models.ASTNode)
+	for _, stmt := range tails {
+		call := stmt
+		if stmt.Type == "ReturnStatement" {
+			call = stmt.Children[0]
+		}
+		assignment := parameterUpdate(params, call)
+		if assignment == nil {
+			return
+		}
+		assignments[stmt] = []*models.ASTNode{assignment}
+	}
+	withContinue := make(map[*models.ASTNode][]*models.ASTNode)
+	for stmt, replacement := range assignments {
+		withContinue[stmt] = replacement
+		if stmt != last {
+			withContinue[stmt] = append(replacement, &models.ASTNode{Type: "ContinueStatement", Line: stmt.Line})
+		}
+	}
+
+	loopBody := make([]*models.ASTNode, 0)
+	if guard, ok := baseGuard(body, calls); ok && assignments[last] != nil {
+		// "if caso_base: ... " seguido de la llamada de cola: while not caso_base
+		for _, stmt := range body[1 : len(body)-1] {
+			loopBody = append(loopBody, rewriteTree(stmt, nil))
+		}
+		loopBody = append(loopBody, assignments[last]...)
+		after := guard.Children[1].Children
+		if n := len(after); n > 0 && after[n-1].Type == "ReturnStatement" && len(after[n-1].Children) == 0 {
+			after = after[:n-1]
+		}
+		body = append([]*models.ASTNode{
+			{Type: "WhileStatement", Line: guard.Line, Children: []*models.ASTNode{
+				negatedCondition(guard.Children[0]),
+				{Type: "Block", Children: loopBody},
+			}},
+		}, after...)
+	} else {
+		for _, stmt := range body {
+			if replacement, ok := withContinue[stmt]; ok {
+				loopBody = append(loopBody, replacement...)
+				continue
+			}
+			loopBody = append(loopBody, rewriteTree(stmt, withContinue))
+		}
+		if !terminates(body) && assignments[last] == nil {
+			// Llegar al final del cuerpo termina la función devolviendo None
+			loopBody = append(loopBody, &models.ASTNode{Type: "ReturnStatement", Line: last.Line})
+		}
+		body = []*models.ASTNode{
+			{Type: "WhileStatement", Line: fn.Line, Children: []*models.ASTNode{
+				{Type: "Boolean", Value: "True"},
+				{Type: "Block", Children: loopBody},
+			}},
+		}
+	}
+
+	header := fn.Children[:len(fn.Children)-len(functionBody(fn))]
+	rewritten := *fn
+	rewritten.Children = make([]*models.ASTNode, 0, len(header)+len(body)+1)
+	for _, child := range header {
+		if acc != nil && child.Type == "Parameter" && child.Value == acc.name {
+			continue
+		}
+		rewritten.Children = append(rewritten.Children, child)
+	}
+	if acc != nil {
+		initial := parameterDefault(params[len(params)-1])
+		rewritten.Children = append(rewritten.Children, &models.ASTNode{Type: "Assignment", Value: acc.name, Children: []*models.ASTNode{initial}, Line: fn.Line})
+	}
+	rewritten.Children = append(rewritten.Children, body...)
+
+	message := fmt.Sprintf("Todas las llamadas recursivas de '%s' están en posición de cola ('%s', línea %d): se puede reescribir como un bucle while que reasigna los parámetros, sin apilar un frame por llamada ni arriesgar un RecursionError con entradas grandes",
+		rf.name, formatSource(rf.calls[0].site.call), rf.calls[0].site.call.Line)
+	if acc != nil {
+		message = fmt.Sprintf("Con el acumulador '%s', '%s' queda en recursión de cola y se puede reescribir como un bucle while que actualiza los parámetros y el acumulador, sin apilar un frame por llamada ni arriesgar un RecursionError con entradas grandes",
+			acc.name, rf.name)
+	}
+	a.suggestions = append(a.suggestions, models.OptimizationSuggestion{
+		Kind:     "loop",
+		Function: rf.name,
+		Line:     rf.node.Line,
+		Message:  message,
+		Edits:    []models.CodeEdit{a.functionEdit(rf.node, &rewritten)},
+	})
+}
+
+// tailCalls reúne las sentencias "return f(...)" y, en posición final, las
+// llamadas sueltas "f(...)"; falla si alguna llamada recursiva no es de cola
+func tailCalls(stmts []*models.ASTNode, calls map[*models.ASTNode]bool, tail bool, found *[]*models.ASTNode) bool {
+	for i, stmt := range stmts {
+		last := tail && i == len(stmts)-1
+		switch {
+		case stmt.Type == "ReturnStatement" && len(stmt.Children) > 0 && calls[stmt.Children[0]]:
+			if !argumentsFree(stmt.Children[0], calls) {
+				return false
+			}
+			*found = append(*found, stmt)
+		case calls[stmt] && last:
+			if !argumentsFree(stmt, calls) {
+				return false
+			}
+			*found = append(*found, stmt)
+		case stmt.Type == "IfStatement":
+			if reachesAny(stmt.Children[0], calls) {
+				return false
+			}
+			for _, branch := range stmt.Children[1:] {
+				if !tailCalls(branch.Children, calls, last, found) {
+					return false
+				}
+			}
+		case reachesAny(stmt, calls):
+			return false
+		}
+	}
+	return true
+}
+
+func argumentsFree(call *models.ASTNode, calls map[*models.ASTNode]bool) bool {
+	for _, arg := range call.Children {
+		if reachesAny(arg, calls) {
+			return false
+		}
+	}
+	return true
+}
+
+// baseGuard reconoce un cuerpo "if caso_base: <termina>" seguido de código
+// sin recursión que acaba en la llamada de cola, y devuelve ese if
+func baseGuard(body []*models.ASTNode, calls map[*models.ASTNode]bool) (*models.ASTNode, bool) {
+	if len(body) < 2 || body[0].Type != "IfStatement" || len(body[0].Children) != 2 {
+		return nil, false
+	}
+	guard := body[0]
+	if reachesAny(guard, calls) || !terminates(guard.Children[1].Children) {
+		return nil, false
+	}
+	for _, stmt := range body[1 : len(body)-1] {
+		if reachesAny(stmt, calls) {
+			return nil, false
+		}
+	}
+	return guard, true
+}
+
+// parameterUpdate convierte los argumentos de la llamada de cola en la
+// asignación de los parámetros que cambian: "n -= 1" o "a, b = b, a % b".
+// Devuelve nil si un argumento no corresponde a un parámetro o ninguno cambia.
+func parameterUpdate(params []*models.ASTNode, call *models.ASTNode) *models.ASTNode {
+	values := make([]*models.ASTNode, len(params))
+	position := 0
+	for _, arg := range call.Children {
+		if arg.Type != "Keyword" {
+			if position >= len(params) {
+				return nil
+			}
+			values[position] = arg
+			position++
+			continue
+		}
+		index := -1
+		for i, param := range params {
+			if param.Value == arg.Value {
+				index = i
+			}
+		}
+		if index < 0 || values[index] != nil {
+			return nil
+		}
+		values[index] = arg.Children[0]
+	}
+
+	targets := make([]*models.ASTNode, 0, len(params))
+	changed := make([]*models.ASTNode, 0, len(params))
+	for i, param := range params {
+		value := values[i]
+		if value == nil {
+			if value = parameterDefault(param); value == nil {
+				return nil
+			}
+		}
+		if value.Type == "Identifier" && value.Value == param.Value {
+			continue
+		}
+		targets = append(targets, &models.ASTNode{Type: "Identifier", Value: param.Value, Line: call.Line})
+		changed = append(changed, value)
+	}
+
+	switch len(changed) {
+	case 0:
+		return nil
+	case 1:
+		value := changed[0]
+		if value.Type == "BinaryOp" && value.Children[0].Type == "Identifier" && value.Children[0].Value == targets[0].Value {
+			switch value.Value {
+			case "+", "-", "*", "//":
+				return &models.ASTNode{Type: "AugAssignment", Value: value.Value + "=", Children: []*models.ASTNode{targets[0], value.Children[1]}, Line: call.Line}
+			}
+		}
+		return &models.ASTNode{Type: "Assignment", Value: targets[0].Value, Children: []*models.ASTNode{value}, Line: call.Line}
+	}
+	return &models.ASTNode{Type: "Assignment", Children: []*models.ASTNode{
+		{Type: "Tuple", Children: targets},
+		{Type: "Tuple", Children: changed},
+	}, Line: call.Line}
+}
+
+// functionEdit reemplaza la definición completa de fn por rewritten
+func (a *optimizationAdvisor) functionEdit(fn, rewritten *models.ASTNode) models.CodeEdit {
+	indent := a.indentation(fn)
+	return models.CodeEdit{
+		Line:    fn.Line,
+		EndLine: lastLine(fn),
+		Before:  strings.Join(formatBlock([]*models.ASTNode{fn}, indent), "\n"),
+		After:   strings.Join(formatBlock([]*models.ASTNode{rewritten}, indent), "\n"),
+	}
+}
+
+// functionReturns devuelve los return del cuerpo sin entrar en funciones anidadas
+func functionReturns(body []*models.ASTNode) []*models.ASTNode {
+	returns := make([]*models.ASTNode, 0)
+	var visit func(node *models.ASTNode)
+	visit = func(node *models.ASTNode) {
+		switch node.Type {
+		case "FunctionDef", "ClassDef", "Lambda":
+			return
+		case "ReturnStatement":
+			returns = append(returns, node)
+		}
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	for _, stmt := range body {
+		visit(stmt)
+	}
+	return returns
+}
+
+func functionBody(fn *models.ASTNode) []*models.ASTNode {
+	_, body := functionParts(fn)
+	return body
+}
+
+// plainParameters indica que la función no usa *args, **kwargs ni el marcador '*'
+func plainParameters(params []*models.ASTNode) bool {
+	for _, param := range params {
+		if strings.HasPrefix(param.Value, "*") {
+			return false
+		}
+	}
+	return true
+}
+
+// definesClosure indica si el cuerpo define funciones o lambdas, que podrían
+// capturar los parámetros que el bucle reasigna
+func definesClosure(body []*models.ASTNode) bool {
+	for _, stmt := range body {
+		found := false
+		var visit func(node *models.ASTNode)
+		visit = func(node *models.ASTNode) {
+			if node.Type == "FunctionDef" || node.Type == "ClassDef" || node.Type == "Lambda" {
+				found = true
+				return
+			}
+			for _, child := range node.Children {
+				visit(child)
+			}
+		}
+		if visit(stmt); found {
+			return true
+		}
+	}
+	return false
+}
+
+func reachesAny(node *models.ASTNode, calls map[*models.ASTNode]bool) bool {
+	if node == nil {
+		return false
+	}
+	if calls[node] {
+		return true
+	}
+	for _, child := range node.Children {
+		if reachesAny(child, calls) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteTree copia el árbol sustituyendo cada nodo de replace por sus
+// reemplazos, que pueden ser varias sentencias
+func rewriteTree(node *models.ASTNode, replace map[*models.ASTNode][]*models.ASTNode) *models.ASTNode {
+	copied := *node
+	copied.Children = make([]*models.ASTNode, 0, len(node.Children))
+	for _, child := range node.Children {
+		if replacement, ok := replace[child]; ok {
+			copied.Children = append(copied.Children, replacement...)
+			continue
+		}
+		copied.Children = append(copied.Children, rewriteTree(child, replace))
+	}
+	return &copied
+}
+
+// parentNodes relaciona cada nodo de la función con su padre
+func parentNodes(fn *models.ASTNode) map[*models.ASTNode]*models.ASTNode {
+	parents := make(map[*models.ASTNode]*models.ASTNode)
+	var visit func(node *models.ASTNode)
+	visit = func(node *models.ASTNode) {
+		for _, child := range node.Children {
+			parents[child] = node
+			visit(child)
+		}
+	}
+	visit(fn)
+	return parents
+}
+
+// exclusiveBranches indica si dos nodos están en ramas distintas de un mismo
+// if o expresión condicional, de modo que nunca se ejecutan los dos
+func exclusiveBranches(parents map[*models.ASTNode]*models.ASTNode, a, b *models.ASTNode) bool {
+	branch := make(map[*models.ASTNode]*models.ASTNode)
+	for child, node := a, parents[a]; node != nil; child, node = node, parents[node] {
+		branch[node] = child
+	}
+	for child, node := b, parents[b]; node != nil; child, node = node, parents[node] {
+		if node == a {
+			return false
+		}
+		other, common := branch[node]
+		if !common {
+			continue
+		}
+		// El hijo 0 es la condición; los demás son las ramas
+		if node.Type == "IfStatement" || node.Type == "IfExpression" {
+			return other != node.Children[0] && child != node.Children[0]
+		}
+		return false
+	}
+	return false
+}
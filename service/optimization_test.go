@@ -0,0 +1,50 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"examen-back/models"
+)
+
+// applyInsertions aplica las ediciones sin Before: cada After se inserta
+// antes de su línea, o al final si la línea está después de la última
+func applyInsertions(code string, edits []models.CodeEdit) string {
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	sorted := append([]models.CodeEdit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Line > sorted[j].Line })
+	for _, edit := range sorted {
+		at := min(edit.Line-1, len(lines))
+		lines = append(lines[:at], append([]string{edit.After}, lines[at:]...)...)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestMemoizationSuggestionParsesAndRuns(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+	}{
+		{"sin importación", "def fib(n):\n    if n < 2:\n        return n\n    return fib(n - 1) + fib(n - 2)\n\nprint(fib(25))\n"},
+		{"al final del programa", "import functools\nprint(1)\ndef fib(n):\n    if n < 2:\n        return n\n    return fib(n - 1) + fib(n - 2)\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var suggestion *models.OptimizationSuggestion
+			for _, found := range analyze(t, tc.code).Optimizations {
+				if found.Kind == "memoization" {
+					suggestion = &found
+				}
+			}
+			if suggestion == nil {
+				t.Fatalf("no se sugirió lru_cache")
+			}
+			rewritten := applyInsertions(tc.code+"print(fib(60))\n", suggestion.Edits)
+			result := run(t, rewritten, EngineTree, models.RunLimits{MaxSteps: 10000})
+			if result.Error != nil || !strings.HasSuffix(result.Stdout, "1548008755920\n") {
+				t.Errorf("el programa sugerido falló:\n%s\n%+v %q", rewritten, result.Error, result.Stdout)
+			}
+		})
+	}
+}
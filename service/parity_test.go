@@ -0,0 +1,56 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"examen-back/models"
+)
+
+// TestEnginesAgree ejecuta cada programa con el intérprete de árbol y con la VM
+// y exige la misma salida, el mismo error y la misma contabilidad de recursos
+func TestEnginesAgree(t *testing.T) {
+	cases := []struct {
+		name   string
+		code   string
+		limits models.RunLimits
+	}{
+		{"factorial", "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\nprint(factorial(25))\n", models.RunLimits{}},
+		{"fibonacci", "def fib(n):\n    if n < 2:\n        return n\n    return fib(n - 1) + fib(n - 2)\n\nprint(fib(15))\n", models.RunLimits{}},
+		{"bucles", "total = 0\nfor i in range(10):\n    if i % 2 == 0:\n        continue\n    j = 0\n    while j < i:\n        j += 1\n        if j > 5:\n            break\n    else:\n        total += j\nprint(total)\n", models.RunLimits{}},
+		{"excepciones", "def f(x):\n    try:\n        return 10 // x\n    except ZeroDivisionError as e:\n        print('error', e)\n        return -1\n    finally:\n        print('fin', x)\n\nprint(f(2), f(0))\n", models.RunLimits{}},
+		{"contenedores", "d = {}\nfor w in 'a b a c b a'.split():\n    d[w] = d.get(w, 0) + 1\nprint(sorted(d.items()), [x * x for x in range(5) if x % 2], len(d))\n", models.RunLimits{}},
+		{"cadenas", "s = 'Hola mundo'\nprint(s.upper(), s[::-1], s[1:4], '-'.join(s.split()), s.find('m'))\n", models.RunLimits{}},
+		{"clausuras y lambdas", "def make(k):\n    return lambda x: x + k\n\nadd = make(3)\nprint(list(map(add, [1, 2, 3])))\n", models.RunLimits{}},
+		{"argumentos", "def f(a, b=2, *rest, **named):\n    return a + b + sum(rest) + len(named)\n\nprint(f(1), f(1, 3, 4, 5, x=1))\n", models.RunLimits{}},
+		{"global y nonlocal", "count = 0\n\ndef outer():\n    n = 0\n    def inner():\n        nonlocal n\n        global count\n        n += 1\n        count += 2\n    inner()\n    inner()\n    return n\n\nprint(outer(), count)\n", models.RunLimits{}},
+		{"raise", "def check(x):\n    if x < 0:\n        raise ValueError('negativo')\n    return x\n\ntry:\n    check(-1)\nexcept ValueError as e:\n    print('capturado', e)\nprint(check(-2))\n", models.RunLimits{}},
+		{"error sin capturar", "x = [1, 2]\nprint(x[0])\nprint(x[5])\n", models.RunLimits{}},
+		{"recursión infinita", "def f(n):\n    return f(n + 1)\n\nf(0)\n", models.RunLimits{MaxDepth: 50}},
+		{"límite de pasos", "i = 0\nwhile True:\n    i += 1\n", models.RunLimits{MaxSteps: 1000}},
+		{"límite de salida", "while True:\n    print('x' * 100)\n", models.RunLimits{MaxOutputBytes: 1000}},
+		{"límite de memoria", "x = []\nwhile True:\n    x.append([0] * 1000)\n", models.RunLimits{MaxMemoryBytes: 1 << 20}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree := run(t, tc.code, EngineTree, tc.limits)
+			vm := run(t, tc.code, EngineVM, tc.limits)
+			if tree.Stdout != vm.Stdout {
+				t.Errorf("salida distinta:\nárbol %q\nVM    %q", tree.Stdout, vm.Stdout)
+			}
+			if !reflect.DeepEqual(tree.Error, vm.Error) {
+				t.Errorf("error distinto:\nárbol %+v\nVM    %+v", tree.Error, vm.Error)
+			}
+			if tree.Steps != vm.Steps || tree.MaxDepth != vm.MaxDepth || tree.MemoryBytes != vm.MemoryBytes {
+				t.Errorf("recursos distintos: árbol %d pasos, profundidad %d, %d bytes; VM %d pasos, profundidad %d, %d bytes",
+					tree.Steps, tree.MaxDepth, tree.MemoryBytes, vm.Steps, vm.MaxDepth, vm.MemoryBytes)
+			}
+			if tree.Limits != vm.Limits {
+				t.Errorf("límites distintos: árbol %+v, VM %+v", tree.Limits, vm.Limits)
+			}
+			if tree.Engine != EngineTree || vm.Engine != EngineVM {
+				t.Errorf("motores informados: %q y %q", tree.Engine, vm.Engine)
+			}
+		})
+	}
+}
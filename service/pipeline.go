@@ -0,0 +1,392 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"examen-back/models"
+)
+
+// Etapas de /api/v1/compile en el orden del compilador
+var compileStages = []string{"tokens", "cst", "ast", "symbols", "cfg", "bytecode"}
+
+// nodeLocator da la posición en el código de un nodo del AST, o nil si el
+// nodo no tiene tokens propios
+type nodeLocator func(*models.ASTNode) *models.SourceRange
+
+func (locate nodeLocator) find(node *models.ASTNode) *models.SourceRange {
+	if locate == nil || node == nil {
+		return nil
+	}
+	return locate(node)
+}
+
+// tokenSpan son los índices del primer y el último token de un nodo; first
+// es -1 si el nodo no tiene tokens
+type tokenSpan struct {
+	first, last int
+}
+
+var emptySpan = tokenSpan{first: -1, last: -1}
+
+func (s tokenSpan) empty() bool {
+	return s.first < 0
+}
+
+func (s tokenSpan) with(index int) tokenSpan {
+	if index < 0 {
+		return s
+	}
+	if s.empty() {
+		return tokenSpan{index, index}
+	}
+	return tokenSpan{min(s.first, index), max(s.last, index)}
+}
+
+func (s tokenSpan) union(other tokenSpan) tokenSpan {
+	if other.empty() {
+		return s
+	}
+	return s.with(other.first).with(other.last)
+}
+
+// sourceMap relaciona el AST con los tokens del análisis léxico. Los nodos
+// solo guardan la posición de un token, así que el tramo de cada uno se
+// reconstruye: sus tokens y los de sus hijos, los paréntesis que abren o
+// cierran dentro del tramo y los tokens que el AST no conserva (def, class,
+// los paréntesis de una llamada, ".atributo").
+type sourceMap struct {
+	tokens  []models.Token
+	ranges  []models.SourceRange
+	at      map[[2]int]int
+	partner map[int]int
+	spans   map[*models.ASTNode]tokenSpan
+}
+
+func newSourceMap(tokens []models.Token, ranges []models.SourceRange, ast *models.ASTNode) *sourceMap {
+	m := &sourceMap{
+		tokens:  tokens,
+		ranges:  ranges,
+		at:      make(map[[2]int]int),
+		partner: make(map[int]int),
+		spans:   make(map[*models.ASTNode]tokenSpan),
+	}
+	var open []int
+	closing := map[string]string{")": "(", "]": "[", "}": "{"}
+	for i, token := range tokens {
+		if !m.significant(i) {
+			continue
+		}
+		m.at[[2]int{token.Line, token.Col}] = i
+		if token.Type != "SYMBOL" {
+			continue
+		}
+		switch token.Value {
+		case "(", "[", "{":
+			open = append(open, i)
+		case ")", "]", "}":
+			if len(open) > 0 && tokens[open[len(open)-1]].Value == closing[token.Value] {
+				m.partner[i], m.partner[open[len(open)-1]] = open[len(open)-1], i
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	if ast != nil {
+		m.span(ast, -1)
+	}
+	return m
+}
+
+// significant indica si el token llega al analizador sintáctico
+func (m *sourceMap) significant(index int) bool {
+	return m.tokens[index].Type != "NEWLINE" && m.tokens[index].Type != "COMMENT"
+}
+
+// next devuelve el siguiente token significativo, o -1
+func (m *sourceMap) next(index int) int {
+	for index++; index < len(m.tokens); index++ {
+		if m.significant(index) {
+			return index
+		}
+	}
+	return -1
+}
+
+func (m *sourceMap) previous(index int) int {
+	for index--; index >= 0; index-- {
+		if m.significant(index) {
+			return index
+		}
+	}
+	return -1
+}
+
+func (m *sourceMap) is(index int, value string) bool {
+	return index >= 0 && m.tokens[index].Value == value && m.tokens[index].Type != "STRING"
+}
+
+// own devuelve el token del nodo, o -1 si el analizador creó el nodo sin un
+// token propio: los None de las partes omitidas de un rebanado y el bloque
+// de una sentencia compuesta, que comparte el token de la cabecera
+func (m *sourceMap) own(node *models.ASTNode, parentToken int) int {
+	index, ok := m.at[[2]int{node.Line, node.Col}]
+	switch {
+	case !ok:
+		return -1
+	case node.Type == "None" && m.tokens[index].Value != "None":
+		return -1
+	case node.Type == "Block" && index == parentToken:
+		return -1
+	}
+	return index
+}
+
+func (m *sourceMap) span(node *models.ASTNode, parentToken int) tokenSpan {
+	own := m.own(node, parentToken)
+	result := emptySpan.with(own)
+	children := make([]tokenSpan, len(node.Children))
+	for i, child := range node.Children {
+		children[i] = m.span(child, own)
+		result = result.union(children[i])
+	}
+
+	// Paréntesis de cierre de un "(" o "[" que sigue al token end
+	closer := func(end int, opening string) {
+		if end < 0 {
+			return
+		}
+		if next := m.next(end); m.is(next, opening) {
+			if partner, ok := m.partner[next]; ok {
+				result = result.with(partner)
+			}
+		}
+	}
+	switch node.Type {
+	case "FunctionDef", "ClassDef":
+		if previous := m.previous(own); own >= 0 && m.tokens[previous].Type == "KEYWORD" {
+			result = result.with(previous)
+		}
+	case "Attribute":
+		if len(children) > 0 && !children[0].empty() {
+			if dot := m.next(children[0].last); m.is(dot, ".") {
+				result = result.with(m.next(dot))
+			}
+		}
+	case "FunctionCall":
+		// El nombre con puntos ("xs.append") no tiene nodos propios
+		end := own
+		for i := 0; i < strings.Count(node.Value, ".") && end >= 0; i++ {
+			end = m.next(m.next(end))
+		}
+		closer(end, "(")
+	case "MethodCall":
+		if len(children) > 0 {
+			closer(children[0].last, "(")
+		}
+	case "Subscript":
+		if len(children) > 0 {
+			closer(children[0].last, "[")
+		}
+	}
+	if own >= 0 {
+		if partner, ok := m.partner[own]; ok && partner > own {
+			result = result.with(partner)
+		}
+	}
+
+	result = m.balance(result)
+	m.spans[node] = result
+	return result
+}
+
+// balance extiende el tramo hasta que sus paréntesis queden emparejados
+func (m *sourceMap) balance(s tokenSpan) tokenSpan {
+	if s.empty() {
+		return s
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := s.first; i <= s.last; i++ {
+			partner, ok := m.partner[i]
+			if !ok {
+				continue
+			}
+			if partner < s.first || partner > s.last {
+				s = s.with(partner)
+				changed = true
+			}
+		}
+	}
+	return s
+}
+
+func (m *sourceMap) spanRange(s tokenSpan) *models.SourceRange {
+	if s.empty() {
+		return nil
+	}
+	start, end := m.ranges[s.first], m.ranges[s.last]
+	return &models.SourceRange{
+		StartLine:   start.StartLine,
+		StartCol:    start.StartCol,
+		EndLine:     end.EndLine,
+		EndCol:      end.EndCol,
+		StartOffset: start.StartOffset,
+		EndOffset:   end.EndOffset,
+	}
+}
+
+// locate es el nodeLocator del programa
+func (m *sourceMap) locate(node *models.ASTNode) *models.SourceRange {
+	return m.spanRange(m.spans[node])
+}
+
+// nameRange es la posición del nombre en un uso o una definición, sin el
+// resto de la sentencia. En las definiciones cuyo token no es el nombre
+// ("except E as nombre") se busca el nombre dentro del tramo del nodo.
+func (m *sourceMap) nameRange(node *models.ASTNode, name string) *models.SourceRange {
+	if node == nil {
+		return nil
+	}
+	index, ok := m.at[[2]int{node.Line, node.Col}]
+	if !ok {
+		return nil
+	}
+	if m.tokens[index].Value != name {
+		if span := m.spans[node]; !span.empty() {
+			for i := span.first; i <= span.last; i++ {
+				if m.tokens[i].Type == "IDENTIFIER" && m.tokens[i].Value == name {
+					index = i
+					break
+				}
+			}
+		}
+	}
+	return m.spanRange(tokenSpan{index, index})
+}
+
+func (m *sourceMap) tokenStage() []models.StageToken {
+	tokens := make([]models.StageToken, 0, len(m.tokens))
+	for i, token := range m.tokens {
+		tokens = append(tokens, models.StageToken{Index: i, Type: token.Type, Value: token.Value, Range: m.ranges[i]})
+	}
+	return tokens
+}
+
+// concrete construye el CST: el nodo del AST con sus hijos en el orden del
+// código y, entre ellos, los tokens que el AST no conserva como hojas
+func (m *sourceMap) concrete(node *models.ASTNode) *models.StageNode {
+	span := m.spans[node]
+	result := &models.StageNode{Type: node.Type, Value: node.Value, Range: m.spanRange(span)}
+
+	children := make([]*models.ASTNode, 0, len(node.Children))
+	for _, child := range node.Children {
+		if !m.spans[child].empty() {
+			children = append(children, child)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return m.spans[children[i]].first < m.spans[children[j]].first
+	})
+
+	next := 0
+	for i := span.first; i >= 0 && i <= span.last; {
+		// Un nodo compartido (la parte media de a < b < c) aparece una sola vez
+		for next < len(children) && m.spans[children[next]].first < i {
+			next++
+		}
+		if next < len(children) && m.spans[children[next]].first == i {
+			result.Children = append(result.Children, m.concrete(children[next]))
+			i = m.spans[children[next]].last + 1
+			next++
+			continue
+		}
+		if m.significant(i) {
+			index := i
+			result.Children = append(result.Children, &models.StageNode{
+				Type:  m.tokens[i].Type,
+				Value: m.tokens[i].Value,
+				Token: &index,
+				Range: m.spanRange(tokenSpan{i, i}),
+			})
+		}
+		i++
+	}
+	return result
+}
+
+func (m *sourceMap) abstract(node *models.ASTNode) *models.StageNode {
+	result := &models.StageNode{Type: node.Type, Value: node.Value, Range: m.locate(node)}
+	for _, child := range node.Children {
+		result.Children = append(result.Children, m.abstract(child))
+	}
+	return result
+}
+
+// symbolStage es la tabla de símbolos de todos los scopes con la posición de
+// cada definición y de los usos que el análisis de scopes resolvió a ella
+func (m *sourceMap) symbolStage(tree *scopeTree) []models.StageSymbol {
+	references := make(map[*scopeSymbol][]models.SourceRange)
+	uses := make([]*models.ASTNode, 0, len(tree.resolved))
+	for node := range tree.resolved {
+		uses = append(uses, node)
+	}
+	sort.Slice(uses, func(i, j int) bool {
+		if uses[i].Line != uses[j].Line {
+			return uses[i].Line < uses[j].Line
+		}
+		return uses[i].Col < uses[j].Col
+	})
+	for _, node := range uses {
+		if position := m.nameRange(node, tree.resolved[node].name); position != nil {
+			references[tree.resolved[node]] = append(references[tree.resolved[node]], *position)
+		}
+	}
+
+	symbols := make([]models.StageSymbol, 0)
+	for _, sc := range tree.scopes {
+		for _, name := range sc.order {
+			symbol := sc.symbols[name]
+			if !symbol.definesHere() {
+				continue
+			}
+			entry := models.StageSymbol{
+				Symbol:     symbol.toModel(sc),
+				References: references[symbol],
+			}
+			if entry.References == nil {
+				entry.References = []models.SourceRange{}
+			}
+			if len(symbol.bindings) > 0 {
+				entry.Range = m.nameRange(symbol.bindings[0].node, name)
+			}
+			symbols = append(symbols, entry)
+		}
+	}
+	return symbols
+}
+
+// CompilePipeline reúne las etapas del compilador para el mismo código. Con
+// errores de sintaxis (ast nil) solo se devuelve la etapa de tokens.
+func CompilePipeline(tokens []models.Token, ranges []models.SourceRange, ast *models.ASTNode) models.CompileResponse {
+	m := newSourceMap(tokens, ranges, ast)
+	response := models.CompileResponse{
+		Stages:   compileStages[:1],
+		Tokens:   m.tokenStage(),
+		Symbols:  []models.StageSymbol{},
+		CFG:      []models.FunctionCFG{},
+		Bytecode: []models.CodeObject{},
+	}
+	if ast == nil {
+		return response
+	}
+
+	response.Stages = compileStages
+	response.CST = m.concrete(ast)
+	response.AST = m.abstract(ast)
+	response.Symbols = m.symbolStage(buildScopeTree(ast))
+	for _, graph := range buildFunctionCFGs(ast) {
+		response.CFG = append(response.CFG, exportCFG(graph, m.locate))
+	}
+	response.Bytecode = CompileProgram(ast).disassemble(m.locate)
+	return response
+}
@@ -0,0 +1,449 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+
+	"examen-back/models"
+)
+
+// Límites de un entero con signo de 64 bits (long en Java y C, int64 en Go)
+var (
+	maxInt64 = big.NewInt(1<<63 - 1)
+	minInt64 = big.NewInt(-1 << 63)
+)
+
+// floatFactorialNote explica por qué un producto de enteros no debe calcularse
+// en float: los float representan exactamente los enteros hasta 2**53
+const floatFactorialNote = "un float solo representa exactamente los enteros hasta 2**53, así que un factorial calculado en float pierde exactitud desde 23! y vale inf desde 171!"
+
+// precisionCheck recorre el programa con el scope de cada nodo para consultar
+// los tipos inferidos
+type precisionCheck struct {
+	ctx   *RuleContext
+	calls map[*models.ASTNode]bool
+	// entries son las llamadas a una función recursiva desde fuera de ella
+	entries     map[*models.ASTNode]*recursiveFunction
+	reported    map[*models.ASTNode]bool
+	diagnostics []models.Diagnostic
+}
+
+// checkNumericPrecision advierte sobre productos enteros que se calculan en
+// float (como un factorial con "return 1.0" o "resultado = 1.0") y sobre
+// divisiones '/' donde probablemente se quería la división entera '//'
+func checkNumericPrecision(ctx *RuleContext) []models.Diagnostic {
+	p := &precisionCheck{
+		ctx:         ctx,
+		calls:       make(map[*models.ASTNode]bool),
+		entries:     make(map[*models.ASTNode]*recursiveFunction),
+		reported:    make(map[*models.ASTNode]bool),
+		diagnostics: make([]models.Diagnostic, 0),
+	}
+	for _, rf := range ctx.recursion.functions {
+		for _, call := range rf.calls {
+			p.calls[call.site.call] = true
+		}
+	}
+	if ctx.AST == nil {
+		return p.diagnostics
+	}
+	for _, rf := range ctx.recursion.functions {
+		for _, site := range p.callSites(rf.node) {
+			if !p.calls[site.call] {
+				p.entries[site.call] = rf
+			}
+		}
+	}
+
+	for _, rf := range ctx.recursion.functions {
+		p.floatRecursion(rf)
+	}
+	p.floatAccumulators(ctx.AST.Children)
+	p.trueDivisions(ctx.AST, ctx.scopes.module)
+	return p.diagnostics
+}
+
+func (p *precisionCheck) report(node *models.ASTNode, code, message string) {
+	p.reportAt(node, node.Line, code, message)
+}
+
+// reportAt informa un nodo en otra línea, como la de la llamada que lo contiene
+func (p *precisionCheck) reportAt(node *models.ASTNode, line int, code, message string) {
+	if p.reported[node] {
+		return
+	}
+	p.reported[node] = true
+	p.diagnostics = append(p.diagnostics, models.Diagnostic{Code: code, Message: message, Line: line})
+}
+
+func (p *precisionCheck) isFloat(node *models.ASTNode, sc *scope) bool {
+	return p.ctx.types.expressionType(node, sc) == typeFloat
+}
+
+// productCall devuelve la llamada recursiva de un retorno "E * f(...)" y el otro factor
+func productCall(value *models.ASTNode, calls []recursiveCall) (*models.ASTNode, *models.ASTNode) {
+	if value == nil || value.Type != "BinaryOp" || value.Value != "*" {
+		return nil, nil
+	}
+	for _, call := range calls {
+		switch call.site.call {
+		case value.Children[1]:
+			return value.Children[1], value.Children[0]
+		case value.Children[0]:
+			return value.Children[0], value.Children[1]
+		}
+	}
+	return nil, nil
+}
+
+// floatRecursion revisa las funciones que multiplican por su propia llamada
+// recursiva: un caso base, un factor o un argumento float convierten todo el
+// producto en float
+func (p *precisionCheck) floatRecursion(rf *recursiveFunction) {
+	sc := p.ctx.scopes.byNode[rf.node]
+	_, body := functionParts(rf.node)
+	returns := functionReturns(body)
+
+	product := false
+	for _, ret := range returns {
+		if len(ret.Children) == 0 {
+			continue
+		}
+		call, factor := productCall(ret.Children[0], rf.calls)
+		if call == nil {
+			continue
+		}
+		product = true
+		if p.isFloat(factor, sc) {
+			p.report(factor, "float-factorial", fmt.Sprintf("El factor '%s' de '%s' en línea %d es float, así que '%s' multiplica en float: %s; use enteros", formatSource(factor), formatSource(ret.Children[0]), ret.Line, rf.name, floatFactorialNote))
+		}
+	}
+	if !product {
+		return
+	}
+
+	for _, ret := range returns {
+		if len(ret.Children) == 0 || reachesAny(ret.Children[0], p.calls) {
+			continue
+		}
+		if value := ret.Children[0]; p.isFloat(value, sc) {
+			p.report(ret, "float-factorial", fmt.Sprintf("El caso base de '%s' devuelve %s (float) en línea %d y cada paso multiplica por ese resultado, así que todo el producto se calcula en float: %s; devuelva un entero ('return %s')", rf.name, formatSource(value), ret.Line, floatFactorialNote, integerLiteral(value)))
+		}
+	}
+
+	// Los argumentos float que vienen de fuera de la función; las divisiones
+	// '/' se informan aparte como true-division
+	for _, site := range p.callSites(rf.node) {
+		for _, arg := range site.call.Children {
+			if arg.Type == "Keyword" {
+				arg = arg.Children[0]
+			}
+			if p.integerDivision(arg, p.scopeOf(site.caller)) {
+				continue
+			}
+			if p.isFloat(arg, p.scopeOf(site.caller)) {
+				p.report(site.call, "float-factorial", fmt.Sprintf("La llamada '%s' en línea %d pasa %s, que es float, así que '%s' multiplica en float: %s; pase un entero", formatSource(site.call), site.call.Line, formatSource(arg), rf.name, floatFactorialNote))
+				break
+			}
+		}
+	}
+}
+
+// callSites devuelve las llamadas a fn hechas desde el módulo y desde otras funciones
+func (p *precisionCheck) callSites(fn *models.ASTNode) []callSite {
+	sites := make([]callSite, 0)
+	for _, site := range p.ctx.calls.module {
+		if site.callee == fn {
+			sites = append(sites, site)
+		}
+	}
+	for _, caller := range p.ctx.calls.functions {
+		for _, site := range p.ctx.calls.calls[caller] {
+			if site.callee == fn {
+				sites = append(sites, site)
+			}
+		}
+	}
+	return sites
+}
+
+func (p *precisionCheck) scopeOf(fn *models.ASTNode) *scope {
+	if fn == nil {
+		return p.ctx.scopes.module
+	}
+	return p.ctx.scopes.byNode[fn]
+}
+
+// integerLiteral propone el entero equivalente a un literal float ("1.0" -> "1")
+func integerLiteral(node *models.ASTNode) string {
+	if value, ok := literalValue(node); ok && value.kind == "float" {
+		if f, _ := value.toFloat(); f == float64(int64(f)) {
+			return fmt.Sprintf("%d", int64(f))
+		}
+	}
+	return "1"
+}
+
+// floatAccumulators busca, en cada cuerpo, una variable inicializada con un
+// literal float que después se multiplica dentro de un bucle
+// ("resultado = 1.0" ... "resultado *= i")
+func (p *precisionCheck) floatAccumulators(stmts []*models.ASTNode) {
+	initial := make(map[string]*models.ASTNode)
+	var visit func(node *models.ASTNode, inLoop bool)
+	visit = func(node *models.ASTNode, inLoop bool) {
+		switch node.Type {
+		case "FunctionDef":
+			_, body := functionParts(node)
+			p.floatAccumulators(body)
+			return
+		case "ClassDef", "Lambda":
+			return
+		case "Assignment":
+			if node.Value != "" {
+				if value, ok := literalValue(node.Children[0]); ok && value.kind == "float" && !inLoop {
+					initial[node.Value] = node
+				}
+				if value := node.Children[0]; inLoop && value.Type == "BinaryOp" && value.Value == "*" && mentionsName(value, node.Value) {
+					p.floatProduct(initial[node.Value], node)
+				}
+			}
+		case "AugAssignment":
+			if target := node.Children[0]; inLoop && node.Value == "*=" && target.Type == "Identifier" {
+				p.floatProduct(initial[target.Value], node)
+			}
+		case "ForStatement", "WhileStatement":
+			inLoop = true
+		}
+		for _, child := range node.Children {
+			visit(child, inLoop)
+		}
+	}
+	for _, stmt := range stmts {
+		visit(stmt, false)
+	}
+}
+
+func (p *precisionCheck) floatProduct(init, update *models.ASTNode) {
+	if init == nil {
+		return
+	}
+	p.report(init, "float-factorial", fmt.Sprintf("'%s' en línea %d hace que el producto acumulado en '%s' (línea %d) se calcule en float: %s; inicialícelo con un entero ('%s = %s')",
+		formatSource(init), init.Line, init.Value, update.Line, floatFactorialNote, init.Value, integerLiteral(init.Children[0])))
+}
+
+// trueDivisions informa las divisiones '/' cuyo resultado se usa como entero:
+// como argumento de una llamada recursiva o de la primera llamada a una
+// función recursiva, en range(), como índice o en una variable que después se
+// usa como índice
+func (p *precisionCheck) trueDivisions(node *models.ASTNode, sc *scope) {
+	if inner := p.ctx.scopes.byNode[node]; inner != nil {
+		sc = inner
+	}
+
+	switch node.Type {
+	case "FunctionCall":
+		if p.calls[node] {
+			for _, arg := range node.Children {
+				if arg.Type == "Keyword" {
+					arg = arg.Children[0]
+				}
+				if p.integerDivision(arg, sc) {
+					p.report(arg, "true-division", fmt.Sprintf("'%s' en línea %d es una división real y siempre devuelve float (6 / 2 == 3.0): como argumento de la llamada recursiva '%s' convierte en float el parámetro y todo lo que se calcula con él; use '%s' para la división entera",
+						formatSource(arg), arg.Line, formatSource(node), floorDivision(arg)))
+				}
+			}
+		}
+		if rf := p.entries[node]; rf != nil {
+			for _, arg := range node.Children {
+				if arg.Type == "Keyword" {
+					arg = arg.Children[0]
+				}
+				if p.integerDivision(arg, sc) {
+					p.reportAt(arg, node.Line, "true-division", fmt.Sprintf("'%s' es una división real y siempre devuelve float (6 / 2 == 3.0): la llamada '%s' en línea %d pasa un float a la función recursiva '%s', que convierte en float el parámetro y todo lo que se calcula con él; use '%s' para la división entera",
+						formatSource(arg), formatSource(node), node.Line, rf.name, floorDivision(arg)))
+				}
+			}
+		}
+		if node.Value == "range" && p.ctx.scopes.resolved[node] == nil {
+			for _, arg := range node.Children {
+				if isTrueDivision(arg) {
+					p.report(arg, "true-division", fmt.Sprintf("'%s' en línea %d es una división real y devuelve float, que range() no acepta (TypeError al ejecutarse); use '%s'", formatSource(arg), arg.Line, floorDivision(arg)))
+				}
+			}
+		}
+
+	case "Subscript":
+		index := node.Children[1]
+		parts := []*models.ASTNode{index}
+		if index.Type == "Slice" {
+			parts = index.Children
+		}
+		for _, part := range parts {
+			if isTrueDivision(part) {
+				p.report(part, "true-division", fmt.Sprintf("'%s' en línea %d es una división real y devuelve float, que no sirve como índice (TypeError al ejecutarse); use '%s'", formatSource(part), part.Line, floorDivision(part)))
+			}
+		}
+
+	case "Assignment":
+		if node.Value != "" && isTrueDivision(node.Children[0]) {
+			if use := p.indexUse(p.ctx.types.bindingSymbol(sc, node.Value)); use != nil {
+				p.report(node.Children[0], "true-division", fmt.Sprintf("'%s' en línea %d es una división real y devuelve float, pero '%s' se usa como índice en línea %d (TypeError al ejecutarse); use '%s'",
+					formatSource(node), node.Line, node.Value, use.Line, node.Value+" = "+floorDivision(node.Children[0])))
+			}
+		}
+	}
+
+	for _, child := range node.Children {
+		p.trueDivisions(child, sc)
+	}
+}
+
+func isTrueDivision(node *models.ASTNode) bool {
+	return node.Type == "BinaryOp" && node.Value == "/"
+}
+
+// integerDivision indica una división '/' en la que ningún operando es float
+func (p *precisionCheck) integerDivision(node *models.ASTNode, sc *scope) bool {
+	if !isTrueDivision(node) {
+		return false
+	}
+	for _, operand := range node.Children {
+		if p.isFloat(operand, sc) {
+			return false
+		}
+	}
+	return true
+}
+
+func floorDivision(node *models.ASTNode) string {
+	return formatSource(&models.ASTNode{Type: "BinaryOp", Value: "//", Children: node.Children})
+}
+
+// indexUse devuelve un uso del símbolo como índice o en range(), o nil
+func (p *precisionCheck) indexUse(symbol *scopeSymbol) *models.ASTNode {
+	if symbol == nil {
+		return nil
+	}
+	var found *models.ASTNode
+	var visit func(node *models.ASTNode, integer bool)
+	visit = func(node *models.ASTNode, integer bool) {
+		if found != nil {
+			return
+		}
+		if integer && node.Type == "Identifier" && p.ctx.scopes.resolved[node] == symbol {
+			found = node
+			return
+		}
+		switch {
+		case node.Type == "Subscript":
+			visit(node.Children[0], false)
+			visit(node.Children[1], true)
+			return
+		case node.Type == "FunctionCall" && node.Value == "range" && p.ctx.scopes.resolved[node] == nil:
+			integer = true
+		case node.Type == "Slice":
+		case node.Type != "BinaryOp" && node.Type != "UnaryOp":
+			// Fuera de la aritmética del índice, el valor ya no es el índice
+			integer = false
+		}
+		for _, child := range node.Children {
+			visit(child, integer)
+		}
+	}
+	visit(p.ctx.AST, false)
+	return found
+}
+
+// checkInt64Overflow informa los enteros calculados en tiempo de compilación
+// que no caben en 64 bits: Python los maneja sin problemas, pero el mismo
+// programa desborda al pasarlo a C, Java o Go. Además de los valores
+// plegados, evalúa las llamadas cuyos argumentos son variables globales
+// asignadas una sola vez con un literal ("x = 25" ... "factorial(x)").
+func checkInt64Overflow(ctx *RuleContext) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+	if ctx.AST == nil {
+		return diagnostics
+	}
+
+	var visit func(node *models.ASTNode) bool
+	visit = func(node *models.ASTNode) bool {
+		inner := false
+		for _, child := range node.Children {
+			inner = visit(child) || inner
+		}
+		// Se informa la expresión más interna que desborda ("factorial(25)" y no "factorial(25) - 1")
+		if inner {
+			return true
+		}
+
+		value, known := ctx.constants.values[node]
+		inputs := ""
+		if !known && node.Type == "FunctionCall" {
+			value, inputs, known = ctx.constants.withGlobalInputs(node)
+		}
+		if !known || value.kind != "int" || (value.i.Cmp(maxInt64) <= 0 && value.i.Cmp(minInt64) >= 0) {
+			return false
+		}
+
+		limit := fmt.Sprintf("el máximo de 64 bits (%s)", maxInt64)
+		if value.i.Sign() < 0 {
+			limit = fmt.Sprintf("el mínimo de 64 bits (%s)", minInt64)
+		}
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Code: "int64-overflow",
+			Message: fmt.Sprintf("'%s'%s en línea %d vale %s, un entero de %d bits: Python lo calcula sin problemas, pero supera %s, así que el mismo cálculo con long en Java o C, o con int64 en Go, desbordaría",
+				formatSource(node), inputs, node.Line, value.Repr(), value.i.BitLen(), limit),
+			Line: node.Line,
+		})
+		return true
+	}
+	visit(ctx.AST)
+	return diagnostics
+}
+
+// withGlobalInputs evalúa una llamada a una función pura cuyos argumentos
+// son literales o globales con un único valor literal; inputs describe esos
+// valores (" con x = 25")
+func (f *constantFolding) withGlobalInputs(call *models.ASTNode) (constValue, string, bool) {
+	fn, ok := f.pureCallee(call)
+	if !ok || fn == nil {
+		return constValue{}, "", false
+	}
+
+	args := make([]constValue, 0, len(call.Children))
+	inputs := ""
+	for _, arg := range call.Children {
+		if value, ok := literalValue(arg); ok {
+			args = append(args, value)
+			continue
+		}
+		symbol := f.tree.resolved[arg]
+		if arg.Type != "Identifier" || symbol == nil || symbol.owner != f.tree.module || len(symbol.bindings) != 1 || symbol.assignedElsewhere {
+			return constValue{}, "", false
+		}
+		binding := symbol.bindings[0].node
+		if binding == nil || binding.Type != "Assignment" || binding.Value != arg.Value {
+			return constValue{}, "", false
+		}
+		value, ok := literalValue(binding.Children[0])
+		if !ok {
+			return constValue{}, "", false
+		}
+		args = append(args, value)
+		if inputs == "" {
+			inputs = " con "
+		} else {
+			inputs += ", "
+		}
+		inputs += arg.Value + " = " + value.Repr()
+	}
+	if inputs == "" {
+		return constValue{}, "", false
+	}
+
+	f.steps = 0
+	f.budget = min(maxFoldSteps, maxFoldTotal-f.total)
+	value, err := f.callFunction(fn, args, nil)
+	f.total += f.steps
+	return value, inputs, err == nil
+}
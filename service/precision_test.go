@@ -0,0 +1,37 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrueDivisionAtCallSites(t *testing.T) {
+	const factorial = "def factorial(n):\n    if n <= 1:\n        return 1\n    return n * factorial(n - 1)\n\nx = 10\n"
+	cases := []struct {
+		name  string
+		calls string
+		line  int // 0 si no debe informarse
+	}{
+		{"en el módulo", "print(factorial(x / 2))\n", 7},
+		{"argumento en otra línea", "print(factorial(\n    x / 2))\n", 7},
+		{"desde otra función", "def g():\n    return factorial(x / 2)\n\nprint(g())\n", 8},
+		{"argumento nombrado", "print(factorial(n=x / 2))\n", 7},
+		{"división entera", "print(factorial(x // 2))\n", 0},
+		{"operando float", "print(factorial(x / 2.0))\n", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			found := diagnosticsWithCode(analyze(t, factorial+tc.calls), "true-division")
+			switch {
+			case tc.line == 0 && len(found) > 0:
+				t.Fatalf("no se esperaba diagnóstico: %v", found)
+			case tc.line == 0:
+				return
+			case len(found) != 1:
+				t.Fatalf("se esperaba un diagnóstico, hay %v", found)
+			case found[0].Line != tc.line || !strings.Contains(found[0].Message, "x // 2"):
+				t.Errorf("diagnóstico inesperado en línea %d: %s", found[0].Line, found[0].Message)
+			}
+		})
+	}
+}
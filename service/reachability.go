@@ -0,0 +1,206 @@
+package service
+
+import (
+	"fmt"
+
+	"examen-back/models"
+)
+
+// checkUnreachableCode informa cada región de código a la que no llega ningún
+// camino desde la entrada, indicando la sentencia que la dejó inalcanzable
+func checkUnreachableCode(graphs []*controlFlowGraph) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+
+	for _, graph := range graphs {
+		reachable := graph.reachable()
+		visited := make(map[*basicBlock]bool)
+
+		for _, start := range graph.blocks {
+			if reachable[start] || visited[start] || start.label != "unreachable" || start.cause == nil {
+				continue
+			}
+
+			// La región incluye los bloques a los que solo se llega desde el inicio inalcanzable
+			first, last := 0, 0
+			queue := []*basicBlock{start}
+			visited[start] = true
+			for len(queue) > 0 {
+				block := queue[0]
+				queue = queue[1:]
+				for _, step := range block.steps {
+					if first == 0 || step.Line < first {
+						first = step.Line
+					}
+					last = max(last, step.Line)
+				}
+				for _, edge := range block.succs {
+					if !reachable[edge.to] && !visited[edge.to] && edge.to != graph.exit {
+						visited[edge.to] = true
+						queue = append(queue, edge.to)
+					}
+				}
+			}
+			if first == 0 {
+				continue
+			}
+
+			lines := fmt.Sprintf("en línea %d", first)
+			if last > first {
+				lines = fmt.Sprintf("en líneas %d-%d", first, last)
+			}
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Code:     "unreachable-code",
+				Severity: "warning",
+				Message:  fmt.Sprintf("Código inalcanzable %s: %s", lines, describeExit(start.cause)),
+				Line:     first,
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// describeExit explica por qué el código posterior a una sentencia no se ejecuta
+func describeExit(stmt *models.ASTNode) string {
+	switch stmt.Type {
+	case "ReturnStatement":
+		return fmt.Sprintf("aparece después de 'return' en línea %d", stmt.Line)
+	case "RaiseStatement":
+		return fmt.Sprintf("aparece después de 'raise' en línea %d", stmt.Line)
+	case "BreakStatement":
+		return fmt.Sprintf("aparece después de 'break' en línea %d", stmt.Line)
+	case "ContinueStatement":
+		return fmt.Sprintf("aparece después de 'continue' en línea %d", stmt.Line)
+	case "WhileStatement":
+		return fmt.Sprintf("el bucle 'while' de la línea %d no tiene salida con 'break'", stmt.Line)
+	case "IfStatement":
+		return fmt.Sprintf("todas las ramas del 'if' de la línea %d terminan antes", stmt.Line)
+	case "TryStatement":
+		return fmt.Sprintf("todos los caminos del 'try' de la línea %d terminan antes", stmt.Line)
+	}
+	return fmt.Sprintf("aparece después de la línea %d", stmt.Line)
+}
+
+// checkReturnPaths detecta funciones que devuelven un valor en algunos caminos
+// pero llegan al final sin 'return' (devolviendo None) en otros, y los 'return'
+// sin valor mezclados con 'return expr'
+func checkReturnPaths(graphs []*controlFlowGraph) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+
+	for _, graph := range graphs {
+		if graph.node.Type != "FunctionDef" {
+			continue
+		}
+
+		reachable := graph.reachable()
+		var valueReturn *models.ASTNode
+		bareReturns := make([]*models.ASTNode, 0)
+		for _, block := range graph.blocks {
+			if !reachable[block] {
+				continue
+			}
+			for _, step := range block.steps {
+				if step.Type != "ReturnStatement" {
+					continue
+				}
+				if len(step.Children) > 0 {
+					if valueReturn == nil || step.Line < valueReturn.Line {
+						valueReturn = step
+					}
+				} else {
+					bareReturns = append(bareReturns, step)
+				}
+			}
+		}
+		if valueReturn == nil {
+			continue
+		}
+
+		for _, pred := range graph.exit.preds {
+			if !reachable[pred] || !pred.hasNormalEdgeTo(graph.exit) {
+				continue
+			}
+
+			line := graph.node.Line
+			last := lastStepBefore(pred, reachable)
+			if last != nil {
+				line = last.Line
+			}
+
+			message := fmt.Sprintf("La función '%s' devuelve un valor en línea %d, pero el camino que termina en línea %d llega al final sin 'return' y devuelve None", graph.node.Value, valueReturn.Line, line)
+			// Una condición de if o while sin cumplir no es un valor descartado
+			if last != nil && graph.roles[last] == "" && isDiscardedValue(last, graph.node.Value) {
+				message += fmt.Sprintf("; el valor de la expresión '%s' se calcula pero no se devuelve, ¿falta 'return'?", formatSource(last))
+			}
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Code:     "missing-return",
+				Severity: "error",
+				Message:  message,
+				Line:     line,
+			})
+		}
+
+		for _, bare := range bareReturns {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Code:     "inconsistent-return",
+				Severity: "warning",
+				Message:  fmt.Sprintf("'return' sin valor en línea %d, pero la función '%s' devuelve un valor en línea %d; use 'return None' de forma explícita o devuelva un valor en todos los caminos", bare.Line, graph.node.Value, valueReturn.Line),
+				Line:     bare.Line,
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// lastStepBefore devuelve el último paso ejecutado al llegar al bloque; si el
+// bloque está vacío (el final de un if sin else) busca hacia atrás el paso más
+// cercano, por ejemplo la condición de un elif que no se cumplió
+func lastStepBefore(block *basicBlock, reachable map[*basicBlock]bool) *models.ASTNode {
+	visited := map[*basicBlock]bool{block: true}
+	queue := []*basicBlock{block}
+	for len(queue) > 0 {
+		var last *models.ASTNode
+		next := make([]*basicBlock, 0)
+		for _, current := range queue {
+			if len(current.steps) > 0 {
+				if step := current.steps[len(current.steps)-1]; last == nil || step.Line > last.Line {
+					last = step
+				}
+				continue
+			}
+			for _, pred := range current.preds {
+				if reachable[pred] && !visited[pred] {
+					visited[pred] = true
+					next = append(next, pred)
+				}
+			}
+		}
+		if last != nil {
+			return last
+		}
+		queue = next
+	}
+	return nil
+}
+
+func (block *basicBlock) hasNormalEdgeTo(target *basicBlock) bool {
+	for _, edge := range block.succs {
+		if edge.to == target && edge.kind == "normal" {
+			return true
+		}
+	}
+	return false
+}
+
+// isDiscardedValue indica si una sentencia es una expresión cuyo resultado se
+// pierde: un cálculo o la llamada recursiva a la propia función
+func isDiscardedValue(stmt *models.ASTNode, function string) bool {
+	switch stmt.Type {
+	case "BinaryOp", "UnaryOp", "Identifier", "Number", "Subscript", "IfExpression":
+		return true
+	case "FunctionCall":
+		return stmt.Value == function
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReturnPaths(t *testing.T) {
+	cases := []struct {
+		name         string
+		code         string
+		missing      []int
+		inconsistent []int
+	}{
+		{"factorial sin return", "def factorial(n):\n    if n == 0:\n        return 1\n    n * factorial(n - 1)\n\nprint(factorial(3))\n", []int{4}, nil},
+		{"if sin else", "def sign(n):\n    if n > 0:\n        return 1\n    elif n < 0:\n        return -1\n\nprint(sign(0))\n", []int{4}, nil},
+		{"return sin valor", "def f(n):\n    if n:\n        return\n    return n\n\nprint(f(1))\n", nil, []int{3}},
+		{"todos los caminos devuelven", "def f(n):\n    if n:\n        return 1\n    else:\n        return 2\n\nprint(f(1))\n", nil, nil},
+		{"while True sin salida", "def f(n):\n    while True:\n        if n > 3:\n            return n\n        n += 1\n\nprint(f(1))\n", nil, nil},
+		{"raise al final", "def f(n):\n    if n:\n        return n\n    raise ValueError('cero')\n\nprint(f(1))\n", nil, nil},
+		{"procedimiento", "def f(n):\n    print(n)\n    return\n\nf(1)\n", nil, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectDiagnostics(t, tc.code, map[string][]int{"missing-return": tc.missing, "inconsistent-return": tc.inconsistent})
+		})
+	}
+}
+
+func TestMissingReturnPointsAtDiscardedValue(t *testing.T) {
+	found := diagnosticsWithCode(analyze(t, "def factorial(n):\n    if n == 0:\n        return 1\n    n * factorial(n - 1)\n\nprint(factorial(3))\n"), "missing-return")
+	if len(found) != 1 || found[0].Severity != "error" || !strings.Contains(found[0].Message, "'n * factorial(n - 1)'") {
+		t.Fatalf("el mensaje debe señalar la expresión sin return: %v", found)
+	}
+
+	found = diagnosticsWithCode(analyze(t, "def sign(n):\n    if n > 0:\n        return 1\n    elif n < 0:\n        return -1\n\nprint(sign(0))\n"), "missing-return")
+	if len(found) != 1 || strings.Contains(found[0].Message, "no se devuelve") || !strings.Contains(found[0].Message, "termina en línea 4") {
+		t.Fatalf("la condición del elif no es un valor descartado: %v", found)
+	}
+}
+
+func TestUnreachableCodeNamesTheCause(t *testing.T) {
+	cases := []struct {
+		name  string
+		code  string
+		lines []int
+		cause string
+	}{
+		{"después de return", "def f(n):\n    return n\n    print(n)\n    n += 1\n\nprint(f(1))\n", []int{3}, "después de 'return' en línea 2"},
+		{"después de raise", "def f(n):\n    raise ValueError(n)\n    print(n)\n\nf(1)\n", []int{3}, "después de 'raise' en línea 2"},
+		{"después de un if que siempre termina", "def f(n):\n    if n:\n        return 1\n    else:\n        return 2\n    print(n)\n\nprint(f(1))\n", []int{6}, "todas las ramas del 'if' de la línea 2"},
+		{"sin código muerto", "def f(n):\n    if n:\n        return 1\n    print(n)\n    return 0\n\nprint(f(1))\n", nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := expectDiagnostics(t, tc.code, map[string][]int{"unreachable-code": tc.lines})
+			for _, diagnostic := range diagnosticsWithCode(result, "unreachable-code") {
+				if !strings.Contains(diagnostic.Message, tc.cause) {
+					t.Errorf("el mensaje debe indicar '%s': %s", tc.cause, diagnostic.Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,641 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"examen-back/models"
+)
+
+// recursionGuard normaliza una condición de caso base como "parámetro op constante".
+// El operador "empty" representa "not p", "p == []" o "p == 0" por veracidad.
+type recursionGuard struct {
+	param  string
+	op     string
+	value  int64
+	length bool
+}
+
+// baseCase es una rama de la función que termina sin llamadas recursivas.
+// Los casos base de bucle corresponden a llamadas dentro de un for, que dejan
+// de producirse cuando el iterable se agota.
+type baseCase struct {
+	line      int
+	condition string
+	returns   *models.ASTNode
+	guards    []recursionGuard
+	loop      bool
+}
+
+// argumentStep compara un argumento de la llamada recursiva con el parámetro
+// de la misma posición: decreasing, increasing, halving, shrinking, unchanged,
+// unknown o unrelated. amount es el paso de sumas y restas constantes.
+type argumentStep struct {
+	param    string
+	arg      *models.ASTNode
+	progress string
+	amount   int64
+}
+
+type recursiveCall struct {
+	site   callSite
+	args   []argumentStep
+	inLoop bool
+	toward bool
+}
+
+type recursiveFunction struct {
+	node      *models.ASTNode
+	name      string
+	kind      string
+	cycle     []*models.ASTNode
+	params    []string
+	baseCases []baseCase
+	calls     []recursiveCall
+	// uncertain indica que la búsqueda de casos base pasó por sentencias que
+	// no modela con exactitud (try, while), así que no encontrarlos no
+	// prueba que falten
+	uncertain bool
+}
+
+type recursionAnalysis struct {
+	graph     *callGraph
+	functions []*recursiveFunction
+	byNode    map[*models.ASTNode]*recursiveFunction
+}
+
+// analyzeRecursion detecta la recursión directa y mutua a partir del grafo de
+// llamadas y describe los casos base y el avance de cada llamada recursiva
+func analyzeRecursion(graph *callGraph) *recursionAnalysis {
+	analysis := &recursionAnalysis{graph: graph, byNode: make(map[*models.ASTNode]*recursiveFunction)}
+
+	for _, component := range graph.stronglyConnected() {
+		if len(component) == 1 && !graph.callsItself(component[0]) {
+			continue
+		}
+
+		kind := "direct"
+		if len(component) > 1 {
+			kind = "mutual"
+			sort.Slice(component, func(i, j int) bool { return component[i].Line < component[j].Line })
+		}
+		for _, fn := range component {
+			params, _ := functionParts(fn)
+			rf := &recursiveFunction{node: fn, name: graph.names[fn], kind: kind, params: make([]string, 0, len(params))}
+			if kind == "mutual" {
+				rf.cycle = component
+			}
+			for _, param := range params {
+				if name := parameterName(param); name != "" && !strings.HasPrefix(param.Value, "*") {
+					rf.params = append(rf.params, name)
+				}
+			}
+			analysis.byNode[fn] = rf
+		}
+	}
+
+	for _, fn := range graph.functions {
+		rf := analysis.byNode[fn]
+		if rf == nil {
+			continue
+		}
+
+		recursiveCalls := make(map[*models.ASTNode]bool)
+		for _, site := range graph.calls[fn] {
+			if analysis.sameCycle(fn, site.callee) {
+				recursiveCalls[site.call] = true
+			}
+		}
+
+		finder := &baseCaseFinder{calls: recursiveCalls, params: rf.params, loops: make(map[*models.ASTNode]bool)}
+		_, body := functionParts(fn)
+		finder.find(body, true)
+		rf.baseCases = finder.cases
+		rf.uncertain = finder.uncertain
+
+		for _, site := range graph.calls[fn] {
+			if recursiveCalls[site.call] {
+				rf.calls = append(rf.calls, recursiveCall{site: site, args: rf.argumentSteps(site.call), inLoop: finder.loops[site.call]})
+			}
+		}
+		analysis.functions = append(analysis.functions, rf)
+	}
+
+	for _, rf := range analysis.functions {
+		for i := range rf.calls {
+			rf.calls[i].toward = analysis.movesToward(rf.calls[i])
+		}
+	}
+
+	return analysis
+}
+
+func (a *recursionAnalysis) sameCycle(caller, callee *models.ASTNode) bool {
+	if caller == callee {
+		return true
+	}
+	rf := a.byNode[caller]
+	if rf == nil {
+		return false
+	}
+	for _, member := range rf.cycle {
+		if member == callee {
+			return true
+		}
+	}
+	return false
+}
+
+// movesToward indica si algún argumento avanza hacia un caso base de la función
+// llamada sobre el parámetro de la misma posición
+func (a *recursionAnalysis) movesToward(call recursiveCall) bool {
+	callee := a.byNode[call.site.callee]
+	if callee == nil {
+		return false
+	}
+
+	for _, base := range callee.baseCases {
+		if base.loop && call.inLoop {
+			return true
+		}
+	}
+
+	for position, step := range call.args {
+		if position >= len(callee.params) {
+			break
+		}
+		for _, base := range callee.baseCases {
+			for _, guard := range base.guards {
+				if guard.param == callee.params[position] && progressReaches(step, guard) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// progressReaches decide si el avance de un argumento puede llegar a cumplir la guarda
+func progressReaches(step argumentStep, guard recursionGuard) bool {
+	lowerBound := guard.op == "<" || guard.op == "<=" || guard.op == "==" || guard.op == "empty"
+
+	switch step.progress {
+	case "decreasing", "halving":
+		return !guard.length && lowerBound
+	case "increasing":
+		return !guard.length && (guard.op == ">" || guard.op == ">=" || guard.op == "==")
+	case "shrinking":
+		return guard.op == "empty" || (guard.length && lowerBound)
+	}
+	return false
+}
+
+// argumentSteps clasifica cada argumento posicional o por nombre de la llamada
+func (rf *recursiveFunction) argumentSteps(call *models.ASTNode) []argumentStep {
+	steps := make([]argumentStep, 0, len(call.Children))
+
+	for position, arg := range call.Children {
+		if arg.Type == "Keyword" {
+			continue
+		}
+		param := ""
+		if position < len(rf.params) {
+			param = rf.params[position]
+		}
+		steps = append(steps, classifyArgument(arg, param))
+	}
+
+	for _, arg := range call.Children {
+		if arg.Type != "Keyword" {
+			continue
+		}
+		for position, param := range rf.params {
+			if param == arg.Value && position >= len(steps) {
+				for len(steps) < position {
+					steps = append(steps, argumentStep{progress: "unknown"})
+				}
+				steps = append(steps, classifyArgument(arg.Children[0], param))
+			}
+		}
+	}
+
+	return steps
+}
+
+// classifyArgument compara el argumento con el parámetro que ocupa su posición
+func classifyArgument(arg *models.ASTNode, param string) argumentStep {
+	step := argumentStep{param: param, arg: arg, progress: "unknown"}
+	if param == "" {
+		return step
+	}
+
+	isParam := func(node *models.ASTNode) bool {
+		return node.Type == "Identifier" && node.Value == param
+	}
+
+	switch arg.Type {
+	case "Identifier":
+		if isParam(arg) {
+			step.progress = "unchanged"
+		}
+
+	case "BinaryOp":
+		left, right := arg.Children[0], arg.Children[1]
+		constant, isConstant := intLiteral(right)
+		// En las operaciones conmutativas la constante puede ir a la izquierda (1 + n)
+		if !isConstant && isParam(right) && (arg.Value == "+" || arg.Value == "*") {
+			constant, isConstant = intLiteral(left)
+			left = right
+		}
+
+		switch {
+		case arg.Value == "%" && isParam(arg.Children[1]):
+			// a % n siempre es menor que n
+			step.progress = "decreasing"
+		case !isConstant || !isParam(left):
+		case (arg.Value == "-" && constant > 0) || (arg.Value == "+" && constant < 0):
+			step.progress, step.amount = "decreasing", max(constant, -constant)
+		case (arg.Value == "+" && constant > 0) || (arg.Value == "-" && constant < 0):
+			step.progress, step.amount = "increasing", max(constant, -constant)
+		case (arg.Value == "+" || arg.Value == "-") && constant == 0:
+			step.progress = "unchanged"
+		case (arg.Value == "//" || arg.Value == "/") && constant >= 2, arg.Value == ">>" && constant >= 1:
+			step.progress = "halving"
+		case arg.Value == "*" && constant >= 2, arg.Value == "<<" && constant >= 1, arg.Value == "**" && constant >= 2:
+			step.progress = "increasing"
+		case (arg.Value == "*" || arg.Value == "//" || arg.Value == "/") && constant == 1:
+			step.progress = "unchanged"
+		}
+
+	case "Subscript":
+		if isParam(arg.Children[0]) && arg.Children[1].Type == "Slice" {
+			slice := arg.Children[1]
+			start, hasStart := intLiteral(slice.Children[0])
+			stop, hasStop := intLiteral(slice.Children[1])
+			if (hasStart && start >= 1) || (hasStop && stop < 0) {
+				step.progress = "shrinking"
+			}
+		}
+	}
+
+	if step.progress == "unknown" && !mentionsName(arg, param) {
+		step.progress = "unrelated"
+	}
+	return step
+}
+
+func mentionsName(node *models.ASTNode, name string) bool {
+	if node == nil {
+		return false
+	}
+	if node.Type == "Identifier" && node.Value == name {
+		return true
+	}
+	for _, child := range node.Children {
+		if mentionsName(child, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// intLiteral devuelve el valor de un literal entero, incluidos los negativos (-1)
+func intLiteral(node *models.ASTNode) (int64, bool) {
+	if node == nil {
+		return 0, false
+	}
+	switch node.Type {
+	case "Number":
+		value, err := strconv.ParseInt(node.Value, 10, 64)
+		return value, err == nil
+	case "UnaryOp":
+		if node.Value == "-" || node.Value == "+" {
+			value, ok := intLiteral(node.Children[0])
+			if node.Value == "-" {
+				value = -value
+			}
+			return value, ok
+		}
+	}
+	return 0, false
+}
+
+// baseCaseFinder recorre el cuerpo de una función buscando ramas sin llamadas recursivas
+type baseCaseFinder struct {
+	calls  map[*models.ASTNode]bool
+	params []string
+	cases  []baseCase
+	// loops marca las llamadas recursivas hechas dentro de un for
+	loops map[*models.ASTNode]bool
+	// uncertain se marca al buscar dentro de un try o un while
+	uncertain bool
+}
+
+// find analiza una lista de sentencias; tail indica que al terminarla la función
+// termina (devolviendo None)
+func (f *baseCaseFinder) find(body []*models.ASTNode, tail bool) {
+	for i, stmt := range body {
+		rest := body[i+1:]
+		last := len(rest) == 0
+
+		switch stmt.Type {
+		case "IfStatement":
+			condition := stmt.Children[0]
+			then := stmt.Children[1].Children
+			f.branch(then, condition, false, tail && last)
+
+			if len(stmt.Children) > 2 {
+				otherwise := stmt.Children[2].Children
+				f.branch(otherwise, condition, true, tail && last)
+				if terminates(then) && terminates(otherwise) {
+					return
+				}
+			} else if f.recursive(then) && !f.recursive(rest) {
+				// "if cond: <recursión>" seguido de código sin recursión: el resto es el caso base
+				f.branch(rest, condition, true, tail)
+				return
+			}
+
+		case "ReturnStatement":
+			if len(stmt.Children) == 0 {
+				return
+			}
+			switch expr := stmt.Children[0]; {
+			case expr.Type == "IfExpression":
+				if !f.recursive(expr.Children[1:2]) {
+					f.addCase(expr.Children[0], false, expr.Children[1])
+				}
+				if !f.recursive(expr.Children[2:3]) {
+					f.addCase(expr.Children[0], true, expr.Children[2])
+				}
+			case expr.Type == "BinaryOp" && (expr.Value == "or" || expr.Value == "and") && !f.recursive(expr.Children[:1]):
+				// "return n == 0 or f(n - 1)" no llama si el operando izquierdo
+				// decide el resultado: verdadero en un or, falso en un and
+				f.addCase(expr.Children[0], expr.Value == "and", expr.Children[0])
+			}
+			return
+
+		case "RaiseStatement":
+			return
+
+		case "TryStatement", "WhileStatement":
+			// Los casos base pueden estar en sus bloques, aunque un try puede
+			// saltar al except y un while no ejecutarse
+			f.uncertain = true
+			for _, block := range stmt.Children {
+				switch block.Type {
+				case "Block":
+					f.find(block.Children, tail && last && stmt.Type == "TryStatement")
+				case "ExceptHandler":
+					f.find(block.Children[len(block.Children)-1].Children, tail && last)
+				}
+			}
+
+		case "ForStatement":
+			if f.recursive(stmt.Children[2].Children) {
+				f.markLoopCalls(stmt.Children[2])
+				f.cases = append(f.cases, baseCase{
+					line:      stmt.Line,
+					condition: formatSource(stmt.Children[1]) + " sin más elementos",
+					loop:      true,
+				})
+			}
+		}
+	}
+}
+
+// branch registra la rama como caso base si termina sin recursión; si tiene
+// recursión busca casos base anidados
+func (f *baseCaseFinder) branch(stmts []*models.ASTNode, condition *models.ASTNode, negated, tail bool) {
+	if f.recursive(stmts) {
+		f.find(stmts, tail)
+		return
+	}
+	if len(stmts) > 0 && stmts[len(stmts)-1].Type == "RaiseStatement" {
+		// Una rama que lanza una excepción es una validación, no un caso base
+		return
+	}
+	if !terminates(stmts) && !tail {
+		// Una rama sin recursión que sigue de largo puede tener casos base
+		// anidados: "if n < 0: raise ... elif n == 0: return 1"
+		f.find(stmts, false)
+		return
+	}
+
+	var returns *models.ASTNode
+	if len(stmts) > 0 && stmts[len(stmts)-1].Type == "ReturnStatement" && len(stmts[len(stmts)-1].Children) > 0 {
+		returns = stmts[len(stmts)-1].Children[0]
+	}
+	f.addCase(condition, negated, returns)
+}
+
+func (f *baseCaseFinder) addCase(condition *models.ASTNode, negated bool, returns *models.ASTNode) {
+	text := formatSource(condition)
+	if negated {
+		text = negatedSource(condition)
+	}
+	f.cases = append(f.cases, baseCase{
+		line:      condition.Line,
+		condition: text,
+		returns:   returns,
+		guards:    extractGuards(condition, negated, f.params),
+	})
+}
+
+func (f *baseCaseFinder) recursive(stmts []*models.ASTNode) bool {
+	for _, stmt := range stmts {
+		if f.containsCall(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *baseCaseFinder) containsCall(node *models.ASTNode) bool {
+	if node == nil || node.Type == "FunctionDef" || node.Type == "ClassDef" {
+		return false
+	}
+	if f.calls[node] {
+		return true
+	}
+	for _, child := range node.Children {
+		if f.containsCall(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *baseCaseFinder) markLoopCalls(node *models.ASTNode) {
+	if node == nil || node.Type == "FunctionDef" || node.Type == "ClassDef" {
+		return
+	}
+	if f.calls[node] {
+		f.loops[node] = true
+	}
+	for _, child := range node.Children {
+		f.markLoopCalls(child)
+	}
+}
+
+// terminates indica si una lista de sentencias siempre termina con return o raise
+func terminates(stmts []*models.ASTNode) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+
+	last := stmts[len(stmts)-1]
+	switch last.Type {
+	case "ReturnStatement", "RaiseStatement":
+		return true
+	case "IfStatement":
+		return len(last.Children) > 2 && terminates(last.Children[1].Children) && terminates(last.Children[2].Children)
+	}
+	return false
+}
+
+var inverseComparison = map[string]string{
+	"<": ">=", "<=": ">", ">": "<=", ">=": "<", "==": "!=", "!=": "==",
+	"in": "not in", "not in": "in", "is": "is not", "is not": "is",
+}
+
+// negatedSource muestra la negación de una condición ("n > 1" para "not n <= 1")
+func negatedSource(condition *models.ASTNode) string {
+	return formatSource(negatedCondition(condition))
+}
+
+// negatedCondition construye la negación de una condición invirtiendo la
+// comparación o quitando un "not" cuando es posible
+func negatedCondition(condition *models.ASTNode) *models.ASTNode {
+	if condition.Type == "BinaryOp" && inverseComparison[condition.Value] != "" {
+		return &models.ASTNode{Type: "BinaryOp", Value: inverseComparison[condition.Value], Children: condition.Children, Line: condition.Line, Col: condition.Col}
+	}
+	if condition.Type == "UnaryOp" && condition.Value == "not" {
+		return condition.Children[0]
+	}
+	return &models.ASTNode{Type: "UnaryOp", Value: "not", Children: []*models.ASTNode{condition}, Line: condition.Line, Col: condition.Col}
+}
+
+var mirroredComparison = map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<=", "==": "==", "!=": "!="}
+
+// extractGuards convierte la condición (o su negación) en guardas sobre los parámetros
+func extractGuards(condition *models.ASTNode, negated bool, params []string) []recursionGuard {
+	isParam := func(node *models.ASTNode) bool {
+		if node.Type != "Identifier" {
+			return false
+		}
+		for _, param := range params {
+			if param == node.Value {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch condition.Type {
+	case "UnaryOp":
+		if condition.Value == "not" {
+			return extractGuards(condition.Children[0], !negated, params)
+		}
+
+	case "Identifier":
+		// "if lst:" con la recursión en la rama verdadera: el caso base es la lista vacía
+		if negated && isParam(condition) {
+			return []recursionGuard{{param: condition.Value, op: "empty"}}
+		}
+
+	case "BinaryOp":
+		op := condition.Value
+		if op == "and" || op == "or" {
+			return append(extractGuards(condition.Children[0], negated, params), extractGuards(condition.Children[1], negated, params)...)
+		}
+		if negated {
+			op = inverseComparison[op]
+		}
+		if mirroredComparison[op] == "" {
+			return nil
+		}
+
+		left, right := condition.Children[0], condition.Children[1]
+		value, isConstant := intLiteral(right)
+		if !isConstant {
+			if value, isConstant = intLiteral(left); !isConstant {
+				if op == "==" && isParam(left) && isEmptyLiteral(right) {
+					return []recursionGuard{{param: left.Value, op: "empty"}}
+				}
+				return nil
+			}
+			left, op = right, mirroredComparison[op]
+		}
+
+		if isParam(left) {
+			return []recursionGuard{{param: left.Value, op: op, value: value}}
+		}
+		if left.Type == "FunctionCall" && left.Value == "len" && len(left.Children) == 1 && isParam(left.Children[0]) {
+			return []recursionGuard{{param: left.Children[0].Value, op: op, value: value, length: true}}
+		}
+	}
+
+	return nil
+}
+
+func isEmptyLiteral(node *models.ASTNode) bool {
+	switch node.Type {
+	case "List", "Tuple", "Set", "Dict":
+		return len(node.Children) == 0
+	case "String":
+		return node.Value == ""
+	}
+	return false
+}
+
+// infos convierte el análisis al formato de la respuesta
+func (a *recursionAnalysis) infos() []models.RecursionInfo {
+	infos := make([]models.RecursionInfo, 0, len(a.functions))
+
+	for _, rf := range a.functions {
+		info := models.RecursionInfo{
+			Function:       rf.name,
+			Line:           rf.node.Line,
+			Kind:           rf.kind,
+			BaseCases:      make([]models.BaseCase, 0, len(rf.baseCases)),
+			RecursiveCalls: make([]models.RecursiveCall, 0, len(rf.calls)),
+		}
+		for _, member := range rf.cycle {
+			info.Cycle = append(info.Cycle, a.graph.names[member])
+		}
+
+		for _, base := range rf.baseCases {
+			returns := ""
+			if !base.loop {
+				returns = "None"
+				if base.returns != nil {
+					returns = formatSource(base.returns)
+				}
+			}
+			info.BaseCases = append(info.BaseCases, models.BaseCase{Line: base.line, Condition: base.condition, Returns: returns})
+		}
+
+		for _, call := range rf.calls {
+			recursive := models.RecursiveCall{
+				Callee:         a.graph.names[call.site.callee],
+				Line:           call.site.call.Line,
+				Arguments:      make([]models.ArgumentProgress, 0, len(call.args)),
+				TowardBaseCase: call.toward,
+			}
+			for _, step := range call.args {
+				recursive.Arguments = append(recursive.Arguments, models.ArgumentProgress{
+					Parameter:  step.param,
+					Expression: formatSource(step.arg),
+					Progress:   step.progress,
+				})
+			}
+			info.RecursiveCalls = append(info.RecursiveCalls, recursive)
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
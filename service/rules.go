@@ -0,0 +1,308 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"examen-back/models"
+)
+
+// Rule es una verificación semántica independiente. Cada regla habilitada
+// aporta un SemanticCheck (aprobado si no reporta nada) y sus diagnósticos.
+type Rule interface {
+	ID() string
+	Description() string
+	// Severity es la severidad por defecto de los diagnósticos de la regla
+	Severity() string
+	Check(ctx *RuleContext) []models.Diagnostic
+}
+
+// RuleContext reúne los resultados del análisis que comparten las reglas
+type RuleContext struct {
+	Tokens []models.Token
+	AST    *models.ASTNode
+
+	scopes    *scopeTree
+	graphs    []*controlFlowGraph
+	calls     *callGraph
+	recursion *recursionAnalysis
+	types     *typeInference
+	constants *constantFolding
+}
+
+// RuleConfig selecciona las reglas de una petición: Enable habilita reglas
+// desactivadas por defecto, Disable las quita y Severities cambia la
+// severidad ("error" o "warning") de todos los diagnósticos de una regla
+type RuleConfig struct {
+	Enable     []string
+	Disable    []string
+	Severities map[string]string
+}
+
+// semanticRule implementa Rule a partir de una función de verificación.
+// category es "general" o "exercise" (específica de los ejercicios; esas
+// reglas están desactivadas salvo que la petición elija un ejercicio).
+type semanticRule struct {
+	id          string
+	description string
+	severity    string
+	category    string
+	disabled    bool
+	check       func(ctx *RuleContext) []models.Diagnostic
+}
+
+func (r *semanticRule) ID() string          { return r.id }
+func (r *semanticRule) Description() string { return r.description }
+func (r *semanticRule) Severity() string    { return r.severity }
+
+func (r *semanticRule) Check(ctx *RuleContext) []models.Diagnostic {
+	return r.check(ctx)
+}
+
+type registeredRule struct {
+	rule     Rule
+	category string
+	enabled  bool
+}
+
+var ruleRegistry = make([]registeredRule, 0)
+
+// RegisterRule agrega una regla al registro; se ejecutan en orden de registro
+func RegisterRule(rule Rule, category string, enabled bool) {
+	for _, existing := range ruleRegistry {
+		if existing.rule.ID() == rule.ID() {
+			panic(fmt.Sprintf("regla '%s' registrada dos veces", rule.ID()))
+		}
+	}
+	ruleRegistry = append(ruleRegistry, registeredRule{rule: rule, category: category, enabled: enabled})
+}
+
+func registerRules(rules ...*semanticRule) {
+	for _, rule := range rules {
+		RegisterRule(rule, rule.category, !rule.disabled)
+	}
+}
+
+// RuleInfos describe las reglas registradas para el endpoint /rules
+func RuleInfos() []models.RuleInfo {
+	infos := make([]models.RuleInfo, 0, len(ruleRegistry))
+	for _, entry := range ruleRegistry {
+		infos = append(infos, models.RuleInfo{
+			ID:          entry.rule.ID(),
+			Description: entry.rule.Description(),
+			Severity:    entry.rule.Severity(),
+			Category:    entry.category,
+			Enabled:     entry.enabled,
+		})
+	}
+	return infos
+}
+
+// exerciseRules devuelve las reglas de la categoría "exercise", que se
+// habilitan al elegir un ejercicio
+func exerciseRules() []string {
+	ids := make([]string, 0)
+	for _, entry := range ruleRegistry {
+		if entry.category == "exercise" {
+			ids = append(ids, entry.rule.ID())
+		}
+	}
+	return ids
+}
+
+// selectRules aplica la configuración sobre el registro y valida los
+// identificadores y severidades pedidos
+func selectRules(config RuleConfig) ([]Rule, map[string]string, error) {
+	known := make(map[string]bool)
+	ids := make([]string, 0, len(ruleRegistry))
+	for _, entry := range ruleRegistry {
+		known[entry.rule.ID()] = true
+		ids = append(ids, entry.rule.ID())
+	}
+
+	unknown := make([]string, 0)
+	check := func(id string) {
+		if !known[id] {
+			unknown = append(unknown, fmt.Sprintf("'%s'%s", id, suggestName(id, ids)))
+		}
+	}
+	enabled := make(map[string]bool)
+	disabled := make(map[string]bool)
+	for _, id := range config.Enable {
+		check(id)
+		enabled[id] = true
+	}
+	for _, id := range config.Disable {
+		check(id)
+		disabled[id] = true
+	}
+
+	overrides := make([]string, 0, len(config.Severities))
+	for id := range config.Severities {
+		overrides = append(overrides, id)
+	}
+	sort.Strings(overrides)
+	for _, id := range overrides {
+		check(id)
+		if severity := config.Severities[id]; severity != "error" && severity != "warning" {
+			return nil, nil, fmt.Errorf("severidad '%s' inválida para la regla '%s'; use 'error' o 'warning'", severity, id)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, nil, fmt.Errorf("reglas desconocidas: %s", strings.Join(unknown, ", "))
+	}
+
+	rules := make([]Rule, 0, len(ruleRegistry))
+	for _, entry := range ruleRegistry {
+		id := entry.rule.ID()
+		if (entry.enabled || enabled[id]) && !disabled[id] {
+			rules = append(rules, entry.rule)
+		}
+	}
+	return rules, config.Severities, nil
+}
+
+func init() {
+	registerRules(
+		&semanticRule{
+			id:          "name-resolution",
+			description: "Los nombres usados están definidos y las importaciones existen",
+			severity:    "warning",
+			category:    "general",
+			check:       func(ctx *RuleContext) []models.Diagnostic { return ctx.scopes.diagnostics },
+		},
+		&semanticRule{
+			id:          "definition-order",
+			description: "Las funciones están definidas antes de llamarse",
+			severity:    "error",
+			category:    "general",
+			check:       checkDefinitionOrder,
+		},
+		&semanticRule{
+			id:          "definite-assignment",
+			description: "Las variables se asignan antes de usarse en todos los caminos",
+			severity:    "error",
+			category:    "general",
+			check: func(ctx *RuleContext) []models.Diagnostic {
+				return checkDefiniteAssignment(ctx.graphs, ctx.scopes)
+			},
+		},
+		&semanticRule{
+			id:          "unused-names",
+			description: "Las variables, parámetros, importaciones y funciones definidos se utilizan",
+			severity:    "warning",
+			category:    "general",
+			check:       checkUnusedNames,
+		},
+		&semanticRule{
+			id:          "shadowing",
+			description: "Los nombres locales no ocultan globales, su propia función ni builtins",
+			severity:    "warning",
+			category:    "general",
+			check:       checkShadowing,
+		},
+		&semanticRule{
+			id:          "unreachable-code",
+			description: "No hay código inalcanzable",
+			severity:    "warning",
+			category:    "general",
+			check: func(ctx *RuleContext) []models.Diagnostic {
+				return checkUnreachableCode(ctx.graphs)
+			},
+		},
+		&semanticRule{
+			id:          "return-paths",
+			description: "Las funciones devuelven un valor en todos los caminos",
+			severity:    "error",
+			category:    "general",
+			check: func(ctx *RuleContext) []models.Diagnostic {
+				return checkReturnPaths(ctx.graphs)
+			},
+		},
+		&semanticRule{
+			id:          "print-arguments",
+			description: "print recibe argumentos válidos",
+			severity:    "warning",
+			category:    "exercise",
+			disabled:    true,
+			check:       checkPrintArguments,
+		},
+		&semanticRule{
+			id:          "call-arity",
+			description: "Las llamadas pasan los argumentos que espera cada función",
+			severity:    "error",
+			category:    "general",
+			check: func(ctx *RuleContext) []models.Diagnostic {
+				return checkCallArity(ctx.AST, ctx.scopes)
+			},
+		},
+		&semanticRule{
+			id:          "recursion-required",
+			description: "Se detecta llamada recursiva correcta",
+			severity:    "warning",
+			category:    "exercise",
+			disabled:    true,
+			check:       checkRecursionRequired,
+		},
+		&semanticRule{
+			id:          "termination",
+			description: "Las funciones recursivas tienen un caso base alcanzable",
+			severity:    "error",
+			category:    "general",
+			check: func(ctx *RuleContext) []models.Diagnostic {
+				return checkTermination(ctx.recursion, ctx.constants)
+			},
+		},
+		&semanticRule{
+			id:          "type-check",
+			description: "Las operaciones y llamadas usan tipos compatibles",
+			severity:    "error",
+			category:    "general",
+			check:       func(ctx *RuleContext) []models.Diagnostic { return ctx.types.diagnostics },
+		},
+		&semanticRule{
+			id:          "division-by-zero",
+			description: "No hay divisiones cuyo divisor siempre vale cero",
+			severity:    "error",
+			category:    "general",
+			check:       checkDivisionByZero,
+		},
+		&semanticRule{
+			id:          "constant-condition",
+			description: "Las condiciones no son siempre verdaderas o siempre falsas",
+			severity:    "warning",
+			category:    "general",
+			check:       checkConstantConditions,
+		},
+		&semanticRule{
+			id:          "numeric-precision",
+			description: "Los productos enteros no se calculan en float y '/' no reemplaza a la división entera '//'",
+			severity:    "warning",
+			category:    "general",
+			check:       checkNumericPrecision,
+		},
+		&semanticRule{
+			id:          "int64-overflow",
+			description: "Los enteros calculados con las entradas literales del programa caben en 64 bits",
+			severity:    "warning",
+			category:    "general",
+			check:       checkInt64Overflow,
+		},
+		&semanticRule{
+			id:          "scope-collision",
+			description: "Las variables tienen alcance correcto (sin colisiones)",
+			severity:    "warning",
+			category:    "general",
+			check:       checkScopeCollisions,
+		},
+		&semanticRule{
+			id:          "x-n-collision",
+			description: "Variables 'x' y 'n' no colisionan (scopes separados)",
+			severity:    "warning",
+			category:    "exercise",
+			disabled:    true,
+			check:       checkXNCollision,
+		},
+	)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"examen-back/models"
+)
+
+// analyzeWith ejecuta el análisis semántico con una selección de reglas
+func analyzeWith(t *testing.T, code string, config RuleConfig) models.SemanticAnalysis {
+	t.Helper()
+	tokens := NewLexicalAnalyzer(code).Tokenize().Tokens
+	syntax := NewSyntaxAnalyzer(tokens).Analyze()
+	if !syntax.Valid {
+		t.Fatalf("errores de sintaxis: %v", syntax.Errors)
+	}
+	analyzer := NewSemanticAnalyzer(tokens, syntax.AST)
+	if err := analyzer.Configure(config); err != nil {
+		t.Fatalf("configuración inválida: %v", err)
+	}
+	return analyzer.Analyze()
+}
+
+// ruleCheck devuelve la comprobación de una regla o nil si no se ejecutó
+func ruleCheck(result models.SemanticAnalysis, id string) *models.SemanticCheck {
+	for i := range result.Checks {
+		if result.Checks[i].Rule == id {
+			return &result.Checks[i]
+		}
+	}
+	return nil
+}
+
+// lineLengthRule es una regla externa, desactivada por defecto, que usa solo
+// la interfaz Rule
+type lineLengthRule struct{}
+
+func (lineLengthRule) ID() string          { return "test-long-lines" }
+func (lineLengthRule) Description() string { return "Las líneas tienen como máximo 20 caracteres" }
+func (lineLengthRule) Severity() string    { return "warning" }
+
+func (lineLengthRule) Check(ctx *RuleContext) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+	for _, token := range ctx.Tokens {
+		if token.Col > 20 {
+			diagnostics = append(diagnostics, models.Diagnostic{Code: "long-line", Message: "línea larga", Line: token.Line})
+			break
+		}
+	}
+	return diagnostics
+}
+
+var registerTestRule sync.Once
+
+func TestRegisteredRuleIsOptIn(t *testing.T) {
+	registerTestRule.Do(func() { RegisterRule(lineLengthRule{}, "general", false) })
+	const code = "print('una línea bastante larga')\n"
+
+	if result := analyzeWith(t, code, RuleConfig{}); ruleCheck(result, "test-long-lines") != nil {
+		t.Fatalf("una regla desactivada no debe ejecutarse por defecto")
+	}
+	result := analyzeWith(t, code, RuleConfig{Enable: []string{"test-long-lines"}})
+	check := ruleCheck(result, "test-long-lines")
+	if check == nil || check.Passed || check.Line != 1 {
+		t.Fatalf("la regla habilitada debe fallar en la línea 1: %+v", check)
+	}
+	found := diagnosticsWithCode(result, "long-line")
+	if len(found) != 1 || found[0].Rule != "test-long-lines" || found[0].Severity != "warning" {
+		t.Errorf("el diagnóstico debe llevar la regla y su severidad por defecto: %v", found)
+	}
+}
+
+func TestRuleSelection(t *testing.T) {
+	const code = "def f(n):\n    x = 1\n    return n\n\nprint(f(2))\n"
+
+	result := analyzeWith(t, code, RuleConfig{})
+	if check := ruleCheck(result, "unused-names"); check == nil || check.Passed || check.Line != 2 {
+		t.Fatalf("unused-names debe fallar en la línea 2: %+v", check)
+	}
+	if check := ruleCheck(result, "termination"); check == nil || !check.Passed {
+		t.Errorf("termination debe ejecutarse y aprobar: %+v", check)
+	}
+	if found := diagnosticsWithCode(result, "unused-variable"); len(found) != 1 || found[0].Severity != "warning" || !result.Valid {
+		t.Fatalf("se esperaba una advertencia por 'x': %v", found)
+	}
+
+	result = analyzeWith(t, code, RuleConfig{Severities: map[string]string{"unused-names": "error"}})
+	if found := diagnosticsWithCode(result, "unused-variable"); len(found) != 1 || found[0].Severity != "error" || result.Valid {
+		t.Errorf("la severidad cambiada debe convertir la advertencia en error: %v (válido %v)", found, result.Valid)
+	}
+
+	result = analyzeWith(t, code, RuleConfig{Disable: []string{"unused-names"}})
+	if ruleCheck(result, "unused-names") != nil || len(diagnosticsWithCode(result, "unused-variable")) > 0 {
+		t.Errorf("una regla deshabilitada no aporta comprobación ni diagnósticos")
+	}
+}
+
+func TestRuleConfigErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  RuleConfig
+		message string
+	}{
+		{"regla desconocida", RuleConfig{Disable: []string{"unused-name"}}, "'unused-name'; ¿quisiste decir 'unused-names'?"},
+		{"severidad inválida", RuleConfig{Severities: map[string]string{"termination": "info"}}, "severidad 'info' inválida"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewSemanticAnalyzer(nil, nil).Configure(tc.config)
+			if err == nil || !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("se esperaba un error con %q: %v", tc.message, err)
+			}
+		})
+	}
+}
+
+func TestExerciseRulesRequireAnExercise(t *testing.T) {
+	const code = "x = 3\nprint(x)\n"
+	exerciseRules := []string{"print-arguments", "recursion-required", "x-n-collision"}
+
+	result := analyze(t, code)
+	for _, id := range exerciseRules {
+		if ruleCheck(result, id) != nil {
+			t.Errorf("'%s' no debe ejecutarse sin un ejercicio", id)
+		}
+	}
+	if found := diagnosticsWithCode(result, "missing-recursion"); len(found) > 0 {
+		t.Errorf("un programa sin recursión es válido fuera de un ejercicio: %v", found)
+	}
+
+	result = analyzeExercise(t, "factorial", code)
+	for _, id := range exerciseRules {
+		if ruleCheck(result, id) == nil {
+			t.Errorf("'%s' debe ejecutarse en el ejercicio factorial", id)
+		}
+	}
+	if found := diagnosticsWithCode(result, "missing-recursion"); len(found) != 1 {
+		t.Errorf("el ejercicio exige recursión: %v", found)
+	}
+
+	// fibonacci deshabilita dos de ellas en su especificación
+	result = analyzeExercise(t, "fibonacci", code)
+	if ruleCheck(result, "recursion-required") == nil || ruleCheck(result, "x-n-collision") != nil || ruleCheck(result, "print-arguments") != nil {
+		t.Errorf("fibonacci solo ejecuta recursion-required: %+v", result.Checks)
+	}
+
+	result = analyzeWith(t, code, RuleConfig{Enable: []string{"recursion-required"}})
+	if check := ruleCheck(result, "recursion-required"); check == nil || check.Passed {
+		t.Errorf("la petición puede habilitar una regla de ejercicio sin elegir uno: %+v", check)
+	}
+}
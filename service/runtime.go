@@ -0,0 +1,1062 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"examen-back/models"
+)
+
+// pyValue es un valor en tiempo de ejecución. Los escalares (int, float, str,
+// bool y None) son constValue, compartidos con el plegado de constantes; los
+// contenedores, funciones, clases e instancias son punteros, de modo que la
+// identidad y la mutación se comportan como en Python.
+type pyValue interface{}
+
+type pyList struct{ items []pyValue }
+
+type pyTuple struct{ items []pyValue }
+
+// pyDict conserva el orden de inserción; index va de la clave normalizada
+// (hashKey) a su posición en keys y values
+type pyDict struct {
+	keys   []pyValue
+	values []pyValue
+	index  map[string]int
+}
+
+type pySet struct {
+	items []pyValue
+	index map[string]int
+}
+
+type pyRange struct{ start, stop, step int64 }
+
+type pySlice struct{ start, stop, step pyValue }
+
+// pyView es el resultado de dict.keys(), dict.values() o dict.items()
+type pyView struct {
+	kind string
+	dict *pyDict
+}
+
+// pyIterator es un iterador perezoso: map, filter, zip, enumerate, iter()...
+type pyIterator struct {
+	name string
+	next func() (pyValue, bool, error)
+}
+
+// pyFunction es una función o lambda del programa. closure es el frame donde se
+// definió (saltando los cuerpos de clase) y defaults guarda los valores por
+// defecto, que se evalúan al ejecutar el def.
+type pyFunction struct {
+	name     string
+	qualname string
+	node     *models.ASTNode
+	scope    *scope
+	closure  *frame
+	defaults map[*models.ASTNode]pyValue
+	class    *pyClass
+}
+
+type keywordArg struct {
+	name  string
+	value pyValue
+}
+
+type pyBuiltin struct {
+	name string
+	call func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error)
+}
+
+// pyClass es una clase del programa o un tipo integrado (int, list,
+// ValueError...). Los tipos integrados construyen sus instancias con construct.
+type pyClass struct {
+	name      string
+	bases     []*pyClass
+	mro       []*pyClass
+	attrs     map[string]pyValue
+	construct func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error)
+	builtin   bool
+}
+
+type pyInstance struct {
+	class *pyClass
+	attrs map[string]pyValue
+}
+
+// pyMethod es una función del programa ligada a su receptor
+type pyMethod struct {
+	self     pyValue
+	function *pyFunction
+}
+
+// pySuper resuelve atributos a partir de la clase siguiente a class en el MRO de self
+type pySuper struct {
+	class *pyClass
+	self  pyValue
+}
+
+type pyModule struct {
+	name  string
+	attrs map[string]pyValue
+}
+
+// pyCached envuelve una función con functools.lru_cache o functools.cache
+type pyCached struct {
+	function pyValue
+	cache    map[string]pyValue
+	hits     int
+	misses   int
+}
+
+// Tamaños aproximados usados para contabilizar la memoria asignada
+const (
+	objectBytes = 64
+	slotBytes   = 16
+)
+
+func isNone(value pyValue) bool {
+	v, ok := value.(constValue)
+	return ok && v.kind == "None"
+}
+
+func newList(items []pyValue) *pyList   { return &pyList{items: items} }
+func newTuple(items []pyValue) *pyTuple { return &pyTuple{items: items} }
+func newDict() *pyDict                  { return &pyDict{index: make(map[string]int)} }
+func newSet() *pySet                    { return &pySet{index: make(map[string]int)} }
+
+func (c *pyClass) lookup(name string) (pyValue, bool) {
+	for _, class := range c.mro {
+		if value, ok := class.attrs[name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (c *pyClass) isSubclass(other *pyClass) bool {
+	for _, class := range c.mro {
+		if class == other {
+			return true
+		}
+	}
+	return false
+}
+
+// computeMRO linealiza la jerarquía: recorrido en profundidad de izquierda a
+// derecha conservando la última aparición de cada clase, que coincide con C3
+// en las jerarquías habituales (incluido el diamante)
+func computeMRO(class *pyClass) []*pyClass {
+	order := make([]*pyClass, 0)
+	var walk func(c *pyClass)
+	walk = func(c *pyClass) {
+		order = append(order, c)
+		for _, base := range c.bases {
+			walk(base)
+		}
+	}
+	walk(class)
+
+	last := make(map[*pyClass]int)
+	for i, c := range order {
+		last[c] = i
+	}
+	mro := make([]*pyClass, 0, len(last))
+	for i, c := range order {
+		if last[c] == i {
+			mro = append(mro, c)
+		}
+	}
+	return mro
+}
+
+// typeName es el nombre del tipo de un valor, como type(x).__name__
+func typeName(value pyValue) string {
+	switch v := value.(type) {
+	case constValue:
+		if v.kind == "None" {
+			return "NoneType"
+		}
+		return v.kind
+	case *pyList:
+		return "list"
+	case *pyTuple:
+		return "tuple"
+	case *pyDict:
+		return "dict"
+	case *pySet:
+		return "set"
+	case *pyRange:
+		return "range"
+	case *pySlice:
+		return "slice"
+	case *pyView:
+		return "dict_" + v.kind
+	case *pyIterator:
+		return v.name
+	case *pyFunction, *pyCached:
+		return "function"
+	case *pyBuiltin:
+		return "builtin_function_or_method"
+	case *pyMethod:
+		return "method"
+	case *pyClass:
+		return "type"
+	case *pyInstance:
+		return v.class.name
+	case *pySuper:
+		return "super"
+	case *pyModule:
+		return "module"
+	}
+	return "object"
+}
+
+// address imita la dirección que Python muestra en el repr por defecto
+func address(value pyValue) string {
+	return fmt.Sprintf("%p", value)
+}
+
+func (d *pyDict) get(key string) (pyValue, bool) {
+	if position, ok := d.index[key]; ok {
+		return d.values[position], true
+	}
+	return nil, false
+}
+
+func (d *pyDict) set(key string, k, v pyValue) {
+	if position, ok := d.index[key]; ok {
+		d.values[position] = v
+		return
+	}
+	d.index[key] = len(d.keys)
+	d.keys = append(d.keys, k)
+	d.values = append(d.values, v)
+}
+
+func (d *pyDict) remove(key string) (pyValue, bool) {
+	position, ok := d.index[key]
+	if !ok {
+		return nil, false
+	}
+	value := d.values[position]
+	d.keys = append(d.keys[:position], d.keys[position+1:]...)
+	d.values = append(d.values[:position], d.values[position+1:]...)
+	delete(d.index, key)
+	for k, p := range d.index {
+		if p > position {
+			d.index[k] = p - 1
+		}
+	}
+	return value, true
+}
+
+func (s *pySet) add(key string, item pyValue) {
+	if _, ok := s.index[key]; ok {
+		return
+	}
+	s.index[key] = len(s.items)
+	s.items = append(s.items, item)
+}
+
+func (s *pySet) remove(key string) bool {
+	position, ok := s.index[key]
+	if !ok {
+		return false
+	}
+	s.items = append(s.items[:position], s.items[position+1:]...)
+	delete(s.index, key)
+	for k, p := range s.index {
+		if p > position {
+			s.index[k] = p - 1
+		}
+	}
+	return true
+}
+
+func (r *pyRange) length() int64 {
+	switch {
+	case r.step > 0 && r.start < r.stop:
+		return (r.stop - r.start + r.step - 1) / r.step
+	case r.step < 0 && r.start > r.stop:
+		return (r.start - r.stop - r.step - 1) / -r.step
+	}
+	return 0
+}
+
+// hashKey normaliza un valor hashable para usarlo como clave de dict o set:
+// 1, 1.0 y True son la misma clave, como en Python
+func (in *Interpreter) hashKey(value pyValue) (string, error) {
+	if err := in.nest(); err != nil {
+		return "", err
+	}
+	defer in.unnest()
+
+	switch v := value.(type) {
+	case constValue:
+		switch v.kind {
+		case "int", "bool":
+			return "n:" + v.toInt().String(), nil
+		case "float":
+			if v.f == math.Trunc(v.f) && !math.IsInf(v.f, 0) {
+				i, _ := big.NewFloat(v.f).Int(nil)
+				return "n:" + i.String(), nil
+			}
+			return "f:" + strconv.FormatFloat(v.f, 'g', -1, 64), nil
+		case "str":
+			return "s:" + v.s, nil
+		}
+		return "None", nil
+	case *pyTuple:
+		var key strings.Builder
+		key.WriteString("t(")
+		for _, item := range v.items {
+			part, err := in.hashKey(item)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&key, "%d:%s", len(part), part)
+		}
+		key.WriteString(")")
+		return key.String(), nil
+	case *pyList, *pyDict, *pySet:
+		return "", in.raise("TypeError", "unhashable type: '%s'", typeName(value))
+	case *pyInstance:
+		if _, ok := v.class.lookup("__hash__"); ok {
+			hash, err := in.callSpecial(v, "__hash__")
+			if err != nil {
+				return "", err
+			}
+			if number, ok := hash.(constValue); ok && number.isIntegral() {
+				return "h:" + number.toInt().String(), nil
+			}
+			return "", in.raise("TypeError", "__hash__ method should return an integer")
+		}
+		if _, ok := v.class.lookup("__eq__"); ok {
+			return "", in.raise("TypeError", "unhashable type: '%s'", v.class.name)
+		}
+	}
+	return "p:" + address(value), nil
+}
+
+// truthy aplica las reglas de verdad de Python, incluidos __bool__ y __len__
+func (in *Interpreter) truthy(value pyValue) (bool, error) {
+	switch v := value.(type) {
+	case constValue:
+		return v.truthy(), nil
+	case *pyList:
+		return len(v.items) > 0, nil
+	case *pyTuple:
+		return len(v.items) > 0, nil
+	case *pyDict:
+		return len(v.keys) > 0, nil
+	case *pySet:
+		return len(v.items) > 0, nil
+	case *pyRange:
+		return v.length() > 0, nil
+	case *pyView:
+		return len(v.dict.keys) > 0, nil
+	case *pyInstance:
+		for _, name := range []string{"__bool__", "__len__"} {
+			if _, ok := v.class.lookup(name); !ok {
+				continue
+			}
+			result, err := in.callSpecial(v, name)
+			if err != nil {
+				return false, err
+			}
+			if number, ok := result.(constValue); ok && (number.isIntegral()) {
+				return number.truthy(), nil
+			}
+			return false, in.raise("TypeError", "%s should return %s", name, map[string]string{"__bool__": "bool", "__len__": "an integer"}[name])
+		}
+	}
+	return true, nil
+}
+
+// identical implementa el operador is. Los escalares iguales del mismo tipo se
+// consideran el mismo objeto, como los enteros pequeños y las cadenas internadas.
+func identical(a, b pyValue) bool {
+	left, okA := a.(constValue)
+	right, okB := b.(constValue)
+	if okA || okB {
+		if !okA || !okB || left.kind != right.kind {
+			return false
+		}
+		equal, err := constCompare("==", left, right)
+		return err == nil && equal.b
+	}
+	return a == b
+}
+
+// equal implementa ==, con __eq__ para las instancias
+func (in *Interpreter) equal(a, b pyValue) (bool, error) {
+	if err := in.nest(); err != nil {
+		return false, err
+	}
+	defer in.unnest()
+
+	if left, ok := a.(constValue); ok {
+		if right, ok := b.(constValue); ok {
+			result, err := constCompare("==", left, right)
+			return err == nil && result.b, nil
+		}
+	}
+
+	switch left := a.(type) {
+	case *pyList:
+		if right, ok := b.(*pyList); ok {
+			return in.equalItems(left.items, right.items)
+		}
+	case *pyTuple:
+		if right, ok := b.(*pyTuple); ok {
+			return in.equalItems(left.items, right.items)
+		}
+	case *pyDict:
+		if right, ok := b.(*pyDict); ok {
+			if len(left.keys) != len(right.keys) {
+				return false, nil
+			}
+			for key, position := range left.index {
+				other, found := right.get(key)
+				if !found {
+					return false, nil
+				}
+				if same, err := in.equal(left.values[position], other); err != nil || !same {
+					return false, err
+				}
+			}
+			return true, nil
+		}
+	case *pySet:
+		if right, ok := b.(*pySet); ok {
+			return len(left.items) == len(right.items) && subset(left, right), nil
+		}
+	case *pyRange:
+		if right, ok := b.(*pyRange); ok {
+			return left.length() == right.length() && (left.length() == 0 || left.start == right.start && (left.length() == 1 || left.step == right.step)), nil
+		}
+	}
+
+	for _, pair := range [][2]pyValue{{a, b}, {b, a}} {
+		if instance, ok := pair[0].(*pyInstance); ok {
+			if _, defined := instance.class.lookup("__eq__"); defined {
+				result, err := in.callSpecial(instance, "__eq__", pair[1])
+				if err != nil {
+					return false, err
+				}
+				return in.truthy(result)
+			}
+		}
+	}
+	return identical(a, b), nil
+}
+
+func (in *Interpreter) equalItems(left, right []pyValue) (bool, error) {
+	if len(left) != len(right) {
+		return false, nil
+	}
+	for i := range left {
+		if identical(left[i], right[i]) {
+			continue
+		}
+		if same, err := in.equal(left[i], right[i]); err != nil || !same {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func subset(a, b *pySet) bool {
+	for key := range a.index {
+		if _, ok := b.index[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// reflected es el método especial que Python prueba con los operandos invertidos
+var reflected = map[string]string{"__lt__": "__gt__", "__le__": "__ge__", "__gt__": "__lt__", "__ge__": "__le__"}
+
+var comparisonMethods = map[string]string{"<": "__lt__", "<=": "__le__", ">": "__gt__", ">=": "__ge__"}
+
+// compare implementa <, <=, > y >=. Las secuencias se comparan
+// lexicográficamente y los conjuntos por inclusión.
+func (in *Interpreter) compare(op string, a, b pyValue) (bool, error) {
+	if err := in.nest(); err != nil {
+		return false, err
+	}
+	defer in.unnest()
+
+	if left, ok := a.(constValue); ok {
+		if right, ok := b.(constValue); ok {
+			result, err := constCompare(op, left, right)
+			if err == nil {
+				return result.b, nil
+			}
+		}
+	}
+
+	var left, right []pyValue
+	switch l := a.(type) {
+	case *pyList:
+		if r, ok := b.(*pyList); ok {
+			left, right = l.items, r.items
+		}
+	case *pyTuple:
+		if r, ok := b.(*pyTuple); ok {
+			left, right = l.items, r.items
+		}
+	case *pySet:
+		if r, ok := b.(*pySet); ok {
+			switch op {
+			case "<=":
+				return subset(l, r), nil
+			case "<":
+				return subset(l, r) && len(l.items) < len(r.items), nil
+			case ">=":
+				return subset(r, l), nil
+			default:
+				return subset(r, l) && len(l.items) > len(r.items), nil
+			}
+		}
+	}
+	if left != nil || right != nil || isSequencePair(a, b) {
+		for i := 0; i < len(left) && i < len(right); i++ {
+			same, err := in.equal(left[i], right[i])
+			if err != nil {
+				return false, err
+			}
+			if !same {
+				return in.compare(op, left[i], right[i])
+			}
+		}
+		result, _ := constCompare(op, smallInt(int64(len(left))), smallInt(int64(len(right))))
+		return result.b, nil
+	}
+
+	method := comparisonMethods[op]
+	if instance, ok := a.(*pyInstance); ok {
+		if _, defined := instance.class.lookup(method); defined {
+			result, err := in.callSpecial(instance, method, b)
+			if err != nil {
+				return false, err
+			}
+			return in.truthy(result)
+		}
+	}
+	if instance, ok := b.(*pyInstance); ok {
+		if _, defined := instance.class.lookup(reflected[method]); defined {
+			result, err := in.callSpecial(instance, reflected[method], a)
+			if err != nil {
+				return false, err
+			}
+			return in.truthy(result)
+		}
+	}
+	return false, in.raise("TypeError", "'%s' not supported between instances of '%s' and '%s'", op, typeName(a), typeName(b))
+}
+
+// isSequencePair reconoce dos listas o dos tuplas vacías, que compare no
+// distingue de "sin secuencias" por tener ambos slices en nil
+func isSequencePair(a, b pyValue) bool {
+	_, listA := a.(*pyList)
+	_, listB := b.(*pyList)
+	_, tupleA := a.(*pyTuple)
+	_, tupleB := b.(*pyTuple)
+	return listA && listB || tupleA && tupleB
+}
+
+// contains implementa el operador in
+func (in *Interpreter) contains(container, item pyValue) (bool, error) {
+	switch c := container.(type) {
+	case constValue:
+		if c.kind == "str" {
+			needle, ok := item.(constValue)
+			if !ok || needle.kind != "str" {
+				return false, in.raise("TypeError", "'in <string>' requires string as left operand, not %s", typeName(item))
+			}
+			return strings.Contains(c.s, needle.s), nil
+		}
+	case *pyDict, *pySet, *pyView:
+		if view, ok := c.(*pyView); ok && view.kind != "keys" {
+			break
+		}
+		key, err := in.hashKey(item)
+		if err != nil {
+			return false, err
+		}
+		switch c := c.(type) {
+		case *pyDict:
+			_, found := c.index[key]
+			return found, nil
+		case *pySet:
+			_, found := c.index[key]
+			return found, nil
+		case *pyView:
+			_, found := c.dict.index[key]
+			return found, nil
+		}
+	case *pyRange:
+		if number, ok := item.(constValue); ok && number.isIntegral() && number.toInt().IsInt64() {
+			n := number.toInt().Int64()
+			if c.step > 0 {
+				return n >= c.start && n < c.stop && (n-c.start)%c.step == 0, nil
+			}
+			return n <= c.start && n > c.stop && (c.start-n)%(-c.step) == 0, nil
+		}
+	case *pyInstance:
+		if _, defined := c.class.lookup("__contains__"); defined {
+			result, err := in.callSpecial(c, "__contains__", item)
+			if err != nil {
+				return false, err
+			}
+			return in.truthy(result)
+		}
+	}
+
+	found := false
+	err := in.iterate(container, func(element pyValue) (bool, error) {
+		if identical(element, item) {
+			found = true
+			return false, nil
+		}
+		same, err := in.equal(element, item)
+		found = same
+		return !same, err
+	})
+	return found, err
+}
+
+// iterator devuelve la función next de un iterable. Las listas se recorren en
+// vivo, como en Python; los dict detectan cambios de tamaño durante el recorrido.
+func (in *Interpreter) iterator(value pyValue) (func() (pyValue, bool, error), error) {
+	switch v := value.(type) {
+	case constValue:
+		if v.kind == "str" {
+			position := 0
+			return func() (pyValue, bool, error) {
+				if position >= len(v.s) {
+					return nil, false, nil
+				}
+				r, size := utf8.DecodeRuneInString(v.s[position:])
+				position += size
+				return strValue(string(r)), true, nil
+			}, nil
+		}
+	case *pyList:
+		return sliceIterator(func() []pyValue { return v.items }), nil
+	case *pyTuple:
+		return sliceIterator(func() []pyValue { return v.items }), nil
+	case *pySet:
+		items := append([]pyValue(nil), v.items...)
+		size := len(v.items)
+		next := sliceIterator(func() []pyValue { return items })
+		return func() (pyValue, bool, error) {
+			if len(v.items) != size {
+				return nil, false, in.raise("RuntimeError", "Set changed size during iteration")
+			}
+			return next()
+		}, nil
+	case *pyDict:
+		return in.dictIterator(v, "keys"), nil
+	case *pyView:
+		return in.dictIterator(v.dict, v.kind), nil
+	case *pyRange:
+		current, remaining := v.start, v.length()
+		return func() (pyValue, bool, error) {
+			if remaining <= 0 {
+				return nil, false, nil
+			}
+			value := smallInt(current)
+			current += v.step
+			remaining--
+			return value, true, nil
+		}, nil
+	case *pyIterator:
+		return v.next, nil
+	case *pyInstance:
+		if _, defined := v.class.lookup("__iter__"); defined {
+			iter, err := in.callSpecial(v, "__iter__")
+			if err != nil {
+				return nil, err
+			}
+			if iter == value {
+				return in.instanceNext(v)
+			}
+			return in.iterator(iter)
+		}
+		if _, defined := v.class.lookup("__next__"); defined {
+			return in.instanceNext(v)
+		}
+		if _, defined := v.class.lookup("__getitem__"); defined {
+			index := int64(0)
+			return func() (pyValue, bool, error) {
+				item, err := in.callSpecial(v, "__getitem__", smallInt(index))
+				if exception, ok := err.(*pyException); ok && exception.is("IndexError", "StopIteration") {
+					return nil, false, nil
+				}
+				index++
+				return item, err == nil, err
+			}, nil
+		}
+	}
+	return nil, in.raise("TypeError", "'%s' object is not iterable", typeName(value))
+}
+
+func sliceIterator(items func() []pyValue) func() (pyValue, bool, error) {
+	position := 0
+	return func() (pyValue, bool, error) {
+		current := items()
+		if position >= len(current) {
+			return nil, false, nil
+		}
+		position++
+		return current[position-1], true, nil
+	}
+}
+
+func (in *Interpreter) dictIterator(d *pyDict, kind string) func() (pyValue, bool, error) {
+	position, size := 0, len(d.keys)
+	return func() (pyValue, bool, error) {
+		if len(d.keys) != size {
+			return nil, false, in.raise("RuntimeError", "dictionary changed size during iteration")
+		}
+		if position >= len(d.keys) {
+			return nil, false, nil
+		}
+		position++
+		switch kind {
+		case "values":
+			return d.values[position-1], true, nil
+		case "items":
+			return newTuple([]pyValue{d.keys[position-1], d.values[position-1]}), true, nil
+		}
+		return d.keys[position-1], true, nil
+	}
+}
+
+// instanceNext recorre un iterador definido por el programa hasta StopIteration
+func (in *Interpreter) instanceNext(instance *pyInstance) (func() (pyValue, bool, error), error) {
+	if _, defined := instance.class.lookup("__next__"); !defined {
+		return nil, in.raise("TypeError", "iter() returned non-iterator of type '%s'", instance.class.name)
+	}
+	return func() (pyValue, bool, error) {
+		item, err := in.callSpecial(instance, "__next__")
+		if exception, ok := err.(*pyException); ok && exception.is("StopIteration") {
+			return nil, false, nil
+		}
+		return item, err == nil, err
+	}, nil
+}
+
+// iterate recorre un iterable; visit devuelve false para detener el recorrido.
+// Cada elemento cuenta como un paso de ejecución.
+func (in *Interpreter) iterate(value pyValue, visit func(pyValue) (bool, error)) error {
+	next, err := in.iterator(value)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := in.tick(); err != nil {
+			return err
+		}
+		item, ok, err := next()
+		if err != nil || !ok {
+			return err
+		}
+		more, err := visit(item)
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+// collect materializa un iterable en un slice nuevo
+func (in *Interpreter) collect(value pyValue) ([]pyValue, error) {
+	items := make([]pyValue, 0)
+	err := in.iterate(value, func(item pyValue) (bool, error) {
+		if err := in.allocate(slotBytes); err != nil {
+			return false, err
+		}
+		items = append(items, item)
+		return true, nil
+	})
+	return items, err
+}
+
+// length implementa len()
+func (in *Interpreter) length(value pyValue) (int64, error) {
+	switch v := value.(type) {
+	case constValue:
+		if v.kind == "str" {
+			return int64(utf8.RuneCountInString(v.s)), nil
+		}
+	case *pyList:
+		return int64(len(v.items)), nil
+	case *pyTuple:
+		return int64(len(v.items)), nil
+	case *pyDict:
+		return int64(len(v.keys)), nil
+	case *pySet:
+		return int64(len(v.items)), nil
+	case *pyView:
+		return int64(len(v.dict.keys)), nil
+	case *pyRange:
+		return v.length(), nil
+	case *pyInstance:
+		if _, defined := v.class.lookup("__len__"); defined {
+			result, err := in.callSpecial(v, "__len__")
+			if err != nil {
+				return 0, err
+			}
+			if number, ok := result.(constValue); ok && number.isIntegral() && number.toInt().IsInt64() {
+				if number.toInt().Sign() < 0 {
+					return 0, in.raise("ValueError", "__len__() should return >= 0")
+				}
+				return number.toInt().Int64(), nil
+			}
+			return 0, in.raise("TypeError", "'%s' object cannot be interpreted as an integer", typeName(result))
+		}
+	}
+	return 0, in.raise("TypeError", "object of type '%s' has no len()", typeName(value))
+}
+
+// intString convierte un entero a decimal respetando el límite de dígitos de
+// Python 3.11+ (sys.set_int_max_str_digits)
+func (in *Interpreter) intString(i *big.Int) (string, error) {
+	// log2(10) ≈ 3.32: con más bits seguro se supera el límite sin convertir
+	if in.maxStrDigits > 0 && float64(i.BitLen()) > float64(in.maxStrDigits)*3.33+4 {
+		return "", in.raise("ValueError", "Exceeds the limit (%d digits) for integer string conversion; use sys.set_int_max_str_digits() to increase the limit", in.maxStrDigits)
+	}
+	if err := in.affordable("La conversión a decimal de un entero tan grande", decimalCost(i.BitLen())); err != nil {
+		return "", err
+	}
+	text := i.String()
+	if digits := len(strings.TrimPrefix(text, "-")); in.maxStrDigits > 0 && digits > in.maxStrDigits {
+		return "", in.raise("ValueError", "Exceeds the limit (%d digits) for integer string conversion; use sys.set_int_max_str_digits() to increase the limit", in.maxStrDigits)
+	}
+	return text, nil
+}
+
+// str implementa str(), con __str__ para las instancias
+func (in *Interpreter) str(value pyValue) (string, error) {
+	switch v := value.(type) {
+	case constValue:
+		if v.kind == "int" {
+			return in.intString(v.i)
+		}
+		return v.Str(), nil
+	case *pyInstance:
+		if _, ok := v.class.lookup("__str__"); ok {
+			return in.specialString(v, "__str__")
+		}
+		if v.class.isSubclass(in.exceptionBase) {
+			return in.exceptionString(v)
+		}
+	}
+	return in.repr(value)
+}
+
+func (in *Interpreter) specialString(instance *pyInstance, name string) (string, error) {
+	result, err := in.callSpecial(instance, name)
+	if err != nil {
+		return "", err
+	}
+	text, ok := result.(constValue)
+	if !ok || text.kind != "str" {
+		return "", in.raise("TypeError", "%s returned non-string (type %s)", name, typeName(result))
+	}
+	return text.s, nil
+}
+
+// exceptionString es str(excepción): el único argumento, nada si no hay
+// argumentos o la tupla completa si hay varios
+func (in *Interpreter) exceptionString(instance *pyInstance) (string, error) {
+	args := exceptionArgs(instance)
+	switch len(args) {
+	case 0:
+		return "", nil
+	case 1:
+		if instance.class.isSubclass(in.builtinClass("KeyError")) {
+			return in.repr(args[0])
+		}
+		return in.str(args[0])
+	}
+	return in.repr(newTuple(args))
+}
+
+func exceptionArgs(instance *pyInstance) []pyValue {
+	if args, ok := instance.attrs["args"].(*pyTuple); ok {
+		return args.items
+	}
+	return nil
+}
+
+// repr implementa repr(). visiting evita la recursión infinita en contenedores
+// que se contienen a sí mismos, que Python muestra como [...]
+func (in *Interpreter) repr(value pyValue) (string, error) {
+	if err := in.nest(); err != nil {
+		return "", err
+	}
+	defer in.unnest()
+
+	switch value.(type) {
+	case *pyList, *pyDict, *pySet:
+		if in.visiting[value] {
+			if _, ok := value.(*pyList); ok {
+				return "[...]", nil
+			}
+			return "{...}", nil
+		}
+		in.visiting[value] = true
+		defer delete(in.visiting, value)
+	}
+
+	switch v := value.(type) {
+	case constValue:
+		if v.kind == "int" {
+			return in.intString(v.i)
+		}
+		return v.Repr(), nil
+	case *pyList:
+		return in.reprItems("[", v.items, "]")
+	case *pyTuple:
+		if len(v.items) == 1 {
+			return in.reprItems("(", v.items, ",)")
+		}
+		return in.reprItems("(", v.items, ")")
+	case *pySet:
+		if len(v.items) == 0 {
+			return "set()", nil
+		}
+		return in.reprItems("{", v.items, "}")
+	case *pyDict:
+		parts := make([]string, len(v.keys))
+		for i := range v.keys {
+			key, err := in.repr(v.keys[i])
+			if err != nil {
+				return "", err
+			}
+			item, err := in.repr(v.values[i])
+			if err != nil {
+				return "", err
+			}
+			parts[i] = key + ": " + item
+		}
+		return "{" + strings.Join(parts, ", ") + "}", in.expired()
+	case *pyView:
+		items, err := in.collect(v)
+		if err != nil {
+			return "", err
+		}
+		return in.reprItems("dict_"+v.kind+"([", items, "])")
+	case *pyRange:
+		if v.step == 1 {
+			return fmt.Sprintf("range(%d, %d)", v.start, v.stop), nil
+		}
+		return fmt.Sprintf("range(%d, %d, %d)", v.start, v.stop, v.step), nil
+	case *pySlice:
+		return in.reprItems("slice(", []pyValue{v.start, v.stop, v.step}, ")")
+	case *pyIterator:
+		return fmt.Sprintf("<%s object at %s>", v.name, address(v)), nil
+	case *pyFunction:
+		return fmt.Sprintf("<function %s at %s>", v.qualname, address(v)), nil
+	case *pyCached:
+		return fmt.Sprintf("<functools._lru_cache_wrapper object at %s>", address(v)), nil
+	case *pyBuiltin:
+		return fmt.Sprintf("<built-in function %s>", v.name), nil
+	case *pyMethod:
+		self, err := in.repr(v.self)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<bound method %s of %s>", v.function.qualname, self), nil
+	case *pyClass:
+		if v.builtin {
+			return fmt.Sprintf("<class '%s'>", v.name), nil
+		}
+		return fmt.Sprintf("<class '__main__.%s'>", v.name), nil
+	case *pyModule:
+		return fmt.Sprintf("<module '%s' (built-in)>", v.name), nil
+	case *pySuper:
+		return fmt.Sprintf("<super: <class '%s'>, <%s object>>", v.class.name, typeName(v.self)), nil
+	case *pyInstance:
+		if _, ok := v.class.lookup("__repr__"); ok {
+			return in.specialString(v, "__repr__")
+		}
+		if v.class.isSubclass(in.exceptionBase) {
+			args, err := in.reprItems("(", exceptionArgs(v), ")")
+			return v.class.name + args, err
+		}
+		return fmt.Sprintf("<__main__.%s object at %s>", v.class.name, address(v)), nil
+	}
+	return fmt.Sprintf("<object at %s>", address(value)), nil
+}
+
+func (in *Interpreter) reprItems(open string, items []pyValue, close string) (string, error) {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		text, err := in.repr(item)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = text
+	}
+	// Cada nivel copia el texto de los de dentro: con contenedores muy
+	// anidados el coste es cuadrático y hay que vigilar el reloj
+	return open + strings.Join(parts, ", ") + close, in.expired()
+}
+
+// sortValues ordena in place con el orden de Python (estable), opcionalmente
+// por una clave calculada con key
+func (in *Interpreter) sortValues(items []pyValue, key pyValue, reverse bool) error {
+	keys := items
+	if key != nil && !isNone(key) {
+		keys = make([]pyValue, len(items))
+		for i, item := range items {
+			value, err := in.call(key, []pyValue{item}, nil)
+			if err != nil {
+				return err
+			}
+			keys[i] = value
+		}
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	var failure error
+	sort.SliceStable(order, func(i, j int) bool {
+		if failure != nil {
+			return false
+		}
+		a, b := keys[order[i]], keys[order[j]]
+		if reverse {
+			a, b = b, a
+		}
+		less, err := in.compare("<", a, b)
+		if err != nil {
+			failure = err
+		}
+		return less
+	})
+	if failure != nil {
+		return failure
+	}
+
+	sorted := make([]pyValue, len(items))
+	for i, position := range order {
+		sorted[i] = items[position]
+	}
+	copy(items, sorted)
+	return nil
+}
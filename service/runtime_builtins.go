@@ -0,0 +1,2005 @@
+package service
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type builtinFunc func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error)
+
+// exceptionHierarchy define las excepciones integradas y su clase base
+var exceptionHierarchy = [][2]string{
+	{"BaseException", "object"},
+	{"SystemExit", "BaseException"},
+	{"KeyboardInterrupt", "BaseException"},
+	{"Exception", "BaseException"},
+	{"ArithmeticError", "Exception"},
+	{"ZeroDivisionError", "ArithmeticError"},
+	{"OverflowError", "ArithmeticError"},
+	{"AssertionError", "Exception"},
+	{"AttributeError", "Exception"},
+	{"EOFError", "Exception"},
+	{"ImportError", "Exception"},
+	{"ModuleNotFoundError", "ImportError"},
+	{"LookupError", "Exception"},
+	{"IndexError", "LookupError"},
+	{"KeyError", "LookupError"},
+	{"MemoryError", "Exception"},
+	{"NameError", "Exception"},
+	{"UnboundLocalError", "NameError"},
+	{"OSError", "Exception"},
+	{"PermissionError", "OSError"},
+	{"FileNotFoundError", "OSError"},
+	{"RuntimeError", "Exception"},
+	{"NotImplementedError", "RuntimeError"},
+	{"RecursionError", "RuntimeError"},
+	{"StopIteration", "Exception"},
+	{"SyntaxError", "Exception"},
+	{"TypeError", "Exception"},
+	{"ValueError", "Exception"},
+}
+
+// builtinClass devuelve el tipo integrado con ese nombre, creándolo si es un
+// tipo interno sin constructor (function, list_iterator...)
+func (in *Interpreter) builtinClass(name string) *pyClass {
+	if class, ok := in.classes[name]; ok {
+		return class
+	}
+	return in.defineBuiltinClass(name, in.objectClass, func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		return nil, in.raise("TypeError", "cannot create '%s' instances", name)
+	})
+}
+
+func (in *Interpreter) defineBuiltinClass(name string, base *pyClass, construct builtinFunc) *pyClass {
+	class := &pyClass{name: name, attrs: make(map[string]pyValue), construct: construct, builtin: true}
+	if base != nil {
+		class.bases = []*pyClass{base}
+	}
+	class.mro = computeMRO(class)
+	in.classes[name] = class
+	return class
+}
+
+// typeOf es type(x)
+func (in *Interpreter) typeOf(value pyValue) *pyClass {
+	switch v := value.(type) {
+	case *pyInstance:
+		return v.class
+	case *pyClass:
+		return in.classes["type"]
+	}
+	return in.builtinClass(typeName(value))
+}
+
+func (in *Interpreter) setupBuiltins() {
+	in.classes = make(map[string]*pyClass)
+	in.builtins = make(map[string]pyValue)
+
+	in.objectClass = in.defineBuiltinClass("object", nil, func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity("object", args, kwargs, 0, 0); err != nil {
+			return nil, err
+		}
+		return &pyInstance{class: in.objectClass, attrs: make(map[string]pyValue)}, nil
+	})
+	integer := in.defineBuiltinClass("int", in.objectClass, constructInt)
+	in.defineBuiltinClass("bool", integer, func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity("bool", args, kwargs, 0, 1); err != nil || len(args) == 0 {
+			return boolValue(false), err
+		}
+		truth, err := in.truthy(args[0])
+		return boolValue(truth), err
+	})
+	in.defineBuiltinClass("float", in.objectClass, constructFloat)
+	in.defineBuiltinClass("str", in.objectClass, func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity("str", args, kwargs, 0, 1); err != nil || len(args) == 0 {
+			return strValue(""), err
+		}
+		text, err := in.str(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return strValue(text), in.allocateValue(strValue(text))
+	})
+	in.defineBuiltinClass("list", in.objectClass, func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity("list", args, kwargs, 0, 1); err != nil || len(args) == 0 {
+			return newList([]pyValue{}), err
+		}
+		items, err := in.collect(args[0])
+		return newList(items), err
+	})
+	in.defineBuiltinClass("tuple", in.objectClass, func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity("tuple", args, kwargs, 0, 1); err != nil || len(args) == 0 {
+			return newTuple([]pyValue{}), err
+		}
+		if tuple, ok := args[0].(*pyTuple); ok {
+			return tuple, nil
+		}
+		items, err := in.collect(args[0])
+		return newTuple(items), err
+	})
+	in.defineBuiltinClass("dict", in.objectClass, constructDict)
+	in.defineBuiltinClass("set", in.objectClass, func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity("set", args, kwargs, 0, 1); err != nil || len(args) == 0 {
+			return newSet(), err
+		}
+		items, err := in.collect(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return in.makeSet(items)
+	})
+	in.defineBuiltinClass("range", in.objectClass, constructRange)
+	in.defineBuiltinClass("type", in.objectClass, func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if len(args) == 3 {
+			return nil, in.raise("NotImplementedError", "type() con tres argumentos no está soportado por el intérprete")
+		}
+		if err := in.arity("type", args, kwargs, 1, 1); err != nil {
+			return nil, err
+		}
+		return in.typeOf(args[0]), nil
+	})
+	in.builtinClass("NoneType")
+
+	for _, entry := range exceptionHierarchy {
+		in.defineBuiltinClass(entry[0], in.classes[entry[1]], nil)
+	}
+	in.exceptionBase = in.classes["BaseException"]
+	in.exceptionBase.attrs["__init__"] = &pyBuiltin{name: "__init__", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if self, ok := args[0].(*pyInstance); ok {
+			self.attrs["args"] = newTuple(append([]pyValue(nil), args[1:]...))
+		}
+		return noneValue(), nil
+	}}
+
+	// Los nombres integrados son los del catálogo que usa el analizador, para
+	// que los dos coincidan; lo que el intérprete no implementa lanza
+	// NotImplementedError al llamarlo
+	for name := range builtinFunctions {
+		class, isClass := in.classes[name]
+		function, implemented := builtinFunctionTable[name]
+		switch {
+		case name == "__name__":
+			// Es una variable del módulo, no un builtin
+		case isClass:
+			in.builtins[name] = class
+		case implemented:
+			in.builtins[name] = &pyBuiltin{name: name, call: function}
+		default:
+			in.builtins[name] = &pyBuiltin{name: name, call: builtinUnsupported(name)}
+		}
+	}
+}
+
+// builtinFunctionTable y sandboxModules se completan en init para evitar un
+// ciclo de inicialización. builtinFunctionTable implementa las funciones del
+// catálogo builtinFunctions. sandboxModules son los módulos que el intérprete
+// implementa; el resto de la biblioteca estándar no está disponible.
+var (
+	builtinFunctionTable map[string]builtinFunc
+	sandboxModules       map[string]func(in *Interpreter) *pyModule
+)
+
+func init() {
+	sandboxModules = map[string]func(in *Interpreter) *pyModule{
+		"math":      mathModule,
+		"sys":       sysModule,
+		"functools": functoolsModule,
+		"random":    randomModule,
+		"string":    stringModule,
+	}
+	builtinFunctionTable = map[string]builtinFunc{
+		"print":  builtinPrint,
+		"input":  builtinInput,
+		"len":    builtinLen,
+		"abs":    builtinAbs,
+		"round":  builtinRound,
+		"pow":    builtinPow,
+		"divmod": builtinDivmod,
+		"max": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.extreme("max", ">", args, kwargs)
+		},
+		"min": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.extreme("min", "<", args, kwargs)
+		},
+		"sum":       builtinSum,
+		"sorted":    builtinSorted,
+		"reversed":  builtinReversed,
+		"enumerate": builtinEnumerate,
+		"zip":       builtinZip,
+		"map":       builtinMap,
+		"filter":    builtinFilter,
+		"iter":      builtinIter,
+		"next":      builtinNext,
+		"all": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.quantifier("all", args, kwargs)
+		},
+		"any": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.quantifier("any", args, kwargs)
+		},
+		"isinstance": builtinIsinstance,
+		"issubclass": builtinIssubclass,
+		"hasattr":    builtinHasattr,
+		"getattr":    builtinGetattr,
+		"setattr":    builtinSetattr,
+		"callable":   builtinCallable,
+		"id":         builtinID,
+		"hash":       builtinHash,
+		"chr":        builtinChr,
+		"ord":        builtinOrd,
+		"bin": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.radix("bin", 2, "0b", args, kwargs)
+		},
+		"oct": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.radix("oct", 8, "0o", args, kwargs)
+		},
+		"hex": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.radix("hex", 16, "0x", args, kwargs)
+		},
+		"repr":   builtinRepr,
+		"format": builtinFormat,
+		"super":  builtinSuper,
+		"open":   builtinOpen,
+		"exit":   builtinExit,
+		"quit":   builtinExit,
+	}
+}
+
+func builtinUnsupported(name string) builtinFunc {
+	return func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		return nil, in.raise("NotImplementedError", "'%s' no está soportado por el intérprete", name)
+	}
+}
+
+// arity valida la cantidad de argumentos posicionales de un builtin sin
+// argumentos por nombre; maximum < 0 indica sin máximo
+func (in *Interpreter) arity(name string, args []pyValue, kwargs []keywordArg, minimum, maximum int) error {
+	if len(kwargs) > 0 {
+		return in.raise("TypeError", "%s() takes no keyword arguments", name)
+	}
+	return in.positional(name, args, minimum, maximum)
+}
+
+func (in *Interpreter) positional(name string, args []pyValue, minimum, maximum int) error {
+	switch {
+	case minimum == maximum && len(args) != minimum:
+		return in.raise("TypeError", "%s() takes exactly %d argument%s (%d given)", name, minimum, plural(minimum), len(args))
+	case len(args) < minimum:
+		return in.raise("TypeError", "%s expected at least %d argument%s, got %d", name, minimum, plural(minimum), len(args))
+	case maximum >= 0 && len(args) > maximum:
+		return in.raise("TypeError", "%s expected at most %d argument%s, got %d", name, maximum, plural(maximum), len(args))
+	}
+	return nil
+}
+
+// keywords valida los argumentos por nombre de un builtin y los devuelve en un mapa
+func (in *Interpreter) keywords(name string, kwargs []keywordArg, allowed ...string) (map[string]pyValue, error) {
+	values := make(map[string]pyValue)
+	for _, kwarg := range kwargs {
+		known := false
+		for _, candidate := range allowed {
+			known = known || candidate == kwarg.name
+		}
+		if !known {
+			return nil, in.raise("TypeError", "%s() got an unexpected keyword argument '%s'", name, kwarg.name)
+		}
+		values[kwarg.name] = kwarg.value
+	}
+	return values, nil
+}
+
+// intArg exige un entero (o bool), como los argumentos de range o chr
+func (in *Interpreter) intArg(value pyValue) (*big.Int, error) {
+	if number, ok := value.(constValue); ok && number.isIntegral() {
+		return number.toInt(), nil
+	}
+	return nil, in.raise("TypeError", "'%s' object cannot be interpreted as an integer", typeName(value))
+}
+
+func (in *Interpreter) int64Arg(value pyValue) (int64, error) {
+	i, err := in.intArg(value)
+	if err != nil {
+		return 0, err
+	}
+	if !i.IsInt64() {
+		return 0, in.raise("OverflowError", "Python int too large to convert to C ssize_t")
+	}
+	return i.Int64(), nil
+}
+
+func (in *Interpreter) floatArg(value pyValue) (float64, error) {
+	if number, ok := value.(constValue); ok && number.isNumber() {
+		f, ok := number.toFloat()
+		if !ok {
+			return 0, in.raise("OverflowError", "int too large to convert to float")
+		}
+		return f, nil
+	}
+	return 0, in.raise("TypeError", "must be real number, not %s", typeName(value))
+}
+
+func (in *Interpreter) strArg(name string, value pyValue) (string, error) {
+	if text, ok := value.(constValue); ok && text.kind == "str" {
+		return text.s, nil
+	}
+	return "", in.raise("TypeError", "%s() argument must be str, not %s", name, typeName(value))
+}
+
+func constructInt(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords("int", kwargs, "base")
+	if err != nil {
+		return nil, err
+	}
+	if err := in.positional("int", args, 0, 2); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return smallInt(0), nil
+	}
+	base := int64(10)
+	explicitBase := len(args) == 2 || options["base"] != nil
+	if len(args) == 2 {
+		options["base"] = args[1]
+	}
+	if value, ok := options["base"]; ok {
+		if base, err = in.int64Arg(value); err != nil {
+			return nil, err
+		}
+		if base != 0 && (base < 2 || base > 36) {
+			return nil, in.raise("ValueError", "int() base must be >= 2 and <= 36, or 0")
+		}
+	}
+
+	switch v := args[0].(type) {
+	case constValue:
+		switch {
+		case v.kind == "str":
+			return in.parseInt(v.s, int(base))
+		case explicitBase:
+			return nil, in.raise("TypeError", "int() can't convert non-string with explicit base")
+		case v.isIntegral():
+			return intValue(v.toInt()), nil
+		case v.kind == "float":
+			if math.IsInf(v.f, 0) {
+				return nil, in.raise("OverflowError", "cannot convert float infinity to integer")
+			}
+			if math.IsNaN(v.f) {
+				return nil, in.raise("ValueError", "cannot convert float NaN to integer")
+			}
+			i, _ := big.NewFloat(math.Trunc(v.f)).Int(nil)
+			return intValue(i), nil
+		}
+	case *pyInstance:
+		if _, ok := v.class.lookup("__int__"); ok {
+			return in.callSpecial(v, "__int__")
+		}
+	}
+	return nil, in.raise("TypeError", "int() argument must be a string, a bytes-like object or a real number, not '%s'", typeName(args[0]))
+}
+
+// parseInt convierte texto a entero como int(texto, base), con el límite de dígitos de Python 3.11+
+func (in *Interpreter) parseInt(text string, base int) (pyValue, error) {
+	invalid := func() error {
+		return in.raise("ValueError", "invalid literal for int() with base %d: %s", base, quoteString(text))
+	}
+	clean := strings.TrimSpace(text)
+	sign := ""
+	if strings.HasPrefix(clean, "-") || strings.HasPrefix(clean, "+") {
+		sign, clean = clean[:1], clean[1:]
+	}
+	prefixes := map[string]int{"0b": 2, "0o": 8, "0x": 16}
+	if len(clean) > 2 {
+		if prefixBase, ok := prefixes[strings.ToLower(clean[:2])]; ok && (base == 0 || base == prefixBase) {
+			clean, base = clean[2:], prefixBase
+			clean = strings.TrimPrefix(clean, "_")
+		}
+	}
+	if base == 0 {
+		if len(clean) > 1 && strings.Trim(clean, "0_") != "" && clean[0] == '0' {
+			return nil, invalid()
+		}
+		base = 10
+	}
+	if clean == "" || strings.HasPrefix(clean, "_") || strings.HasSuffix(clean, "_") || strings.Contains(clean, "__") {
+		return nil, invalid()
+	}
+	clean = strings.ReplaceAll(clean, "_", "")
+	if base == 10 && in.maxStrDigits > 0 && len(clean) > in.maxStrDigits {
+		return nil, in.raise("ValueError", "Exceeds the limit (%d digits) for integer string conversion: value has %d digits; use sys.set_int_max_str_digits() to increase the limit", in.maxStrDigits, len(clean))
+	}
+	// Solo las bases que no son potencia de dos tienen coste cuadrático
+	if base&(base-1) != 0 {
+		digits := float64(len(clean))
+		if err := in.affordable("La lectura de un entero de tantos dígitos", nsPerParsedDigit2*digits*digits); err != nil {
+			return nil, err
+		}
+	}
+	i, ok := new(big.Int).SetString(sign+clean, base)
+	if !ok {
+		return nil, invalid()
+	}
+	return intValue(i), nil
+}
+
+func constructFloat(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("float", args, kwargs, 0, 1); err != nil || len(args) == 0 {
+		return floatValue(0), err
+	}
+	switch v := args[0].(type) {
+	case constValue:
+		if v.kind == "str" {
+			text := strings.ToLower(strings.TrimSpace(v.s))
+			switch strings.TrimLeft(text, "+-") {
+			case "inf", "infinity", "nan":
+				f, _ := strconv.ParseFloat(text, 64)
+				return floatValue(f), nil
+			}
+			valid := text != "" && !strings.HasPrefix(text, "0x") && !strings.Contains(text, "__") && !strings.HasPrefix(text, "_") && !strings.HasSuffix(text, "_")
+			if f, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64); valid && (err == nil || math.IsInf(f, 0)) {
+				return floatValue(f), nil
+			}
+			return nil, in.raise("ValueError", "could not convert string to float: %s", quoteString(v.s))
+		}
+		if v.isNumber() {
+			f, err := in.floatArg(v)
+			return floatValue(f), err
+		}
+	case *pyInstance:
+		if _, ok := v.class.lookup("__float__"); ok {
+			return in.callSpecial(v, "__float__")
+		}
+	}
+	return nil, in.raise("TypeError", "float() argument must be a string or a real number, not '%s'", typeName(args[0]))
+}
+
+func constructDict(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.positional("dict", args, 0, 1); err != nil {
+		return nil, err
+	}
+	dict := newDict()
+	if err := in.dictUpdate(dict, args, kwargs); err != nil {
+		return nil, err
+	}
+	return dict, in.allocate(objectBytes)
+}
+
+func constructRange(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("range", args, kwargs, 1, 3); err != nil {
+		return nil, err
+	}
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		value, err := in.int64Arg(arg)
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = value
+	}
+	switch len(bounds) {
+	case 1:
+		return &pyRange{start: 0, stop: bounds[0], step: 1}, nil
+	case 2:
+		return &pyRange{start: bounds[0], stop: bounds[1], step: 1}, nil
+	}
+	if bounds[2] == 0 {
+		return nil, in.raise("ValueError", "range() arg 3 must not be zero")
+	}
+	return &pyRange{start: bounds[0], stop: bounds[1], step: bounds[2]}, nil
+}
+
+// stream es el destino de print(file=...): la salida estándar, la de errores
+// o cualquier objeto con un método write
+func (in *Interpreter) stream(target pyValue, text string) error {
+	if target == nil || isNone(target) {
+		return in.write(text)
+	}
+	write, err := in.getAttr(target, "write")
+	if err != nil {
+		return err
+	}
+	_, err = in.call(write, []pyValue{strValue(text)}, nil)
+	return err
+}
+
+func builtinPrint(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords("print", kwargs, "sep", "end", "file", "flush")
+	if err != nil {
+		return nil, err
+	}
+	separator, end := " ", "\n"
+	for name, target := range map[string]*string{"sep": &separator, "end": &end} {
+		value, ok := options[name]
+		if !ok || isNone(value) {
+			continue
+		}
+		text, ok := value.(constValue)
+		if !ok || text.kind != "str" {
+			return nil, in.raise("TypeError", "%s must be None or a string, not %s", name, typeName(value))
+		}
+		*target = text.s
+	}
+
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if parts[i], err = in.str(arg); err != nil {
+			return nil, err
+		}
+	}
+	return noneValue(), in.stream(options["file"], strings.Join(parts, separator)+end)
+}
+
+// builtinInput lee la siguiente línea de la entrada estándar de la petición
+func builtinInput(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("input", args, kwargs, 0, 1); err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		prompt, err := in.str(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := in.write(prompt); err != nil {
+			return nil, err
+		}
+	}
+	line, ok := in.readLine()
+	if !ok {
+		return nil, in.raise("EOFError", "EOF when reading a line")
+	}
+	return strValue(strings.TrimRight(line, "\r\n")), nil
+}
+
+func (in *Interpreter) readLine() (string, bool) {
+	if len(in.stdin) == 0 {
+		return "", false
+	}
+	line := in.stdin[0]
+	in.stdin = in.stdin[1:]
+	return line, true
+}
+
+func builtinLen(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("len", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	n, err := in.length(args[0])
+	return smallInt(n), err
+}
+
+func builtinAbs(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("abs", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case constValue:
+		switch {
+		case v.kind == "float":
+			return floatValue(math.Abs(v.f)), nil
+		case v.isIntegral():
+			return intValue(new(big.Int).Abs(v.toInt())), nil
+		}
+	case *pyInstance:
+		if _, ok := v.class.lookup("__abs__"); ok {
+			return in.callSpecial(v, "__abs__")
+		}
+	}
+	return nil, in.raise("TypeError", "bad operand type for abs(): '%s'", typeName(args[0]))
+}
+
+// builtinRound redondea al par más cercano, como Python; con ndigits el
+// redondeo es el del valor binario exacto (round(2.675, 2) == 2.67)
+func builtinRound(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords("round", kwargs, "ndigits")
+	if err != nil {
+		return nil, err
+	}
+	if err := in.positional("round", args, 1, 2); err != nil {
+		return nil, err
+	}
+	if len(args) == 2 {
+		options["ndigits"] = args[1]
+	}
+	number, ok := args[0].(constValue)
+	if !ok || !number.isNumber() {
+		if instance, ok := args[0].(*pyInstance); ok {
+			if _, defined := instance.class.lookup("__round__"); defined {
+				return in.callSpecial(instance, "__round__")
+			}
+		}
+		return nil, in.raise("TypeError", "type %s doesn't define __round__ method", typeName(args[0]))
+	}
+
+	digits, hasDigits := options["ndigits"]
+	if !hasDigits || isNone(digits) {
+		if number.isIntegral() {
+			return intValue(number.toInt()), nil
+		}
+		if math.IsInf(number.f, 0) {
+			return nil, in.raise("OverflowError", "cannot convert float infinity to integer")
+		}
+		if math.IsNaN(number.f) {
+			return nil, in.raise("ValueError", "cannot convert float NaN to integer")
+		}
+		i, _ := big.NewFloat(math.RoundToEven(number.f)).Int(nil)
+		return intValue(i), nil
+	}
+
+	n, err := in.int64Arg(digits)
+	if err != nil {
+		return nil, err
+	}
+	if number.isIntegral() {
+		if n >= 0 {
+			return intValue(number.toInt()), nil
+		}
+		// Redondeo a decenas, centenas...: mitad al par
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(-n), nil)
+		quotient, remainder := floorDivMod(number.toInt(), scale)
+		twice := new(big.Int).Mul(remainder, big.NewInt(2))
+		if cmp := twice.Cmp(scale); cmp > 0 || cmp == 0 && quotient.Bit(0) == 1 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+		return intValue(quotient.Mul(quotient, scale)), nil
+	}
+	if math.IsInf(number.f, 0) || math.IsNaN(number.f) || n > 300 {
+		return number, nil
+	}
+	if n < 0 {
+		scale := math.Pow(10, float64(-n))
+		return floatValue(math.RoundToEven(number.f/scale) * scale), nil
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(number.f, 'f', int(n), 64), 64)
+	return floatValue(rounded), nil
+}
+
+func builtinPow(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords("pow", kwargs, "base", "exp", "mod")
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range []string{"base", "exp", "mod"} {
+		if i < len(args) {
+			options[name] = args[i]
+		}
+	}
+	base, exp := options["base"], options["exp"]
+	if base == nil || exp == nil || len(args) > 3 {
+		return nil, in.raise("TypeError", "pow() takes from 2 to 3 positional arguments but %d were given", len(args))
+	}
+	mod, hasMod := options["mod"]
+	if !hasMod || isNone(mod) {
+		return in.binary("**", base, exp)
+	}
+
+	b, okB := base.(constValue)
+	e, okE := exp.(constValue)
+	m, okM := mod.(constValue)
+	if !okB || !okE || !okM || !b.isIntegral() || !e.isIntegral() || !m.isIntegral() {
+		return nil, in.raise("TypeError", "pow() 3rd argument not allowed unless all arguments are integers")
+	}
+	if m.toInt().Sign() == 0 {
+		return nil, in.raise("ValueError", "pow() 3rd argument cannot be 0")
+	}
+	modulus := new(big.Int).Abs(m.toInt())
+	value := b.toInt()
+	exponent := e.toInt()
+	if exponent.Sign() < 0 {
+		inverse := new(big.Int).ModInverse(new(big.Int).Mod(value, modulus), modulus)
+		if inverse == nil {
+			return nil, in.raise("ValueError", "base is not invertible for the given modulus")
+		}
+		value, exponent = inverse, new(big.Int).Neg(exponent)
+	}
+	if err := in.affordable("pow() con enteros tan grandes", powModCost(exponent, modulus)); err != nil {
+		return nil, err
+	}
+	result := new(big.Int).Exp(value, exponent, modulus)
+	// El resultado toma el signo del módulo, como %
+	if m.toInt().Sign() < 0 && result.Sign() != 0 {
+		result.Add(result, m.toInt())
+	}
+	return intValue(result), nil
+}
+
+func builtinDivmod(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("divmod", args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	quotient, err := in.binary("//", args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	remainder, err := in.binary("%", args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	return newTuple([]pyValue{quotient, remainder}), nil
+}
+
+// extreme implementa max y min: sobre un iterable o sobre varios argumentos,
+// con key y default
+func (in *Interpreter) extreme(name, op string, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords(name, kwargs, "key", "default")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, in.raise("TypeError", "%s expected at least 1 argument, got 0", name)
+	}
+	items := args
+	if len(args) == 1 {
+		if items, err = in.collect(args[0]); err != nil {
+			return nil, err
+		}
+	} else if _, ok := options["default"]; ok {
+		return nil, in.raise("TypeError", "Cannot specify a default for %s() with multiple positional arguments", name)
+	}
+	if len(items) == 0 {
+		if value, ok := options["default"]; ok {
+			return value, nil
+		}
+		return nil, in.raise("ValueError", "%s() iterable argument is empty", name)
+	}
+
+	key := options["key"]
+	score := func(item pyValue) (pyValue, error) {
+		if key == nil || isNone(key) {
+			return item, nil
+		}
+		return in.call(key, []pyValue{item}, nil)
+	}
+	best := items[0]
+	bestScore, err := score(best)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items[1:] {
+		itemScore, err := score(item)
+		if err != nil {
+			return nil, err
+		}
+		better, err := in.compare(op, itemScore, bestScore)
+		if err != nil {
+			return nil, err
+		}
+		if better {
+			best, bestScore = item, itemScore
+		}
+	}
+	return best, nil
+}
+
+func builtinSum(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords("sum", kwargs, "start")
+	if err != nil {
+		return nil, err
+	}
+	if err := in.positional("sum", args, 1, 2); err != nil {
+		return nil, err
+	}
+	var total pyValue = smallInt(0)
+	if len(args) == 2 {
+		total = args[1]
+	} else if start, ok := options["start"]; ok {
+		total = start
+	}
+	if text, ok := total.(constValue); ok && text.kind == "str" {
+		return nil, in.raise("TypeError", "sum() can't sum strings [use ''.join(seq) instead]")
+	}
+	err = in.iterate(args[0], func(item pyValue) (bool, error) {
+		var err error
+		total, err = in.binary("+", total, item)
+		return true, err
+	})
+	return total, err
+}
+
+func builtinSorted(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords("sorted", kwargs, "key", "reverse")
+	if err != nil {
+		return nil, err
+	}
+	if err := in.positional("sorted", args, 1, 1); err != nil {
+		return nil, err
+	}
+	items, err := in.collect(args[0])
+	if err != nil {
+		return nil, err
+	}
+	reverse := false
+	if value, ok := options["reverse"]; ok {
+		if reverse, err = in.truthy(value); err != nil {
+			return nil, err
+		}
+	}
+	return newList(items), in.sortValues(items, options["key"], reverse)
+}
+
+func builtinReversed(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("reversed", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	var items []pyValue
+	switch v := args[0].(type) {
+	case *pyList:
+		items = v.items
+	case *pyTuple:
+		items = v.items
+	case *pyRange:
+		reversed := &pyRange{start: v.start + (v.length()-1)*v.step, stop: v.start - v.step, step: -v.step}
+		next, _ := in.iterator(reversed)
+		return &pyIterator{name: "range_iterator", next: next}, nil
+	case constValue:
+		if v.kind != "str" {
+			return nil, in.raise("TypeError", "'%s' object is not reversible", typeName(v))
+		}
+		chars, _ := in.collect(v)
+		items = chars
+	case *pyDict:
+		items = v.keys
+	case *pyInstance:
+		if _, ok := v.class.lookup("__reversed__"); ok {
+			return in.callSpecial(v, "__reversed__")
+		}
+		count, err := in.length(v)
+		if err != nil {
+			return nil, in.raise("TypeError", "'%s' object is not reversible", typeName(v))
+		}
+		position := count
+		return &pyIterator{name: "reversed", next: func() (pyValue, bool, error) {
+			if position <= 0 {
+				return nil, false, nil
+			}
+			position--
+			item, err := in.getItem(v, smallInt(position))
+			return item, err == nil, err
+		}}, nil
+	default:
+		return nil, in.raise("TypeError", "'%s' object is not reversible", typeName(args[0]))
+	}
+	position := len(items)
+	return &pyIterator{name: "list_reverseiterator", next: func() (pyValue, bool, error) {
+		if position <= 0 || position > len(items) {
+			return nil, false, nil
+		}
+		position--
+		return items[position], true, nil
+	}}, nil
+}
+
+func builtinEnumerate(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords("enumerate", kwargs, "start")
+	if err != nil {
+		return nil, err
+	}
+	if err := in.positional("enumerate", args, 1, 2); err != nil {
+		return nil, err
+	}
+	start := pyValue(smallInt(0))
+	if len(args) == 2 {
+		start = args[1]
+	} else if value, ok := options["start"]; ok {
+		start = value
+	}
+	counter, err := in.intArg(start)
+	if err != nil {
+		return nil, err
+	}
+	counter = new(big.Int).Set(counter)
+	next, err := in.iterator(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &pyIterator{name: "enumerate", next: func() (pyValue, bool, error) {
+		item, ok, err := next()
+		if !ok || err != nil {
+			return nil, false, err
+		}
+		index := intValue(new(big.Int).Set(counter))
+		counter.Add(counter, big.NewInt(1))
+		return newTuple([]pyValue{index, item}), true, nil
+	}}, nil
+}
+
+func builtinZip(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	options, err := in.keywords("zip", kwargs, "strict")
+	if err != nil {
+		return nil, err
+	}
+	strict := false
+	if value, ok := options["strict"]; ok {
+		if strict, err = in.truthy(value); err != nil {
+			return nil, err
+		}
+	}
+	nexts := make([]func() (pyValue, bool, error), len(args))
+	for i, arg := range args {
+		if nexts[i], err = in.iterator(arg); err != nil {
+			return nil, err
+		}
+	}
+	return &pyIterator{name: "zip", next: func() (pyValue, bool, error) {
+		if len(nexts) == 0 {
+			return nil, false, nil
+		}
+		items := make([]pyValue, 0, len(nexts))
+		for i, next := range nexts {
+			item, ok, err := next()
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				if strict && (i > 0 || anyRemaining(nexts[1:])) {
+					return nil, false, in.raise("ValueError", "zip() argument %d is shorter than the others", max(i+1, 2))
+				}
+				return nil, false, nil
+			}
+			items = append(items, item)
+		}
+		return newTuple(items), true, nil
+	}}, nil
+}
+
+func anyRemaining(nexts []func() (pyValue, bool, error)) bool {
+	for _, next := range nexts {
+		if _, ok, _ := next(); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func builtinMap(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("map", args, kwargs, 2, -1); err != nil {
+		return nil, err
+	}
+	function := args[0]
+	zipped, err := builtinZip(in, args[1:], nil)
+	if err != nil {
+		return nil, err
+	}
+	next := zipped.(*pyIterator).next
+	return &pyIterator{name: "map", next: func() (pyValue, bool, error) {
+		group, ok, err := next()
+		if !ok || err != nil {
+			return nil, false, err
+		}
+		value, err := in.call(function, group.(*pyTuple).items, nil)
+		return value, err == nil, err
+	}}, nil
+}
+
+func builtinFilter(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("filter", args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	function := args[0]
+	next, err := in.iterator(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return &pyIterator{name: "filter", next: func() (pyValue, bool, error) {
+		for {
+			item, ok, err := next()
+			if !ok || err != nil {
+				return nil, false, err
+			}
+			verdict := item
+			if !isNone(function) {
+				if verdict, err = in.call(function, []pyValue{item}, nil); err != nil {
+					return nil, false, err
+				}
+			}
+			truth, err := in.truthy(verdict)
+			if err != nil {
+				return nil, false, err
+			}
+			if truth {
+				return item, true, nil
+			}
+			if err := in.tick(); err != nil {
+				return nil, false, err
+			}
+		}
+	}}, nil
+}
+
+// iteratorNames son los nombres de tipo que Python da a los iteradores de iter()
+var iteratorNames = map[string]string{
+	"list": "list_iterator", "tuple": "tuple_iterator", "str": "str_ascii_iterator",
+	"dict": "dict_keyiterator", "set": "set_iterator", "range": "range_iterator",
+	"dict_keys": "dict_keyiterator", "dict_values": "dict_valueiterator", "dict_items": "dict_itemiterator",
+}
+
+func builtinIter(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("iter", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case *pyIterator:
+		return v, nil
+	case *pyInstance:
+		if _, ok := v.class.lookup("__iter__"); ok {
+			return in.callSpecial(v, "__iter__")
+		}
+	}
+	next, err := in.iterator(args[0])
+	if err != nil {
+		return nil, err
+	}
+	name := iteratorNames[typeName(args[0])]
+	if name == "" {
+		name = "iterator"
+	}
+	return &pyIterator{name: name, next: next}, nil
+}
+
+func builtinNext(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("next", args, kwargs, 1, 2); err != nil {
+		return nil, err
+	}
+	var item pyValue
+	var ok bool
+	var err error
+	switch v := args[0].(type) {
+	case *pyIterator:
+		item, ok, err = v.next()
+	case *pyInstance:
+		if _, defined := v.class.lookup("__next__"); !defined {
+			return nil, in.raise("TypeError", "'%s' object is not an iterator", typeName(v))
+		}
+		item, err = in.callSpecial(v, "__next__")
+		ok = err == nil
+		if exception, stop := err.(*pyException); stop && exception.is("StopIteration") && len(args) == 2 {
+			return args[1], nil
+		}
+	default:
+		return nil, in.raise("TypeError", "'%s' object is not an iterator", typeName(args[0]))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return nil, in.throw(&pyInstance{class: in.builtinClass("StopIteration"), attrs: map[string]pyValue{"args": newTuple(nil)}})
+	}
+	return item, nil
+}
+
+func (in *Interpreter) quantifier(name string, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity(name, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	// all busca un falso y any un verdadero
+	wanted := name == "any"
+	found := false
+	err := in.iterate(args[0], func(item pyValue) (bool, error) {
+		truth, err := in.truthy(item)
+		if truth == wanted {
+			found = true
+		}
+		return !found, err
+	})
+	return boolValue(found == wanted), err
+}
+
+// classes extrae las clases de un argumento clase o tupla de clases
+func (in *Interpreter) classes_(name string, value pyValue) ([]*pyClass, error) {
+	candidates := []pyValue{value}
+	if tuple, ok := value.(*pyTuple); ok {
+		candidates = tuple.items
+	}
+	classes := make([]*pyClass, 0, len(candidates))
+	for _, candidate := range candidates {
+		class, ok := candidate.(*pyClass)
+		if !ok {
+			return nil, in.raise("TypeError", "%s() arg 2 must be a type, a tuple of types, or a union", name)
+		}
+		classes = append(classes, class)
+	}
+	return classes, nil
+}
+
+func builtinIsinstance(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("isinstance", args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	classes, err := in.classes_("isinstance", args[1])
+	if err != nil {
+		return nil, err
+	}
+	class := in.typeOf(args[0])
+	for _, candidate := range classes {
+		if class.isSubclass(candidate) {
+			return boolValue(true), nil
+		}
+	}
+	return boolValue(false), nil
+}
+
+func builtinIssubclass(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("issubclass", args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	class, ok := args[0].(*pyClass)
+	if !ok {
+		return nil, in.raise("TypeError", "issubclass() arg 1 must be a class")
+	}
+	classes, err := in.classes_("issubclass", args[1])
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range classes {
+		if class.isSubclass(candidate) {
+			return boolValue(true), nil
+		}
+	}
+	return boolValue(false), nil
+}
+
+func builtinHasattr(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("hasattr", args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	name, err := in.strArg("attribute name", args[1])
+	if err != nil {
+		return nil, in.raise("TypeError", "attribute name must be string, not '%s'", typeName(args[1]))
+	}
+	_, err = in.getAttr(args[0], name)
+	if exception, ok := err.(*pyException); ok && exception.is("AttributeError") {
+		return boolValue(false), nil
+	}
+	return boolValue(err == nil), err
+}
+
+func builtinGetattr(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("getattr", args, kwargs, 2, 3); err != nil {
+		return nil, err
+	}
+	name, ok := args[1].(constValue)
+	if !ok || name.kind != "str" {
+		return nil, in.raise("TypeError", "attribute name must be string, not '%s'", typeName(args[1]))
+	}
+	value, err := in.getAttr(args[0], name.s)
+	if exception, ok := err.(*pyException); ok && exception.is("AttributeError") && len(args) == 3 {
+		return args[2], nil
+	}
+	return value, err
+}
+
+func builtinSetattr(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("setattr", args, kwargs, 3, 3); err != nil {
+		return nil, err
+	}
+	name, ok := args[1].(constValue)
+	if !ok || name.kind != "str" {
+		return nil, in.raise("TypeError", "attribute name must be string, not '%s'", typeName(args[1]))
+	}
+	return noneValue(), in.setAttr(args[0], name.s, args[2])
+}
+
+func builtinCallable(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("callable", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case *pyFunction, *pyMethod, *pyBuiltin, *pyClass, *pyCached:
+		return boolValue(true), nil
+	case *pyInstance:
+		_, ok := v.class.lookup("__call__")
+		return boolValue(ok), nil
+	}
+	return boolValue(false), nil
+}
+
+func fnvHash(text string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(text))
+	return int64(hash.Sum64() >> 1)
+}
+
+func builtinID(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("id", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	if value, ok := args[0].(constValue); ok {
+		return smallInt(fnvHash(value.kind + ":" + value.Repr())), nil
+	}
+	address, _ := strconv.ParseInt(strings.TrimPrefix(address(args[0]), "0x"), 16, 64)
+	return smallInt(address), nil
+}
+
+// builtinHash devuelve el hash de Python para los enteros (módulo 2**61 - 1) y
+// un hash estable del valor para el resto
+func builtinHash(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("hash", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	key, err := in.hashKey(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if number, ok := args[0].(constValue); ok && number.isIntegral() {
+		modulus := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 61), big.NewInt(1))
+		hash := new(big.Int).Rem(number.toInt(), modulus)
+		if hash.Int64() == -1 {
+			hash.SetInt64(-2)
+		}
+		return intValue(hash), nil
+	}
+	if strings.HasPrefix(key, "h:") {
+		i, _ := new(big.Int).SetString(key[2:], 10)
+		return intValue(i), nil
+	}
+	return smallInt(fnvHash(key)), nil
+}
+
+func builtinChr(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("chr", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	code, err := in.int64Arg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if code < 0 || code > 0x10FFFF {
+		return nil, in.raise("ValueError", "chr() arg not in range(0x110000)")
+	}
+	return strValue(string(rune(code))), nil
+}
+
+func builtinOrd(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("ord", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	text, ok := args[0].(constValue)
+	if !ok || text.kind != "str" {
+		return nil, in.raise("TypeError", "ord() expected string of length 1, but %s found", typeName(args[0]))
+	}
+	if count := utf8.RuneCountInString(text.s); count != 1 {
+		return nil, in.raise("TypeError", "ord() expected a character, but string of length %d found", count)
+	}
+	r, _ := utf8.DecodeRuneInString(text.s)
+	return smallInt(int64(r)), nil
+}
+
+func (in *Interpreter) radix(name string, base int, prefix string, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity(name, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	i, err := in.intArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if i.Sign() < 0 {
+		return strValue("-" + prefix + new(big.Int).Neg(i).Text(base)), nil
+	}
+	return strValue(prefix + i.Text(base)), nil
+}
+
+func builtinRepr(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("repr", args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	text, err := in.repr(args[0])
+	return strValue(text), err
+}
+
+func builtinFormat(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("format", args, kwargs, 1, 2); err != nil {
+		return nil, err
+	}
+	spec := ""
+	if len(args) == 2 {
+		text, err := in.strArg("format", args[1])
+		if err != nil {
+			return nil, err
+		}
+		spec = text
+	}
+	text, err := in.formatValue(args[0], spec)
+	return strValue(text), err
+}
+
+// builtinSuper implementa super() sin argumentos: usa la clase donde se
+// definió la función en curso y su primer argumento
+func builtinSuper(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("super", args, kwargs, 0, 2); err != nil {
+		return nil, err
+	}
+	if len(args) == 2 {
+		class, ok := args[0].(*pyClass)
+		if !ok {
+			return nil, in.raise("TypeError", "super() argument 1 must be a type, not %s", typeName(args[0]))
+		}
+		return &pySuper{class: class, self: args[1]}, nil
+	}
+	fr := in.frame
+	if fr.fn == nil || fr.fn.class == nil || len(fr.args) == 0 {
+		return nil, in.raise("RuntimeError", "super(): no arguments")
+	}
+	return &pySuper{class: fr.fn.class, self: fr.args[0]}, nil
+}
+
+func builtinOpen(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	return nil, in.raise("PermissionError", "el acceso a archivos no está permitido en el sandbox")
+}
+
+func builtinExit(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("exit", args, kwargs, 0, 1); err != nil {
+		return nil, err
+	}
+	return nil, in.throw(&pyInstance{class: in.builtinClass("SystemExit"), attrs: map[string]pyValue{"args": newTuple(args)}})
+}
+
+// importModule carga un módulo del sandbox. Los módulos de la biblioteca
+// estándar que no se implementan no están disponibles.
+func (in *Interpreter) importModule(name string) (*pyModule, error) {
+	if module, ok := in.modules[name]; ok {
+		return module, nil
+	}
+	build, ok := sandboxModules[name]
+	if !ok {
+		if _, known := lookupModule(name); known {
+			return nil, in.raise("ImportError", "el módulo '%s' no está disponible en el sandbox", name)
+		}
+		return nil, in.raise("ModuleNotFoundError", "No module named '%s'", name)
+	}
+	module := build(in)
+	in.modules[name] = module
+	return module, nil
+}
+
+func newModule(name string, functions map[string]builtinFunc, constants map[string]pyValue) *pyModule {
+	module := &pyModule{name: name, attrs: make(map[string]pyValue)}
+	for attr, function := range functions {
+		module.attrs[attr] = &pyBuiltin{name: attr, call: function}
+	}
+	for attr, value := range constants {
+		module.attrs[attr] = value
+	}
+	return module
+}
+
+// mathFunction adapta una función real de una variable; los resultados fuera
+// de dominio lanzan ValueError y los desbordamientos OverflowError, como math
+func mathFunction(name string, function func(float64) float64) builtinFunc {
+	return func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity(name, args, kwargs, 1, 1); err != nil {
+			return nil, err
+		}
+		x, err := in.floatArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		result := function(x)
+		switch {
+		case math.IsNaN(result) && !math.IsNaN(x):
+			return nil, in.raise("ValueError", "math domain error")
+		case math.IsInf(result, 0) && !math.IsInf(x, 0):
+			if x == 0 {
+				return nil, in.raise("ValueError", "math domain error")
+			}
+			return nil, in.raise("OverflowError", "math range error")
+		}
+		return floatValue(result), nil
+	}
+}
+
+// mathRounding implementa floor, ceil y trunc, que devuelven enteros
+func mathRounding(name string, function func(float64) float64) builtinFunc {
+	return func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity(name, args, kwargs, 1, 1); err != nil {
+			return nil, err
+		}
+		if number, ok := args[0].(constValue); ok && number.isIntegral() {
+			return intValue(number.toInt()), nil
+		}
+		x, err := in.floatArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if math.IsInf(x, 0) {
+			return nil, in.raise("OverflowError", "cannot convert float infinity to integer")
+		}
+		if math.IsNaN(x) {
+			return nil, in.raise("ValueError", "cannot convert float NaN to integer")
+		}
+		i, _ := big.NewFloat(function(x)).Int(nil)
+		return intValue(i), nil
+	}
+}
+
+func mathModule(in *Interpreter) *pyModule {
+	functions := map[string]builtinFunc{
+		"sqrt":    mathFunction("sqrt", math.Sqrt),
+		"exp":     mathFunction("exp", math.Exp),
+		"log2":    mathFunction("log2", math.Log2),
+		"log10":   mathFunction("log10", math.Log10),
+		"sin":     mathFunction("sin", math.Sin),
+		"cos":     mathFunction("cos", math.Cos),
+		"tan":     mathFunction("tan", math.Tan),
+		"asin":    mathFunction("asin", math.Asin),
+		"acos":    mathFunction("acos", math.Acos),
+		"atan":    mathFunction("atan", math.Atan),
+		"fabs":    mathFunction("fabs", math.Abs),
+		"degrees": mathFunction("degrees", func(x float64) float64 { return x * 180 / math.Pi }),
+		"radians": mathFunction("radians", func(x float64) float64 { return x * math.Pi / 180 }),
+		"floor":   mathRounding("floor", math.Floor),
+		"ceil":    mathRounding("ceil", math.Ceil),
+		"trunc":   mathRounding("trunc", math.Trunc),
+		"log": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("log", args, kwargs, 1, 2); err != nil {
+				return nil, err
+			}
+			values := make([]float64, len(args))
+			for i, arg := range args {
+				x, err := in.floatArg(arg)
+				if err != nil {
+					return nil, err
+				}
+				if x <= 0 {
+					return nil, in.raise("ValueError", "math domain error")
+				}
+				values[i] = math.Log(x)
+			}
+			if len(values) == 2 {
+				if values[1] == 0 {
+					return nil, in.raise("ZeroDivisionError", "float division by zero")
+				}
+				return floatValue(values[0] / values[1]), nil
+			}
+			return floatValue(values[0]), nil
+		},
+		"pow": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("pow", args, kwargs, 2, 2); err != nil {
+				return nil, err
+			}
+			x, err := in.floatArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			y, err := in.floatArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+			result, err := floatBinary("**", floatValue(x), floatValue(y))
+			if raised, ok := err.(*pythonError); ok {
+				return nil, in.raise(raised.name, "%s", raised.message)
+			}
+			if err != nil {
+				return nil, in.raise("ValueError", "math domain error")
+			}
+			return result, nil
+		},
+		"atan2": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.floatPair("atan2", args, kwargs, math.Atan2)
+		},
+		"hypot": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.floatPair("hypot", args, kwargs, math.Hypot)
+		},
+		"copysign": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.floatPair("copysign", args, kwargs, math.Copysign)
+		},
+		"isclose": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			options, err := in.keywords("isclose", kwargs, "rel_tol", "abs_tol")
+			if err != nil {
+				return nil, err
+			}
+			if err := in.positional("isclose", args, 2, 2); err != nil {
+				return nil, err
+			}
+			values := make([]float64, 0, 4)
+			for _, value := range []pyValue{args[0], args[1], options["rel_tol"], options["abs_tol"]} {
+				if value == nil {
+					values = append(values, map[int]float64{2: 1e-09, 3: 0}[len(values)])
+					continue
+				}
+				x, err := in.floatArg(value)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, x)
+			}
+			a, b, relative, absolute := values[0], values[1], values[2], values[3]
+			if a == b {
+				return boolValue(true), nil
+			}
+			difference := math.Abs(a - b)
+			return boolValue(difference <= math.Max(relative*math.Max(math.Abs(a), math.Abs(b)), absolute)), nil
+		},
+		"isfinite": mathPredicate("isfinite", func(x float64) bool { return !math.IsInf(x, 0) && !math.IsNaN(x) }),
+		"isinf":    mathPredicate("isinf", func(x float64) bool { return math.IsInf(x, 0) }),
+		"isnan":    mathPredicate("isnan", math.IsNaN),
+		"factorial": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("factorial", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			n, err := in.int64Arg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if n < 0 {
+				return nil, in.raise("ValueError", "factorial() not defined for negative values")
+			}
+			// log2(n!) <= n*log2(n): se verifica el tamaño antes de calcular
+			if n > 1 && float64(n)*math.Log2(float64(n))/8 > float64(in.valueBytes()) {
+				return nil, in.limit("MemoryLimitExceeded", "El resultado de factorial(%d) supera el tamaño máximo de %d bytes por valor", n, in.valueBytes())
+			}
+			result := intValue(new(big.Int).MulRange(1, max(n, 1)))
+			return result, in.allocateValue(result)
+		},
+		"gcd": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.foldIntegers("gcd", args, kwargs, func(a, b *big.Int) *big.Int {
+				return new(big.Int).GCD(nil, nil, new(big.Int).Abs(a), new(big.Int).Abs(b))
+			}, 0)
+		},
+		"lcm": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.foldIntegers("lcm", args, kwargs, func(a, b *big.Int) *big.Int {
+				if a.Sign() == 0 || b.Sign() == 0 {
+					return big.NewInt(0)
+				}
+				gcd := new(big.Int).GCD(nil, nil, new(big.Int).Abs(a), new(big.Int).Abs(b))
+				return new(big.Int).Abs(new(big.Int).Mul(new(big.Int).Quo(a, gcd), b))
+			}, 1)
+		},
+		"isqrt": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("isqrt", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			n, err := in.intArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if n.Sign() < 0 {
+				return nil, in.raise("ValueError", "isqrt() argument must be nonnegative")
+			}
+			return intValue(new(big.Int).Sqrt(n)), nil
+		},
+		"comb": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.combinatorics("comb", args, kwargs)
+		},
+		"perm": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.combinatorics("perm", args, kwargs)
+		},
+		"fsum": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("fsum", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			total := new(big.Float).SetPrec(2048)
+			err := in.iterate(args[0], func(item pyValue) (bool, error) {
+				x, err := in.floatArg(item)
+				total.Add(total, new(big.Float).SetPrec(2048).SetFloat64(x))
+				return true, err
+			})
+			result, _ := total.Float64()
+			return floatValue(result), err
+		},
+		"prod": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			options, err := in.keywords("prod", kwargs, "start")
+			if err != nil {
+				return nil, err
+			}
+			if err := in.positional("prod", args, 1, 1); err != nil {
+				return nil, err
+			}
+			var product pyValue = smallInt(1)
+			if start, ok := options["start"]; ok {
+				product = start
+			}
+			err = in.iterate(args[0], func(item pyValue) (bool, error) {
+				var err error
+				product, err = in.binary("*", product, item)
+				return true, err
+			})
+			return product, err
+		},
+	}
+	return newModule("math", functions, map[string]pyValue{
+		"pi":  floatValue(math.Pi),
+		"e":   floatValue(math.E),
+		"tau": floatValue(2 * math.Pi),
+		"inf": floatValue(math.Inf(1)),
+		"nan": floatValue(math.NaN()),
+	})
+}
+
+func mathPredicate(name string, predicate func(float64) bool) builtinFunc {
+	return func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity(name, args, kwargs, 1, 1); err != nil {
+			return nil, err
+		}
+		x, err := in.floatArg(args[0])
+		return boolValue(err == nil && predicate(x)), err
+	}
+}
+
+func (in *Interpreter) floatPair(name string, args []pyValue, kwargs []keywordArg, function func(float64, float64) float64) (pyValue, error) {
+	if err := in.arity(name, args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	x, err := in.floatArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := in.floatArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return floatValue(function(x, y)), nil
+}
+
+func (in *Interpreter) foldIntegers(name string, args []pyValue, kwargs []keywordArg, combine func(a, b *big.Int) *big.Int, identity int64) (pyValue, error) {
+	if err := in.arity(name, args, kwargs, 0, -1); err != nil {
+		return nil, err
+	}
+	result := big.NewInt(identity)
+	for i, arg := range args {
+		value, err := in.intArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			result = new(big.Int).Abs(value)
+			continue
+		}
+		result = combine(result, value)
+	}
+	return intValue(result), nil
+}
+
+func (in *Interpreter) combinatorics(name string, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	minimum := 2
+	if name == "perm" {
+		minimum = 1
+	}
+	if err := in.arity(name, args, kwargs, minimum, 2); err != nil {
+		return nil, err
+	}
+	n, err := in.int64Arg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	k := n
+	if len(args) == 2 && !isNone(args[1]) {
+		if k, err = in.int64Arg(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if n < 0 || k < 0 {
+		return nil, in.raise("ValueError", "%s must be a non-negative integer", map[bool]string{true: "n", false: "k"}[n < 0])
+	}
+	if k > n {
+		return smallInt(0), nil
+	}
+	if float64(k)*math.Log2(float64(max(n, 2)))/8 > float64(in.valueBytes()) {
+		return nil, in.limit("MemoryLimitExceeded", "El resultado de %s(%d, %d) supera el tamaño máximo de %d bytes por valor", name, n, k, in.valueBytes())
+	}
+	if name == "comb" {
+		return intValue(new(big.Int).Binomial(n, k)), nil
+	}
+	return intValue(new(big.Int).MulRange(n-k+1, n)), nil
+}
+
+// sysModule expone lo que un programa de consola suele usar de sys; la
+// entrada estándar es la de la petición
+func sysModule(in *Interpreter) *pyModule {
+	stream := func(name string, write func(text string) error) *pyInstance {
+		class := in.builtinClass("TextIOWrapper")
+		return &pyInstance{class: class, attrs: map[string]pyValue{
+			"name": strValue("<" + name + ">"),
+			"write": &pyBuiltin{name: "write", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+				if err := in.arity("write", args, kwargs, 1, 1); err != nil {
+					return nil, err
+				}
+				text, ok := args[0].(constValue)
+				if !ok || text.kind != "str" {
+					return nil, in.raise("TypeError", "write() argument must be str, not %s", typeName(args[0]))
+				}
+				return smallInt(int64(utf8.RuneCountInString(text.s))), write(text.s)
+			}},
+			"flush": &pyBuiltin{name: "flush", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+				return noneValue(), nil
+			}},
+		}}
+	}
+	stdin := &pyInstance{class: in.builtinClass("TextIOWrapper"), attrs: map[string]pyValue{
+		"name": strValue("<stdin>"),
+		"readline": &pyBuiltin{name: "readline", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			line, _ := in.readLine()
+			return strValue(line), nil
+		}},
+		"read": &pyBuiltin{name: "read", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			text := strings.Join(in.stdin, "")
+			in.stdin = nil
+			return strValue(text), nil
+		}},
+		"readlines": &pyBuiltin{name: "readlines", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			lines := make([]pyValue, len(in.stdin))
+			for i, line := range in.stdin {
+				lines[i] = strValue(line)
+			}
+			in.stdin = nil
+			return newList(lines), nil
+		}},
+	}}
+
+	functions := map[string]builtinFunc{
+		"setrecursionlimit": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("setrecursionlimit", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			limit, err := in.int64Arg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if limit < 1 {
+				return nil, in.raise("ValueError", "recursion limit must be greater or equal than 1")
+			}
+			// El límite del sandbox no se puede superar
+			in.recursionLimit = int(min(limit, int64(in.limits.MaxDepth)))
+			return noneValue(), nil
+		},
+		"getrecursionlimit": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return smallInt(int64(in.recursionLimit)), in.arity("getrecursionlimit", args, kwargs, 0, 0)
+		},
+		"set_int_max_str_digits": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("set_int_max_str_digits", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			digits, err := in.int64Arg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if digits != 0 && digits < 640 {
+				return nil, in.raise("ValueError", "maxdigits must be 0 or larger than 640")
+			}
+			in.maxStrDigits = int(min(digits, math.MaxInt32))
+			return noneValue(), nil
+		},
+		"get_int_max_str_digits": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return smallInt(int64(in.maxStrDigits)), in.arity("get_int_max_str_digits", args, kwargs, 0, 0)
+		},
+		"exit": builtinExit,
+	}
+	return newModule("sys", functions, map[string]pyValue{
+		"maxsize":      smallInt(math.MaxInt64),
+		"argv":         newList([]pyValue{strValue("main.py")}),
+		"version":      strValue("3.12.0 (sandbox)"),
+		"version_info": newTuple([]pyValue{smallInt(3), smallInt(12), smallInt(0)}),
+		"platform":     strValue("sandbox"),
+		"stdin":        stdin,
+		"stdout":       stream("stdout", in.write),
+		"stderr":       stream("stderr", func(text string) error { return in.output(&in.stderr, text) }),
+	})
+}
+
+// functoolsModule implementa lru_cache, cache y reduce
+func functoolsModule(in *Interpreter) *pyModule {
+	wrap := func(function pyValue) (pyValue, error) {
+		if !callableValue(function) {
+			return nil, in.raise("TypeError", "Expected first argument to be an integer, a callable, or None")
+		}
+		return &pyCached{function: function, cache: make(map[string]pyValue)}, nil
+	}
+	functions := map[string]builtinFunc{
+		"lru_cache": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if _, err := in.keywords("lru_cache", kwargs, "maxsize", "typed"); err != nil {
+				return nil, err
+			}
+			if err := in.positional("lru_cache", args, 0, 2); err != nil {
+				return nil, err
+			}
+			if len(args) > 0 && callableValue(args[0]) {
+				return wrap(args[0])
+			}
+			// lru_cache(maxsize=None) devuelve el decorador
+			return &pyBuiltin{name: "decorating_function", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+				if err := in.arity("decorating_function", args, kwargs, 1, 1); err != nil {
+					return nil, err
+				}
+				return wrap(args[0])
+			}}, nil
+		},
+		"cache": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("cache", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			return wrap(args[0])
+		},
+		"reduce": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("reduce", args, kwargs, 2, 3); err != nil {
+				return nil, err
+			}
+			var accumulator pyValue
+			if len(args) == 3 {
+				accumulator = args[2]
+			}
+			err := in.iterate(args[1], func(item pyValue) (bool, error) {
+				if accumulator == nil {
+					accumulator = item
+					return true, nil
+				}
+				var err error
+				accumulator, err = in.call(args[0], []pyValue{accumulator, item}, nil)
+				return true, err
+			})
+			if err == nil && accumulator == nil {
+				return nil, in.raise("TypeError", "reduce() of empty iterable with no initial value")
+			}
+			return accumulator, err
+		},
+	}
+	return newModule("functools", functions, nil)
+}
+
+func callableValue(value pyValue) bool {
+	switch value.(type) {
+	case *pyFunction, *pyMethod, *pyBuiltin, *pyClass, *pyCached:
+		return true
+	}
+	return false
+}
+
+// cachedAttr expone cache_info, cache_clear y __wrapped__ de una función con caché
+func (in *Interpreter) cachedAttr(cached *pyCached, name string) (pyValue, error) {
+	switch name {
+	case "cache_info":
+		return &pyBuiltin{name: "cache_info", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			class := in.builtinClass("CacheInfo")
+			class.attrs["__repr__"] = &pyBuiltin{name: "__repr__", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+				info := args[0].(*pyInstance).attrs
+				return strValue(fmt.Sprintf("CacheInfo(hits=%s, misses=%s, maxsize=None, currsize=%s)", info["hits"].(constValue).Repr(), info["misses"].(constValue).Repr(), info["currsize"].(constValue).Repr())), nil
+			}}
+			return &pyInstance{class: class, attrs: map[string]pyValue{
+				"hits":     smallInt(int64(cached.hits)),
+				"misses":   smallInt(int64(cached.misses)),
+				"maxsize":  noneValue(),
+				"currsize": smallInt(int64(len(cached.cache))),
+			}}, in.arity("cache_info", args, kwargs, 0, 0)
+		}}, nil
+	case "cache_clear":
+		return &pyBuiltin{name: "cache_clear", call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			cached.cache = make(map[string]pyValue)
+			cached.hits, cached.misses = 0, 0
+			return noneValue(), nil
+		}}, nil
+	case "__wrapped__":
+		return cached.function, nil
+	}
+	return in.getAttr(cached.function, name)
+}
+
+// randomModule usa una semilla fija para que las ejecuciones sean reproducibles
+func randomModule(in *Interpreter) *pyModule {
+	functions := map[string]builtinFunc{
+		"seed": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("seed", args, kwargs, 0, 1); err != nil {
+				return nil, err
+			}
+			seed := int64(0)
+			if len(args) == 1 && !isNone(args[0]) {
+				key, err := in.hashKey(args[0])
+				if err != nil {
+					return nil, err
+				}
+				seed = fnvHash(key)
+			}
+			in.random.Seed(seed)
+			return noneValue(), nil
+		},
+		"random": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return floatValue(in.random.Float64()), in.arity("random", args, kwargs, 0, 0)
+		},
+		"uniform": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.floatPair("uniform", args, kwargs, func(a, b float64) float64 { return a + (b-a)*in.random.Float64() })
+		},
+		"randint": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("randint", args, kwargs, 2, 2); err != nil {
+				return nil, err
+			}
+			return in.randomRange(args[0], args[1], smallInt(1), true)
+		},
+		"randrange": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("randrange", args, kwargs, 1, 3); err != nil {
+				return nil, err
+			}
+			switch len(args) {
+			case 1:
+				return in.randomRange(smallInt(0), args[0], smallInt(1), false)
+			case 2:
+				return in.randomRange(args[0], args[1], smallInt(1), false)
+			}
+			return in.randomRange(args[0], args[1], args[2], false)
+		},
+		"choice": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("choice", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			count, err := in.length(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, in.raise("IndexError", "Cannot choose from an empty sequence")
+			}
+			return in.getItem(args[0], smallInt(in.random.Int63n(count)))
+		},
+		"shuffle": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("shuffle", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			list, ok := args[0].(*pyList)
+			if !ok {
+				return nil, in.raise("TypeError", "'%s' object does not support item assignment", typeName(args[0]))
+			}
+			in.random.Shuffle(len(list.items), func(i, j int) { list.items[i], list.items[j] = list.items[j], list.items[i] })
+			return noneValue(), nil
+		},
+		"sample": func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("sample", args, kwargs, 2, 2); err != nil {
+				return nil, err
+			}
+			population, err := in.collect(args[0])
+			if err != nil {
+				return nil, err
+			}
+			k, err := in.int64Arg(args[1])
+			if err != nil {
+				return nil, err
+			}
+			if k < 0 || k > int64(len(population)) {
+				return nil, in.raise("ValueError", "Sample larger than population or is negative")
+			}
+			order := in.random.Perm(len(population))
+			result := make([]pyValue, k)
+			for i := range result {
+				result[i] = population[order[i]]
+			}
+			return newList(result), nil
+		},
+	}
+	return newModule("random", functions, nil)
+}
+
+func (in *Interpreter) randomRange(startValue, stopValue, stepValue pyValue, inclusive bool) (pyValue, error) {
+	start, err := in.int64Arg(startValue)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := in.int64Arg(stopValue)
+	if err != nil {
+		return nil, err
+	}
+	step, err := in.int64Arg(stepValue)
+	if err != nil {
+		return nil, err
+	}
+	if inclusive {
+		stop++
+	}
+	span := &pyRange{start: start, stop: stop, step: max(step, 1)}
+	if step == 0 || span.length() == 0 {
+		return nil, in.raise("ValueError", "empty range for randrange() (%d, %d, %d)", start, stop, step)
+	}
+	return smallInt(start + in.random.Int63n(span.length())*span.step), nil
+}
+
+func stringModule(in *Interpreter) *pyModule {
+	return newModule("string", nil, map[string]pyValue{
+		"ascii_lowercase": strValue("abcdefghijklmnopqrstuvwxyz"),
+		"ascii_uppercase": strValue("ABCDEFGHIJKLMNOPQRSTUVWXYZ"),
+		"ascii_letters":   strValue("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"),
+		"digits":          strValue("0123456789"),
+		"hexdigits":       strValue("0123456789abcdefABCDEF"),
+		"octdigits":       strValue("01234567"),
+		"punctuation":     strValue("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"),
+		"whitespace":      strValue(" \t\n\r\x0b\x0c"),
+	})
+}
@@ -0,0 +1,1744 @@
+package service
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// nativeMethod es un método de un tipo integrado; self es el receptor
+type nativeMethod func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error)
+
+// nativeMethods se completa en init para evitar un ciclo de inicialización
+var nativeMethods map[string]map[string]nativeMethod
+
+func init() {
+	nativeMethods = map[string]map[string]nativeMethod{
+		"str":   strMethods(),
+		"list":  listMethods(),
+		"dict":  dictMethods(),
+		"set":   setMethods(),
+		"tuple": tupleMethods(),
+		"int": {
+			"bit_length": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+				return smallInt(int64(self.(constValue).toInt().BitLen())), in.arity("bit_length", args, kwargs, 0, 0)
+			},
+			"bit_count": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+				count := 0
+				for _, word := range new(big.Int).Abs(self.(constValue).toInt()).Bits() {
+					for ; word != 0; word &= word - 1 {
+						count++
+					}
+				}
+				return smallInt(int64(count)), in.arity("bit_count", args, kwargs, 0, 0)
+			},
+		},
+		"float": {
+			"is_integer": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+				f := self.(constValue).f
+				return boolValue(!math.IsInf(f, 0) && f == math.Trunc(f)), in.arity("is_integer", args, kwargs, 0, 0)
+			},
+		},
+	}
+}
+
+// boundMethod devuelve el método de un valor integrado ligado a ese valor
+func (in *Interpreter) boundMethod(object pyValue, name string) (pyValue, bool) {
+	kind := typeName(object)
+	if kind == "bool" {
+		kind = "int"
+	}
+	method, ok := nativeMethods[kind][name]
+	if !ok {
+		return nil, false
+	}
+	return &pyBuiltin{name: name, call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		return method(in, object, args, kwargs)
+	}}, true
+}
+
+// unboundMethod es el método accedido desde el tipo (str.upper, dict.fromkeys):
+// el receptor llega como primer argumento
+func (in *Interpreter) unboundMethod(className, name string) (pyValue, bool) {
+	if className == "dict" && name == "fromkeys" {
+		return &pyBuiltin{name: name, call: dictFromKeys}, true
+	}
+	method, ok := nativeMethods[className][name]
+	if !ok {
+		return nil, false
+	}
+	return &pyBuiltin{name: name, call: func(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if len(args) == 0 {
+			return nil, in.raise("TypeError", "unbound method %s.%s() needs an argument", className, name)
+		}
+		if kind := typeName(args[0]); kind != className && !(className == "int" && kind == "bool") {
+			return nil, in.raise("TypeError", "descriptor '%s' for '%s' objects doesn't apply to a '%s' object", name, className, kind)
+		}
+		return method(in, args[0], args[1:], kwargs)
+	}}, true
+}
+
+// runeBounds normaliza los argumentos start y end de find, count,
+// startswith... como un slice sobre los caracteres
+func (in *Interpreter) runeBounds(length int, args []pyValue) (int, int, error) {
+	start, end := 0, length
+	for i, target := range []*int{&start, &end} {
+		if i >= len(args) || isNone(args[i]) {
+			continue
+		}
+		n, err := in.intArg(args[i])
+		if err != nil {
+			return 0, 0, in.raise("TypeError", "slice indices must be integers or None or have an __index__ method")
+		}
+		position := n.Int64()
+		if !n.IsInt64() {
+			position = int64(n.Sign()) * math.MaxInt32
+		}
+		if position < 0 {
+			position += int64(length)
+		}
+		*target = int(max(0, min(position, int64(length))))
+	}
+	return start, end, nil
+}
+
+// search busca sub en self[start:end] y devuelve la posición en caracteres
+func (in *Interpreter) search(name string, self pyValue, args []pyValue, kwargs []keywordArg, last bool) (int, error) {
+	if err := in.arity(name, args, kwargs, 1, 3); err != nil {
+		return 0, err
+	}
+	sub, err := in.strMethodArg(name, args[0])
+	if err != nil {
+		return 0, err
+	}
+	runes := []rune(self.(constValue).s)
+	start, end, err := in.runeBounds(len(runes), args[1:])
+	if err != nil || start > end {
+		return -1, err
+	}
+	window := string(runes[start:end])
+	index := strings.Index(window, sub)
+	if last {
+		index = strings.LastIndex(window, sub)
+	}
+	if index < 0 {
+		return -1, nil
+	}
+	return start + utf8.RuneCountInString(window[:index]), nil
+}
+
+func (in *Interpreter) strMethodArg(name string, value pyValue) (string, error) {
+	text, ok := value.(constValue)
+	if !ok || text.kind != "str" {
+		return "", in.raise("TypeError", "must be str, not %s", typeName(value))
+	}
+	return text.s, nil
+}
+
+// optionalChars es el argumento chars de strip o sep de split: None o un str
+func (in *Interpreter) optionalChars(name string, args []pyValue, position int) (string, bool, error) {
+	if position >= len(args) || isNone(args[position]) {
+		return "", false, nil
+	}
+	text, err := in.strMethodArg(name, args[position])
+	return text, true, err
+}
+
+func isPythonSpace(r rune) bool {
+	return unicode.IsSpace(r) || r == '\x1c' || r == '\x1d' || r == '\x1e' || r == '\x1f'
+}
+
+func strMethods() map[string]nativeMethod {
+	transform := func(name string, function func(string) string) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity(name, args, kwargs, 0, 0); err != nil {
+				return nil, err
+			}
+			return strValue(function(self.(constValue).s)), nil
+		}
+	}
+	predicate := func(name string, cased bool, test func(r rune) bool) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity(name, args, kwargs, 0, 0); err != nil {
+				return nil, err
+			}
+			text := self.(constValue).s
+			if cased {
+				// isupper e islower solo miran las letras con mayúscula y minúscula
+				hasCased := false
+				for _, r := range text {
+					if unicode.IsUpper(r) || unicode.IsLower(r) {
+						hasCased = true
+						if !test(r) {
+							return boolValue(false), nil
+						}
+					}
+				}
+				return boolValue(hasCased), nil
+			}
+			for _, r := range text {
+				if !test(r) {
+					return boolValue(false), nil
+				}
+			}
+			return boolValue(text != ""), nil
+		}
+	}
+	strip := func(name string, left, right bool) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity(name, args, kwargs, 0, 1); err != nil {
+				return nil, err
+			}
+			chars, given, err := in.optionalChars(name, args, 0)
+			if err != nil {
+				return nil, err
+			}
+			trim := isPythonSpace
+			if given {
+				trim = func(r rune) bool { return strings.ContainsRune(chars, r) }
+			}
+			text := self.(constValue).s
+			if left {
+				text = strings.TrimLeftFunc(text, trim)
+			}
+			if right {
+				text = strings.TrimRightFunc(text, trim)
+			}
+			return strValue(text), nil
+		}
+	}
+	pad := func(name string, align byte) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity(name, args, kwargs, 1, 2); err != nil {
+				return nil, err
+			}
+			width, err := in.int64Arg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			fill := " "
+			if len(args) == 2 {
+				text, ok := args[1].(constValue)
+				if !ok || text.kind != "str" || utf8.RuneCountInString(text.s) != 1 {
+					return nil, in.raise("TypeError", "The fill character must be exactly one character long")
+				}
+				fill = text.s
+			}
+			if err := in.allocate(max(width, 0)); err != nil {
+				return nil, err
+			}
+			return strValue(padText(self.(constValue).s, int(width), fill, align)), nil
+		}
+	}
+	search := func(name string, last, mustFind bool) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			index, err := in.search(name, self, args, kwargs, last)
+			if err != nil {
+				return nil, err
+			}
+			if index < 0 && mustFind {
+				return nil, in.raise("ValueError", "substring not found")
+			}
+			return smallInt(int64(index)), nil
+		}
+	}
+	affix := func(name string, test func(text, affix string) bool) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity(name, args, kwargs, 1, 3); err != nil {
+				return nil, err
+			}
+			runes := []rune(self.(constValue).s)
+			start, end, err := in.runeBounds(len(runes), args[1:])
+			if err != nil {
+				return nil, err
+			}
+			if start > end {
+				return boolValue(false), nil
+			}
+			candidates := []pyValue{args[0]}
+			if tuple, ok := args[0].(*pyTuple); ok {
+				candidates = tuple.items
+			}
+			for _, candidate := range candidates {
+				text, ok := candidate.(constValue)
+				if !ok || text.kind != "str" {
+					return nil, in.raise("TypeError", "%s first arg must be str or a tuple of str, not %s", name, typeName(candidate))
+				}
+				if test(string(runes[start:end]), text.s) {
+					return boolValue(true), nil
+				}
+			}
+			return boolValue(false), nil
+		}
+	}
+	partition := func(name string, last bool) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity(name, args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			sep, err := in.strMethodArg(name, args[0])
+			if err != nil {
+				return nil, err
+			}
+			if sep == "" {
+				return nil, in.raise("ValueError", "empty separator")
+			}
+			text := self.(constValue).s
+			index := strings.Index(text, sep)
+			if last {
+				index = strings.LastIndex(text, sep)
+			}
+			if index < 0 {
+				if last {
+					return newTuple([]pyValue{strValue(""), strValue(""), strValue(text)}), nil
+				}
+				return newTuple([]pyValue{strValue(text), strValue(""), strValue("")}), nil
+			}
+			return newTuple([]pyValue{strValue(text[:index]), strValue(sep), strValue(text[index+len(sep):])}), nil
+		}
+	}
+
+	return map[string]nativeMethod{
+		"upper":      transform("upper", strings.ToUpper),
+		"lower":      transform("lower", strings.ToLower),
+		"casefold":   transform("casefold", strings.ToLower),
+		"swapcase":   transform("swapcase", swapCase),
+		"title":      transform("title", titleCase),
+		"capitalize": transform("capitalize", capitalizeText),
+		"isdigit":    predicate("isdigit", false, unicode.IsDigit),
+		"isdecimal":  predicate("isdecimal", false, func(r rune) bool { return unicode.Is(unicode.Nd, r) }),
+		"isnumeric":  predicate("isnumeric", false, unicode.IsNumber),
+		"isalpha":    predicate("isalpha", false, unicode.IsLetter),
+		"isalnum":    predicate("isalnum", false, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }),
+		"isspace":    predicate("isspace", false, isPythonSpace),
+		"isupper":    predicate("isupper", true, unicode.IsUpper),
+		"islower":    predicate("islower", true, unicode.IsLower),
+		"isidentifier": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			text := self.(constValue).s
+			valid := text != ""
+			for i, r := range text {
+				valid = valid && (r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r))
+			}
+			return boolValue(valid), in.arity("isidentifier", args, kwargs, 0, 0)
+		},
+		"strip":      strip("strip", true, true),
+		"lstrip":     strip("lstrip", true, false),
+		"rstrip":     strip("rstrip", false, true),
+		"center":     pad("center", '^'),
+		"ljust":      pad("ljust", '<'),
+		"rjust":      pad("rjust", '>'),
+		"find":       search("find", false, false),
+		"rfind":      search("rfind", true, false),
+		"index":      search("index", false, true),
+		"rindex":     search("rindex", true, true),
+		"startswith": affix("startswith", strings.HasPrefix),
+		"endswith":   affix("endswith", strings.HasSuffix),
+		"partition":  partition("partition", false),
+		"rpartition": partition("rpartition", true),
+		"split": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.split("split", self, args, kwargs, false)
+		},
+		"rsplit": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return in.split("rsplit", self, args, kwargs, true)
+		},
+		"splitlines": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			options, err := in.keywords("splitlines", kwargs, "keepends")
+			if err != nil {
+				return nil, err
+			}
+			if err := in.positional("splitlines", args, 0, 1); err != nil {
+				return nil, err
+			}
+			if len(args) == 1 {
+				options["keepends"] = args[0]
+			}
+			keep := false
+			if value, ok := options["keepends"]; ok {
+				if keep, err = in.truthy(value); err != nil {
+					return nil, err
+				}
+			}
+			lines := make([]pyValue, 0)
+			text := self.(constValue).s
+			for text != "" {
+				end := strings.IndexAny(text, "\r\n")
+				if end < 0 {
+					lines = append(lines, strValue(text))
+					break
+				}
+				next := end + 1
+				if strings.HasPrefix(text[end:], "\r\n") {
+					next++
+				}
+				if keep {
+					end = next
+				}
+				lines = append(lines, strValue(text[:end]))
+				text = text[next:]
+			}
+			return newList(lines), nil
+		},
+		"join": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("join", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			parts := make([]string, 0)
+			size := 0
+			err := in.iterate(args[0], func(item pyValue) (bool, error) {
+				text, ok := item.(constValue)
+				if !ok || text.kind != "str" {
+					return false, in.raise("TypeError", "sequence item %d: expected str instance, %s found", len(parts), typeName(item))
+				}
+				parts = append(parts, text.s)
+				size += len(text.s)
+				return true, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			result := strValue(strings.Join(parts, self.(constValue).s))
+			return result, in.allocateValue(result)
+		},
+		"replace": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("replace", args, kwargs, 2, 3); err != nil {
+				return nil, err
+			}
+			old, err := in.strMethodArg("replace", args[0])
+			if err != nil {
+				return nil, err
+			}
+			replacement, err := in.strMethodArg("replace", args[1])
+			if err != nil {
+				return nil, err
+			}
+			count := int64(-1)
+			if len(args) == 3 {
+				if count, err = in.int64Arg(args[2]); err != nil {
+					return nil, err
+				}
+			}
+			text := self.(constValue).s
+			occurrences := int64(strings.Count(text, old))
+			if count >= 0 {
+				occurrences = min(occurrences, count)
+			}
+			if size := int64(len(text)) + occurrences*int64(len(replacement)-len(old)); size > int64(in.valueBytes()) {
+				return nil, in.limit("MemoryLimitExceeded", "El resultado de replace supera el tamaño máximo de %d bytes por valor", in.valueBytes())
+			}
+			result := strValue(strings.Replace(text, old, replacement, int(max(count, -1))))
+			return result, in.allocateValue(result)
+		},
+		"count": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("count", args, kwargs, 1, 3); err != nil {
+				return nil, err
+			}
+			sub, err := in.strMethodArg("count", args[0])
+			if err != nil {
+				return nil, err
+			}
+			runes := []rune(self.(constValue).s)
+			start, end, err := in.runeBounds(len(runes), args[1:])
+			if err != nil || start > end {
+				return smallInt(0), err
+			}
+			return smallInt(int64(strings.Count(string(runes[start:end]), sub))), nil
+		},
+		"zfill": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("zfill", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			width, err := in.int64Arg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if err := in.allocate(max(width, 0)); err != nil {
+				return nil, err
+			}
+			text := self.(constValue).s
+			sign := ""
+			if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+				sign, text = text[:1], text[1:]
+			}
+			missing := int(width) - len(sign) - utf8.RuneCountInString(text)
+			return strValue(sign + strings.Repeat("0", max(missing, 0)) + text), nil
+		},
+		"removeprefix": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("removeprefix", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			prefix, err := in.strMethodArg("removeprefix", args[0])
+			return strValue(strings.TrimPrefix(self.(constValue).s, prefix)), err
+		},
+		"removesuffix": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("removesuffix", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			suffix, err := in.strMethodArg("removesuffix", args[0])
+			return strValue(strings.TrimSuffix(self.(constValue).s, suffix)), err
+		},
+		"format": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			text, err := in.formatString(self.(constValue).s, args, kwargs)
+			if err != nil {
+				return nil, err
+			}
+			return strValue(text), in.allocateValue(strValue(text))
+		},
+	}
+}
+
+func swapCase(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, text)
+}
+
+// titleCase pone en mayúscula la primera letra de cada secuencia de letras,
+// como str.title
+func titleCase(text string) string {
+	var builder strings.Builder
+	previous := false
+	for _, r := range text {
+		if previous {
+			builder.WriteRune(unicode.ToLower(r))
+		} else {
+			builder.WriteRune(unicode.ToUpper(r))
+		}
+		previous = unicode.IsLetter(r)
+	}
+	return builder.String()
+}
+
+func capitalizeText(text string) string {
+	if text == "" {
+		return text
+	}
+	first, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(first)) + strings.ToLower(text[size:])
+}
+
+// padText alinea el texto en width caracteres: '<' a la izquierda, '>' a la
+// derecha y '^' centrado (el carácter sobrante va a la derecha, como Python)
+func padText(text string, width int, fill string, align byte) string {
+	missing := width - utf8.RuneCountInString(text)
+	if missing <= 0 {
+		return text
+	}
+	switch align {
+	case '<':
+		return text + strings.Repeat(fill, missing)
+	case '^':
+		left := missing / 2
+		if missing%2 == 1 && width%2 == 1 {
+			left++
+		}
+		return strings.Repeat(fill, left) + text + strings.Repeat(fill, missing-left)
+	}
+	return strings.Repeat(fill, missing) + text
+}
+
+// split implementa str.split y str.rsplit; sin separador se parte por
+// espacios en blanco consecutivos y se descartan los extremos
+func (in *Interpreter) split(name string, self pyValue, args []pyValue, kwargs []keywordArg, fromRight bool) (pyValue, error) {
+	options, err := in.keywords(name, kwargs, "sep", "maxsplit")
+	if err != nil {
+		return nil, err
+	}
+	if err := in.positional(name, args, 0, 2); err != nil {
+		return nil, err
+	}
+	for i, option := range []string{"sep", "maxsplit"} {
+		if i < len(args) {
+			options[option] = args[i]
+		}
+	}
+	maxSplit := int64(-1)
+	if value, ok := options["maxsplit"]; ok {
+		if maxSplit, err = in.int64Arg(value); err != nil {
+			return nil, err
+		}
+	}
+	text := self.(constValue).s
+	parts := make([]string, 0)
+
+	sep, hasSep := "", false
+	if value, ok := options["sep"]; ok && !isNone(value) {
+		if sep, err = in.strMethodArg(name, value); err != nil {
+			return nil, err
+		}
+		hasSep = true
+	}
+	switch {
+	case hasSep && sep == "":
+		return nil, in.raise("ValueError", "empty separator")
+	case hasSep && !fromRight:
+		parts = strings.SplitN(text, sep, int(max(maxSplit+1, -1)))
+		if maxSplit == -1 {
+			parts = strings.Split(text, sep)
+		}
+	case hasSep:
+		for maxSplit != 0 {
+			index := strings.LastIndex(text, sep)
+			if index < 0 {
+				break
+			}
+			parts = append([]string{text[index+len(sep):]}, parts...)
+			text = text[:index]
+			maxSplit--
+		}
+		parts = append([]string{text}, parts...)
+	case !fromRight:
+		for {
+			text = strings.TrimLeftFunc(text, isPythonSpace)
+			if text == "" {
+				break
+			}
+			if maxSplit == 0 {
+				parts = append(parts, strings.TrimRightFunc(text, isPythonSpace))
+				break
+			}
+			end := strings.IndexFunc(text, isPythonSpace)
+			if end < 0 {
+				parts = append(parts, text)
+				break
+			}
+			parts = append(parts, text[:end])
+			text = text[end:]
+			maxSplit--
+		}
+	default:
+		for {
+			text = strings.TrimRightFunc(text, isPythonSpace)
+			if text == "" {
+				break
+			}
+			if maxSplit == 0 {
+				parts = append([]string{strings.TrimLeftFunc(text, isPythonSpace)}, parts...)
+				break
+			}
+			start := strings.LastIndexFunc(text, isPythonSpace)
+			parts = append([]string{text[start+1:]}, parts...)
+			if start < 0 {
+				break
+			}
+			text = text[:start]
+			maxSplit--
+		}
+	}
+
+	if err := in.allocate(objectBytes + slotBytes*int64(len(parts))); err != nil {
+		return nil, err
+	}
+	items := make([]pyValue, len(parts))
+	for i, part := range parts {
+		items[i] = strValue(part)
+	}
+	return newList(items), nil
+}
+
+// findItem busca item en items[start:end] con la igualdad de Python
+func (in *Interpreter) findItem(items []pyValue, item pyValue, bounds []pyValue) (int, error) {
+	start, end, err := in.runeBounds(len(items), bounds)
+	if err != nil {
+		return -1, err
+	}
+	for i := start; i < end && i < len(items); i++ {
+		same, err := in.equal(items[i], item)
+		if err != nil {
+			return -1, err
+		}
+		if same {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+func (in *Interpreter) countItems(items []pyValue, item pyValue) (pyValue, error) {
+	count := int64(0)
+	for _, candidate := range items {
+		same, err := in.equal(candidate, item)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			count++
+		}
+	}
+	return smallInt(count), nil
+}
+
+func listMethods() map[string]nativeMethod {
+	return map[string]nativeMethod{
+		"append": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("append", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			if err := in.allocate(slotBytes); err != nil {
+				return nil, err
+			}
+			list := self.(*pyList)
+			list.items = append(list.items, args[0])
+			return noneValue(), nil
+		},
+		"extend": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("extend", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			items, err := in.collect(args[0])
+			if err != nil {
+				return nil, err
+			}
+			list := self.(*pyList)
+			list.items = append(list.items, items...)
+			return noneValue(), nil
+		},
+		"insert": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("insert", args, kwargs, 2, 2); err != nil {
+				return nil, err
+			}
+			list := self.(*pyList)
+			position, _, err := in.runeBounds(len(list.items), args[:1])
+			if err != nil {
+				return nil, err
+			}
+			if err := in.allocate(slotBytes); err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, nil)
+			copy(list.items[position+1:], list.items[position:])
+			list.items[position] = args[1]
+			return noneValue(), nil
+		},
+		"pop": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("pop", args, kwargs, 0, 1); err != nil {
+				return nil, err
+			}
+			list := self.(*pyList)
+			if len(list.items) == 0 {
+				return nil, in.raise("IndexError", "pop from empty list")
+			}
+			position := len(list.items) - 1
+			if len(args) == 1 {
+				var err error
+				if position, err = in.sequenceIndex(args[0], len(list.items), "pop"); err != nil {
+					if exception, ok := err.(*pyException); ok && exception.is("IndexError") {
+						return nil, in.raise("IndexError", "pop index out of range")
+					}
+					return nil, err
+				}
+			}
+			item := list.items[position]
+			list.items = append(list.items[:position], list.items[position+1:]...)
+			return item, nil
+		},
+		"remove": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("remove", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			list := self.(*pyList)
+			position, err := in.findItem(list.items, args[0], nil)
+			if err != nil {
+				return nil, err
+			}
+			if position < 0 {
+				return nil, in.raise("ValueError", "list.remove(x): x not in list")
+			}
+			list.items = append(list.items[:position], list.items[position+1:]...)
+			return noneValue(), nil
+		},
+		"index": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("index", args, kwargs, 1, 3); err != nil {
+				return nil, err
+			}
+			position, err := in.findItem(self.(*pyList).items, args[0], args[1:])
+			if err != nil {
+				return nil, err
+			}
+			if position < 0 {
+				text, err := in.repr(args[0])
+				if err != nil {
+					return nil, err
+				}
+				return nil, in.raise("ValueError", "%s is not in list", text)
+			}
+			return smallInt(int64(position)), nil
+		},
+		"count": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("count", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			return in.countItems(self.(*pyList).items, args[0])
+		},
+		"sort": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if len(args) > 0 {
+				return nil, in.raise("TypeError", "sort() takes no positional arguments")
+			}
+			options, err := in.keywords("sort", kwargs, "key", "reverse")
+			if err != nil {
+				return nil, err
+			}
+			reverse := false
+			if value, ok := options["reverse"]; ok {
+				if reverse, err = in.truthy(value); err != nil {
+					return nil, err
+				}
+			}
+			return noneValue(), in.sortValues(self.(*pyList).items, options["key"], reverse)
+		},
+		"reverse": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			items := self.(*pyList).items
+			for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+				items[i], items[j] = items[j], items[i]
+			}
+			return noneValue(), in.arity("reverse", args, kwargs, 0, 0)
+		},
+		"copy": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("copy", args, kwargs, 0, 0); err != nil {
+				return nil, err
+			}
+			items := self.(*pyList).items
+			if err := in.allocate(objectBytes + slotBytes*int64(len(items))); err != nil {
+				return nil, err
+			}
+			return newList(append([]pyValue{}, items...)), nil
+		},
+		"clear": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			self.(*pyList).items = []pyValue{}
+			return noneValue(), in.arity("clear", args, kwargs, 0, 0)
+		},
+	}
+}
+
+func tupleMethods() map[string]nativeMethod {
+	return map[string]nativeMethod{
+		"index": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("index", args, kwargs, 1, 3); err != nil {
+				return nil, err
+			}
+			position, err := in.findItem(self.(*pyTuple).items, args[0], args[1:])
+			if err != nil {
+				return nil, err
+			}
+			if position < 0 {
+				return nil, in.raise("ValueError", "tuple.index(x): x not in tuple")
+			}
+			return smallInt(int64(position)), nil
+		},
+		"count": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("count", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			return in.countItems(self.(*pyTuple).items, args[0])
+		},
+	}
+}
+
+// dictUpdate agrega a dict los pares de un mapeo o de un iterable de pares y
+// los argumentos por nombre, como dict(...) y dict.update(...)
+func (in *Interpreter) dictUpdate(dict *pyDict, args []pyValue, kwargs []keywordArg) error {
+	if len(args) == 1 {
+		if source, ok := args[0].(*pyDict); ok {
+			keys, values := append([]pyValue{}, source.keys...), append([]pyValue{}, source.values...)
+			for i, key := range keys {
+				if err := in.dictSet(dict, key, values[i]); err != nil {
+					return err
+				}
+			}
+		} else {
+			position := 0
+			err := in.iterate(args[0], func(item pyValue) (bool, error) {
+				pair, err := in.collect(item)
+				if err != nil {
+					return false, in.raise("TypeError", "cannot convert dictionary update sequence element #%d to a sequence", position)
+				}
+				if len(pair) != 2 {
+					return false, in.raise("ValueError", "dictionary update sequence element #%d has length %d; 2 is required", position, len(pair))
+				}
+				position++
+				return true, in.dictSet(dict, pair[0], pair[1])
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, kwarg := range kwargs {
+		if err := in.dictSet(dict, strValue(kwarg.name), kwarg.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dictFromKeys(in *Interpreter, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	if err := in.arity("fromkeys", args, kwargs, 1, 2); err != nil {
+		return nil, err
+	}
+	var value pyValue = noneValue()
+	if len(args) == 2 {
+		value = args[1]
+	}
+	dict := newDict()
+	err := in.iterate(args[0], func(key pyValue) (bool, error) {
+		return true, in.dictSet(dict, key, value)
+	})
+	return dict, err
+}
+
+func (in *Interpreter) keyError(key pyValue) error {
+	return in.throw(&pyInstance{class: in.builtinClass("KeyError"), attrs: map[string]pyValue{"args": newTuple([]pyValue{key})}})
+}
+
+func dictMethods() map[string]nativeMethod {
+	view := func(kind string) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return &pyView{kind: kind, dict: self.(*pyDict)}, in.arity(kind, args, kwargs, 0, 0)
+		}
+	}
+	return map[string]nativeMethod{
+		"keys":   view("keys"),
+		"values": view("values"),
+		"items":  view("items"),
+		"get": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("get", args, kwargs, 1, 2); err != nil {
+				return nil, err
+			}
+			key, err := in.hashKey(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if value, ok := self.(*pyDict).get(key); ok {
+				return value, nil
+			}
+			if len(args) == 2 {
+				return args[1], nil
+			}
+			return noneValue(), nil
+		},
+		"pop": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("pop", args, kwargs, 1, 2); err != nil {
+				return nil, err
+			}
+			key, err := in.hashKey(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if value, ok := self.(*pyDict).remove(key); ok {
+				return value, nil
+			}
+			if len(args) == 2 {
+				return args[1], nil
+			}
+			return nil, in.keyError(args[0])
+		},
+		"popitem": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("popitem", args, kwargs, 0, 0); err != nil {
+				return nil, err
+			}
+			dict := self.(*pyDict)
+			if len(dict.keys) == 0 {
+				return nil, in.raise("KeyError", "popitem(): dictionary is empty")
+			}
+			last := dict.keys[len(dict.keys)-1]
+			key, _ := in.hashKey(last)
+			value, _ := dict.remove(key)
+			return newTuple([]pyValue{last, value}), nil
+		},
+		"setdefault": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("setdefault", args, kwargs, 1, 2); err != nil {
+				return nil, err
+			}
+			key, err := in.hashKey(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if value, ok := self.(*pyDict).get(key); ok {
+				return value, nil
+			}
+			var value pyValue = noneValue()
+			if len(args) == 2 {
+				value = args[1]
+			}
+			return value, in.dictSet(self.(*pyDict), args[0], value)
+		},
+		"update": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.positional("update", args, 0, 1); err != nil {
+				return nil, err
+			}
+			return noneValue(), in.dictUpdate(self.(*pyDict), args, kwargs)
+		},
+		"copy": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("copy", args, kwargs, 0, 0); err != nil {
+				return nil, err
+			}
+			dict := newDict()
+			return dict, in.dictUpdate(dict, []pyValue{self}, nil)
+		},
+		"clear": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			*self.(*pyDict) = *newDict()
+			return noneValue(), in.arity("clear", args, kwargs, 0, 0)
+		},
+		"fromkeys": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return dictFromKeys(in, args, kwargs)
+		},
+	}
+}
+
+// setArg convierte el argumento de un método de set en un set
+func (in *Interpreter) setArg(value pyValue) (*pySet, error) {
+	if set, ok := value.(*pySet); ok {
+		return set, nil
+	}
+	items, err := in.collect(value)
+	if err != nil {
+		return nil, err
+	}
+	return in.makeSet(items)
+}
+
+func copySet(set *pySet) *pySet {
+	result := newSet()
+	for key, position := range set.index {
+		result.index[key] = position
+	}
+	result.items = append([]pyValue{}, set.items...)
+	return result
+}
+
+// setAlgebra aplica una operación de conjuntos con cada argumento; keep decide
+// qué elementos de self se conservan y add cuáles del argumento se agregan
+func setAlgebra(name string, inPlace bool, keep func(other *pySet, key string) bool, add bool) nativeMethod {
+	return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+		if err := in.arity(name, args, kwargs, 0, -1); err != nil {
+			return nil, err
+		}
+		result := copySet(self.(*pySet))
+		for _, arg := range args {
+			other, err := in.setArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			next := newSet()
+			for _, item := range result.items {
+				key, _ := in.hashKey(item)
+				if keep(other, key) {
+					next.add(key, item)
+				}
+			}
+			if add {
+				for _, item := range other.items {
+					key, _ := in.hashKey(item)
+					if _, ok := result.index[key]; !ok || keep == nil {
+						next.add(key, item)
+					}
+				}
+			}
+			result = next
+		}
+		if err := in.allocate(slotBytes * int64(len(result.items))); err != nil {
+			return nil, err
+		}
+		if inPlace {
+			*self.(*pySet) = *result
+			return noneValue(), nil
+		}
+		return result, nil
+	}
+}
+
+func setMethods() map[string]nativeMethod {
+	always := func(other *pySet, key string) bool { return true }
+	inside := func(other *pySet, key string) bool { _, ok := other.index[key]; return ok }
+	outside := func(other *pySet, key string) bool { _, ok := other.index[key]; return !ok }
+	relation := func(name string, test func(self, other *pySet) bool) nativeMethod {
+		return func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity(name, args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			other, err := in.setArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return boolValue(test(self.(*pySet), other)), nil
+		}
+	}
+	return map[string]nativeMethod{
+		"union":                       setAlgebra("union", false, always, true),
+		"update":                      setAlgebra("update", true, always, true),
+		"intersection":                setAlgebra("intersection", false, inside, false),
+		"intersection_update":         setAlgebra("intersection_update", true, inside, false),
+		"difference":                  setAlgebra("difference", false, outside, false),
+		"difference_update":           setAlgebra("difference_update", true, outside, false),
+		"symmetric_difference":        setAlgebra("symmetric_difference", false, outside, true),
+		"symmetric_difference_update": setAlgebra("symmetric_difference_update", true, outside, true),
+		"issubset":                    relation("issubset", subset),
+		"issuperset":                  relation("issuperset", func(self, other *pySet) bool { return subset(other, self) }),
+		"isdisjoint": relation("isdisjoint", func(self, other *pySet) bool {
+			for key := range self.index {
+				if _, ok := other.index[key]; ok {
+					return false
+				}
+			}
+			return true
+		}),
+		"add": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("add", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			key, err := in.hashKey(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if err := in.allocate(slotBytes); err != nil {
+				return nil, err
+			}
+			self.(*pySet).add(key, args[0])
+			return noneValue(), nil
+		},
+		"remove": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("remove", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			key, err := in.hashKey(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if !self.(*pySet).remove(key) {
+				return nil, in.keyError(args[0])
+			}
+			return noneValue(), nil
+		},
+		"discard": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("discard", args, kwargs, 1, 1); err != nil {
+				return nil, err
+			}
+			key, err := in.hashKey(args[0])
+			if err != nil {
+				return nil, err
+			}
+			self.(*pySet).remove(key)
+			return noneValue(), nil
+		},
+		"pop": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			if err := in.arity("pop", args, kwargs, 0, 0); err != nil {
+				return nil, err
+			}
+			set := self.(*pySet)
+			if len(set.items) == 0 {
+				return nil, in.raise("KeyError", "pop from an empty set")
+			}
+			item := set.items[0]
+			key, _ := in.hashKey(item)
+			set.remove(key)
+			return item, nil
+		},
+		"copy": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			return copySet(self.(*pySet)), in.arity("copy", args, kwargs, 0, 0)
+		},
+		"clear": func(in *Interpreter, self pyValue, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+			*self.(*pySet) = *newSet()
+			return noneValue(), in.arity("clear", args, kwargs, 0, 0)
+		},
+	}
+}
+
+// formatSpec es una especificación del mini-lenguaje de format():
+// [[fill]align][sign][#][0][width][grouping][.precision][type]
+type formatSpec struct {
+	fill      string
+	align     byte
+	sign      byte
+	alternate bool
+	width     int
+	grouping  byte
+	precision int
+	kind      byte
+}
+
+func (in *Interpreter) parseFormatSpec(spec string) (formatSpec, error) {
+	parsed := formatSpec{fill: " ", precision: -1}
+	runes := []rune(spec)
+	isAlign := func(r rune) bool { return r == '<' || r == '>' || r == '^' || r == '=' }
+	i := 0
+	switch {
+	case len(runes) >= 2 && isAlign(runes[1]):
+		parsed.fill, parsed.align, i = string(runes[0]), byte(runes[1]), 2
+	case len(runes) >= 1 && isAlign(runes[0]):
+		parsed.align, i = byte(runes[0]), 1
+	}
+	if i < len(runes) && (runes[i] == '+' || runes[i] == '-' || runes[i] == ' ') {
+		parsed.sign = byte(runes[i])
+		i++
+	}
+	if i < len(runes) && runes[i] == '#' {
+		parsed.alternate = true
+		i++
+	}
+	if i < len(runes) && runes[i] == '0' {
+		if parsed.align == 0 {
+			parsed.fill, parsed.align = "0", '='
+		}
+		i++
+	}
+	digits := func() (int, bool) {
+		start := i
+		for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(string(runes[start:i]))
+		return n, err == nil
+	}
+	if n, ok := digits(); ok {
+		parsed.width = n
+	}
+	if i < len(runes) && (runes[i] == ',' || runes[i] == '_') {
+		parsed.grouping = byte(runes[i])
+		i++
+	}
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		n, ok := digits()
+		if !ok {
+			return parsed, in.raise("ValueError", "Format specifier missing precision")
+		}
+		parsed.precision = n
+	}
+	if i < len(runes) {
+		parsed.kind = byte(runes[i])
+		i++
+	}
+	if i < len(runes) || parsed.width > 1<<20 {
+		return parsed, in.raise("ValueError", "Invalid format specifier '%s'", spec)
+	}
+	return parsed, nil
+}
+
+// formatValue implementa format(value, spec) para str, int, float y bool; el
+// resto de los valores solo admite la especificación vacía
+func (in *Interpreter) formatValue(value pyValue, spec string) (string, error) {
+	if instance, ok := value.(*pyInstance); ok {
+		if _, defined := instance.class.lookup("__format__"); defined {
+			result, err := in.callSpecial(instance, "__format__", strValue(spec))
+			if err != nil {
+				return "", err
+			}
+			return in.str(result)
+		}
+	}
+	number, ok := value.(constValue)
+	if spec == "" || !ok || number.kind == "None" {
+		if spec != "" {
+			return "", in.raise("TypeError", "unsupported format string passed to %s.__format__", typeName(value))
+		}
+		return in.str(value)
+	}
+	parsed, err := in.parseFormatSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	invalid := func() error {
+		return in.raise("ValueError", "Unknown format code '%c' for object of type '%s'", parsed.kind, typeName(value))
+	}
+
+	switch {
+	case number.kind == "str":
+		if parsed.kind != 0 && parsed.kind != 's' {
+			return "", invalid()
+		}
+		if parsed.sign != 0 || parsed.align == '=' {
+			return "", in.raise("ValueError", "Sign not allowed in string format specifier")
+		}
+		text := number.s
+		if parsed.precision >= 0 && utf8.RuneCountInString(text) > parsed.precision {
+			text = string([]rune(text)[:parsed.precision])
+		}
+		return padText(text, parsed.width, parsed.fill, orDefault(parsed.align, '<')), nil
+	case number.kind == "bool" && parsed.kind == 0:
+		return padText(number.Str(), parsed.width, parsed.fill, orDefault(parsed.align, '<')), nil
+	case number.isIntegral() && strings.IndexByte("bcdoxXn", parsed.kind) >= 0 && parsed.kind != 0 || number.isIntegral() && parsed.kind == 0:
+		if parsed.precision >= 0 {
+			return "", in.raise("ValueError", "Precision not allowed in integer format specifier")
+		}
+		return in.formatInteger(number.toInt(), parsed)
+	case number.isNumber():
+		if strings.IndexByte("eEfFgGn%", parsed.kind) < 0 && parsed.kind != 0 {
+			return "", invalid()
+		}
+		f, ok := number.toFloat()
+		if !ok {
+			return "", in.raise("OverflowError", "int too large to convert to float")
+		}
+		return formatFloatSpec(f, parsed), nil
+	}
+	return "", invalid()
+}
+
+func orDefault(align, fallback byte) byte {
+	if align == 0 {
+		return fallback
+	}
+	return align
+}
+
+func (in *Interpreter) formatInteger(i *big.Int, spec formatSpec) (string, error) {
+	magnitude := new(big.Int).Abs(i)
+	prefix := ""
+	var digits string
+	switch spec.kind {
+	case 'b':
+		digits, prefix = magnitude.Text(2), "0b"
+	case 'o':
+		digits, prefix = magnitude.Text(8), "0o"
+	case 'x':
+		digits, prefix = magnitude.Text(16), "0x"
+	case 'X':
+		digits, prefix = strings.ToUpper(magnitude.Text(16)), "0X"
+	case 'c':
+		if !i.IsInt64() || i.Int64() < 0 || i.Int64() > 0x10FFFF {
+			return "", in.raise("OverflowError", "%%c arg not in range(0x110000)")
+		}
+		return padText(string(rune(i.Int64())), spec.width, spec.fill, orDefault(spec.align, '<')), nil
+	default:
+		text, err := in.intString(magnitude)
+		if err != nil {
+			return "", err
+		}
+		digits = text
+	}
+	if !spec.alternate {
+		prefix = ""
+	}
+	if spec.grouping != 0 {
+		size := 3
+		if spec.kind == 'b' || spec.kind == 'o' || spec.kind == 'x' || spec.kind == 'X' {
+			size = 4
+		}
+		digits = groupDigits(digits, size, spec.grouping)
+	}
+	return alignNumber(signOf(i.Sign() < 0, spec.sign)+prefix, digits, spec), nil
+}
+
+func signOf(negative bool, sign byte) string {
+	switch {
+	case negative:
+		return "-"
+	case sign == '+':
+		return "+"
+	case sign == ' ':
+		return " "
+	}
+	return ""
+}
+
+// groupDigits separa la parte entera en grupos de size dígitos
+func groupDigits(digits string, size int, separator byte) string {
+	integer, rest := digits, ""
+	if dot := strings.IndexAny(digits, ".eE%"); dot >= 0 {
+		integer, rest = digits[:dot], digits[dot:]
+	}
+	var builder strings.Builder
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%size == 0 {
+			builder.WriteByte(separator)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String() + rest
+}
+
+// alignNumber alinea un número; con '=' el relleno va entre el signo y los dígitos
+func alignNumber(sign, digits string, spec formatSpec) string {
+	align := orDefault(spec.align, '>')
+	if align == '=' {
+		missing := spec.width - len(sign) - utf8.RuneCountInString(digits)
+		return sign + strings.Repeat(spec.fill, max(missing, 0)) + digits
+	}
+	return padText(sign+digits, spec.width, spec.fill, align)
+}
+
+func formatFloatSpec(f float64, spec formatSpec) string {
+	precision := spec.precision
+	negative := math.Signbit(f) && !math.IsNaN(f)
+	magnitude := math.Abs(f)
+	var digits string
+	switch spec.kind {
+	case 'f', 'F', '%':
+		if precision < 0 {
+			precision = 6
+		}
+		if spec.kind == '%' {
+			magnitude *= 100
+		}
+		digits = strconv.FormatFloat(magnitude, 'f', precision, 64)
+		if spec.alternate && precision == 0 {
+			digits += "."
+		}
+		if spec.kind == '%' {
+			digits += "%"
+		}
+	case 'e', 'E':
+		if precision < 0 {
+			precision = 6
+		}
+		digits = strconv.FormatFloat(magnitude, 'e', precision, 64)
+	case 'g', 'G', 'n':
+		if precision < 0 {
+			precision = 6
+		}
+		digits = strconv.FormatFloat(magnitude, 'g', max(precision, 1), 64)
+	default:
+		// Sin tipo: como str() o como 'g' con al menos un decimal
+		if precision < 0 {
+			digits = formatFloat(magnitude)
+		} else {
+			digits = strconv.FormatFloat(magnitude, 'g', max(precision, 1), 64)
+			if !strings.ContainsAny(digits, ".eIN") {
+				digits += ".0"
+			}
+		}
+	}
+	switch {
+	case math.IsInf(f, 0):
+		digits = strings.Replace(digits, "+Inf", "inf", 1)
+		digits = strings.Replace(digits, "Inf", "inf", 1)
+	case math.IsNaN(f):
+		digits = "nan"
+	}
+	if spec.kind == 'E' || spec.kind == 'F' || spec.kind == 'G' {
+		digits = strings.ToUpper(digits)
+	}
+	if spec.grouping != 0 && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		digits = groupDigits(digits, 3, spec.grouping)
+	}
+	return alignNumber(signOf(negative, spec.sign), digits, spec)
+}
+
+// formatString implementa str.format: campos {}, {0}, {nombre}, con
+// atributos e índices ({p.x}, {xs[0]}), conversión !r/!s y especificación
+func (in *Interpreter) formatString(template string, args []pyValue, kwargs []keywordArg) (string, error) {
+	var builder strings.Builder
+	automatic := 0
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c == '}' {
+			if i+1 < len(template) && template[i+1] == '}' {
+				builder.WriteByte('}')
+				i++
+				continue
+			}
+			return "", in.raise("ValueError", "Single '}' encountered in format string")
+		}
+		if c != '{' {
+			builder.WriteByte(c)
+			continue
+		}
+		if i+1 < len(template) && template[i+1] == '{' {
+			builder.WriteByte('{')
+			i++
+			continue
+		}
+
+		// El campo termina en la llave que cierra, contando las anidadas de la especificación
+		depth, end := 1, i+1
+		for ; end < len(template) && depth > 0; end++ {
+			switch template[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		if depth > 0 {
+			return "", in.raise("ValueError", "expected '}' before end of string")
+		}
+		field := template[i+1 : end-1]
+		i = end - 1
+
+		name, spec := field, ""
+		if colon := strings.IndexByte(field, ':'); colon >= 0 {
+			name, spec = field[:colon], field[colon+1:]
+		}
+		conversion := byte(0)
+		if bang := strings.IndexByte(name, '!'); bang >= 0 {
+			if bang+2 != len(name) {
+				return "", in.raise("ValueError", "expected ':' after conversion specifier")
+			}
+			name, conversion = name[:bang], name[bang+1]
+		}
+		if strings.Contains(spec, "{") {
+			nested, err := in.formatString(spec, args, kwargs)
+			if err != nil {
+				return "", err
+			}
+			spec = nested
+		}
+
+		value, err := in.formatField(name, &automatic, args, kwargs)
+		if err != nil {
+			return "", err
+		}
+		switch conversion {
+		case 0:
+		case 'r', 'a':
+			text, err := in.repr(value)
+			if err != nil {
+				return "", err
+			}
+			value = strValue(text)
+		case 's':
+			text, err := in.str(value)
+			if err != nil {
+				return "", err
+			}
+			value = strValue(text)
+		default:
+			return "", in.raise("ValueError", "Unknown conversion specifier %c", conversion)
+		}
+		text, err := in.formatValue(value, spec)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(text)
+		if builder.Len() > in.valueBytes() {
+			return "", in.limit("MemoryLimitExceeded", "El resultado de format supera el tamaño máximo de %d bytes por valor", in.valueBytes())
+		}
+	}
+	return builder.String(), nil
+}
+
+// formatField resuelve el nombre de un campo de str.format
+func (in *Interpreter) formatField(name string, automatic *int, args []pyValue, kwargs []keywordArg) (pyValue, error) {
+	base := name
+	if cut := strings.IndexAny(name, ".["); cut >= 0 {
+		base = name[:cut]
+	}
+	rest := name[len(base):]
+
+	var value pyValue
+	if base == "" {
+		if *automatic < 0 {
+			return nil, in.raise("ValueError", "cannot switch from manual field specification to automatic field numbering")
+		}
+		base = strconv.Itoa(*automatic)
+		*automatic++
+	} else if _, err := strconv.Atoi(base); err == nil {
+		if *automatic > 0 {
+			return nil, in.raise("ValueError", "cannot switch from automatic field numbering to manual field specification")
+		}
+		*automatic = -1
+	}
+	if position, err := strconv.Atoi(base); err == nil {
+		if position >= len(args) {
+			return nil, in.raise("IndexError", "Replacement index %d out of range for positional args tuple", position)
+		}
+		value = args[position]
+	} else {
+		for _, kwarg := range kwargs {
+			if kwarg.name == base {
+				value = kwarg.value
+			}
+		}
+		if value == nil {
+			return nil, in.keyError(strValue(base))
+		}
+	}
+
+	for rest != "" {
+		var err error
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			value, err = in.getAttr(value, rest[1:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, in.raise("ValueError", "Missing ']' in format string")
+			}
+			key := rest[1:end]
+			var index pyValue = strValue(key)
+			if n, convErr := strconv.Atoi(key); convErr == nil {
+				index = smallInt(int64(n))
+			}
+			value, err = in.getItem(value, index)
+			rest = rest[end+1:]
+		default:
+			return nil, in.raise("ValueError", "Only '.' or '[' may follow ']' in format field specifier")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// formatPercent implementa el operador % de str (printf-style):
+// %[(nombre)][flags][ancho][.precisión]tipo
+func (in *Interpreter) formatPercent(format string, args pyValue) (string, error) {
+	values := []pyValue{args}
+	if tuple, ok := args.(*pyTuple); ok {
+		values = tuple.items
+	}
+	mapping, _ := args.(*pyDict)
+	used := 0
+	next := func() (pyValue, error) {
+		if used >= len(values) {
+			return nil, in.raise("TypeError", "not enough arguments for format string")
+		}
+		used++
+		return values[used-1], nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			builder.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i >= len(format) {
+			return "", in.raise("ValueError", "incomplete format")
+		}
+
+		var value pyValue
+		if format[i] == '(' {
+			end := strings.IndexByte(format[i:], ')')
+			if end < 0 {
+				return "", in.raise("ValueError", "incomplete format key")
+			}
+			if mapping == nil {
+				return "", in.raise("TypeError", "format requires a mapping")
+			}
+			key := strValue(format[i+1 : i+end])
+			hash, _ := in.hashKey(key)
+			found, ok := mapping.get(hash)
+			if !ok {
+				return "", in.keyError(key)
+			}
+			value = found
+			i += end + 1
+		}
+
+		spec := formatSpec{fill: " ", precision: -1}
+		for ; i < len(format) && strings.IndexByte("-+ 0#", format[i]) >= 0; i++ {
+			switch format[i] {
+			case '-':
+				spec.align = '<'
+			case '+', ' ':
+				if spec.sign != '+' {
+					spec.sign = format[i]
+				}
+			case '0':
+				if spec.align == 0 {
+					spec.fill, spec.align = "0", '='
+				}
+			case '#':
+				spec.alternate = true
+			}
+		}
+		if spec.align == '<' {
+			spec.fill = " "
+		}
+		start := i
+		for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+		}
+		spec.width, _ = strconv.Atoi(format[start:i])
+		if i < len(format) && format[i] == '.' {
+			i++
+			start = i
+			for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+			}
+			spec.precision, _ = strconv.Atoi(format[start:i])
+		}
+		if i >= len(format) {
+			return "", in.raise("ValueError", "incomplete format")
+		}
+		kind := format[i]
+		if kind == '%' {
+			builder.WriteByte('%')
+			continue
+		}
+		if value == nil {
+			var err error
+			if value, err = next(); err != nil {
+				return "", err
+			}
+		}
+
+		var text string
+		var err error
+		switch kind {
+		case 's', 'r', 'a':
+			if kind == 's' {
+				text, err = in.str(value)
+			} else {
+				text, err = in.repr(value)
+			}
+			if err == nil {
+				if spec.precision >= 0 && utf8.RuneCountInString(text) > spec.precision {
+					text = string([]rune(text)[:spec.precision])
+				}
+				text = padText(text, spec.width, " ", orDefault(spec.align, '>'))
+			}
+		case 'd', 'i', 'u', 'x', 'X', 'o':
+			number, ok := value.(constValue)
+			if !ok || !number.isNumber() || kind != 'd' && kind != 'i' && kind != 'u' && !number.isIntegral() {
+				expected := "a real number"
+				if kind == 'x' || kind == 'X' || kind == 'o' {
+					expected = "an integer"
+				}
+				return "", in.raise("TypeError", "%%%c format: %s is required, not %s", kind, expected, typeName(value))
+			}
+			integer := number.toInt()
+			if number.kind == "float" {
+				truncated, convErr := constructInt(in, []pyValue{number}, nil)
+				if convErr != nil {
+					return "", convErr
+				}
+				integer = truncated.(constValue).toInt()
+			}
+			spec.kind = map[byte]byte{'x': 'x', 'X': 'X', 'o': 'o'}[kind]
+			text, err = in.formatInteger(integer, spec)
+		case 'e', 'E', 'f', 'F', 'g', 'G':
+			number, ok := value.(constValue)
+			if !ok || !number.isNumber() {
+				return "", in.raise("TypeError", "must be real number, not %s", typeName(value))
+			}
+			f, _ := number.toFloat()
+			spec.kind = kind
+			text = formatFloatSpec(f, spec)
+		case 'c':
+			if number, ok := value.(constValue); ok && number.kind == "str" && utf8.RuneCountInString(number.s) == 1 {
+				text = padText(number.s, spec.width, " ", orDefault(spec.align, '>'))
+			} else if number, ok := value.(constValue); ok && number.isIntegral() {
+				spec.kind = 'c'
+				spec.align = orDefault(spec.align, '>')
+				text, err = in.formatInteger(number.toInt(), spec)
+			} else {
+				return "", in.raise("TypeError", "%%c requires an int or a unicode character, not %s", typeName(value))
+			}
+		default:
+			return "", in.raise("ValueError", "unsupported format character '%c' (0x%x) at index %d", kind, kind, i)
+		}
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(text)
+		if builder.Len() > in.valueBytes() {
+			return "", in.limit("MemoryLimitExceeded", "El resultado de %% supera el tamaño máximo de %d bytes por valor", in.valueBytes())
+		}
+	}
+	if mapping == nil && used < len(values) {
+		return "", in.raise("TypeError", "not all arguments converted during string formatting")
+	}
+	return builder.String(), nil
+}
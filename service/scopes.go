@@ -0,0 +1,734 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// scopeSymbol es un nombre visible en un scope. binding indica cómo se resuelve:
+// "local" (enlazado en el scope), "cell" (local capturado por una función anidada),
+// "global", "nonlocal", "free" (capturado de una función externa) o "builtin".
+// Para global, nonlocal y free, target apunta al símbolo que realmente se enlaza.
+type scopeSymbol struct {
+	name     string
+	kind     string
+	binding  string
+	line     int
+	module   string
+	node     *models.ASTNode
+	owner    *scope
+	target   *scopeSymbol
+	bindings []symbolBinding
+	uses     []int
+
+	// assignedElsewhere indica que otra función asigna el símbolo mediante global o nonlocal
+	assignedElsewhere bool
+
+	// inferredType es el tipo calculado por la inferencia ("int", "(int) -> int")
+	inferredType string
+}
+
+type symbolBinding struct {
+	kind string
+	line int
+	node *models.ASTNode
+}
+
+type nameUse struct {
+	name string
+	path string
+	line int
+	node *models.ASTNode
+}
+
+// scope es un nodo del árbol de scopes: module, function, class, lambda o comprehension
+type scope struct {
+	name     string
+	kind     string
+	node     *models.ASTNode
+	parent   *scope
+	children []*scope
+	symbols  map[string]*scopeSymbol
+	order    []string
+	uses     []nameUse
+}
+
+type scopeTree struct {
+	module      *scope
+	scopes      []*scope
+	byNode      map[*models.ASTNode]*scope
+	resolved    map[*models.ASTNode]*scopeSymbol
+	diagnostics []models.Diagnostic
+}
+
+// buildScopeTree recorre el AST creando un scope por módulo, función, clase, lambda
+// y comprensión, y resuelve cada nombre usado con la regla LEGB
+func buildScopeTree(ast *models.ASTNode) *scopeTree {
+	tree := &scopeTree{
+		byNode:   make(map[*models.ASTNode]*scope),
+		resolved: make(map[*models.ASTNode]*scopeSymbol),
+	}
+	tree.module = tree.newScope("global", "module", ast, nil)
+
+	if ast != nil {
+		for _, child := range ast.Children {
+			tree.visit(child, tree.module)
+		}
+	}
+
+	tree.resolveNonlocals()
+	for _, sc := range tree.scopes {
+		for _, use := range sc.uses {
+			tree.resolveUse(sc, use)
+		}
+	}
+
+	return tree
+}
+
+func (t *scopeTree) newScope(name, kind string, node *models.ASTNode, parent *scope) *scope {
+	sc := &scope{
+		name:    name,
+		kind:    kind,
+		node:    node,
+		parent:  parent,
+		symbols: make(map[string]*scopeSymbol),
+	}
+	if parent != nil {
+		parent.children = append(parent.children, sc)
+	}
+	t.scopes = append(t.scopes, sc)
+	if node != nil {
+		t.byNode[node] = sc
+	}
+	return sc
+}
+
+func (t *scopeTree) report(severity, code string, line int, message string) {
+	t.diagnostics = append(t.diagnostics, models.Diagnostic{
+		Code:     code,
+		Severity: severity,
+		Message:  message,
+		Line:     line,
+	})
+}
+
+func (sc *scope) addSymbol(symbol *scopeSymbol) *scopeSymbol {
+	symbol.owner = sc
+	sc.symbols[symbol.name] = symbol
+	sc.order = append(sc.order, symbol.name)
+	return symbol
+}
+
+// bind registra un enlace del nombre en el scope, respetando declaraciones global
+func (t *scopeTree) bind(sc *scope, name, kind string, line int, node *models.ASTNode) *scopeSymbol {
+	symbol := sc.symbols[name]
+	if symbol != nil && symbol.binding == "global" && sc != t.module {
+		symbol.bindings = append(symbol.bindings, symbolBinding{kind: kind, line: line, node: node})
+		global := t.bind(t.module, name, kind, line, node)
+		global.assignedElsewhere = true
+		return global
+	}
+
+	if symbol == nil {
+		binding := "local"
+		if sc == t.module {
+			binding = "global"
+		}
+		symbol = sc.addSymbol(&scopeSymbol{
+			name:    name,
+			kind:    kind,
+			binding: binding,
+			line:    line,
+		})
+	}
+	if symbol.node == nil && (kind == "function" || kind == "class") {
+		symbol.node = node
+	}
+	symbol.bindings = append(symbol.bindings, symbolBinding{kind: kind, line: line, node: node})
+
+	return symbol
+}
+
+func (t *scopeTree) use(sc *scope, name, path string, node *models.ASTNode) {
+	sc.uses = append(sc.uses, nameUse{name: name, path: path, line: node.Line, node: node})
+}
+
+// declare procesa "global x" y "nonlocal x"
+func (t *scopeTree) declare(sc *scope, declaration string, ident *models.ASTNode) {
+	name := ident.Value
+
+	if sc == t.module {
+		if declaration == "nonlocal" {
+			t.report("error", "nonlocal-at-module-level", ident.Line, fmt.Sprintf("Declaración nonlocal '%s' no permitida a nivel de módulo (línea %d)", name, ident.Line))
+		}
+		return
+	}
+
+	if symbol := sc.symbols[name]; symbol != nil {
+		if symbol.kind == "parameter" {
+			t.report("error", "parameter-declared-"+declaration, ident.Line, fmt.Sprintf("'%s' es parámetro y no puede declararse %s (línea %d)", name, declaration, ident.Line))
+			return
+		}
+		if symbol.binding == "local" {
+			t.report("error", "used-before-"+declaration+"-declaration", ident.Line, fmt.Sprintf("'%s' se asigna antes de su declaración %s en línea %d", name, declaration, ident.Line))
+			return
+		}
+	}
+	for _, use := range sc.uses {
+		if use.name == name {
+			t.report("error", "used-before-"+declaration+"-declaration", ident.Line, fmt.Sprintf("'%s' se usa antes de su declaración %s en línea %d", name, declaration, ident.Line))
+			return
+		}
+	}
+
+	if sc.symbols[name] == nil {
+		sc.addSymbol(&scopeSymbol{
+			name:    name,
+			kind:    "variable",
+			binding: declaration,
+			line:    ident.Line,
+		})
+	}
+}
+
+// functionParts separa los parámetros del cuerpo de un FunctionDef o Lambda
+func functionParts(node *models.ASTNode) ([]*models.ASTNode, []*models.ASTNode) {
+	split := 0
+	for split < len(node.Children) && node.Children[split].Type == "Parameter" {
+		split++
+	}
+	body := split
+	for body < len(node.Children) && node.Children[body].Type == "Annotation" {
+		body++
+	}
+	return node.Children[:split], node.Children[body:]
+}
+
+// annotationOf devuelve la expresión de tipo anotada en un parámetro, en una
+// asignación o, para una FunctionDef, la anotación de retorno
+func annotationOf(node *models.ASTNode) *models.ASTNode {
+	var candidate *models.ASTNode
+	switch node.Type {
+	case "Parameter", "AnnotatedName":
+		if len(node.Children) > 0 {
+			candidate = node.Children[0]
+		}
+	case "Assignment":
+		candidate = node.Children[len(node.Children)-1]
+	case "FunctionDef":
+		params, _ := functionParts(node)
+		if len(params) < len(node.Children) {
+			candidate = node.Children[len(params)]
+		}
+	}
+
+	if candidate == nil || candidate.Type != "Annotation" {
+		return nil
+	}
+	return candidate.Children[0]
+}
+
+// parameterDefault devuelve el valor por defecto de un parámetro, si lo tiene
+func parameterDefault(param *models.ASTNode) *models.ASTNode {
+	for _, child := range param.Children {
+		if child.Type != "Annotation" {
+			return child
+		}
+	}
+	return nil
+}
+
+// parameterName quita los asteriscos de *args y **kwargs; el marcador '*' no enlaza nombre
+func parameterName(param *models.ASTNode) string {
+	return strings.TrimLeft(param.Value, "*")
+}
+
+func (t *scopeTree) visit(node *models.ASTNode, sc *scope) {
+	if node == nil {
+		return
+	}
+
+	switch node.Type {
+	case "FunctionDef", "Lambda":
+		params, body := functionParts(node)
+
+		// Los valores por defecto se evalúan en el scope que define la función
+		for _, param := range params {
+			for _, child := range param.Children {
+				t.visit(child, sc)
+			}
+		}
+
+		if returns := annotationOf(node); returns != nil {
+			t.visit(returns, sc)
+		}
+
+		kind := "function"
+		name := sc.name + "." + node.Value
+		if node.Type == "Lambda" {
+			kind = "lambda"
+			name = fmt.Sprintf("%s.<lambda:%d>", sc.name, node.Line)
+		} else {
+			t.bind(sc, node.Value, "function", node.Line, node)
+		}
+
+		fn := t.newScope(name, kind, node, sc)
+		for _, param := range params {
+			if paramName := parameterName(param); paramName != "" {
+				t.bind(fn, paramName, "parameter", param.Line, param)
+			}
+		}
+		for _, stmt := range body {
+			t.visit(stmt, fn)
+		}
+
+	case "ClassDef":
+		body := make([]*models.ASTNode, 0)
+		for _, child := range node.Children {
+			if child.Type == "Base" {
+				t.visit(child, sc)
+			} else {
+				body = append(body, child)
+			}
+		}
+
+		t.bind(sc, node.Value, "class", node.Line, node)
+		class := t.newScope(sc.name+"."+node.Value, "class", node, sc)
+		for _, stmt := range body {
+			t.visit(stmt, class)
+		}
+
+	case "Assignment":
+		if node.Value != "" {
+			t.visit(node.Children[0], sc)
+			t.bind(sc, node.Value, "variable", node.Line, node)
+		} else {
+			t.visit(node.Children[1], sc)
+			t.visitTarget(node.Children[0], sc)
+		}
+
+	case "AugAssignment":
+		t.visit(node.Children[1], sc)
+		target := node.Children[0]
+		if target.Type == "Identifier" {
+			t.use(sc, target.Value, target.Value, target)
+			t.bind(sc, target.Value, "variable", target.Line, node)
+		} else {
+			t.visit(target, sc)
+		}
+
+	case "ForStatement":
+		t.visit(node.Children[1], sc)
+		t.visitTarget(node.Children[0], sc)
+		for _, child := range node.Children[2:] {
+			t.visit(child, sc)
+		}
+
+	case "ListComp", "SetComp", "DictComp", "GeneratorExp":
+		t.visitComprehension(node, sc)
+
+	case "ExceptHandler":
+		block := node.Children[len(node.Children)-1]
+		for _, child := range node.Children[:len(node.Children)-1] {
+			t.visit(child, sc)
+		}
+		if node.Value != "" {
+			t.bind(sc, node.Value, "variable", node.Line, node)
+		}
+		t.visit(block, sc)
+
+	case "Import":
+		for _, alias := range node.Children {
+			t.bindImport(sc, alias)
+		}
+
+	case "ImportFrom":
+		t.bindImportFrom(sc, node)
+
+	case "GlobalStatement", "NonlocalStatement":
+		declaration := "global"
+		if node.Type == "NonlocalStatement" {
+			declaration = "nonlocal"
+		}
+		for _, ident := range node.Children {
+			t.declare(sc, declaration, ident)
+		}
+
+	case "Identifier":
+		t.use(sc, node.Value, node.Value, node)
+
+	case "Attribute":
+		if path := dottedName(node); path != "" {
+			t.use(sc, strings.Split(path, ".")[0], path, node)
+		} else {
+			for _, child := range node.Children {
+				t.visit(child, sc)
+			}
+		}
+
+	case "FunctionCall":
+		t.use(sc, strings.Split(node.Value, ".")[0], node.Value, node)
+		for _, child := range node.Children {
+			t.visit(child, sc)
+		}
+
+	default:
+		for _, child := range node.Children {
+			t.visit(child, sc)
+		}
+	}
+}
+
+// visitTarget enlaza los nombres del lado izquierdo de una asignación o de un for
+func (t *scopeTree) visitTarget(target *models.ASTNode, sc *scope) {
+	switch target.Type {
+	case "Identifier":
+		t.bind(sc, target.Value, "variable", target.Line, target)
+	case "Tuple", "List":
+		for _, child := range target.Children {
+			t.visitTarget(child, sc)
+		}
+	default:
+		t.visit(target, sc)
+	}
+}
+
+// visitComprehension crea el scope propio de la comprensión. El primer iterable
+// se evalúa en el scope exterior, como en Python.
+func (t *scopeTree) visitComprehension(node *models.ASTNode, sc *scope) {
+	elements := make([]*models.ASTNode, 0)
+	clauses := make([]*models.ASTNode, 0)
+	for _, child := range node.Children {
+		if child.Type == "Comprehension" {
+			clauses = append(clauses, child)
+		} else {
+			elements = append(elements, child)
+		}
+	}
+
+	if len(clauses) == 0 {
+		for _, element := range elements {
+			t.visit(element, sc)
+		}
+		return
+	}
+
+	labels := map[string]string{
+		"ListComp":     "listcomp",
+		"SetComp":      "setcomp",
+		"DictComp":     "dictcomp",
+		"GeneratorExp": "genexpr",
+	}
+
+	t.visit(clauses[0].Children[1], sc)
+	comp := t.newScope(fmt.Sprintf("%s.<%s:%d>", sc.name, labels[node.Type], node.Line), "comprehension", node, sc)
+
+	for i, clause := range clauses {
+		if i > 0 {
+			t.visit(clause.Children[1], comp)
+		}
+		t.visitTarget(clause.Children[0], comp)
+		for _, condition := range clause.Children[2:] {
+			t.visit(condition, comp)
+		}
+	}
+	for _, element := range elements {
+		t.visit(element, comp)
+	}
+}
+
+// bindImport registra "import a.b [as c]": sin alias se enlaza el primer componente
+func (t *scopeTree) bindImport(sc *scope, alias *models.ASTNode) {
+	module := alias.Value
+	boundName := strings.Split(module, ".")[0]
+	boundModule := boundName
+	if len(alias.Children) > 0 {
+		boundName = alias.Children[0].Value
+		boundModule = module
+	}
+
+	if _, known := lookupModule(module); !known {
+		t.report("warning", "unknown-module", alias.Line, fmt.Sprintf("Módulo '%s' no está en el catálogo de la biblioteca estándar; sus nombres no se verifican (línea %d)", module, alias.Line))
+	}
+
+	symbol := t.bind(sc, boundName, "module", alias.Line, alias)
+	if symbol.module == "" {
+		symbol.module = boundModule
+	}
+}
+
+func (t *scopeTree) bindImportFrom(sc *scope, node *models.ASTNode) {
+	stub, known := lookupModule(node.Value)
+	if !known {
+		t.report("warning", "unknown-module", node.Line, fmt.Sprintf("Módulo '%s' no está en el catálogo de la biblioteca estándar; sus nombres no se verifican (línea %d)", node.Value, node.Line))
+	}
+
+	for _, alias := range node.Children {
+		if alias.Value == "*" {
+			if known {
+				for _, name := range sortedMemberNames(stub) {
+					t.bindModuleMember(sc, name, stub.Members[name], node.Value, alias)
+				}
+			}
+			continue
+		}
+
+		boundName := alias.Value
+		if len(alias.Children) > 0 {
+			boundName = alias.Children[0].Value
+		}
+
+		member := ModuleMember{Kind: "constant"}
+		if known {
+			var exists bool
+			member, exists = stub.Members[alias.Value]
+			if !exists {
+				t.report("error", "import-name-not-found", alias.Line, fmt.Sprintf("No se puede importar '%s' desde '%s' en línea %d%s", alias.Value, node.Value, alias.Line, suggestName(alias.Value, sortedMemberNames(stub))))
+				continue
+			}
+		}
+
+		t.bindModuleMember(sc, boundName, member, node.Value, alias)
+	}
+}
+
+func (t *scopeTree) bindModuleMember(sc *scope, name string, member ModuleMember, module string, alias *models.ASTNode) {
+	kind := "variable"
+	origin := module
+	switch member.Kind {
+	case "function":
+		kind = "function"
+	case "class":
+		kind = "class"
+	case "module":
+		kind = "module"
+		origin = module + "." + name
+	}
+
+	symbol := t.bind(sc, name, kind, alias.Line, alias)
+	if symbol.module == "" {
+		symbol.module = origin
+	}
+}
+
+// resolveNonlocals enlaza cada declaración nonlocal con la variable de la función externa
+func (t *scopeTree) resolveNonlocals() {
+	for _, sc := range t.scopes {
+		for _, name := range sc.order {
+			symbol := sc.symbols[name]
+			if symbol.binding != "nonlocal" {
+				continue
+			}
+
+			target, how := t.lookupOuter(sc, name)
+			if how != "free" {
+				t.report("error", "nonlocal-without-binding", symbol.line, fmt.Sprintf("No existe un enlace para la variable nonlocal '%s' en una función externa (línea %d)", name, symbol.line))
+				continue
+			}
+
+			t.capture(sc, target)
+			symbol.target = target
+			symbol.kind = target.kind
+			target.bindings = append(target.bindings, symbol.bindings...)
+			if len(symbol.bindings) > 0 {
+				target.assignedElsewhere = true
+			}
+		}
+	}
+}
+
+// lookupOuter busca un nombre en los scopes que encierran a sc siguiendo LEGB.
+// Los cuerpos de clase no son visibles desde los scopes anidados.
+func (t *scopeTree) lookupOuter(sc *scope, name string) (*scopeSymbol, string) {
+	for outer := sc.parent; outer != nil && outer != t.module; outer = outer.parent {
+		if outer.kind == "class" {
+			continue
+		}
+
+		symbol := outer.symbols[name]
+		if symbol == nil {
+			continue
+		}
+		switch symbol.binding {
+		case "local", "cell":
+			return symbol, "free"
+		case "nonlocal", "free":
+			if symbol.target != nil {
+				return symbol.target, "free"
+			}
+		case "global":
+			return t.lookupGlobal(name)
+		}
+	}
+
+	return t.lookupGlobal(name)
+}
+
+func (t *scopeTree) lookupGlobal(name string) (*scopeSymbol, string) {
+	// El módulo también registra las funciones integradas que usa; esas no
+	// son variables globales
+	if symbol := t.module.symbols[name]; symbol != nil && symbol.binding != "builtin" {
+		return symbol, "global"
+	}
+	if isBuiltinName(name) {
+		return nil, "builtin"
+	}
+	return nil, ""
+}
+
+// capture marca la variable como celda y la registra como libre en los scopes intermedios
+func (t *scopeTree) capture(sc *scope, target *scopeSymbol) {
+	if target.binding == "local" {
+		target.binding = "cell"
+	}
+
+	for inner := sc; inner != nil && inner != target.owner; inner = inner.parent {
+		if inner.symbols[target.name] != nil {
+			continue
+		}
+		inner.addSymbol(&scopeSymbol{
+			name:    target.name,
+			kind:    target.kind,
+			binding: "free",
+			line:    target.line,
+			target:  target,
+		})
+	}
+}
+
+func (t *scopeTree) resolveUse(sc *scope, use nameUse) {
+	symbol := sc.symbols[use.name]
+
+	var target *scopeSymbol
+	switch {
+	case symbol != nil && (symbol.binding == "local" || symbol.binding == "cell"):
+		target = symbol
+	case symbol != nil && symbol.binding == "global":
+		target = t.module.symbols[use.name]
+	case symbol != nil && (symbol.binding == "nonlocal" || symbol.binding == "free"):
+		target = symbol.target
+	case symbol != nil && symbol.binding == "builtin":
+		return
+	default:
+		var how string
+		target, how = t.lookupOuter(sc, use.name)
+		switch how {
+		case "free":
+			t.capture(sc, target)
+		case "global":
+			sc.addSymbol(&scopeSymbol{
+				name:    use.name,
+				kind:    target.kind,
+				binding: "global",
+				line:    use.line,
+				target:  target,
+			})
+		case "builtin":
+			sc.addSymbol(&scopeSymbol{
+				name:    use.name,
+				kind:    "builtin",
+				binding: "builtin",
+				line:    use.line,
+			})
+			return
+		}
+	}
+
+	if target == nil {
+		t.report("warning", "undefined-name", use.line, fmt.Sprintf("Identificador '%s' usado sin definir en línea %d", use.name, use.line))
+		return
+	}
+
+	target.uses = append(target.uses, use.line)
+	t.resolved[use.node] = target
+
+	if strings.Contains(use.path, ".") && target.kind == "module" {
+		t.checkModuleAttribute(target, use.path, use.line)
+	}
+}
+
+// checkModuleAttribute resuelve nombres como "math.factorial" contra el catálogo de módulos
+func (t *scopeTree) checkModuleAttribute(symbol *scopeSymbol, dotted string, line int) {
+	parts := strings.Split(dotted, ".")
+
+	stub, known := lookupModule(symbol.module)
+	for _, part := range parts[1:] {
+		if !known {
+			return
+		}
+
+		member, exists := stub.Members[part]
+		if !exists {
+			t.report("error", "unknown-module-attribute", line, fmt.Sprintf("El módulo '%s' no tiene el atributo '%s' (línea %d)%s", stub.Name, part, line, suggestName(part, sortedMemberNames(stub))))
+			return
+		}
+		if member.Kind != "module" {
+			return
+		}
+		stub, known = lookupModule(stub.Name + "." + part)
+	}
+}
+
+// definesHere indica si el símbolo es un enlace propio del scope (no una referencia)
+func (symbol *scopeSymbol) definesHere() bool {
+	switch symbol.binding {
+	case "local", "cell":
+		return true
+	case "global":
+		return symbol.owner.kind == "module"
+	}
+	return false
+}
+
+func (symbol *scopeSymbol) toModel(sc *scope) models.Symbol {
+	return models.Symbol{
+		Name:    symbol.name,
+		Type:    symbol.kind,
+		Scope:   sc.name,
+		Line:    symbol.line,
+		Module:  symbol.module,
+		Binding: symbol.binding,
+
+		InferredType: symbol.inferredType,
+	}
+}
+
+// symbolTable devuelve los símbolos definidos en todos los scopes
+func (t *scopeTree) symbolTable() []models.Symbol {
+	symbols := make([]models.Symbol, 0)
+	for _, sc := range t.scopes {
+		for _, name := range sc.order {
+			if symbol := sc.symbols[name]; symbol.definesHere() {
+				symbols = append(symbols, symbol.toModel(sc))
+			}
+		}
+	}
+	return symbols
+}
+
+// scopeInfos lista los símbolos de cada scope, incluidas las referencias libres y globales
+func (t *scopeTree) scopeInfos() []models.ScopeInfo {
+	infos := make([]models.ScopeInfo, 0, len(t.scopes))
+	for _, sc := range t.scopes {
+		info := models.ScopeInfo{
+			Name:    sc.name,
+			Kind:    sc.kind,
+			Line:    1,
+			Symbols: make([]models.Symbol, 0, len(sc.order)),
+		}
+		if sc.parent != nil {
+			info.Parent = sc.parent.name
+		}
+		if sc.node != nil && sc.kind != "module" {
+			info.Line = sc.node.Line
+		}
+		for _, name := range sc.order {
+			info.Symbols = append(info.Symbols, sc.symbols[name].toModel(sc))
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
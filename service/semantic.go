@@ -9,317 +9,300 @@ import (
 type SemanticAnalyzer struct {
 	tokens      []models.Token
 	ast         *models.ASTNode
-	symbolTable map[string]models.Symbol
-	scopes      []string
+	scopes      *scopeTree
+	graphs      []*controlFlowGraph
+	calls       *callGraph
+	recursion   *recursionAnalysis
+	types       *typeInference
+	constants   *constantFolding
+	rules       []Rule
+	severities  map[string]string
+	exercise    *ExerciseSpec
 	errors      []string
 	warnings    []string
+	diagnostics []models.Diagnostic
 	checks      []models.SemanticCheck
 }
 
 func NewSemanticAnalyzer(tokens []models.Token, ast *models.ASTNode) *SemanticAnalyzer {
+	rules, _, _ := selectRules(RuleConfig{})
 	return &SemanticAnalyzer{
 		tokens:      tokens,
 		ast:         ast,
-		symbolTable: make(map[string]models.Symbol),
-		scopes:      []string{"global"},
+		rules:       rules,
 	}
 }
 
+// SetExercise agrega al análisis los requisitos de un ejercicio
+func (s *SemanticAnalyzer) SetExercise(spec *ExerciseSpec) {
+	s.exercise = spec
+}
+
+// Configure habilita, deshabilita o cambia la severidad de las reglas para
+// este análisis; devuelve un error si se nombra una regla desconocida
+func (s *SemanticAnalyzer) Configure(config RuleConfig) error {
+	rules, severities, err := selectRules(config)
+	if err != nil {
+		return err
+	}
+	s.rules = rules
+	s.severities = severities
+	return nil
+}
+
 func (s *SemanticAnalyzer) Analyze() models.SemanticAnalysis {
 	s.buildSymbolTable()
 	
-	s.checkFactorialFunction()
-	s.checkVariableUsage()
-	s.checkFunctionCalls()
-	s.checkVariableScopes()
+	ctx := &RuleContext{
+		Tokens:    s.tokens,
+		AST:       s.ast,
+		scopes:    s.scopes,
+		graphs:    s.graphs,
+		calls:     s.calls,
+		recursion: s.recursion,
+		types:     s.types,
+		constants: s.constants,
+	}
+	for _, rule := range s.rules {
+		s.runRule(rule, ctx)
+	}
 	
-	symbolTableSlice := make([]models.Symbol, 0, len(s.symbolTable))
-	for _, symbol := range s.symbolTable {
-		symbolTableSlice = append(symbolTableSlice, symbol)
+	exercise := ""
+	if s.exercise != nil {
+		exercise = s.exercise.ID
+		s.checks = append(s.checks, evaluateExercise(s.exercise, ctx)...)
 	}
 	
 	return models.SemanticAnalysis{
-		Checks:      s.checks,
-		SymbolTable: symbolTableSlice,
-		Warnings:    s.warnings,
-		Valid:       len(s.errors) == 0,
+		Exercise:      exercise,
+		Checks:        s.checks,
+		SymbolTable:   s.scopes.symbolTable(),
+		Scopes:        s.scopes.scopeInfos(),
+		Warnings:      s.warnings,
+		Diagnostics:   s.diagnostics,
+		CallGraph:     s.calls.edges(),
+		Recursion:     s.recursion.infos(),
+		Constants:     s.constants.infos(),
+		Metrics:       computeMetrics(s.tokens, s.ast, s.scopes, s.recursion),
+		Optimizations: adviseOptimizations(s.tokens, s.ast, s.recursion, s.constants),
+		Valid:         len(s.errors) == 0,
 	}
 }
 
-func (s *SemanticAnalyzer) buildSymbolTable() {
-	if s.ast == nil {
-		return
-	}
+// runRule ejecuta una regla, aplica la severidad pedida y registra su resultado
+// como verificación aprobada o fallida
+func (s *SemanticAnalyzer) runRule(rule Rule, ctx *RuleContext) {
+	diagnostics := rule.Check(ctx)
 	
-	s.analyzeNode(s.ast, "global")
-}
-
-func (s *SemanticAnalyzer) analyzeNode(node *models.ASTNode, scope string) {
-	if node == nil {
-		return
+	check := models.SemanticCheck{
+		Rule:        rule.ID(),
+		Description: rule.Description(),
+		Passed:      len(diagnostics) == 0,
 	}
-	
-	switch node.Type {
-	case "FunctionDef":
-		s.symbolTable[node.Value] = models.Symbol{
-			Name:  node.Value,
-			Type:  "function",
-			Scope: scope,
-			Line:  node.Line,
+	for _, diagnostic := range diagnostics {
+		if check.Line == 0 {
+			check.Line = diagnostic.Line
 		}
 		
-		functionScope := scope + "." + node.Value
-		
-		for _, child := range node.Children {
-			if child.Type == "Parameter" {
-				s.symbolTable[child.Value] = models.Symbol{
-					Name:  child.Value,
-					Type:  "parameter",
-					Scope: functionScope,
-					Line:  child.Line,
-				}
-			} else {
-				s.analyzeNode(child, functionScope)
-			}
+		diagnostic.Rule = rule.ID()
+		if diagnostic.Severity == "" {
+			diagnostic.Severity = rule.Severity()
 		}
-		
-	case "Assignment":
-		s.symbolTable[node.Value] = models.Symbol{
-			Name:  node.Value,
-			Type:  "variable",
-			Scope: scope,
-			Line:  node.Line,
-		}
-		
-		for _, child := range node.Children {
-			s.analyzeNode(child, scope)
-		}
-		
-	case "Identifier":
-		if _, exists := s.symbolTable[node.Value]; !exists {
-			builtins := map[string]bool{
-				"print": true,
-				"len":   true,
-				"str":   true,
-				"int":   true,
-				"float": true,
-			}
-			
-			if !builtins[node.Value] {
-				s.warnings = append(s.warnings, fmt.Sprintf("Identificador '%s' usado sin definir en línea %d", node.Value, node.Line))
-			}
-		}
-		
-	default:
-		for _, child := range node.Children {
-			s.analyzeNode(child, scope)
+		if severity, overridden := s.severities[rule.ID()]; overridden {
+			diagnostic.Severity = severity
 		}
+		s.addDiagnostic(diagnostic)
 	}
+	s.checks = append(s.checks, check)
 }
 
-func (s *SemanticAnalyzer) checkFactorialFunction() {
-	factorialDefined := false
-	factorialUsed := false
-	factorialDefLine := 0
-	var factorialUseLines []int
-	
-	if symbol, exists := s.symbolTable["factorial"]; exists && symbol.Type == "function" {
-		factorialDefined = true
-		factorialDefLine = symbol.Line
-	}
-	
-	for _, token := range s.tokens {
-		if token.Type == "IDENTIFIER" && token.Value == "factorial" {
-			prevTokenIndex := -1
-			for i, t := range s.tokens {
-				if &t == &token {
-					if i > 0 {
-						prevTokenIndex = i - 1
-					}
-					break
-				}
-			}
-			
-			if prevTokenIndex >= 0 && s.tokens[prevTokenIndex].Value != "def" {
-				factorialUsed = true
-				factorialUseLines = append(factorialUseLines, token.Line)
-			}
-		}
+func (s *SemanticAnalyzer) addDiagnostic(diagnostic models.Diagnostic) {
+	if diagnostic.Severity == "error" {
+		s.errors = append(s.errors, diagnostic.Message)
+	} else {
+		s.warnings = append(s.warnings, diagnostic.Message)
 	}
+	s.diagnostics = append(s.diagnostics, diagnostic)
+}
+
+func (s *SemanticAnalyzer) buildSymbolTable() {
+	s.scopes = buildScopeTree(s.ast)
 	
-	definedBeforeUse := true
-	if factorialUsed && factorialDefined {
-		for _, useLine := range factorialUseLines {
-			if useLine <= factorialDefLine {
-				definedBeforeUse = false
-				break
-			}
+	s.graphs = buildFunctionCFGs(s.ast)
+	s.calls = buildCallGraph(s.ast, s.scopes)
+	s.recursion = analyzeRecursion(s.calls)
+	s.types = inferTypes(s.ast, s.scopes, s.calls, s.graphs)
+	s.constants = foldConstants(s.ast, s.scopes)
+	s.constants.annotate()
+}
+
+// suggestName propone el candidato más parecido cuando hay un error tipográfico
+func suggestName(name string, candidates []string) string {
+	best := ""
+	bestDistance := max(len(name)/3, 2) + 1
+	for _, candidate := range candidates {
+		distance := editDistance(name, candidate)
+		if distance < bestDistance || (distance == bestDistance && best != "" && candidate < best) {
+			best = candidate
+			bestDistance = distance
 		}
 	}
 	
-	s.checks = append(s.checks, models.SemanticCheck{
-		Description: "factorial está definido antes de usarse",
-		Passed:      !factorialUsed || (factorialDefined && definedBeforeUse),
-		Line:        factorialDefLine,
-	})
-	
-	if factorialUsed && !factorialDefined {
-		s.errors = append(s.errors, "Función 'factorial' usada sin definir")
+	if best == "" {
+		return ""
 	}
+	return fmt.Sprintf("; ¿quisiste decir '%s'?", best)
 }
 
-func (s *SemanticAnalyzer) checkVariableUsage() {
-	definedVars := make(map[string]int) 
-	
-	for _, token := range s.tokens {
-		if token.Type == "IDENTIFIER" {
-			isAssignment := false
-			for i, t := range s.tokens {
-				if &t == &token && i+1 < len(s.tokens) && s.tokens[i+1].Value == "=" {
-					isAssignment = true
-					definedVars[token.Value] = token.Line
-					break
-				}
-			}
-			
-			if !isAssignment {
-				if defLine, exists := definedVars[token.Value]; !exists {
-					isParameter := false
-					if symbol, symbolExists := s.symbolTable[token.Value]; symbolExists && symbol.Type == "parameter" {
-						isParameter = true
-					}
-					
-					builtins := map[string]bool{"print": true, "len": true, "str": true, "int": true, "float": true}
-					isBuiltin := builtins[token.Value]
-					
-					if !isParameter && !isBuiltin {
-						s.warnings = append(s.warnings, fmt.Sprintf("Variable '%s' usada sin definir en línea %d", token.Value, token.Line))
-					}
-				} else if defLine > token.Line {
-					s.warnings = append(s.warnings, fmt.Sprintf("Variable '%s' usada antes de definir en línea %d", token.Value, token.Line))
-				}
-			}
-		}
-	}
-	
-	nUsed := false
-	nDefined := false
-	
-	if symbol, exists := s.symbolTable["n"]; exists && symbol.Type == "parameter" {
-		nDefined = true
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
 	}
 	
-	for _, token := range s.tokens {
-		if token.Type == "IDENTIFIER" && token.Value == "n" {
-			isParamDef := false
-			for i, t := range s.tokens {
-				if &t == &token && i > 0 && s.tokens[i-1].Value == "(" {
-					isParamDef = true
-					break
-				}
-			}
-			
-			if !isParamDef {
-				nUsed = true
-				break
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
 			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
 		}
+		previous, current = current, previous
 	}
 	
-	s.checks = append(s.checks, models.SemanticCheck{
-		Description: "El parámetro 'n' es utilizado correctamente",
-		Passed:      !nDefined || nUsed,
-	})
+	return previous[len(b)]
 }
 
-func (s *SemanticAnalyzer) checkFunctionCalls() {
-	printHasArgs := false
+// checkDefinitionOrder verifica que las llamadas hechas a nivel de módulo
+// ocurran después de la definición de la función llamada
+func checkDefinitionOrder(ctx *RuleContext) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
 	
-	for i, token := range s.tokens {
-		if token.Type == "IDENTIFIER" && token.Value == "print" {
-			if i+1 < len(s.tokens) && s.tokens[i+1].Value == "(" {
-				if i+2 < len(s.tokens) && s.tokens[i+2].Value != ")" {
-					printHasArgs = true
-				}
-			}
+	for _, site := range ctx.calls.module {
+		if site.call.Line < site.callee.Line {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Code:     "call-before-definition",
+				Severity: "error",
+				Message:  fmt.Sprintf("La función '%s' se llama en línea %d antes de definirse en línea %d: NameError al ejecutarse", site.callee.Value, site.call.Line, site.callee.Line),
+				Line:     site.call.Line,
+			})
 		}
 	}
 	
-	s.checks = append(s.checks, models.SemanticCheck{
-		Description: "print recibe argumentos válidos",
-		Passed:      printHasArgs,
-	})
-	
-	recursiveCallFound := false
+	return diagnostics
+}
+
+// checkPrintArguments comprueba que el programa muestre algún resultado con print
+func checkPrintArguments(ctx *RuleContext) []models.Diagnostic {
+	if hasCallWithArguments(ctx.AST, "print") {
+		return nil
+	}
+	return []models.Diagnostic{{
+		Code:    "missing-print",
+		Message: "El programa no muestra ningún resultado: no hay una llamada a print con argumentos",
+	}}
+}
+
+// checkRecursionRequired exige una función recursiva (detectada en el grafo de
+// llamadas) cuyas llamadas avancen hacia un caso base
+func checkRecursionRequired(ctx *RuleContext) []models.Diagnostic {
+	if len(ctx.recursion.functions) == 0 {
+		return []models.Diagnostic{{
+			Code:    "missing-recursion",
+			Message: "No se encontró ninguna función recursiva",
+		}}
+	}
 	
-	for i, token := range s.tokens {
-		if token.Type == "IDENTIFIER" && token.Value == "factorial" {
-			if i > 0 && s.tokens[i-1].Value != "def" {
-				if i+1 < len(s.tokens) && s.tokens[i+1].Value == "(" {
-					recursiveCallFound = true
-				}
+	diagnostics := make([]models.Diagnostic, 0)
+	for _, rf := range ctx.recursion.functions {
+		// La regla termination ya informa como error el ciclo sin caso base
+		if !ctx.recursion.cycleHasBaseCase(rf) {
+			continue
+		}
+		for _, call := range rf.calls {
+			// ni la llamada que no avanza: no se repite como advertencia
+			if _, reported := nonDecreasingCall(ctx.recursion, call); reported {
+				continue
+			}
+			if !call.toward {
+				diagnostics = append(diagnostics, models.Diagnostic{
+					Code:    "recursion-progress",
+					Message: fmt.Sprintf("No se pudo comprobar que la llamada recursiva '%s' en línea %d avance hacia un caso base de '%s'", formatSource(call.site.call), call.site.call.Line, rf.name),
+					Line:    call.site.call.Line,
+				})
 			}
 		}
 	}
-	
-	s.checks = append(s.checks, models.SemanticCheck{
-		Description: "Se detecta llamada recursiva correcta",
-		Passed:      recursiveCallFound,
-	})
+	return diagnostics
 }
 
-func (s *SemanticAnalyzer) checkVariableScopes() {
-	scopeConflicts := false
-	conflictDetails := []string{}
-	
-	varsByName := make(map[string][]models.Symbol)
-	for _, symbol := range s.symbolTable {
-		if symbol.Type == "variable" || symbol.Type == "parameter" {
-			varsByName[symbol.Name] = append(varsByName[symbol.Name], symbol)
+func hasCallWithArguments(node *models.ASTNode, name string) bool {
+	if node == nil {
+		return false
+	}
+	if node.Type == "FunctionCall" && node.Value == name && len(node.Children) > 0 {
+		return true
+	}
+	for _, child := range node.Children {
+		if hasCallWithArguments(child, name) {
+			return true
 		}
 	}
+	return false
+}
+
+// checkScopeCollisions reporta nombres redefinidos con otro tipo en el mismo scope.
+// Reasignar variables y parámetros es normal; redefinir una función, clase o
+// módulo dentro del mismo scope (o convertir una variable en función) es una colisión
+func checkScopeCollisions(ctx *RuleContext) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
 	
-	for name, symbols := range varsByName {
-		if len(symbols) > 1 {
-			// Verificar si están en scopes diferentes (esto es válido)
-			scopes := make(map[string]bool)
-			for _, symbol := range symbols {
-				scopes[symbol.Scope] = true
+	for _, sc := range ctx.scopes.scopes {
+		for _, name := range sc.order {
+			symbol := sc.symbols[name]
+			if !symbol.definesHere() {
+				continue
 			}
 			
-			// Si hay múltiples definiciones en el mismo scope, es un conflicto
-			if len(scopes) == 1 && len(symbols) > 1 {
-				scopeConflicts = true
-				conflictDetails = append(conflictDetails, fmt.Sprintf("Variable '%s' definida múltiples veces en el mismo scope", name))
+			first := symbol.bindings[0]
+			for _, binding := range symbol.bindings[1:] {
+				if isDefinitionKind(first.kind) || isDefinitionKind(binding.kind) {
+					diagnostics = append(diagnostics, models.Diagnostic{
+						Code:    "scope-collision",
+						Message: fmt.Sprintf("'%s' definido como %s en línea %d y redefinido como %s en línea %d (scope '%s')", name, first.kind, first.line, binding.kind, binding.line, sc.name),
+					})
+					break
+				}
 			}
 		}
 	}
 	
-	s.checks = append(s.checks, models.SemanticCheck{
-		Description: "Las variables tienen alcance correcto (sin colisiones)",
-		Passed:      !scopeConflicts,
-	})
-	
-	if scopeConflicts {
-		s.warnings = append(s.warnings, conflictDetails...)
-	}
-	
-	// Verificar específicamente que x y n no colisionen
-	xSymbol, xExists := s.symbolTable["x"]
-	nSymbol, nExists := s.symbolTable["n"]
-	
-	xnConflict := false
-	if xExists && nExists {
-		// x debería estar en scope global, n en scope de función
-		if xSymbol.Scope == nSymbol.Scope {
-			xnConflict = true
+	return diagnostics
+}
+
+// checkXNCollision verifica que 'x' (global) y 'n' (parámetro) del ejercicio
+// no se definan en el mismo scope
+func checkXNCollision(ctx *RuleContext) []models.Diagnostic {
+	for _, sc := range ctx.scopes.scopes {
+		xSymbol := sc.symbols["x"]
+		nSymbol := sc.symbols["n"]
+		
+		if xSymbol != nil && nSymbol != nil && xSymbol.definesHere() && nSymbol.definesHere() {
+			return []models.Diagnostic{{
+				Code:    "x-n-collision",
+				Message: fmt.Sprintf("Las variables 'x' y 'n' se definen en el mismo scope '%s'", sc.name),
+				Line:    nSymbol.line,
+			}}
 		}
 	}
-	
-	s.checks = append(s.checks, models.SemanticCheck{
-		Description: "Variables 'x' y 'n' no colisionan (scopes separados)",
-		Passed:      !xnConflict,
-	})
+	return nil
+}
+
+func isDefinitionKind(kind string) bool {
+	return kind == "function" || kind == "class" || kind == "module"
 }
 
 // Función auxiliar para verificar si una cadena contiene otra
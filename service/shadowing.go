@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// kindNames traduce el tipo de un símbolo para los mensajes
+var kindNames = map[string]string{
+	"variable":  "la variable",
+	"parameter": "el parámetro",
+	"function":  "la función",
+	"class":     "la clase",
+	"module":    "el módulo",
+}
+
+// checkShadowing reporta nombres locales que ocultan una variable global o de
+// una función externa, parámetros con el mismo nombre que su propia función
+// (lo que rompe la llamada recursiva) y nombres que redefinen un builtin.
+// Las variables de comprensiones y los miembros de clase no se consideran.
+func checkShadowing(ctx *RuleContext) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+	noqa := parseSuppressions(ctx.Tokens)
+	report := func(symbol *scopeSymbol, code, message string) {
+		if noqa.suppressed(symbol.line, code) {
+			return
+		}
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Code:     code,
+			Severity: "warning",
+			Message:  message,
+			Line:     symbol.line,
+		})
+	}
+
+	for _, sc := range ctx.scopes.scopes {
+		if sc.kind == "class" || sc.kind == "comprehension" {
+			continue
+		}
+		owner := strings.TrimPrefix(sc.name, ctx.scopes.module.name+".")
+		where := fmt.Sprintf("dentro de '%s'", owner)
+		if sc == ctx.scopes.module {
+			where = "en el módulo"
+		}
+
+		for _, name := range sc.order {
+			symbol := sc.symbols[name]
+			if !symbol.definesHere() || isReceiverParameter(sc, symbol) {
+				continue
+			}
+
+			if isBuiltinName(name) && !strings.HasPrefix(name, "__") {
+				report(symbol, "builtin-redefined", fmt.Sprintf("%s '%s' (línea %d) redefine el builtin '%s'; %s ya no se puede usar el original", capitalize(kindName(symbol.kind)), name, symbol.line, name, where))
+			}
+			if sc == ctx.scopes.module {
+				continue
+			}
+
+			if symbol.kind == "parameter" && sc.kind == "function" && sc.node != nil && sc.node.Value == name {
+				report(symbol, "parameter-shadows-function", fmt.Sprintf("El parámetro '%s' tiene el mismo nombre que su función (línea %d): dentro del cuerpo '%s' es el argumento y las llamadas recursivas a '%s' fallarán", name, symbol.line, name, name))
+				continue
+			}
+
+			outer, enclosing := shadowedSymbol(ctx.scopes, sc, name)
+			switch {
+			case outer == nil:
+			case outer.owner == ctx.scopes.module:
+				report(symbol, "shadowed-global", fmt.Sprintf("%s '%s' de '%s' (línea %d) oculta %s global '%s' (línea %d)", capitalize(kindName(symbol.kind)), name, owner, symbol.line, kindName(outer.kind), name, outer.line))
+			default:
+				report(symbol, "shadowed-enclosing", fmt.Sprintf("%s '%s' de '%s' (línea %d) oculta %s '%s' de la función externa '%s' (línea %d)", capitalize(kindName(symbol.kind)), name, owner, symbol.line, kindName(outer.kind), name, enclosing, outer.line))
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// shadowedSymbol busca la definición que el nombre ocultaría en los scopes que
+// encierran a sc, saltando los cuerpos de clase como lo hace LEGB
+func shadowedSymbol(tree *scopeTree, sc *scope, name string) (*scopeSymbol, string) {
+	for outer := sc.parent; outer != nil; outer = outer.parent {
+		if outer.kind == "class" {
+			continue
+		}
+		if symbol := outer.symbols[name]; symbol != nil && symbol.definesHere() {
+			return symbol, strings.TrimPrefix(outer.name, tree.module.name+".")
+		}
+	}
+	return nil, ""
+}
+
+func kindName(kind string) string {
+	if name, ok := kindNames[kind]; ok {
+		return name
+	}
+	return "el nombre"
+}
+
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
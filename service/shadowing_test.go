@@ -0,0 +1,38 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShadowing(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+		want map[string][]int
+	}{
+		{"local oculta global", "n = 5\n\ndef f(x):\n    n = x + 1\n    return n\n\nprint(f(n))\n", map[string][]int{"shadowed-global": {4}}},
+		{"parámetro oculta global", "n = 5\n\ndef f(n):\n    return n\n\nprint(f(n))\n", map[string][]int{"shadowed-global": {3}}},
+		{"parámetro con el nombre de la función", "def factorial(factorial):\n    return factorial\n\nprint(factorial(3))\n", map[string][]int{"parameter-shadows-function": {1}, "shadowed-global": nil}},
+		{"función interna", "def outer(k):\n    def inner(k):\n        return k\n    return inner(k)\n\nprint(outer(1))\n", map[string][]int{"shadowed-enclosing": {2}}},
+		{"builtin en el módulo", "sum = 0\nfor i in range(3):\n    sum += i\nprint(sum)\n", map[string][]int{"builtin-redefined": {1}}},
+		{"builtin como parámetro", "def f(list):\n    return len(list)\n\nprint(f([1]))\n", map[string][]int{"builtin-redefined": {1}}},
+		{"builtin como función", "def max(a, b):\n    return a if a > b else b\n\nprint(max(1, 2))\n", map[string][]int{"builtin-redefined": {1}}},
+		{"nombres distintos", "total = 0\n\ndef f(n):\n    result = n * 2\n    return result\n\nprint(f(total))\n", map[string][]int{"shadowed-global": nil, "builtin-redefined": nil, "shadowed-enclosing": nil}},
+		{"global declarado", "count = 0\n\ndef f():\n    global count\n    count = 1\n\nf()\nprint(count)\n", map[string][]int{"shadowed-global": nil}},
+		{"variable de comprensión", "x = 1\nprint([x for x in range(3)], x)\n", map[string][]int{"shadowed-global": nil}},
+		{"método de clase", "class A:\n    def value(self):\n        return 1\n\ndef value():\n    return 2\n\nprint(A().value(), value())\n", map[string][]int{"shadowed-global": nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectDiagnostics(t, tc.code, tc.want)
+		})
+	}
+}
+
+func TestParameterShadowingFunctionMessage(t *testing.T) {
+	found := diagnosticsWithCode(analyze(t, "def factorial(factorial):\n    return factorial\n\nprint(factorial(3))\n"), "parameter-shadows-function")
+	if len(found) != 1 || found[0].Severity != "warning" || !strings.Contains(found[0].Message, "las llamadas recursivas a 'factorial' fallarán") {
+		t.Fatalf("mensaje inesperado: %v", found)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"strings"
+
+	"examen-back/models"
+)
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// sourceMapSegment enlaza una columna de una línea generada con una
+// posición del código original; todas las posiciones empiezan en 0. Un
+// sourceLine negativo marca código sin origen.
+type sourceMapSegment struct {
+	column       int
+	sourceLine   int
+	sourceColumn int
+}
+
+// sourceMapBuilder acumula los segmentos de cada línea generada y los
+// codifica en el campo mappings de un Source Map v3
+type sourceMapBuilder struct {
+	lines [][]sourceMapSegment
+}
+
+// add enlaza la columna column de la línea generada line con la posición
+// (sourceLine, sourceColumn) del código original
+func (b *sourceMapBuilder) add(line, column, sourceLine, sourceColumn int) {
+	for len(b.lines) <= line {
+		b.lines = append(b.lines, nil)
+	}
+	b.lines[line] = append(b.lines[line], sourceMapSegment{column, sourceLine, sourceColumn})
+}
+
+// unmapped marca la línea generada line como código sin origen (las
+// funciones auxiliares), para que no herede la posición de la línea anterior
+func (b *sourceMapBuilder) unmapped(line int) {
+	b.add(line, 0, -1, 0)
+}
+
+// mappings codifica los segmentos: las líneas se separan con ';', los
+// segmentos de una línea con ',' y cada campo es la diferencia con el
+// segmento anterior en VLQ base64. La columna generada se reinicia en cada
+// línea; el índice del archivo, la línea y la columna originales no.
+func (b *sourceMapBuilder) mappings() string {
+	var out strings.Builder
+	sourceLine, sourceColumn := 0, 0
+	for i, segments := range b.lines {
+		if i > 0 {
+			out.WriteByte(';')
+		}
+		column := 0
+		for j, segment := range segments {
+			if j > 0 {
+				out.WriteByte(',')
+			}
+			encodeVLQ(&out, segment.column-column)
+			column = segment.column
+			if segment.sourceLine < 0 {
+				continue
+			}
+			encodeVLQ(&out, 0)
+			encodeVLQ(&out, segment.sourceLine-sourceLine)
+			encodeVLQ(&out, segment.sourceColumn-sourceColumn)
+			sourceLine, sourceColumn = segment.sourceLine, segment.sourceColumn
+		}
+	}
+	return out.String()
+}
+
+// sourceMap arma el mapa completo para un único archivo de origen
+func (b *sourceMapBuilder) sourceMap(file, source, content string) *models.SourceMap {
+	return &models.SourceMap{
+		Version:        3,
+		File:           file,
+		Sources:        []string{source},
+		SourcesContent: []string{content},
+		Names:          []string{},
+		Mappings:       b.mappings(),
+	}
+}
+
+// encodeVLQ escribe un entero en VLQ base64: el bit menos significativo
+// del primer dígito es el signo y cada dígito lleva 5 bits de valor más
+// uno de continuación
+func encodeVLQ(out *strings.Builder, value int) {
+	vlq := value << 1
+	if value < 0 {
+		vlq = (-value << 1) | 1
+	}
+	for {
+		digit := vlq & 31
+		vlq >>= 5
+		if vlq > 0 {
+			digit |= 32
+		}
+		out.WriteByte(base64Digits[digit])
+		if vlq == 0 {
+			return
+		}
+	}
+}
@@ -5,23 +5,84 @@ import (
 	"examen-back/models"
 )
 
+// maxExpressionNesting limita el anidamiento de las expresiones, como el
+// "too many nested parentheses" de CPython: el análisis es recursivo y una
+// entrada con cientos de miles de paréntesis o signos agotaría la pila
+const maxExpressionNesting = 200
+
+// maxTreeDepth limita la profundidad del árbol de una sentencia
+const maxTreeDepth = 1000
+
+// tooDeep recorre el árbol sin recursión y devuelve la línea del primer nodo
+// que supera maxTreeDepth
+func tooDeep(root *models.ASTNode) (int, bool) {
+	type entry struct {
+		node  *models.ASTNode
+		depth int
+	}
+	pending := []entry{{root, 1}}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if current.depth > maxTreeDepth {
+			return current.node.Line, true
+		}
+		for _, child := range current.node.Children {
+			pending = append(pending, entry{child, current.depth + 1})
+		}
+	}
+	return 0, false
+}
+
 type SyntaxAnalyzer struct {
-	tokens   []models.Token
-	position int
-	errors   []string
+	tokens     []models.Token
+	lineStarts []bool
+	indents    []int
+	position   int
+	errors     []string
+	// nesting es la profundidad actual de expresiones anidadas
+	nesting int
 }
 
 func NewSyntaxAnalyzer(tokens []models.Token) *SyntaxAnalyzer {
-	// Filtrar tokens de nueva línea y comentarios para el análisis sintáctico
+	// Filtrar tokens de nueva línea y comentarios para el análisis sintáctico.
+	// Se recuerda qué tokens inician una línea lógica: su columna da la indentación.
 	filteredTokens := make([]models.Token, 0)
+	lineStarts := make([]bool, 0)
+	atLineStart := true
+	depth := 0
+	
 	for _, token := range tokens {
-		if token.Type != "NEWLINE" && token.Type != "COMMENT" {
-			filteredTokens = append(filteredTokens, token)
+		if token.Type == "NEWLINE" {
+			// Dentro de paréntesis la línea lógica continúa
+			if depth == 0 {
+				atLineStart = true
+			}
+			continue
+		}
+		if token.Type == "COMMENT" {
+			continue
+		}
+		
+		if token.Type == "SYMBOL" {
+			switch token.Value {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth > 0 {
+					depth--
+				}
+			}
 		}
+		
+		filteredTokens = append(filteredTokens, token)
+		lineStarts = append(lineStarts, atLineStart)
+		atLineStart = false
 	}
 	
 	return &SyntaxAnalyzer{
-		tokens: filteredTokens,
+		tokens:     filteredTokens,
+		lineStarts: lineStarts,
 	}
 }
 
@@ -57,76 +118,360 @@ func (s *SyntaxAnalyzer) expect(tokenType, value string) bool {
 	return true
 }
 
+// startsLine indica si el token actual es el primero de una línea lógica
+func (s *SyntaxAnalyzer) startsLine() bool {
+	return s.position < len(s.tokens) && s.lineStarts[s.position]
+}
+
+func (s *SyntaxAnalyzer) isKeyword(value string) bool {
+	token := s.peek()
+	return token != nil && token.Type == "KEYWORD" && token.Value == value
+}
+
+func (s *SyntaxAnalyzer) isSymbol(value string) bool {
+	token := s.peek()
+	return token != nil && token.Type == "SYMBOL" && token.Value == value
+}
+
+func (s *SyntaxAnalyzer) isOperator(value string) bool {
+	token := s.peek()
+	return token != nil && token.Type == "OPERATOR" && token.Value == value
+}
+
+// continues indica si el siguiente token es uno de los operadores dados y pertenece
+// a la misma línea lógica, de modo que la expresión actual puede extenderse
+func (s *SyntaxAnalyzer) continues(values ...string) bool {
+	token := s.peek()
+	if token == nil || s.startsLine() || token.Type == "STRING" {
+		return false
+	}
+	for _, value := range values {
+		if token.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SyntaxAnalyzer) atStatementEnd() bool {
+	return s.peek() == nil || s.startsLine() || s.isSymbol(";")
+}
+
+// recoverLine descarta el resto de la línea lógica tras un error
+func (s *SyntaxAnalyzer) recoverLine(start int) {
+	if s.position == start {
+		s.advance()
+	}
+	for s.peek() != nil && !s.startsLine() {
+		s.advance()
+	}
+}
+
+func (s *SyntaxAnalyzer) currentIndent() int {
+	if len(s.indents) == 0 {
+		return 0
+	}
+	return s.indents[len(s.indents)-1]
+}
+
+func newNode(nodeType, value string, token *models.Token, children ...*models.ASTNode) *models.ASTNode {
+	return &models.ASTNode{
+		Type:     nodeType,
+		Value:    value,
+		Children: children,
+		Line:     token.Line,
+		Col:      token.Col,
+	}
+}
+
+// enter abre un nivel de anidamiento; si se supera maxExpressionNesting
+// registra el error y devuelve false. Cada llamada que devuelve true se
+// cierra con leave.
+func (s *SyntaxAnalyzer) enter() bool {
+	if s.nesting >= maxExpressionNesting {
+		line := 0
+		if token := s.peek(); token != nil {
+			line = token.Line
+		}
+		s.errors = append(s.errors, fmt.Sprintf("Demasiados niveles de anidamiento en la expresión en línea %d (el máximo es %d)", line, maxExpressionNesting))
+		return false
+	}
+	s.nesting++
+	return true
+}
+
+func (s *SyntaxAnalyzer) leave() {
+	s.nesting--
+}
+
 func (s *SyntaxAnalyzer) parseExpression() *models.ASTNode {
-	return s.parseComparison()
+	if !s.enter() {
+		return nil
+	}
+	defer s.leave()
+	
+	if s.isKeyword("lambda") {
+		return s.parseLambda()
+	}
+	
+	expr := s.parseOr()
+	if expr == nil {
+		return nil
+	}
+	
+	// Expresión condicional: a if cond else b
+	if s.isKeyword("if") && !s.startsLine() {
+		ifToken := s.advance()
+		condition := s.parseOr()
+		if condition == nil {
+			return nil
+		}
+		if !s.expect("KEYWORD", "else") {
+			return nil
+		}
+		alternative := s.parseExpression()
+		if alternative == nil {
+			return nil
+		}
+		return newNode("IfExpression", "", ifToken, condition, expr, alternative)
+	}
+	
+	return expr
 }
 
-func (s *SyntaxAnalyzer) parseComparison() *models.ASTNode {
-	left := s.parseArithmetic()
+// parseExpressionList analiza "a, b, c" como una tupla sin paréntesis
+func (s *SyntaxAnalyzer) parseExpressionList() *models.ASTNode {
+	first := s.parseExpression()
+	if first == nil || !s.continues(",") {
+		return first
+	}
+	
+	tuple := &models.ASTNode{
+		Type:     "Tuple",
+		Children: []*models.ASTNode{first},
+		Line:     first.Line,
+		Col:      first.Col,
+	}
+	for s.continues(",") {
+		s.advance() // consume ','
+		if s.atExpressionEnd() {
+			break
+		}
+		element := s.parseExpression()
+		if element == nil {
+			return nil
+		}
+		tuple.Children = append(tuple.Children, element)
+	}
 	
+	return tuple
+}
+
+func (s *SyntaxAnalyzer) atExpressionEnd() bool {
+	if s.atStatementEnd() {
+		return true
+	}
 	token := s.peek()
-	if token != nil && (token.Value == "<" || token.Value == "<=" || token.Value == ">" || token.Value == ">=" || token.Value == "==" || token.Value == "!=") {
+	switch token.Value {
+	case ")", "]", "}", ":", "=", "+=", "-=", "*=", "/=", "//=", "%=", "**=":
+		return token.Type != "STRING"
+	}
+	return false
+}
+
+func (s *SyntaxAnalyzer) parseOr() *models.ASTNode {
+	left := s.parseAnd()
+	
+	for left != nil && s.continues("or") {
 		op := s.advance()
-		right := s.parseArithmetic()
-		
-		return &models.ASTNode{
-			Type:     "BinaryOp",
-			Value:    op.Value,
-			Children: []*models.ASTNode{left, right},
-			Line:     op.Line,
+		right := s.parseAnd()
+		if right == nil {
+			return nil
 		}
+		left = newNode("BinaryOp", op.Value, op, left, right)
 	}
 	
 	return left
 }
 
-func (s *SyntaxAnalyzer) parseArithmetic() *models.ASTNode {
-	left := s.parseTerm()
+func (s *SyntaxAnalyzer) parseAnd() *models.ASTNode {
+	left := s.parseNot()
 	
-	for {
-		token := s.peek()
-		if token == nil || (token.Value != "+" && token.Value != "-") {
-			break
-		}
-		
+	for left != nil && s.continues("and") {
 		op := s.advance()
-		right := s.parseTerm()
-		
-		left = &models.ASTNode{
-			Type:     "BinaryOp",
-			Value:    op.Value,
-			Children: []*models.ASTNode{left, right},
-			Line:     op.Line,
+		right := s.parseNot()
+		if right == nil {
+			return nil
 		}
+		left = newNode("BinaryOp", op.Value, op, left, right)
 	}
 	
 	return left
 }
 
-func (s *SyntaxAnalyzer) parseTerm() *models.ASTNode {
-	left := s.parseFactor()
+func (s *SyntaxAnalyzer) parseNot() *models.ASTNode {
+	if s.isKeyword("not") {
+		if !s.enter() {
+			return nil
+		}
+		defer s.leave()
+		op := s.advance()
+		operand := s.parseNot()
+		if operand == nil {
+			return nil
+		}
+		return newNode("UnaryOp", "not", op, operand)
+	}
+	
+	return s.parseComparison()
+}
+
+// comparisonOperator reconoce operadores de comparación de una o dos palabras
+func (s *SyntaxAnalyzer) comparisonOperator() (string, int) {
+	if !s.continues("<", "<=", ">", ">=", "==", "!=", "in", "not", "is") {
+		return "", 0
+	}
+	
+	token := s.peek()
+	next := ""
+	if s.position+1 < len(s.tokens) {
+		next = s.tokens[s.position+1].Value
+	}
+	
+	switch token.Value {
+	case "not":
+		if next == "in" {
+			return "not in", 2
+		}
+		return "", 0
+	case "is":
+		if next == "not" {
+			return "is not", 2
+		}
+	}
+	
+	return token.Value, 1
+}
+
+func (s *SyntaxAnalyzer) parseComparison() *models.ASTNode {
+	left := s.parseBitOr()
+	if left == nil {
+		return nil
+	}
 	
+	// Las comparaciones encadenadas (a < b < c) se expresan como (a < b) and (b < c)
+	var result *models.ASTNode
 	for {
-		token := s.peek()
-		if token == nil || (token.Value != "*" && token.Value != "/" && token.Value != "%") {
+		op, width := s.comparisonOperator()
+		if op == "" {
 			break
 		}
 		
-		op := s.advance()
-		right := s.parseFactor()
+		opToken := s.peek()
+		s.position += width
+		right := s.parseBitOr()
+		if right == nil {
+			return nil
+		}
 		
-		left = &models.ASTNode{
-			Type:     "BinaryOp",
-			Value:    op.Value,
-			Children: []*models.ASTNode{left, right},
-			Line:     op.Line,
+		comparison := newNode("BinaryOp", op, opToken, left, right)
+		if result == nil {
+			result = comparison
+		} else {
+			result = newNode("BinaryOp", "and", opToken, result, comparison)
+		}
+		left = right
+	}
+	
+	if result == nil {
+		return left
+	}
+	return result
+}
+
+// parseBinary analiza operadores binarios asociativos por la izquierda de un mismo nivel
+func (s *SyntaxAnalyzer) parseBinary(next func() *models.ASTNode, operators ...string) *models.ASTNode {
+	left := next()
+	
+	for left != nil && s.continues(operators...) {
+		op := s.advance()
+		right := next()
+		if right == nil {
+			return nil
 		}
+		left = newNode("BinaryOp", op.Value, op, left, right)
 	}
 	
 	return left
 }
 
+func (s *SyntaxAnalyzer) parseBitOr() *models.ASTNode {
+	return s.parseBinary(s.parseBitXor, "|")
+}
+
+func (s *SyntaxAnalyzer) parseBitXor() *models.ASTNode {
+	return s.parseBinary(s.parseBitAnd, "^")
+}
+
+func (s *SyntaxAnalyzer) parseBitAnd() *models.ASTNode {
+	return s.parseBinary(s.parseShift, "&")
+}
+
+func (s *SyntaxAnalyzer) parseShift() *models.ASTNode {
+	return s.parseBinary(s.parseArithmetic, "<<", ">>")
+}
+
+func (s *SyntaxAnalyzer) parseArithmetic() *models.ASTNode {
+	return s.parseBinary(s.parseTerm, "+", "-")
+}
+
+func (s *SyntaxAnalyzer) parseTerm() *models.ASTNode {
+	return s.parseBinary(s.parseUnary, "*", "/", "//", "%")
+}
+
+func (s *SyntaxAnalyzer) parseUnary() *models.ASTNode {
+	token := s.peek()
+	if token != nil && token.Type == "OPERATOR" && (token.Value == "-" || token.Value == "+" || token.Value == "~") {
+		if !s.enter() {
+			return nil
+		}
+		defer s.leave()
+		op := s.advance()
+		operand := s.parseUnary()
+		if operand == nil {
+			return nil
+		}
+		return newNode("UnaryOp", op.Value, op, operand)
+	}
+	
+	return s.parsePower()
+}
+
+func (s *SyntaxAnalyzer) parsePower() *models.ASTNode {
+	base := s.parseFactor()
+	if base == nil || !s.continues("**") {
+		return base
+	}
+	
+	// ** es asociativo por la derecha y liga más fuerte que el signo de la izquierda
+	op := s.advance()
+	exponent := s.parseUnary()
+	if exponent == nil {
+		return nil
+	}
+	return newNode("BinaryOp", op.Value, op, base, exponent)
+}
+
 func (s *SyntaxAnalyzer) parseFactor() *models.ASTNode {
+	primary := s.parsePrimary()
+	if primary == nil {
+		return nil
+	}
+	
+	return s.parsePostfix(primary)
+}
+
+func (s *SyntaxAnalyzer) parsePrimary() *models.ASTNode {
 	token := s.peek()
 	if token == nil {
 		s.errors = append(s.errors, "Se esperaba una expresión")
@@ -136,67 +481,38 @@ func (s *SyntaxAnalyzer) parseFactor() *models.ASTNode {
 	switch token.Type {
 	case "NUMBER":
 		s.advance()
-		return &models.ASTNode{
-			Type:  "Number",
-			Value: token.Value,
-			Line:  token.Line,
-		}
+		return newNode("Number", token.Value, token)
 		
 	case "STRING":
 		s.advance()
-		return &models.ASTNode{
-			Type:  "String",
-			Value: token.Value,
-			Line:  token.Line,
-		}
+		return newNode("String", token.Value, token)
 		
 	case "IDENTIFIER":
-		identifier := s.advance()
-		
-		// Verificar si es una llamada a función
-		if s.peek() != nil && s.peek().Value == "(" {
-			s.advance() // consume '('
-			
-			args := make([]*models.ASTNode, 0)
-			
-			// Parsear argumentos
-			if s.peek() != nil && s.peek().Value != ")" {
-				args = append(args, s.parseExpression())
-				
-				for s.peek() != nil && s.peek().Value == "," {
-					s.advance() // consume ','
-					args = append(args, s.parseExpression())
-				}
-			}
-			
-			if !s.expect("SYMBOL", ")") {
-				return nil
-			}
-			
-			node := &models.ASTNode{
-				Type:     "FunctionCall",
-				Value:    identifier.Value,
-				Children: args,
-				Line:     identifier.Line,
-			}
-			
-			return node
-		}
+		s.advance()
+		return newNode("Identifier", token.Value, token)
 		
-		return &models.ASTNode{
-			Type:  "Identifier",
-			Value: identifier.Value,
-			Line:  identifier.Line,
+	case "KEYWORD":
+		switch token.Value {
+		case "True", "False":
+			s.advance()
+			return newNode("Boolean", token.Value, token)
+		case "None":
+			s.advance()
+			return newNode("None", "", token)
+		case "print":
+			// print es una función integrada aunque el lexer la marque como palabra clave
+			s.advance()
+			return newNode("Identifier", token.Value, token)
 		}
 		
 	case "SYMBOL":
-		if token.Value == "(" {
-			s.advance() // consume '('
-			expr := s.parseExpression()
-			if !s.expect("SYMBOL", ")") {
-				return nil
-			}
-			return expr
+		switch token.Value {
+		case "(":
+			return s.parseParenthesized()
+		case "[":
+			return s.parseListDisplay()
+		case "{":
+			return s.parseDictDisplay()
 		}
 	}
 	
@@ -204,119 +520,814 @@ func (s *SyntaxAnalyzer) parseFactor() *models.ASTNode {
 	return nil
 }
 
-func (s *SyntaxAnalyzer) parseStatement() *models.ASTNode {
-	token := s.peek()
-	if token == nil {
-		return nil
-	}
+func (s *SyntaxAnalyzer) parseParenthesized() *models.ASTNode {
+	open := s.advance() // consume '('
 	
-	switch {
-	case token.Type == "KEYWORD" && token.Value == "def":
-		return s.parseFunctionDef()
-		
-	case token.Type == "KEYWORD" && token.Value == "if":
-		return s.parseIfStatement()
-		
-	case token.Type == "KEYWORD" && token.Value == "return":
-		return s.parseReturnStatement()
-		
-	case token.Type == "IDENTIFIER":
-		// Puede ser asignación o llamada a función
-		identifier := s.advance()
-		
-		if s.peek() != nil && s.peek().Value == "=" {
-			s.advance() // consume '='
-			value := s.parseExpression()
-			
-			return &models.ASTNode{
-				Type:  "Assignment",
-				Value: identifier.Value,
-				Children: []*models.ASTNode{value},
-				Line:  identifier.Line,
-			}
-		} else {
-			// Es una expresión (probablemente llamada a función)
-			s.position-- // retroceder
-			return s.parseExpression()
-		}
-		
-	default:
-		return s.parseExpression()
+	if s.isSymbol(")") {
+		s.advance()
+		return newNode("Tuple", "", open)
 	}
-}
-
-func (s *SyntaxAnalyzer) parseFunctionDef() *models.ASTNode {
-	if !s.expect("KEYWORD", "def") {
+	
+	expr := s.parseExpression()
+	if expr == nil {
 		return nil
 	}
 	
-	nameToken := s.peek()
-	if !s.expect("IDENTIFIER", "") {
-		return nil
+	if s.isKeyword("for") {
+		expr = s.parseComprehension("GeneratorExp", open, expr)
+	} else if s.isSymbol(",") {
+		tuple := newNode("Tuple", "", open, expr)
+		for s.isSymbol(",") {
+			s.advance() // consume ','
+			if s.isSymbol(")") {
+				break
+			}
+			element := s.parseExpression()
+			if element == nil {
+				return nil
+			}
+			tuple.Children = append(tuple.Children, element)
+		}
+		expr = tuple
 	}
 	
-	if !s.expect("SYMBOL", "(") {
+	if expr == nil || !s.expect("SYMBOL", ")") {
 		return nil
 	}
+	return expr
+}
+
+func (s *SyntaxAnalyzer) parseListDisplay() *models.ASTNode {
+	open := s.advance() // consume '['
+	list := newNode("List", "", open)
 	
-	params := make([]*models.ASTNode, 0)
-	
-	// Parsear parámetros
-	if s.peek() != nil && s.peek().Value != ")" {
-		if s.expect("IDENTIFIER", "") {
-			params = append(params, &models.ASTNode{
-				Type:  "Parameter",
-				Value: s.tokens[s.position-1].Value,
-				Line:  s.tokens[s.position-1].Line,
-			})
+	if !s.isSymbol("]") {
+		first := s.parseExpression()
+		if first == nil {
+			return nil
 		}
 		
-		for s.peek() != nil && s.peek().Value == "," {
-			s.advance() // consume ','
-			if s.expect("IDENTIFIER", "") {
-				params = append(params, &models.ASTNode{
-					Type:  "Parameter",
-					Value: s.tokens[s.position-1].Value,
-					Line:  s.tokens[s.position-1].Line,
-				})
+		if s.isKeyword("for") {
+			list = s.parseComprehension("ListComp", open, first)
+			if list == nil {
+				return nil
+			}
+		} else {
+			list.Children = append(list.Children, first)
+			for s.isSymbol(",") {
+				s.advance() // consume ','
+				if s.isSymbol("]") {
+					break
+				}
+				element := s.parseExpression()
+				if element == nil {
+					return nil
+				}
+				list.Children = append(list.Children, element)
 			}
 		}
 	}
 	
-	if !s.expect("SYMBOL", ")") {
+	if !s.expect("SYMBOL", "]") {
 		return nil
 	}
+	return list
+}
+
+// parseDictDisplay analiza diccionarios {k: v}, conjuntos {a, b} y sus comprensiones.
+// Los hijos de un Dict alternan clave y valor.
+func (s *SyntaxAnalyzer) parseDictDisplay() *models.ASTNode {
+	open := s.advance() // consume '{'
 	
-	if !s.expect("SYMBOL", ":") {
-		return nil
+	if s.isSymbol("}") {
+		s.advance()
+		return newNode("Dict", "", open)
 	}
 	
-	// Parsear cuerpo de la función (simplificado)
-	body := make([]*models.ASTNode, 0)
+	first := s.parseExpression()
+	if first == nil {
+		return nil
+	}
 	
-	// En un parser real aquí manejaríamos la indentación
-	// Por simplicidad, parseamos hasta encontrar otra función o el final
-	for s.peek() != nil && !(s.peek().Type == "KEYWORD" && s.peek().Value == "def") {
+	var display *models.ASTNode
+	if s.isSymbol(":") {
+		s.advance() // consume ':'
+		value := s.parseExpression()
+		if value == nil {
+			return nil
+		}
+		
+		if s.isKeyword("for") {
+			display = s.parseComprehension("DictComp", open, first, value)
+		} else {
+			display = newNode("Dict", "", open, first, value)
+			for s.isSymbol(",") {
+				s.advance() // consume ','
+				if s.isSymbol("}") {
+					break
+				}
+				key := s.parseExpression()
+				if key == nil || !s.expect("SYMBOL", ":") {
+					return nil
+				}
+				value := s.parseExpression()
+				if value == nil {
+					return nil
+				}
+				display.Children = append(display.Children, key, value)
+			}
+		}
+	} else if s.isKeyword("for") {
+		display = s.parseComprehension("SetComp", open, first)
+	} else {
+		display = newNode("Set", "", open, first)
+		for s.isSymbol(",") {
+			s.advance() // consume ','
+			if s.isSymbol("}") {
+				break
+			}
+			element := s.parseExpression()
+			if element == nil {
+				return nil
+			}
+			display.Children = append(display.Children, element)
+		}
+	}
+	
+	if display == nil || !s.expect("SYMBOL", "}") {
+		return nil
+	}
+	return display
+}
+
+// parseComprehension analiza las cláusulas "for objetivo in iterable [if cond]...".
+// Los hijos son los elementos producidos seguidos de un nodo Comprehension por cláusula,
+// cuyos hijos son: objetivo, iterable y condiciones.
+func (s *SyntaxAnalyzer) parseComprehension(nodeType string, start *models.Token, elements ...*models.ASTNode) *models.ASTNode {
+	node := newNode(nodeType, "", start, elements...)
+	
+	for s.isKeyword("for") {
+		forToken := s.advance()
+		target := s.parseTargetList()
+		if target == nil || !s.expect("KEYWORD", "in") {
+			return nil
+		}
+		iterable := s.parseOr()
+		if iterable == nil {
+			return nil
+		}
+		
+		clause := newNode("Comprehension", "", forToken, target, iterable)
+		for s.isKeyword("if") {
+			s.advance() // consume 'if'
+			condition := s.parseOr()
+			if condition == nil {
+				return nil
+			}
+			clause.Children = append(clause.Children, condition)
+		}
+		node.Children = append(node.Children, clause)
+	}
+	
+	return node
+}
+
+// parseTargetList analiza el objetivo de un for: "x" o "i, x"
+func (s *SyntaxAnalyzer) parseTargetList() *models.ASTNode {
+	first := s.parseBitOr()
+	if first == nil {
+		return nil
+	}
+	
+	target := first
+	if s.isSymbol(",") {
+		target = &models.ASTNode{
+			Type:     "Tuple",
+			Children: []*models.ASTNode{first},
+			Line:     first.Line,
+			Col:      first.Col,
+		}
+		for s.isSymbol(",") {
+			s.advance() // consume ','
+			if s.isKeyword("in") {
+				break
+			}
+			element := s.parseBitOr()
+			if element == nil {
+				return nil
+			}
+			target.Children = append(target.Children, element)
+		}
+	}
+	
+	if !isAssignable(target) {
+		s.errors = append(s.errors, fmt.Sprintf("No se puede asignar a la expresión en línea %d", target.Line))
+		return nil
+	}
+	return target
+}
+
+func isAssignable(node *models.ASTNode) bool {
+	switch node.Type {
+	case "Identifier", "Subscript", "Attribute":
+		return true
+	case "Tuple", "List":
+		for _, child := range node.Children {
+			if !isAssignable(child) {
+				return false
+			}
+		}
+		return len(node.Children) > 0
+	}
+	return false
+}
+
+func (s *SyntaxAnalyzer) parseLambda() *models.ASTNode {
+	lambdaToken := s.advance() // consume 'lambda'
+	
+	params, ok := s.parseParameters(":")
+	if !ok || !s.expect("SYMBOL", ":") {
+		return nil
+	}
+	
+	body := s.parseExpression()
+	if body == nil {
+		return nil
+	}
+	
+	return newNode("Lambda", "", lambdaToken, append(params, body)...)
+}
+
+// parsePostfix procesa accesos a atributos (modulo.nombre), subíndices y llamadas a función
+func (s *SyntaxAnalyzer) parsePostfix(node *models.ASTNode) *models.ASTNode {
+	for s.continues(".", "(", "[") && s.peek().Type == "SYMBOL" {
+		switch s.peek().Value {
+		case ".":
+			s.advance() // consume '.'
+			attr := s.peek()
+			if !s.expect("IDENTIFIER", "") {
+				return nil
+			}
+			node = &models.ASTNode{
+				Type:     "Attribute",
+				Value:    attr.Value,
+				Children: []*models.ASTNode{node},
+				Line:     node.Line,
+				Col:      node.Col,
+			}
+			
+		case "(":
+			args := s.parseCallArguments()
+			if args == nil {
+				return nil
+			}
+			
+			if name := dottedName(node); name != "" {
+				node = &models.ASTNode{
+					Type:     "FunctionCall",
+					Value:    name,
+					Children: args,
+					Line:     node.Line,
+					Col:      node.Col,
+				}
+			} else {
+				// Llamada sobre una expresión arbitraria: el primer hijo es el objeto llamado
+				node = &models.ASTNode{
+					Type:     "MethodCall",
+					Value:    node.Value,
+					Children: append([]*models.ASTNode{node}, args...),
+					Line:     node.Line,
+					Col:      node.Col,
+				}
+			}
+			
+		case "[":
+			s.advance() // consume '['
+			index := s.parseSubscript()
+			if index == nil || !s.expect("SYMBOL", "]") {
+				return nil
+			}
+			node = &models.ASTNode{
+				Type:     "Subscript",
+				Children: []*models.ASTNode{node, index},
+				Line:     node.Line,
+				Col:      node.Col,
+			}
+		}
+	}
+	
+	return node
+}
+
+// parseSubscript analiza el índice de a[i] o un rebanado a[inicio:fin:paso].
+// Las partes omitidas de un Slice se representan con nodos None.
+func (s *SyntaxAnalyzer) parseSubscript() *models.ASTNode {
+	start := s.peek()
+	if start == nil {
+		s.errors = append(s.errors, "Se esperaba una expresión")
+		return nil
+	}
+	
+	var first *models.ASTNode
+	if !s.isSymbol(":") {
+		first = s.parseExpressionList()
+		if first == nil {
+			return nil
+		}
+		if !s.isSymbol(":") {
+			return first
+		}
+	}
+	
+	slice := newNode("Slice", "", start)
+	parts := []*models.ASTNode{first}
+	for len(parts) < 3 && s.isSymbol(":") {
+		s.advance() // consume ':'
+		var part *models.ASTNode
+		if !s.isSymbol(":") && !s.isSymbol("]") {
+			part = s.parseExpression()
+			if part == nil {
+				return nil
+			}
+		}
+		parts = append(parts, part)
+	}
+	
+	for len(parts) < 3 {
+		parts = append(parts, nil)
+	}
+	for _, part := range parts {
+		if part == nil {
+			part = newNode("None", "", start)
+		}
+		slice.Children = append(slice.Children, part)
+	}
+	
+	return slice
+}
+
+func (s *SyntaxAnalyzer) parseCallArguments() []*models.ASTNode {
+	s.advance() // consume '('
+	
+	args := make([]*models.ASTNode, 0)
+	
+	for s.peek() != nil && s.peek().Value != ")" {
+		// Argumento con nombre: nombre=valor
+		if s.peek().Type == "IDENTIFIER" && s.position+1 < len(s.tokens) && s.tokens[s.position+1].Value == "=" {
+			name := s.advance()
+			s.advance() // consume '='
+			value := s.parseExpression()
+			if value == nil {
+				return nil
+			}
+			args = append(args, newNode("Keyword", name.Value, name, value))
+		} else {
+			start := s.peek()
+			arg := s.parseExpression()
+			if arg == nil {
+				return nil
+			}
+			// Expresión generadora como único argumento: sum(x for x in datos)
+			if s.isKeyword("for") {
+				arg = s.parseComprehension("GeneratorExp", start, arg)
+				if arg == nil {
+					return nil
+				}
+			}
+			args = append(args, arg)
+		}
+		
+		if s.peek() == nil || s.peek().Value != "," {
+			break
+		}
+		s.advance() // consume ','
+	}
+	
+	if !s.expect("SYMBOL", ")") {
+		return nil
+	}
+	
+	return args
+}
+
+// dottedName devuelve "a.b.c" si el nodo es una cadena de atributos sobre un identificador
+func dottedName(node *models.ASTNode) string {
+	switch node.Type {
+	case "Identifier":
+		return node.Value
+	case "Attribute":
+		if len(node.Children) == 1 {
+			if base := dottedName(node.Children[0]); base != "" {
+				return base + "." + node.Value
+			}
+		}
+	}
+	return ""
+}
+
+// parseLine analiza una sentencia compuesta o una o varias sentencias simples
+// separadas por ';' que ocupan una línea lógica
+func (s *SyntaxAnalyzer) parseLine() []*models.ASTNode {
+	start := s.position
+	token := s.peek()
+	
+	if token.Type == "KEYWORD" {
+		var compound *models.ASTNode
+		isCompound := true
+		
+		switch token.Value {
+		case "def":
+			compound = s.parseFunctionDef()
+		case "class":
+			compound = s.parseClassDef()
+		case "if":
+			compound = s.parseIfStatement()
+		case "while":
+			compound = s.parseWhileStatement()
+		case "for":
+			compound = s.parseForStatement()
+		case "try":
+			compound = s.parseTryStatement()
+		default:
+			isCompound = false
+		}
+		
+		if isCompound {
+			if compound == nil {
+				s.recoverLine(start)
+				return nil
+			}
+			return []*models.ASTNode{compound}
+		}
+	}
+	
+	statements := make([]*models.ASTNode, 0)
+	for {
 		stmt := s.parseStatement()
-		if stmt != nil {
-			body = append(body, stmt)
+		if stmt == nil {
+			s.recoverLine(start)
+			return statements
+		}
+		statements = append(statements, stmt)
+		
+		if s.isSymbol(";") {
+			s.advance() // consume ';'
+			if s.atStatementEnd() {
+				break
+			}
+			continue
+		}
+		
+		if !s.atStatementEnd() {
+			token := s.peek()
+			s.errors = append(s.errors, fmt.Sprintf("Se esperaba fin de sentencia en línea %d, pero se encontró '%s'", token.Line, token.Value))
+			s.recoverLine(start)
+		}
+		break
+	}
+	
+	return statements
+}
+
+// parseStatements analiza las líneas de un bloque con la indentación indicada
+func (s *SyntaxAnalyzer) parseStatements(indent int) []*models.ASTNode {
+	statements := make([]*models.ASTNode, 0)
+	
+	for s.peek() != nil && s.startsLine() && s.peek().Col >= indent {
+		if s.peek().Col > indent {
+			s.errors = append(s.errors, fmt.Sprintf("Indentación inesperada en línea %d", s.peek().Line))
+		}
+		statements = append(statements, s.parseLine()...)
+	}
+	
+	return statements
+}
+
+// parseBlock analiza el cuerpo de una sentencia compuesta después de ':'.
+// El cuerpo puede ir en la misma línea (if n <= 1: return 1) o en un bloque indentado.
+func (s *SyntaxAnalyzer) parseBlock(header *models.Token) *models.ASTNode {
+	block := newNode("Block", "", header)
+	
+	if s.peek() == nil {
+		s.errors = append(s.errors, fmt.Sprintf("Se esperaba un bloque indentado después de la línea %d", header.Line))
+		return block
+	}
+	
+	if !s.startsLine() {
+		block.Children = s.parseLine()
+		return block
+	}
+	
+	indent := s.peek().Col
+	if indent <= s.currentIndent() {
+		s.errors = append(s.errors, fmt.Sprintf("Se esperaba un bloque indentado en línea %d", s.peek().Line))
+		return block
+	}
+	
+	s.indents = append(s.indents, indent)
+	block.Children = s.parseStatements(indent)
+	s.indents = s.indents[:len(s.indents)-1]
+	
+	return block
+}
+
+// clauseFollows indica si la siguiente línea es una cláusula (else, elif) de la sentencia en la columna dada
+func (s *SyntaxAnalyzer) clauseFollows(keyword string, col int) bool {
+	return s.isKeyword(keyword) && s.startsLine() && s.peek().Col == col
+}
+
+func (s *SyntaxAnalyzer) parseStatement() *models.ASTNode {
+	token := s.peek()
+	if token == nil {
+		return nil
+	}
+	
+	if token.Type == "KEYWORD" {
+		switch token.Value {
+		case "return":
+			return s.parseReturnStatement()
+		case "import":
+			return s.parseImport()
+		case "from":
+			return s.parseImportFrom()
+		case "global":
+			return s.parseNameDeclaration("GlobalStatement")
+		case "nonlocal":
+			return s.parseNameDeclaration("NonlocalStatement")
+		case "pass":
+			s.advance()
+			return newNode("PassStatement", "", token)
+		case "break":
+			s.advance()
+			return newNode("BreakStatement", "", token)
+		case "continue":
+			s.advance()
+			return newNode("ContinueStatement", "", token)
+		case "raise":
+			return s.parseRaiseStatement()
+		case "else", "elif":
+			s.errors = append(s.errors, fmt.Sprintf("'%s' sin 'if' correspondiente en línea %d", token.Value, token.Line))
+			return nil
+		case "except", "finally":
+			s.errors = append(s.errors, fmt.Sprintf("'%s' sin 'try' correspondiente en línea %d", token.Value, token.Line))
+			return nil
+		}
+	}
+	
+	// Puede ser asignación o una expresión (probablemente llamada a función)
+	expr := s.parseExpressionList()
+	if expr == nil {
+		return nil
+	}
+	
+	if expr.Type == "Identifier" && s.continues(":") {
+		return s.parseAnnotatedAssignment(expr)
+	}
+	
+	op := s.peek()
+	if op == nil || op.Type != "OPERATOR" || s.startsLine() {
+		return expr
+	}
+	
+	switch op.Value {
+	case "=":
+		if !isAssignable(expr) {
+			s.errors = append(s.errors, fmt.Sprintf("No se puede asignar a la expresión en línea %d", op.Line))
+			return nil
+		}
+		s.advance() // consume '='
+		value := s.parseExpressionList()
+		if value == nil {
+			return nil
+		}
+		if s.continues("=") {
+			s.errors = append(s.errors, fmt.Sprintf("Asignación encadenada no soportada en línea %d", op.Line))
+			return nil
+		}
+		
+		// Asignación a un nombre: Value es el nombre; en otro caso el primer hijo es el destino
+		if expr.Type == "Identifier" {
+			return &models.ASTNode{
+				Type:     "Assignment",
+				Value:    expr.Value,
+				Children: []*models.ASTNode{value},
+				Line:     expr.Line,
+				Col:      expr.Col,
+			}
+		}
+		return &models.ASTNode{
+			Type:     "Assignment",
+			Children: []*models.ASTNode{expr, value},
+			Line:     expr.Line,
+			Col:      expr.Col,
+		}
+		
+	case "+=", "-=", "*=", "/=", "//=", "%=", "**=":
+		if expr.Type != "Identifier" && expr.Type != "Subscript" && expr.Type != "Attribute" {
+			s.errors = append(s.errors, fmt.Sprintf("Destino inválido para asignación aumentada en línea %d", op.Line))
+			return nil
+		}
+		s.advance() // consume operador
+		value := s.parseExpressionList()
+		if value == nil {
+			return nil
+		}
+		return &models.ASTNode{
+			Type:     "AugAssignment",
+			Value:    op.Value,
+			Children: []*models.ASTNode{expr, value},
+			Line:     expr.Line,
+			Col:      expr.Col,
+		}
+	}
+	
+	return expr
+}
+
+// parseParameters analiza una lista de parámetros hasta el símbolo de cierre.
+// Los parámetros *args y **kwargs conservan sus asteriscos en Value; la anotación
+// de tipo (nodo Annotation) y el valor por defecto se guardan como hijos, en ese orden.
+// En las lambdas (cierre ':') no hay anotaciones.
+func (s *SyntaxAnalyzer) parseParameters(closing string) ([]*models.ASTNode, bool) {
+	params := make([]*models.ASTNode, 0)
+	
+	for s.peek() != nil && !s.isSymbol(closing) {
+		prefix := ""
+		if s.isOperator("*") || s.isOperator("**") {
+			prefix = s.advance().Value
+		}
+		
+		nameToken := s.peek()
+		if prefix == "*" && (s.isSymbol(",") || s.isSymbol(closing)) {
+			// '*' solo: marca el inicio de los parámetros solo por nombre
+			params = append(params, newNode("Parameter", "*", nameToken))
 		} else {
+			if !s.expect("IDENTIFIER", "") {
+				return nil, false
+			}
+			param := newNode("Parameter", prefix+nameToken.Value, nameToken)
+			
+			if closing != ":" && s.isSymbol(":") {
+				annotation := s.parseAnnotation()
+				if annotation == nil {
+					return nil, false
+				}
+				param.Children = append(param.Children, annotation)
+			}
+			
+			if prefix == "" && s.isOperator("=") {
+				s.advance() // consume '='
+				defaultValue := s.parseExpression()
+				if defaultValue == nil {
+					return nil, false
+				}
+				param.Children = append(param.Children, defaultValue)
+			}
+			params = append(params, param)
+		}
+		
+		if !s.isSymbol(",") {
 			break
 		}
+		s.advance() // consume ','
+	}
+	
+	return params, true
+}
+
+func (s *SyntaxAnalyzer) parseFunctionDef() *models.ASTNode {
+	defToken := s.advance() // consume 'def'
+	
+	nameToken := s.peek()
+	if !s.expect("IDENTIFIER", "") {
+		return nil
+	}
+	
+	if !s.expect("SYMBOL", "(") {
+		return nil
+	}
+	
+	params, ok := s.parseParameters(")")
+	if !ok {
+		return nil
+	}
+	
+	if !s.expect("SYMBOL", ")") {
+		return nil
+	}
+	
+	// La anotación de retorno va entre los parámetros y el cuerpo
+	children := params
+	if s.isSymbol("->") {
+		returns := s.parseAnnotation()
+		if returns == nil {
+			return nil
+		}
+		children = append(children, returns)
+	}
+	
+	if !s.expect("SYMBOL", ":") {
+		return nil
 	}
 	
-	children := append(params, body...)
+	body := s.parseBlock(defToken)
+	children = append(children, body.Children...)
+	
+	return newNode("FunctionDef", nameToken.Value, nameToken, children...)
+}
+
+// parseAnnotation consume ':' o '->' y devuelve un nodo Annotation con la expresión de tipo
+func (s *SyntaxAnalyzer) parseAnnotation() *models.ASTNode {
+	start := s.advance() // consume ':' o '->'
+	
+	expr := s.parseExpression()
+	if expr == nil {
+		return nil
+	}
+	return newNode("Annotation", "", start, expr)
+}
+
+// parseAnnotatedAssignment analiza "x: int = 5"; la anotación queda como último
+// hijo de la asignación. Sin valor ("x: int") produce un nodo AnnotatedName.
+func (s *SyntaxAnalyzer) parseAnnotatedAssignment(target *models.ASTNode) *models.ASTNode {
+	annotation := s.parseAnnotation()
+	if annotation == nil {
+		return nil
+	}
+	
+	if !s.continues("=") {
+		return &models.ASTNode{
+			Type:     "AnnotatedName",
+			Value:    target.Value,
+			Children: []*models.ASTNode{annotation},
+			Line:     target.Line,
+			Col:      target.Col,
+		}
+	}
 	
+	s.advance() // consume '='
+	value := s.parseExpressionList()
+	if value == nil {
+		return nil
+	}
 	return &models.ASTNode{
-		Type:     "FunctionDef",
-		Value:    nameToken.Value,
-		Children: children,
-		Line:     nameToken.Line,
+		Type:     "Assignment",
+		Value:    target.Value,
+		Children: []*models.ASTNode{value, annotation},
+		Line:     target.Line,
+		Col:      target.Col,
 	}
 }
 
+// parseClassDef analiza "class Nombre(Base):"; las bases se guardan como nodos Base
+// seguidos del cuerpo de la clase
+func (s *SyntaxAnalyzer) parseClassDef() *models.ASTNode {
+	classToken := s.advance() // consume 'class'
+	
+	nameToken := s.peek()
+	if !s.expect("IDENTIFIER", "") {
+		return nil
+	}
+	
+	children := make([]*models.ASTNode, 0)
+	if s.isSymbol("(") {
+		s.advance() // consume '('
+		for s.peek() != nil && !s.isSymbol(")") {
+			base := s.parseExpression()
+			if base == nil {
+				return nil
+			}
+			children = append(children, &models.ASTNode{
+				Type:     "Base",
+				Children: []*models.ASTNode{base},
+				Line:     base.Line,
+				Col:      base.Col,
+			})
+			if !s.isSymbol(",") {
+				break
+			}
+			s.advance() // consume ','
+		}
+		if !s.expect("SYMBOL", ")") {
+			return nil
+		}
+	}
+	
+	if !s.expect("SYMBOL", ":") {
+		return nil
+	}
+	
+	body := s.parseBlock(classToken)
+	children = append(children, body.Children...)
+	
+	return newNode("ClassDef", nameToken.Value, nameToken, children...)
+}
+
+// parseIfStatement produce un IfStatement con hijos: condición, bloque then y,
+// opcionalmente, bloque else. Un elif se representa como un else cuyo único
+// hijo es otro IfStatement.
 func (s *SyntaxAnalyzer) parseIfStatement() *models.ASTNode {
-	ifToken := s.advance() // consume 'if'
+	ifToken := s.advance() // consume 'if' o 'elif'
 	
 	condition := s.parseExpression()
 	if condition == nil {
@@ -327,68 +1338,335 @@ func (s *SyntaxAnalyzer) parseIfStatement() *models.ASTNode {
 		return nil
 	}
 	
-	// Parsear cuerpo del if
-	thenBody := make([]*models.ASTNode, 0)
+	thenBlock := s.parseBlock(ifToken)
+	node := newNode("IfStatement", "", ifToken, condition, thenBlock)
 	
-	// Simplificado: parseamos hasta encontrar 'else' o el final
-	for s.peek() != nil && !(s.peek().Type == "KEYWORD" && s.peek().Value == "else") {
-		stmt := s.parseStatement()
-		if stmt != nil {
-			thenBody = append(thenBody, stmt)
-		} else {
-			break
+	if s.clauseFollows("elif", ifToken.Col) {
+		elifToken := s.peek()
+		elif := s.parseIfStatement()
+		if elif == nil {
+			return node
 		}
+		node.Children = append(node.Children, newNode("Block", "", elifToken, elif))
+	} else if s.clauseFollows("else", ifToken.Col) {
+		elseToken := s.advance() // consume 'else'
+		if !s.expect("SYMBOL", ":") {
+			return node
+		}
+		node.Children = append(node.Children, s.parseBlock(elseToken))
 	}
 	
-	children := []*models.ASTNode{condition}
-	children = append(children, thenBody...)
+	return node
+}
+
+// parseWhileStatement produce hijos: condición, cuerpo y bloque else opcional
+func (s *SyntaxAnalyzer) parseWhileStatement() *models.ASTNode {
+	whileToken := s.advance() // consume 'while'
 	
-	// Verificar si hay else
-	if s.peek() != nil && s.peek().Type == "KEYWORD" && s.peek().Value == "else" {
-		s.advance() // consume 'else'
-		if !s.expect("SYMBOL", ":") {
-			return nil
-		}
+	condition := s.parseExpression()
+	if condition == nil || !s.expect("SYMBOL", ":") {
+		return nil
+	}
+	
+	node := newNode("WhileStatement", "", whileToken, condition, s.parseBlock(whileToken))
+	s.parseLoopElse(node, whileToken.Col)
+	
+	return node
+}
+
+// parseForStatement produce hijos: objetivo, iterable, cuerpo y bloque else opcional
+func (s *SyntaxAnalyzer) parseForStatement() *models.ASTNode {
+	forToken := s.advance() // consume 'for'
+	
+	target := s.parseTargetList()
+	if target == nil || !s.expect("KEYWORD", "in") {
+		return nil
+	}
+	
+	iterable := s.parseExpressionList()
+	if iterable == nil || !s.expect("SYMBOL", ":") {
+		return nil
+	}
+	
+	node := newNode("ForStatement", "", forToken, target, iterable, s.parseBlock(forToken))
+	s.parseLoopElse(node, forToken.Col)
+	
+	return node
+}
+
+func (s *SyntaxAnalyzer) parseLoopElse(node *models.ASTNode, col int) {
+	if !s.clauseFollows("else", col) {
+		return
+	}
+	
+	elseToken := s.advance() // consume 'else'
+	if s.expect("SYMBOL", ":") {
+		node.Children = append(node.Children, s.parseBlock(elseToken))
+	}
+}
+
+// parseTryStatement produce hijos: bloque try, un ExceptHandler por cláusula except
+// y los bloques opcionales con Value "else" y "finally". Los hijos de un
+// ExceptHandler son el tipo de excepción (opcional) y su bloque; el nombre
+// ligado con 'as' se guarda en Value.
+func (s *SyntaxAnalyzer) parseTryStatement() *models.ASTNode {
+	tryToken := s.advance() // consume 'try'
+	
+	if !s.expect("SYMBOL", ":") {
+		return nil
+	}
+	
+	node := newNode("TryStatement", "", tryToken, s.parseBlock(tryToken))
+	
+	for s.clauseFollows("except", tryToken.Col) {
+		exceptToken := s.advance() // consume 'except'
+		handler := newNode("ExceptHandler", "", exceptToken)
 		
-		// Parsear cuerpo del else
-		elseBody := make([]*models.ASTNode, 0)
-		for s.peek() != nil {
-			stmt := s.parseStatement()
-			if stmt != nil {
-				elseBody = append(elseBody, stmt)
-			} else {
-				break
+		if !s.isSymbol(":") {
+			exceptionType := s.parseExpression()
+			if exceptionType == nil {
+				return node
+			}
+			handler.Children = append(handler.Children, exceptionType)
+			
+			if s.isKeyword("as") {
+				s.advance() // consume 'as'
+				nameToken := s.peek()
+				if !s.expect("IDENTIFIER", "") {
+					return node
+				}
+				handler.Value = nameToken.Value
 			}
 		}
 		
-		children = append(children, elseBody...)
+		if !s.expect("SYMBOL", ":") {
+			return node
+		}
+		handler.Children = append(handler.Children, s.parseBlock(exceptToken))
+		node.Children = append(node.Children, handler)
 	}
 	
-	return &models.ASTNode{
-		Type:     "IfStatement",
-		Children: children,
-		Line:     ifToken.Line,
+	hasHandlers := len(node.Children) > 1
+	if hasHandlers && s.clauseFollows("else", tryToken.Col) {
+		node.Children = append(node.Children, s.parseClauseBlock("else"))
 	}
+	
+	if s.clauseFollows("finally", tryToken.Col) {
+		node.Children = append(node.Children, s.parseClauseBlock("finally"))
+	} else if !hasHandlers {
+		s.errors = append(s.errors, fmt.Sprintf("Se esperaba 'except' o 'finally' después del bloque try de la línea %d", tryToken.Line))
+	}
+	
+	return node
+}
+
+// parseClauseBlock analiza "else:" o "finally:" y etiqueta el bloque con la palabra clave
+func (s *SyntaxAnalyzer) parseClauseBlock(keyword string) *models.ASTNode {
+	clauseToken := s.advance() // consume la palabra clave
+	if !s.expect("SYMBOL", ":") {
+		return newNode("Block", keyword, clauseToken)
+	}
+	
+	block := s.parseBlock(clauseToken)
+	block.Value = keyword
+	return block
+}
+
+// parseRaiseStatement produce hijos: excepción (opcional) y causa de "raise ... from ..."
+func (s *SyntaxAnalyzer) parseRaiseStatement() *models.ASTNode {
+	raiseToken := s.advance() // consume 'raise'
+	
+	node := newNode("RaiseStatement", "", raiseToken)
+	if s.atStatementEnd() {
+		return node
+	}
+	
+	exception := s.parseExpression()
+	if exception == nil {
+		return nil
+	}
+	node.Children = append(node.Children, exception)
+	
+	if s.isKeyword("from") {
+		s.advance() // consume 'from'
+		cause := s.parseExpression()
+		if cause == nil {
+			return nil
+		}
+		node.Children = append(node.Children, cause)
+	}
+	
+	return node
 }
 
 func (s *SyntaxAnalyzer) parseReturnStatement() *models.ASTNode {
 	returnToken := s.advance() // consume 'return'
 	
-	var value *models.ASTNode
-	if s.peek() != nil && s.peek().Type != "KEYWORD" {
-		value = s.parseExpression()
+	node := newNode("ReturnStatement", "", returnToken)
+	if !s.atStatementEnd() {
+		value := s.parseExpressionList()
+		if value == nil {
+			return nil
+		}
+		node.Children = append(node.Children, value)
 	}
 	
-	children := make([]*models.ASTNode, 0)
-	if value != nil {
-		children = append(children, value)
+	return node
+}
+
+// parseNameDeclaration analiza "global a, b" y "nonlocal a, b"
+func (s *SyntaxAnalyzer) parseNameDeclaration(nodeType string) *models.ASTNode {
+	keyword := s.advance() // consume 'global' o 'nonlocal'
+	node := newNode(nodeType, "", keyword)
+	
+	for {
+		nameToken := s.peek()
+		if !s.expect("IDENTIFIER", "") {
+			return nil
+		}
+		node.Children = append(node.Children, newNode("Identifier", nameToken.Value, nameToken))
+		
+		if !s.isSymbol(",") {
+			break
+		}
+		s.advance() // consume ','
 	}
 	
-	return &models.ASTNode{
-		Type:     "ReturnStatement",
-		Children: children,
-		Line:     returnToken.Line,
+	return node
+}
+
+func (s *SyntaxAnalyzer) parseImport() *models.ASTNode {
+	importToken := s.advance() // consume 'import'
+	
+	node := newNode("Import", "", importToken)
+	for {
+		alias := s.parseImportAlias(true)
+		if alias == nil {
+			return nil
+		}
+		node.Children = append(node.Children, alias)
+		
+		if !s.isSymbol(",") {
+			break
+		}
+		s.advance() // consume ','
+	}
+	
+	return node
+}
+
+func (s *SyntaxAnalyzer) parseImportFrom() *models.ASTNode {
+	fromToken := s.advance() // consume 'from'
+	
+	// Importaciones relativas: from . import x, from ..pkg import y
+	module := ""
+	for s.isSymbol(".") {
+		s.advance()
+		module += "."
+	}
+	
+	if s.peek() != nil && s.peek().Type == "IDENTIFIER" {
+		name := s.parseDottedName()
+		if name == "" {
+			return nil
+		}
+		module += name
+	} else if module == "" {
+		s.expect("IDENTIFIER", "")
+		return nil
 	}
+	
+	if !s.expect("KEYWORD", "import") {
+		return nil
+	}
+	
+	node := newNode("ImportFrom", module, fromToken)
+	
+	if s.isOperator("*") {
+		star := s.advance()
+		node.Children = append(node.Children, newNode("Alias", "*", star))
+		return node
+	}
+	
+	parenthesized := s.isSymbol("(")
+	if parenthesized {
+		s.advance() // consume '('
+	}
+	
+	for {
+		alias := s.parseImportAlias(false)
+		if alias == nil {
+			return nil
+		}
+		node.Children = append(node.Children, alias)
+		
+		if !s.isSymbol(",") {
+			break
+		}
+		s.advance() // consume ','
+		
+		// Se permite una coma final dentro de los paréntesis
+		if parenthesized && s.isSymbol(")") {
+			break
+		}
+	}
+	
+	if parenthesized && !s.expect("SYMBOL", ")") {
+		return nil
+	}
+	
+	return node
+}
+
+// parseImportAlias lee "nombre [as alias]"; el alias se guarda como hijo Identifier
+func (s *SyntaxAnalyzer) parseImportAlias(dotted bool) *models.ASTNode {
+	nameToken := s.peek()
+	if nameToken == nil || nameToken.Type != "IDENTIFIER" {
+		s.expect("IDENTIFIER", "")
+		return nil
+	}
+	
+	name := nameToken.Value
+	if dotted {
+		name = s.parseDottedName()
+	} else {
+		s.advance()
+	}
+	if name == "" {
+		return nil
+	}
+	
+	alias := newNode("Alias", name, nameToken)
+	
+	if s.isKeyword("as") {
+		s.advance() // consume 'as'
+		asToken := s.peek()
+		if !s.expect("IDENTIFIER", "") {
+			return nil
+		}
+		alias.Children = append(alias.Children, newNode("Identifier", asToken.Value, asToken))
+	}
+	
+	return alias
+}
+
+func (s *SyntaxAnalyzer) parseDottedName() string {
+	first := s.peek()
+	if !s.expect("IDENTIFIER", "") {
+		return ""
+	}
+	
+	name := first.Value
+	for s.isSymbol(".") {
+		s.advance() // consume '.'
+		part := s.peek()
+		if !s.expect("IDENTIFIER", "") {
+			return ""
+		}
+		name += "." + part.Value
+	}
+	
+	return name
 }
 
 func (s *SyntaxAnalyzer) Analyze() models.SyntaxAnalysis {
@@ -402,24 +1680,34 @@ func (s *SyntaxAnalyzer) Analyze() models.SyntaxAnalysis {
 	// Verificaciones básicas
 	s.checkBasicSyntax()
 	
-	// Construir AST
+	// Construir AST: el nivel superior del programa está en la columna 1
 	statements := make([]*models.ASTNode, 0)
 	
 	for s.position < len(s.tokens) {
-		stmt := s.parseStatement()
-		if stmt != nil {
-			statements = append(statements, stmt)
-		}
+		start := s.position
+		statements = append(statements, s.parseStatements(1)...)
 		
-		// Evitar bucle infinito
-		if s.position >= len(s.tokens) {
-			break
+		// Si no se consumió ningún token, saltar el token problemático
+		if s.position == start {
+			s.advance()
+		}
+	}
+	
+	// Una cadena larga como 1+1+...+1 se analiza en un bucle, pero su árbol
+	// es tan profundo como larga es la cadena y los análisis posteriores lo
+	// recorren con recursión: esas sentencias se descartan con un error
+	kept := statements[:0]
+	for _, stmt := range statements {
+		if line, deep := tooDeep(stmt); deep {
+			s.errors = append(s.errors, fmt.Sprintf("La expresión en línea %d es demasiado compleja: su árbol supera los %d niveles", line, maxTreeDepth))
+			continue
 		}
+		kept = append(kept, stmt)
 	}
 	
 	ast := &models.ASTNode{
 		Type:     "Program",
-		Children: statements,
+		Children: kept,
 		Line:     1,
 	}
 	
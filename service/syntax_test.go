@@ -0,0 +1,67 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"examen-back/models"
+)
+
+// parse analiza un programa con el lexer y el parser del servicio
+func parse(code string) models.SyntaxAnalysis {
+	tokens := NewLexicalAnalyzer(code).Tokenize().Tokens
+	return NewSyntaxAnalyzer(tokens).Analyze()
+}
+
+func TestParseNestingLimit(t *testing.T) {
+	cases := []struct {
+		name  string
+		code  string
+		valid bool
+	}{
+		{"paréntesis dentro del límite", "x = " + strings.Repeat("(", 150) + "1" + strings.Repeat(")", 150) + "\n", true},
+		{"paréntesis", "x = " + strings.Repeat("(", 300000) + "1" + strings.Repeat(")", 300000) + "\n", false},
+		{"listas", "x = " + strings.Repeat("[", 300000) + strings.Repeat("]", 300000) + "\n", false},
+		{"signos", "x = " + strings.Repeat("-", 300000) + "1\n", false},
+		{"not", "x = " + strings.Repeat("not ", 300000) + "1\n", false},
+		{"lambdas", "f = " + strings.Repeat("lambda: ", 300000) + "1\n", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := parse(tc.code)
+			if result.Valid != tc.valid {
+				t.Fatalf("Valid = %v, se esperaba %v (errores: %v)", result.Valid, tc.valid, result.Errors)
+			}
+			if !tc.valid && !strings.Contains(strings.Join(result.Errors, "\n"), "Demasiados niveles de anidamiento") {
+				t.Errorf("falta el error de anidamiento: %v", result.Errors)
+			}
+		})
+	}
+}
+
+func TestParseTreeDepthLimit(t *testing.T) {
+	sum := func(terms int) string {
+		return "x = " + strings.Repeat("1 + ", terms-1) + "1\nprint(x)\n"
+	}
+	cases := []struct {
+		name  string
+		code  string
+		valid bool
+	}{
+		{"suma corta", sum(500), true},
+		{"suma larga", sum(100000), false},
+		{"llamadas encadenadas", "f" + strings.Repeat("()", 100000) + "\nprint(1)\n", false},
+		{"índices encadenados", "x" + strings.Repeat("[0]", 100000) + "\nprint(1)\n", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := parse(tc.code)
+			if result.Valid != tc.valid {
+				t.Fatalf("Valid = %v, se esperaba %v (errores: %v)", result.Valid, tc.valid, result.Errors)
+			}
+			if !tc.valid && len(result.AST.Children) != 1 {
+				t.Errorf("la sentencia demasiado profunda debe descartarse del AST")
+			}
+		})
+	}
+}
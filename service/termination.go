@@ -0,0 +1,336 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// checkTermination aplica heurísticas de terminación sobre las funciones
+// recursivas para anticipar un RecursionError: falta de caso base, casos base
+// de igualdad que los pasos de la recursión saltan, y argumentos que no
+// avanzan. constants da los argumentos constantes de las llamadas.
+func checkTermination(analysis *recursionAnalysis, constants *constantFolding) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+	reportedCycles := make(map[*models.ASTNode]bool)
+
+	for _, rf := range analysis.functions {
+		if !analysis.cycleHasBaseCase(rf) {
+			if len(rf.cycle) > 0 && reportedCycles[rf.cycle[0]] {
+				continue
+			}
+			diagnostics = append(diagnostics, missingBaseCase(analysis, rf))
+			if len(rf.cycle) > 0 {
+				reportedCycles[rf.cycle[0]] = true
+			}
+			continue
+		}
+
+		diagnostics = append(diagnostics, unreachableBaseCases(analysis, constants, rf)...)
+
+		for _, call := range rf.calls {
+			if diagnostic, found := nonDecreasingCall(analysis, call); found {
+				diagnostics = append(diagnostics, diagnostic)
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+func (a *recursionAnalysis) cycleHasBaseCase(rf *recursiveFunction) bool {
+	if len(rf.baseCases) > 0 {
+		return true
+	}
+	for _, member := range rf.cycle {
+		if len(a.byNode[member].baseCases) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func missingBaseCase(analysis *recursionAnalysis, rf *recursiveFunction) models.Diagnostic {
+	message := fmt.Sprintf("La función recursiva '%s' (línea %d) no tiene caso base: todos sus caminos vuelven a llamarse y la ejecución termina en RecursionError", rf.name, rf.node.Line)
+	if len(rf.cycle) > 0 {
+		names := make([]string, len(rf.cycle))
+		for i, member := range rf.cycle {
+			names[i] = "'" + analysis.graph.names[member] + "'"
+		}
+		message = fmt.Sprintf("Las funciones mutuamente recursivas %s no tienen caso base: se llaman entre sí en todos los caminos y la ejecución termina en RecursionError", strings.Join(names, ", "))
+	}
+
+	// Con try o while la búsqueda no es exacta: no encontrar el caso base no
+	// prueba que falte
+	severity := "error"
+	for _, member := range append([]*models.ASTNode{rf.node}, rf.cycle...) {
+		if other := analysis.byNode[member]; other != nil && other.uncertain {
+			severity = "warning"
+			message = fmt.Sprintf("No se reconoce un caso base en la función recursiva '%s' (línea %d): puede terminar por una excepción capturada o al salir de un while, pero si todos sus caminos vuelven a llamarse la ejecución termina en RecursionError", rf.name, rf.node.Line)
+			break
+		}
+	}
+
+	return models.Diagnostic{
+		Code:     "missing-base-case",
+		Severity: severity,
+		Message:  message,
+		Line:     rf.node.Line,
+	}
+}
+
+// unreachableBaseCases detecta parámetros cuyos únicos casos base son
+// igualdades ("n == 0") y alguna llamada los salta: con pasos de k, un valor
+// entre el menor caso base y k-1 por encima que no sea caso base pasa de largo.
+// Si todas las llamadas desde fuera de la recursión pasan enteros constantes,
+// se siguen esos valores: solo es un error si alguno salta los casos base. Si
+// no se conocen, el salto es posible pero no seguro y se avisa con un warning.
+func unreachableBaseCases(analysis *recursionAnalysis, constants *constantFolding, rf *recursiveFunction) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+
+	for position, param := range rf.params {
+		equalities := make(map[int64]baseCase)
+		lowest := int64(0)
+		onlyEqualities := true
+		for _, base := range rf.baseCases {
+			if base.loop {
+				onlyEqualities = false
+			}
+			for _, guard := range base.guards {
+				if guard.param != param {
+					continue
+				}
+				if guard.op != "==" || guard.length {
+					onlyEqualities = false
+					continue
+				}
+				if len(equalities) == 0 || guard.value < lowest {
+					lowest = guard.value
+				}
+				equalities[guard.value] = base
+			}
+		}
+		if !onlyEqualities || len(equalities) == 0 {
+			continue
+		}
+		entries, known := entryValues(analysis, constants, rf, position)
+
+		for _, call := range rf.calls {
+			if call.site.callee != rf.node || position >= len(call.args) {
+				continue
+			}
+			step := call.args[position]
+			if step.progress != "decreasing" || step.amount < 2 {
+				continue
+			}
+
+			for offset := int64(1); offset < step.amount; offset++ {
+				skipped := lowest + offset
+				if _, isBase := equalities[skipped]; isBase {
+					continue
+				}
+
+				base := equalities[lowest]
+				if !known {
+					diagnostics = append(diagnostics, models.Diagnostic{
+						Code:     "unreachable-base-case",
+						Severity: "warning",
+						Message: fmt.Sprintf("El caso base '%s' (línea %d) puede no alcanzarse: la llamada '%s' en línea %d reduce '%s' de %d en %d, así que con %s = %d pasa a %d sin cumplirlo y la recursión no termina; use una condición como '%s <= %d'",
+							base.condition, base.line, formatSource(call.site.call), call.site.call.Line, param, step.amount, step.amount, param, skipped, skipped-step.amount, param, lowest),
+						Line: call.site.call.Line,
+					})
+					break
+				}
+
+				for _, entry := range entries {
+					from, escape, ok := skipsBaseCases(rf, position, entry.value, equalities, lowest)
+					if !ok {
+						continue
+					}
+					diagnostics = append(diagnostics, models.Diagnostic{
+						Code:     "unreachable-base-case",
+						Severity: "error",
+						Message: fmt.Sprintf("El caso base '%s' (línea %d) no se alcanza desde la llamada '%s' en línea %d: la llamada '%s' en línea %d reduce '%s' de %d en %d, así que pasa de %d a %d sin cumplirlo y la recursión no termina; use una condición como '%s <= %d'",
+							base.condition, base.line, formatSource(entry.call), entry.call.Line, formatSource(escape.site.call), escape.site.call.Line,
+							param, escape.args[position].amount, escape.args[position].amount, from, from-escape.args[position].amount, param, lowest),
+						Line: call.site.call.Line,
+					})
+					break
+				}
+				break
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// entryCall es una llamada a una función recursiva desde fuera de su
+// recursión con un entero constante en el parámetro analizado
+type entryCall struct {
+	call  *models.ASTNode
+	value int64
+}
+
+// entryValues devuelve los valores constantes con que el programa llama a
+// rf desde fuera de su recursión en el parámetro position. known es falso si
+// no hay llamadas, si alguna no pasa un entero constante o si la llama otra
+// función del ciclo.
+func entryValues(analysis *recursionAnalysis, constants *constantFolding, rf *recursiveFunction, position int) ([]entryCall, bool) {
+	sites := append([]callSite{}, analysis.graph.module...)
+	for _, fn := range analysis.graph.functions {
+		sites = append(sites, analysis.graph.calls[fn]...)
+	}
+
+	entries := make([]entryCall, 0)
+	for _, site := range sites {
+		if site.callee != rf.node || site.caller == rf.node {
+			continue
+		}
+		for _, member := range rf.cycle {
+			if site.caller == member {
+				return nil, false
+			}
+		}
+		arg := argumentAt(site.call, position, rf.params[position])
+		if arg == nil || constants == nil {
+			return nil, false
+		}
+		value, ok := constants.valueOf(arg)
+		if !ok || value.kind != "int" || !value.i.IsInt64() {
+			return nil, false
+		}
+		entries = append(entries, entryCall{call: site.call, value: value.i.Int64()})
+	}
+	return entries, len(entries) > 0
+}
+
+// argumentAt devuelve el argumento de una llamada para el parámetro param,
+// que ocupa la posición position, o nil si no se puede determinar
+func argumentAt(call *models.ASTNode, position int, param string) *models.ASTNode {
+	index := 0
+	for _, arg := range call.Children {
+		switch arg.Type {
+		case "Keyword":
+			if arg.Value == param && len(arg.Children) > 0 {
+				return arg.Children[0]
+			}
+		case "Starred":
+			return nil
+		default:
+			if index == position {
+				return arg
+			}
+			index++
+		}
+	}
+	return nil
+}
+
+// maxFollowedValues limita los valores que skipsBaseCases sigue desde una
+// llamada; con más no se puede afirmar nada y no se informa error
+const maxFollowedValues = 100000
+
+// skipsBaseCases sigue los valores que toma el parámetro desde start con los
+// pasos de las llamadas recursivas. Si alguno queda por debajo del menor caso
+// base sin cumplir ninguno, devuelve el valor desde el que se salta y la
+// llamada que lo salta.
+func skipsBaseCases(rf *recursiveFunction, position int, start int64, equalities map[int64]baseCase, lowest int64) (int64, recursiveCall, bool) {
+	if start < lowest || start-lowest > maxFollowedValues {
+		return 0, recursiveCall{}, false
+	}
+	steps := make([]recursiveCall, 0, len(rf.calls))
+	for _, call := range rf.calls {
+		if call.site.callee != rf.node || position >= len(call.args) {
+			continue
+		}
+		if step := call.args[position]; step.progress != "decreasing" || step.amount < 1 {
+			// Con pasos desconocidos no se puede seguir los valores
+			return 0, recursiveCall{}, false
+		}
+		steps = append(steps, call)
+	}
+
+	visited := map[int64]bool{start: true}
+	pending := []int64{start}
+	for len(pending) > 0 {
+		value := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if _, isBase := equalities[value]; isBase {
+			continue
+		}
+		for _, call := range steps {
+			next := value - call.args[position].amount
+			if next < lowest {
+				return value, call, true
+			}
+			if !visited[next] {
+				visited[next] = true
+				pending = append(pending, next)
+			}
+		}
+	}
+	return 0, recursiveCall{}, false
+}
+
+// nonDecreasingCall informa una llamada recursiva en la que ningún parámetro
+// con caso base avanza hacia él (todos se pasan sin cambios o se alejan) y
+// los demás argumentos tampoco cambian
+func nonDecreasingCall(analysis *recursionAnalysis, call recursiveCall) (models.Diagnostic, bool) {
+	callee := analysis.byNode[call.site.callee]
+	if callee == nil || call.toward || call.inLoop {
+		return models.Diagnostic{}, false
+	}
+
+	var guarded []argumentStep
+	var guardText string
+	for position, step := range call.args {
+		if position >= len(callee.params) {
+			break
+		}
+		hasGuard := false
+		for _, base := range callee.baseCases {
+			for _, guard := range base.guards {
+				if guard.param == callee.params[position] {
+					hasGuard = true
+					if guardText == "" {
+						guardText = base.condition
+					}
+				}
+			}
+		}
+		if hasGuard {
+			guarded = append(guarded, step)
+		} else if step.progress != "unchanged" && step.progress != "unrelated" {
+			// Otro argumento cambia: puede ser un orden lexicográfico como en Ackermann
+			return models.Diagnostic{}, false
+		}
+	}
+	if len(guarded) == 0 {
+		return models.Diagnostic{}, false
+	}
+
+	explanations := make([]string, 0, len(guarded))
+	for _, step := range guarded {
+		switch step.progress {
+		case "unchanged":
+			explanations = append(explanations, fmt.Sprintf("'%s' se pasa sin cambios", step.param))
+		case "increasing":
+			explanations = append(explanations, fmt.Sprintf("'%s' crece ('%s')", step.param, formatSource(step.arg)))
+		case "decreasing", "halving", "shrinking":
+			explanations = append(explanations, fmt.Sprintf("'%s' se aleja del caso base ('%s')", step.param, formatSource(step.arg)))
+		default:
+			// Sin información suficiente no se puede afirmar que no termine
+			return models.Diagnostic{}, false
+		}
+	}
+
+	return models.Diagnostic{
+		Code:     "non-decreasing-recursion",
+		Severity: "error",
+		Message: fmt.Sprintf("La llamada recursiva '%s' en línea %d no avanza hacia el caso base '%s': %s, así que la recursión no termina",
+			formatSource(call.site.call), call.site.call.Line, guardText, strings.Join(explanations, "; ")),
+		Line: call.site.call.Line,
+	}, true
+}
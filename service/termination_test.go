@@ -0,0 +1,133 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"examen-back/models"
+)
+
+// analyze ejecuta el análisis semántico con las reglas por defecto
+func analyze(t *testing.T, code string) models.SemanticAnalysis {
+	t.Helper()
+	tokens := NewLexicalAnalyzer(code).Tokenize().Tokens
+	syntax := NewSyntaxAnalyzer(tokens).Analyze()
+	if !syntax.Valid {
+		t.Fatalf("errores de sintaxis: %v", syntax.Errors)
+	}
+	return NewSemanticAnalyzer(tokens, syntax.AST).Analyze()
+}
+
+// diagnosticsWithCode filtra los diagnósticos de un código
+func diagnosticsWithCode(result models.SemanticAnalysis, code string) []models.Diagnostic {
+	found := make([]models.Diagnostic, 0)
+	for _, diagnostic := range result.Diagnostics {
+		if diagnostic.Code == code {
+			found = append(found, diagnostic)
+		}
+	}
+	return found
+}
+
+// expectDiagnostics analiza el código y compara las líneas de cada código de
+// diagnóstico con las esperadas; una lista vacía exige que no aparezca
+func expectDiagnostics(t *testing.T, code string, want map[string][]int) models.SemanticAnalysis {
+	t.Helper()
+	return expectDiagnosticsWith(t, code, RuleConfig{}, want)
+}
+
+// expectDiagnosticsWith es expectDiagnostics con una selección de reglas
+func expectDiagnosticsWith(t *testing.T, code string, config RuleConfig, want map[string][]int) models.SemanticAnalysis {
+	t.Helper()
+	result := analyzeWith(t, code, config)
+	for name, lines := range want {
+		found := diagnosticsWithCode(result, name)
+		got := make([]int, len(found))
+		for i, diagnostic := range found {
+			got[i] = diagnostic.Line
+		}
+		if !slices.Equal(got, lines) && len(got)+len(lines) > 0 {
+			t.Errorf("%s: se esperaban las líneas %v y hay %v: %v", name, lines, got, found)
+		}
+	}
+	return result
+}
+
+func TestUnreachableBaseCaseUsesCallSites(t *testing.T) {
+	const function = "def f(n):\n    if n == 0:\n        return 1\n    return f(n - 2)\n\n"
+	cases := []struct {
+		name     string
+		calls    string
+		severity string // "" si no debe informarse
+	}{
+		{"valor par", "print(f(4))\n", ""},
+		{"varios valores pares", "print(f(4))\nprint(f(10))\n", ""},
+		{"valor impar", "print(f(4))\nprint(f(7))\n", "error"},
+		{"valor desconocido", "print(f(int(input())))\n", "warning"},
+		{"sin llamadas", "", "warning"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			found := diagnosticsWithCode(analyze(t, function+tc.calls), "unreachable-base-case")
+			switch {
+			case tc.severity == "" && len(found) > 0:
+				t.Fatalf("no se esperaba diagnóstico: %v", found)
+			case tc.severity != "" && len(found) != 1:
+				t.Fatalf("se esperaba un diagnóstico, hay %v", found)
+			case tc.severity != "" && found[0].Severity != tc.severity:
+				t.Errorf("severidad %q, se esperaba %q", found[0].Severity, tc.severity)
+			}
+		})
+	}
+}
+
+func TestMissingBaseCase(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     string
+		severity string // "" si no debe informarse
+	}{
+		{"validación y caso base en elif", "def f(n):\n    if n < 0:\n        raise ValueError(\"negativo\")\n    elif n == 0:\n        return 1\n    return n * f(n - 1)\n", ""},
+		{"caso base anidado en una rama sin recursión", "def f(n):\n    if n < 10:\n        x = n\n        if x == 0:\n            return 1\n    return f(n - 1)\n", ""},
+		{"caso base dentro de un try", "def f(n):\n    try:\n        if n == 0:\n            return 1\n    except ValueError:\n        pass\n    return n * f(n - 1)\n", ""},
+		{"caso base dentro de un while", "def f(n):\n    while n > 100:\n        if n == 101:\n            return 0\n        n -= 1\n    return f(n - 1)\n", ""},
+		{"cortocircuito con or", "def f(n):\n    return n == 0 or f(n - 1)\n", ""},
+		{"cortocircuito con and", "def f(n):\n    return n > 0 and f(n - 1)\n", ""},
+		{"recursión a la izquierda del or", "def f(n):\n    return f(n - 1) or n == 0\n", "error"},
+		{"termina por una excepción capturada", "def f(n):\n    try:\n        return f(n - 1)\n    except RecursionError:\n        return 0\n", "warning"},
+		{"sin caso base", "def f(n):\n    return f(n - 1)\n", "error"},
+		{"solo una validación", "def f(n):\n    if n < 0:\n        raise ValueError(\"negativo\")\n    return f(n - 1)\n", "error"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			found := diagnosticsWithCode(analyze(t, tc.code+"\nprint(f(5))\n"), "missing-base-case")
+			switch {
+			case tc.severity == "" && len(found) > 0:
+				t.Fatalf("no se esperaba diagnóstico: %v", found)
+			case tc.severity != "" && len(found) != 1:
+				t.Fatalf("se esperaba un diagnóstico, hay %v", found)
+			case tc.severity != "" && found[0].Severity != tc.severity:
+				t.Errorf("severidad %q, se esperaba %q", found[0].Severity, tc.severity)
+			}
+		})
+	}
+}
+
+func TestRecursionProgressIsNotRepeated(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+		want map[string][]int
+	}{
+		{"llamada sin cambios", "def f(n):\n    if n == 0:\n        return 1\n    return f(n)\n\nprint(f(3))\n", map[string][]int{"non-decreasing-recursion": {4}, "recursion-progress": nil}},
+		{"llamada que crece", "def f(n):\n    if n <= 0:\n        return 1\n    return f(n + 1)\n\nprint(f(3))\n", map[string][]int{"non-decreasing-recursion": {4}, "recursion-progress": nil}},
+		{"sin caso base", "def f(n):\n    return f(n - 1)\n\nprint(f(3))\n", map[string][]int{"missing-base-case": {1}, "recursion-progress": nil}},
+		{"avance desconocido", "def f(n):\n    if n == 0:\n        return 1\n    return f(abs(n) - 1)\n\nprint(f(3))\n", map[string][]int{"non-decreasing-recursion": nil, "recursion-progress": {4}}},
+		{"avanza hacia el caso base", "def f(n):\n    if n == 0:\n        return 1\n    return f(n - 1)\n\nprint(f(3))\n", map[string][]int{"non-decreasing-recursion": nil, "recursion-progress": nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectDiagnosticsWith(t, tc.code, RuleConfig{Enable: []string{"recursion-required"}}, tc.want)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"examen-back/models"
+)
+
+// Lenguajes de destino de /api/v1/transpile
+const (
+	TargetGo = "go"
+	TargetJS = "js"
+)
+
+// ValidateTarget comprueba el lenguaje de destino pedido
+func ValidateTarget(target string) error {
+	switch target {
+	case TargetGo, TargetJS:
+		return nil
+	case "":
+		return fmt.Errorf("falta el lenguaje de destino (se admite '%s' o '%s')", TargetGo, TargetJS)
+	}
+	return fmt.Errorf("destino desconocido '%s' (se admite '%s' o '%s')", target, TargetGo, TargetJS)
+}
+
+// transpiler reúne los análisis que necesitan los generadores de código y
+// las construcciones que no se pudieron traducir
+type transpiler struct {
+	ast       *models.ASTNode
+	tree      *scopeTree
+	calls     *callGraph
+	recursion *recursionAnalysis
+	types     *typeInference
+	constants *constantFolding
+
+	// reads son las lecturas de cada variable y forTargets el for que
+	// asigna cada destino de un for
+	reads      map[*scopeSymbol][]*models.ASTNode
+	forTargets map[*models.ASTNode]*models.ASTNode
+	// inline son los enlaces donde se declara la variable (ver placeDeclarations)
+	inline map[*models.ASTNode]bool
+
+	untranslated []models.UntranslatedConstruct
+	notes        []models.TranspileNote
+	reported     map[*models.ASTNode]bool
+	noted        map[string]bool
+}
+
+func newTranspiler(ast *models.ASTNode) *transpiler {
+	tree := buildScopeTree(ast)
+	graphs := buildFunctionCFGs(ast)
+	calls := buildCallGraph(ast, tree)
+	t := &transpiler{
+		ast:          ast,
+		tree:         tree,
+		calls:        calls,
+		recursion:    analyzeRecursion(calls),
+		types:        inferTypes(ast, tree, calls, graphs),
+		constants:    foldConstants(ast, tree),
+		reads:        make(map[*scopeSymbol][]*models.ASTNode),
+		forTargets:   make(map[*models.ASTNode]*models.ASTNode),
+		inline:       make(map[*models.ASTNode]bool),
+		untranslated: make([]models.UntranslatedConstruct, 0),
+		notes:        make([]models.TranspileNote, 0),
+		reported:     make(map[*models.ASTNode]bool),
+		noted:        make(map[string]bool),
+	}
+	for node, symbol := range tree.resolved {
+		t.reads[symbol] = append(t.reads[symbol], node)
+	}
+	t.collectForTargets(ast)
+	return t
+}
+
+func (t *transpiler) collectForTargets(node *models.ASTNode) {
+	if node.Type == "ForStatement" {
+		targets := []*models.ASTNode{node.Children[0]}
+		if node.Children[0].Type == "Tuple" {
+			targets = node.Children[0].Children
+		}
+		for _, target := range targets {
+			t.forTargets[target] = node
+		}
+	}
+	for _, child := range node.Children {
+		t.collectForTargets(child)
+	}
+}
+
+// placeDeclarations decide dónde se declara cada variable local de un
+// cuerpo. Si su primera asignación está en el nivel superior del cuerpo se
+// declara ahí; las variables de un for que solo se leen dentro del bucle se
+// declaran en su cabecera. Esos enlaces quedan en inline. Las demás se
+// devuelven para declararlas al comienzo del cuerpo: declaradas dentro de un
+// bloque quedarían limitadas a él, y en Python no lo están. skip excluye
+// las variables que el generador declara por su cuenta.
+func (t *transpiler) placeDeclarations(body []*models.ASTNode, sc *scope, skip func(*scopeSymbol) bool) []*scopeSymbol {
+	top := make(map[*models.ASTNode]bool)
+	for _, stmt := range body {
+		if stmt.Type != "Assignment" {
+			continue
+		}
+		targets, _ := assignmentPairs(stmt)
+		for _, target := range targets {
+			top[target] = true
+		}
+	}
+
+	hoisted := make([]*scopeSymbol, 0)
+	for _, name := range sc.order {
+		symbol := sc.symbols[name]
+		if symbol.kind != "variable" || symbol.module != "" || !symbol.definesHere() ||
+			len(symbol.bindings) == 0 || skip(symbol) {
+			continue
+		}
+		first := symbol.bindings[0].node
+		switch {
+		case top[first]:
+			t.inline[first] = true
+		case t.loopScoped(symbol):
+			for _, binding := range symbol.bindings {
+				t.inline[binding.node] = true
+			}
+		default:
+			hoisted = append(hoisted, symbol)
+		}
+	}
+	return hoisted
+}
+
+// loopScoped indica si una variable solo la asignan cabeceras de for y solo
+// se lee dentro de esos bucles
+func (t *transpiler) loopScoped(symbol *scopeSymbol) bool {
+	loops := make([]*models.ASTNode, 0, len(symbol.bindings))
+	for _, binding := range symbol.bindings {
+		loop := t.forTargets[binding.node]
+		if loop == nil {
+			return false
+		}
+		loops = append(loops, loop)
+	}
+	for _, read := range t.reads[symbol] {
+		inside := false
+		for _, loop := range loops {
+			if read.Line >= loop.Line && read.Line <= lastLine(loop) {
+				inside = true
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	return true
+}
+
+// unsupported registra una construcción sin traducción; cada nodo se
+// informa una sola vez aunque el generador lo visite de nuevo
+func (t *transpiler) unsupported(node *models.ASTNode, construct, reason string) {
+	if t.reported[node] {
+		return
+	}
+	t.reported[node] = true
+	// De una sentencia compuesta basta con la cabecera
+	source := formatSource(node)
+	for _, child := range node.Children {
+		if child.Type == "Block" {
+			source = formatBlock([]*models.ASTNode{node}, "")[0]
+			break
+		}
+	}
+	t.untranslated = append(t.untranslated, models.UntranslatedConstruct{
+		Construct: construct,
+		Line:      node.Line,
+		Source:    source,
+		Reason:    reason,
+	})
+}
+
+// unsupportedLoopElse informa el else de un while o un for; como el bloque
+// else no tiene texto propio, se muestra con la cabecera del bucle
+func (t *transpiler) unsupportedLoopElse(loop, otherwise *models.ASTNode, reason string) {
+	if t.reported[otherwise] {
+		return
+	}
+	t.reported[otherwise] = true
+	t.untranslated = append(t.untranslated, models.UntranslatedConstruct{
+		Construct: "cláusula else de un bucle",
+		Line:      otherwise.Line,
+		Source:    formatBlock([]*models.ASTNode{loop}, "")[0] + " ... else:",
+		Reason:    reason,
+	})
+}
+
+// note registra una diferencia de comportamiento; el mismo mensaje se
+// repite como máximo una vez por línea
+func (t *transpiler) note(node *models.ASTNode, message string) {
+	key := fmt.Sprintf("%d:%s", node.Line, message)
+	if t.noted[key] {
+		return
+	}
+	t.noted[key] = true
+	t.notes = append(t.notes, models.TranspileNote{Line: node.Line, Message: message})
+}
+
+// symbol devuelve la variable que define un nombre usado, siguiendo las
+// declaraciones global y nonlocal
+func (t *transpiler) symbol(node *models.ASTNode) *scopeSymbol {
+	return t.tree.resolved[node]
+}
+
+// qualifiedName es el nombre completo de lo que se llama o se lee:
+// "math.sqrt" tanto para math.sqrt(x) como para sqrt(x) después de
+// "from math import sqrt"; las funciones integradas conservan su nombre
+func (t *transpiler) qualifiedName(node *models.ASTNode, path string) string {
+	symbol := t.symbol(node)
+	switch {
+	case symbol == nil:
+		return path
+	case symbol.kind == "module":
+		first := strings.SplitN(path, ".", 2)
+		if len(first) == 2 {
+			return symbol.module + "." + first[1]
+		}
+		return symbol.module
+	case symbol.module != "" && symbol.node != nil:
+		return symbol.module + "." + symbol.node.Value
+	}
+	return path
+}
+
+// userFunction devuelve la FunctionDef del programa que llama un
+// FunctionCall, o nil si es una función integrada o de un módulo
+func (t *transpiler) userFunction(call *models.ASTNode) *models.ASTNode {
+	symbol := t.symbol(call)
+	if symbol == nil || symbol.kind != "function" || symbol.module != "" || strings.Contains(call.Value, ".") {
+		return nil
+	}
+	return symbol.node
+}
+
+// callArguments ordena los argumentos de una llamada según los parámetros de
+// fn: los posicionales, los nombrados y los valores por defecto de los que
+// faltan. ok es falso si la llamada no encaja con la firma.
+func callArguments(fn, call *models.ASTNode) ([]*models.ASTNode, bool) {
+	params, _ := functionParts(fn)
+	args := make([]*models.ASTNode, len(params))
+	position := 0
+	for _, arg := range call.Children {
+		if arg.Type == "Keyword" {
+			found := false
+			for i, param := range params {
+				if param.Value == arg.Value && len(arg.Children) > 0 {
+					args[i], found = arg.Children[0], true
+				}
+			}
+			if !found {
+				return nil, false
+			}
+			continue
+		}
+		if position >= len(params) || arg.Type == "Starred" {
+			return nil, false
+		}
+		args[position] = arg
+		position++
+	}
+	for i, param := range params {
+		if args[i] == nil {
+			if args[i] = parameterDefault(param); args[i] == nil {
+				return nil, false
+			}
+		}
+	}
+	return args, true
+}
+
+// isMainGuard reconoce 'if __name__ == "__main__":', cuyo cuerpo se traduce
+// como parte del programa principal
+func isMainGuard(node *models.ASTNode) bool {
+	if node.Type != "IfStatement" || len(node.Children) != 2 {
+		return false
+	}
+	condition := node.Children[0]
+	if condition.Type != "BinaryOp" || condition.Value != "==" {
+		return false
+	}
+	left, right := condition.Children[0], condition.Children[1]
+	if left.Type == "String" {
+		left, right = right, left
+	}
+	value, ok := literalValue(right)
+	return left.Type == "Identifier" && left.Value == "__name__" && ok && value.kind == "str" && value.s == "__main__"
+}
+
+// isElif indica si el bloque else de un IfStatement es un elif
+func isElif(otherwise *models.ASTNode) bool {
+	return len(otherwise.Children) == 1 && otherwise.Children[0].Type == "IfStatement" &&
+		otherwise.Children[0].Line == otherwise.Line && otherwise.Children[0].Col == otherwise.Col
+}
+
+// Transpile traduce el programa al lenguaje de destino, que ya debe estar
+// validado. code es el programa original, que el source map de la
+// traducción a JavaScript incluye.
+func Transpile(ast *models.ASTNode, code, target string) models.TranspileResponse {
+	t := newTranspiler(ast)
+	response := models.TranspileResponse{Target: target}
+	switch target {
+	case TargetGo:
+		response.Output = newGoGenerator(t).generate()
+	case TargetJS:
+		response.Output, response.SourceMap = newJSGenerator(t).generate(code)
+	}
+
+	sort.SliceStable(t.untranslated, func(i, j int) bool {
+		return t.untranslated[i].Line < t.untranslated[j].Line
+	})
+	sort.SliceStable(t.notes, func(i, j int) bool {
+		return t.notes[i].Line < t.notes[j].Line
+	})
+	response.Untranslated = t.untranslated
+	response.Notes = t.notes
+	return response
+}
@@ -0,0 +1,2282 @@
+package service
+
+import (
+	"fmt"
+	"go/format"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"examen-back/models"
+)
+
+// Precedencia de los operadores de Go, de menor a mayor; decide dónde hacen
+// falta paréntesis
+const (
+	goPrecOr = 1 + iota
+	goPrecAnd
+	goPrecCompare
+	goPrecAdd
+	goPrecMul
+	goPrecUnary
+	goPrecPrimary
+)
+
+// Tipos de Go en los que se traducen los de Python
+const (
+	goInt    = "int"
+	goBig    = "*big.Int"
+	goFloat  = "float64"
+	goString = "string"
+	goBool   = "bool"
+)
+
+var goBinaryPrecedence = map[string]int{
+	"*": goPrecMul, "/": goPrecMul, "%": goPrecMul, "<<": goPrecMul, ">>": goPrecMul, "&": goPrecMul,
+	"+": goPrecAdd, "-": goPrecAdd, "|": goPrecAdd, "^": goPrecAdd,
+	"==": goPrecCompare, "!=": goPrecCompare, "<": goPrecCompare, "<=": goPrecCompare, ">": goPrecCompare, ">=": goPrecCompare,
+}
+
+// Métodos de *big.Int para cada operador aritmético de Python
+var bigMethods = map[string]string{
+	"+": "Add", "-": "Sub", "*": "Mul", "//": "Div", "%": "Mod", "**": "Exp",
+	"&": "And", "|": "Or", "^": "Xor",
+}
+
+// Comparación contraria, para traducir "not a < b" como "a >= b"
+var invertedComparison = map[string]string{
+	"==": "!=", "!=": "==", "<": ">=", "<=": ">", ">": "<=", ">=": "<",
+}
+
+// Funciones de math con la misma firma en Go
+var goMathFunctions = map[string]string{
+	"math.sqrt": "math.Sqrt", "math.pow": "math.Pow", "math.fabs": "math.Abs", "math.exp": "math.Exp",
+	"math.log2": "math.Log2", "math.log10": "math.Log10", "math.sin": "math.Sin", "math.cos": "math.Cos",
+	"math.tan": "math.Tan", "math.atan": "math.Atan", "math.hypot": "math.Hypot",
+}
+
+var goMathConstants = map[string]string{
+	"math.pi": "math.Pi", "math.e": "math.E", "math.inf": "math.Inf(1)",
+}
+
+// Palabras reservadas de Go y nombres que usa el código generado; una
+// variable de Python con ese nombre se renombra con un guion bajo final
+var goReserved = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true,
+	"goto": true, "if": true, "import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true, "switch": true, "type": true,
+	"var": true, "main": true, "init": true, "nil": true, "true": true, "false": true,
+	"append": true, "cap": true, "len": true, "make": true, "new": true, "copy": true,
+	"min": true, "max": true, "panic": true, "print": true, "println": true, "any": true,
+	"int": true, "float64": true, "string": true, "bool": true, "byte": true, "rune": true, "error": true,
+	"fmt": true, "big": true, "math": true, "strings": true, "strconv": true, "slices": true,
+	"bufio": true, "os": true, "stdin": true, "utf8": true,
+}
+
+// goHelper es una función auxiliar que se agrega al final del programa
+// cuando el código la usa
+type goHelper struct {
+	code    string
+	imports []string
+	needs   []string
+}
+
+var goHelpers = map[string]goHelper{
+	"formatFloat": {
+		code: `// formatFloat escribe un float como print de Python: 120.0, 0.1, 1e+16
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-4 || abs >= 1e16) {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}`,
+		imports: []string{"math", "strconv", "strings"},
+	},
+	"formatBool": {
+		code: `// formatBool escribe un bool como print de Python
+func formatBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}`,
+	},
+	"formatList": {
+		code: `// formatList escribe una lista como print de Python: [1, 2] o ['a', 'b']
+func formatList[T any](items []T) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		switch v := any(item).(type) {
+		case string:
+			parts[i] = "'" + v + "'"
+		case float64:
+			parts[i] = formatFloat(v)
+		case bool:
+			parts[i] = formatBool(v)
+		default:
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}`,
+		imports: []string{"fmt", "strings"},
+		needs:   []string{"formatFloat", "formatBool"},
+	},
+	"readLine": {
+		code: `var stdin = bufio.NewReader(os.Stdin)
+
+// readLine lee una línea de la entrada estándar, como input() de Python
+func readLine(prompt string) string {
+	fmt.Print(prompt)
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}`,
+		imports: []string{"bufio", "fmt", "os", "strings"},
+	},
+	"atoi": {
+		code: `// atoi convierte un texto como int() de Python; un texto inválido detiene el programa
+func atoi(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		panic(fmt.Sprintf("ValueError: invalid literal for int() with base 10: %q", s))
+	}
+	return n
+}`,
+		imports: []string{"fmt", "strconv", "strings"},
+	},
+	"parseFloat": {
+		code: `// parseFloat convierte un texto como float() de Python; un texto inválido detiene el programa
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		panic(fmt.Sprintf("ValueError: could not convert string to float: %q", s))
+	}
+	return f
+}`,
+		imports: []string{"fmt", "strconv", "strings"},
+	},
+	"absInt": {
+		code: `func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}`,
+	},
+	"sumOf": {
+		code: `func sumOf[T int | float64](items []T) T {
+	var total T
+	for _, item := range items {
+		total += item
+	}
+	return total
+}`,
+	},
+	"floorDiv": {
+		code: `// floorDiv divide como // de Python: redondea hacia abajo
+func floorDiv(a, b int) int {
+	if b == 0 {
+		panic("ZeroDivisionError: integer division or modulo by zero")
+	}
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}`,
+	},
+	"floorMod": {
+		code: `// floorMod calcula el resto como % de Python: tiene el signo del divisor
+func floorMod(a, b int) int {
+	if b == 0 {
+		panic("ZeroDivisionError: integer division or modulo by zero")
+	}
+	r := a % b
+	if r != 0 && (r < 0) != (b < 0) {
+		r += b
+	}
+	return r
+}`,
+	},
+	"divisor": {
+		code: `// divisor detiene el programa si b es cero, como la división de Python;
+// Go devolvería infinito
+func divisor(b float64) float64 {
+	if b == 0 {
+		panic("ZeroDivisionError: division by zero")
+	}
+	return b
+}`,
+	},
+	"powInt": {
+		code: `// powInt calcula ** entre enteros; con un exponente negativo Python
+// devuelve un float que esta traducción no representa
+func powInt(base, exp *big.Int) *big.Int {
+	if exp.Sign() < 0 {
+		panic("ValueError: ** con exponente negativo devuelve un float en Python")
+	}
+	return new(big.Int).Exp(base, exp, nil)
+}`,
+		imports: []string{"math/big"},
+	},
+	"find": {
+		code: `// find es str.find de Python: la posición se cuenta en caracteres
+func find(s, sub string) int {
+	i := strings.Index(s, sub)
+	if i < 0 {
+		return -1
+	}
+	return utf8.RuneCountInString(s[:i])
+}`,
+		imports: []string{"strings", "unicode/utf8"},
+	},
+	"bigInt": {
+		code: `// bigInt crea un entero a partir de un literal que no cabe en int
+func bigInt(literal string) *big.Int {
+	n, _ := new(big.Int).SetString(literal, 0)
+	return n
+}`,
+		imports: []string{"math/big"},
+	},
+}
+
+// goExpr es una expresión de Go ya generada: su código, su tipo de Go y la
+// precedencia de su operador principal. constant indica un literal sin tipo,
+// que Go convierte solo (2 sirve como float64). Un tipo vacío indica que la
+// expresión no se pudo traducir.
+type goExpr struct {
+	code     string
+	typ      string
+	prec     int
+	constant bool
+}
+
+func goPrimary(code, typ string) goExpr {
+	return goExpr{code: code, typ: typ, prec: goPrecPrimary}
+}
+
+// wrap devuelve el código entre paréntesis si su operador liga menos que prec
+func (e goExpr) wrap(prec int) string {
+	if e.prec < prec {
+		return "(" + e.code + ")"
+	}
+	return e.code
+}
+
+func isGoSlice(typ string) bool {
+	return strings.HasPrefix(typ, "[]")
+}
+
+func isGoNumber(typ string) bool {
+	return typ == goInt || typ == goFloat || typ == goBig
+}
+
+// goName convierte un nombre de Python al estilo de Go y evita las palabras
+// reservadas
+func goName(name string) string {
+	result := camelCase(name)
+	if goReserved[result] {
+		return result + "_"
+	}
+	return result
+}
+
+// camelCase convierte un nombre de Python (snake_case) a camelCase; los
+// guiones bajos iniciales se conservan
+func camelCase(name string) string {
+	prefix := name[:len(name)-len(strings.TrimLeft(name, "_"))]
+	parts := strings.Split(name[len(prefix):], "_")
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		if i > 0 && b.Len() > len(prefix) {
+			part = strings.ToUpper(part[:1]) + part[1:]
+		}
+		b.WriteString(part)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// goGenerator traduce el AST a un programa de Go. Los tipos salen de la
+// inferencia; los enteros se traducen como int salvo los que pueden crecer
+// sin cota, que usan *big.Int (ver findBigIntegers).
+type goGenerator struct {
+	*transpiler
+
+	bigSymbols map[*scopeSymbol]bool
+	bigResults map[*models.ASTNode]bool
+	// carriers son las variables que guardan el resultado de una llamada
+	// recursiva ("half = power(b, e // 2)")
+	carriers map[*scopeSymbol]bool
+	changed  bool
+
+	globals map[*scopeSymbol]bool
+
+	imports map[string]bool
+	helpers map[string]bool
+
+	out    *strings.Builder
+	depth  int
+	fn     *models.ASTNode
+	sc     *scope
+	failed bool
+}
+
+func newGoGenerator(t *transpiler) *goGenerator {
+	return &goGenerator{
+		transpiler: t,
+		bigSymbols: make(map[*scopeSymbol]bool),
+		bigResults: make(map[*models.ASTNode]bool),
+		carriers:   make(map[*scopeSymbol]bool),
+		globals:    make(map[*scopeSymbol]bool),
+		imports:    make(map[string]bool),
+		helpers:    make(map[string]bool),
+	}
+}
+
+func (g *goGenerator) line(format string, args ...any) {
+	g.out.WriteString(strings.Repeat("\t", g.depth))
+	fmt.Fprintf(g.out, format, args...)
+	g.out.WriteString("\n")
+}
+
+func (g *goGenerator) use(pkg string) {
+	g.imports[pkg] = true
+}
+
+func (g *goGenerator) helper(name string) {
+	if g.helpers[name] {
+		return
+	}
+	g.helpers[name] = true
+	for _, pkg := range goHelpers[name].imports {
+		g.use(pkg)
+	}
+	for _, need := range goHelpers[name].needs {
+		g.helper(need)
+	}
+}
+
+// fail registra una expresión sin traducción y devuelve un marcador que
+// conserva el código original como comentario
+func (g *goGenerator) fail(node *models.ASTNode, construct, reason string) goExpr {
+	g.unsupported(node, construct, reason)
+	return g.failedExpr(node)
+}
+
+func (g *goGenerator) failedExpr(node *models.ASTNode) goExpr {
+	g.failed = true
+	return goPrimary("nil /* sin traducir: "+formatSource(node)+" */", "")
+}
+
+// generate devuelve el programa completo: paquete, imports, variables de
+// paquete, funciones, main y funciones auxiliares, formateado con gofmt
+func (g *goGenerator) generate() string {
+	g.collectGlobals()
+	g.findBigIntegers()
+
+	var functions, program strings.Builder
+	var mainBody []*models.ASTNode
+	for _, stmt := range g.ast.Children {
+		switch {
+		case stmt.Type == "FunctionDef":
+			g.out = &functions
+			g.function(stmt)
+		case stmt.Type == "Import" || stmt.Type == "ImportFrom":
+			g.out = &program
+			g.importStatement(stmt)
+		case isMainGuard(stmt):
+			mainBody = append(mainBody, stmt.Children[1].Children...)
+		default:
+			mainBody = append(mainBody, stmt)
+		}
+	}
+
+	var mainFunction strings.Builder
+	g.out = &mainFunction
+	g.fn, g.sc = nil, g.tree.module
+	g.line("func main() {")
+	g.depth++
+	g.declareLocals(mainBody, g.tree.module)
+	g.block(mainBody)
+	g.depth--
+	g.line("}")
+
+	var variables strings.Builder
+	g.out = &variables
+	for _, name := range g.tree.module.order {
+		if symbol := g.tree.module.symbols[name]; g.globals[symbol] {
+			g.line("var %s %s", goName(name), g.symbolType(symbol, symbol.node))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("// Código generado a partir del programa en Python; requiere Go 1.23 o\n// posterior (for range sobre enteros, slices.Repeat).\npackage main\n\n")
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for pkg := range g.imports {
+			imports = append(imports, strconv.Quote(pkg))
+		}
+		sort.Strings(imports)
+		b.WriteString("import (\n\t" + strings.Join(imports, "\n\t") + "\n)\n\n")
+	}
+	if variables.Len() > 0 {
+		b.WriteString(variables.String() + "\n")
+	}
+	b.WriteString(program.String())
+	b.WriteString(functions.String())
+	b.WriteString(mainFunction.String())
+
+	helpers := make([]string, 0, len(g.helpers))
+	for name := range g.helpers {
+		helpers = append(helpers, name)
+	}
+	sort.Strings(helpers)
+	for _, name := range helpers {
+		b.WriteString("\n" + goHelpers[name].code + "\n")
+	}
+
+	// Con construcciones sin traducir el código puede no compilar; se
+	// devuelve tal cual se generó
+	if formatted, err := format.Source([]byte(b.String())); err == nil {
+		return string(formatted)
+	}
+	return b.String()
+}
+
+// collectGlobals registra las variables del módulo que usan las funciones,
+// que se declaran a nivel de paquete
+func (g *goGenerator) collectGlobals() {
+	var visit func(node *models.ASTNode, inFunction bool)
+	visit = func(node *models.ASTNode, inFunction bool) {
+		switch node.Type {
+		case "FunctionDef":
+			inFunction = true
+		case "GlobalStatement":
+			for _, ident := range node.Children {
+				if symbol := g.tree.module.symbols[ident.Value]; symbol != nil {
+					g.globals[symbol] = true
+				}
+			}
+		}
+		if inFunction {
+			if symbol := g.tree.resolved[node]; symbol != nil && symbol.owner == g.tree.module && symbol.kind == "variable" && symbol.module == "" {
+				g.globals[symbol] = true
+			}
+		}
+		for _, child := range node.Children {
+			visit(child, inFunction)
+		}
+	}
+	visit(g.ast, false)
+}
+
+// findBigIntegers decide qué enteros se traducen como *big.Int. Los enteros
+// de Python no tienen límite, pero la mayoría de los valores de un programa
+// (contadores, índices, argumentos que decrecen) caben en int. Crecen sin
+// cota los productos y potencias de un resultado recursivo (factorial), la
+// suma de dos llamadas recursivas (fibonacci), los productos acumulados en
+// un bucle o en un parámetro acumulador y los valores plegados fuera del
+// rango de int64. La marca se propaga a las variables, parámetros y
+// retornos que reciben esos valores hasta un punto fijo.
+func (g *goGenerator) findBigIntegers() {
+	for pass := 0; pass < maxInferencePasses; pass++ {
+		g.changed = false
+		g.scanBig(g.ast.Children, g.tree.module, nil, false)
+		if !g.changed {
+			break
+		}
+	}
+}
+
+func (g *goGenerator) markBig(symbol *scopeSymbol) {
+	if symbol == nil || g.bigSymbols[symbol] {
+		return
+	}
+	t := g.types.symbols[symbol]
+	if t != typeInt && (t == nil || t.name != "list" || t.elem != typeInt) {
+		return
+	}
+	g.bigSymbols[symbol] = true
+	g.changed = true
+}
+
+func (g *goGenerator) scanBig(stmts []*models.ASTNode, sc *scope, fn *models.ASTNode, loop bool) {
+	for _, stmt := range stmts {
+		g.scanCalls(stmt, sc, fn)
+		switch stmt.Type {
+		case "FunctionDef":
+			_, body := functionParts(stmt)
+			g.scanBig(body, g.tree.byNode[stmt], stmt, false)
+			continue
+
+		case "Assignment":
+			targets, values := assignmentPairs(stmt)
+			names := make([]string, 0, len(targets))
+			for _, target := range targets {
+				names = append(names, targetName(target))
+			}
+			for i, target := range targets {
+				if symbol := g.types.bindingSymbol(sc, targetName(target)); symbol != nil && g.recursiveCall(values[i], fn) && !g.carriers[symbol] {
+					g.carriers[symbol] = true
+					g.changed = true
+				}
+				grows := loop && growsFrom(values[i], names)
+				if !g.isBig(values[i], sc, fn) && !grows {
+					continue
+				}
+				switch {
+				case targetName(target) != "":
+					g.markBig(g.types.bindingSymbol(sc, targetName(target)))
+				case target.Type == "Subscript" && target.Children[0].Type == "Identifier":
+					g.markBig(g.symbol(target.Children[0]))
+				}
+			}
+
+		case "AugAssignment":
+			target, op := stmt.Children[0], strings.TrimSuffix(stmt.Value, "=")
+			if target.Type != "Identifier" {
+				break
+			}
+			if g.isBig(stmt.Children[1], sc, fn) || op == "**" || (loop && (op == "*" || op == "<<")) {
+				g.markBig(g.types.bindingSymbol(sc, target.Value))
+			}
+
+		case "ReturnStatement":
+			if fn != nil && len(stmt.Children) > 0 && g.isBig(stmt.Children[0], sc, fn) && !g.bigResults[fn] {
+				g.bigResults[fn] = true
+				g.changed = true
+			}
+		}
+
+		inner := loop || stmt.Type == "WhileStatement" || stmt.Type == "ForStatement"
+		for _, block := range nestedBlocks(stmt) {
+			g.scanBig(block.Children, sc, fn, inner)
+		}
+	}
+}
+
+// scanCalls propaga los enteros grandes a los parámetros a través de los
+// argumentos de cada llamada de una sentencia, sin entrar en sus bloques
+func (g *goGenerator) scanCalls(node *models.ASTNode, sc *scope, fn *models.ASTNode) {
+	for _, child := range node.Children {
+		if child.Type != "Block" && child.Type != "FunctionDef" && child.Type != "ExceptHandler" {
+			g.scanCalls(child, sc, fn)
+		}
+	}
+	if node.Type != "FunctionCall" {
+		return
+	}
+
+	if strings.HasSuffix(node.Value, ".append") && len(node.Children) == 1 && g.isBig(node.Children[0], sc, fn) {
+		g.markBig(g.symbol(node))
+		return
+	}
+	callee := g.userFunction(node)
+	if callee == nil || g.types.functions[callee] == nil {
+		return
+	}
+	args, ok := callArguments(callee, node)
+	if !ok {
+		return
+	}
+	ft := g.types.functions[callee]
+	for i, arg := range args {
+		// Un acumulador que se multiplica en cada llamada recursiva crece sin cota
+		accumulates := fn == callee && growsFrom(arg, ft.names)
+		if i < len(ft.symbols) && (accumulates || g.isBig(arg, sc, fn)) {
+			g.markBig(ft.symbols[i])
+		}
+	}
+}
+
+// isBig indica si una expresión entera puede salir del rango de int
+func (g *goGenerator) isBig(node *models.ASTNode, sc *scope, fn *models.ASTNode) bool {
+	if value, ok := g.constants.values[node]; ok && value.kind == "int" && (value.i.Cmp(maxInt64) > 0 || value.i.Cmp(minInt64) < 0) {
+		return true
+	}
+
+	switch node.Type {
+	case "Number":
+		value, ok := new(big.Int).SetString(strings.ReplaceAll(node.Value, "_", ""), 0)
+		return ok && (value.Cmp(maxInt64) > 0 || value.Cmp(minInt64) < 0)
+
+	case "Identifier":
+		return g.bigSymbols[g.symbol(node)]
+
+	case "FunctionCall":
+		if callee := g.userFunction(node); callee != nil {
+			return g.bigResults[callee]
+		}
+		switch g.qualifiedName(node, node.Value) {
+		case "math.factorial":
+			return true
+		case "pow":
+			return g.types.expressionType(node, sc) == typeInt
+		case "abs", "max", "min", "sum":
+			for _, arg := range node.Children {
+				if g.isBig(arg, sc, fn) {
+					return true
+				}
+			}
+		}
+
+	case "BinaryOp":
+		if g.types.expressionType(node, sc) != typeInt {
+			return false
+		}
+		left, right := node.Children[0], node.Children[1]
+		if g.isBig(left, sc, fn) || g.isBig(right, sc, fn) {
+			return true
+		}
+		switch node.Value {
+		case "**":
+			return true
+		case "*", "<<":
+			return g.recursiveResult(left, fn) || g.recursiveResult(right, fn)
+		case "+", "-":
+			return g.recursiveCall(left, fn) && g.recursiveCall(right, fn)
+		}
+
+	case "UnaryOp":
+		return g.isBig(node.Children[0], sc, fn)
+
+	case "IfExpression":
+		return g.isBig(node.Children[1], sc, fn) || g.isBig(node.Children[2], sc, fn)
+
+	case "Subscript":
+		return node.Children[1].Type != "Slice" && g.isBig(node.Children[0], sc, fn)
+	}
+	return false
+}
+
+// recursiveCall indica si node es una llamada recursiva desde fn
+func (g *goGenerator) recursiveCall(node *models.ASTNode, fn *models.ASTNode) bool {
+	if fn == nil || node.Type != "FunctionCall" {
+		return false
+	}
+	callee := g.userFunction(node)
+	return callee != nil && g.recursion.sameCycle(fn, callee)
+}
+
+// recursiveResult indica si node es una llamada recursiva desde fn o una
+// variable que guarda su resultado
+func (g *goGenerator) recursiveResult(node *models.ASTNode, fn *models.ASTNode) bool {
+	return g.recursiveCall(node, fn) || (fn != nil && node.Type == "Identifier" && g.carriers[g.symbol(node)])
+}
+
+// growsFrom indica si value crece a partir de las variables names: un
+// producto, potencia o desplazamiento que las usa (resultado = resultado * i)
+// o una suma de dos de ellas (a, b = b, a + b)
+func growsFrom(value *models.ASTNode, names []string) bool {
+	if value.Type != "BinaryOp" {
+		return false
+	}
+	mentions := func(node *models.ASTNode) bool {
+		for _, name := range names {
+			if name != "" && mentionsName(node, name) {
+				return true
+			}
+		}
+		return false
+	}
+	left, right := value.Children[0], value.Children[1]
+	switch value.Value {
+	case "*", "**", "<<":
+		return mentions(left) || mentions(right)
+	case "+":
+		return mentions(left) && mentions(right)
+	}
+	return false
+}
+
+// nestedBlocks devuelve los bloques de una sentencia compuesta, incluidos
+// los de sus cláusulas except
+func nestedBlocks(stmt *models.ASTNode) []*models.ASTNode {
+	blocks := make([]*models.ASTNode, 0)
+	if stmt.Type == "FunctionDef" || stmt.Type == "ClassDef" {
+		return blocks
+	}
+	for _, child := range stmt.Children {
+		switch {
+		case child.Type == "Block":
+			blocks = append(blocks, child)
+		case child.Type == "ExceptHandler" && len(child.Children) > 0:
+			blocks = append(blocks, child.Children[len(child.Children)-1])
+		}
+	}
+	return blocks
+}
+
+// assignmentPairs separa una asignación en destinos y valores; en
+// "a, b = x, y" cada destino queda con su valor. Una asignación simple a un
+// nombre usa el propio nodo Assignment como destino.
+func assignmentPairs(node *models.ASTNode) ([]*models.ASTNode, []*models.ASTNode) {
+	if node.Value != "" {
+		return []*models.ASTNode{node}, []*models.ASTNode{node.Children[0]}
+	}
+	target, value := node.Children[0], node.Children[1]
+	isSequence := func(n *models.ASTNode) bool { return n.Type == "Tuple" || n.Type == "List" }
+	if isSequence(target) && isSequence(value) && len(target.Children) == len(value.Children) {
+		return target.Children, value.Children
+	}
+	return []*models.ASTNode{target}, []*models.ASTNode{value}
+}
+
+// targetName es el nombre asignado por un destino, o "" si no es un nombre
+func targetName(target *models.ASTNode) string {
+	switch target.Type {
+	case "Assignment", "Identifier":
+		return target.Value
+	}
+	return ""
+}
+
+// goType traduce un tipo inferido; ok es falso si no tiene equivalente
+func (g *goGenerator) goType(t *pyType, big bool) (string, bool) {
+	switch {
+	case t == typeInt:
+		if big {
+			g.use("math/big")
+			return goBig, true
+		}
+		return goInt, true
+	case t == typeFloat:
+		return goFloat, true
+	case t == typeStr:
+		return goString, true
+	case t == typeBool:
+		return goBool, true
+	case t != nil && t.name == "list" && t.elem != nil:
+		elem, ok := g.goType(t.elem, big)
+		return "[]" + elem, ok
+	}
+	return "", false
+}
+
+// describeType muestra un tipo inferido en los motivos de las construcciones
+// sin traducir
+func describeType(t *pyType) string {
+	if t == nil {
+		return "sin información"
+	}
+	return t.String()
+}
+
+// symbolType es el tipo de Go de una variable; si no tiene uno único se
+// informa en node y se usa any
+func (g *goGenerator) symbolType(symbol *scopeSymbol, node *models.ASTNode) string {
+	t := g.types.symbols[symbol]
+	if typ, ok := g.goType(t, g.bigSymbols[symbol]); ok {
+		return typ
+	}
+	if node != nil {
+		g.unsupported(node, "tipo", fmt.Sprintf("'%s' no tiene un tipo único que Go pueda declarar (%s)", symbol.name, describeType(t)))
+	}
+	return "any"
+}
+
+// resultType es el tipo de retorno de una función, o "" si no devuelve valor
+func (g *goGenerator) resultType(fn *models.ASTNode) string {
+	ft := g.types.functions[fn]
+	if ft == nil {
+		return ""
+	}
+	result := ft.result
+	if ft.declaredResult != nil {
+		result = ft.declaredResult
+	}
+	if result == nil || result == typeNone {
+		return ""
+	}
+	if ft.fallsOff {
+		g.unsupported(fn, "función", fmt.Sprintf("'%s' devuelve un valor en algunos caminos y termina sin return (None) en otros; Go exige un valor de retorno en todos", fn.Value))
+	}
+	typ, ok := g.goType(result, g.bigResults[fn])
+	if !ok {
+		g.unsupported(fn, "función", fmt.Sprintf("'%s' no devuelve un tipo único que Go pueda declarar (%s)", fn.Value, describeType(result)))
+		return "any"
+	}
+	return typ
+}
+
+// paramType es el tipo de Go del parámetro i de fn
+func (g *goGenerator) paramType(fn *models.ASTNode, i int) string {
+	ft := g.types.functions[fn]
+	params, _ := functionParts(fn)
+	t := ft.paramType(i)
+	if typ, ok := g.goType(t, g.bigSymbols[ft.symbols[i]]); ok {
+		return typ
+	}
+	reason := fmt.Sprintf("el parámetro '%s' no tiene un tipo único que Go pueda declarar (%s)", params[i].Value, describeType(t))
+	if t == nil {
+		reason = fmt.Sprintf("no se pudo inferir el tipo del parámetro '%s': la función no se llama en el programa ni tiene anotaciones", params[i].Value)
+	}
+	g.unsupported(params[i], "parámetro", reason)
+	return "any"
+}
+
+func (g *goGenerator) function(fn *models.ASTNode) {
+	g.fn, g.sc = fn, g.tree.byNode[fn]
+	defer func() { g.fn, g.sc = nil, g.tree.module }()
+
+	params, body := functionParts(fn)
+	if len(body) > 0 && body[0].Type == "String" {
+		doc, _ := literalValue(body[0])
+		for _, text := range strings.Split(strings.TrimSpace(doc.s), "\n") {
+			g.line("// %s", strings.TrimSpace(text))
+		}
+		body = body[1:]
+	}
+
+	parts := make([]string, 0, len(params))
+	for i, param := range params {
+		if strings.HasPrefix(param.Value, "*") {
+			g.unsupported(param, "parámetro", fmt.Sprintf("los parámetros '%s' no tienen traducción", param.Value))
+			continue
+		}
+		parts = append(parts, goName(param.Value)+" "+g.paramType(fn, i))
+	}
+	header := fmt.Sprintf("func %s(%s)", goName(fn.Value), strings.Join(parts, ", "))
+	if result := g.resultType(fn); result != "" {
+		header += " " + result
+	}
+
+	g.line("%s {", header)
+	g.depth++
+	g.declareLocals(body, g.sc)
+	g.block(body)
+	g.depth--
+	g.line("}")
+	g.line("")
+}
+
+// declareLocals declara con var al comienzo del cuerpo las variables que
+// no se pueden declarar donde se asignan (ver placeDeclarations). Las
+// variables que nunca se leen no se declaran: sus asignaciones van a _.
+func (g *goGenerator) declareLocals(body []*models.ASTNode, sc *scope) {
+	skip := func(symbol *scopeSymbol) bool {
+		return g.globals[symbol] || len(g.reads[symbol]) == 0
+	}
+	for _, symbol := range g.placeDeclarations(body, sc, skip) {
+		g.line("var %s %s", goName(symbol.name), g.symbolType(symbol, symbol.bindings[0].node))
+	}
+}
+
+func (g *goGenerator) block(stmts []*models.ASTNode) {
+	for _, stmt := range stmts {
+		g.statement(stmt)
+	}
+}
+
+// untranslatedStatement deja la sentencia como comentario
+func (g *goGenerator) untranslatedStatement(stmt *models.ASTNode, construct, reason string) {
+	g.unsupported(stmt, construct, reason)
+	for _, text := range formatBlock([]*models.ASTNode{stmt}, "") {
+		g.line("// sin traducir: %s", text)
+	}
+}
+
+func (g *goGenerator) statement(stmt *models.ASTNode) {
+	switch stmt.Type {
+	case "IfStatement":
+		g.ifStatement(stmt)
+		return
+	case "WhileStatement":
+		g.whileStatement(stmt)
+		return
+	case "ForStatement":
+		g.forStatement(stmt)
+		return
+	case "PassStatement", "GlobalStatement":
+		return
+	case "BreakStatement":
+		g.line("break")
+		return
+	case "ContinueStatement":
+		g.line("continue")
+		return
+	case "String":
+		// Un texto suelto (docstring) se conserva como comentario
+		text, _ := literalValue(stmt)
+		for _, line := range strings.Split(strings.TrimSpace(text.s), "\n") {
+			g.line("// %s", strings.TrimSpace(line))
+		}
+		return
+	case "Import", "ImportFrom":
+		g.importStatement(stmt)
+		return
+	case "FunctionDef":
+		g.untranslatedStatement(stmt, "función anidada", "Go no admite funciones con nombre dentro de otra; habría que convertirla en una clausura")
+		return
+	case "ClassDef":
+		g.untranslatedStatement(stmt, "clase", "las clases de Python no tienen una traducción directa a Go")
+		return
+	case "TryStatement":
+		g.untranslatedStatement(stmt, "try", "Go no tiene excepciones: los errores se devuelven como valores")
+		return
+	case "NonlocalStatement":
+		g.untranslatedStatement(stmt, "nonlocal", "las funciones anidadas no se traducen")
+		return
+	}
+
+	// Las sentencias simples se generan aparte: si alguna de sus expresiones
+	// no se puede traducir, la sentencia completa queda como comentario
+	out, failed := g.out, g.failed
+	var buffer strings.Builder
+	g.out, g.failed = &buffer, false
+	depth := g.depth
+	g.depth = 0
+	g.simpleStatement(stmt)
+	g.out, g.depth = out, depth
+	if g.failed {
+		g.line("// sin traducir: %s", formatSource(stmt))
+	} else {
+		for _, text := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+			if text != "" {
+				g.line("%s", text)
+			}
+		}
+	}
+	g.failed = failed
+}
+
+func (g *goGenerator) simpleStatement(stmt *models.ASTNode) {
+	switch stmt.Type {
+	case "Assignment":
+		g.assignment(stmt)
+	case "AugAssignment":
+		g.augAssignment(stmt)
+	case "ReturnStatement":
+		g.returnStatement(stmt)
+	case "RaiseStatement":
+		g.raiseStatement(stmt)
+	case "FunctionCall", "MethodCall":
+		g.callStatement(stmt)
+	default:
+		g.fail(stmt, "sentencia", fmt.Sprintf("la sentencia %s no tiene traducción", stmt.Type))
+	}
+}
+
+// importStatement acepta math, que se traduce al paquete math de Go; los
+// demás módulos solo se informan si el programa usa lo que importan
+func (g *goGenerator) importStatement(stmt *models.ASTNode) {
+	module := stmt.Value
+	for _, alias := range stmt.Children {
+		if stmt.Type == "Import" {
+			module = alias.Value
+		}
+		if module == "math" {
+			continue
+		}
+		bound := alias.Value
+		if len(alias.Children) > 0 {
+			bound = alias.Children[0].Value
+		}
+		if stmt.Type == "Import" {
+			bound = strings.Split(bound, ".")[0]
+		}
+		if symbol := g.sc.symbols[bound]; symbol != nil && len(g.reads[symbol]) > 0 {
+			g.unsupported(stmt, "import", fmt.Sprintf("el módulo '%s' no tiene equivalente en la traducción (solo se traduce math)", module))
+			g.line("// sin traducir: %s", formatSource(stmt))
+			return
+		}
+	}
+}
+
+func (g *goGenerator) ifStatement(node *models.ASTNode) {
+	prefix := "if "
+	for {
+		g.line("%s%s {", prefix, g.truth(node.Children[0], false).code)
+		g.depth++
+		g.block(node.Children[1].Children)
+		g.depth--
+		if len(node.Children) < 3 {
+			break
+		}
+		otherwise := node.Children[2]
+		if isElif(otherwise) {
+			node, prefix = otherwise.Children[0], "} else if "
+			continue
+		}
+		g.line("} else {")
+		g.depth++
+		g.block(otherwise.Children)
+		g.depth--
+		break
+	}
+	g.line("}")
+}
+
+// loopElse informa el else de un while o un for, que Go no tiene
+func (g *goGenerator) loopElse(node *models.ASTNode, body int) {
+	if len(node.Children) > body+1 {
+		otherwise := node.Children[body+1]
+		g.unsupportedLoopElse(node, otherwise, "Go no tiene else en los bucles; se ejecuta cuando el bucle termina sin break y habría que expresarlo con una variable")
+		for _, text := range formatBlock(otherwise.Children, "    ") {
+			g.line("// sin traducir (else del bucle): %s", strings.TrimLeft(text, " "))
+		}
+	}
+}
+
+func (g *goGenerator) whileStatement(node *models.ASTNode) {
+	condition := node.Children[0]
+	if condition.Type == "Boolean" && condition.Value == "True" {
+		g.line("for {")
+	} else {
+		g.line("for %s {", g.truth(condition, false).code)
+	}
+	g.depth++
+	g.block(node.Children[1].Children)
+	g.depth--
+	g.line("}")
+	g.loopElse(node, 1)
+}
+
+func (g *goGenerator) forStatement(node *models.ASTNode) {
+	failed := g.failed
+	g.failed = false
+	header := g.forHeader(node.Children[0], node.Children[1], node)
+	if g.failed {
+		g.line("// sin traducir: %s", formatBlock([]*models.ASTNode{node}, "")[0])
+		g.line("for {")
+	} else {
+		g.line("for %s {", header)
+	}
+	g.failed = failed
+	g.depth++
+	g.block(node.Children[2].Children)
+	g.depth--
+	g.line("}")
+	g.loopElse(node, 2)
+}
+
+// loopTarget es el nombre de la variable de un for y el operador con que se
+// asigna en la cabecera: := si el for la declara, = si ya estaba declarada.
+// Una variable que no se lee se traduce como _.
+func (g *goGenerator) loopTarget(target *models.ASTNode) (string, string, bool) {
+	if target.Type != "Identifier" {
+		return "", "", false
+	}
+	symbol := g.types.bindingSymbol(g.sc, target.Value)
+	if len(g.reads[symbol]) == 0 && !g.globals[symbol] {
+		return "_", ":=", true
+	}
+	if g.inline[target] {
+		return goName(target.Value), ":=", true
+	}
+	return goName(target.Value), "=", true
+}
+
+func (g *goGenerator) forHeader(target, iter, node *models.ASTNode) string {
+	if iter.Type == "FunctionCall" && g.symbol(iter) == nil {
+		switch iter.Value {
+		case "range":
+			return g.rangeHeader(target, iter, node)
+		case "enumerate":
+			return g.enumerateHeader(target, iter, node)
+		}
+	}
+
+	name, op, ok := g.loopTarget(target)
+	if !ok {
+		return g.fail(target, "for", "solo se traduce el desempaquetado de enumerate en la cabecera de un for").code
+	}
+	sequence := g.expr(iter, "")
+	switch {
+	case sequence.typ == "":
+		return sequence.code
+	case isGoSlice(sequence.typ):
+	case sequence.typ == goString:
+		g.use("strings")
+		sequence = goPrimary(fmt.Sprintf("strings.Split(%s, \"\")", sequence.code), "[]string")
+	default:
+		return g.fail(iter, "for", "solo se traducen los for sobre range, enumerate, listas y cadenas").code
+	}
+	if name == "_" {
+		return "range " + sequence.code
+	}
+	return fmt.Sprintf("_, %s %s range %s", name, op, sequence.code)
+}
+
+func (g *goGenerator) rangeHeader(target, iter, node *models.ASTNode) string {
+	name, op, ok := g.loopTarget(target)
+	args := iter.Children
+	if !ok || len(args) == 0 || len(args) > 3 {
+		return g.fail(iter, "for", "solo se traduce range con una variable y de uno a tres argumentos").code
+	}
+	bounds := make([]goExpr, 0, 2)
+	for _, arg := range args[:min(len(args), 2)] {
+		bounds = append(bounds, g.value(arg, goInt))
+	}
+	if len(args) == 1 {
+		if name == "_" {
+			return "range " + bounds[0].code
+		}
+		return fmt.Sprintf("%s %s range %s", name, op, bounds[0].code)
+	}
+
+	step, stepCode := int64(1), ""
+	if len(args) == 3 {
+		var known bool
+		if step, known = intLiteral(args[2]); !known {
+			// El sentido del for depende del signo del paso; se supone positivo
+			step, stepCode = 2, g.value(args[2], goInt).code
+			g.note(args[2], fmt.Sprintf("se supone que el paso '%s' de range es positivo", formatSource(args[2])))
+		} else if step == 0 {
+			return g.fail(args[2], "for", "range con paso 0 lanza ValueError").code
+		}
+	}
+	start, stop := bounds[0], bounds[1]
+	if name == "_" {
+		if step == 1 {
+			return fmt.Sprintf("range %s - %s", stop.wrap(goPrecAdd), start.wrap(goPrecMul))
+		}
+		name, op = "_i", ":="
+	}
+	if op == "=" && len(g.reads[g.types.bindingSymbol(g.sc, target.Value)]) > 0 {
+		g.note(node, fmt.Sprintf("al terminar el for, '%s' vale el límite del range en Go y el último valor recorrido en Python", target.Value))
+	}
+
+	compare, increment := "<", name+"++"
+	switch {
+	case stepCode != "":
+		increment = fmt.Sprintf("%s += %s", name, stepCode)
+	case step > 1:
+		increment = fmt.Sprintf("%s += %d", name, step)
+	case step == -1:
+		compare, increment = ">", name+"--"
+	case step < 0:
+		compare, increment = ">", fmt.Sprintf("%s -= %d", name, -step)
+	}
+	return fmt.Sprintf("%s %s %s; %s %s %s; %s", name, op, start.code, name, compare, stop.code, increment)
+}
+
+func (g *goGenerator) enumerateHeader(target, iter, node *models.ASTNode) string {
+	if target.Type != "Tuple" || len(target.Children) != 2 || len(iter.Children) != 1 || iter.Children[0].Type == "Keyword" {
+		return g.fail(node.Children[1], "for", "solo se traduce enumerate(lista) desempaquetado en dos variables").code
+	}
+	index, op, ok := g.loopTarget(target.Children[0])
+	item, itemOp, itemOK := g.loopTarget(target.Children[1])
+	if !ok || !itemOK {
+		return g.fail(target, "for", "solo se traduce enumerate con dos nombres simples").code
+	}
+	if op != itemOp && index != "_" && item != "_" {
+		return g.fail(target, "for", "las dos variables de enumerate deben declararse en el mismo lugar").code
+	}
+	if index == "_" {
+		op = itemOp
+	}
+	sequence := g.expr(iter.Children[0], "")
+	switch {
+	case sequence.typ == "":
+		return sequence.code
+	case sequence.typ == goString:
+		g.use("strings")
+		sequence = goPrimary(fmt.Sprintf("strings.Split(%s, \"\")", sequence.code), "[]string")
+	case !isGoSlice(sequence.typ):
+		return g.fail(iter, "for", "solo se traduce enumerate sobre listas y cadenas").code
+	}
+	if index == "_" && item == "_" {
+		return "range " + sequence.code
+	}
+	if item == "_" {
+		return fmt.Sprintf("%s %s range %s", index, op, sequence.code)
+	}
+	return fmt.Sprintf("%s, %s %s range %s", index, item, op, sequence.code)
+}
+
+// assignTarget traduce un destino de asignación: el código del lado
+// izquierdo, el tipo que espera y si la asignación declara la variable
+func (g *goGenerator) assignTarget(target *models.ASTNode) (string, string, bool) {
+	if name := targetName(target); name != "" {
+		symbol := g.types.bindingSymbol(g.sc, name)
+		typ := g.symbolType(symbol, target)
+		if len(g.reads[symbol]) == 0 && !g.globals[symbol] {
+			return "_", typ, false
+		}
+		return goName(name), typ, g.inline[target]
+	}
+	if target.Type == "Subscript" && target.Children[1].Type != "Slice" {
+		element := g.expr(target, "")
+		return element.code, element.typ, false
+	}
+	return g.fail(target, "asignación", "solo se asigna a nombres y a elementos de una lista").code, "", false
+}
+
+func (g *goGenerator) assignment(node *models.ASTNode) {
+	targets, values := assignmentPairs(node)
+	if node.Value == "" && (targets[0].Type == "Tuple" || targets[0].Type == "List") {
+		g.fail(node, "desempaquetado", "solo se traducen las asignaciones múltiples con el mismo número de valores (a, b = x, y)")
+		return
+	}
+
+	left := make([]string, len(targets))
+	right := make([]string, len(targets))
+	declares := make([]bool, len(targets))
+	declare, reuse := false, false
+	for i, target := range targets {
+		code, typ, isNew := g.assignTarget(target)
+		left[i], declares[i] = code, isNew
+		right[i] = g.value(values[i], typ).code
+		if isNew {
+			declare = true
+		} else if code != "_" {
+			reuse = true
+		}
+	}
+
+	// := solo si todos los nombres son nuevos; si no, los nuevos se
+	// declaran antes para no ocultar variables existentes
+	op := "="
+	if declare && !reuse {
+		op = ":="
+	} else if declare {
+		for i, target := range targets {
+			if declares[i] {
+				symbol := g.types.bindingSymbol(g.sc, targetName(target))
+				g.line("var %s %s", left[i], g.symbolType(symbol, target))
+			}
+		}
+	}
+	if op == "=" && len(targets) == 1 && left[0] == "_" && values[0].Type == "FunctionCall" {
+		g.line("%s", right[0])
+		return
+	}
+	g.line("%s %s %s", strings.Join(left, ", "), op, strings.Join(right, ", "))
+}
+
+// Operadores aumentados que Go escribe igual para cada tipo
+var goAugmented = map[string]map[string]bool{
+	goInt:    {"+": true, "-": true, "*": true, "&": true, "|": true, "^": true, "<<": true, ">>": true},
+	goFloat:  {"+": true, "-": true, "*": true},
+	goString: {"+": true},
+}
+
+func (g *goGenerator) augAssignment(node *models.ASTNode) {
+	target, value := node.Children[0], node.Children[1]
+	op := strings.TrimSuffix(node.Value, "=")
+	if target.Type != "Identifier" && target.Type != "Subscript" {
+		g.fail(target, "asignación", "solo se asigna a nombres y a elementos de una lista")
+		return
+	}
+	current := g.expr(target, "")
+	if current.typ == "" {
+		return
+	}
+	if isGoSlice(current.typ) && op == "+" {
+		g.line("%s = append(%s, %s...)", current.code, current.code, g.value(value, current.typ).code)
+		return
+	}
+
+	operand := g.expr(value, "")
+	result := g.arithmetic(op, current, operand, node)
+	switch {
+	case result.typ == "":
+		return
+	case result.typ != current.typ:
+		g.fail(node, "asignación", fmt.Sprintf("'%s' cambia el tipo de la variable de %s a %s", formatSource(node), current.typ, result.typ))
+		return
+	case goAugmented[current.typ][op]:
+		operand = g.convert(operand, current.typ, value)
+		if current.typ == goInt && operand.constant && operand.code == "1" && (op == "+" || op == "-") {
+			g.line("%s%s%s", current.code, op, op)
+			return
+		}
+		g.line("%s %s= %s", current.code, op, operand.code)
+	default:
+		g.line("%s = %s", current.code, result.code)
+	}
+}
+
+func (g *goGenerator) returnStatement(node *models.ASTNode) {
+	if g.fn == nil {
+		g.fail(node, "return", "return fuera de una función")
+		return
+	}
+	result := g.resultType(g.fn)
+	if len(node.Children) == 0 || (node.Children[0].Type == "None" && result == "") {
+		g.line("return")
+		return
+	}
+	g.line("return %s", g.value(node.Children[0], result).code)
+}
+
+// raiseStatement traduce raise Error(mensaje) como panic con el nombre de la
+// excepción y el mensaje, que es lo que Python muestra al final del traceback
+func (g *goGenerator) raiseStatement(node *models.ASTNode) {
+	if len(node.Children) != 1 {
+		g.fail(node, "raise", "solo se traduce raise con una excepción")
+		return
+	}
+	exception := node.Children[0]
+	switch {
+	case exception.Type == "Identifier":
+		g.line("panic(%s)", strconv.Quote(exception.Value))
+	case exception.Type == "FunctionCall" && len(exception.Children) == 0:
+		g.line("panic(%s)", strconv.Quote(exception.Value))
+	case exception.Type == "FunctionCall" && len(exception.Children) == 1:
+		if value, ok := literalValue(exception.Children[0]); ok && value.kind == "str" {
+			g.line("panic(%s)", strconv.Quote(exception.Value+": "+value.s))
+			return
+		}
+		message := g.expr(exception.Children[0], "")
+		if message.typ != "" && message.typ != goString {
+			message = g.str(message, exception.Children[0])
+		}
+		g.line("panic(%s + %s)", strconv.Quote(exception.Value+": "), message.wrap(goPrecMul))
+	default:
+		g.fail(node, "raise", "solo se traduce raise con el nombre de la excepción o una llamada con un mensaje")
+	}
+}
+
+func (g *goGenerator) callStatement(node *models.ASTNode) {
+	if node.Type == "FunctionCall" && g.symbol(node) == nil && node.Value == "print" {
+		g.line("%s", g.printCall(node))
+		return
+	}
+	if node.Type == "FunctionCall" && g.listStatement(node) {
+		return
+	}
+	var call goExpr
+	if fn := g.userFunction(node); fn != nil && node.Type == "FunctionCall" {
+		call = g.userCall(fn, node, true)
+	} else {
+		call = g.expr(node, "")
+	}
+	if call.typ != "" || call.code != "" {
+		g.line("%s", call.code)
+	}
+}
+
+// listStatement traduce los métodos que modifican una lista en el lugar
+func (g *goGenerator) listStatement(node *models.ASTNode) bool {
+	dot := strings.LastIndex(node.Value, ".")
+	if dot < 0 || strings.Count(node.Value, ".") != 1 {
+		return false
+	}
+	symbol := g.symbol(node)
+	if symbol == nil || symbol.kind == "module" {
+		return false
+	}
+	list := g.identifier(node, node.Value[:dot])
+	if !isGoSlice(list.typ) {
+		return false
+	}
+	elem := list.typ[2:]
+	args := node.Children
+	switch method := node.Value[dot+1:]; {
+	case method == "append" && len(args) == 1:
+		g.line("%s = append(%s, %s)", list.code, list.code, g.value(args[0], elem).code)
+	case method == "extend" && len(args) == 1:
+		g.line("%s = append(%s, %s...)", list.code, list.code, g.value(args[0], list.typ).code)
+	case method == "insert" && len(args) == 2:
+		g.use("slices")
+		g.line("%s = slices.Insert(%s, %s, %s)", list.code, list.code, g.value(args[0], goInt).code, g.value(args[1], elem).code)
+	case method == "pop" && len(args) == 0:
+		g.line("%s = %s[:len(%s)-1]", list.code, list.code, list.code)
+	case method == "sort" && len(args) == 0 && elem != goBig:
+		g.use("slices")
+		g.line("slices.Sort(%s)", list.code)
+	case method == "reverse" && len(args) == 0:
+		g.use("slices")
+		g.line("slices.Reverse(%s)", list.code)
+	default:
+		return false
+	}
+	return true
+}
+
+// printCall traduce print con fmt.Println, o con fmt.Print y los
+// separadores explícitos si se indican sep o end
+func (g *goGenerator) printCall(node *models.ASTNode) string {
+	g.use("fmt")
+	var args []string
+	var sep, end *models.ASTNode
+	for _, arg := range node.Children {
+		switch {
+		case arg.Type == "Keyword" && arg.Value == "sep" && len(arg.Children) > 0:
+			sep = arg.Children[0]
+		case arg.Type == "Keyword" && arg.Value == "end" && len(arg.Children) > 0:
+			end = arg.Children[0]
+		case arg.Type == "Keyword" || arg.Type == "Starred":
+			return g.fail(arg, "print", "print solo se traduce con argumentos posicionales, sep y end").code
+		default:
+			args = append(args, g.printable(g.expr(arg, ""), arg).code)
+		}
+	}
+	if sep == nil && end == nil {
+		return "fmt.Println(" + strings.Join(args, ", ") + ")"
+	}
+
+	// fmt.Print no agrega espacios junto a un operando string, así que los
+	// separadores se escriben tal cual
+	separator, terminator := goPrimary(`" "`, goString), goPrimary(`"\n"`, goString)
+	if sep != nil {
+		separator = g.expr(sep, goString)
+	}
+	if end != nil {
+		terminator = g.expr(end, goString)
+	}
+	if (separator.typ != goString && separator.typ != "") || (terminator.typ != goString && terminator.typ != "") {
+		return g.fail(node, "print", "sep y end deben ser cadenas").code
+	}
+	parts := make([]string, 0, 2*len(args)+1)
+	for i, arg := range args {
+		if i > 0 {
+			parts = append(parts, separator.code)
+		}
+		parts = append(parts, arg)
+	}
+	if terminator.code != `""` {
+		parts = append(parts, terminator.code)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "fmt.Print(" + strings.Join(parts, ", ") + ")"
+}
+
+// printable adapta un valor para que fmt lo escriba como print de Python
+func (g *goGenerator) printable(e goExpr, node *models.ASTNode) goExpr {
+	switch {
+	case e.typ == goFloat:
+		g.helper("formatFloat")
+		return goPrimary("formatFloat("+e.code+")", goString)
+	case e.typ == goBool:
+		g.helper("formatBool")
+		return goPrimary("formatBool("+e.code+")", goString)
+	case isGoSlice(e.typ):
+		g.helper("formatList")
+		return goPrimary("formatList("+e.code+")", goString)
+	}
+	return e
+}
+
+// value traduce una expresión y la convierte al tipo want
+func (g *goGenerator) value(node *models.ASTNode, want string) goExpr {
+	return g.convert(g.expr(node, want), want, node)
+}
+
+// convert adapta una expresión al tipo want: int a *big.Int o a float64, y
+// *big.Int a int cuando el valor se usa como índice o límite
+func (g *goGenerator) convert(e goExpr, want string, node *models.ASTNode) goExpr {
+	if want == "" || want == "any" || e.typ == "" || e.typ == want {
+		return e
+	}
+	switch {
+	case e.typ == goInt && want == goBig:
+		g.use("math/big")
+		if e.constant {
+			return goPrimary("big.NewInt("+e.code+")", goBig)
+		}
+		return goPrimary("big.NewInt(int64("+e.code+"))", goBig)
+	case e.typ == goInt && want == goFloat:
+		if e.constant {
+			return goExpr{code: e.code, typ: goFloat, prec: e.prec, constant: true}
+		}
+		return goPrimary("float64("+e.code+")", goFloat)
+	case e.typ == goBig && want == goInt:
+		return goPrimary("int("+e.wrap(goPrecPrimary)+".Int64())", goInt)
+	case isGoSlice(e.typ) && isGoSlice(want) && strings.HasPrefix(e.code, e.typ+"{}"):
+		return goPrimary(want+"{}", want)
+	}
+	return g.fail(node, "conversión", fmt.Sprintf("'%s' es %s y se esperaba %s", formatSource(node), e.typ, want))
+}
+
+// str traduce str(x)
+func (g *goGenerator) str(e goExpr, node *models.ASTNode) goExpr {
+	switch e.typ {
+	case goString:
+		return e
+	case goInt:
+		g.use("strconv")
+		return goPrimary("strconv.Itoa("+e.code+")", goString)
+	case goBig:
+		return goPrimary(e.wrap(goPrecPrimary)+".String()", goString)
+	case goFloat, goBool:
+		return g.printable(e, node)
+	case "":
+		return e
+	}
+	if isGoSlice(e.typ) {
+		return g.printable(e, node)
+	}
+	return g.fail(node, "str", fmt.Sprintf("str() de un valor %s no tiene traducción", e.typ))
+}
+
+// truth traduce una expresión usada como condición. Python evalúa la
+// verdad de cualquier valor; Go exige un bool, así que los números se
+// comparan con cero, las cadenas con "" y las listas por su longitud.
+func (g *goGenerator) truth(node *models.ASTNode, negate bool) goExpr {
+	switch {
+	case node.Type == "UnaryOp" && node.Value == "not":
+		return g.truth(node.Children[0], !negate)
+	case node.Type == "BinaryOp" && (node.Value == "and" || node.Value == "or"):
+		op, prec := "&&", goPrecAnd
+		if node.Value == "or" {
+			op, prec = "||", goPrecOr
+		}
+		left, right := g.truth(node.Children[0], false), g.truth(node.Children[1], false)
+		e := goExpr{code: left.wrap(prec) + " " + op + " " + right.wrap(prec+1), typ: goBool, prec: prec}
+		if negate {
+			return goExpr{code: "!(" + e.code + ")", typ: goBool, prec: goPrecUnary}
+		}
+		return e
+	case node.Type == "BinaryOp" && negate && invertedComparison[node.Value] != "":
+		operand := g.types.expressionType(node.Children[0], g.sc)
+		// Con float la comparación invertida no es equivalente si hay NaN
+		if operand == typeInt || operand == typeStr {
+			return g.compare(invertedComparison[node.Value], node)
+		}
+	}
+
+	e := g.expr(node, "")
+	zero := map[string]string{goInt: "0", goFloat: "0", goString: `""`}[e.typ]
+	eq := map[bool]string{false: "!=", true: "=="}[negate]
+	switch {
+	case e.typ == "":
+		return e
+	case e.typ == goBool:
+		if negate {
+			return goExpr{code: "!" + e.wrap(goPrecUnary), typ: goBool, prec: goPrecUnary}
+		}
+		return e
+	case zero != "":
+		return goExpr{code: e.wrap(goPrecCompare+1) + " " + eq + " " + zero, typ: goBool, prec: goPrecCompare}
+	case e.typ == goBig:
+		return goExpr{code: e.wrap(goPrecPrimary) + ".Sign() " + eq + " 0", typ: goBool, prec: goPrecCompare}
+	case isGoSlice(e.typ):
+		compare := map[bool]string{false: ">", true: "=="}[negate]
+		return goExpr{code: "len(" + e.code + ") " + compare + " 0", typ: goBool, prec: goPrecCompare}
+	}
+	return g.fail(node, "condición", fmt.Sprintf("un valor %s no puede usarse como condición", e.typ))
+}
+
+// expr traduce una expresión; want es el tipo esperado por el contexto y
+// solo se usa para dar tipo a las listas vacías
+func (g *goGenerator) expr(node *models.ASTNode, want string) goExpr {
+	switch node.Type {
+	case "Number":
+		value, ok := literalValue(node)
+		switch {
+		case !ok && !strings.ContainsAny(node.Value, ".jJ"):
+			if _, isInt := new(big.Int).SetString(strings.ReplaceAll(node.Value, "_", ""), 0); isInt {
+				return goExpr{code: node.Value, typ: goInt, prec: goPrecPrimary, constant: true}
+			}
+		case ok && value.kind == "float":
+			return goExpr{code: node.Value, typ: goFloat, prec: goPrecPrimary, constant: true}
+		case ok && value.kind == "int":
+			if value.i.Cmp(maxInt64) > 0 {
+				g.helper("bigInt")
+				return goPrimary("bigInt("+strconv.Quote(node.Value)+")", goBig)
+			}
+			return goExpr{code: node.Value, typ: goInt, prec: goPrecPrimary, constant: true}
+		}
+		return g.fail(node, "número", "los números complejos no tienen traducción")
+
+	case "String":
+		value, _ := literalValue(node)
+		return goExpr{code: strconv.Quote(value.s), typ: goString, prec: goPrecPrimary, constant: true}
+
+	case "Boolean":
+		return goExpr{code: strings.ToLower(node.Value), typ: goBool, prec: goPrecPrimary, constant: true}
+
+	case "None":
+		return g.fail(node, "None", "None solo se traduce como el retorno de una función que no devuelve valor")
+
+	case "Identifier":
+		return g.identifier(node, node.Value)
+
+	case "Attribute":
+		if path := dottedName(node); path != "" {
+			if constant, ok := goMathConstants[g.qualifiedName(node, path)]; ok {
+				g.use("math")
+				return goPrimary(constant, goFloat)
+			}
+		}
+		return g.fail(node, "atributo", "los atributos solo se traducen para las constantes de math")
+
+	case "UnaryOp":
+		return g.unary(node)
+
+	case "BinaryOp":
+		switch {
+		case node.Value == "and" || node.Value == "or":
+			if g.types.expressionType(node, g.sc) != typeBool {
+				return g.fail(node, node.Value, fmt.Sprintf("'%s' con valores que no son bool devuelve uno de los operandos; Go solo combina booleanos", node.Value))
+			}
+			return g.truth(node, false)
+		case isComparison(node.Value):
+			return g.compare(node.Value, node)
+		}
+		return g.arithmetic(node.Value, g.expr(node.Children[0], ""), g.expr(node.Children[1], ""), node)
+
+	case "IfExpression":
+		condition := g.truth(node.Children[0], false)
+		then, otherwise := g.expr(node.Children[1], want), g.expr(node.Children[2], want)
+		typ := want
+		if typ == "" {
+			typ = then.typ
+			if then.typ != otherwise.typ && isGoNumber(then.typ) && isGoNumber(otherwise.typ) {
+				typ = widerNumber(then.typ, otherwise.typ)
+			}
+		}
+		if condition.typ == "" || then.typ == "" || otherwise.typ == "" {
+			return g.failedExpr(node)
+		}
+		then, otherwise = g.convert(then, typ, node.Children[1]), g.convert(otherwise, typ, node.Children[2])
+		return goPrimary(fmt.Sprintf("func() %s {\nif %s {\nreturn %s\n}\nreturn %s\n}()", typ, condition.code, then.code, otherwise.code), typ)
+
+	case "List":
+		return g.listLiteral(node, want)
+
+	case "Subscript":
+		return g.subscript(node)
+
+	case "FunctionCall":
+		return g.call(node)
+
+	case "MethodCall":
+		receiver := g.expr(node.Children[0].Children[0], "")
+		if receiver.typ == "" {
+			return receiver
+		}
+		return g.method(receiver, node.Value, node.Children[1:], node)
+	}
+
+	constructs := map[string]string{
+		"Lambda": "lambda", "ListComp": "comprensión de lista", "SetComp": "comprensión de conjunto",
+		"DictComp": "comprensión de diccionario", "GeneratorExp": "expresión generadora",
+		"Dict": "diccionario", "Set": "conjunto", "Tuple": "tupla",
+	}
+	construct := constructs[node.Type]
+	if construct == "" {
+		construct = node.Type
+	}
+	return g.fail(node, construct, fmt.Sprintf("%s no tiene traducción a Go en esta versión", construct))
+}
+
+func widerNumber(a, b string) string {
+	switch {
+	case a == goFloat || b == goFloat:
+		return goFloat
+	case a == goBig || b == goBig:
+		return goBig
+	}
+	return goInt
+}
+
+// identifier traduce la lectura de una variable; node es el nodo que la
+// resolvió (un Identifier, o la llamada "xs.append" para su receptor)
+func (g *goGenerator) identifier(node *models.ASTNode, name string) goExpr {
+	symbol := g.symbol(node)
+	switch {
+	case symbol == nil:
+		return g.fail(node, "nombre", fmt.Sprintf("'%s' no es una variable del programa", name))
+	case symbol.kind == "variable" && symbol.module != "":
+		if constant, ok := goMathConstants[g.qualifiedName(node, name)]; ok {
+			g.use("math")
+			return goPrimary(constant, goFloat)
+		}
+		return g.fail(node, "nombre", fmt.Sprintf("'%s' de %s no tiene traducción", name, symbol.module))
+	case symbol.kind == "function" || symbol.kind == "class" || symbol.kind == "module":
+		return g.fail(node, "nombre", fmt.Sprintf("'%s' se usa como valor; las funciones, clases y módulos como valores no se traducen", name))
+	}
+	return goPrimary(goName(name), g.symbolType(symbol, symbol.node))
+}
+
+func (g *goGenerator) unary(node *models.ASTNode) goExpr {
+	if node.Value == "not" {
+		return g.truth(node.Children[0], true)
+	}
+	operand := g.expr(node.Children[0], "")
+	switch {
+	case operand.typ == "":
+		return operand
+	case node.Value == "+" && isGoNumber(operand.typ):
+		return operand
+	case node.Value == "-" && operand.typ == goBig:
+		return goPrimary("new(big.Int).Neg("+operand.code+")", goBig)
+	case node.Value == "-" && (operand.typ == goInt || operand.typ == goFloat):
+		code := operand.wrap(goPrecUnary)
+		if strings.HasPrefix(code, "-") {
+			code = "(" + code + ")"
+		}
+		return goExpr{code: "-" + code, typ: operand.typ, prec: goPrecUnary, constant: operand.constant}
+	case node.Value == "~" && operand.typ == goInt:
+		return goExpr{code: "^" + operand.wrap(goPrecUnary), typ: goInt, prec: goPrecUnary}
+	}
+	return g.fail(node, "operador", fmt.Sprintf("'%s' no se aplica a un valor %s", node.Value, operand.typ))
+}
+
+// compare traduce una comparación; con *big.Int se usa Cmp
+func (g *goGenerator) compare(op string, node *models.ASTNode) goExpr {
+	switch op {
+	case "is", "is not":
+		return g.fail(node, op, "is compara la identidad de los objetos y no tiene traducción")
+	case "in", "not in":
+		return g.membership(op, node)
+	}
+
+	left, right := g.expr(node.Children[0], ""), g.expr(node.Children[1], "")
+	if left.typ == "" || right.typ == "" {
+		return g.failedExpr(node)
+	}
+	binary := func(l, r goExpr) goExpr {
+		return goExpr{code: l.wrap(goPrecCompare+1) + " " + op + " " + r.wrap(goPrecCompare+1), typ: goBool, prec: goPrecCompare}
+	}
+	switch {
+	case isGoNumber(left.typ) && isGoNumber(right.typ):
+		typ := widerNumber(left.typ, right.typ)
+		if (left.typ == goBig && right.typ == goFloat) || (left.typ == goFloat && right.typ == goBig) {
+			return g.fail(node, "comparación", "comparar un entero grande con un float no tiene traducción")
+		}
+		l, r := g.convert(left, typ, node.Children[0]), g.convert(right, typ, node.Children[1])
+		if typ != goBig {
+			return binary(l, r)
+		}
+		if right.constant && right.code == "0" {
+			return goExpr{code: l.wrap(goPrecPrimary) + ".Sign() " + op + " 0", typ: goBool, prec: goPrecCompare}
+		}
+		return goExpr{code: l.wrap(goPrecPrimary) + ".Cmp(" + r.code + ") " + op + " 0", typ: goBool, prec: goPrecCompare}
+	case left.typ == goString && right.typ == goString:
+		return binary(left, right)
+	case left.typ == goBool && right.typ == goBool && (op == "==" || op == "!="):
+		return binary(left, right)
+	case isGoSlice(left.typ) && left.typ == right.typ && (op == "==" || op == "!="):
+		g.use("slices")
+		prefix := map[string]string{"==": "", "!=": "!"}[op]
+		return goExpr{code: prefix + "slices.Equal(" + left.code + ", " + right.code + ")", typ: goBool, prec: goPrecUnary}
+	}
+	return g.fail(node, "comparación", fmt.Sprintf("'%s' entre %s y %s no tiene traducción", op, left.typ, right.typ))
+}
+
+func (g *goGenerator) membership(op string, node *models.ASTNode) goExpr {
+	item, container := g.expr(node.Children[0], ""), g.expr(node.Children[1], "")
+	if item.typ == "" || container.typ == "" {
+		return g.failedExpr(node)
+	}
+	prefix := ""
+	if op == "not in" {
+		prefix = "!"
+	}
+	switch {
+	case container.typ == goString && item.typ == goString:
+		g.use("strings")
+		return goExpr{code: prefix + "strings.Contains(" + container.code + ", " + item.code + ")", typ: goBool, prec: goPrecUnary}
+	case isGoSlice(container.typ):
+		item = g.convert(item, container.typ[2:], node.Children[0])
+		g.use("slices")
+		return goExpr{code: prefix + "slices.Contains(" + container.code + ", " + item.code + ")", typ: goBool, prec: goPrecUnary}
+	}
+	return g.fail(node, op, fmt.Sprintf("'%s' sobre un valor %s no tiene traducción", op, container.typ))
+}
+
+// arithmetic traduce un operador aritmético entre dos expresiones ya
+// generadas. Con float se usan las funciones de math para //, % y **; con
+// enteros grandes, los métodos de *big.Int.
+func (g *goGenerator) arithmetic(op string, left, right goExpr, node *models.ASTNode) goExpr {
+	if left.typ == "" || right.typ == "" {
+		return g.failedExpr(node)
+	}
+	leftNode, rightNode := node, node
+	if len(node.Children) == 2 {
+		leftNode, rightNode = node.Children[0], node.Children[1]
+	}
+	// Un divisor constante distinto de cero no necesita la comprobación de
+	// ZeroDivisionError
+	divisor := func(r goExpr) goExpr {
+		if value, ok := g.constants.valueOf(rightNode); ok && value.isNumber() && !value.isZero() {
+			return r
+		}
+		g.helper("divisor")
+		return goPrimary("divisor("+r.code+")", goFloat)
+	}
+	binary := func(l, r goExpr, typ string) goExpr {
+		prec := goBinaryPrecedence[op]
+		return goExpr{code: l.wrap(prec) + " " + op + " " + r.wrap(prec+1), typ: typ, prec: prec, constant: l.constant && r.constant}
+	}
+
+	switch {
+	case left.typ == goString && right.typ == goString && op == "+":
+		return binary(left, right, goString)
+	case op == "*" && ((left.typ == goString && right.typ == goInt) || (left.typ == goInt && right.typ == goString)):
+		if left.typ == goInt {
+			left, right = right, left
+		}
+		g.use("strings")
+		return goPrimary("strings.Repeat("+left.code+", "+right.code+")", goString)
+	case op == "*" && ((isGoSlice(left.typ) && right.typ == goInt) || (left.typ == goInt && isGoSlice(right.typ))):
+		if left.typ == goInt {
+			left, right = right, left
+		}
+		g.use("slices")
+		return goPrimary("slices.Repeat("+left.code+", "+right.code+")", left.typ)
+	case isGoSlice(left.typ) && left.typ == right.typ && op == "+":
+		g.use("slices")
+		return goPrimary("slices.Concat("+left.code+", "+right.code+")", left.typ)
+	case !isGoNumber(left.typ) || !isGoNumber(right.typ):
+		return g.fail(node, "operación", fmt.Sprintf("'%s' entre %s y %s no tiene traducción", op, left.typ, right.typ))
+	}
+
+	switch {
+	case left.typ == goFloat || right.typ == goFloat:
+		if left.typ == goBig || right.typ == goBig {
+			return g.fail(node, "operación", "las operaciones entre enteros grandes y float no tienen traducción")
+		}
+		l, r := g.convert(left, goFloat, leftNode), g.convert(right, goFloat, rightNode)
+		switch op {
+		case "+", "-", "*":
+			return binary(l, r, goFloat)
+		case "/":
+			return binary(l, divisor(r), goFloat)
+		case "//":
+			g.use("math")
+			return goPrimary("math.Floor("+l.wrap(goPrecMul)+" / "+divisor(r).wrap(goPrecMul+1)+")", goFloat)
+		case "%":
+			g.use("math")
+			g.note(node, "math.Mod conserva el signo del dividendo y el % de Python el del divisor: difieren con operandos negativos")
+			return goPrimary("math.Mod("+l.code+", "+divisor(r).code+")", goFloat)
+		case "**":
+			g.use("math")
+			return goPrimary("math.Pow("+l.code+", "+r.code+")", goFloat)
+		}
+
+	case op == "**" && left.typ == goInt && right.typ == goInt && g.negative(rightNode):
+		// Python devuelve un float: 2 ** -1 es 0.5
+		g.use("math")
+		l, r := g.convert(left, goFloat, leftNode), g.convert(right, goFloat, rightNode)
+		return goPrimary("math.Pow("+l.code+", "+r.code+")", goFloat)
+
+	case left.typ == goBig || right.typ == goBig || op == "**":
+		g.use("math/big")
+		switch op {
+		case "<<", ">>":
+			if right.typ == goBig {
+				return g.fail(node, "operación", "un desplazamiento por un entero grande no tiene traducción")
+			}
+			method := map[string]string{"<<": "Lsh", ">>": "Rsh"}[op]
+			return goPrimary(fmt.Sprintf("new(big.Int).%s(%s, uint(%s))", method, g.convert(left, goBig, leftNode).code, right.code), goBig)
+		case "/":
+			return g.fail(node, "operación", "la división real entre enteros grandes no tiene traducción")
+		}
+		l, r := g.convert(left, goBig, leftNode), g.convert(right, goBig, rightNode)
+		method := bigMethods[op]
+		if op == "//" || op == "%" {
+			g.note(node, "Div y Mod de math/big usan la división euclídea: coinciden con // y % de Python solo si el divisor es positivo")
+		}
+		if op == "**" {
+			if value, ok := g.constants.valueOf(rightNode); ok && value.isIntegral() && value.toInt().Sign() >= 0 {
+				return goPrimary(fmt.Sprintf("new(big.Int).Exp(%s, %s, nil)", l.code, r.code), goBig)
+			}
+			// Exp devuelve 1 con un exponente negativo; powInt detiene el programa
+			g.helper("powInt")
+			g.note(node, "con un exponente negativo Python devuelve un float y la traducción se detiene: solo se traducen exponentes que no son negativos")
+			return goPrimary(fmt.Sprintf("powInt(%s, %s)", l.code, r.code), goBig)
+		}
+		return goPrimary(fmt.Sprintf("new(big.Int).%s(%s, %s)", method, l.code, r.code), goBig)
+
+	default:
+		switch op {
+		case "/":
+			l, r := g.convert(left, goFloat, leftNode), g.convert(right, goFloat, rightNode)
+			if left.constant && right.constant {
+				l = goPrimary("float64("+left.code+")", goFloat)
+			}
+			return binary(l, divisor(r), goFloat)
+		case "//", "%":
+			// Con operandos constantes se usa el valor que calcula Python
+			if value, ok := g.constants.values[node]; ok && value.kind == "int" && node.Type == "BinaryOp" {
+				return goExpr{code: value.i.String(), typ: goInt, prec: goPrecPrimary, constant: true}
+			}
+			// La división entera de Go trunca hacia cero y la de Python
+			// redondea hacia abajo; floorDiv y floorMod siguen a Python
+			helper := map[string]string{"//": "floorDiv", "%": "floorMod"}[op]
+			g.helper(helper)
+			return goPrimary(helper+"("+left.code+", "+right.code+")", goInt)
+		case "+", "-", "*", "<<", ">>", "&", "|", "^":
+			return binary(left, right, goInt)
+		}
+	}
+	return g.fail(node, "operación", fmt.Sprintf("el operador '%s' no tiene traducción", op))
+}
+
+// negative indica si la expresión es un entero constante negativo
+func (g *goGenerator) negative(node *models.ASTNode) bool {
+	value, ok := g.constants.valueOf(node)
+	return ok && value.isIntegral() && value.toInt().Sign() < 0
+}
+
+func (g *goGenerator) listLiteral(node *models.ASTNode, want string) goExpr {
+	typ := want
+	if !isGoSlice(typ) {
+		var ok bool
+		if typ, ok = g.goType(g.types.expressionType(node, g.sc), false); !ok || !isGoSlice(typ) {
+			return g.fail(node, "lista", "no se pudo inferir el tipo de los elementos de la lista")
+		}
+	}
+	items := make([]string, 0, len(node.Children))
+	for _, child := range node.Children {
+		item := g.value(child, typ[2:])
+		if item.typ == "" {
+			return item
+		}
+		items = append(items, item.code)
+	}
+	return goPrimary(typ+"{"+strings.Join(items, ", ")+"}", typ)
+}
+
+// length traduce len(); un string se mide en caracteres, como en Python
+func (g *goGenerator) length(container goExpr) string {
+	if container.typ == goString {
+		g.use("unicode/utf8")
+		return "utf8.RuneCountInString(" + container.code + ")"
+	}
+	return "len(" + container.code + ")"
+}
+
+// index traduce un índice de Python; un literal negativo cuenta desde el final
+func (g *goGenerator) index(container goExpr, node *models.ASTNode) (string, bool) {
+	if value, ok := intLiteral(node); ok && value < 0 {
+		if container.prec < goPrecPrimary || strings.Contains(container.code, "(") {
+			g.fail(node, "índice", "un índice negativo solo se traduce sobre una variable")
+			return "", false
+		}
+		return fmt.Sprintf("%s-%d", g.length(container), -value), true
+	}
+	index := g.value(node, goInt)
+	return index.code, index.typ != ""
+}
+
+func (g *goGenerator) subscript(node *models.ASTNode) goExpr {
+	container := g.expr(node.Children[0], "")
+	if container.typ == "" {
+		return container
+	}
+	if !isGoSlice(container.typ) && container.typ != goString {
+		return g.fail(node, "índice", fmt.Sprintf("el acceso por índice a un valor %s no tiene traducción", container.typ))
+	}
+	// Los índices de Python cuentan caracteres y los de un string de Go
+	// bytes, así que el string se indexa como []rune
+	indexed := container.wrap(goPrecPrimary)
+	if container.typ == goString {
+		indexed = "[]rune(" + container.code + ")"
+	}
+
+	part := node.Children[1]
+	if part.Type != "Slice" {
+		index, ok := g.index(container, part)
+		if !ok {
+			return g.failedExpr(node)
+		}
+		if container.typ == goString {
+			return goPrimary("string("+indexed+"["+index+"])", goString)
+		}
+		return goPrimary(indexed+"["+index+"]", container.typ[2:])
+	}
+
+	bounds := make([]string, 2)
+	for i, bound := range part.Children {
+		switch {
+		case bound.Type == "None":
+			continue
+		case i == 2:
+			return g.fail(part, "rebanado", "los rebanados con paso no tienen traducción")
+		}
+		index, ok := g.index(container, bound)
+		if !ok {
+			return g.failedExpr(node)
+		}
+		bounds[i] = index
+	}
+	if container.typ == goString {
+		return goPrimary("string("+indexed+"["+bounds[0]+":"+bounds[1]+"])", goString)
+	}
+	return goPrimary(indexed+"["+bounds[0]+":"+bounds[1]+"]", container.typ)
+}
+
+// call traduce una llamada a una función del programa, a una función
+// integrada, a math o a un método de una variable ("s.upper()")
+func (g *goGenerator) call(node *models.ASTNode) goExpr {
+	if fn := g.userFunction(node); fn != nil {
+		return g.userCall(fn, node, false)
+	}
+	symbol := g.symbol(node)
+	if dot := strings.LastIndex(node.Value, "."); dot >= 0 && symbol != nil && symbol.kind != "module" {
+		if strings.Count(node.Value, ".") > 1 {
+			return g.fail(node, "método", "solo se traducen los métodos de una variable")
+		}
+		receiver := g.identifier(node, node.Value[:dot])
+		if receiver.typ == "" {
+			return receiver
+		}
+		return g.method(receiver, node.Value[dot+1:], node.Children, node)
+	}
+	return g.builtinCall(g.qualifiedName(node, node.Value), node)
+}
+
+// userCall traduce una llamada a una función del programa; statement indica
+// que el resultado se descarta, lo único posible si la función no devuelve
+// ningún valor
+func (g *goGenerator) userCall(fn, node *models.ASTNode, statement bool) goExpr {
+	if g.types.functions[fn] == nil || fn.Type != "FunctionDef" {
+		return g.fail(node, "llamada", fmt.Sprintf("'%s' no es una función traducible", fn.Value))
+	}
+	args, ok := callArguments(fn, node)
+	if !ok {
+		return g.fail(node, "llamada", fmt.Sprintf("los argumentos de '%s' no coinciden con sus parámetros", formatSource(node)))
+	}
+	codes := make([]string, len(args))
+	for i, arg := range args {
+		value := g.value(arg, g.paramType(fn, i))
+		if value.typ == "" {
+			return value
+		}
+		codes[i] = value.code
+	}
+	result := g.resultType(fn)
+	if result == "" && !statement {
+		return g.fail(node, "llamada", fmt.Sprintf("'%s' no devuelve ningún valor: en Python la llamada vale None y en Go no puede usarse como valor", fn.Value))
+	}
+	return goPrimary(goName(fn.Value)+"("+strings.Join(codes, ", ")+")", result)
+}
+
+// builtinCall traduce las funciones integradas y las de math que tienen un
+// equivalente directo en Go
+func (g *goGenerator) builtinCall(name string, node *models.ASTNode) goExpr {
+	for _, arg := range node.Children {
+		if arg.Type == "Keyword" || arg.Type == "Starred" {
+			return g.fail(node, "llamada", fmt.Sprintf("'%s' solo se traduce con argumentos posicionales", name))
+		}
+	}
+	args := make([]goExpr, len(node.Children))
+	for i, arg := range node.Children {
+		if args[i] = g.expr(arg, ""); args[i].typ == "" {
+			return args[i]
+		}
+	}
+	arity := func(counts ...int) bool {
+		for _, count := range counts {
+			if len(args) == count {
+				return true
+			}
+		}
+		return false
+	}
+
+	if goFunc, ok := goMathFunctions[name]; ok {
+		g.use("math")
+		codes := make([]string, len(args))
+		for i, arg := range args {
+			codes[i] = g.convert(arg, goFloat, node.Children[i]).code
+		}
+		return goPrimary(goFunc+"("+strings.Join(codes, ", ")+")", goFloat)
+	}
+
+	switch {
+	case name == "len" && arity(1) && (isGoSlice(args[0].typ) || args[0].typ == goString):
+		return goPrimary(g.length(args[0]), goInt)
+
+	case name == "str" && arity(1):
+		return g.str(args[0], node.Children[0])
+
+	case name == "int" && arity(1):
+		switch args[0].typ {
+		case goInt, goBig:
+			return args[0]
+		case goFloat:
+			return goPrimary("int("+args[0].code+")", goInt)
+		case goString:
+			g.helper("atoi")
+			return goPrimary("atoi("+args[0].code+")", goInt)
+		}
+
+	case name == "float" && arity(1):
+		switch args[0].typ {
+		case goInt, goFloat:
+			return g.convert(args[0], goFloat, node.Children[0])
+		case goString:
+			g.helper("parseFloat")
+			return goPrimary("parseFloat("+args[0].code+")", goFloat)
+		}
+
+	case name == "bool" && arity(1):
+		return g.truth(node.Children[0], false)
+
+	case name == "input" && arity(0, 1):
+		g.helper("readLine")
+		prompt := `""`
+		if len(args) == 1 {
+			prompt = g.str(args[0], node.Children[0]).code
+		}
+		return goPrimary("readLine("+prompt+")", goString)
+
+	case name == "abs" && arity(1):
+		switch args[0].typ {
+		case goInt:
+			g.helper("absInt")
+			return goPrimary("absInt("+args[0].code+")", goInt)
+		case goFloat:
+			g.use("math")
+			return goPrimary("math.Abs("+args[0].code+")", goFloat)
+		case goBig:
+			return goPrimary("new(big.Int).Abs("+args[0].code+")", goBig)
+		}
+
+	case (name == "min" || name == "max") && arity(1) && isGoSlice(args[0].typ) && args[0].typ != "[]"+goBig:
+		g.use("slices")
+		return goPrimary("slices."+strings.ToUpper(name[:1])+name[1:]+"("+args[0].code+")", args[0].typ[2:])
+
+	case (name == "min" || name == "max") && len(args) >= 2:
+		typ := args[0].typ
+		for _, arg := range args[1:] {
+			if !isGoNumber(arg.typ) && arg.typ != typ {
+				typ = ""
+				break
+			}
+			if isGoNumber(typ) && isGoNumber(arg.typ) {
+				typ = widerNumber(typ, arg.typ)
+			}
+		}
+		if typ == "" || typ == goBig || isGoSlice(typ) || typ == goBool {
+			break
+		}
+		codes := make([]string, len(args))
+		for i, arg := range args {
+			codes[i] = g.convert(arg, typ, node.Children[i]).code
+		}
+		return goPrimary(name+"("+strings.Join(codes, ", ")+")", typ)
+
+	case name == "sum" && arity(1) && (args[0].typ == "[]"+goInt || args[0].typ == "[]"+goFloat):
+		g.helper("sumOf")
+		return goPrimary("sumOf("+args[0].code+")", args[0].typ[2:])
+
+	case name == "round" && arity(1) && isGoNumber(args[0].typ):
+		if args[0].typ != goFloat {
+			return args[0]
+		}
+		g.use("math")
+		return goPrimary("int(math.RoundToEven("+args[0].code+"))", goInt)
+
+	case name == "pow" && arity(2):
+		return g.arithmetic("**", args[0], args[1], node)
+
+	case (name == "math.floor" || name == "math.ceil") && arity(1) && isGoNumber(args[0].typ):
+		if args[0].typ != goFloat {
+			return args[0]
+		}
+		g.use("math")
+		return goPrimary("int(math."+strings.ToUpper(name[5:6])+name[6:]+"("+args[0].code+"))", goInt)
+
+	case name == "math.log" && arity(1, 2):
+		g.use("math")
+		x := g.convert(args[0], goFloat, node.Children[0]).code
+		if len(args) == 1 {
+			return goPrimary("math.Log("+x+")", goFloat)
+		}
+		base := g.convert(args[1], goFloat, node.Children[1]).code
+		return goExpr{code: "math.Log(" + x + ") / math.Log(" + base + ")", typ: goFloat, prec: goPrecMul}
+
+	case name == "math.factorial" && arity(1) && args[0].typ == goInt:
+		g.use("math/big")
+		return goPrimary("new(big.Int).MulRange(1, int64("+args[0].code+"))", goBig)
+
+	case name == "print":
+		return g.fail(node, "print", "print solo se traduce como sentencia")
+
+	case name == "range":
+		return g.fail(node, "range", "range solo se traduce en la cabecera de un for")
+	}
+
+	kinds := make([]string, len(args))
+	for i, arg := range args {
+		kinds[i] = arg.typ
+	}
+	return g.fail(node, "llamada", fmt.Sprintf("'%s' con argumentos (%s) no tiene traducción", name, strings.Join(kinds, ", ")))
+}
+
+// method traduce los métodos de cadenas y listas que devuelven un valor
+func (g *goGenerator) method(receiver goExpr, method string, nodes []*models.ASTNode, node *models.ASTNode) goExpr {
+	args := make([]goExpr, len(nodes))
+	for i, arg := range nodes {
+		if arg.Type == "Keyword" {
+			return g.fail(node, "método", "los métodos solo se traducen con argumentos posicionales")
+		}
+		if args[i] = g.expr(arg, ""); args[i].typ == "" {
+			return args[i]
+		}
+	}
+	strArgs := func(count int) bool {
+		if len(args) != count {
+			return false
+		}
+		for _, arg := range args {
+			if arg.typ != goString {
+				return false
+			}
+		}
+		return true
+	}
+	call := func(function string, typ string, operands ...goExpr) goExpr {
+		codes := make([]string, len(operands))
+		for i, operand := range operands {
+			codes[i] = operand.code
+		}
+		g.use(strings.Split(function, ".")[0])
+		return goPrimary(function+"("+strings.Join(codes, ", ")+")", typ)
+	}
+
+	if receiver.typ == goString {
+		switch {
+		case method == "upper" && strArgs(0):
+			return call("strings.ToUpper", goString, receiver)
+		case method == "lower" && strArgs(0):
+			return call("strings.ToLower", goString, receiver)
+		case method == "strip" && strArgs(0):
+			return call("strings.TrimSpace", goString, receiver)
+		case method == "split" && strArgs(0):
+			return call("strings.Fields", "[]string", receiver)
+		case method == "split" && strArgs(1):
+			return call("strings.Split", "[]string", receiver, args[0])
+		case method == "join" && len(args) == 1 && args[0].typ == "[]string":
+			return call("strings.Join", goString, args[0], receiver)
+		case method == "startswith" && strArgs(1):
+			return call("strings.HasPrefix", goBool, receiver, args[0])
+		case method == "endswith" && strArgs(1):
+			return call("strings.HasSuffix", goBool, receiver, args[0])
+		case method == "replace" && strArgs(2):
+			return call("strings.ReplaceAll", goString, receiver, args[0], args[1])
+		case method == "count" && strArgs(1):
+			return call("strings.Count", goInt, receiver, args[0])
+		case method == "find" && strArgs(1):
+			g.helper("find")
+			return goPrimary("find("+receiver.code+", "+args[0].code+")", goInt)
+		}
+	}
+	if isGoSlice(receiver.typ) && method == "index" && len(args) == 1 {
+		g.note(node, "slices.Index devuelve -1 si el elemento no está; list.index de Python lanza ValueError")
+		return call("slices.Index", goInt, receiver, g.convert(args[0], receiver.typ[2:], nodes[0]))
+	}
+	return g.fail(node, "método", fmt.Sprintf("el método '%s' de un valor %s no tiene traducción", method, receiver.typ))
+}
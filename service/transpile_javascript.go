@@ -0,0 +1,2197 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"examen-back/models"
+)
+
+// Precedencia de los operadores de JavaScript, de menor a mayor
+const (
+	jsPrecArrow = 1 + iota
+	jsPrecTernary
+	jsPrecOr
+	jsPrecAnd
+	jsPrecBitOr
+	jsPrecBitXor
+	jsPrecBitAnd
+	jsPrecEquality
+	jsPrecRelational
+	jsPrecShift
+	jsPrecAdd
+	jsPrecMul
+	jsPrecExp
+	jsPrecUnary
+	jsPrecPrimary
+)
+
+// Tipos de los valores en el código generado. Los enteros de Python son
+// BigInt y los float son number; los dos no se pueden mezclar en una
+// operación, así que el generador convierte con Number() cuando hace falta.
+const (
+	jsInt      = "int"
+	jsFloat    = "float"
+	jsStr      = "str"
+	jsBool     = "bool"
+	jsList     = "list"
+	jsNone     = "None"
+	jsFunction = "function"
+	jsAny      = "any"
+)
+
+var jsBinaryPrecedence = map[string]int{
+	"+": jsPrecAdd, "-": jsPrecAdd, "*": jsPrecMul, "/": jsPrecMul, "%": jsPrecMul, "**": jsPrecExp,
+	"<<": jsPrecShift, ">>": jsPrecShift, "&": jsPrecBitAnd, "|": jsPrecBitOr, "^": jsPrecBitXor,
+	"==": jsPrecEquality, "!=": jsPrecEquality, "===": jsPrecEquality, "!==": jsPrecEquality,
+	"<": jsPrecRelational, "<=": jsPrecRelational, ">": jsPrecRelational, ">=": jsPrecRelational,
+}
+
+// Funciones de math que reciben y devuelven number
+var jsMathFunctions = map[string]string{
+	"math.sqrt": "Math.sqrt", "math.pow": "Math.pow", "math.fabs": "Math.abs", "math.exp": "Math.exp",
+	"math.log2": "Math.log2", "math.log10": "Math.log10", "math.sin": "Math.sin", "math.cos": "Math.cos",
+	"math.tan": "Math.tan", "math.atan": "Math.atan", "math.atan2": "Math.atan2", "math.hypot": "Math.hypot",
+}
+
+var jsMathConstants = map[string]string{
+	"math.pi": "Math.PI", "math.e": "Math.E", "math.inf": "Infinity",
+}
+
+// Palabras reservadas de JavaScript y nombres que usa el código generado
+var jsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"yield": true, "let": true, "static": true, "await": true, "implements": true, "package": true,
+	"protected": true, "interface": true, "private": true, "public": true, "arguments": true,
+	"eval": true, "undefined": true, "NaN": true, "Infinity": true, "Math": true, "Number": true,
+	"BigInt": true, "String": true, "Array": true, "Error": true, "console": true, "prompt": true,
+	"globalThis": true, "flushOutput": true,
+}
+
+// jsHelper es una función auxiliar que se agrega al final del programa
+// cuando el código la usa
+type jsHelper struct {
+	code  string
+	needs []string
+}
+
+var jsHelpers = map[string]jsHelper{
+	"pyStr": {
+		code: `// pyStr convierte un valor en texto como str() de Python
+function pyStr(value) {
+  if (typeof value === "string") {
+    return value;
+  }
+  if (value instanceof Error) {
+    return value.message;
+  }
+  return pyRepr(value);
+}`,
+		needs: []string{"pyRepr"},
+	},
+	"pyRepr": {
+		code: `// pyRepr escribe un valor como lo muestra Python: 120.0, True, None, [1, 'a']
+function pyRepr(value) {
+  switch (typeof value) {
+    case "bigint":
+      return value.toString();
+    case "boolean":
+      return value ? "True" : "False";
+    case "string":
+      return "'" + value + "'";
+    case "function":
+      return "<function " + value.name + ">";
+    case "number":
+      if (Number.isNaN(value)) {
+        return "nan";
+      }
+      if (!Number.isFinite(value)) {
+        return value > 0 ? "inf" : "-inf";
+      }
+      if (value !== 0 && (Math.abs(value) < 1e-4 || Math.abs(value) >= 1e16)) {
+        return value.toExponential().replace(/e([+-])(\d)$/, "e$10$2");
+      }
+      return Number.isInteger(value) ? value.toFixed(1) : String(value);
+  }
+  if (value === null || value === undefined) {
+    return "None";
+  }
+  if (Array.isArray(value)) {
+    return "[" + value.map(pyRepr).join(", ") + "]";
+  }
+  return String(value);
+}`,
+	},
+	"pyPrint": {
+		code: `// pyPrint escribe como print con end: console.log siempre termina la
+// línea, así que el texto se acumula hasta el próximo salto de línea
+function pyPrint(text, end) {
+  const lines = ((pyPrint.pending ?? "") + text + end).split("\n");
+  pyPrint.pending = lines.pop();
+  for (const line of lines) {
+    console.log(line);
+  }
+}
+
+function flushOutput() {
+  if (pyPrint.pending) {
+    console.log(pyPrint.pending);
+  }
+  pyPrint.pending = "";
+}`,
+	},
+	"pyFloorDiv": {
+		code: `// pyFloorDiv es la división entera de Python, que redondea hacia abajo
+function pyFloorDiv(a, b) {
+  const quotient = a / b;
+  return a % b !== 0n && (a < 0n) !== (b < 0n) ? quotient - 1n : quotient;
+}`,
+	},
+	"pyMod": {
+		code: `// pyMod es el resto de Python, que toma el signo del divisor
+function pyMod(a, b) {
+  const rest = a % b;
+  return rest != 0 && (rest < 0) !== (b < 0) ? rest + b : rest;
+}`,
+	},
+	"pyRepeat": {
+		code: `// pyRepeat es lista * n de Python: repite los elementos sin copiarlos,
+// así que [[0] * 3] * 3 contiene tres veces la misma fila
+function pyRepeat(items, n) {
+  const result = [];
+  for (let i = 0; i < n; i++) {
+    result.push(...items);
+  }
+  return result;
+}`,
+	},
+	"pyEquals": {
+		code: `// pyEquals compara como == de Python: listas elemento a elemento y
+// enteros con float por su valor
+function pyEquals(a, b) {
+  if (Array.isArray(a) && Array.isArray(b)) {
+    return a.length === b.length && a.every((item, i) => pyEquals(item, b[i]));
+  }
+  if (typeof a === "bigint" || typeof b === "bigint") {
+    return (typeof a === "number" || typeof a === "bigint") && a == b;
+  }
+  return a === b || (a == null && b == null);
+}`,
+	},
+	"pyCompare": {
+		code: `// pyCompare ordena números y textos como sort() de Python; el sort de
+// JavaScript sin comparador ordena como texto
+function pyCompare(a, b) {
+  return a < b ? -1 : a > b ? 1 : 0;
+}`,
+	},
+	"pyBool": {
+		code: `// pyBool evalúa la verdad de un valor como Python: una lista vacía es falsa
+function pyBool(value) {
+  return Array.isArray(value) ? value.length > 0 : Boolean(value);
+}`,
+	},
+	"pyRange": {
+		code: `function* pyRange(start, stop, step = 1n) {
+  if (stop === undefined) {
+    [start, stop] = [0n, start];
+  }
+  for (let i = start; step > 0n ? i < stop : i > stop; i += step) {
+    yield i;
+  }
+}`,
+	},
+	"pyEnumerate": {
+		code: `function* pyEnumerate(items) {
+  let i = 0n;
+  for (const item of items) {
+    yield [i++, item];
+  }
+}`,
+	},
+	"pyError": {
+		code: `// pyError crea el error de una excepción de Python con su nombre y mensaje
+function pyError(name, message) {
+  const error = new Error(message);
+  error.name = name;
+  return error;
+}`,
+	},
+	"pyInput": {
+		code: `// pyInput lee una línea como input(); en el navegador usa prompt
+function pyInput(message = "") {
+  const line = typeof prompt === "function" ? prompt(message) : null;
+  if (line === null) {
+    throw pyError("EOFError", "EOF when reading a line");
+  }
+  return line;
+}`,
+		needs: []string{"pyError"},
+	},
+	"pyInt": {
+		code: `// pyInt convierte un texto como int() de Python
+function pyInt(text) {
+  const trimmed = text.trim();
+  if (!/^[+-]?\d+$/.test(trimmed)) {
+    throw pyError("ValueError", "invalid literal for int() with base 10: '" + text + "'");
+  }
+  return BigInt(trimmed);
+}`,
+		needs: []string{"pyError"},
+	},
+	"pyMax": {
+		code: `function pyMax(...values) {
+  return values.reduce((best, value) => (value > best ? value : best));
+}`,
+	},
+	"pyMin": {
+		code: `function pyMin(...values) {
+  return values.reduce((best, value) => (value < best ? value : best));
+}`,
+	},
+	"pyAbs": {
+		code: `function pyAbs(value) {
+  return value < 0 ? -value : value;
+}`,
+	},
+	"pyRound": {
+		code: `// pyRound redondea como round() de Python: los empates van al par
+function pyRound(value) {
+  const floor = Math.floor(value);
+  const diff = value - floor;
+  return BigInt(diff > 0.5 || (diff === 0.5 && floor % 2 !== 0) ? floor + 1 : floor);
+}`,
+	},
+	"pyFactorial": {
+		code: `function pyFactorial(n) {
+  let result = 1n;
+  for (let i = 2n; i <= n; i++) {
+    result *= i;
+  }
+  return result;
+}`,
+	},
+}
+
+// jsExpr es una expresión de JavaScript ya generada. elem es el tipo de los
+// elementos de una lista y literal indica un entero literal (5n), que se
+// convierte a number quitando la n. asNumber es el mismo valor como number
+// cuando el entero sale de uno (len(xs) es BigInt(xs.length)). Un tipo
+// vacío indica que la expresión no se pudo traducir.
+type jsExpr struct {
+	code     string
+	kind     string
+	elem     string
+	prec     int
+	literal  bool
+	asNumber string
+}
+
+func jsPrimary(code, kind string) jsExpr {
+	return jsExpr{code: code, kind: kind, prec: jsPrecPrimary}
+}
+
+// wrap devuelve el código entre paréntesis si su operador liga menos que prec
+func (e jsExpr) wrap(prec int) string {
+	if e.prec < prec {
+		return "(" + e.code + ")"
+	}
+	return e.code
+}
+
+func isJSNumber(kind string) bool {
+	return kind == jsInt || kind == jsFloat
+}
+
+// jsName convierte un nombre de Python al estilo de JavaScript y evita las
+// palabras reservadas
+func jsName(name string) string {
+	result := camelCase(name)
+	if jsReserved[result] || strings.HasPrefix(result, "py") && jsHelpers[result].code != "" {
+		return result + "_"
+	}
+	return result
+}
+
+// jsQuote escribe un texto como literal de JavaScript
+func jsQuote(text string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(text)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// jsKind traduce un tipo inferido. Una unión de int y float se trata como
+// float, porque BigInt y number no se combinan.
+func jsKind(t *pyType) (string, string) {
+	switch {
+	case t == nil:
+		return jsAny, ""
+	case t == typeInt:
+		return jsInt, ""
+	case t == typeFloat:
+		return jsFloat, ""
+	case t == typeStr:
+		return jsStr, ""
+	case t == typeBool:
+		return jsBool, ""
+	case t == typeNone:
+		return jsNone, ""
+	case t.function != nil:
+		return jsFunction, ""
+	case t.name == "list" || t.name == "tuple":
+		elem := ""
+		if t.elem != nil {
+			elem, _ = jsKind(t.elem)
+		}
+		return jsList, elem
+	case numericUnion(t):
+		return jsFloat, ""
+	}
+	return jsAny, ""
+}
+
+// numericUnion indica una unión de números con algún float (int | float)
+func numericUnion(t *pyType) bool {
+	if t.name != "union" {
+		return false
+	}
+	float := false
+	for _, member := range t.members {
+		switch member {
+		case typeFloat:
+			float = true
+		case typeInt, typeBool:
+		default:
+			return false
+		}
+	}
+	return float
+}
+
+// sourceColumn es la columna, desde 0, donde empieza una sentencia: la
+// menor de los nodos de su primera línea. El nodo de una def tiene la
+// columna del nombre.
+func sourceColumn(node *models.ASTNode) int {
+	column := node.Col
+	var visit func(child *models.ASTNode)
+	visit = func(child *models.ASTNode) {
+		if child.Type == "Block" || child.Line != node.Line {
+			return
+		}
+		if child.Col > 0 && (column == 0 || child.Col < column) {
+			column = child.Col
+		}
+		for _, grandchild := range child.Children {
+			visit(grandchild)
+		}
+	}
+	for _, child := range node.Children {
+		visit(child)
+	}
+	switch {
+	case node.Type == "FunctionDef" && column > len("def "):
+		column -= len("def ")
+	case node.Type == "ClassDef" && column > len("class "):
+		column -= len("class ")
+	}
+	return max(column-1, 0)
+}
+
+// jsLine es una línea del programa generado y la sentencia de Python de la
+// que sale, que se registra en el source map
+type jsLine struct {
+	text  string
+	node  *models.ASTNode
+	depth int
+}
+
+// jsGenerator traduce el AST a JavaScript ES2020. Las funciones se
+// generan en el orden del programa (las declaraciones function se elevan),
+// cada línea recuerda la sentencia de Python de la que sale y el source map
+// se arma con esas posiciones al final.
+type jsGenerator struct {
+	*transpiler
+
+	lines   []jsLine
+	depth   int
+	helpers map[string]bool
+	fn      *models.ASTNode
+	sc      *scope
+	failed  bool
+	// buffered indica que algún print usa end: toda la salida pasa por pyPrint
+	buffered bool
+}
+
+func newJSGenerator(t *transpiler) *jsGenerator {
+	return &jsGenerator{
+		transpiler: t,
+		helpers:    make(map[string]bool),
+	}
+}
+
+func (g *jsGenerator) line(node *models.ASTNode, format string, args ...any) {
+	g.lines = append(g.lines, jsLine{text: fmt.Sprintf(format, args...), node: node, depth: g.depth})
+}
+
+func (g *jsGenerator) helper(name string) {
+	if g.helpers[name] {
+		return
+	}
+	g.helpers[name] = true
+	for _, need := range jsHelpers[name].needs {
+		g.helper(need)
+	}
+}
+
+func (g *jsGenerator) fail(node *models.ASTNode, construct, reason string) jsExpr {
+	g.unsupported(node, construct, reason)
+	return g.failedExpr(node)
+}
+
+func (g *jsGenerator) failedExpr(node *models.ASTNode) jsExpr {
+	g.failed = true
+	return jsPrimary("undefined /* sin traducir: "+formatSource(node)+" */", "")
+}
+
+// generate devuelve el programa, que termina con el source map en línea,
+// y el mismo mapa por separado. code es el programa original, que se
+// incluye en el mapa (sourcesContent).
+func (g *jsGenerator) generate(code string) (string, *models.SourceMap) {
+	g.buffered = usesPrintEnd(g.ast)
+	g.sc = g.tree.module
+	// El cuerpo de if __name__ == "__main__" es parte del programa principal
+	body := make([]*models.ASTNode, 0, len(g.ast.Children))
+	for _, stmt := range g.ast.Children {
+		if isMainGuard(stmt) {
+			body = append(body, stmt.Children[1].Children...)
+			continue
+		}
+		body = append(body, stmt)
+	}
+	g.declareLocals(body, g.tree.module)
+	g.block(body)
+	if g.buffered {
+		g.helper("pyPrint")
+		g.line(nil, "flushOutput();")
+	}
+
+	var out strings.Builder
+	var mapping sourceMapBuilder
+	generated := 0
+	write := func(text string) {
+		out.WriteString(text + "\n")
+		generated++
+	}
+	write("// Código generado a partir del programa en Python (ES2020): los enteros")
+	write("// son BigInt y print se traduce con console.log.")
+	write(`"use strict";`)
+	write("")
+	for _, l := range g.lines {
+		if l.text == "" {
+			write("")
+			continue
+		}
+		if l.node != nil && l.node.Line > 0 {
+			mapping.add(generated, 2*l.depth, l.node.Line-1, sourceColumn(l.node))
+		}
+		write(strings.Repeat("  ", l.depth) + l.text)
+	}
+
+	helpers := make([]string, 0, len(g.helpers))
+	for name := range g.helpers {
+		helpers = append(helpers, name)
+	}
+	sort.Strings(helpers)
+	for _, name := range helpers {
+		write("")
+		for _, text := range strings.Split(jsHelpers[name].code, "\n") {
+			mapping.unmapped(generated)
+			write(text)
+		}
+	}
+
+	sourceMap := mapping.sourceMap("programa.js", "programa.py", code)
+	encoded, _ := json.Marshal(sourceMap)
+	write("")
+	out.WriteString("//# sourceMappingURL=data:application/json;base64," + base64.StdEncoding.EncodeToString(encoded) + "\n")
+	return out.String(), sourceMap
+}
+
+// usesPrintEnd indica si algún print indica end
+func usesPrintEnd(node *models.ASTNode) bool {
+	if node.Type == "FunctionCall" && node.Value == "print" {
+		for _, arg := range node.Children {
+			if arg.Type == "Keyword" && arg.Value == "end" {
+				return true
+			}
+		}
+	}
+	for _, child := range node.Children {
+		if usesPrintEnd(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// declareLocals declara con let al comienzo del cuerpo las variables que no
+// se pueden declarar donde se asignan (ver placeDeclarations). Los nombres
+// de un desempaquetado en el nivel superior se declaran en la asignación
+// (let [a, b] = par) y el nombre de un except lo declara el catch.
+func (g *jsGenerator) declareLocals(body []*models.ASTNode, sc *scope) {
+	unpacked := make(map[*models.ASTNode]bool)
+	for _, stmt := range body {
+		if stmt.Type != "Assignment" || stmt.Value != "" {
+			continue
+		}
+		if targets, _ := assignmentPairs(stmt); targets[0].Type == "Tuple" || targets[0].Type == "List" {
+			for _, target := range targets[0].Children {
+				unpacked[target] = true
+			}
+		}
+	}
+	caught := func(symbol *scopeSymbol) bool {
+		for _, binding := range symbol.bindings {
+			if binding.node.Type != "ExceptHandler" {
+				return false
+			}
+		}
+		return true
+	}
+
+	hoisted := g.placeDeclarations(body, sc, caught)
+	names := make([]string, 0, len(hoisted))
+	for _, symbol := range hoisted {
+		if first := symbol.bindings[0].node; unpacked[first] {
+			g.inline[first] = true
+			continue
+		}
+		names = append(names, jsName(symbol.name))
+	}
+	if len(names) > 0 {
+		g.line(nil, "let %s;", strings.Join(names, ", "))
+	}
+}
+
+// symbolKind es el tipo de una variable; si puede ser int o float se avisa
+// de que se guarda siempre como number
+func (g *jsGenerator) symbolKind(symbol *scopeSymbol) (string, string) {
+	t := g.types.symbols[symbol]
+	if t != nil && numericUnion(t) && symbol.node != nil {
+		g.note(symbol.node, fmt.Sprintf("'%s' puede ser int o float: en JavaScript se guarda siempre como number y print muestra los enteros con .0", symbol.name))
+	}
+	return jsKind(t)
+}
+
+func (g *jsGenerator) block(stmts []*models.ASTNode) {
+	for _, stmt := range stmts {
+		g.statement(stmt)
+	}
+}
+
+func (g *jsGenerator) untranslatedStatement(stmt *models.ASTNode, construct, reason string) {
+	g.unsupported(stmt, construct, reason)
+	for _, text := range formatBlock([]*models.ASTNode{stmt}, "") {
+		g.line(stmt, "// sin traducir: %s", text)
+	}
+}
+
+func (g *jsGenerator) statement(stmt *models.ASTNode) {
+	switch stmt.Type {
+	case "IfStatement":
+		if isMainGuard(stmt) {
+			g.block(stmt.Children[1].Children)
+			return
+		}
+		g.ifStatement(stmt)
+		return
+	case "WhileStatement":
+		g.whileStatement(stmt)
+		return
+	case "ForStatement":
+		g.forStatement(stmt)
+		return
+	case "FunctionDef":
+		g.function(stmt)
+		return
+	case "TryStatement":
+		g.tryStatement(stmt)
+		return
+	case "PassStatement", "GlobalStatement", "NonlocalStatement":
+		// Las funciones de JavaScript ya ven y modifican las variables externas
+		return
+	case "BreakStatement":
+		g.line(stmt, "break;")
+		return
+	case "ContinueStatement":
+		g.line(stmt, "continue;")
+		return
+	case "String":
+		text, _ := literalValue(stmt)
+		for _, line := range strings.Split(strings.TrimSpace(text.s), "\n") {
+			g.line(stmt, "// %s", strings.TrimSpace(line))
+		}
+		return
+	case "Import", "ImportFrom":
+		g.importStatement(stmt)
+		return
+	case "ClassDef":
+		g.untranslatedStatement(stmt, "clase", "las clases de Python no se traducen en esta versión")
+		return
+	}
+
+	// Si una expresión de la sentencia no se puede traducir, la sentencia
+	// completa queda como comentario
+	start, failed := len(g.lines), g.failed
+	g.failed = false
+	g.simpleStatement(stmt)
+	if g.failed {
+		g.lines = g.lines[:start]
+		// La variable que declaraba la asignación se declara igual para
+		// que las sentencias siguientes compilen
+		if stmt.Type == "Assignment" {
+			targets, _ := assignmentPairs(stmt)
+			if stmt.Value == "" && (targets[0].Type == "Tuple" || targets[0].Type == "List") {
+				targets = targets[0].Children
+			}
+			for _, target := range targets {
+				if g.inline[target] {
+					g.line(nil, "let %s;", jsName(targetName(target)))
+				}
+			}
+		}
+		g.line(stmt, "// sin traducir: %s", formatSource(stmt))
+	}
+	g.failed = failed
+}
+
+func (g *jsGenerator) simpleStatement(stmt *models.ASTNode) {
+	switch stmt.Type {
+	case "Assignment":
+		g.assignment(stmt)
+	case "AugAssignment":
+		g.augAssignment(stmt)
+	case "ReturnStatement":
+		g.returnStatement(stmt)
+	case "RaiseStatement":
+		g.raiseStatement(stmt)
+	case "FunctionCall", "MethodCall":
+		g.callStatement(stmt)
+	default:
+		if e := g.expr(stmt); e.kind != "" {
+			g.line(stmt, "%s;", e.code)
+		}
+	}
+}
+
+// importStatement acepta math, que se traduce con Math; los demás módulos
+// solo se informan si el programa usa lo que importan
+func (g *jsGenerator) importStatement(stmt *models.ASTNode) {
+	module := stmt.Value
+	for _, alias := range stmt.Children {
+		if stmt.Type == "Import" {
+			module = alias.Value
+		}
+		if module == "math" {
+			continue
+		}
+		bound := alias.Value
+		if len(alias.Children) > 0 {
+			bound = alias.Children[0].Value
+		}
+		if stmt.Type == "Import" {
+			bound = strings.Split(bound, ".")[0]
+		}
+		if symbol := g.sc.symbols[bound]; symbol != nil && len(g.reads[symbol]) > 0 {
+			g.unsupported(stmt, "import", fmt.Sprintf("el módulo '%s' no tiene equivalente en la traducción (solo se traduce math)", module))
+			g.line(stmt, "// sin traducir: %s", formatSource(stmt))
+			return
+		}
+	}
+}
+
+func (g *jsGenerator) function(fn *models.ASTNode) {
+	outerFn, outerScope := g.fn, g.sc
+	g.fn, g.sc = fn, g.tree.byNode[fn]
+	defer func() { g.fn, g.sc = outerFn, outerScope }()
+
+	params, body := functionParts(fn)
+	if len(body) > 0 && body[0].Type == "String" {
+		doc, _ := literalValue(body[0])
+		for _, text := range strings.Split(strings.TrimSpace(doc.s), "\n") {
+			g.line(body[0], "// %s", strings.TrimSpace(text))
+		}
+		body = body[1:]
+	}
+
+	parts, ok := g.parameters(params)
+	if !ok {
+		g.untranslatedStatement(fn, "función", fmt.Sprintf("la firma de '%s' no se puede traducir", fn.Value))
+		return
+	}
+	g.line(fn, "function %s(%s) {", jsName(fn.Value), strings.Join(parts, ", "))
+	g.depth++
+	g.declareLocals(body, g.sc)
+	g.block(body)
+	g.depth--
+	g.line(nil, "}")
+	if g.fn == fn && outerFn == nil {
+		g.line(nil, "")
+	}
+}
+
+// parameters traduce los parámetros de una def o una lambda: los valores
+// por defecto se conservan y *args pasa a ser ...args
+func (g *jsGenerator) parameters(params []*models.ASTNode) ([]string, bool) {
+	parts := make([]string, 0, len(params))
+	for _, param := range params {
+		name := parameterName(param)
+		switch {
+		case strings.HasPrefix(param.Value, "**"):
+			g.unsupported(param, "parámetro", fmt.Sprintf("los parámetros '%s' no tienen traducción", param.Value))
+			return nil, false
+		case param.Value == "*":
+			// JavaScript no tiene argumentos nombrados
+			g.unsupported(param, "parámetro", "los parámetros que solo se pasan por nombre (después de '*') no tienen traducción")
+			return nil, false
+		case strings.HasPrefix(param.Value, "*"):
+			parts = append(parts, "..."+jsName(name))
+			continue
+		}
+		part := jsName(name)
+		if value := parameterDefault(param); value != nil {
+			want := ""
+			if symbol := g.sc.symbols[name]; symbol != nil {
+				want, _ = g.symbolKind(symbol)
+			}
+			defaultValue := g.value(value, want)
+			if defaultValue.kind == "" {
+				return nil, false
+			}
+			part += " = " + defaultValue.code
+		}
+		parts = append(parts, part)
+	}
+	return parts, true
+}
+
+func (g *jsGenerator) ifStatement(node *models.ASTNode) {
+	prefix := "if"
+	for {
+		g.line(node, "%s (%s) {", prefix, g.truth(node.Children[0], false).code)
+		g.depth++
+		g.block(node.Children[1].Children)
+		g.depth--
+		if len(node.Children) < 3 {
+			break
+		}
+		otherwise := node.Children[2]
+		if isElif(otherwise) {
+			node, prefix = otherwise.Children[0], "} else if"
+			continue
+		}
+		g.line(nil, "} else {")
+		g.depth++
+		g.block(otherwise.Children)
+		g.depth--
+		break
+	}
+	g.line(nil, "}")
+}
+
+// loopElse informa el else de un while o un for, que JavaScript no tiene
+func (g *jsGenerator) loopElse(node *models.ASTNode, body int) {
+	if len(node.Children) > body+1 {
+		otherwise := node.Children[body+1]
+		g.unsupportedLoopElse(node, otherwise, "JavaScript no tiene else en los bucles; se ejecuta cuando el bucle termina sin break y habría que expresarlo con una variable")
+		for _, text := range formatBlock(otherwise.Children, "    ") {
+			g.line(otherwise, "// sin traducir (else del bucle): %s", strings.TrimLeft(text, " "))
+		}
+	}
+}
+
+func (g *jsGenerator) whileStatement(node *models.ASTNode) {
+	condition := node.Children[0]
+	if condition.Type == "Boolean" && condition.Value == "True" {
+		g.line(node, "while (true) {")
+	} else {
+		g.line(node, "while (%s) {", g.truth(condition, false).code)
+	}
+	g.depth++
+	g.block(node.Children[1].Children)
+	g.depth--
+	g.line(nil, "}")
+	g.loopElse(node, 1)
+}
+
+func (g *jsGenerator) forStatement(node *models.ASTNode) {
+	failed := g.failed
+	g.failed = false
+	header := g.forHeader(node.Children[0], node.Children[1], node)
+	if g.failed {
+		g.line(node, "// sin traducir: %s", formatBlock([]*models.ASTNode{node}, "")[0])
+		g.line(node, "while (false) {")
+	} else {
+		g.line(node, "for (%s) {", header)
+	}
+	g.failed = failed
+	g.depth++
+	g.block(node.Children[2].Children)
+	g.depth--
+	g.line(nil, "}")
+	g.loopElse(node, 2)
+}
+
+// loopPattern es el destino de un for con su declaración: const o let si
+// el for la declara, nada si ya estaba declarada
+func (g *jsGenerator) loopPattern(target *models.ASTNode) (string, string, bool) {
+	names := []*models.ASTNode{target}
+	if target.Type == "Tuple" || target.Type == "List" {
+		names = target.Children
+	}
+	declaration, pattern := "", make([]string, 0, len(names))
+	for _, name := range names {
+		if name.Type != "Identifier" {
+			return "", "", false
+		}
+		symbol := g.types.bindingSymbol(g.sc, name.Value)
+		switch {
+		case !g.inline[name]:
+			if declaration != "" {
+				return "", "", false
+			}
+		case len(symbol.bindings) == 1:
+			declaration = "const "
+		default:
+			declaration = "let "
+		}
+		pattern = append(pattern, jsName(name.Value))
+	}
+	if declaration != "" {
+		for _, name := range names {
+			if !g.inline[name] {
+				return "", "", false
+			}
+		}
+	}
+	if target.Type == "Tuple" || target.Type == "List" {
+		return declaration, "[" + strings.Join(pattern, ", ") + "]", true
+	}
+	return declaration, pattern[0], true
+}
+
+func (g *jsGenerator) forHeader(target, iter, node *models.ASTNode) string {
+	if iter.Type == "FunctionCall" && g.symbol(iter) == nil && iter.Value == "range" && target.Type == "Identifier" {
+		if header, ok := g.rangeHeader(target, iter, node); ok {
+			return header
+		}
+		return g.failedExpr(iter).code
+	}
+
+	declaration, pattern, ok := g.loopPattern(target)
+	if !ok {
+		return g.fail(target, "for", "el destino del for debe ser un nombre o una tupla de nombres").code
+	}
+	var sequence jsExpr
+	if iter.Type == "FunctionCall" && g.symbol(iter) == nil && iter.Value == "enumerate" && len(iter.Children) == 1 {
+		items := g.expr(iter.Children[0])
+		if items.kind == "" {
+			return items.code
+		}
+		g.helper("pyEnumerate")
+		sequence = jsPrimary("pyEnumerate("+items.code+")", jsAny)
+	} else if sequence = g.iterable(iter); sequence.kind == "" {
+		return sequence.code
+	}
+	return fmt.Sprintf("%s%s of %s", declaration, pattern, sequence.code)
+}
+
+// rangeHeader traduce for i in range(...) como un for con contador BigInt
+func (g *jsGenerator) rangeHeader(target, iter, node *models.ASTNode) (string, bool) {
+	args := iter.Children
+	if len(args) == 0 || len(args) > 3 {
+		g.unsupported(iter, "for", "range recibe de uno a tres argumentos")
+		return "", false
+	}
+	bounds := make([]jsExpr, 0, 3)
+	for _, arg := range args {
+		bound := g.value(arg, jsInt)
+		if bound.kind == "" {
+			return "", false
+		}
+		bounds = append(bounds, bound)
+	}
+	start, stop := jsExpr{code: "0n", kind: jsInt, prec: jsPrecPrimary, literal: true}, bounds[0]
+	if len(bounds) > 1 {
+		start, stop = bounds[0], bounds[1]
+	}
+
+	step, stepCode := int64(1), ""
+	if len(args) == 3 {
+		var known bool
+		if step, known = intLiteral(args[2]); !known {
+			// El sentido del for depende del signo del paso; se supone positivo
+			step, stepCode = 2, bounds[2].code
+			g.note(args[2], fmt.Sprintf("se supone que el paso '%s' de range es positivo", formatSource(args[2])))
+		} else if step == 0 {
+			g.unsupported(args[2], "for", "range con paso 0 lanza ValueError")
+			return "", false
+		}
+	}
+
+	declaration, name, _ := g.loopPattern(target)
+	if declaration == "const " {
+		declaration = "let "
+	}
+	if declaration == "" && len(g.reads[g.types.bindingSymbol(g.sc, target.Value)]) > 0 {
+		g.note(node, fmt.Sprintf("al terminar el for, '%s' vale el límite del range en JavaScript y el último valor recorrido en Python", target.Value))
+	}
+	compare, increment := "<", name+"++"
+	switch {
+	case stepCode != "":
+		increment = fmt.Sprintf("%s += %s", name, stepCode)
+	case step > 1:
+		increment = fmt.Sprintf("%s += %dn", name, step)
+	case step == -1:
+		compare, increment = ">", name+"--"
+	case step < 0:
+		compare, increment = ">", fmt.Sprintf("%s -= %dn", name, -step)
+	}
+	return fmt.Sprintf("%s%s = %s; %s %s %s; %s", declaration, name, start.code, name, compare, stop.wrap(jsPrecRelational+1), increment), true
+}
+
+// iterable traduce lo que recorre un for o una comprensión: range pasa a
+// ser el generador pyRange y las listas y cadenas se recorren con for...of
+func (g *jsGenerator) iterable(iter *models.ASTNode) jsExpr {
+	if iter.Type == "FunctionCall" && g.symbol(iter) == nil && iter.Value == "range" {
+		return g.rangeValue(iter)
+	}
+	sequence := g.expr(iter)
+	switch sequence.kind {
+	case "", jsList, jsStr, jsAny:
+		return sequence
+	}
+	return g.fail(iter, "for", fmt.Sprintf("un valor %s no se puede recorrer", sequence.kind))
+}
+
+func (g *jsGenerator) rangeValue(node *models.ASTNode) jsExpr {
+	if len(node.Children) == 0 || len(node.Children) > 3 {
+		return g.fail(node, "range", "range recibe de uno a tres argumentos")
+	}
+	codes := make([]string, len(node.Children))
+	for i, arg := range node.Children {
+		value := g.value(arg, jsInt)
+		if value.kind == "" {
+			return value
+		}
+		codes[i] = value.code
+	}
+	g.helper("pyRange")
+	return jsPrimary("pyRange("+strings.Join(codes, ", ")+")", jsAny)
+}
+
+// tryStatement traduce try con un único except sin tipo o de Exception,
+// que captura cualquier error, y finally. Los except de un tipo concreto no
+// se traducen: los errores de JavaScript no tienen los nombres de Python.
+func (g *jsGenerator) tryStatement(node *models.ASTNode) {
+	var handlers []*models.ASTNode
+	var finally *models.ASTNode
+	for _, child := range node.Children[1:] {
+		switch {
+		case child.Type == "ExceptHandler":
+			handlers = append(handlers, child)
+		case child.Type == "Block" && child.Value == "finally":
+			finally = child
+		default:
+			g.untranslatedStatement(node, "try", "el else de un try no tiene traducción")
+			return
+		}
+	}
+	if len(handlers) > 1 {
+		g.untranslatedStatement(node, "try", "varios except con tipos distintos no tienen traducción: los errores de JavaScript no tienen los nombres de Python")
+		return
+	}
+	if len(handlers) == 1 && len(handlers[0].Children) > 1 {
+		kind := formatSource(handlers[0].Children[0])
+		if kind != "Exception" && kind != "BaseException" {
+			g.untranslatedStatement(node, "try", fmt.Sprintf("except %s no tiene traducción: los errores de JavaScript no tienen los nombres de Python; solo se traduce except o except Exception", kind))
+			return
+		}
+	}
+
+	g.line(node, "try {")
+	g.depth++
+	g.block(node.Children[0].Children)
+	g.depth--
+	if len(handlers) == 1 {
+		handler := handlers[0]
+		name := "error"
+		if handler.Value != "" {
+			name = jsName(handler.Value)
+		}
+		g.line(handler, "} catch (%s) {", name)
+		g.depth++
+		g.block(handler.Children[len(handler.Children)-1].Children)
+		g.depth--
+	}
+	if finally != nil {
+		g.line(finally, "} finally {")
+		g.depth++
+		g.block(finally.Children)
+		g.depth--
+	}
+	g.line(nil, "}")
+}
+
+// assignTarget traduce un destino de asignación: el código del lado
+// izquierdo, el tipo que espera y si la asignación declara la variable
+func (g *jsGenerator) assignTarget(target *models.ASTNode) (string, string, bool) {
+	if name := targetName(target); name != "" {
+		kind, _ := g.symbolKind(g.types.bindingSymbol(g.sc, name))
+		return jsName(name), kind, g.inline[target]
+	}
+	if target.Type == "Subscript" && target.Children[1].Type != "Slice" {
+		element := g.subscript(target)
+		return element.code, "", false
+	}
+	return g.fail(target, "asignación", "solo se asigna a nombres y a elementos de una lista").code, "", false
+}
+
+func (g *jsGenerator) assignment(node *models.ASTNode) {
+	targets, values := assignmentPairs(node)
+	if node.Value == "" && (targets[0].Type == "Tuple" || targets[0].Type == "List") {
+		// a, b = par: desestructuración
+		left := make([]string, len(targets[0].Children))
+		declares := true
+		for i, target := range targets[0].Children {
+			code, _, isNew := g.assignTarget(target)
+			left[i], declares = code, declares && isNew
+		}
+		value := g.expr(values[0])
+		if !declares {
+			g.declareNew(targets[0].Children)
+		}
+		g.line(node, "%s[%s] = %s;", map[bool]string{true: "let ", false: ""}[declares], strings.Join(left, ", "), value.code)
+		return
+	}
+
+	left := make([]string, len(targets))
+	right := make([]string, len(targets))
+	declares := true
+	for i, target := range targets {
+		code, kind, isNew := g.assignTarget(target)
+		left[i], declares = code, declares && isNew
+		right[i] = g.value(values[i], kind).code
+	}
+	if !declares {
+		g.declareNew(targets)
+	}
+	keyword := map[bool]string{true: "let ", false: ""}[declares]
+	if len(targets) == 1 {
+		g.line(node, "%s%s = %s;", keyword, left[0], right[0])
+		return
+	}
+	g.line(node, "%s[%s] = [%s];", keyword, strings.Join(left, ", "), strings.Join(right, ", "))
+}
+
+// declareNew declara antes de una asignación múltiple las variables nuevas
+// que se mezclan con otras ya declaradas
+func (g *jsGenerator) declareNew(targets []*models.ASTNode) {
+	for _, target := range targets {
+		if g.inline[target] {
+			g.line(nil, "let %s;", jsName(targetName(target)))
+		}
+	}
+}
+
+func (g *jsGenerator) augAssignment(node *models.ASTNode) {
+	target, value := node.Children[0], node.Children[1]
+	op := strings.TrimSuffix(node.Value, "=")
+	if target.Type != "Identifier" && target.Type != "Subscript" {
+		g.fail(target, "asignación", "solo se asigna a nombres y a elementos de una lista")
+		return
+	}
+	current := g.expr(target)
+	if current.kind == "" {
+		return
+	}
+	if current.kind == jsList && op == "+" {
+		g.line(node, "%s.push(...%s);", current.code, g.expr(value).code)
+		return
+	}
+
+	operand := g.expr(value)
+	result := g.arithmetic(op, current, operand, node)
+	simple := map[string]bool{"+": true, "-": true, "*": true, "**": true, "&": true, "|": true, "^": true, "<<": true, ">>": true}
+	switch {
+	case result.kind == "":
+		return
+	case simple[op] && current.kind == operand.kind && result.kind == current.kind:
+		if current.kind == jsInt && operand.literal && operand.code == "1n" && (op == "+" || op == "-") {
+			g.line(node, "%s%s%s;", current.code, op, op)
+			return
+		}
+		g.line(node, "%s %s= %s;", current.code, op, operand.code)
+	case op == "/" && current.kind == jsFloat:
+		g.line(node, "%s /= %s;", current.code, g.number(operand))
+	default:
+		g.line(node, "%s = %s;", current.code, result.code)
+	}
+}
+
+func (g *jsGenerator) returnStatement(node *models.ASTNode) {
+	if g.fn == nil {
+		g.fail(node, "return", "return fuera de una función")
+		return
+	}
+	if len(node.Children) == 0 {
+		g.line(node, "return;")
+		return
+	}
+	want, _ := jsKind(g.types.functions[g.fn].returnType())
+	g.line(node, "return %s;", g.value(node.Children[0], want).code)
+}
+
+// raiseStatement traduce raise Error(mensaje) con un Error de JavaScript
+// que conserva el nombre de la excepción
+func (g *jsGenerator) raiseStatement(node *models.ASTNode) {
+	if len(node.Children) != 1 {
+		g.fail(node, "raise", "solo se traduce raise con una excepción")
+		return
+	}
+	g.helper("pyError")
+	exception := node.Children[0]
+	switch {
+	case exception.Type == "Identifier":
+		g.line(node, "throw pyError(%s, \"\");", jsQuote(exception.Value))
+	case exception.Type == "FunctionCall" && len(exception.Children) == 0:
+		g.line(node, "throw pyError(%s, \"\");", jsQuote(exception.Value))
+	case exception.Type == "FunctionCall" && len(exception.Children) == 1:
+		message := g.str(g.expr(exception.Children[0]))
+		g.line(node, "throw pyError(%s, %s);", jsQuote(exception.Value), message.code)
+	default:
+		g.fail(node, "raise", "solo se traduce raise con el nombre de la excepción o una llamada con un mensaje")
+	}
+}
+
+func (g *jsGenerator) callStatement(node *models.ASTNode) {
+	if node.Type == "FunctionCall" && g.symbol(node) == nil && node.Value == "print" {
+		if code := g.printCall(node); code != "" {
+			g.line(node, "%s;", code)
+		}
+		return
+	}
+	if call := g.expr(node); call.kind != "" {
+		g.line(node, "%s;", call.code)
+	}
+}
+
+// printPiece es un argumento de print ya convertido a texto; los literales
+// se juntan con los separadores en un solo literal
+type printPiece struct {
+	code    string
+	literal *string
+	prec    int
+}
+
+// printCall traduce print con console.log, que recibe un único texto:
+// con varios argumentos console.log interpreta %s y %d en el primero
+func (g *jsGenerator) printCall(node *models.ASTNode) string {
+	var args []*models.ASTNode
+	var sep, end *models.ASTNode
+	for _, arg := range node.Children {
+		switch {
+		case arg.Type == "Keyword" && arg.Value == "sep" && len(arg.Children) > 0:
+			sep = arg.Children[0]
+		case arg.Type == "Keyword" && arg.Value == "end" && len(arg.Children) > 0:
+			end = arg.Children[0]
+		case arg.Type == "Keyword" || arg.Type == "Starred":
+			return g.fail(arg, "print", "print solo se traduce con argumentos posicionales, sep y end").code
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	piece := func(node *models.ASTNode) printPiece {
+		if value, ok := literalValue(node); ok && value.kind == "str" {
+			return printPiece{literal: &value.s}
+		}
+		text := g.str(g.expr(node))
+		return printPiece{code: text.code, prec: text.prec}
+	}
+	space := " "
+	separator := printPiece{literal: &space}
+	if sep != nil {
+		separator = piece(sep)
+	}
+	pieces := make([]printPiece, 0, 2*len(args))
+	for i, arg := range args {
+		if i > 0 {
+			pieces = append(pieces, separator)
+		}
+		pieces = append(pieces, piece(arg))
+	}
+
+	// Se juntan los literales vecinos: "total:" + " " pasa a ser "total: "
+	merged := make([]printPiece, 0, len(pieces))
+	for _, p := range pieces {
+		if last := len(merged) - 1; p.literal != nil && last >= 0 && merged[last].literal != nil {
+			joined := *merged[last].literal + *p.literal
+			merged[last] = printPiece{literal: &joined}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	codes := make([]string, 0, len(merged))
+	for i, p := range merged {
+		switch {
+		case p.literal != nil:
+			codes = append(codes, jsQuote(*p.literal))
+		case i == 0:
+			codes = append(codes, jsExpr{code: p.code, prec: p.prec}.wrap(jsPrecAdd))
+		default:
+			codes = append(codes, jsExpr{code: p.code, prec: p.prec}.wrap(jsPrecAdd+1))
+		}
+	}
+	if len(merged) == 1 && merged[0].literal == nil && len(args) == 1 {
+		codes[0] = merged[0].code
+	}
+	text := strings.Join(codes, " + ")
+
+	if !g.buffered {
+		return "console.log(" + text + ")"
+	}
+	g.helper("pyPrint")
+	terminator := `"\n"`
+	if end != nil {
+		terminator = g.str(g.expr(end)).code
+	}
+	if text == "" {
+		text = `""`
+	}
+	return "pyPrint(" + text + ", " + terminator + ")"
+}
+
+// str convierte un valor en texto como str() de Python
+func (g *jsGenerator) str(e jsExpr) jsExpr {
+	switch e.kind {
+	case "", jsStr:
+		return e
+	case jsInt:
+		if e.asNumber != "" {
+			return jsPrimary("String("+e.asNumber+")", jsStr)
+		}
+		return jsPrimary(e.wrap(jsPrecPrimary)+".toString()", jsStr)
+	}
+	g.helper("pyStr")
+	return jsPrimary("pyStr("+e.code+")", jsStr)
+}
+
+// number convierte un entero a number; los literales pierden la n
+func (g *jsGenerator) number(e jsExpr) string {
+	switch {
+	case e.kind != jsInt:
+		return e.code
+	case e.literal:
+		return strings.TrimSuffix(e.code, "n")
+	case e.asNumber != "":
+		return e.asNumber
+	}
+	return "Number(" + e.code + ")"
+}
+
+// value traduce una expresión y la convierte al tipo want: int a number
+// cuando se espera un float y bool a BigInt cuando se espera un int
+func (g *jsGenerator) value(node *models.ASTNode, want string) jsExpr {
+	e := g.expr(node)
+	switch {
+	case e.kind == jsInt && want == jsFloat:
+		code := g.number(e)
+		return jsExpr{code: code, kind: jsFloat, prec: map[bool]int{true: e.prec, false: jsPrecPrimary}[e.literal]}
+	case e.kind == jsBool && want == jsInt:
+		return jsPrimary("BigInt("+e.code+")", jsInt)
+	}
+	return e
+}
+
+// truth traduce una expresión usada como condición. La verdad de los
+// números, cadenas y None coincide con la de Python; una lista vacía es
+// verdadera en JavaScript, así que se compara su longitud.
+func (g *jsGenerator) truth(node *models.ASTNode, negate bool) jsExpr {
+	switch {
+	case node.Type == "UnaryOp" && node.Value == "not":
+		return g.truth(node.Children[0], !negate)
+	case node.Type == "BinaryOp" && (node.Value == "and" || node.Value == "or"):
+		op, prec := "&&", jsPrecAnd
+		if node.Value == "or" {
+			op, prec = "||", jsPrecOr
+		}
+		left, right := g.truth(node.Children[0], false), g.truth(node.Children[1], false)
+		e := jsExpr{code: left.wrap(prec) + " " + op + " " + right.wrap(prec+1), kind: jsBool, prec: prec}
+		if negate {
+			return jsExpr{code: "!(" + e.code + ")", kind: jsBool, prec: jsPrecUnary}
+		}
+		return e
+	case node.Type == "BinaryOp" && negate && invertedComparison[node.Value] != "":
+		operand, _ := jsKind(g.types.expressionType(node.Children[0], g.sc))
+		// Con float la comparación invertida no es equivalente si hay NaN
+		if operand == jsInt || operand == jsStr {
+			return g.compare(invertedComparison[node.Value], node)
+		}
+	}
+
+	e := g.expr(node)
+	switch e.kind {
+	case "":
+		return e
+	case jsList:
+		compare := map[bool]string{false: " > 0", true: " === 0"}[negate]
+		return jsExpr{code: e.wrap(jsPrecPrimary) + ".length" + compare, kind: jsBool, prec: jsPrecRelational}
+	case jsAny:
+		g.helper("pyBool")
+		e = jsPrimary("pyBool("+e.code+")", jsBool)
+	}
+	if negate {
+		return jsExpr{code: "!" + e.wrap(jsPrecUnary), kind: jsBool, prec: jsPrecUnary}
+	}
+	return e
+}
+
+// expr traduce una expresión
+func (g *jsGenerator) expr(node *models.ASTNode) jsExpr {
+	switch node.Type {
+	case "Number":
+		literal := strings.ReplaceAll(node.Value, "_", "")
+		switch {
+		case strings.ContainsAny(literal, "jJ"):
+			return g.fail(node, "número", "los números complejos no tienen traducción")
+		case numberType(node.Value) == typeFloat:
+			return jsExpr{code: literal, kind: jsFloat, prec: jsPrecPrimary}
+		}
+		if trimmed := strings.TrimLeft(literal, "0"); trimmed != "" && !strings.ContainsAny(literal, "xXoObB") {
+			literal = trimmed
+		}
+		return jsExpr{code: literal + "n", kind: jsInt, prec: jsPrecPrimary, literal: true}
+
+	case "String":
+		value, _ := literalValue(node)
+		return jsPrimary(jsQuote(value.s), jsStr)
+
+	case "Boolean":
+		return jsPrimary(strings.ToLower(node.Value), jsBool)
+
+	case "None":
+		return jsPrimary("null", jsNone)
+
+	case "Identifier":
+		return g.identifier(node, node.Value)
+
+	case "Attribute":
+		if path := dottedName(node); path != "" {
+			if constant, ok := jsMathConstants[g.qualifiedName(node, path)]; ok {
+				return jsPrimary(constant, jsFloat)
+			}
+		}
+		return g.fail(node, "atributo", "los atributos solo se traducen para las constantes de math")
+
+	case "UnaryOp":
+		return g.unary(node)
+
+	case "BinaryOp":
+		switch {
+		case node.Value == "and" || node.Value == "or":
+			return g.logical(node)
+		case isComparison(node.Value):
+			return g.compare(node.Value, node)
+		}
+		return g.arithmetic(node.Value, g.expr(node.Children[0]), g.expr(node.Children[1]), node)
+
+	case "IfExpression":
+		condition := g.truth(node.Children[0], false)
+		then, otherwise := g.expr(node.Children[1]), g.expr(node.Children[2])
+		if condition.kind == "" || then.kind == "" || otherwise.kind == "" {
+			return g.failedExpr(node)
+		}
+		kind := then.kind
+		if then.kind != otherwise.kind {
+			kind = jsAny
+			if isJSNumber(then.kind) && isJSNumber(otherwise.kind) {
+				kind = jsFloat
+				then, otherwise = g.value(node.Children[1], jsFloat), g.value(node.Children[2], jsFloat)
+			}
+		}
+		code := condition.wrap(jsPrecTernary+1) + " ? " + then.wrap(jsPrecTernary) + " : " + otherwise.wrap(jsPrecTernary)
+		return jsExpr{code: code, kind: kind, elem: then.elem, prec: jsPrecTernary}
+
+	case "List", "Tuple":
+		_, elem := jsKind(g.types.expressionType(node, g.sc))
+		items := make([]string, 0, len(node.Children))
+		for _, child := range node.Children {
+			item := g.value(child, elem)
+			if item.kind == "" {
+				return item
+			}
+			items = append(items, item.code)
+		}
+		return jsExpr{code: "[" + strings.Join(items, ", ") + "]", kind: jsList, elem: elem, prec: jsPrecPrimary}
+
+	case "Subscript":
+		return g.subscript(node)
+
+	case "FunctionCall":
+		return g.call(node)
+
+	case "MethodCall":
+		receiver := g.expr(node.Children[0].Children[0])
+		if receiver.kind == "" {
+			return receiver
+		}
+		return g.method(receiver, node.Value, node.Children[1:], node)
+
+	case "Lambda":
+		return g.lambda(node)
+
+	case "ListComp", "GeneratorExp":
+		return g.comprehension(node)
+	}
+
+	constructs := map[string]string{
+		"SetComp": "comprensión de conjunto", "DictComp": "comprensión de diccionario",
+		"Dict": "diccionario", "Set": "conjunto", "Keyword": "argumento con nombre",
+	}
+	construct := constructs[node.Type]
+	if construct == "" {
+		construct = node.Type
+	}
+	return g.fail(node, construct, fmt.Sprintf("%s no tiene traducción a JavaScript en esta versión", construct))
+}
+
+// identifier traduce la lectura de una variable; node es el nodo que la
+// resolvió (un Identifier, o la llamada "xs.append" para su receptor)
+func (g *jsGenerator) identifier(node *models.ASTNode, name string) jsExpr {
+	symbol := g.symbol(node)
+	switch {
+	case symbol == nil:
+		return g.fail(node, "nombre", fmt.Sprintf("'%s' no es una variable del programa", name))
+	case symbol.module != "":
+		if constant, ok := jsMathConstants[g.qualifiedName(node, name)]; ok {
+			return jsPrimary(constant, jsFloat)
+		}
+		return g.fail(node, "nombre", fmt.Sprintf("'%s' de %s no tiene traducción", name, symbol.module))
+	case symbol.kind == "class" || symbol.kind == "module":
+		return g.fail(node, "nombre", fmt.Sprintf("'%s' se usa como valor; las clases y los módulos como valores no se traducen", name))
+	case symbol.kind == "function":
+		return jsPrimary(jsName(name), jsFunction)
+	}
+	kind, elem := g.symbolKind(symbol)
+	return jsExpr{code: jsName(name), kind: kind, elem: elem, prec: jsPrecPrimary}
+}
+
+func (g *jsGenerator) unary(node *models.ASTNode) jsExpr {
+	if node.Value == "not" {
+		return g.truth(node.Children[0], true)
+	}
+	operand := g.expr(node.Children[0])
+	switch {
+	case operand.kind == "":
+		return operand
+	case node.Value == "+" && operand.kind == jsInt:
+		return operand
+	case node.Value == "-" || node.Value == "+" || node.Value == "~":
+		if operand.kind == jsBool {
+			operand = jsPrimary("BigInt("+operand.code+")", jsInt)
+		}
+		code := operand.wrap(jsPrecUnary)
+		if strings.HasPrefix(code, "-") || strings.HasPrefix(code, "+") {
+			code = "(" + code + ")"
+		}
+		return jsExpr{code: node.Value + code, kind: operand.kind, prec: jsPrecUnary, literal: operand.literal && node.Value == "-"}
+	}
+	return g.fail(node, "operador", fmt.Sprintf("'%s' no tiene traducción", node.Value))
+}
+
+// logical traduce and y or como valores: && y || devuelven uno de los
+// operandos igual que en Python, salvo con listas, cuya verdad difiere
+func (g *jsGenerator) logical(node *models.ASTNode) jsExpr {
+	if t := g.types.expressionType(node, g.sc); t == typeBool {
+		return g.truth(node, false)
+	}
+	op, prec := "&&", jsPrecAnd
+	if node.Value == "or" {
+		op, prec = "||", jsPrecOr
+	}
+	left, right := g.expr(node.Children[0]), g.expr(node.Children[1])
+	if left.kind == "" || right.kind == "" {
+		return g.failedExpr(node)
+	}
+	if left.kind == jsList {
+		return g.fail(node, node.Value, fmt.Sprintf("'%s' con una lista como operando no tiene traducción: una lista vacía es verdadera en JavaScript", node.Value))
+	}
+	kind := left.kind
+	if right.kind != kind {
+		kind = jsAny
+	}
+	return jsExpr{code: left.wrap(prec) + " " + op + " " + right.wrap(prec+1), kind: kind, prec: prec}
+}
+
+// compare traduce una comparación. == usa === entre valores del mismo tipo,
+// == entre BigInt y number (1 == 1.0 en Python) y pyEquals con listas.
+func (g *jsGenerator) compare(op string, node *models.ASTNode) jsExpr {
+	if op == "in" || op == "not in" {
+		return g.membership(op, node)
+	}
+	left, right := g.expr(node.Children[0]), g.expr(node.Children[1])
+	if left.kind == "" || right.kind == "" {
+		return g.failedExpr(node)
+	}
+	if left.kind == jsInt && right.kind == jsInt && (left.asNumber != "" && right.literal || left.literal && right.asNumber != "") {
+		// len(xs) == 0 se compara como number, sin pasar por BigInt
+		left = jsExpr{code: g.number(left), kind: jsFloat, prec: left.prec}
+		right = jsExpr{code: g.number(right), kind: jsFloat, prec: right.prec}
+	}
+	binary := func(jsOp string) jsExpr {
+		prec := jsBinaryPrecedence[jsOp]
+		return jsExpr{code: left.wrap(prec) + " " + jsOp + " " + right.wrap(prec+1), kind: jsBool, prec: prec}
+	}
+	negated := op == "!=" || op == "is not"
+
+	switch {
+	case left.kind == jsNone || right.kind == jsNone:
+		// == null también reconoce undefined, el resultado de una función sin return
+		return binary(map[bool]string{false: "==", true: "!="}[negated])
+	case op == "is" || op == "is not":
+		return binary(map[bool]string{false: "===", true: "!=="}[negated])
+	case op == "==" || op == "!=":
+		switch {
+		case left.kind == right.kind && left.kind != jsList && left.kind != jsAny:
+			return binary(map[bool]string{false: "===", true: "!=="}[negated])
+		case isJSNumber(left.kind) && isJSNumber(right.kind):
+			return binary(op)
+		}
+		g.helper("pyEquals")
+		prefix := map[bool]string{false: "", true: "!"}[negated]
+		return jsExpr{code: prefix + "pyEquals(" + left.code + ", " + right.code + ")", kind: jsBool, prec: jsPrecUnary}
+	case left.kind == jsList || right.kind == jsList:
+		return g.fail(node, "comparación", fmt.Sprintf("'%s' entre listas no tiene traducción", op))
+	}
+	return binary(op)
+}
+
+func (g *jsGenerator) membership(op string, node *models.ASTNode) jsExpr {
+	item, container := g.expr(node.Children[0]), g.expr(node.Children[1])
+	if item.kind == "" || container.kind == "" {
+		return g.failedExpr(node)
+	}
+	prefix := map[string]string{"in": "", "not in": "!"}[op]
+	switch {
+	case container.kind == jsStr || (container.kind == jsList && container.elem != jsList && container.elem != jsAny && container.elem != ""):
+		item = g.value(node.Children[0], container.elem)
+		return jsExpr{code: prefix + container.wrap(jsPrecPrimary) + ".includes(" + item.code + ")", kind: jsBool, prec: jsPrecUnary}
+	case container.kind == jsList || container.kind == jsAny:
+		g.helper("pyEquals")
+		return jsExpr{code: prefix + container.wrap(jsPrecPrimary) + ".some((item) => pyEquals(item, " + item.code + "))", kind: jsBool, prec: jsPrecUnary}
+	}
+	return g.fail(node, op, fmt.Sprintf("'%s' sobre un valor %s no tiene traducción", op, container.kind))
+}
+
+// arithmetic traduce un operador aritmético entre dos expresiones ya
+// generadas. Entre enteros se opera con BigInt; si interviene un float, los
+// enteros se convierten con Number(). // y % usan las funciones auxiliares
+// que redondean como Python.
+func (g *jsGenerator) arithmetic(op string, left, right jsExpr, node *models.ASTNode) jsExpr {
+	if left.kind == "" || right.kind == "" {
+		return g.failedExpr(node)
+	}
+	binary := func(l, r jsExpr, kind string) jsExpr {
+		prec := jsBinaryPrecedence[op]
+		leftCode := l.wrap(prec)
+		if op == "**" {
+			// El operando izquierdo de ** no puede ser una expresión unaria
+			leftCode = l.wrap(jsPrecUnary + 1)
+		}
+		right := r.wrap(prec + 1)
+		if op == "**" {
+			right = r.wrap(prec)
+		}
+		return jsExpr{code: leftCode + " " + op + " " + right, kind: kind, prec: prec}
+	}
+	if left.kind == jsBool {
+		left = jsPrimary("BigInt("+left.code+")", jsInt)
+	}
+	if right.kind == jsBool {
+		right = jsPrimary("BigInt("+right.code+")", jsInt)
+	}
+
+	switch {
+	case left.kind == jsStr && right.kind == jsStr && op == "+":
+		return binary(left, right, jsStr)
+	case op == "*" && ((left.kind == jsStr && right.kind == jsInt) || (left.kind == jsInt && right.kind == jsStr)):
+		if left.kind == jsInt {
+			left, right = right, left
+		}
+		return jsPrimary(left.wrap(jsPrecPrimary)+".repeat("+g.number(right)+")", jsStr)
+	case left.kind == jsList && right.kind == jsList && op == "+":
+		return jsExpr{code: "[..." + left.code + ", ..." + right.code + "]", kind: jsList, elem: left.elem, prec: jsPrecPrimary}
+	case op == "*" && ((left.kind == jsList && right.kind == jsInt) || (left.kind == jsInt && right.kind == jsList)):
+		if left.kind == jsInt {
+			left, right = right, left
+		}
+		g.helper("pyRepeat")
+		return jsExpr{code: "pyRepeat(" + left.code + ", " + g.number(right) + ")", kind: jsList, elem: left.elem, prec: jsPrecPrimary}
+	case left.kind == jsAny || right.kind == jsAny:
+		// Sin tipos conocidos se opera sin conversiones
+		g.note(node, fmt.Sprintf("no se conoce el tipo de los operandos de '%s': si se mezclan enteros y float, JavaScript lanza TypeError al combinar BigInt y number", formatSource(node)))
+		return g.numeric(op, left, right, jsAny, binary)
+	case !isJSNumber(left.kind) || !isJSNumber(right.kind):
+		return g.fail(node, "operación", fmt.Sprintf("'%s' entre %s y %s no tiene traducción", op, left.kind, right.kind))
+	}
+
+	exponent := node
+	if len(node.Children) == 2 {
+		exponent = node.Children[1]
+	}
+	// Entre enteros, un exponente negativo da un float en Python: 2 ** -1 es 0.5
+	negative := false
+	if value, ok := g.constants.valueOf(exponent); ok && op == "**" && value.isIntegral() {
+		negative = value.toInt().Sign() < 0
+	}
+
+	if left.kind == jsFloat || right.kind == jsFloat || op == "/" || negative {
+		l := jsExpr{code: g.number(left), kind: jsFloat, prec: left.prec}
+		r := jsExpr{code: g.number(right), kind: jsFloat, prec: right.prec}
+		if left.kind == jsInt && !left.literal {
+			l.prec = jsPrecPrimary
+		}
+		if right.kind == jsInt && !right.literal {
+			r.prec = jsPrecPrimary
+		}
+		return g.numeric(op, l, r, jsFloat, binary)
+	}
+	if value, ok := g.constants.valueOf(exponent); op == "**" && (!ok || !value.isIntegral()) {
+		g.note(node, "si el exponente es negativo, Python devuelve un float y JavaScript lanza RangeError con BigInt")
+	}
+	return g.numeric(op, left, right, jsInt, binary)
+}
+
+func (g *jsGenerator) numeric(op string, left, right jsExpr, kind string, binary func(l, r jsExpr, kind string) jsExpr) jsExpr {
+	switch op {
+	case "//":
+		if kind == jsFloat {
+			return jsPrimary("Math.floor("+left.wrap(jsPrecMul)+" / "+right.wrap(jsPrecMul+1)+")", jsFloat)
+		}
+		g.helper("pyFloorDiv")
+		return jsPrimary("pyFloorDiv("+left.code+", "+right.code+")", kind)
+	case "%":
+		g.helper("pyMod")
+		return jsPrimary("pyMod("+left.code+", "+right.code+")", kind)
+	case "/":
+		if kind == jsAny {
+			return binary(jsExpr{code: "Number(" + left.code + ")", prec: jsPrecPrimary}, jsExpr{code: "Number(" + right.code + ")", prec: jsPrecPrimary}, jsFloat)
+		}
+		return binary(left, right, jsFloat)
+	}
+	if jsBinaryPrecedence[op] == 0 {
+		return g.fail(nil, "operación", fmt.Sprintf("el operador '%s' no tiene traducción", op))
+	}
+	return binary(left, right, kind)
+}
+
+// index traduce un índice de Python; un literal negativo cuenta desde el final
+func (g *jsGenerator) index(container jsExpr, node *models.ASTNode) (string, bool) {
+	if value, ok := intLiteral(node); ok {
+		if value >= 0 {
+			return fmt.Sprint(value), true
+		}
+		if container.prec < jsPrecPrimary || strings.Contains(container.code, "(") {
+			g.fail(node, "índice", "un índice negativo solo se traduce sobre una variable")
+			return "", false
+		}
+		return fmt.Sprintf("%s.length - %d", container.code, -value), true
+	}
+	index := g.expr(node)
+	if index.kind == "" {
+		return "", false
+	}
+	return g.number(index), true
+}
+
+func (g *jsGenerator) subscript(node *models.ASTNode) jsExpr {
+	container := g.expr(node.Children[0])
+	if container.kind == "" {
+		return container
+	}
+	if container.kind != jsList && container.kind != jsStr && container.kind != jsAny {
+		return g.fail(node, "índice", fmt.Sprintf("el acceso por índice a un valor %s no tiene traducción", container.kind))
+	}
+
+	part := node.Children[1]
+	if part.Type != "Slice" {
+		index, ok := g.index(container, part)
+		if !ok {
+			return g.failedExpr(node)
+		}
+		kind := container.elem
+		if container.kind == jsStr {
+			kind = jsStr
+		} else if kind == "" {
+			kind = jsAny
+		}
+		return jsPrimary(container.wrap(jsPrecPrimary)+"["+index+"]", kind)
+	}
+
+	bounds := make([]string, 0, 2)
+	for i, bound := range part.Children {
+		if i == 2 {
+			if step, ok := intLiteral(bound); ok && step == -1 && len(bounds) == 0 && part.Children[0].Type == "None" && part.Children[1].Type == "None" {
+				if container.kind == jsStr {
+					return jsPrimary("[..."+container.code+"].reverse().join(\"\")", jsStr)
+				}
+				return jsExpr{code: "[..." + container.code + "].reverse()", kind: container.kind, elem: container.elem, prec: jsPrecPrimary}
+			}
+			if bound.Type != "None" {
+				return g.fail(part, "rebanado", "los rebanados con paso (salvo [::-1]) no tienen traducción")
+			}
+			continue
+		}
+		if bound.Type == "None" {
+			if i == 0 && part.Children[1].Type != "None" {
+				bounds = append(bounds, "0")
+			}
+			continue
+		}
+		// slice acepta índices negativos igual que Python
+		index := g.expr(bound)
+		if index.kind == "" {
+			return g.failedExpr(node)
+		}
+		bounds = append(bounds, g.number(index))
+	}
+	return jsExpr{code: container.wrap(jsPrecPrimary) + ".slice(" + strings.Join(bounds, ", ") + ")", kind: container.kind, elem: container.elem, prec: jsPrecPrimary}
+}
+
+// lambda traduce una lambda como función flecha
+func (g *jsGenerator) lambda(node *models.ASTNode) jsExpr {
+	outer := g.sc
+	if sc := g.tree.byNode[node]; sc != nil {
+		g.sc = sc
+	}
+	defer func() { g.sc = outer }()
+
+	params := node.Children[:len(node.Children)-1]
+	parts, ok := g.parameters(params)
+	if !ok {
+		return g.failedExpr(node)
+	}
+	body := g.expr(node.Children[len(node.Children)-1])
+	if body.kind == "" {
+		return body
+	}
+	code := body.wrap(jsPrecTernary)
+	if strings.HasPrefix(code, "{") {
+		code = "(" + code + ")"
+	}
+	return jsExpr{code: "(" + strings.Join(parts, ", ") + ") => " + code, kind: jsFunction, prec: jsPrecArrow}
+}
+
+// comprehension traduce una comprensión de lista con un único for como
+// filter y map sobre un arreglo
+func (g *jsGenerator) comprehension(node *models.ASTNode) jsExpr {
+	if len(node.Children) != 2 || node.Children[1].Type != "Comprehension" {
+		return g.fail(node, "comprensión", "solo se traducen las comprensiones con un único for")
+	}
+	outer := g.sc
+	if sc := g.tree.byNode[node]; sc != nil {
+		g.sc = sc
+	}
+	defer func() { g.sc = outer }()
+
+	clause := node.Children[1]
+	target := clause.Children[0]
+	var pattern string
+	switch target.Type {
+	case "Identifier":
+		pattern = jsName(target.Value)
+	case "Tuple":
+		names := make([]string, 0, len(target.Children))
+		for _, name := range target.Children {
+			if name.Type != "Identifier" {
+				return g.fail(target, "comprensión", "el destino debe ser un nombre o una tupla de nombres")
+			}
+			names = append(names, jsName(name.Value))
+		}
+		pattern = "[" + strings.Join(names, ", ") + "]"
+	default:
+		return g.fail(target, "comprensión", "el destino debe ser un nombre o una tupla de nombres")
+	}
+
+	source := g.iterable(clause.Children[1])
+	if source.kind == "" {
+		return source
+	}
+	code := source.code
+	if source.kind != jsList {
+		code = "Array.from(" + source.code + ")"
+	}
+	for _, condition := range clause.Children[2:] {
+		test := g.truth(condition, false)
+		if test.kind == "" {
+			return test
+		}
+		code += ".filter((" + pattern + ") => " + test.wrap(jsPrecTernary) + ")"
+	}
+	element := g.expr(node.Children[0])
+	if element.kind == "" {
+		return element
+	}
+	if element.code != pattern {
+		body := element.wrap(jsPrecTernary)
+		if strings.HasPrefix(body, "{") {
+			body = "(" + body + ")"
+		}
+		code += ".map((" + pattern + ") => " + body + ")"
+	}
+	return jsExpr{code: code, kind: jsList, elem: element.kind, prec: jsPrecPrimary}
+}
+
+// call traduce una llamada a una función del programa, a una función
+// integrada, a math o a un método de una variable ("s.upper()")
+func (g *jsGenerator) call(node *models.ASTNode) jsExpr {
+	symbol := g.symbol(node)
+	if fn := g.userFunction(node); fn != nil {
+		return g.userCall(fn, node)
+	}
+	if dot := strings.LastIndex(node.Value, "."); dot >= 0 && symbol != nil && symbol.kind != "module" {
+		if strings.Count(node.Value, ".") > 1 {
+			return g.fail(node, "método", "solo se traducen los métodos de una variable")
+		}
+		receiver := g.identifier(node, node.Value[:dot])
+		if receiver.kind == "" {
+			return receiver
+		}
+		return g.method(receiver, node.Value[dot+1:], node.Children, node)
+	}
+	if symbol != nil && symbol.kind == "variable" && symbol.module == "" {
+		// Una variable que guarda una función (una lambda)
+		args := make([]string, len(node.Children))
+		for i, arg := range node.Children {
+			if arg.Type == "Keyword" {
+				return g.fail(node, "argumento con nombre", "las funciones guardadas en variables solo se traducen con argumentos posicionales")
+			}
+			value := g.expr(arg)
+			if value.kind == "" {
+				return value
+			}
+			args[i] = value.code
+		}
+		return jsPrimary(jsName(node.Value)+"("+strings.Join(args, ", ")+")", jsAny)
+	}
+	return g.builtinCall(g.qualifiedName(node, node.Value), node)
+}
+
+func (g *jsGenerator) userCall(fn, node *models.ASTNode) jsExpr {
+	ft := g.types.functions[fn]
+	if ft == nil || fn.Type != "FunctionDef" {
+		return g.fail(node, "llamada", fmt.Sprintf("'%s' no es una función traducible", fn.Value))
+	}
+	params, _ := functionParts(fn)
+	for _, param := range params {
+		if strings.HasPrefix(param.Value, "*") && param.Value != "*" {
+			// Con *args los argumentos se pasan tal cual
+			args := make([]string, 0, len(node.Children))
+			for _, arg := range node.Children {
+				if arg.Type == "Keyword" {
+					return g.fail(node, "argumento con nombre", fmt.Sprintf("'%s' recibe *args: solo se traducen sus llamadas con argumentos posicionales", fn.Value))
+				}
+				value := g.expr(arg)
+				if value.kind == "" {
+					return value
+				}
+				args = append(args, value.code)
+			}
+			kind, elem := jsKind(ft.returnType())
+			return jsExpr{code: jsName(fn.Value) + "(" + strings.Join(args, ", ") + ")", kind: kind, elem: elem, prec: jsPrecPrimary}
+		}
+	}
+
+	args, ok := callArguments(fn, node)
+	if !ok {
+		return g.fail(node, "llamada", fmt.Sprintf("los argumentos de '%s' no coinciden con sus parámetros", formatSource(node)))
+	}
+	// Los valores por defecto que quedan al final los pone la propia función
+	for len(args) > 0 && args[len(args)-1] == parameterDefault(params[len(args)-1]) {
+		args = args[:len(args)-1]
+	}
+	codes := make([]string, len(args))
+	for i, arg := range args {
+		want := ""
+		if i < len(ft.symbols) {
+			want, _ = jsKind(g.types.symbols[ft.symbols[i]])
+		}
+		value := g.value(arg, want)
+		if value.kind == "" {
+			return value
+		}
+		codes[i] = value.code
+	}
+	kind, elem := jsKind(ft.returnType())
+	return jsExpr{code: jsName(fn.Value) + "(" + strings.Join(codes, ", ") + ")", kind: kind, elem: elem, prec: jsPrecPrimary}
+}
+
+// builtinCall traduce las funciones integradas y las de math
+func (g *jsGenerator) builtinCall(name string, node *models.ASTNode) jsExpr {
+	if name == "print" {
+		return g.fail(node, "print", "print solo se traduce como sentencia")
+	}
+	if name == "range" {
+		return g.rangeValue(node)
+	}
+	for _, arg := range node.Children {
+		if arg.Type == "Keyword" || arg.Type == "Starred" {
+			return g.fail(node, "llamada", fmt.Sprintf("'%s' solo se traduce con argumentos posicionales", name))
+		}
+	}
+	args := make([]jsExpr, len(node.Children))
+	for i, arg := range node.Children {
+		if args[i] = g.expr(arg); args[i].kind == "" {
+			return args[i]
+		}
+	}
+	codes := func() []string {
+		result := make([]string, len(args))
+		for i, arg := range args {
+			result[i] = arg.code
+		}
+		return result
+	}
+	numbers := func() []string {
+		result := make([]string, len(args))
+		for i, arg := range args {
+			result[i] = g.number(arg)
+		}
+		return result
+	}
+	if fn, ok := jsMathFunctions[name]; ok {
+		return jsPrimary(fn+"("+strings.Join(numbers(), ", ")+")", jsFloat)
+	}
+
+	one := len(args) == 1
+	switch {
+	case name == "len" && one:
+		length := args[0].wrap(jsPrecPrimary) + ".length"
+		return jsExpr{code: "BigInt(" + length + ")", kind: jsInt, prec: jsPrecPrimary, asNumber: length}
+
+	case name == "str" && one:
+		return g.str(args[0])
+
+	case name == "int" && one:
+		switch args[0].kind {
+		case jsInt:
+			return args[0]
+		case jsFloat:
+			return jsPrimary("BigInt(Math.trunc("+args[0].code+"))", jsInt)
+		case jsBool:
+			return jsPrimary("BigInt("+args[0].code+")", jsInt)
+		case jsStr:
+			g.helper("pyInt")
+			return jsPrimary("pyInt("+args[0].code+")", jsInt)
+		}
+
+	case name == "float" && one:
+		return jsPrimary("Number("+args[0].code+")", jsFloat)
+
+	case name == "bool" && one:
+		return g.truth(node.Children[0], false)
+
+	case name == "input" && len(args) <= 1:
+		g.helper("pyInput")
+		prompt := ""
+		if one {
+			prompt = g.str(args[0]).code
+		}
+		return jsPrimary("pyInput("+prompt+")", jsStr)
+
+	case name == "abs" && one && isJSNumber(args[0].kind):
+		if args[0].kind == jsFloat {
+			return jsPrimary("Math.abs("+args[0].code+")", jsFloat)
+		}
+		g.helper("pyAbs")
+		return jsPrimary("pyAbs("+args[0].code+")", jsInt)
+
+	case (name == "min" || name == "max") && len(args) > 0:
+		helper := "pyM" + name[1:]
+		g.helper(helper)
+		if one {
+			return jsPrimary(helper+"(..."+args[0].code+")", orAny(args[0].elem))
+		}
+		kind := args[0].kind
+		for _, arg := range args[1:] {
+			if arg.kind != kind {
+				kind = jsAny
+			}
+		}
+		return jsPrimary(helper+"("+strings.Join(codes(), ", ")+")", kind)
+
+	case name == "sum" && one:
+		start, kind := "0n", jsInt
+		if args[0].elem == jsFloat {
+			start, kind = "0", jsFloat
+		}
+		return jsPrimary(args[0].wrap(jsPrecPrimary)+".reduce((total, item) => total + item, "+start+")", kind)
+
+	case name == "round" && one && isJSNumber(args[0].kind):
+		if args[0].kind == jsInt {
+			return args[0]
+		}
+		g.helper("pyRound")
+		return jsPrimary("pyRound("+args[0].code+")", jsInt)
+
+	case name == "pow" && len(args) == 2:
+		return g.arithmetic("**", args[0], args[1], node)
+
+	case name == "list" && one:
+		if node.Children[0].Type == "FunctionCall" && node.Children[0].Value == "range" {
+			return jsExpr{code: "Array.from(" + args[0].code + ")", kind: jsList, elem: jsInt, prec: jsPrecPrimary}
+		}
+		return jsExpr{code: "Array.from(" + args[0].code + ")", kind: jsList, elem: args[0].elem, prec: jsPrecPrimary}
+
+	case name == "sorted" && one:
+		g.helper("pyCompare")
+		return jsExpr{code: "[..." + args[0].code + "].sort(pyCompare)", kind: jsList, elem: args[0].elem, prec: jsPrecPrimary}
+
+	case name == "reversed" && one:
+		return jsExpr{code: "[..." + args[0].code + "].reverse()", kind: jsList, elem: args[0].elem, prec: jsPrecPrimary}
+
+	case name == "chr" && one:
+		return jsPrimary("String.fromCodePoint("+g.number(args[0])+")", jsStr)
+
+	case name == "ord" && one:
+		return jsPrimary("BigInt("+args[0].wrap(jsPrecPrimary)+".codePointAt(0))", jsInt)
+
+	case (name == "math.floor" || name == "math.ceil") && one && isJSNumber(args[0].kind):
+		if args[0].kind == jsInt {
+			return args[0]
+		}
+		return jsPrimary("BigInt(Math."+name[5:]+"("+args[0].code+"))", jsInt)
+
+	case name == "math.log" && len(args) >= 1 && len(args) <= 2:
+		values := numbers()
+		if one {
+			return jsPrimary("Math.log("+values[0]+")", jsFloat)
+		}
+		return jsExpr{code: "Math.log(" + values[0] + ") / Math.log(" + values[1] + ")", kind: jsFloat, prec: jsPrecMul}
+
+	case name == "math.factorial" && one:
+		g.helper("pyFactorial")
+		return jsPrimary("pyFactorial("+args[0].code+")", jsInt)
+	}
+
+	kinds := make([]string, len(args))
+	for i, arg := range args {
+		kinds[i] = arg.kind
+	}
+	return g.fail(node, "llamada", fmt.Sprintf("'%s' con argumentos (%s) no tiene traducción", name, strings.Join(kinds, ", ")))
+}
+
+func orAny(kind string) string {
+	if kind == "" {
+		return jsAny
+	}
+	return kind
+}
+
+// method traduce los métodos de cadenas y listas
+func (g *jsGenerator) method(receiver jsExpr, method string, nodes []*models.ASTNode, node *models.ASTNode) jsExpr {
+	args := make([]jsExpr, len(nodes))
+	for i, arg := range nodes {
+		if arg.Type == "Keyword" {
+			return g.fail(node, "método", "los métodos solo se traducen con argumentos posicionales")
+		}
+		if args[i] = g.expr(arg); args[i].kind == "" {
+			return args[i]
+		}
+	}
+	target := receiver.wrap(jsPrecPrimary)
+	call := func(name, kind string, operands ...string) jsExpr {
+		return jsPrimary(target+"."+name+"("+strings.Join(operands, ", ")+")", kind)
+	}
+
+	if receiver.kind == jsStr || receiver.kind == jsAny && len(args) <= 2 {
+		codes := make([]string, len(args))
+		for i, arg := range args {
+			codes[i] = arg.code
+		}
+		switch {
+		case method == "upper" && len(args) == 0:
+			return call("toUpperCase", jsStr)
+		case method == "lower" && len(args) == 0:
+			return call("toLowerCase", jsStr)
+		case method == "strip" && len(args) == 0:
+			return call("trim", jsStr)
+		case method == "split" && len(args) == 0:
+			e := call("split", jsList, "/\\s+/")
+			e.code += ".filter(Boolean)"
+			e.elem = jsStr
+			return e
+		case method == "split" && len(args) == 1:
+			e := call("split", jsList, codes...)
+			e.elem = jsStr
+			return e
+		case method == "join" && len(args) == 1:
+			return jsPrimary(args[0].wrap(jsPrecPrimary)+".join("+receiver.code+")", jsStr)
+		case method == "startswith" && len(args) == 1:
+			return call("startsWith", jsBool, codes...)
+		case method == "endswith" && len(args) == 1:
+			return call("endsWith", jsBool, codes...)
+		case method == "replace" && len(args) == 2:
+			// replaceAll es de ES2021
+			return jsPrimary(target+".split("+codes[0]+").join("+codes[1]+")", jsStr)
+		case method == "count" && len(args) == 1 && receiver.kind == jsStr:
+			return jsPrimary("BigInt("+target+".split("+codes[0]+").length - 1)", jsInt)
+		case method == "find" && len(args) == 1:
+			return jsPrimary("BigInt("+target+".indexOf("+codes[0]+"))", jsInt)
+		case method == "isdigit" && len(args) == 0:
+			return jsPrimary("/^\\d+$/.test("+receiver.code+")", jsBool)
+		}
+	}
+
+	if receiver.kind == jsList || receiver.kind == jsAny {
+		elem := receiver.elem
+		switch {
+		case method == "append" && len(args) == 1:
+			return call("push", jsNone, g.value(nodes[0], elem).code)
+		case method == "extend" && len(args) == 1:
+			return call("push", jsNone, "..."+args[0].code)
+		case method == "insert" && len(args) == 2:
+			return call("splice", jsNone, g.number(args[0]), "0", g.value(nodes[1], elem).code)
+		case method == "pop" && len(args) == 0:
+			return call("pop", orAny(elem))
+		case method == "pop" && len(args) == 1:
+			e := call("splice", orAny(elem), g.number(args[0]), "1")
+			e.code += "[0]"
+			return e
+		case method == "sort" && len(args) == 0:
+			g.helper("pyCompare")
+			return call("sort", jsNone, "pyCompare")
+		case method == "reverse" && len(args) == 0:
+			return call("reverse", jsNone)
+		case method == "index" && len(args) == 1:
+			g.note(node, "indexOf devuelve -1 si el elemento no está; list.index de Python lanza ValueError")
+			return jsPrimary("BigInt("+target+".indexOf("+args[0].code+"))", jsInt)
+		case method == "count" && len(args) == 1:
+			g.helper("pyEquals")
+			return jsPrimary("BigInt("+target+".filter((item) => pyEquals(item, "+args[0].code+")).length)", jsInt)
+		}
+	}
+	return g.fail(node, "método", fmt.Sprintf("el método '%s' de un valor %s no tiene traducción", method, receiver.kind))
+}
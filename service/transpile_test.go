@@ -0,0 +1,175 @@
+package service
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"examen-back/models"
+)
+
+// transpile traduce un programa sin errores de sintaxis
+func transpile(t *testing.T, code, target string) models.TranspileResponse {
+	t.Helper()
+	syntax := parse(code)
+	if !syntax.Valid {
+		t.Fatalf("errores de sintaxis: %v", syntax.Errors)
+	}
+	return Transpile(syntax.AST, code, target)
+}
+
+func TestTranspileKeywordOnlyParameters(t *testing.T) {
+	const code = "def f(a, b=2, *, c):\n    return a + b + c\n\ndef g(*, k=1):\n    return k * 2\n\nprint(f(1, c=3))\nprint(g(k=4))\n"
+	for _, target := range []string{TargetGo, TargetJS} {
+		t.Run(target, func(t *testing.T) {
+			response := transpile(t, code, target)
+			separators := 0
+			for _, construct := range response.Untranslated {
+				if construct.Construct == "parámetro" && construct.Source == "*" {
+					separators++
+				}
+			}
+			if separators != 2 {
+				t.Errorf("los dos separadores '*' deben informarse sin traducir: %+v", response.Untranslated)
+			}
+		})
+	}
+}
+
+// goRun compila y ejecuta un programa de Go; devuelve la salida estándar y
+// la de errores
+func goRun(t *testing.T, source string) (string, string, error) {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no hay una herramienta go instalada")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command("go", "build", "-o", filepath.Join(dir, "main"), filepath.Join(dir, "main.go")).CombinedOutput(); err != nil {
+		t.Fatalf("la traducción no compila: %v\n%s\n%s", err, output, source)
+	}
+	var stdout, stderr strings.Builder
+	cmd := exec.Command(filepath.Join(dir, "main"))
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestTranspileLoopElseIsReportedWithItsLoop(t *testing.T) {
+	const code = "n = 3\nwhile n > 0:\n    n -= 1\nelse:\n    print(\"fin\")\nfor x in [1, 2]:\n    print(x)\nelse:\n    print(\"ok\")\n"
+	expected := []models.UntranslatedConstruct{
+		{Construct: "cláusula else de un bucle", Line: 4, Source: "while n > 0: ... else:"},
+		{Construct: "cláusula else de un bucle", Line: 8, Source: "for x in [1, 2]: ... else:"},
+	}
+	for _, target := range []string{TargetGo, TargetJS} {
+		t.Run(target, func(t *testing.T) {
+			response := transpile(t, code, target)
+			if len(response.Untranslated) != len(expected) {
+				t.Fatalf("construcciones sin traducir: %+v", response.Untranslated)
+			}
+			for i, construct := range response.Untranslated {
+				construct.Reason = ""
+				if construct != expected[i] {
+					t.Errorf("se informó %+v, se esperaba %+v", construct, expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTranspileJavaScriptKeywordArguments(t *testing.T) {
+	const code = "g = lambda a, b=1: a + b\nprint(g(1, b=2))\ndef h(*args, k=0):\n    return k\nprint(h(1, k=2))\n"
+	response := transpile(t, code, TargetJS)
+	sources := []string{"g(1, b=2)", "h(1, k=2)"}
+	if len(response.Untranslated) != len(sources) {
+		t.Fatalf("se esperaba una entrada por llamada: %+v", response.Untranslated)
+	}
+	for i, construct := range response.Untranslated {
+		if construct.Construct != "argumento con nombre" || construct.Source != sources[i] {
+			t.Errorf("se informó %+v, se esperaba el argumento con nombre de %s", construct, sources[i])
+		}
+	}
+}
+
+func TestTranspiledGoMatchesPython(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+	}{
+		{"strings con caracteres no ASCII", "s = \"ñandú\"\nprint(len(s), s[1], s[-1], s[1:3], s.find(\"d\"))\nfor c in s:\n    print(c)\n"},
+		{"división entera", "n = 7\nm = -2\nprint(n // m, n % m, -n // 2, -n % 3, n / 2, 7.5 // 2)\nn //= 2\nn %= 2\nprint(n)\n"},
+		{"potencias", "print(2 ** -1, pow(2, -2), 3 ** 4)\ne = 5\nprint(2 ** e)\nx = 2 ** -1\nprint(x + 1)\n"},
+		{"llamadas sin valor", "def f(x):\n    print(x)\n    return\n\nf(3)\n"},
+		{"resultado recursivo guardado en una variable", "def power(b, e):\n    if e == 0:\n        return 1\n    half = power(b, e // 2)\n    if e % 2 == 0:\n        return half * half\n    return half * half * b\n\nprint(power(2, 100))\nprint(power(3, 5))\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := transpile(t, tc.code, TargetGo)
+			if len(response.Untranslated) > 0 {
+				t.Fatalf("construcciones sin traducir: %+v", response.Untranslated)
+			}
+			expected := run(t, tc.code, EngineTree, models.RunLimits{})
+			stdout, stderr, err := goRun(t, response.Output)
+			if err != nil || stdout != expected.Stdout {
+				t.Errorf("Go escribió %q (%v %s), Python %q\n%s", stdout, err, stderr, expected.Stdout, response.Output)
+			}
+		})
+	}
+}
+
+func TestTranspiledGoRaisesZeroDivisionError(t *testing.T) {
+	for _, code := range []string{"print(10 // 0)\n", "n = 0\nprint(7 % n)\n", "x = 0.0\nprint(1 / x)\n", "print(1.5 // 0)\n"} {
+		response := transpile(t, code, TargetGo)
+		if len(response.Untranslated) > 0 {
+			t.Fatalf("%q: construcciones sin traducir: %+v", code, response.Untranslated)
+		}
+		if result := run(t, code, EngineTree, models.RunLimits{}); result.Error == nil || result.Error.Type != "ZeroDivisionError" {
+			t.Fatalf("%q: Python debía lanzar ZeroDivisionError: %+v", code, result.Error)
+		}
+		if _, stderr, err := goRun(t, response.Output); err == nil || !strings.Contains(stderr, "ZeroDivisionError") {
+			t.Errorf("%q: Go terminó con %v: %s", code, err, stderr)
+		}
+	}
+}
+
+func TestTranspiledGoReportsCallsWithoutValue(t *testing.T) {
+	response := transpile(t, "def f():\n    return\n\nf()\nprint(f())\n", TargetGo)
+	if len(response.Untranslated) != 1 || response.Untranslated[0].Source != "f()" || response.Untranslated[0].Line != 5 {
+		t.Errorf("solo print(f()) debía informarse sin traducir: %+v", response.Untranslated)
+	}
+}
+
+func TestTranspiledJavaScriptMatchesPython(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("no hay un intérprete node instalado")
+	}
+	cases := []struct {
+		name string
+		code string
+	}{
+		{"listas repetidas comparten sus elementos", "grid = [[0] * 3] * 3\ngrid[0][0] = 1\nprint(grid)\nrow = [0] * 2\nrow[0] = 5\nprint(row * 2)\n"},
+		{"potencias", "print(2 ** -1, pow(2, -2), 3 ** 4)\nx = 2 ** -1\nprint(x + 1)\ny = 5\ny **= 2\nprint(y)\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := transpile(t, tc.code, TargetJS)
+			if len(response.Untranslated) > 0 {
+				t.Fatalf("construcciones sin traducir: %+v", response.Untranslated)
+			}
+			expected := run(t, tc.code, EngineTree, models.RunLimits{})
+			file := filepath.Join(t.TempDir(), "programa.js")
+			if err := os.WriteFile(file, []byte(response.Output), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			output, err := exec.Command(node, file).CombinedOutput()
+			if err != nil || string(output) != expected.Stdout {
+				t.Errorf("JavaScript escribió %q (%v), Python %q\n%s", output, err, expected.Stdout, response.Output)
+			}
+		})
+	}
+}
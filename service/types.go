@@ -0,0 +1,359 @@
+package service
+
+import (
+	"sort"
+	"strings"
+)
+
+// pyType es un tipo inferido. Un puntero nil significa "sin información
+// todavía" y object significa "cualquier tipo" (no se puede precisar).
+type pyType struct {
+	name     string
+	elem     *pyType
+	members  []*pyType
+	function *functionType
+}
+
+var (
+	typeInt    = &pyType{name: "int"}
+	typeFloat  = &pyType{name: "float"}
+	typeStr    = &pyType{name: "str"}
+	typeBool   = &pyType{name: "bool"}
+	typeNone   = &pyType{name: "None"}
+	typeObject = &pyType{name: "object"}
+	typeModule = &pyType{name: "module"}
+)
+
+// containerTypes son los tipos que admiten un parámetro de elemento (list[int])
+var containerTypes = map[string]bool{"list": true, "set": true, "tuple": true, "dict": true, "iterator": true}
+
+func simpleType(name string) *pyType {
+	switch name {
+	case "int":
+		return typeInt
+	case "float":
+		return typeFloat
+	case "str":
+		return typeStr
+	case "bool":
+		return typeBool
+	case "None":
+		return typeNone
+	case "module":
+		return typeModule
+	case "list", "set", "tuple", "dict", "iterator", "range":
+		return &pyType{name: name}
+	}
+	return typeObject
+}
+
+func containerOf(name string, elem *pyType) *pyType {
+	return &pyType{name: name, elem: elem}
+}
+
+func (t *pyType) String() string {
+	if t == nil {
+		return ""
+	}
+
+	switch {
+	case t.name == "union":
+		parts := make([]string, len(t.members))
+		for i, member := range t.members {
+			parts[i] = member.String()
+		}
+		return strings.Join(parts, " | ")
+	case t.function != nil:
+		return t.function.signature()
+	case t.elem != nil:
+		return t.name + "[" + t.elem.String() + "]"
+	}
+	return t.name
+}
+
+func sameType(a, b *pyType) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.function != nil || b.function != nil {
+		return a.function == b.function
+	}
+	return a.String() == b.String()
+}
+
+func (t *pyType) isNumeric() bool {
+	return t == typeInt || t == typeFloat || t == typeBool
+}
+
+// isDefinite indica un tipo concreto sobre el que se puede afirmar un error
+func (t *pyType) isDefinite() bool {
+	return t != nil && t.name != "object" && t.name != "union"
+}
+
+// joinTypes combina los tipos de dos caminos. bool se absorbe en int y las
+// uniones de más de cuatro miembros se generalizan a object.
+func joinTypes(a, b *pyType) *pyType {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	if merged, ok := mergeTypes(a, b); ok {
+		return merged
+	}
+
+	unique := make([]*pyType, 0)
+	for _, member := range append(unionMembers(a), unionMembers(b)...) {
+		merged := false
+		for i, existing := range unique {
+			if joined, ok := mergeTypes(existing, member); ok {
+				unique[i] = joined
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			unique = append(unique, member)
+		}
+	}
+	if len(unique) == 1 {
+		return unique[0]
+	}
+	if len(unique) > 4 {
+		return typeObject
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		if (unique[i] == typeNone) != (unique[j] == typeNone) {
+			return unique[j] == typeNone
+		}
+		return unique[i].String() < unique[j].String()
+	})
+	return &pyType{name: "union", members: unique}
+}
+
+// mergeTypes combina dos tipos sin formar una unión, si es posible
+func mergeTypes(a, b *pyType) (*pyType, bool) {
+	switch {
+	case a.name == "union" || b.name == "union":
+		return nil, false
+	case sameType(a, b):
+		return a, true
+	case a == typeObject || b == typeObject:
+		return typeObject, true
+	case (a == typeInt && b == typeBool) || (a == typeBool && b == typeInt):
+		return typeInt, true
+	case a.name == b.name && containerTypes[a.name]:
+		return containerOf(a.name, joinTypes(a.elem, b.elem)), true
+	}
+	return nil, false
+}
+
+// elementType es el tipo de los elementos al iterar un valor
+func elementType(t *pyType) *pyType {
+	switch {
+	case t == nil:
+		return nil
+	case t == typeStr:
+		return typeStr
+	case t.name == "range":
+		return typeInt
+	case containerTypes[t.name]:
+		if t.elem != nil {
+			return t.elem
+		}
+	}
+	return typeObject
+}
+
+// parseTypeName convierte el tipo de una firma del catálogo ("list[str]") en un pyType
+func parseTypeName(name string) *pyType {
+	name = strings.TrimSpace(name)
+	if open := strings.Index(name, "["); open > 0 && strings.HasSuffix(name, "]") {
+		return containerOf(name[:open], parseTypeName(name[open+1:len(name)-1]))
+	}
+	return simpleType(name)
+}
+
+// compatibleType indica si un valor del tipo actual puede usarse donde se espera otro
+func compatibleType(actual, expected *pyType) bool {
+	switch {
+	case actual == nil || expected == nil || actual == typeObject || expected == typeObject:
+		return true
+	case expected.name == "union":
+		for _, member := range expected.members {
+			if compatibleType(actual, member) {
+				return true
+			}
+		}
+		return false
+	case actual.name == "union":
+		for _, member := range actual.members {
+			if compatibleType(member, expected) {
+				return true
+			}
+		}
+		return false
+	case sameType(actual, expected):
+		return true
+	case expected == typeFloat:
+		return actual == typeInt || actual == typeBool
+	case expected == typeInt:
+		return actual == typeBool
+	case actual.name == expected.name:
+		return actual.elem == nil || expected.elem == nil || compatibleType(actual.elem, expected.elem)
+	}
+	return false
+}
+
+// binaryType calcula el tipo de "a op b"; ok es falso si la operación produce
+// un TypeError con esos tipos
+func binaryType(op string, left, right *pyType) (*pyType, bool) {
+	if left == nil || right == nil {
+		return nil, true
+	}
+	if left == typeObject || right == typeObject {
+		if isComparison(op) {
+			return typeBool, true
+		}
+		return typeObject, true
+	}
+
+	if left.name == "union" || right.name == "union" {
+		// Solo es un error si falla con todas las combinaciones posibles
+		var result *pyType
+		valid := false
+		for _, l := range unionMembers(left) {
+			for _, r := range unionMembers(right) {
+				if t, ok := binaryType(op, l, r); ok {
+					result = joinTypes(result, t)
+					valid = true
+				}
+			}
+		}
+		return result, valid
+	}
+
+	numeric := left.isNumeric() && right.isNumeric()
+	integral := (left == typeInt || left == typeBool) && (right == typeInt || right == typeBool)
+	widened := typeInt
+	if left == typeFloat || right == typeFloat {
+		widened = typeFloat
+	}
+
+	switch op {
+	case "and", "or":
+		return joinTypes(left, right), true
+	case "==", "!=", "is", "is not", "in", "not in":
+		return typeBool, true
+	case "<", "<=", ">", ">=":
+		ordered := numeric || (left.name == right.name && (left == typeStr || left.name == "list" || left.name == "tuple" || left.name == "set"))
+		return typeBool, ordered
+	case "+":
+		switch {
+		case numeric:
+			return widened, true
+		case left == typeStr && right == typeStr:
+			return typeStr, true
+		case left.name == right.name && (left.name == "list" || left.name == "tuple"):
+			return joinTypes(left, right), true
+		}
+	case "-":
+		if numeric {
+			return widened, true
+		}
+		if left.name == "set" && right.name == "set" {
+			return left, true
+		}
+	case "*":
+		switch {
+		case numeric:
+			return widened, true
+		case (left == typeStr || left.name == "list" || left.name == "tuple") && (right == typeInt || right == typeBool):
+			return left, true
+		case (right == typeStr || right.name == "list" || right.name == "tuple") && (left == typeInt || left == typeBool):
+			return right, true
+		}
+	case "/":
+		if numeric {
+			return typeFloat, true
+		}
+	case "//", "%":
+		if numeric {
+			return widened, true
+		}
+		if op == "%" && left == typeStr {
+			return typeStr, true
+		}
+	case "**":
+		if numeric {
+			return widened, true
+		}
+	case "<<", ">>":
+		if integral {
+			return typeInt, true
+		}
+	case "&", "|", "^":
+		if left == typeBool && right == typeBool {
+			return typeBool, true
+		}
+		if integral {
+			return typeInt, true
+		}
+		if left.name == "set" && right.name == "set" {
+			return left, true
+		}
+	}
+
+	return nil, false
+}
+
+func unaryType(op string, operand *pyType) (*pyType, bool) {
+	switch {
+	case op == "not":
+		return typeBool, true
+	case operand == nil:
+		return nil, true
+	case operand == typeObject:
+		return typeObject, true
+	case operand.name == "union":
+		var result *pyType
+		valid := false
+		for _, member := range operand.members {
+			if t, ok := unaryType(op, member); ok {
+				result = joinTypes(result, t)
+				valid = true
+			}
+		}
+		return result, valid
+	case op == "~":
+		return typeInt, operand == typeInt || operand == typeBool
+	case operand == typeBool:
+		return typeInt, true
+	}
+	return operand, operand.isNumeric()
+}
+
+func unionMembers(t *pyType) []*pyType {
+	if t.name == "union" {
+		return t.members
+	}
+	return []*pyType{t}
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "is", "is not", "in", "not in":
+		return true
+	}
+	return false
+}
+
+func isArithmetic(op string) bool {
+	switch op {
+	case "+", "-", "*", "/", "//", "%", "**", "<<", ">>", "&", "|", "^":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"strings"
+
+	"examen-back/models"
+)
+
+// formatSource reconstruye el código Python de una expresión o sentencia simple
+// a partir del AST. Se usa para mostrar los pasos de los bloques del CFG.
+func formatSource(node *models.ASTNode) string {
+	if node == nil {
+		return ""
+	}
+
+	switch node.Type {
+	case "Number", "Identifier", "Boolean":
+		return node.Value
+
+	case "None":
+		return "None"
+
+	case "String":
+		if strings.Contains(node.Value, "\"") {
+			return "'" + node.Value + "'"
+		}
+		return "\"" + node.Value + "\""
+
+	case "BinaryOp":
+		return formatOperand(node.Children[0], node) + " " + node.Value + " " + formatOperand(node.Children[1], node)
+
+	case "UnaryOp":
+		if node.Value == "not" {
+			return "not " + formatOperand(node.Children[0], node)
+		}
+		return node.Value + formatOperand(node.Children[0], node)
+
+	case "IfExpression":
+		return formatSource(node.Children[1]) + " if " + formatSource(node.Children[0]) + " else " + formatSource(node.Children[2])
+
+	case "Lambda":
+		params, body := functionParts(node)
+		if len(params) == 0 {
+			return "lambda: " + formatSource(body[0])
+		}
+		return "lambda " + formatParameters(params) + ": " + formatSource(body[0])
+
+	case "Tuple":
+		if len(node.Children) == 1 {
+			return "(" + formatSource(node.Children[0]) + ",)"
+		}
+		return "(" + formatList(node.Children) + ")"
+
+	case "List":
+		return "[" + formatList(node.Children) + "]"
+
+	case "Set":
+		return "{" + formatList(node.Children) + "}"
+
+	case "Dict":
+		pairs := make([]string, 0, len(node.Children)/2)
+		for i := 0; i+1 < len(node.Children); i += 2 {
+			pairs = append(pairs, formatSource(node.Children[i])+": "+formatSource(node.Children[i+1]))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+
+	case "ListComp", "SetComp", "DictComp", "GeneratorExp":
+		return formatComprehension(node)
+
+	case "FunctionCall":
+		return node.Value + "(" + formatList(node.Children) + ")"
+
+	case "MethodCall":
+		return formatOperand(node.Children[0], node) + "(" + formatList(node.Children[1:]) + ")"
+
+	case "Attribute":
+		return formatOperand(node.Children[0], node) + "." + node.Value
+
+	case "Subscript":
+		return formatOperand(node.Children[0], node) + "[" + formatSource(node.Children[1]) + "]"
+
+	case "Slice":
+		// Las partes omitidas se guardan como None
+		parts := make([]string, len(node.Children))
+		for i, part := range node.Children {
+			if part.Type != "None" {
+				parts[i] = formatSource(part)
+			}
+		}
+		if parts[2] == "" {
+			parts = parts[:2]
+		}
+		return strings.Join(parts, ":")
+
+	case "Keyword":
+		return node.Value + "=" + formatSource(node.Children[0])
+
+	case "Parameter":
+		return formatParameters([]*models.ASTNode{node})
+
+	case "Assignment":
+		if annotation := annotationOf(node); annotation != nil {
+			return node.Value + ": " + formatSource(annotation) + " = " + formatSource(node.Children[0])
+		}
+		if node.Value != "" {
+			return node.Value + " = " + formatSource(node.Children[0])
+		}
+		return formatSource(node.Children[0]) + " = " + formatSource(node.Children[1])
+
+	case "AugAssignment":
+		return formatSource(node.Children[0]) + " " + node.Value + " " + formatSource(node.Children[1])
+
+	case "ReturnStatement":
+		if len(node.Children) == 0 {
+			return "return"
+		}
+		return "return " + formatSource(node.Children[0])
+
+	case "RaiseStatement":
+		switch len(node.Children) {
+		case 0:
+			return "raise"
+		case 1:
+			return "raise " + formatSource(node.Children[0])
+		}
+		return "raise " + formatSource(node.Children[0]) + " from " + formatSource(node.Children[1])
+
+	case "PassStatement":
+		return "pass"
+	case "BreakStatement":
+		return "break"
+	case "ContinueStatement":
+		return "continue"
+
+	case "GlobalStatement", "NonlocalStatement":
+		keyword := "global"
+		if node.Type == "NonlocalStatement" {
+			keyword = "nonlocal"
+		}
+		return keyword + " " + formatList(node.Children)
+
+	case "Import":
+		return "import " + formatAliases(node.Children)
+
+	case "ImportFrom":
+		return "from " + node.Value + " import " + formatAliases(node.Children)
+
+	case "AnnotatedName":
+		return node.Value + ": " + formatSource(annotationOf(node))
+
+	case "FunctionDef":
+		params, _ := functionParts(node)
+		if returns := annotationOf(node); returns != nil {
+			return "def " + node.Value + "(" + formatParameters(params) + ") -> " + formatSource(returns)
+		}
+		return "def " + node.Value + "(" + formatParameters(params) + ")"
+
+	case "ClassDef":
+		bases := make([]*models.ASTNode, 0)
+		for _, child := range node.Children {
+			if child.Type == "Base" {
+				bases = append(bases, child.Children[0])
+			}
+		}
+		if len(bases) == 0 {
+			return "class " + node.Value
+		}
+		return "class " + node.Value + "(" + formatList(bases) + ")"
+
+	case "ForStatement":
+		return "for " + formatTarget(node.Children[0]) + " in " + formatSource(node.Children[1])
+
+	case "ExceptHandler":
+		if len(node.Children) == 1 {
+			return "except"
+		}
+		text := "except " + formatSource(node.Children[0])
+		if node.Value != "" {
+			text += " as " + node.Value
+		}
+		return text
+	}
+
+	return node.Type
+}
+
+// formatOperand agrega paréntesis cuando el operando liga menos que el operador padre
+func formatOperand(operand, parent *models.ASTNode) string {
+	text := formatSource(operand)
+	if operatorPrecedence(operand) < operatorPrecedence(parent) ||
+		(parent.Type == "BinaryOp" && parent.Value == "**" && operand == parent.Children[0] && operand.Type == "BinaryOp" && operand.Value == "**") {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// operatorPrecedence sigue el orden de la gramática de Python (mayor liga más fuerte)
+func operatorPrecedence(node *models.ASTNode) int {
+	switch node.Type {
+	case "Lambda":
+		return 0
+	case "IfExpression":
+		return 1
+	case "UnaryOp":
+		switch node.Value {
+		case "not":
+			return 4
+		default:
+			return 12
+		}
+	case "BinaryOp":
+		switch node.Value {
+		case "or":
+			return 2
+		case "and":
+			return 3
+		case "<", ">", "<=", ">=", "==", "!=", "in", "not in", "is", "is not":
+			return 5
+		case "|":
+			return 6
+		case "^":
+			return 7
+		case "&":
+			return 8
+		case "<<", ">>":
+			return 9
+		case "+", "-":
+			return 10
+		case "*", "/", "//", "%":
+			return 11
+		case "**":
+			return 13
+		}
+	}
+	return 14
+}
+
+func formatList(nodes []*models.ASTNode) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = formatSource(node)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatTarget muestra las tuplas de destino sin paréntesis ("i, x")
+func formatTarget(target *models.ASTNode) string {
+	if target.Type == "Tuple" && len(target.Children) > 1 {
+		return formatList(target.Children)
+	}
+	return formatSource(target)
+}
+
+func formatParameters(params []*models.ASTNode) string {
+	parts := make([]string, len(params))
+	for i, param := range params {
+		parts[i] = param.Value
+		if annotation := annotationOf(param); annotation != nil {
+			parts[i] += ": " + formatSource(annotation)
+		}
+		if value := parameterDefault(param); value != nil {
+			if annotationOf(param) != nil {
+				parts[i] += " = " + formatSource(value)
+			} else {
+				parts[i] += "=" + formatSource(value)
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatAliases(aliases []*models.ASTNode) string {
+	parts := make([]string, len(aliases))
+	for i, alias := range aliases {
+		parts[i] = alias.Value
+		if len(alias.Children) > 0 {
+			parts[i] += " as " + alias.Children[0].Value
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatComprehension(node *models.ASTNode) string {
+	elements := make([]*models.ASTNode, 0)
+	clauses := make([]string, 0)
+	for _, child := range node.Children {
+		if child.Type != "Comprehension" {
+			elements = append(elements, child)
+			continue
+		}
+		clause := "for " + formatTarget(child.Children[0]) + " in " + formatSource(child.Children[1])
+		for _, condition := range child.Children[2:] {
+			clause += " if " + formatSource(condition)
+		}
+		clauses = append(clauses, clause)
+	}
+
+	head := formatList(elements)
+	if node.Type == "DictComp" && len(elements) == 2 {
+		head = formatSource(elements[0]) + ": " + formatSource(elements[1])
+	}
+	body := head + " " + strings.Join(clauses, " ")
+
+	switch node.Type {
+	case "ListComp":
+		return "[" + body + "]"
+	case "SetComp", "DictComp":
+		return "{" + body + "}"
+	}
+	return "(" + body + ")"
+}
+
+// formatBlock reconstruye una lista de sentencias con sus bloques anidados,
+// una línea por sentencia y cuatro espacios de sangría por nivel a partir de
+// indent. Los comentarios y el formato original no se conservan.
+func formatBlock(stmts []*models.ASTNode, indent string) []string {
+	lines := make([]string, 0, len(stmts))
+	if len(stmts) == 0 {
+		return append(lines, indent+"pass")
+	}
+	inner := indent + "    "
+
+	for _, stmt := range stmts {
+		switch stmt.Type {
+		case "IfStatement":
+			keyword := "if "
+			for stmt != nil {
+				lines = append(lines, indent+keyword+formatSource(stmt.Children[0])+":")
+				lines = append(lines, formatBlock(stmt.Children[1].Children, inner)...)
+				if len(stmt.Children) < 3 {
+					break
+				}
+				otherwise := stmt.Children[2]
+				// Un elif es un else cuyo único hijo es un IfStatement con su mismo token
+				if len(otherwise.Children) == 1 && otherwise.Children[0].Type == "IfStatement" &&
+					otherwise.Children[0].Line == otherwise.Line && otherwise.Children[0].Col == otherwise.Col {
+					stmt, keyword = otherwise.Children[0], "elif "
+					continue
+				}
+				lines = append(lines, indent+"else:")
+				lines = append(lines, formatBlock(otherwise.Children, inner)...)
+				break
+			}
+
+		case "WhileStatement", "ForStatement":
+			header, body := "while "+formatSource(stmt.Children[0]), 1
+			if stmt.Type == "ForStatement" {
+				header, body = formatSource(stmt), 2
+			}
+			lines = append(lines, indent+header+":")
+			lines = append(lines, formatBlock(stmt.Children[body].Children, inner)...)
+			if len(stmt.Children) > body+1 {
+				lines = append(lines, indent+"else:")
+				lines = append(lines, formatBlock(stmt.Children[body+1].Children, inner)...)
+			}
+
+		case "FunctionDef":
+			_, body := functionParts(stmt)
+			lines = append(lines, indent+formatSource(stmt)+":")
+			lines = append(lines, formatBlock(body, inner)...)
+
+		case "ClassDef":
+			body := make([]*models.ASTNode, 0, len(stmt.Children))
+			for _, child := range stmt.Children {
+				if child.Type != "Base" {
+					body = append(body, child)
+				}
+			}
+			lines = append(lines, indent+formatSource(stmt)+":")
+			lines = append(lines, formatBlock(body, inner)...)
+
+		case "TryStatement":
+			lines = append(lines, indent+"try:")
+			lines = append(lines, formatBlock(stmt.Children[0].Children, inner)...)
+			for _, clause := range stmt.Children[1:] {
+				if clause.Type == "ExceptHandler" {
+					lines = append(lines, indent+formatSource(clause)+":")
+					clause = clause.Children[len(clause.Children)-1]
+				} else {
+					lines = append(lines, indent+clause.Value+":")
+				}
+				lines = append(lines, formatBlock(clause.Children, inner)...)
+			}
+
+		case "Assignment":
+			// Asignación paralela "a, b = x, y" sin los paréntesis de las tuplas
+			if stmt.Value == "" && stmt.Children[0].Type == "Tuple" && stmt.Children[1].Type == "Tuple" && len(stmt.Children[1].Children) > 1 {
+				lines = append(lines, indent+formatTarget(stmt.Children[0])+" = "+formatList(stmt.Children[1].Children))
+				continue
+			}
+			lines = append(lines, indent+formatSource(stmt))
+
+		default:
+			lines = append(lines, indent+formatSource(stmt))
+		}
+	}
+	return lines
+}
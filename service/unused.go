@@ -0,0 +1,194 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"examen-back/models"
+)
+
+// noqaPattern reconoce "# noqa" y "# noqa: unused-variable, unused-import"
+var noqaPattern = regexp.MustCompile(`(?i)#\s*noqa\b(?::\s*([a-z0-9_,\s-]+))?`)
+
+// flake8Codes traduce los códigos de pyflakes que los estudiantes copian de
+// otros linters a los códigos de diagnóstico propios
+var flake8Codes = map[string]string{
+	"F401": "unused-import",
+	"F841": "unused-variable",
+}
+
+// loopCounters son los nombres habituales de un contador de for que solo
+// cuenta vueltas ("for i in range(n):") y no se reportan sin usar
+var loopCounters = map[string]bool{"i": true, "j": true, "k": true}
+
+// suppressions guarda, por línea, los códigos silenciados con "# noqa". Un
+// conjunto vacío silencia todos los diagnósticos de la línea.
+type suppressions map[int]map[string]bool
+
+func parseSuppressions(tokens []models.Token) suppressions {
+	result := make(suppressions)
+	for _, token := range tokens {
+		if token.Type != "COMMENT" {
+			continue
+		}
+		match := noqaPattern.FindStringSubmatch(token.Value)
+		if match == nil {
+			continue
+		}
+
+		codes := make(map[string]bool)
+		for _, code := range strings.Split(match[1], ",") {
+			code = strings.TrimSpace(code)
+			if alias, ok := flake8Codes[strings.ToUpper(code)]; ok {
+				code = alias
+			}
+			if code != "" {
+				codes[code] = true
+			}
+		}
+		result[token.Line] = codes
+	}
+	return result
+}
+
+func (s suppressions) suppressed(line int, code string) bool {
+	codes, found := s[line]
+	return found && (len(codes) == 0 || codes[code])
+}
+
+// checkUnusedNames reporta variables locales asignadas y nunca leídas,
+// parámetros sin usar, importaciones sin referencias y funciones que nunca se
+// llaman. Los nombres que empiezan con '_', los contadores de for y las
+// líneas con "# noqa" se omiten.
+func checkUnusedNames(ctx *RuleContext) []models.Diagnostic {
+	diagnostics := make([]models.Diagnostic, 0)
+	noqa := parseSuppressions(ctx.Tokens)
+	counters := forTargets(ctx.AST)
+
+	for _, sc := range ctx.scopes.scopes {
+		for _, name := range sc.order {
+			symbol := sc.symbols[name]
+			if !symbol.definesHere() || strings.HasPrefix(name, "_") {
+				continue
+			}
+
+			code, message := unusedSymbol(ctx, sc, symbol)
+			if code == "" || (code == "unused-variable" && isLoopCounter(symbol, counters)) {
+				continue
+			}
+
+			suppressed := false
+			for _, binding := range symbol.bindings {
+				if noqa.suppressed(binding.line, code) {
+					suppressed = true
+				}
+			}
+			if !suppressed {
+				diagnostics = append(diagnostics, models.Diagnostic{
+					Code:     code,
+					Severity: "warning",
+					Message:  message,
+					Line:     symbol.line,
+				})
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// unusedSymbol decide si un símbolo está sin usar y con qué código reportarlo
+func unusedSymbol(ctx *RuleContext, sc *scope, symbol *scopeSymbol) (string, string) {
+	if symbol.module != "" {
+		// "from m import *" enlaza todo el módulo: no se exige usar cada nombre
+		if len(symbol.uses) > 0 || symbol.bindings[0].node.Value == "*" {
+			return "", ""
+		}
+		if symbol.kind == "module" {
+			return "unused-import", fmt.Sprintf("El módulo '%s' se importa en línea %d pero nunca se usa", symbol.module, symbol.line)
+		}
+		return "unused-import", fmt.Sprintf("'%s' se importa de '%s' en línea %d pero nunca se usa", symbol.name, strings.TrimSuffix(symbol.module, "."+symbol.name), symbol.line)
+	}
+
+	switch symbol.kind {
+	case "variable":
+		// Las variables de módulo pueden usarse desde otros módulos o desde el intérprete
+		if sc.kind == "module" || sc.kind == "class" || len(symbol.uses) > 0 || symbol.assignedElsewhere {
+			return "", ""
+		}
+		return "unused-variable", fmt.Sprintf("La variable '%s' se asigna en línea %d pero nunca se lee; elimínela o renómbrela como '_%s'", symbol.name, symbol.line, symbol.name)
+
+	case "parameter":
+		if len(symbol.uses) > 0 || isReceiverParameter(sc, symbol) {
+			return "", ""
+		}
+		return "unused-parameter", fmt.Sprintf("El parámetro '%s' de '%s' (línea %d) no se utiliza", symbol.name, strings.TrimPrefix(sc.name, ctx.scopes.module.name+"."), symbol.line)
+
+	case "function":
+		if sc.kind == "class" || symbol.node == nil || referencedOutside(ctx.scopes, symbol) {
+			return "", ""
+		}
+		return "unused-function", fmt.Sprintf("La función '%s' se define en línea %d pero nunca se llama", symbol.name, symbol.line)
+	}
+	return "", ""
+}
+
+// forTargets marca los identificadores que son el objetivo de un for
+func forTargets(ast *models.ASTNode) map[*models.ASTNode]bool {
+	targets := make(map[*models.ASTNode]bool)
+	walkNodes(ast, func(node *models.ASTNode) {
+		if node.Type == "ForStatement" {
+			walkNodes(node.Children[0], func(target *models.ASTNode) {
+				if target.Type == "Identifier" {
+					targets[target] = true
+				}
+			})
+		}
+	})
+	return targets
+}
+
+// isLoopCounter indica una variable con nombre de contador que solo se
+// asigna como objetivo de un for
+func isLoopCounter(symbol *scopeSymbol, targets map[*models.ASTNode]bool) bool {
+	if !loopCounters[symbol.name] {
+		return false
+	}
+	for _, binding := range symbol.bindings {
+		if !targets[binding.node] {
+			return false
+		}
+	}
+	return true
+}
+
+// isReceiverParameter indica el primer parámetro de un método (self, cls)
+func isReceiverParameter(sc *scope, symbol *scopeSymbol) bool {
+	if sc.parent == nil || sc.parent.kind != "class" || sc.node == nil {
+		return false
+	}
+	params, _ := functionParts(sc.node)
+	return len(params) > 0 && params[0] == symbol.bindings[0].node
+}
+
+// referencedOutside indica si una función se usa fuera de su propio cuerpo:
+// una función que solo se llama a sí misma nunca se ejecuta
+func referencedOutside(tree *scopeTree, symbol *scopeSymbol) bool {
+	inside := make(map[*models.ASTNode]bool)
+	var mark func(node *models.ASTNode)
+	mark = func(node *models.ASTNode) {
+		inside[node] = true
+		for _, child := range node.Children {
+			mark(child)
+		}
+	}
+	mark(symbol.node)
+
+	for node, target := range tree.resolved {
+		if target == symbol && !inside[node] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package service
+
+import "testing"
+
+func TestUnusedNames(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+		want map[string][]int
+	}{
+		{"variable sin leer", "def f(n):\n    x = n * 2\n    return n\n\nprint(f(1))\n", map[string][]int{"unused-variable": {2}}},
+		{"parámetro sin usar", "def f(n, m):\n    return n\n\nprint(f(1, 2))\n", map[string][]int{"unused-parameter": {1}}},
+		{"importación sin usar", "import math\nprint(1)\n", map[string][]int{"unused-import": {1}}},
+		{"función que solo se llama a sí misma", "def f(n):\n    return f(n - 1)\n\nprint(1)\n", map[string][]int{"unused-function": {1}}},
+		{"contador de for", "def f(n):\n    total = 0\n    for i in range(n):\n        total += 2\n    return total\n\nprint(f(3))\n", map[string][]int{"unused-variable": nil}},
+		{"contadores anidados", "def f(n):\n    total = 0\n    for i in range(n):\n        for j in range(n):\n            total += 1\n    return total\n\nprint(f(3))\n", map[string][]int{"unused-variable": nil}},
+		{"guion bajo", "def f(n):\n    for _ in range(n):\n        print(n)\n    _tmp = 1\n\nf(2)\n", map[string][]int{"unused-variable": nil}},
+		{"objetivo de for con otro nombre", "def f(items):\n    count = 0\n    for item in items:\n        count += 1\n    return count\n\nprint(f([1]))\n", map[string][]int{"unused-variable": {3}}},
+		{"contador asignado fuera del for", "def f(n):\n    i = n\n    for i in range(n):\n        print(n)\n\nf(2)\n", map[string][]int{"unused-variable": {2}}},
+		{"noqa", "def f(n):\n    x = n  # noqa\n    return n\n\nprint(f(1))\n", map[string][]int{"unused-variable": nil}},
+		{"noqa con el código propio", "import math  # noqa: unused-import\nprint(1)\n", map[string][]int{"unused-import": nil}},
+		{"noqa con F401", "import math  # noqa: F401\nprint(1)\n", map[string][]int{"unused-import": nil}},
+		{"noqa con F841", "def f(n):\n    x = n  # noqa: f841\n    return n\n\nprint(f(1))\n", map[string][]int{"unused-variable": nil}},
+		{"noqa con otro código", "import math  # noqa: F841\nprint(1)\n", map[string][]int{"unused-import": {1}}},
+		{"variables usadas", "import math\n\ndef f(n):\n    x = math.sqrt(n)\n    return x\n\nprint(f(4))\n", map[string][]int{"unused-variable": nil, "unused-import": nil, "unused-parameter": nil, "unused-function": nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectDiagnostics(t, tc.code, tc.want)
+		})
+	}
+}
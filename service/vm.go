@@ -0,0 +1,317 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"examen-back/models"
+)
+
+// Motores de ejecución que aceptan /run y /grade
+const (
+	EngineTree = "tree"
+	EngineVM   = "vm"
+)
+
+// ValidateEngine comprueba el motor pedido; vacío equivale al intérprete de árbol
+func ValidateEngine(engine string) error {
+	switch engine {
+	case "", EngineTree, EngineVM:
+		return nil
+	}
+	return fmt.Errorf("motor desconocido '%s' (se admite '%s' o '%s')", engine, EngineTree, EngineVM)
+}
+
+// UseBytecode hace que el intérprete ejecute el módulo y las funciones con el
+// código compilado de program, que debe provenir del mismo AST. Los límites,
+// el trazador de llamadas y el depurador funcionan igual que con el
+// intérprete de árbol, porque las llamadas siguen pasando por callFunction.
+func (in *Interpreter) UseBytecode(program *BytecodeProgram) {
+	in.program = program
+}
+
+// compiled devuelve el código de una función o lambda, o nil si se interpreta
+func (in *Interpreter) compiled(node *models.ASTNode) *codeObject {
+	if in.program == nil {
+		return nil
+	}
+	return in.program.codes[node]
+}
+
+// execute ejecuta un código compilado en el frame fr con una pila de
+// operandos propia y devuelve el valor de RETURN_VALUE
+func (in *Interpreter) execute(code *codeObject, fr *frame) (pyValue, error) {
+	stack := make([]pyValue, 0, 8)
+	push := func(value pyValue) {
+		stack = append(stack, value)
+	}
+	pop := func() pyValue {
+		value := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return value
+	}
+	popN := func(n int) []pyValue {
+		items := make([]pyValue, n)
+		copy(items, stack[len(stack)-n:])
+		stack = stack[:len(stack)-n]
+		return items
+	}
+	var kwnames []string
+
+	for pc := 0; pc < len(code.code); {
+		instr := &code.code[pc]
+		pc++
+		if instr.flags != 0 {
+			fr.line = instr.line
+			if instr.flags&flagTick != 0 {
+				if err := in.tick(); err != nil {
+					return nil, err
+				}
+			}
+			if instr.flags&flagStep != 0 {
+				if err := in.step(instr.node, fr); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		var value pyValue
+		var err error
+		switch instr.op {
+		case opNop:
+			continue
+
+		case opPopTop:
+			pop()
+			continue
+
+		case opCopy:
+			push(stack[len(stack)-instr.arg])
+			continue
+
+		case opSwap:
+			top, other := len(stack)-1, len(stack)-instr.arg
+			stack[top], stack[other] = stack[other], stack[top]
+			continue
+
+		case opLoadConst:
+			value = code.consts[instr.arg]
+
+		case opLoadFast:
+			name := code.names[instr.arg]
+			var ok bool
+			if value, ok = fr.locals[name]; !ok {
+				err = in.raise("UnboundLocalError", "cannot access local variable '%s' where it is not associated with a value", name)
+			}
+
+		case opLoadGlobal:
+			name := code.names[instr.arg]
+			var ok bool
+			if value, ok = in.globals[name]; !ok {
+				if value, ok = in.builtins[name]; !ok {
+					err = in.raise("NameError", "name '%s' is not defined", name)
+				}
+			}
+
+		case opLoadName, opLoadDeref:
+			value, err = in.lookup(fr, code.names[instr.arg])
+
+		case opStoreFast:
+			fr.locals[code.names[instr.arg]] = pop()
+			continue
+
+		case opStoreGlobal, opStoreName, opStoreDeref:
+			in.store(fr, code.names[instr.arg], pop())
+			continue
+
+		case opLoadAttr:
+			value, err = in.getAttr(pop(), code.names[instr.arg])
+
+		case opStoreAttr:
+			object := pop()
+			if err := in.setAttr(object, code.names[instr.arg], pop()); err != nil {
+				return nil, err
+			}
+			continue
+
+		case opBinarySubscr:
+			index := pop()
+			value, err = in.getItem(pop(), index)
+
+		case opStoreSubscr:
+			operands := popN(3)
+			if err := in.setItem(operands[1], operands[2], operands[0]); err != nil {
+				return nil, err
+			}
+			continue
+
+		case opBuildSlice:
+			parts := popN(3)
+			value = &pySlice{start: parts[0], stop: parts[1], step: parts[2]}
+
+		case opBinaryOp, opCompareOp:
+			right := pop()
+			value, err = in.binaryOp(binaryOperators[instr.arg], pop(), right)
+
+		case opUnaryNegative, opUnaryPositive, opUnaryInvert, opUnaryNot:
+			value, err = in.unary(unarySymbols[instr.op], pop())
+
+		case opBuildList, opBuildTuple, opBuildSet:
+			items := popN(instr.arg)
+			if err := in.allocate(objectBytes + slotBytes*int64(len(items))); err != nil {
+				return nil, err
+			}
+			switch instr.op {
+			case opBuildList:
+				value = newList(items)
+			case opBuildTuple:
+				value = newTuple(items)
+			default:
+				value, err = in.makeSet(items)
+			}
+
+		case opBuildMap:
+			items := popN(2 * instr.arg)
+			dict := newDict()
+			for i := 0; i < len(items); i += 2 {
+				if err := in.dictSet(dict, items[i], items[i+1]); err != nil {
+					return nil, err
+				}
+			}
+			value, err = dict, in.allocate(objectBytes)
+
+		case opUnpackSequence:
+			items, err := in.collect(pop())
+			if err != nil {
+				return nil, err
+			}
+			switch expected := instr.arg; {
+			case len(items) > expected:
+				return nil, in.raise("ValueError", "too many values to unpack (expected %d)", expected)
+			case len(items) < expected:
+				return nil, in.raise("ValueError", "not enough values to unpack (expected %d, got %d)", expected, len(items))
+			}
+			for i := len(items) - 1; i >= 0; i-- {
+				push(items[i])
+			}
+			continue
+
+		case opKwNames:
+			kwnames = code.kwnames[instr.arg]
+			continue
+
+		case opCall:
+			args := popN(instr.arg)
+			callee := pop()
+			var kwargs []keywordArg
+			if len(kwnames) > 0 {
+				positional := len(args) - len(kwnames)
+				for i, name := range kwnames {
+					kwargs = append(kwargs, keywordArg{name: name, value: args[positional+i]})
+				}
+				args, kwnames = args[:positional], nil
+			}
+			value, err = in.call(callee, args, kwargs)
+
+		case opMakeFunction:
+			value, err = in.defineFunction(code.nodes[instr.arg], fr)
+
+		case opJumpForward, opJumpBackward:
+			pc = instr.arg
+			continue
+
+		case opPopJumpIfFalse, opPopJumpIfTrue:
+			truth, err := in.truthy(pop())
+			if err != nil {
+				return nil, err
+			}
+			if truth == (instr.op == opPopJumpIfTrue) {
+				pc = instr.arg
+			}
+			continue
+
+		case opJumpIfFalseOrPop, opJumpIfTrueOrPop:
+			truth, err := in.truthy(stack[len(stack)-1])
+			if err != nil {
+				return nil, err
+			}
+			if truth == (instr.op == opJumpIfTrueOrPop) {
+				pc = instr.arg
+			} else {
+				pop()
+			}
+			continue
+
+		case opGetIter:
+			next, err := in.iterator(pop())
+			if err != nil {
+				return nil, err
+			}
+			value = &pyIterator{name: "iterator", next: next}
+
+		case opForIter:
+			item, ok, err := stack[len(stack)-1].(*pyIterator).next()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				pop()
+				pc = instr.arg
+				continue
+			}
+			value = item
+
+		case opReturnValue:
+			return pop(), nil
+
+		case opExecNode:
+			flow, result, err := in.exec(code.nodes[instr.arg], fr)
+			if err != nil {
+				return nil, err
+			}
+			switch flow {
+			case flowReturn:
+				return result, nil
+			case flowBreak, flowContinue:
+				if targets, ok := code.loops[pc-1]; ok {
+					pc = targets.continueTo
+					if flow == flowBreak {
+						stack = stack[:targets.height]
+						pc = targets.breakTo
+					}
+				}
+			}
+			continue
+
+		case opEvalNode:
+			value, err = in.eval(code.nodes[instr.arg], fr)
+
+		default:
+			return nil, fmt.Errorf("instrucción desconocida %s", instr.op)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		push(value)
+	}
+	return noneValue(), nil
+}
+
+// binaryOp aplica un operador de BINARY_OP o COMPARE_OP. En las versiones
+// aumentadas, += sobre una lista la extiende en el lugar como augAssign.
+func (in *Interpreter) binaryOp(op string, left, right pyValue) (pyValue, error) {
+	if isComparison(op) || !strings.HasSuffix(op, "=") {
+		return in.binary(op, left, right)
+	}
+	op = strings.TrimSuffix(op, "=")
+	if list, ok := left.(*pyList); ok && op == "+" {
+		items, err := in.collect(right)
+		if err != nil {
+			return nil, err
+		}
+		list.items = append(list.items, items...)
+		return list, nil
+	}
+	return in.binary(op, left, right)
+}